@@ -0,0 +1,1138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/exp/golden"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/evanschultz/float-rw-client/pkg/api/apitest"
+	"github.com/evanschultz/float-rw-client/pkg/cache"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/crash"
+	"github.com/evanschultz/float-rw-client/pkg/logging"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/recall"
+	"github.com/evanschultz/float-rw-client/pkg/vault"
+	"github.com/muesli/termenv"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	// Golden frames are compared without color
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
+
+// debugTimestampRegex matches the debug panel's message times, masked in
+// golden frames
+var debugTimestampRegex = regexp.MustCompile(`\[\d{2}:\d{2}:\d{2}\]`)
+
+// typeKeys turns s into one key message per rune
+func typeKeys(s string) []tea.Msg {
+	var msgs []tea.Msg
+	for _, r := range s {
+		msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return msgs
+}
+
+// newTestApp opens path without evna dispatch, whose results would land in
+// the debug panel at no fixed point in a run
+func newTestApp(path string) *OutlinerApp {
+	app := NewOutlinerApp("")
+	app.outliner.Evna().SetEnabled(false)
+	app.filename = path
+	app.loadFile()
+	return app
+}
+
+// runApp plays msgs against app in a real program and returns the final
+// model once everything before the quit has been handled
+func runApp(t *testing.T, app *OutlinerApp, msgs ...tea.Msg) *OutlinerApp {
+	t.Helper()
+	tm := teatest.NewTestModel(t, app, teatest.WithInitialTermSize(60, 20))
+	for _, msg := range msgs {
+		tm.Send(msg)
+	}
+	if err := tm.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	return tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second)).(*OutlinerApp)
+}
+
+// requireFrame compares the app's current frame with testdata/<test>.golden,
+// masking times and the temp dir; run with -update to rewrite it
+func requireFrame(t *testing.T, app *OutlinerApp) {
+	t.Helper()
+	frame := debugTimestampRegex.ReplaceAllString(app.View(), "[hh:mm:ss]")
+	if app.filename != "" {
+		frame = strings.ReplaceAll(frame, filepath.Dir(app.filename)+string(filepath.Separator), "")
+	}
+	golden.RequireEqual(t, []byte(frame))
+}
+
+func TestAppIndentOutdent(t *testing.T) {
+	var msgs []tea.Msg
+	msgs = append(msgs, typeKeys("alpha")...)
+	msgs = append(msgs, tea.KeyMsg{Type: tea.KeyEnter})
+	msgs = append(msgs, typeKeys("beta")...)
+	msgs = append(msgs, tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyEnter})
+	msgs = append(msgs, typeKeys("gamma")...)
+	msgs = append(msgs, tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyShiftTab})
+
+	app := runApp(t, newTestApp(""), msgs...)
+
+	want := "• alpha\n  • beta\n    • gamma\n"
+	if got := app.outliner.GetContent(); got != want {
+		t.Fatalf("content = %q, want %q", got, want)
+	}
+	requireFrame(t, app)
+}
+
+func TestAppReducerChildInsertion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(path, []byte("• reducer:: auth_notes collect all decisions about auth\n• ctx:: reviewing\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := runApp(t, newTestApp(path), outliner.ReducerUpdateMsg{
+		ReducerName: "auth_notes",
+		Action:      outliner.DispatchAction{PatternType: "decision", Content: "use oauth for auth"},
+	})
+
+	want := "• reducer:: auth_notes collect all decisions about auth\n  • decision: use oauth for auth\n• ctx:: reviewing\n"
+	if got := app.outliner.GetContent(); got != want {
+		t.Fatalf("content = %q, want %q", got, want)
+	}
+	requireFrame(t, app)
+}
+
+func TestAppDebugPanelFocus(t *testing.T) {
+	var msgs []tea.Msg
+	msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l"), Alt: true})
+	msgs = append(msgs, typeKeys("jk")...) // the panel's list takes these
+	app := runApp(t, newTestApp(""), msgs...)
+
+	if got := app.outliner.GetContent(); got != "• \n" {
+		t.Fatalf("focused debug panel let keys through: %q", got)
+	}
+	requireFrame(t, app)
+
+	// Esc hands keys back to the outline
+	msgs = append(msgs, tea.KeyMsg{Type: tea.KeyEsc})
+	msgs = append(msgs, typeKeys("x")...)
+	app = runApp(t, newTestApp(""), msgs...)
+	if got := app.outliner.GetContent(); got != "• x\n" {
+		t.Fatalf("content after esc = %q", got)
+	}
+}
+
+func TestAppEditSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "today.md")
+
+	var msgs []tea.Msg
+	msgs = append(msgs, typeKeys("ctx:: saving from a test")...)
+	msgs = append(msgs, tea.KeyMsg{Type: tea.KeyCtrlS})
+	msgs = append(msgs, typeKeys(" later")...) // unsaved
+	app := runApp(t, newTestApp(path), msgs...)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(content); got != "• ctx:: saving from a test\n" {
+		t.Errorf("saved %q", got)
+	}
+	if app.saved {
+		t.Error("edit after save not marked unsaved")
+	}
+	if !strings.Contains(app.renderStatusBar(), "[modified]") {
+		t.Error("status bar doesn't show [modified]")
+	}
+}
+
+func TestAppLogsInDebugPanel(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "float-outliner.log")
+	feed := logging.NewFeed()
+	defer slog.SetDefault(slog.Default())
+	closer, err := logging.Setup("float-outliner", logPath, "debug", feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+
+	app := newTestApp(filepath.Join(dir, "today.md"))
+	app.logs = feed
+	var msgs []tea.Msg
+	msgs = append(msgs, typeKeys("ctx:: logged")...)
+	msgs = append(msgs, tea.KeyMsg{Type: tea.KeyCtrlS})
+	app = runApp(t, app, msgs...)
+
+	shown := false
+	for _, msg := range app.outliner.DebugMessages() {
+		shown = shown || msg.Type == "LOG" && strings.HasPrefix(msg.Content, "saved file=")
+	}
+	if !shown {
+		t.Errorf("save wasn't shown in the debug panel: %+v", app.outliner.DebugMessages())
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "msg=saved") || !strings.Contains(string(data), "today.md") {
+		t.Errorf("log file = %q", data)
+	}
+}
+
+func TestAppRecoverSession(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(path, []byte("• ctx:: saved\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := runApp(t, newTestApp(path), typeKeys(" eureka:: unsaved")...)
+	message := app.recoverSession(&crash.Panic{Value: "boom", Time: time.Now()})
+
+	recovered, err := os.ReadFile(filepath.Join(filepath.Dir(path), "notes.recovered.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(recovered), "eureka:: unsaved") {
+		t.Errorf("recovered %q", recovered)
+	}
+	if saved, _ := os.ReadFile(path); string(saved) != "• ctx:: saved\n" {
+		t.Errorf("original overwritten: %q", saved)
+	}
+
+	reports, _ := filepath.Glob(filepath.Join(crash.DefaultDir(), "float-outliner-*"))
+	if len(reports) != 1 {
+		t.Fatalf("crash reports = %v", reports)
+	}
+	for _, name := range []string{"panic.txt", "outline-1.md", "dispatch.jsonl", "debug.log"} {
+		if _, err := os.Stat(filepath.Join(reports[0], name)); err != nil {
+			t.Error(err)
+		}
+	}
+	if !strings.Contains(message, "notes.recovered.md") || !strings.Contains(message, reports[0]) {
+		t.Errorf("message = %q", message)
+	}
+}
+
+func TestAppJump(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	notes, other := filepath.Join(dir, "notes.md"), filepath.Join(dir, "other.md")
+	os.WriteFile(notes, []byte("• project\n  • decision:: use [[sqlite]]\n• other\n"), 0644)
+	os.WriteFile(other, []byte("• far away thought\n"), 0644)
+
+	app := newTestApp(notes)
+	app.openBuffer(other)
+	app.switchBuffer(0)
+
+	// Fold the project, then jump into it
+	msgs := []tea.Msg{tea.KeyMsg{Type: tea.KeyCtrlUp}, tea.KeyMsg{Type: tea.KeyCtrlJ}}
+	msgs = append(msgs, typeKeys("sqlite")...)
+	msgs = append(msgs, tea.KeyMsg{Type: tea.KeyEnter})
+	app = runApp(t, app, msgs...)
+	if app.jump != nil || app.outliner.Cursor() != 1 {
+		t.Fatalf("jump left navigator %v, cursor on %d", app.jump, app.outliner.Cursor())
+	}
+	if !strings.Contains(app.outliner.View(), "use [[sqlite]]") {
+		t.Errorf("jumped-to node is still folded:\n%s", app.outliner.View())
+	}
+
+	// Matches in other buffers switch to them
+	app.openJump()
+	for _, msg := range typeKeys("far away") {
+		app.updateJump(msg.(tea.KeyMsg))
+	}
+	if len(app.jump.results) == 0 || !strings.HasPrefix(app.jump.results[0].target.Context, "other.md: ") {
+		t.Fatalf("results = %+v", app.jump.results)
+	}
+	if frame := app.renderJump(); !strings.Contains(frame, "far away thought") {
+		t.Errorf("navigator shows:\n%s", frame)
+	}
+	app.updateJump(tea.KeyMsg{Type: tea.KeyEnter})
+	if app.filename != other || app.outliner.Cursor() != 0 {
+		t.Errorf("jump went to %s node %d", app.filename, app.outliner.Cursor())
+	}
+}
+
+func TestAppArchiveFile(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.md")
+	os.WriteFile(path, []byte("• decision:: done with this\n• ctx:: still going\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "archive.md"), []byte("• earlier [archived:: 2026-10-01 10:00]\n"), 0644)
+
+	app := newTestApp(path)
+	app.cfg = &config.Config{Outliner: config.OutlinerConfig{ArchiveFile: "archive.md"}}
+	app.archiveAtCursor()
+
+	archive, _ := os.ReadFile(filepath.Join(dir, "archive.md"))
+	if !strings.HasPrefix(string(archive), "• earlier") || !strings.Contains(string(archive), "\n• decision:: done with this [archived:: ") {
+		t.Errorf("archive file = %q", archive)
+	}
+	if got := app.outliner.GetContent(); got != "• ctx:: still going\n" || app.saved {
+		t.Errorf("outline after archiving = %q, saved %t", got, app.saved)
+	}
+}
+
+func TestAppNodeRefs(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("PATH", "") // no clipboard tool; the ref is still kept for pasting
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.md")
+	os.WriteFile(notes, []byte("• intro\n• decision:: ship it\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "other.md"), []byte("• start\n"), 0644)
+
+	app := newTestApp(notes)
+	app.outliner.SetCursor(1)
+	app.copyNodeRef()
+	path, id, ok := outliner.ParseNodeRef(app.nodeRef)
+	if !ok || !sameFile(path, notes) || !strings.HasSuffix(app.outliner.CurrentText(), "[id:: "+id+"]") {
+		t.Fatalf("copied ref %q, node %q", app.nodeRef, app.outliner.CurrentText())
+	}
+
+	app.openBuffer(filepath.Join(dir, "other.md"))
+	app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(app.nodeRef), Paste: true})
+	if got := app.outliner.GetContent(); got != "• start\n• "+app.nodeRef+"\n" {
+		t.Fatalf("after pasting the ref:\n%s", got)
+	}
+
+	app.followLink()
+	if !sameFile(app.filename, notes) || app.outliner.Cursor() != 1 {
+		t.Errorf("followed to %s node %d", app.filename, app.outliner.Cursor())
+	}
+}
+
+func TestAppFollowOrigin(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.md")
+	other := filepath.Join(dir, "other.md")
+	os.WriteFile(notes, []byte("• reducer:: shipped collect all decisions\n• decision:: ship it\n"), 0644)
+	os.WriteFile(other, []byte("• start\n• decision:: cut scope\n"), 0644)
+
+	app := newTestApp(notes)
+	var shipped outliner.DispatchAction
+	for _, action := range app.outliner.Dispatch().GetActions() {
+		if action.PatternType == "decision" {
+			shipped = action
+		}
+	}
+	app.Update(outliner.ReducerUpdateMsg{ReducerName: "shipped", Action: shipped})
+	// From the dispatch log, named by file and line
+	app.Update(outliner.ReducerUpdateMsg{ReducerName: "shipped", Action: outliner.DispatchAction{
+		NodeID: "other.md:2", Source: other, PatternType: "decision", Content: "cut scope",
+	}})
+
+	app.outliner.SetCursor(1)
+	app.followLink()
+	if got := app.outliner.CurrentText(); got != "decision:: ship it" {
+		t.Fatalf("followed the first child to %q", got)
+	}
+
+	app.outliner.SetCursor(2)
+	app.followLink()
+	if !sameFile(app.filename, other) || app.outliner.CurrentText() != "decision:: cut scope" {
+		t.Errorf("followed the logged child to %s %q", app.filename, app.outliner.CurrentText())
+	}
+}
+
+func TestAppLinkResolvesOnSave(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.md")
+	os.WriteFile(notes, []byte("• see [[fresh page]]\n• the cursor's row\n"), 0644)
+
+	lipgloss.SetColorProfile(termenv.ANSI)
+	defer lipgloss.SetColorProfile(termenv.Ascii)
+	unresolved := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Underline(true).Render("[[fresh page]]")
+
+	app := newTestApp(notes)
+	if err := app.openVault(dir, notes); err != nil {
+		t.Fatal(err)
+	}
+	app.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	app.outliner.SetCursor(1)
+	if !strings.Contains(app.outliner.View(), unresolved) {
+		t.Fatalf("a link to a missing page isn't dimmed:\n%q", app.outliner.View())
+	}
+
+	app.outliner.SetCursor(0)
+	app.followLink()
+	app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("new")})
+	app.saveFile()
+	app.Update(tea.KeyMsg{Type: tea.KeyCtrlCaret})
+	if !sameFile(app.filename, notes) {
+		t.Fatalf("switched back to %s", app.filename)
+	}
+	app.outliner.SetCursor(1)
+	if strings.Contains(app.outliner.View(), unresolved) {
+		t.Error("the link is still dimmed after its page was saved")
+	}
+}
+
+func TestAppRenameAcrossVault(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.md")
+	other := filepath.Join(dir, "other.md")
+	open := filepath.Join(dir, "open.md")
+	os.WriteFile(notes, []byte("• ship [[auth]]\n"), 0644)
+	os.WriteFile(other, []byte("• [[Auth|login]] notes\n• nothing here\n"), 0644)
+	os.WriteFile(open, []byte("• review [[auth]]\n"), 0644)
+
+	app := newTestApp(open)
+	if err := app.openVault(dir, open); err != nil {
+		t.Fatal(err)
+	}
+	app.openBuffer(notes)
+
+	if err := app.runPaletteCommand("rename auth -> [[identity]]"); err != nil {
+		t.Fatal(err)
+	}
+	if app.rename == nil || len(app.rename.files) != 2 {
+		t.Fatalf("preview = %+v", app.rename)
+	}
+	if app.outliner.GetContent() != "• ship [[auth]]\n" {
+		t.Fatal("renamed before confirming")
+	}
+
+	app.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if app.rename != nil {
+		t.Fatal("preview still open after confirming")
+	}
+	if got := app.outliner.GetContent(); got != "• ship [[identity]]\n• aka:: auth → [[identity]]\n" {
+		t.Errorf("active buffer = %q", got)
+	}
+	if data, _ := os.ReadFile(other); string(data) != "• [[identity|login]] notes\n• nothing here\n" {
+		t.Errorf("other.md = %q", data)
+	}
+	if refs := app.vault.Backlinks("identity"); len(refs) != 1 || refs[0].Path != "other.md" {
+		t.Errorf("vault backlinks = %+v", refs)
+	}
+	// The open buffer is edited, not written behind it
+	if data, _ := os.ReadFile(open); string(data) != "• review [[auth]]\n" {
+		t.Errorf("open.md written to %q", data)
+	}
+	if b := app.buffers[0]; b.saved || b.outliner.GetContent() != "• review [[identity]]\n" {
+		t.Errorf("open buffer = %q, saved %v", b.outliner.GetContent(), b.saved)
+	}
+}
+
+func TestPushResults(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("READWISE_TOKEN", "")
+	srv := apitest.NewServer()
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("• reducer:: shipped collect all decisions\n• decision:: ship it\n• decision:: cut scope\n• hello\n"), 0644)
+	app := newTestApp(path)
+	app.cfg = &config.Config{API: config.APIConfig{Token: apitest.Token, BaseURL: srv.URL}}
+
+	app.outliner.SetCursor(3)
+	if err := app.pushResults(); err == nil {
+		t.Error("pushed from a plain node")
+	}
+
+	app.outliner.SetCursor(0)
+	if err := app.pushResults(); err != nil {
+		t.Fatal(err)
+	}
+	books, err := srv.APIClient().GetBooks(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	book := books.Results[len(books.Results)-1]
+	if book.Title != dispatchBook || book.NumHighlights != 2 {
+		t.Fatalf("pushed into %+v", book)
+	}
+
+	// Pushing again updates the same highlights
+	if err := app.pushResults(); err != nil {
+		t.Fatal(err)
+	}
+	books, _ = srv.APIClient().GetBooks(context.Background(), nil)
+	if n := books.Results[len(books.Results)-1].NumHighlights; n != 2 {
+		t.Errorf("second push left %d highlights", n)
+	}
+}
+
+func TestSwitchProfile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("FLOAT_LINE_CONFIG", "")
+	t.Setenv(config.ProfileEnv, "")
+	t.Cleanup(func() { config.SetProfile("") })
+
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(home, "float-line", "config.toml"), "[evna]\nendpoint = \"http://personal.invalid\"\n[imprints.garden]\nfilters = [\"dream\"]\n")
+	write(filepath.Join(home, "float-line", "profiles", "work", "config.toml"), "[evna]\nendpoint = \"http://work.invalid\"\n[imprints.standup]\nfilters = [\"decision\"]\n")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.md")
+	write(path, "- decision:: ship it\n")
+	write(filepath.Join(dir, ".float-line", "dispatch-log.jsonl"), `{"seq":1,"action_id":"a1","type":"ctx","content":"personal","time":"2025-01-01T00:00:00Z"}`+"\n")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := newTestApp(path)
+	app.applyConfig(cfg)
+	if !slices.Contains(app.outliner.Dispatch().ImprintNames(), "garden") || len(app.outliner.Dispatch().GetHistory()) != 1 {
+		t.Fatal("default profile not applied")
+	}
+
+	if err := app.switchProfile("play"); err == nil {
+		t.Error("switched to a profile that doesn't exist")
+	}
+	if err := app.switchProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	imprints := app.outliner.Dispatch().ImprintNames()
+	if config.Profile() != "work" || app.cfg.Evna.Endpoint != "http://work.invalid" {
+		t.Errorf("on profile %s with endpoint %s", config.Profile(), app.cfg.Evna.Endpoint)
+	}
+	if !slices.Contains(imprints, "standup") || slices.Contains(imprints, "garden") {
+		t.Errorf("imprints after switching: %v", imprints)
+	}
+	// The default profile's dispatch log isn't the work profile's
+	if n := len(app.outliner.Dispatch().GetHistory()); n != 0 {
+		t.Errorf("work profile sees %d actions of the default's", n)
+	}
+	app.Update(tea.WindowSizeMsg{Width: 200, Height: 20})
+	if !strings.Contains(app.View(), "[profile: work]") {
+		t.Error("status bar doesn't name the profile")
+	}
+}
+
+func TestIdleBoundary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "today.md")
+	if err := os.WriteFile(path, []byte("• ctx:: 2025-08-05 9:00am [mode:: focus]\n• drafting\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	app := newTestApp(path)
+	app.idleBoundary = 10 * time.Minute
+	app.outliner.SetCursor(1)
+
+	start := time.Date(2025, 8, 5, 9, 0, 0, 0, time.Local)
+	app.noteKeypress(start)
+	app.noteKeypress(start.Add(9 * time.Minute))
+	app.noteKeypress(start.Add(25 * time.Minute)) // back from a break
+	if app.saved || strings.Count(app.outliner.GetContent(), "ctx::") != 2 {
+		t.Fatalf("no new block:\n%s", app.outliner.GetContent())
+	}
+	if !strings.Contains(app.outliner.GetContent(), "[mode:: focus] [duration:: 9m]") {
+		t.Errorf("closed block:\n%s", app.outliner.GetContent())
+	}
+
+	// Within the boundary again, nothing more is split
+	app.noteKeypress(start.Add(30 * time.Minute))
+	if n := strings.Count(app.outliner.GetContent(), "ctx::"); n != 2 {
+		t.Errorf("%d ctx:: blocks", n)
+	}
+}
+
+func TestLayoutPresets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	session, err := cache.OpenSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := newTestApp("")
+	app.setSession(session)
+	app.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+
+	if err := app.setLayout("monitor"); err != nil {
+		t.Fatal(err)
+	}
+	if !app.outliner.IsDebugVisible() || !app.outliner.IsDiagnosticsVisible() || app.outliner.DebugRatio() != 0.5 {
+		t.Errorf("monitor: debug %v, diagnostics %v, ratio %v", app.outliner.IsDebugVisible(), app.outliner.IsDiagnosticsVisible(), app.outliner.DebugRatio())
+	}
+	if err := app.setLayout("cinema"); err == nil || !strings.Contains(err.Error(), "writing") {
+		t.Errorf("unknown layout: err = %v", err)
+	}
+
+	// Resizing the debug panel makes the layout the custom one
+	app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l"), Alt: true})
+	app.Update(tea.KeyMsg{Type: tea.KeyCtrlUp})
+	reopened, err := cache.OpenSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.layout != 0 || reopened.OutlinerLayout != "" || reopened.DebugRatio == 0.5 || reopened.DebugRatio != app.outliner.DebugRatio() {
+		t.Errorf("after resizing: layout %d, session %+v", app.layout, reopened)
+	}
+
+	// The next run starts in the layout last picked
+	if err := app.setLayout("writing"); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err = cache.OpenSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := newTestApp("")
+	restored.setSession(reopened)
+	if restored.outliner.IsDebugVisible() || outlinerLayouts[restored.layout].name != "writing" {
+		t.Errorf("restored layout %d, debug shown %v", restored.layout, restored.outliner.IsDebugVisible())
+	}
+}
+
+func TestAppNarrowTerminals(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(path, []byte("• ctx:: 2025-08-05 9:00am [project:: float-line] [mode:: narrow terminals]\n  • decision:: collapse the panels into one under a switcher\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, width := range []int{40, 60, 80} {
+		app := newTestApp(path)
+		app.Update(tea.WindowSizeMsg{Width: width, Height: 20})
+		for _, key := range []tea.KeyMsg{{Type: tea.KeyCtrlG}, {Type: tea.KeyRunes, Runes: []rune("l"), Alt: true}, {Type: tea.KeyRunes, Runes: []rune("z"), Alt: true}} {
+			lines := strings.Split(app.View(), "\n")
+			if len(lines) > 20 {
+				t.Errorf("%d cols: %d rows:\n%s", width, len(lines), app.View())
+			}
+			for i, line := range lines {
+				if w := lipgloss.Width(line); w > width {
+					t.Errorf("%d cols: row %d is %d wide:\n%s", width, i, w, app.View())
+					break
+				}
+			}
+			app.Update(key)
+		}
+	}
+}
+
+func TestAppDoorHearsCaptures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doors.md")
+	if err := os.WriteFile(path, []byte("• eureka:: doors listen\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	app := newTestApp(path)
+	app.openDoor("chat")
+	door := app.door
+	heard := func() []string { return door.GetState()["messages"].([]string) }
+	before := len(heard())
+	app.outliner.TriggerConsciousnessCapture()
+	messages := heard()
+	if len(messages) != before+1 || !strings.Contains(messages[before], "eureka:: doors listen") {
+		t.Fatalf("chat door heard %q", messages)
+	}
+
+	app.closeDoor()
+	app.outliner.TriggerConsciousnessCapture()
+	if got := heard(); len(got) != before+1 {
+		t.Errorf("closed door still hears captures: %q", got)
+	}
+}
+
+func TestAppMergeRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(path, []byte("• kept on disk\n• plan the week\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	recovered := "• plan the week, then rest\n• unsaved idea\n  • its detail\n"
+	if err := os.WriteFile(recoveryPath(path), []byte(recovered), 0644); err != nil {
+		t.Fatal(err)
+	}
+	app := newTestApp(path)
+	if toasts := app.toasts.Active(); len(toasts) == 0 || !strings.Contains(toasts[len(toasts)-1].Text, "notes.recovered.md") {
+		t.Errorf("no toast about the recovery file: %+v", toasts)
+	}
+
+	if err := app.openMerge(""); err != nil {
+		t.Fatal(err)
+	}
+	if app.diff == nil || len(app.diff.diff.Changes) != 3 {
+		t.Fatalf("merge view = %+v", app.diff)
+	}
+	// Leave the edit, take the new subtree, keep what only the file has
+	for i, c := range app.diff.diff.Changes {
+		if c.Kind == outliner.ChangeEdited {
+			app.diff.selected = i
+			app.updateDiff(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+		}
+	}
+	app.updateDiff(tea.KeyMsg{Type: tea.KeyEnter})
+	want := "• kept on disk\n• plan the week\n• unsaved idea\n  • its detail\n"
+	if got := app.outliner.GetContent(); got != want {
+		t.Errorf("merged buffer = %q, want %q", got, want)
+	}
+	if app.diff != nil || app.saved {
+		t.Error("merging should close the view and leave the buffer unsaved")
+	}
+}
+
+func TestQuickCapture(t *testing.T) {
+	got := quickLines("    - read later\n      * eureka:: it nests\n\n    # Heading\n    plain\n")
+	want := []string{"• read later", "  • eureka:: it nests", "# Heading", "• plain"}
+	if !slices.Equal(got, want) {
+		t.Errorf("quickLines = %q, want %q", got, want)
+	}
+
+	path := filepath.Join(t.TempDir(), "inbox", "inbox.md")
+	if start, err := appendToInbox(path, []string{"• first"}); err != nil || start != 0 {
+		t.Fatalf("appending to a new inbox started at %d: %v", start, err)
+	}
+	os.WriteFile(path, []byte("• first\n• no newline"), 0644)
+	if start, err := appendToInbox(path, want[:2]); err != nil || start != 2 {
+		t.Fatalf("appending started at %d: %v", start, err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "• first\n• no newline\n• read later\n  • eureka:: it nests\n" {
+		t.Errorf("inbox = %q", data)
+	}
+}
+
+func TestQuickCaptureConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inbox.md")
+	os.WriteFile(path, []byte("• already here\n"), 0644)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := appendToInbox(path, []string{fmt.Sprintf("• capture %d", i), fmt.Sprintf("  • eureka:: detail %d", i)}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 41 || lines[0] != "• already here" {
+		t.Fatalf("inbox has %d lines:\n%s", len(lines), data)
+	}
+	for i := 0; i < 20; i++ {
+		at := slices.Index(lines, fmt.Sprintf("• capture %d", i))
+		if at < 0 || lines[at+1] != fmt.Sprintf("  • eureka:: detail %d", i) {
+			t.Errorf("capture %d lost or split up:\n%s", i, data)
+		}
+	}
+}
+
+func TestCapturePopup(t *testing.T) {
+	var m tea.Model = newCapturePopup("/tmp/inbox.md")
+	for _, r := range "eureka:: fast" {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if view := m.View(); !strings.Contains(view, "inbox.md") || !strings.Contains(view, "eureka::") {
+		t.Errorf("popup view = %q", view)
+	}
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if p := m.(capturePopup); !p.captured || p.text != "eureka:: fast" || cmd == nil || m.View() != "" {
+		t.Errorf("enter left captured=%v text=%q", p.captured, p.text)
+	}
+
+	m = newCapturePopup("/tmp/inbox.md")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("draft")})
+	if m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc}); m.(capturePopup).captured {
+		t.Error("esc captured the line")
+	}
+}
+
+func TestAppInboxRefile(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	inbox, projects := filepath.Join(dir, "inbox.md"), filepath.Join(dir, "projects.md")
+	os.WriteFile(inbox, []byte("• call the printer people\n  • ask about toner\n• the cache key was the bug\n"), 0644)
+	os.WriteFile(projects, []byte("• Office\n• Garden\n"), 0644)
+
+	app := newTestApp(projects)
+	cfg := config.Default()
+	cfg.Evna.Enabled = false
+	cfg.Outliner.Inbox = inbox
+	app.cfg = cfg
+	app.Update(tea.WindowSizeMsg{Width: 60, Height: 20})
+	// Alt+I stays the outliner's imprint view
+	app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}, Alt: true})
+	if app.inbox != nil || !app.outliner.IsImprintViewOpen() {
+		t.Fatal("alt+i didn't reach the imprint view")
+	}
+	app.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}, Alt: true})
+	if app.inbox == nil || len(app.inbox.items) != 2 || app.inbox.items[0].Nodes != 2 {
+		t.Fatalf("inbox view = %+v", app.inbox)
+	}
+
+	// Refile the first item under Office
+	app.updateInbox(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	for _, msg := range typeKeys("office") {
+		app.updateInbox(msg.(tea.KeyMsg))
+	}
+	if frame := app.renderInbox(); !strings.Contains(frame, "Refile to: office") || !strings.Contains(frame, "projects.md") {
+		t.Errorf("picker frame:\n%s", frame)
+	}
+	app.updateInbox(tea.KeyMsg{Type: tea.KeyEnter})
+	if data, _ := os.ReadFile(projects); string(data) != "• Office\n  • call the printer people\n    • ask about toner\n• Garden\n" {
+		t.Errorf("projects.md = %q", data)
+	}
+	if !sameFile(app.filename, inbox) || len(app.inbox.items) != 1 {
+		t.Fatalf("after refiling: buffer %s, items %+v", app.filename, app.inbox.items)
+	}
+
+	// Convert the last one, then archive it, which empties the inbox
+	app.updateInbox(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	for _, msg := range typeKeys("eureka") {
+		app.updateInbox(msg.(tea.KeyMsg))
+	}
+	app.updateInbox(tea.KeyMsg{Type: tea.KeyEnter})
+	if data, _ := os.ReadFile(inbox); string(data) != "• eureka:: the cache key was the bug\n" {
+		t.Errorf("inbox.md = %q", data)
+	}
+	app.updateInbox(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	if app.inbox != nil {
+		t.Error("an empty inbox left the view open")
+	}
+	if items := app.outliner.InboxItems(); len(items) != 0 || !app.saved {
+		t.Errorf("archived inbox left %+v, saved %v", items, app.saved)
+	}
+}
+
+func TestAppRecall(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("• eureka:: the cache key was the bug\n• highlight:: attention is generosity\n• ctx:: not a card\n"), 0644)
+	app := newTestApp(path)
+	app.Update(tea.WindowSizeMsg{Width: 60, Height: 20})
+
+	if err := app.openRecall(); err != nil {
+		t.Fatal(err)
+	}
+	if app.recall == nil || len(app.recall.due) != 2 {
+		t.Fatalf("recall view = %+v", app.recall)
+	}
+	if frame := app.renderRecall(); !strings.Contains(frame, "card 1 of 2") || !strings.Contains(frame, "3 good (1d)") {
+		t.Errorf("recall frame:\n%s", frame)
+	}
+
+	app.updateRecall(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'3'}})
+	app.updateRecall(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	if app.recall != nil {
+		t.Fatal("the review stayed open after the last card")
+	}
+	actions := app.outliner.Dispatch().GetActions()
+	if last := actions[len(actions)-1]; last.PatternType != "ctx" || last.Content != "reviewed 2 recall cards (1 again, 1 good)" {
+		t.Errorf("logged %s:: %s", last.PatternType, last.Content)
+	}
+
+	// Both are scheduled for later now, and the schedule was saved
+	if err := app.openRecall(); err != nil || app.recall != nil {
+		t.Errorf("cards due again straight away: %+v, %v", app.recall, err)
+	}
+	if deck, err := recall.Load(recallPath()); err != nil || len(deck.Due(time.Now().AddDate(0, 0, 1))) != 2 {
+		t.Errorf("saved deck: %v", err)
+	}
+}
+
+func TestAppDocStats(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "zine.md")
+	os.WriteFile(path, []byte("# Issue one\n• eureka:: layouts follow the selectors\n• a quiet line\n"), 0644)
+	app := newTestApp(path)
+	app.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+
+	if status := app.renderStatusBar(); !strings.Contains(status, "[9 words]") {
+		t.Errorf("status bar = %q", status)
+	}
+	if err := paletteCommands["words"].run(app, nil); err != nil || app.words == nil {
+		t.Fatalf("words didn't open the popup: %v", err)
+	}
+	if frame := app.renderDocStats(); !strings.Contains(frame, "9 words · 3 nodes") || strings.Contains(frame, "Sections") {
+		t.Errorf("collapsed popup:\n%s", frame)
+	}
+	app.updateDocStats(tea.KeyMsg{Type: tea.KeyTab})
+	if frame := app.renderDocStats(); !strings.Contains(frame, "eureka::") || !strings.Contains(frame, "Issue one") {
+		t.Errorf("expanded popup:\n%s", frame)
+	}
+	app.updateDocStats(tea.KeyMsg{Type: tea.KeyEsc})
+	if app.words != nil {
+		t.Error("esc left the popup open")
+	}
+
+	app.saveFile()
+	messages := app.outliner.DebugMessages()
+	if last := messages[len(messages)-1]; last.Type != "STATS" || !strings.HasPrefix(last.Content, "9 words · 3 nodes · patterns: 1 eureka") {
+		t.Errorf("last debug message = %+v", last)
+	}
+}
+
+// runCommand runs a subcommand with args and returns what it printed to
+// stdout; the flags it was given go back to their defaults when it returns
+func runCommand(t *testing.T, cmd *cobra.Command, args ...string) (string, error) {
+	t.Helper()
+	defer func() {
+		for _, arg := range args {
+			if f := cmd.Flags().Lookup(strings.TrimPrefix(arg, "--")); f != nil {
+				f.Value.Set(f.DefValue)
+				f.Changed = false
+			}
+		}
+	}()
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatal(err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	printed := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		printed <- string(data)
+	}()
+
+	if cmd.RunE != nil {
+		err = cmd.RunE(cmd, cmd.Flags().Args())
+	} else {
+		cmd.Run(cmd, cmd.Flags().Args())
+	}
+	w.Close()
+	return <-printed, err
+}
+
+// ndjsonKinds decodes NDJSON output, failing on a line that isn't an
+// object, and returns each line's kind
+func ndjsonKinds(t *testing.T, out string) []string {
+	t.Helper()
+	var kinds []string
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("not an NDJSON line: %q", line)
+		}
+		kind, _ := record["kind"].(string)
+		kinds = append(kinds, kind)
+	}
+	return kinds
+}
+
+func TestJSONOutput(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.md")
+	os.WriteFile(notes, []byte("• decision:: ship it\n• eureka:: found it\n  • plain child\n"), 0644)
+	loose := filepath.Join(dir, "loose.md")
+	os.WriteFile(loose, []byte("eureka:: no bullet\n"), 0644)
+
+	for _, tc := range []struct {
+		name  string
+		cmd   *cobra.Command
+		args  []string
+		kinds []string
+	}{
+		{"capture", captureCmd, []string{"--no-dispatch", "--json", notes}, []string{"pattern", "pattern"}},
+		{"capture default", captureCmd, []string{"--no-dispatch", notes}, []string{"pattern", "pattern"}},
+		{"query", queryCmd, []string{"--dir", dir, "--reducer", "collect all decisions", "--json"}, []string{"action"}},
+		{"query selector", queryCmd, []string{"--dir", dir, "--reducer", "collect all decisions", "--selector", "Calls", "--json"}, []string{"action", "selector"}},
+		{"lint", lintCmd, []string{"--json", loose}, []string{"issue"}},
+		{"export", exportCmd, []string{"--json", notes}, []string{"node", "node", "node"}},
+		{"export format", exportCmd, []string{"--format", "ndjson", notes}, []string{"node", "node", "node"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := runCommand(t, tc.cmd, tc.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kinds := ndjsonKinds(t, out); !slices.Equal(kinds, tc.kinds) {
+				t.Errorf("kinds = %v, want %v:\n%s", kinds, tc.kinds, out)
+			}
+		})
+	}
+
+	// --json is ndjson, so any other --format is a mistake
+	for cmd, args := range map[*cobra.Command][]string{
+		captureCmd: {"--no-dispatch", notes},
+		queryCmd:   {"--dir", dir, "--reducer", "collect all decisions"},
+		exportCmd:  {notes},
+	} {
+		if _, err := runCommand(t, cmd, append(args, "--json", "--format", "json")...); err == nil ||
+			err.Error() != "--json can't be combined with --format json" {
+			t.Errorf("%s --json --format json = %v", cmd.Name(), err)
+		}
+	}
+}
+
+func TestCaptureCommand(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(config.ProfileEnv, "")
+	config.SetProfile("")
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.md")
+	os.WriteFile(notes, []byte("# Monday\n• ctx:: 2025-08-05 @ 10:00 - [project:: float]\n• plain bullet\n• decision:: ship it\n"), 0644)
+
+	out, err := runCommand(t, captureCmd, "--no-dispatch", "--format", "json", notes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []captureRecord
+	if err := json.Unmarshal([]byte(out), &records); err != nil {
+		t.Fatalf("--format json isn't one array: %v\n%s", err, out)
+	}
+	if len(records) != 2 {
+		t.Fatalf("captured %d patterns:\n%s", len(records), out)
+	}
+	for i, want := range []captureRecord{
+		{Kind: "pattern", Source: notes, Line: 2, Type: "ctx"},
+		{Kind: "pattern", Source: notes, Line: 4, Type: "decision", Content: "ship it"},
+	} {
+		got := records[i]
+		if got.Kind != want.Kind || got.Source != want.Source || got.Line != want.Line || got.Type != want.Type ||
+			(want.Content != "" && got.Content != want.Content) || got.ActionID == "" || got.Imprint == "" || got.Timestamp.IsZero() {
+			t.Errorf("record %d = %+v", i, got)
+		}
+	}
+	if records[0].Context["project"] != "float" {
+		t.Errorf("ctx record context = %v", records[0].Context)
+	}
+
+	// "-" reads stdin, reported as the source
+	stdin, err := os.Open(notes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	realStdin := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = realStdin }()
+	out, err = runCommand(t, captureCmd, "--no-dispatch", "-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"source":"stdin","line":4,"type":"decision"`) {
+		t.Errorf("stdin capture:\n%s", out)
+	}
+
+	// Without --no-dispatch each pattern goes to evna, and failures fail the run
+	status := http.StatusOK
+	var sent []string
+	evna := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		sent = append(sent, string(data))
+		w.WriteHeader(status)
+	}))
+	defer evna.Close()
+	for key, value := range map[string]string{"evna.enabled": "true", "evna.endpoint": evna.URL} {
+		if err := config.Set(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := runCommand(t, captureCmd, notes); err != nil || len(sent) != 2 || !strings.Contains(sent[1], "ship it") {
+		t.Errorf("dispatched %q, %v", sent, err)
+	}
+	status = http.StatusInternalServerError
+	if _, err := runCommand(t, captureCmd, notes); err == nil || err.Error() != "2 patterns failed to dispatch to evna" {
+		t.Errorf("capture with evna down = %v", err)
+	}
+
+	if _, err := runCommand(t, captureCmd, "--no-dispatch", "--format", "text", notes); err == nil {
+		t.Error("capture took --format text")
+	}
+	if _, err := runCommand(t, captureCmd, "--no-dispatch", filepath.Join(dir, "missing.md")); err == nil {
+		t.Error("capture of a missing file succeeded")
+	}
+}
+
+func TestDailyNote(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Date(2026, 3, 12, 0, 0, 0, 0, time.Local)
+	tmpl := filepath.Join(dir, "daily.tmpl")
+	os.WriteFile(tmpl, []byte("# {{.Weekday}} {{.Date}}\n• mode:: {{.Mode}}\n"), 0644)
+	wd, _ := os.Getwd()
+
+	for _, tc := range []struct {
+		name    string
+		daily   config.DailyConfig
+		path    string
+		content string
+	}{
+		{"default", config.DailyConfig{Dir: dir, Project: "float", Mode: "deep"}, "2026-03-12.md",
+			"# 2026-03-12\n\n• ctx:: 2026-03-12 TIME [project:: float] [mode:: deep]\n• \n"},
+		{"project from directory", config.DailyConfig{Dir: filepath.Join(dir, "wd")}, "wd/2026-03-12.md",
+			"# 2026-03-12\n\n• ctx:: 2026-03-12 TIME [project:: " + filepath.Base(wd) + "] [mode:: ]\n• \n"},
+		{"template and format", config.DailyConfig{Dir: filepath.Join(dir, "journal"), Template: tmpl, Format: "2006_01_02", Mode: "flow"},
+			"journal/2026_03_12.md", "# Thursday 2026-03-12\n• mode:: flow\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Daily = tc.daily
+			path, err := ensureDailyNote(cfg, nil, day)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.Join(dir, filepath.FromSlash(tc.path)); path != want {
+				t.Errorf("path = %s, want %s", path, want)
+			}
+			data, _ := os.ReadFile(path)
+			content := regexp.MustCompile(`\d{1,2}:\d{2}[ap]m`).ReplaceAllString(string(data), "TIME")
+			if content != tc.content {
+				t.Errorf("note = %q, want %q", content, tc.content)
+			}
+		})
+	}
+
+	// An existing note is opened as it is
+	cfg := config.Default()
+	cfg.Daily.Dir = dir
+	path := filepath.Join(dir, "2026-03-13.md")
+	os.WriteFile(path, []byte("• already written\n"), 0644)
+	if got, err := ensureDailyNote(cfg, nil, day.AddDate(0, 0, 1)); err != nil || got != path {
+		t.Fatalf("ensureDailyNote = %s, %v", got, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "• already written\n" {
+		t.Errorf("existing note rewritten: %q", data)
+	}
+
+	// Inside a vault the note follows its journals unless daily.dir is set
+	root := filepath.Join(dir, "vault")
+	os.MkdirAll(filepath.Join(root, "logseq"), 0755)
+	v, err := vault.New(root, vault.KindLogseq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Daily.Dir = ""
+	if got, err := ensureDailyNote(cfg, v, day); err != nil || got != v.JournalPath(day) {
+		t.Errorf("vault daily note = %s, %v, want %s", got, err, v.JournalPath(day))
+	}
+
+	cfg.Daily.Template = filepath.Join(dir, "missing.tmpl")
+	if _, err := ensureDailyNote(cfg, nil, day.AddDate(0, 0, 2)); err == nil || !strings.HasPrefix(err.Error(), "read daily template") {
+		t.Errorf("missing template = %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+)
+
+// archivePath is where outliner.archive_file points, relative to the
+// active file; "" archives into the outline's own archive:: section
+func (a *OutlinerApp) archivePath() string {
+	if a.cfg == nil || a.cfg.Outliner.ArchiveFile == "" {
+		return ""
+	}
+	if filepath.IsAbs(a.cfg.Outliner.ArchiveFile) {
+		return a.cfg.Outliner.ArchiveFile
+	}
+	return filepath.Join(a.exportDir(), a.cfg.Outliner.ArchiveFile)
+}
+
+// archiveAtCursor archives the subtree under the cursor, into the archive
+// file when one is configured
+func (a *OutlinerApp) archiveAtCursor() {
+	path := a.archivePath()
+	if path == "" {
+		if err := a.outliner.ArchiveSubtree(time.Now()); err != nil {
+			a.toasts.PushError(err)
+			return
+		}
+		a.saved = false
+		return
+	}
+
+	text, err := a.outliner.CutSubtree(time.Now())
+	if err != nil {
+		a.toasts.PushError(err)
+		return
+	}
+	if err := appendFile(path, text); err != nil {
+		// Put the subtree back rather than lose it
+		a.outliner.Undo()
+		a.toasts.PushError(fmt.Errorf("archive to %s: %w", path, err))
+		return
+	}
+	a.saved = false
+	a.toasts.Push(components.ToastSuccess, i18n.T("outliner.toast.archived", filepath.Base(path)))
+}
+
+// appendFile adds text to the end of path, creating it if needed
+func appendFile(path, text string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+)
+
+// noteKeypress tracks typing for idle boundaries: the first keypress after
+// a gap of [outliner] idle_boundary closes the ctx:: block with the time
+// worked before the gap and opens a fresh one to carry on in
+func (a *OutlinerApp) noteKeypress(now time.Time) {
+	if a.idleBoundary <= 0 {
+		return
+	}
+	if a.lastKey.IsZero() {
+		a.workStart, a.lastKey = now, now
+		return
+	}
+
+	if idle := now.Sub(a.lastKey); idle >= a.idleBoundary {
+		worked := a.lastKey.Sub(a.workStart)
+		if a.outliner.ContextBoundary(worked, now) {
+			a.saved = false
+			slog.Info("ctx boundary", "worked", worked.Round(time.Second), "idle", idle.Round(time.Second))
+			a.toasts.Push(components.ToastSuccess, i18n.T("outliner.toast.block_closed", outliner.FormatWorked(worked), outliner.FormatWorked(idle)))
+		}
+		a.workStart = now
+	}
+	a.lastKey = now
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/bridge"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+	"github.com/spf13/cobra"
+)
+
+var bridgeCmd = &cobra.Command{
+	Use:   "bridge",
+	Short: "Manage bridge:: IDs and the bridge registry",
+	Long: `Bridges connect a moment of context to the place it is picked up again.
+Saving an outline gives every bridge:: node without one a sequential
+[bridge-id:: CB-YYYYMMDD-HHMM-XXXX] and records each node carrying the ID in
+the registry (bridge.registry, default ~/.config/float-line/bridges.json).
+In the editor, Alt+B on a node with a bridge-id jumps to its other end.`,
+}
+
+var bridgeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List registered bridges",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reg, err := loadBridgeRegistry()
+		if err != nil {
+			return err
+		}
+		for _, b := range reg.List() {
+			if len(b.Ends) == 0 {
+				continue // reserved but never saved
+			}
+			summary := b.Ends[0].Text
+			if len(b.Context) > 0 {
+				summary = strings.TrimPrefix(b.Context[0], "• ")
+			}
+			fmt.Printf("%s  %d ends  %s\n", b.ID, len(b.Ends), summary)
+		}
+		return nil
+	},
+}
+
+var bridgeShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show a bridge's ends and stored context",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reg, err := loadBridgeRegistry()
+		if err != nil {
+			return err
+		}
+		b, ok := reg.Get(args[0])
+		if !ok {
+			return fmt.Errorf("no bridge %s in %s", args[0], reg.Path())
+		}
+
+		fmt.Printf("%s (created %s)\n", b.ID, b.Created.Format("2006-01-02 15:04"))
+		for _, end := range b.Ends {
+			role := "ref   "
+			if end.Origin {
+				role = "origin"
+			}
+			fmt.Printf("  %s %s:%d  %s\n", role, end.File, end.Line, end.Text)
+		}
+		if len(b.Context) > 0 {
+			fmt.Println("\ncontext:")
+			for _, line := range b.Context {
+				fmt.Println("  " + line)
+			}
+		}
+		return nil
+	},
+}
+
+var bridgeValidateCmd = &cobra.Command{
+	Use:   "validate [file|dir]...",
+	Short: "Report duplicate, dangling, and missing bridge IDs",
+	Long: `Validate re-scans the given files (markdown files under directories) into
+the registry, then reports bridge IDs opened by more than one bridge:: node,
+references to IDs no bridge:: node opens, and bridge:: nodes without an ID.
+Exits 1 when problems are found.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reg, err := loadBridgeRegistry()
+		if err != nil {
+			return err
+		}
+
+		for _, arg := range args {
+			files, err := markdownFiles(arg)
+			if err != nil {
+				return err
+			}
+			for _, file := range files {
+				content, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				reg.Sync(file, string(content))
+			}
+		}
+		if len(args) > 0 {
+			if err := reg.Save(); err != nil {
+				return err
+			}
+		}
+
+		problems := reg.Validate()
+		for _, p := range problems {
+			fmt.Printf("%s:%d: %s\n", p.End.File, p.End.Line, p.Message)
+		}
+		if len(problems) > 0 {
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+var bridgeNewCmd = &cobra.Command{
+	Use:   "new",
+	Short: "Reserve and print the next bridge ID",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reg, err := loadBridgeRegistry()
+		if err != nil {
+			return err
+		}
+		id := reg.NextID(time.Now())
+		if err := reg.Save(); err != nil {
+			return err
+		}
+		fmt.Println(id)
+		return nil
+	},
+}
+
+// bridgeRegistryPath returns bridge.registry or the default location
+func bridgeRegistryPath(cfg *config.Config) string {
+	if cfg.Bridge.Registry != "" {
+		return config.ExpandHome(cfg.Bridge.Registry)
+	}
+	return filepath.Join(config.Dir(), "bridges.json")
+}
+
+func loadBridgeRegistry() (*bridge.Registry, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	return bridge.Load(bridgeRegistryPath(cfg))
+}
+
+// markdownFiles expands a directory into the markdown files beneath it
+func markdownFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != path && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".md") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// jumpBridge moves to the other end of the bridge under the cursor, opening
+// its file in a buffer when it lives elsewhere
+func (a *OutlinerApp) jumpBridge() {
+	text := a.outliner.CurrentText()
+	id, ok := bridge.ID(text)
+	if !ok {
+		return
+	}
+
+	if a.bridges != nil {
+		if b, ok := a.bridges.Get(id); ok {
+			if end, ok := b.OtherEnd(a.filename, text); ok && !sameFile(end.File, a.filename) {
+				a.openBuffer(end.File)
+				if i := a.outliner.FindNode(end.Text, -1); i >= 0 {
+					a.outliner.SetCursor(i)
+				} else if i := a.outliner.FindNode(id, -1); i >= 0 {
+					a.outliner.SetCursor(i)
+				}
+				return
+			}
+		}
+	}
+
+	// Both ends in this file, or no registry entry yet
+	if i := a.outliner.FindNode(id, a.outliner.Cursor()); i >= 0 {
+		a.outliner.SetCursor(i)
+	}
+}
+
+func init() {
+	bridgeCmd.AddCommand(bridgeListCmd)
+	bridgeCmd.AddCommand(bridgeShowCmd)
+	bridgeCmd.AddCommand(bridgeValidateCmd)
+	bridgeCmd.AddCommand(bridgeNewCmd)
+}
+
+// restoreBridge inserts a bridge's stored context under the current node,
+// collapsed, closing the loop on the bridge
+func (a *OutlinerApp) restoreBridge(id string) error {
+	if a.bridges == nil {
+		return fmt.Errorf("bridge registry unavailable")
+	}
+	b, ok := a.bridges.Get(id)
+	if !ok || len(b.Context) == 0 {
+		return fmt.Errorf("no stored context for bridge %s", id)
+	}
+
+	// The restored root refers to the bridge rather than opening it again,
+	// so the registry doesn't see a duplicate origin
+	context := append([]string(nil), b.Context...)
+	context[0] = strings.Replace(context[0], "bridge::", "restored::", 1)
+
+	n := a.outliner.InsertSubtree(context, map[string]string{"restored-from": id})
+	if n == 0 {
+		return fmt.Errorf("no stored context for bridge %s", id)
+	}
+	a.saved = false
+	a.toasts.Push(components.ToastSuccess, i18n.T("outliner.toast.restored", n, id))
+	return nil
+}
+
+// restoreBridgeAtCursor restores the bridge referenced by the current node
+func (a *OutlinerApp) restoreBridgeAtCursor() {
+	id, ok := bridge.ID(a.outliner.CurrentText())
+	if !ok {
+		a.toasts.Push(components.ToastWarn, i18n.T("outliner.toast.no_bridge_id"))
+		return
+	}
+	if err := a.restoreBridge(id); err != nil {
+		a.toasts.PushError(err)
+	}
+}
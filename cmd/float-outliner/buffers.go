@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+)
+
+// buffer is an open file. The active buffer lives in OutlinerApp's own
+// fields; the others wait here until switched to.
+type buffer struct {
+	outliner outliner.Outliner
+	filename string
+	saved    bool
+	format   string
+}
+
+// openBuffer opens path in a new buffer, or switches to it if it's already
+// open. Missing files start empty and are created on save.
+func (a *OutlinerApp) openBuffer(path string) {
+	a.stashBuffer()
+	for i, b := range a.buffers {
+		if sameFile(b.filename, path) {
+			a.switchBuffer(i)
+			return
+		}
+	}
+
+	format, err := resolveFormat("", path)
+	if err != nil {
+		format = formatMarkdown
+	}
+	b := buffer{outliner: outliner.New(), filename: path, saved: true, format: format}
+	a.configureOutliner(&b.outliner)
+	a.arrange(&b.outliner)
+	if content, err := os.ReadFile(path); err == nil {
+		b.outliner.SetContent(string(content))
+	}
+	loadDispatchHistory(&b.outliner, path)
+
+	a.buffers = append(a.buffers, b)
+	a.previous = a.current
+	a.activate(len(a.buffers) - 1)
+}
+
+// switchBuffer makes buffer i active, remembering the one being left
+func (a *OutlinerApp) switchBuffer(i int) {
+	if i < 0 || i >= len(a.buffers) || i == a.current {
+		return
+	}
+	a.stashBuffer()
+	a.previous = a.current
+	a.activate(i)
+}
+
+// stashBuffer copies the active buffer into the buffer list
+func (a *OutlinerApp) stashBuffer() {
+	active := buffer{outliner: a.outliner, filename: a.filename, saved: a.saved, format: a.format}
+	if len(a.buffers) == 0 {
+		a.buffers = []buffer{active}
+		a.current = 0
+		return
+	}
+	a.buffers[a.current] = active
+}
+
+func (a *OutlinerApp) activate(i int) {
+	b := a.buffers[i]
+	a.outliner, a.filename, a.saved, a.format = b.outliner, b.filename, b.saved, b.format
+	a.current = i
+	a.outliner.SetDebugRatio(a.debugRatio())
+	a.outliner.SetSize(a.width, a.outlinerHeight())
+	a.outliner.Focus()
+	a.refreshGit()
+}
+
+// bufferStatus describes the open buffers for the status bar
+func (a *OutlinerApp) bufferStatus() string {
+	status := ""
+	if len(a.buffers) > 1 {
+		status = fmt.Sprintf(" [%d/%d]", a.current+1, len(a.buffers))
+	}
+	if a.vault != nil {
+		status += fmt.Sprintf(" [vault: %d pages]", a.vault.Pages())
+	}
+	return status
+}
+
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
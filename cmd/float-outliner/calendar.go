@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evanschultz/float-rw-client/pkg/calendar"
+)
+
+// calendarCheckInterval is how often the feed is checked for being due;
+// [calendar] refresh says how often it's actually fetched
+const calendarCheckInterval = time.Minute
+
+// calendarTickMsg asks whether the calendar is due a fetch
+type calendarTickMsg struct{}
+
+// calendarMsg reports a finished calendar fetch
+type calendarMsg struct {
+	feed *calendar.Feed
+	err  error
+}
+
+// calendarDoor is a door that shows the calendar
+type calendarDoor interface {
+	SetCalendar(cal *calendar.Calendar)
+}
+
+// calendarTick schedules the next check of the calendar feed
+func calendarTick() tea.Cmd {
+	return tea.Tick(calendarCheckInterval, func(time.Time) tea.Msg {
+		return calendarTickMsg{}
+	})
+}
+
+// fetchCalendar fetches the calendar in the background if it's due
+func (a *OutlinerApp) fetchCalendar() tea.Cmd {
+	feed := a.calendar
+	if !feed.Due(time.Now()) {
+		return nil
+	}
+	return func() tea.Msg {
+		return calendarMsg{feed: feed, err: feed.Refresh(context.Background())}
+	}
+}
+
+// useCalendar hands a fetched calendar to every open buffer; a fetch for
+// a profile since switched away from is dropped
+func (a *OutlinerApp) useCalendar(msg calendarMsg) {
+	if msg.feed != a.calendar {
+		return
+	}
+	if msg.err != nil {
+		slog.Warn("calendar fetch failed", "err", msg.err)
+		return
+	}
+	cal := a.calendar.Calendar()
+	a.outliner.SetCalendar(cal)
+	for i := range a.buffers {
+		if i != a.current {
+			a.buffers[i].outliner.SetCalendar(cal)
+		}
+	}
+	if d, ok := a.door.(calendarDoor); ok {
+		d.SetCalendar(cal)
+	}
+	slog.Debug("calendar fetched", "events", cal.Len())
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/calendar"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	captureFormat     string
+	captureNoDispatch bool
+)
+
+var captureCmd = &cobra.Command{
+	Use:   "capture <file|->...",
+	Short: "Detect :: patterns without the TUI and dispatch them to evna",
+	Long: `Capture runs the parser and FLOAT.dispatch over files (or stdin with "-")
+and prints one record per detected pattern. Patterns are also dispatched to
+evna using the [evna] config section unless --no-dispatch is set.`,
+	Example: `  float-outliner capture journal/2025-08-05.md
+  cat today.md | float-outliner capture - --format json
+  float-outliner capture notes/*.md --no-dispatch --json | jq -r .type`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runCapture,
+}
+
+// captureRecord is the JSON shape of one captured pattern
+type captureRecord struct {
+	Kind      string            `json:"kind"` // "pattern"
+	Source    string            `json:"source"`
+	Line      int               `json:"line"`
+	Type      string            `json:"type"`
+	Content   string            `json:"content"`
+	Context   map[string]string `json:"context,omitempty"`
+	ActionID  string            `json:"action_id"`
+	Imprint   string            `json:"imprint"`
+	Sigil     string            `json:"sigil,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+func runCapture(cmd *cobra.Command, args []string) error {
+	format, err := outputFormat(cmd, captureFormat)
+	if err != nil {
+		return err
+	}
+	if format != formatNDJSON && format != "json" {
+		return fmt.Errorf("unknown --format %q: use ndjson or json", format)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	parser := outliner.NewParser()
+	dispatch := outliner.NewFloatDispatchSystem()
+	evna := outliner.NewEvnaDispatcher()
+	applyDispatchConfig(evna, dispatch, cfg)
+	if captureNoDispatch {
+		evna.SetEnabled(false)
+	}
+
+	// ctx:: captures name the meeting they were written in
+	var cal *calendar.Calendar
+	if calendar.Configured(cfg.Calendar) {
+		if cal, err = calendar.Load(cmd.Context(), cfg.Calendar, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
+	}
+
+	failures := 0
+	evna.SetErrorLogger(func(msgType, content string) {
+		failures++
+		fmt.Fprintf(os.Stderr, "%s: %s\n", msgType, content)
+	})
+
+	out := json.NewEncoder(os.Stdout)
+	records := []captureRecord{}
+
+	for _, path := range args {
+		content, source, err := readCaptureInput(path)
+		if err != nil {
+			return err
+		}
+
+		parsed := parser.Parse(content)
+		for _, pattern := range parsed.ConsciousnessData {
+			nodeID := fmt.Sprintf("%s:%d", source, pattern.Line)
+			pattern = outliner.AnnotateMeeting(cal, pattern, time.Now())
+			action := dispatch.DispatchWith(nodeID, pattern.Content, pattern.Type, outliner.MeetingMetadata(pattern), time.Now())
+
+			if err := evna.DispatchPatterns([]outliner.ConsciousnessPattern{pattern}, "float-capture:"+source); err != nil {
+				return fmt.Errorf("dispatch %s:%d: %w", source, pattern.Line, err)
+			}
+
+			record := captureRecord{
+				Kind:      "pattern",
+				Source:    source,
+				Line:      pattern.Line,
+				Type:      pattern.Type,
+				Content:   pattern.Content,
+				Context:   pattern.Context,
+				ActionID:  action.ID,
+				Imprint:   action.Imprint,
+				Sigil:     action.Sigil,
+				Timestamp: action.Timestamp,
+			}
+
+			if format == formatNDJSON {
+				if err := out.Encode(record); err != nil {
+					return err
+				}
+			} else {
+				records = append(records, record)
+			}
+		}
+	}
+
+	if format == "json" {
+		out.SetIndent("", "  ")
+		if err := out.Encode(records); err != nil {
+			return err
+		}
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d patterns failed to dispatch to evna", failures)
+	}
+	return nil
+}
+
+// readCaptureInput reads a file, or stdin for "-"
+func readCaptureInput(path string) (content, source string, err error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", "", fmt.Errorf("read stdin: %w", err)
+		}
+		return string(data), "stdin", nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("read %s: %w", path, err)
+	}
+	return string(data), path, nil
+}
+
+func init() {
+	captureCmd.Flags().StringVar(&captureFormat, "format", formatNDJSON, "Output format: ndjson or json")
+	addJSONFlag(captureCmd)
+	captureCmd.Flags().BoolVar(&captureNoDispatch, "no-dispatch", false, "Print patterns without sending them to evna")
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evanschultz/float-rw-client/pkg/bridge"
+	"github.com/evanschultz/float-rw-client/pkg/calendar"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/termcolor"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+)
+
+// keymapPresets translate preset-specific keys into the outliner's default
+// bindings, so the outliner itself only knows one keymap
+var keymapPresets = map[string]map[string]tea.KeyMsg{
+	"default": {},
+	"workflowy": {
+		"ctrl+right": {Type: tea.KeyTab},
+		"ctrl+left":  {Type: tea.KeyShiftTab},
+		"alt+right":  {Type: tea.KeyTab},
+		"alt+left":   {Type: tea.KeyShiftTab},
+	},
+}
+
+// autosaveTickMsg fires every outliner.autosave_interval seconds
+type autosaveTickMsg struct{}
+
+// applyConfig pushes the shared config into the outliner and app
+func (a *OutlinerApp) applyConfig(cfg *config.Config) {
+	a.cfg = cfg
+
+	if keymap, ok := keymapPresets[cfg.Outliner.Keymap]; ok {
+		a.keymap = keymap
+	}
+
+	if cfg.Outliner.Autosave && cfg.Outliner.AutosaveInterval > 0 {
+		a.autosaveInterval = time.Duration(cfg.Outliner.AutosaveInterval) * time.Second
+	}
+
+	a.idleBoundary = time.Duration(cfg.Outliner.IdleBoundary) * time.Minute
+	a.calendar = calendar.NewFeed(cfg.Calendar)
+
+	a.autoCommit = cfg.Git.AutoCommit
+	a.refreshGit()
+
+	if reg, err := bridge.Load(bridgeRegistryPath(cfg)); err == nil {
+		a.bridges = reg
+	} else {
+		slog.Warn("bridge registry not loaded", "path", bridgeRegistryPath(cfg), "err", err)
+	}
+
+	a.configureOutliner(&a.outliner)
+	loadDispatchHistory(&a.outliner, a.filename)
+
+	// Routing problems show up in the debug panel once, at startup
+	a.outliner.ValidateCollections()
+}
+
+// configureOutliner applies the theme and dispatch config to an outliner;
+// buffers opened later get the same settings as the first
+func (a *OutlinerApp) configureOutliner(o *outliner.Outliner) {
+	if a.vault != nil {
+		o.SetLinkIndex(a.vault)
+	}
+	if a.cfg == nil {
+		return
+	}
+	o.SetZenWidth(a.cfg.Outliner.ZenWidth)
+	o.SetTypewriter(a.cfg.Outliner.Typewriter)
+	o.SetScrollMargin(a.cfg.Outliner.ScrollMargin)
+	o.SetSearchArchived(a.cfg.Outliner.SearchArchived)
+	o.SetCalendar(a.calendar.Calendar())
+
+	o.SetTheme(themeFromConfig(a.cfg))
+	o.SetAccessible(a.cfg.Accessibility.Enabled)
+
+	applyDispatchConfig(o.Evna(), o.Dispatch(), a.cfg)
+	o.SetCaptureReview(a.cfg.Evna.Review)
+}
+
+// themeFromConfig is the configured theme: custom pattern colors, then
+// explicit [theme.patterns] on top
+func themeFromConfig(cfg *config.Config) outliner.Theme {
+	colors := map[string]string{}
+	for name, pattern := range cfg.Patterns {
+		if pattern.Color != "" {
+			colors[name] = pattern.Color
+		}
+	}
+	for name, color := range cfg.Theme.Patterns {
+		colors[name] = color
+	}
+	return outliner.Theme{
+		Accent:     cfg.Theme.Accent,
+		Patterns:   colors,
+		Monochrome: termcolor.Current() == termcolor.Mono,
+	}
+}
+
+// colorSetting is theme.colors, except that the screen reader mode
+// renders in monochrome unless a level is set
+func colorSetting(cfg *config.Config) string {
+	setting := strings.ToLower(strings.TrimSpace(cfg.Theme.Colors))
+	if cfg.Accessibility.Enabled && (setting == "" || setting == "auto") {
+		return "mono"
+	}
+	return setting
+}
+
+// localesDir holds translations of the UI, one <locale>.toml each
+func localesDir() string {
+	return filepath.Join(config.Dir(), "locales")
+}
+
+// newAnnouncer is the screen reader mode's announcer for capture events,
+// or nil when the mode is off
+func newAnnouncer(cfg config.AccessibilityConfig) (*components.Announcer, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	return components.NewAnnouncer(os.Stdout, cfg.Notify)
+}
+
+// registerPatterns declares the custom pattern types in [patterns]
+func registerPatterns(cfg *config.Config) error {
+	names := make([]string, 0, len(cfg.Patterns))
+	for name := range cfg.Patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := outliner.RegisterPatternType(name); err != nil {
+			return fmt.Errorf("patterns.%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// applyDispatchConfig applies the [evna], [imprints] and [redact]
+// sections over whatever was applied before, so nothing of another
+// profile's carries over; shared by the TUI and headless commands
+func applyDispatchConfig(evna *outliner.EvnaDispatcher, dispatch *outliner.FloatDispatchSystem, cfg *config.Config) {
+	evna.ResetConfig()
+	dispatch.ResetConfig()
+
+	// Broken rules were reported at startup; nothing leaves unredacted
+	redactor, err := outliner.NewRedactorFromConfig(cfg.Redact)
+	if err != nil {
+		slog.Error("evna dispatch disabled", "err", err)
+	}
+	evna.SetRedactor(redactor)
+
+	evna.SetEnabled(cfg.Evna.Enabled && err == nil)
+	evna.SetEndpoint(cfg.Evna.Endpoint)
+	evna.SetDefaultCollection(cfg.Evna.DefaultCollection)
+	evna.SetCollectionsURL(cfg.Evna.CollectionsURL)
+
+	// Custom pattern collections, then explicit [evna.collections] on top
+	collections := map[string]string{}
+	for name, pattern := range cfg.Patterns {
+		if pattern.Collection != "" {
+			collections[name] = pattern.Collection
+		}
+		if pattern.Imprint != "" {
+			dispatch.SetImprintRoute(name, pattern.Imprint)
+		}
+	}
+	for name, collection := range cfg.Evna.Collections {
+		collections[name] = collection
+	}
+	evna.SetCollectionRouting(collections)
+
+	dispatch.SetExecConfig(outliner.ExecConfig{
+		Enabled:   cfg.Reducers.Exec,
+		Timeout:   time.Duration(cfg.Reducers.ExecTimeout) * time.Second,
+		BatchSize: cfg.Reducers.ExecBatch,
+	})
+	if r := cfg.Reducers; r.EmbeddingsURL != "" && err == nil {
+		embedder := &outliner.HTTPEmbedder{URL: r.EmbeddingsURL, Model: r.EmbeddingsModel, APIKey: r.EmbeddingsKey, Redactor: redactor}
+		dispatch.SetEmbeddings(outliner.NewEmbeddings(embedder, time.Duration(r.EmbeddingsTimeout)*time.Second), r.Similarity)
+	}
+	if err := dispatch.SetGlobalReducers(cfg.Reducers.Global); err != nil {
+		slog.Warn("global reducers", "err", err)
+	}
+
+	for name, imprint := range cfg.Imprints {
+		metadata := map[string]string{}
+		if imprint.Color != "" {
+			metadata["color"] = imprint.Color
+		}
+		if imprint.Sigil != "" {
+			metadata["sigil"] = imprint.Sigil
+		}
+		dispatch.RegisterImprint(&outliner.Imprint{
+			Name:      name,
+			Voice:     imprint.Voice,
+			Aesthetic: imprint.Aesthetic,
+			Filters:   imprint.Filters,
+			Metadata:  metadata,
+		})
+	}
+}
+
+// translateKey maps a preset binding to the default one
+func (a *OutlinerApp) translateKey(msg tea.KeyMsg) tea.KeyMsg {
+	if mapped, ok := a.keymap[msg.String()]; ok {
+		return mapped
+	}
+	return msg
+}
+
+// autosaveTick schedules the next autosave check
+func (a *OutlinerApp) autosaveTick() tea.Cmd {
+	if a.autosaveInterval == 0 {
+		return nil
+	}
+	return tea.Tick(a.autosaveInterval, func(time.Time) tea.Msg {
+		return autosaveTickMsg{}
+	})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/spf13/cobra"
+)
+
+const (
+	formatMarkdown = "markdown"
+	formatOPML     = "opml"
+)
+
+var (
+	convertFormat string
+	convertOut    string
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert <file>",
+	Short: "Convert an outline between markdown and OPML",
+	Long: `Convert reads a markdown or OPML outline (detected from its content) and
+writes it in --format. OPML output stores consciousness metadata (node IDs,
+pattern types, capture state, timestamps) in _float* attributes that
+Workflowy, Dynalist, and OmniOutliner preserve, so outlines round-trip.`,
+	Example: `  float-outliner convert notes.md --format opml > notes.opml
+  float-outliner convert workflowy-export.opml --format markdown --out notes.md`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConvert,
+}
+
+func runConvert(cmd *cobra.Command, args []string) error {
+	format, err := resolveFormat(convertFormat, convertOut)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("read %s: %w", args[0], err)
+	}
+
+	o := outliner.New()
+	o.Evna().SetEnabled(false) // converting shouldn't dispatch anything
+	o.SetContent(string(content))
+
+	out, err := renderContent(o, format, args[0])
+	if err != nil {
+		return err
+	}
+
+	if convertOut == "" {
+		fmt.Print(out)
+		return nil
+	}
+	return os.WriteFile(convertOut, []byte(out), 0644)
+}
+
+// resolveFormat validates an explicit format or infers it from a filename
+func resolveFormat(format, filename string) (string, error) {
+	switch format {
+	case formatMarkdown, formatOPML:
+		return format, nil
+	case "":
+		if strings.EqualFold(filepath.Ext(filename), ".opml") {
+			return formatOPML, nil
+		}
+		return formatMarkdown, nil
+	}
+	return "", fmt.Errorf("unknown format %q: use markdown or opml", format)
+}
+
+// renderContent serializes the outline; OPML titles come from the filename
+func renderContent(o outliner.Outliner, format, filename string) (string, error) {
+	if format == formatOPML {
+		title := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+		return o.GetOPML(title)
+	}
+	return o.GetContent(), nil
+}
+
+func init() {
+	convertCmd.Flags().StringVar(&convertFormat, "format", "", "Output format: markdown or opml (default from --out, else markdown)")
+	convertCmd.Flags().StringVarP(&convertOut, "out", "o", "", "Write to a file instead of stdout")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/encrypt"
+	"github.com/spf13/cobra"
+)
+
+var decryptCmd = &cobra.Command{
+	Use:   "decrypt <file>",
+	Short: "Print a file encrypted at rest",
+	Long: `Decrypt prints a dispatch log, crash report file or selector export that was
+written with [encryption] on, using the configured keyfile or passphrase.
+Plain lines and files are printed as they are.`,
+	Example: `  float-outliner decrypt notes/.float-line/dispatch-log.jsonl | jq .content
+  float-outliner decrypt ~/.cache/float-line/crashes/float-outliner-20250805-180000/outline-1.md`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDecrypt,
+}
+
+func runDecrypt(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("read %s: %w", args[0], err)
+	}
+
+	if encrypt.Active() == nil {
+		// Files can outlive encryption being turned off
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		cfg.Encryption.Enabled = true
+		if err := encrypt.Setup(cfg.Encryption, encrypt.TerminalPrompt); err != nil {
+			return err
+		}
+		defer encrypt.Use(nil)
+	}
+
+	if encrypt.Sealed(data) {
+		plain, err := encrypt.Active().Open(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+		_, err = os.Stdout.Write(plain)
+		return err
+	}
+	for i, line := range bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")) {
+		plain, err := encrypt.OpenLine(line)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", args[0], i+1, err)
+		}
+		fmt.Println(string(plain))
+	}
+	return nil
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffMerge bool
+	diffOut   string
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old> <new>",
+	Short: "Compare two versions of an outline by structure",
+	Long: `Diff compares two outlines as trees rather than line by line. A subtree that
+moved is one change wherever it went, as is a node indented or outdented in
+place and a node whose text was edited. Each change prints on one line with
+the node's line in the new file (in the old one for removals):
+
+  +   12  added    new node (3 nodes)
+  -    4  removed  old node
+  ~    7  edited   before → after
+  >    5  moved    node → under parent
+  ↔    9  level    node (level 1 → 2)
+
+--merge prints the old file with every change but the removals applied
+instead, so nothing either version has is lost: the way to combine a crash's
+recovery file with the file it was written beside. In the outliner, "merge"
+in the Ctrl+K palette does the same with the changes to take picked one by
+one.`,
+	Example: `  float-outliner diff notes.md notes.recovered.md
+  float-outliner diff notes.md notes.recovered.md --merge --out notes.md
+  float-outliner diff old.md new.md --json | jq -r 'select(.change == "moved") | .text'`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiff,
+}
+
+// diffRecord is the JSON shape of one change
+type diffRecord struct {
+	Kind      string `json:"kind"` // "change"
+	Change    string `json:"change"`
+	Line      int    `json:"line"`     // in the new file, 0 for removals
+	OldLine   int    `json:"old_line"` // in the old file, 0 for additions
+	Text      string `json:"text"`
+	NewText   string `json:"new_text,omitempty"`
+	FromLevel int    `json:"from_level"`
+	ToLevel   int    `json:"to_level"`
+	Parent    string `json:"parent,omitempty"`
+	Nodes     int    `json:"nodes"`
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	before, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("read %s: %w", args[0], err)
+	}
+	after, err := os.ReadFile(args[1])
+	if err != nil {
+		return fmt.Errorf("read %s: %w", args[1], err)
+	}
+	d := outliner.DiffOutlines(string(before), string(after))
+
+	if diffMerge {
+		o := outliner.New()
+		o.Evna().SetEnabled(false) // merging shouldn't dispatch anything
+		o.SetContent(d.Merge(d.Additive()))
+		target := diffOut
+		if target == "" {
+			target = args[0]
+		}
+		format, err := resolveFormat("", target)
+		if err != nil {
+			return err
+		}
+		out, err := renderContent(o, format, target)
+		if err != nil {
+			return err
+		}
+		if diffOut == "" {
+			fmt.Print(out)
+			return nil
+		}
+		return os.WriteFile(diffOut, []byte(out), 0644)
+	}
+
+	if jsonOutput {
+		out := json.NewEncoder(os.Stdout)
+		for _, c := range d.Changes {
+			out.Encode(diffRecord{
+				Kind:      "change",
+				Change:    string(c.Kind),
+				Line:      c.After + 1,
+				OldLine:   c.Before + 1,
+				Text:      c.Text,
+				NewText:   c.NewText,
+				FromLevel: c.FromLevel,
+				ToLevel:   c.ToLevel,
+				Parent:    c.ToParent,
+				Nodes:     c.Nodes,
+			})
+		}
+		return nil
+	}
+	for _, c := range d.Changes {
+		fmt.Println(describeChange(c))
+	}
+	return nil
+}
+
+// changeMarks are the signs changes are listed with
+var changeMarks = map[outliner.ChangeKind]string{
+	outliner.ChangeAdded:   "+",
+	outliner.ChangeRemoved: "-",
+	outliner.ChangeEdited:  "~",
+	outliner.ChangeMoved:   ">",
+	outliner.ChangeLevel:   "↔",
+}
+
+// describeChange is a change as one line: its mark, line, kind and nodes
+func describeChange(c outliner.OutlineChange) string {
+	line := c.After + 1
+	if c.Kind == outliner.ChangeRemoved {
+		line = c.Before + 1
+	}
+	text := strings.TrimSpace(c.Text)
+	switch c.Kind {
+	case outliner.ChangeEdited:
+		text += " → " + strings.TrimSpace(c.NewText)
+	case outliner.ChangeMoved:
+		if c.ToParent == "" {
+			text += " → top level"
+		} else {
+			text += " → under " + strings.TrimSpace(c.ToParent)
+		}
+	case outliner.ChangeLevel:
+		text += fmt.Sprintf(" (level %d → %d)", c.FromLevel, c.ToLevel)
+	}
+	if c.Nodes > 1 {
+		text += fmt.Sprintf(" (%d nodes)", c.Nodes)
+	}
+	return fmt.Sprintf("%s %4d  %-7s  %s", changeMarks[c.Kind], line, c.Kind, text)
+}
+
+// diffView is the in-app diff: the active buffer compared with another
+// version of it, and in merge mode the changes picked to take from it
+type diffView struct {
+	title    string
+	other    string // the other version's file, for the merge toast
+	diff     *outliner.OutlineDiff
+	merge    bool
+	take     []bool // in merge mode, by change
+	selected int
+}
+
+// openDiff compares path, the active file on disk by default, with the
+// buffer as it's been edited
+func (a *OutlinerApp) openDiff(path string) error {
+	if path == "" {
+		path = a.filename
+	}
+	if path == "" {
+		return fmt.Errorf("usage: diff <file> (the buffer has no file to compare with)")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	d := outliner.DiffOutlines(string(content), a.outliner.GetContent())
+	if len(d.Changes) == 0 {
+		a.toasts.Push(components.ToastInfo, i18n.T("outliner.toast.no_changes", filepath.Base(path)))
+		return nil
+	}
+	a.diff = &diffView{title: i18n.T("outliner.diff.title", filepath.Base(path)), other: path, diff: d}
+	return nil
+}
+
+// openMerge compares the buffer with path, its recovery file by default,
+// to pick which of path's changes to take
+func (a *OutlinerApp) openMerge(path string) error {
+	if path == "" && a.filename != "" {
+		path = recoveryPath(a.filename)
+	}
+	if path == "" {
+		return fmt.Errorf("usage: merge <file>")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	d := a.outliner.DiffWith(string(content))
+	if len(d.Changes) == 0 {
+		a.toasts.Push(components.ToastInfo, i18n.T("outliner.toast.no_changes", filepath.Base(path)))
+		return nil
+	}
+	a.diff = &diffView{title: i18n.T("outliner.diff.merge_title", filepath.Base(path)), other: path, diff: d, merge: true, take: d.Additive()}
+	return nil
+}
+
+// noticeRecovery says when the file just opened has a recovery file from a
+// crash beside it
+func (a *OutlinerApp) noticeRecovery() {
+	if a.filename == "" {
+		return
+	}
+	if _, err := os.Stat(recoveryPath(a.filename)); err == nil {
+		a.toasts.Push(components.ToastWarn, i18n.T("outliner.toast.recovery_found", filepath.Base(recoveryPath(a.filename))))
+	}
+}
+
+// updateDiff handles keys while the diff view is open
+func (a *OutlinerApp) updateDiff(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := a.diff
+	changes := v.diff.Changes
+	switch msg.String() {
+	case "esc", "q":
+		a.diff = nil
+	case "up", "k":
+		v.selected = max(0, v.selected-1)
+	case "down", "j":
+		v.selected = min(len(changes)-1, v.selected+1)
+	case "pgup":
+		v.selected = max(0, v.selected-max(1, a.height-4))
+	case "pgdown":
+		v.selected = min(len(changes)-1, v.selected+max(1, a.height-4))
+	case " ", "x":
+		if v.merge {
+			v.take[v.selected] = !v.take[v.selected]
+		}
+	case "a":
+		if v.merge {
+			all := !allTaken(v.take)
+			for i := range v.take {
+				v.take[i] = all
+			}
+		}
+	case "enter":
+		a.diff = nil
+		if !v.merge {
+			// The buffer is the new version here
+			if c := changes[v.selected]; c.After >= 0 {
+				a.outliner.JumpToLine(c.After + 1)
+			}
+			return a, nil
+		}
+		if n := a.outliner.ApplyMerge(v.diff, v.take); n > 0 {
+			a.saved = false
+			a.toasts.Push(components.ToastSuccess, i18n.N("outliner.toast.merged", n, filepath.Base(v.other)))
+		}
+	}
+	return a, nil
+}
+
+func allTaken(take []bool) bool {
+	for _, t := range take {
+		if !t {
+			return false
+		}
+	}
+	return true
+}
+
+// renderDiff draws the diff view's title, keys and changes
+func (a *OutlinerApp) renderDiff() string {
+	v := a.diff
+	height := max(1, a.height-4)
+	var b strings.Builder
+
+	b.WriteString(historyTitleStyle.Render(i18n.N("outliner.diff.changes", len(v.diff.Changes), v.title)) + "\n")
+	if v.merge {
+		b.WriteString(historyDimStyle.Render(i18n.T("outliner.diff.merge_help")) + "\n\n")
+	} else {
+		b.WriteString(historyDimStyle.Render(i18n.T("outliner.diff.help")) + "\n\n")
+	}
+
+	start := max(0, v.selected-height+1)
+	end := min(len(v.diff.Changes), start+height)
+	for i := start; i < end; i++ {
+		c := v.diff.Changes[i]
+		line := describeChange(c)
+		if v.merge {
+			box := "[ ] "
+			if v.take[i] {
+				box = "[x] "
+			}
+			line = box + line
+		}
+		line = cells.Cut(line, a.width)
+		switch {
+		case i == v.selected:
+			line = historySelectedStyle.Render(line)
+		case c.Kind == outliner.ChangeAdded:
+			line = diffAddStyle.Render(line)
+		case c.Kind == outliner.ChangeRemoved:
+			line = diffRemoveStyle.Render(line)
+		case c.Kind == outliner.ChangeMoved || c.Kind == outliner.ChangeLevel:
+			line = diffHunkStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffMerge, "merge", false, "Print the old file with the new one's additions, edits and moves applied")
+	diffCmd.Flags().StringVarP(&diffOut, "out", "o", "", "With --merge, write to a file instead of stdout")
+	addJSONFlag(diffCmd)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+)
+
+var docStatsStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("62")).Padding(0, 1)
+
+// docStatsView is the writing stats popup, kept live as the buffer
+// changes; expanded it breaks patterns down by type and words by section
+type docStatsView struct {
+	expanded bool
+}
+
+// updateDocStats handles keys while the writing stats popup is open
+func (a *OutlinerApp) updateDocStats(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		a.words = nil
+	case "tab", "enter", " ":
+		a.words.expanded = !a.words.expanded
+	}
+	return a, nil
+}
+
+// statusWords is the status bar's word count, with the node count and
+// capture ratio too in detail mode
+func (a *OutlinerApp) statusWords() string {
+	stats := a.outliner.DocumentStats(time.Now())
+	if !a.outliner.IsDetailMode() {
+		return i18n.N("outliner.status.words", stats.Words)
+	}
+	return i18n.T("outliner.status.stats", stats.Words, stats.Nodes, int(math.Round(stats.CaptureRatio()*100)))
+}
+
+// renderDocStats draws the writing stats popup in the middle of the
+// screen
+func (a *OutlinerApp) renderDocStats() string {
+	stats := a.outliner.DocumentStats(time.Now())
+	inner := max(20, min(64, a.width-4))
+	var b strings.Builder
+
+	b.WriteString(historyTitleStyle.Render(i18n.T("outliner.docstats.title", bufferName(a.filename))) + "\n\n")
+	b.WriteString(i18n.T("outliner.docstats.counts", stats.Words, stats.Nodes, stats.AddedToday) + "\n")
+	b.WriteString(i18n.T("outliner.docstats.patterns", stats.Matches, stats.Density()) + "\n")
+	if stats.Patterned > 0 {
+		b.WriteString(i18n.T("outliner.docstats.captured", stats.CaptureRatio()*100, stats.Captured, stats.Patterned) + "\n")
+	}
+
+	if a.words.expanded {
+		labelWidth := min(24, inner/2)
+		if types := stats.PatternTypes(); len(types) > 0 {
+			b.WriteString("\n" + historyTitleStyle.Render(i18n.T("outliner.docstats.by_type")) + "\n")
+			for _, patternType := range types {
+				b.WriteString(fmt.Sprintf("  %s %5d\n", cells.Pad(cells.Cut(patternType+"::", labelWidth), labelWidth), stats.Patterns[patternType]))
+			}
+		}
+		if len(stats.Sections) > 0 {
+			b.WriteString("\n" + historyTitleStyle.Render(i18n.T("outliner.docstats.sections")) + "\n")
+			for _, s := range stats.Sections {
+				title := cells.Cut(strings.Repeat("  ", s.Level-1)+s.Title, labelWidth)
+				b.WriteString("  " + i18n.T("outliner.docstats.section", cells.Pad(title, labelWidth), s.Words, s.Nodes) + "\n")
+			}
+		}
+		b.WriteString("\n" + historyDimStyle.Render(i18n.T("outliner.docstats.expanded_help")))
+	} else {
+		b.WriteString("\n" + historyDimStyle.Render(i18n.T("outliner.docstats.help")))
+	}
+
+	box := docStatsStyle.Width(inner + 2).Render(b.String())
+	return lipgloss.Place(a.width, a.height-2, lipgloss.Center, lipgloss.Center, box)
+}
+
+// logDocStats summarizes the buffer's stats in the debug panel
+func (a *OutlinerApp) logDocStats() {
+	a.outliner.AddDebugMessage("STATS", a.outliner.DocumentStats(time.Now()).Summary(), outliner.DebugLevelInfo)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evanschultz/float-rw-client/pkg/cache"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+)
+
+// dispatchDoor is a door that reads from the outliner's dispatch system
+type dispatchDoor interface {
+	SetDispatch(fds *outliner.FloatDispatchSystem)
+}
+
+// reportDoor is a door that lists the active outline's graph report
+type reportDoor interface {
+	SetReport(report outliner.GraphReport)
+}
+
+// openDoor opens a registered door full-screen over the outliner
+func (a *OutlinerApp) openDoor(name string) tea.Cmd {
+	door := a.doors.Create(name)
+	if door == nil {
+		a.toasts.Push(components.ToastWarn, i18n.T("outliner.toast.no_door", name))
+		return nil
+	}
+	if d, ok := door.(dispatchDoor); ok {
+		d.SetDispatch(a.outliner.Dispatch())
+	}
+	if d, ok := door.(calendarDoor); ok {
+		d.SetCalendar(a.outliner.Calendar())
+	}
+	if d, ok := door.(reportDoor); ok {
+		d.SetReport(a.graphReport())
+	}
+	// Doors render at the size they're told, before their first frame
+	door.Update(a.doorSize())
+	door.Activate()
+	a.door = door
+	// The open door hears of captures until it closes
+	a.doorEnd = outliner.Subscribe(a.outliner.Dispatch().Bus(), func(e outliner.PatternsCaptured) {
+		if a.door != nil {
+			a.door.OnConsciousnessCapture(e.Patterns)
+		}
+	})
+	return door.Init(nil)
+}
+
+// doorSize is the space the open door is drawn in, above the status bar
+func (a *OutlinerApp) doorSize() tea.WindowSizeMsg {
+	return tea.WindowSizeMsg{Width: a.width, Height: a.height - 2}
+}
+
+// updateDoor routes keys to the open door; Esc closes it
+func (a *OutlinerApp) updateDoor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "esc" {
+		a.closeDoor()
+		return a, nil
+	}
+	door, cmd := a.door.Update(msg)
+	a.door = door
+	return a, cmd
+}
+
+// closeDoor closes the open door, if any
+func (a *OutlinerApp) closeDoor() {
+	if a.door != nil {
+		a.doorEnd()
+		a.door.Deactivate()
+		a.door = nil
+	}
+}
+
+// promoteSandbox closes the sandbox and writes its definition into the
+// outline after the cursor's node, capturing it
+func (a *OutlinerApp) promoteSandbox(msg outliner.SandboxPromoteMsg) {
+	a.closeDoor()
+	if a.outliner.PromoteDefinitions(msg.Nodes) == 0 {
+		return
+	}
+	a.saved = false
+	a.toasts.Push(components.ToastSuccess, i18n.T("outliner.toast.promoted", msg.Name))
+}
+
+// graphReport is the active outline's graph report. A bridge-id alone in
+// this file isn't lone when the bridge registry has its other end elsewhere.
+func (a *OutlinerApp) graphReport() outliner.GraphReport {
+	report := a.outliner.GraphReport()
+	if a.bridges == nil {
+		return report
+	}
+	lone := report.LoneBridges[:0]
+	for _, entry := range report.LoneBridges {
+		if b, ok := a.bridges.Get(entry.Label); ok && len(b.Ends) > 1 {
+			continue
+		}
+		lone = append(lone, entry)
+	}
+	report.LoneBridges = lone
+	return report
+}
+
+// jumpFromGraph closes the graph report and moves the cursor to the node
+// it picked
+func (a *OutlinerApp) jumpFromGraph(msg outliner.GraphJumpMsg) {
+	a.closeDoor()
+	a.outliner.JumpToNode(msg.NodeID)
+}
+
+// doorPluginDir is where door plugins are found: ~/.config/float-line/doors
+func doorPluginDir() string {
+	return filepath.Join(config.Dir(), "doors")
+}
+
+// registerDoorPlugins registers the door plugins in dir, each working in
+// its own directory under the cache
+func (a *OutlinerApp) registerDoorPlugins(dir string) {
+	names, err := a.doors.RegisterPlugins(dir, filepath.Join(cache.Dir(), "doors"), outliner.DefaultPluginTimeout)
+	if err != nil {
+		slog.Warn("door plugins", "dir", dir, "err", err)
+	}
+	if len(names) > 0 {
+		slog.Info("door plugins registered", "dir", dir, "doors", names)
+	}
+}
+
+// openReplay opens the replay of this file's captures, at a time typed as
+// at or the latest capture
+func (a *OutlinerApp) openReplay(at string) error {
+	replay := a.outliner.NewReplay(a.filename)
+	if at != "" {
+		t, err := outliner.ParseReplayTime(at, time.Now())
+		if err != nil {
+			return err
+		}
+		replay.Seek(t)
+	}
+	replay.Activate()
+	a.door = replay
+	return nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+	"github.com/spf13/cobra"
+)
+
+// exportDir is where relative [output::] paths resolve: beside the outline
+func (a *OutlinerApp) exportDir() string {
+	if a.filename == "" {
+		return "."
+	}
+	return filepath.Dir(a.filename)
+}
+
+// exportSelector writes the selector under the cursor to its artifact file
+func (a *OutlinerApp) exportSelector() {
+	path, err := a.outliner.ExportSelectorAtCursor(a.exportDir())
+	if err != nil {
+		a.toasts.PushError(err)
+		return
+	}
+	a.saved = false // the node may have gained an [output::] annotation
+	a.toasts.Push(components.ToastSuccess, i18n.T("outliner.toast.exported", path))
+}
+
+// reexportSelectors refreshes artifact files whose selector output changed,
+// when selector watching is on
+func (a *OutlinerApp) reexportSelectors() {
+	if !a.watchSelectors {
+		return
+	}
+	written, err := a.outliner.ExportSelectors(a.exportDir(), true)
+	if err != nil {
+		a.toasts.PushError(err)
+		return
+	}
+	if len(written) > 0 {
+		a.toasts.Push(components.ToastSuccess, i18n.N("outliner.toast.reexported", len(written)))
+	}
+}
+
+// writeReducerResults regenerates the results files of reducers with an
+// [output::] annotation whose collected actions changed
+func (a *OutlinerApp) writeReducerResults() {
+	written, err := a.outliner.ExportReducers(a.exportDir())
+	if err != nil {
+		a.toasts.PushError(err)
+		return
+	}
+	if len(written) > 0 {
+		slog.Info("reducer results written", "files", written)
+	}
+}
+
+// toggleSelectorWatch turns automatic re-export on or off
+func (a *OutlinerApp) toggleSelectorWatch() {
+	a.watchSelectors = !a.watchSelectors
+	if a.watchSelectors {
+		a.toasts.Push(components.ToastInfo, i18n.T("outliner.toast.exports_on_save"))
+		a.reexportSelectors()
+	} else {
+		a.toasts.Push(components.ToastInfo, i18n.T("outliner.toast.exports_off"))
+	}
+}
+
+const formatHTML = "html"
+
+var (
+	exportFormat string
+	exportOut    string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <file>",
+	Short: "Export an outline as a standalone HTML page",
+	Long: `Export renders an outline for reading outside the terminal. The HTML page
+is a collapsible tree in the theme's pattern colors, with imprint badges,
+[[links]] to a concept index, and the nodes' metadata embedded as JSON in
+<script id="float-metadata"> for downstream tooling. Private and archived
+subtrees are left out. --format markdown and opml work as in convert;
+--json prints one object per node with the same fields as the embedded JSON.`,
+	Example: `  float-outliner export notes.md > notes.html
+  float-outliner export notes.md --format html --out site/notes.html
+  float-outliner export notes.md --json | jq -r 'select(.pattern == "decision") | .text'`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExport,
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	content, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("read %s: %w", args[0], err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	// Imprints and colors as configured, for the badges
+	o := outliner.New()
+	applyDispatchConfig(o.Evna(), o.Dispatch(), cfg)
+	o.Evna().SetEnabled(false) // exporting shouldn't dispatch anything
+	o.SetTheme(themeFromConfig(cfg))
+	o.SetContent(string(content))
+
+	format, err := outputFormat(cmd, exportFormat)
+	if err != nil {
+		return err
+	}
+
+	var out string
+	if format == formatNDJSON {
+		out, err = nodeLines(o)
+	} else if format == formatHTML {
+		out, err = o.GetHTML(outlineTitle(args[0]))
+	} else if format, ferr := resolveFormat(format, exportOut); ferr != nil {
+		return fmt.Errorf("unknown format %q: use html, markdown, opml or ndjson", format)
+	} else {
+		out, err = renderContent(o, format, args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	if exportOut == "" {
+		fmt.Print(out)
+		return nil
+	}
+	return os.WriteFile(exportOut, []byte(out), 0644)
+}
+
+// nodeRecord is the NDJSON shape of one exported node
+type nodeRecord struct {
+	Kind string `json:"kind"` // "node"
+	outliner.NodeRecord
+}
+
+// nodeLines renders the outline's nodes as NDJSON, one per line
+func nodeLines(o outliner.Outliner) (string, error) {
+	var b strings.Builder
+	out := json.NewEncoder(&b)
+	for _, record := range o.NodeRecords() {
+		if err := out.Encode(nodeRecord{Kind: "node", NodeRecord: record}); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// outlineTitle is an outline's title: its file name without extension
+func outlineTitle(filename string) string {
+	if filename == "" {
+		return "untitled"
+	}
+	return strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+}
+
+// exportHTML writes the outline as HTML beside it, or to path
+func (a *OutlinerApp) exportHTML(path string) error {
+	if path == "" {
+		if a.filename == "" {
+			return fmt.Errorf("usage: export html <path> (the outline has no file yet)")
+		}
+		path = strings.TrimSuffix(a.filename, filepath.Ext(a.filename)) + ".html"
+	} else if !filepath.IsAbs(path) {
+		path = filepath.Join(a.exportDir(), path)
+	}
+	out, err := a.outliner.GetHTML(outlineTitle(a.filename))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(out), 0644); err != nil {
+		return fmt.Errorf("export %s: %w", path, err)
+	}
+	a.toasts.Push(components.ToastSuccess, i18n.T("outliner.toast.exported", path))
+	return nil
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormat, "format", formatHTML, "Output format: html, markdown, opml or ndjson")
+	addJSONFlag(exportCmd)
+	exportCmd.Flags().StringVarP(&exportOut, "out", "o", "", "Write to a file instead of stdout")
+}
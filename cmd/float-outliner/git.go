@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/evanschultz/float-rw-client/pkg/gitrepo"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+)
+
+// refreshGit finds the repository for the active file and reads its status
+func (a *OutlinerApp) refreshGit() {
+	path := a.filename
+	if path == "" {
+		path = "."
+	}
+
+	repo, err := gitrepo.Find(path)
+	if err != nil {
+		a.repo = nil
+		return
+	}
+	a.repo = repo
+	a.refreshGitStatus()
+}
+
+// refreshGitStatus rereads the repo's status; a failed read keeps the last
+func (a *OutlinerApp) refreshGitStatus() {
+	status, err := a.repo.Status()
+	if err != nil {
+		slog.Debug("git status", "repo", a.repo.Root, "err", err)
+		return
+	}
+	a.gitStatus = status
+}
+
+// commitSnapshot commits the saved file with a summary of the patterns it
+// gained or lost since the last commit
+func (a *OutlinerApp) commitSnapshot(previous, current string) {
+	if a.repo == nil || !a.autoCommit {
+		return
+	}
+
+	message := fmt.Sprintf("%s: %s", filepath.Base(a.filename), summarizePatternChanges(previous, current))
+	if _, err := a.repo.CommitFile(a.filename, message); err != nil {
+		slog.Error("commit failed", "file", a.filename, "err", err)
+		a.toasts.PushError(fmt.Errorf("commit %s: %w", filepath.Base(a.filename), err))
+		return
+	}
+	slog.Info("committed", "file", a.filename, "message", message)
+}
+
+// summarizePatternChanges describes pattern count changes, e.g.
+// "+2 eureka, +1 decision"
+func summarizePatternChanges(previous, current string) string {
+	before := countPatterns(previous)
+	after := countPatterns(current)
+
+	type change struct {
+		pattern string
+		delta   int
+	}
+	var changes []change
+	for pattern, n := range after {
+		if d := n - before[pattern]; d != 0 {
+			changes = append(changes, change{pattern, d})
+		}
+	}
+	for pattern, n := range before {
+		if _, ok := after[pattern]; !ok {
+			changes = append(changes, change{pattern, -n})
+		}
+	}
+	if len(changes) == 0 {
+		return "update outline"
+	}
+
+	// Additions first, biggest first
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].delta != changes[j].delta {
+			return changes[i].delta > changes[j].delta
+		}
+		return changes[i].pattern < changes[j].pattern
+	})
+
+	parts := make([]string, len(changes))
+	for i, c := range changes {
+		parts[i] = fmt.Sprintf("%+d %s", c.delta, c.pattern)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func countPatterns(content string) map[string]int {
+	counts := make(map[string]int)
+	if content == "" {
+		return counts
+	}
+	for _, pattern := range outliner.NewParser().Parse(content).ConsciousnessData {
+		counts[pattern.Type]++
+	}
+	return counts
+}
+
+// gitStatusText shows branch and dirty state for the status bar
+func (a *OutlinerApp) gitStatusText() string {
+	if a.repo == nil || a.gitStatus.Branch == "" {
+		return ""
+	}
+	if a.gitStatus.Dirty {
+		return fmt.Sprintf(" [%s*]", a.gitStatus.Branch)
+	}
+	return fmt.Sprintf(" [%s]", a.gitStatus.Branch)
+}
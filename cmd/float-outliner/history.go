@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/evanschultz/float-rw-client/pkg/gitrepo"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+)
+
+// historyLimit caps how many commits the history browser lists
+const historyLimit = 100
+
+var (
+	historyTitleStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("62"))
+	historySelectedStyle = lipgloss.NewStyle().Background(lipgloss.Color("237"))
+	historyDimStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	diffAddStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	diffRemoveStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	diffHunkStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+)
+
+// historyBrowser lists past versions of the active file and shows the diff
+// each commit made
+type historyBrowser struct {
+	commits  []gitrepo.Commit
+	selected int
+	diff     []string // lines of the open diff; nil while listing
+	offset   int      // first visible diff line
+	err      error
+}
+
+// openHistory loads the active file's history
+func (a *OutlinerApp) openHistory() {
+	if a.repo == nil || a.filename == "" {
+		return
+	}
+	commits, err := a.repo.Log(a.filename, historyLimit)
+	a.history = &historyBrowser{commits: commits, err: err}
+}
+
+// updateHistory handles keys while the history browser is open
+func (a *OutlinerApp) updateHistory(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	h := a.history
+	page := max(1, a.height-4)
+
+	if h.diff != nil {
+		switch msg.String() {
+		case "esc", "q", "left":
+			h.diff = nil
+		case "up", "k":
+			h.offset = max(0, h.offset-1)
+		case "down", "j":
+			h.offset = min(max(0, len(h.diff)-page), h.offset+1)
+		case "pgup":
+			h.offset = max(0, h.offset-page)
+		case "pgdown", " ":
+			h.offset = min(max(0, len(h.diff)-page), h.offset+page)
+		}
+		return a, nil
+	}
+
+	switch msg.String() {
+	case "esc", "q", "alt+h":
+		a.history = nil
+	case "up", "k":
+		h.selected = max(0, h.selected-1)
+	case "down", "j":
+		h.selected = min(max(0, len(h.commits)-1), h.selected+1)
+	case "enter", "right":
+		if h.selected < len(h.commits) {
+			diff, err := a.repo.Diff(h.commits[h.selected].Hash, a.filename)
+			if err != nil {
+				h.err = err
+				return a, nil
+			}
+			h.diff = strings.Split(strings.TrimRight(diff, "\n"), "\n")
+			h.offset = 0
+		}
+	}
+	return a, nil
+}
+
+// renderHistory draws the commit list or the open diff
+func (a *OutlinerApp) renderHistory() string {
+	h := a.history
+	height := max(1, a.height-4)
+	var b strings.Builder
+
+	if h.diff != nil {
+		c := h.commits[h.selected]
+		b.WriteString(historyTitleStyle.Render(fmt.Sprintf("%s  %s", c.Hash[:8], c.Subject)) + "\n")
+		b.WriteString(historyDimStyle.Render(i18n.T("outliner.history.diff_help")) + "\n\n")
+
+		end := min(len(h.diff), h.offset+height)
+		for _, line := range h.diff[h.offset:end] {
+			b.WriteString(styleDiffLine(line) + "\n")
+		}
+		return b.String()
+	}
+
+	b.WriteString(historyTitleStyle.Render(i18n.T("outliner.history.title", a.filename)) + "\n")
+	b.WriteString(historyDimStyle.Render(i18n.T("outliner.history.help")) + "\n\n")
+
+	if h.err != nil {
+		b.WriteString(diffRemoveStyle.Render(h.err.Error()) + "\n")
+	}
+	if len(h.commits) == 0 && h.err == nil {
+		b.WriteString(historyDimStyle.Render(i18n.T("outliner.history.none")) + "\n")
+	}
+
+	start := max(0, h.selected-height+1)
+	end := min(len(h.commits), start+height)
+	for i := start; i < end; i++ {
+		c := h.commits[i]
+		line := fmt.Sprintf(" %s  %s  %s", c.Hash[:8], c.Time.Format("2006-01-02 15:04"), c.Subject)
+		if i == h.selected {
+			line = historySelectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+func styleDiffLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		return historyDimStyle.Render(line)
+	case strings.HasPrefix(line, "+"):
+		return diffAddStyle.Render(line)
+	case strings.HasPrefix(line, "-"):
+		return diffRemoveStyle.Render(line)
+	case strings.HasPrefix(line, "@@"):
+		return diffHunkStyle.Render(line)
+	}
+	return line
+}
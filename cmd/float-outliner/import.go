@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importFrom   string
+	importFormat string
+	importOut    string
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import --from <roam|workflowy|dynalist> <file>",
+	Short: "Import an outline exported from Roam, Workflowy or Dynalist",
+	Long: `Import reads another outliner's export and writes it as an outline in
+--format. Blocks keep their nesting, and their IDs and timestamps where the
+export has them (OPML output preserves both):
+
+  roam       JSON or EDN export. Pages become headings with their blocks
+             under them; attribute blocks ("Status:: done") become [status:: done]
+             annotations on their parent unless they name a pattern type
+             (eureka::, decision::, ...); {{[[TODO]]}}/{{[[DONE]]}} become
+             [ ]/[x] tasks; ((block refs)) become mirrors or [[#^id]] links;
+             daily note pages get a [date:: ...].
+  workflowy  OPML export or JSON backup. Completed items become [x] tasks,
+             notes note:: lines, formatting markdown and dates [date:: ...].
+  dynalist   OPML export or the API's document JSON. Checkboxes become tasks,
+             headings headings, notes note:: lines and !(dates) [date:: ...].
+
+#tags and [[links]] are kept as they are.`,
+	Example: `  float-outliner import --from roam roam-export.json --out notes.md
+  float-outliner import --from workflowy workflowy.opml > notes.md
+  float-outliner import --from dynalist doc.json --format opml --out doc.opml`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	format, err := resolveFormat(importFormat, importOut)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("read %s: %w", args[0], err)
+	}
+
+	o := outliner.New()
+	o.Evna().SetEnabled(false) // importing shouldn't dispatch anything
+	if err := o.Import(importFrom, data); err != nil {
+		return fmt.Errorf("import %s: %w", args[0], err)
+	}
+
+	out, err := renderContent(o, format, args[0])
+	if err != nil {
+		return err
+	}
+
+	if importOut == "" {
+		fmt.Print(out)
+		return nil
+	}
+	return os.WriteFile(importOut, []byte(out), 0644)
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importFrom, "from", "", "Outliner the export is from: "+strings.Join(outliner.ImportFormats, ", "))
+	importCmd.Flags().StringVar(&importFormat, "format", "", "Output format: markdown or opml (default from --out, else markdown)")
+	importCmd.Flags().StringVarP(&importOut, "out", "o", "", "Write to a file instead of stdout")
+	importCmd.MarkFlagRequired("from")
+}
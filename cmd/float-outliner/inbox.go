@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+)
+
+// inboxView processes the capture inbox, quick's and capture-popup's
+// file: each top-level node is refiled, converted to a pattern or
+// archived, and the inbox saved after each
+type inboxView struct {
+	buffer   int // the inbox's
+	items    []outliner.InboxItem
+	selected int
+	picker   *inboxPicker
+}
+
+// inboxPicker is the fuzzy list a refile target or a pattern type is
+// picked from
+type inboxPicker struct {
+	convert  bool // picking a pattern type
+	input    string
+	choices  []inboxChoice
+	selected int
+}
+
+// inboxChoice is a refile target, a file or a node in one, or a pattern
+// type
+type inboxChoice struct {
+	label     string
+	context   string
+	path      string
+	node      int // -1 for the top level of path
+	score     int
+	positions []int
+}
+
+// openInbox opens the inbox file in a buffer with the processing view over
+// it
+func (a *OutlinerApp) openInbox() {
+	cfg := a.cfg
+	if cfg == nil {
+		cfg = config.Default()
+	}
+	a.openBuffer(config.ExpandHome(inboxPath(cfg)))
+	a.inbox = &inboxView{buffer: a.current}
+	a.refreshInbox()
+	if len(a.inbox.items) == 0 {
+		a.inbox = nil
+		a.toasts.Push(components.ToastInfo, i18n.T("outliner.toast.inbox_empty", bufferName(a.filename)))
+	}
+}
+
+// refreshInbox relists the inbox's items, keeping the selection in range
+func (a *OutlinerApp) refreshInbox() {
+	v := a.inbox
+	v.items = a.outliner.InboxItems()
+	v.selected = max(0, min(v.selected, len(v.items)-1))
+}
+
+// searchInboxPicker reruns the picker's search for its input
+func (a *OutlinerApp) searchInboxPicker() {
+	p := a.inbox.picker
+	p.choices, p.selected = nil, 0
+	add := func(c inboxChoice) {
+		if score, positions, ok := outliner.FuzzyMatch(p.input, c.label); ok {
+			c.score, c.positions = score, positions
+			p.choices = append(p.choices, c)
+		}
+	}
+
+	if p.convert {
+		for _, patternType := range outliner.Patterns.Types() {
+			add(inboxChoice{label: patternType + "::", path: patternType})
+		}
+	} else {
+		for _, path := range a.refileFiles() {
+			add(inboxChoice{label: bufferName(path), context: i18n.T("outliner.inbox.top_level"), path: path, node: -1})
+		}
+		for i, b := range a.buffers {
+			if i == a.inbox.buffer || b.filename == "" {
+				continue
+			}
+			for _, t := range b.outliner.JumpTargets(p.input) {
+				if t.Kind == "node" {
+					p.choices = append(p.choices, inboxChoice{
+						label: t.Label, context: bufferName(b.filename) + ": " + t.Context,
+						path: b.filename, node: t.Node, score: t.Score, positions: t.Positions,
+					})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(p.choices, func(x, y int) bool { return p.choices[x].score > p.choices[y].score })
+	if len(p.choices) > jumpLimit {
+		p.choices = p.choices[:jumpLimit]
+	}
+}
+
+// refileFiles are the files items can be refiled into: the open buffers,
+// today's note once it exists and the markdown files in the working directory
+func (a *OutlinerApp) refileFiles() []string {
+	var files []string
+	seen := func(path string) bool {
+		for _, f := range files {
+			if sameFile(f, path) {
+				return true
+			}
+		}
+		return sameFile(path, a.buffers[a.inbox.buffer].filename)
+	}
+	for _, b := range a.buffers {
+		if b.filename != "" && !seen(b.filename) {
+			files = append(files, b.filename)
+		}
+	}
+	if a.cfg != nil {
+		if today := dailyNotePath(a.cfg, a.vault, time.Now()); !seen(today) {
+			if _, err := os.Stat(today); err == nil {
+				files = append(files, today)
+			}
+		}
+	}
+	local, _ := filepath.Glob("*.md")
+	for _, path := range local {
+		if !seen(path) {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// updateInbox handles keys while the inbox view is open
+func (a *OutlinerApp) updateInbox(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := a.inbox
+	if v.picker != nil {
+		return a.updateInboxPicker(msg)
+	}
+	switch msg.String() {
+	case "esc", "q":
+		a.inbox = nil
+	case "up", "k":
+		v.selected = max(0, v.selected-1)
+	case "down", "j":
+		v.selected = min(len(v.items)-1, v.selected+1)
+	case "enter":
+		// Go to the item to edit it
+		a.inbox = nil
+		a.outliner.SetCursor(v.items[v.selected].Node)
+	case "r":
+		v.picker = &inboxPicker{}
+		a.searchInboxPicker()
+	case "t":
+		v.picker = &inboxPicker{convert: true}
+		a.searchInboxPicker()
+	case "a":
+		a.outliner.SetCursor(v.items[v.selected].Node)
+		a.archiveAtCursor()
+		a.processedInbox()
+	case "u":
+		if a.outliner.Undo() {
+			a.processedInbox()
+		}
+	}
+	return a, nil
+}
+
+// updateInboxPicker handles keys while a refile target or pattern type is
+// being picked
+func (a *OutlinerApp) updateInboxPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := a.inbox
+	p := v.picker
+	switch msg.String() {
+	case "esc":
+		v.picker = nil
+	case "up", "ctrl+p":
+		p.selected = max(0, p.selected-1)
+	case "down", "ctrl+n":
+		p.selected = min(max(0, len(p.choices)-1), p.selected+1)
+	case "enter":
+		v.picker = nil
+		if p.selected >= len(p.choices) {
+			return a, nil
+		}
+		choice := p.choices[p.selected]
+		if p.convert {
+			if err := a.outliner.SetPatternType(v.items[v.selected].Node, choice.path); err != nil {
+				a.toasts.PushError(err)
+				return a, nil
+			}
+			a.processedInbox()
+			return a, nil
+		}
+		if err := a.refile(v.items[v.selected].Node, choice); err != nil {
+			a.toasts.PushError(err)
+			return a, nil
+		}
+		a.toasts.Push(components.ToastSuccess, i18n.T("outliner.toast.refiled", bufferName(choice.path)))
+	case "backspace":
+		if runes := []rune(p.input); len(runes) > 0 {
+			p.input = string(runes[:len(runes)-1])
+			a.searchInboxPicker()
+		}
+	default:
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			p.input += string(msg.Runes)
+			if msg.Type == tea.KeySpace {
+				p.input += " "
+			}
+			a.searchInboxPicker()
+		}
+	}
+	return a, nil
+}
+
+// refile moves inbox node i's subtree under the choice's node, opening its
+// file if it isn't, and saves both files
+func (a *OutlinerApp) refile(i int, choice inboxChoice) error {
+	if dir := filepath.Dir(choice.path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	subtree, err := a.outliner.TakeSubtree(i)
+	if err != nil {
+		return err
+	}
+
+	inbox, previous := a.inbox.buffer, a.previous
+	a.openBuffer(choice.path)
+	if err := a.outliner.RefileSubtree(subtree, choice.node); err != nil {
+		a.switchBuffer(inbox)
+		a.outliner.Undo() // put the item back
+		a.previous = previous
+		return fmt.Errorf("refile to %s: %w", bufferName(choice.path), err)
+	}
+	a.saveFile()
+	a.switchBuffer(inbox)
+	a.previous = previous
+	a.processedInbox()
+	return nil
+}
+
+// processedInbox saves the inbox after an item was processed and relists
+// what's left, closing the view once it's empty
+func (a *OutlinerApp) processedInbox() {
+	a.saveFile()
+	a.refreshInbox()
+	if len(a.inbox.items) == 0 {
+		a.inbox = nil
+		a.toasts.Push(components.ToastSuccess, i18n.T("outliner.toast.inbox_empty", bufferName(a.filename)))
+	}
+}
+
+// renderInbox draws the inbox's items, or the picker over them
+func (a *OutlinerApp) renderInbox() string {
+	v := a.inbox
+	height := max(1, a.height-5)
+	var b strings.Builder
+
+	if p := v.picker; p != nil {
+		title := i18n.T("outliner.inbox.refile", p.input+"│")
+		if p.convert {
+			title = i18n.T("outliner.inbox.convert", p.input+"│")
+		}
+		b.WriteString(historyTitleStyle.Render(title) + "\n")
+		b.WriteString(historyDimStyle.Render(cells.Cut(v.items[v.selected].Text, a.width)) + "\n\n")
+		if len(p.choices) == 0 {
+			b.WriteString(historyDimStyle.Render(i18n.T("outliner.inbox.none")) + "\n")
+			return b.String()
+		}
+		start := max(0, p.selected-height+1)
+		end := min(len(p.choices), start+height)
+		for i := start; i < end; i++ {
+			c := p.choices[i]
+			line := cells.Cut(" "+highlightMatches(c.label, c.positions)+"  "+historyDimStyle.Render(c.context), a.width)
+			if i == p.selected {
+				line = historySelectedStyle.Render(line)
+			}
+			b.WriteString(line + "\n")
+		}
+		return b.String()
+	}
+
+	b.WriteString(historyTitleStyle.Render(i18n.N("outliner.inbox.title", len(v.items), bufferName(a.filename))) + "\n")
+	b.WriteString(historyDimStyle.Render(i18n.T("outliner.inbox.help")) + "\n\n")
+	start := max(0, v.selected-height+1)
+	end := min(len(v.items), start+height)
+	for i := start; i < end; i++ {
+		item := v.items[i]
+		var meta []string
+		if item.PatternType != "" {
+			meta = append(meta, item.PatternType+"::")
+		}
+		if item.Nodes > 1 {
+			meta = append(meta, i18n.N("outliner.inbox.nodes", item.Nodes))
+		}
+		line := cells.Cut(" "+item.Text+"  "+historyDimStyle.Render(strings.Join(meta, " • ")), a.width)
+		if i == v.selected {
+			line = historySelectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+)
+
+// jumpLimit caps how many matches the navigator lists
+const jumpLimit = 200
+
+var jumpMatchStyle = lipgloss.NewStyle().Bold(true).Underline(true)
+
+// jumper is the Ctrl+J navigator: a fuzzy search over every open buffer's
+// nodes, [[concepts]] and pattern types, and the buffers themselves
+type jumper struct {
+	input    string
+	results  []jumpResult
+	selected int
+}
+
+// jumpResult is a target in buffer; buffer targets have no node
+type jumpResult struct {
+	buffer int
+	target outliner.JumpTarget
+}
+
+// openJump opens the navigator listing everything
+func (a *OutlinerApp) openJump() {
+	a.jump = &jumper{}
+	a.searchJump()
+}
+
+// searchJump reruns the search for the navigator's input, the active
+// buffer's matches first on equal scores
+func (a *OutlinerApp) searchJump() {
+	j := a.jump
+	j.results, j.selected = nil, 0
+
+	for _, target := range a.outliner.JumpTargets(j.input) {
+		j.results = append(j.results, jumpResult{buffer: a.current, target: target})
+	}
+	for i, b := range a.buffers {
+		if i == a.current {
+			continue
+		}
+		name := bufferName(b.filename)
+		for _, target := range b.outliner.JumpTargets(j.input) {
+			target.Context = name + ": " + target.Context
+			j.results = append(j.results, jumpResult{buffer: i, target: target})
+		}
+		if score, positions, ok := outliner.FuzzyMatch(j.input, name); ok {
+			j.results = append(j.results, jumpResult{buffer: i, target: outliner.JumpTarget{
+				Kind: "buffer", Node: -1, Label: name, Context: b.filename, Score: score, Positions: positions,
+			}})
+		}
+	}
+
+	sort.SliceStable(j.results, func(x, y int) bool {
+		return j.results[x].target.Score > j.results[y].target.Score
+	})
+	if len(j.results) > jumpLimit {
+		j.results = j.results[:jumpLimit]
+	}
+}
+
+// bufferName is how the navigator shows a buffer's file
+func bufferName(filename string) string {
+	if filename == "" {
+		return "[untitled]"
+	}
+	return filepath.Base(filename)
+}
+
+// updateJump handles keys while the navigator is open
+func (a *OutlinerApp) updateJump(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	j := a.jump
+	switch msg.String() {
+	case "esc", "ctrl+j":
+		a.jump = nil
+	case "up", "ctrl+p":
+		j.selected = max(0, j.selected-1)
+	case "down", "ctrl+n":
+		j.selected = min(max(0, len(j.results)-1), j.selected+1)
+	case "enter":
+		a.jump = nil
+		if j.selected < len(j.results) {
+			a.jumpTo(j.results[j.selected])
+		}
+	case "backspace":
+		if runes := []rune(j.input); len(runes) > 0 {
+			j.input = string(runes[:len(runes)-1])
+			a.searchJump()
+		}
+	default:
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			j.input += string(msg.Runes)
+			if msg.Type == tea.KeySpace {
+				j.input += " "
+			}
+			a.searchJump()
+		}
+	}
+	return a, nil
+}
+
+// jumpTo switches to the result's buffer and moves the cursor to its node
+func (a *OutlinerApp) jumpTo(r jumpResult) {
+	if r.buffer != a.current && len(a.buffers) > 0 {
+		a.switchBuffer(r.buffer)
+	}
+	if r.target.Node >= 0 {
+		a.outliner.SetCursor(r.target.Node)
+	}
+}
+
+// renderJump draws the navigator's prompt and matches around the selection
+func (a *OutlinerApp) renderJump() string {
+	j := a.jump
+	height := max(1, a.height-5)
+	var b strings.Builder
+
+	b.WriteString(historyTitleStyle.Render(i18n.T("outliner.jump.title", j.input+"│")) + "\n")
+	b.WriteString(historyDimStyle.Render(i18n.T("outliner.jump.help")) + "\n\n")
+	if len(j.results) == 0 {
+		b.WriteString(historyDimStyle.Render(i18n.T("outliner.jump.none")) + "\n")
+		return b.String()
+	}
+
+	start := max(0, j.selected-height+1)
+	end := min(len(j.results), start+height)
+	for i := start; i < end; i++ {
+		t := j.results[i].target
+		line := cells.Cut(" "+historyDimStyle.Render(cells.Pad(t.Kind, 8))+highlightMatches(t.Label, t.Positions)+"  "+historyDimStyle.Render(t.Context), a.width)
+		if i == j.selected {
+			line = historySelectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// highlightMatches marks the runes of text at positions
+func highlightMatches(text string, positions []int) string {
+	matched := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		matched[p] = true
+	}
+	var b strings.Builder
+	for i, r := range []rune(text) {
+		if matched[i] {
+			b.WriteString(jumpMatchStyle.Render(string(r)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
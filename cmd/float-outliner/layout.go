@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/evanschultz/float-rw-client/pkg/cache"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+)
+
+// outlinerLayout is a named arrangement of the outliner's panels that
+// Alt+G and the palette's layout command switch between
+type outlinerLayout struct {
+	name        string
+	debug       bool    // the debug panel is shown
+	debugRatio  float64 // its share of the height; 0 keeps the one resized with ctrl+↑/↓
+	diagnostics bool    // the diagnostics panel is shown
+}
+
+// outlinerLayouts are the presets in the order Alt+G cycles them; the
+// first is the custom layout ctrl+↑/↓ resize
+var outlinerLayouts = []outlinerLayout{
+	{name: "custom", debug: true},
+	{name: "writing"},
+	{name: "monitor", debug: true, debugRatio: 0.5, diagnostics: true},
+}
+
+// layoutNames lists the presets for messages
+func layoutNames() string {
+	names := make([]string, len(outlinerLayouts))
+	for i, preset := range outlinerLayouts {
+		names[i] = preset.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// debugRatio is the debug panel's share of the height in the layout in use
+func (a *OutlinerApp) debugRatio() float64 {
+	if ratio := outlinerLayouts[a.layout].debugRatio; ratio > 0 {
+		return ratio
+	}
+	return a.session.DebugRatio
+}
+
+// arrange lays an outliner's panels out by the preset in use
+func (a *OutlinerApp) arrange(o *outliner.Outliner) {
+	preset := outlinerLayouts[a.layout]
+	o.SetDebugVisible(preset.debug)
+	o.SetDiagnosticsVisible(preset.diagnostics)
+	o.SetDebugRatio(a.debugRatio())
+}
+
+// setLayout switches every buffer to the named preset, or the next one
+// when name is empty, and keeps it in the session
+func (a *OutlinerApp) setLayout(name string) error {
+	next := (a.layout + 1) % len(outlinerLayouts)
+	if name != "" {
+		next = -1
+		for i, preset := range outlinerLayouts {
+			if preset.name == name {
+				next = i
+			}
+		}
+		if next < 0 {
+			return fmt.Errorf("no layout %q (%s)", name, layoutNames())
+		}
+	}
+
+	a.layout = next
+	a.arrange(&a.outliner)
+	for i := range a.buffers {
+		if i != a.current {
+			a.arrange(&a.buffers[i].outliner)
+		}
+	}
+	a.saveLayout()
+	a.toasts.Push(components.ToastInfo, i18n.T("outliner.toast.layout", outlinerLayouts[a.layout].name))
+	return nil
+}
+
+// keepLayout saves the debug panel's size to the session once it's been
+// resized with ctrl+↑/↓, which makes the layout the custom one
+func (a *OutlinerApp) keepLayout() {
+	if a.outliner.DebugRatio() == a.debugRatio() {
+		return
+	}
+	a.layout = 0
+	a.saveLayout()
+}
+
+// saveLayout keeps the preset in use and the debug panel's size in the
+// session
+func (a *OutlinerApp) saveLayout() {
+	ratio := a.session.DebugRatio
+	if a.layout == 0 {
+		ratio = a.outliner.DebugRatio()
+	}
+	name := ""
+	if a.layout > 0 {
+		name = outlinerLayouts[a.layout].name
+	}
+	err := a.session.Update(func(s *cache.Session) {
+		s.DebugRatio, s.OutlinerLayout = ratio, name
+	})
+	if err != nil {
+		slog.Warn("session not saved", "err", err)
+	}
+}
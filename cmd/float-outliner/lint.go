@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes for `lint`, so CI can tell findings from broken invocations
+const (
+	lintExitIssues = 1
+	lintExitError  = 2
+)
+
+var (
+	lintMinSeverity string
+	lintFailOn      string
+	lintFormat      string
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint <files...>",
+	Short: "Check outline files for annotation problems",
+	Long: `Lint runs the outline linter over each file and prints one issue per line as
+file:line: severity: message [type].
+
+Exit status is 0 when no issue reaches --fail-on, 1 when one does, and 2 when
+a file can't be read or a flag is invalid.`,
+	Example: `  float-outliner lint notes/*.md
+  float-outliner lint --fail-on warning --severity warning notes/*.md
+  float-outliner lint --format json today.md
+  float-outliner lint --json notes/*.md | jq -r 'select(.severity == "error") | .file'`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runLint,
+}
+
+// lintRecord is the JSON shape of one issue
+type lintRecord struct {
+	Kind     string `json:"kind"` // "issue"
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Type     string `json:"type"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func runLint(cmd *cobra.Command, args []string) {
+	for _, severity := range []string{lintMinSeverity, lintFailOn} {
+		if outliner.SeverityRank(severity) == 0 {
+			fmt.Fprintf(os.Stderr, "unknown severity %q: use error, warning, or info\n", severity)
+			os.Exit(lintExitError)
+		}
+	}
+	format, err := outputFormat(cmd, lintFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(lintExitError)
+	}
+	if format != "text" && format != "json" && format != formatNDJSON {
+		fmt.Fprintf(os.Stderr, "unknown --format %q: use text, json or ndjson\n", format)
+		os.Exit(lintExitError)
+	}
+
+	parser := outliner.NewParser()
+	records := []lintRecord{}
+	failing := false
+
+	for _, path := range args {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "read %s: %v\n", path, err)
+			os.Exit(lintExitError)
+		}
+
+		for _, issue := range parser.Lint(string(content)) {
+			if outliner.SeverityRank(issue.Severity) < outliner.SeverityRank(lintMinSeverity) {
+				continue
+			}
+			if outliner.SeverityRank(issue.Severity) >= outliner.SeverityRank(lintFailOn) {
+				failing = true
+			}
+			records = append(records, lintRecord{
+				Kind:     "issue",
+				File:     path,
+				Line:     issue.Line,
+				Type:     issue.Type,
+				Severity: issue.Severity,
+				Message:  issue.Message,
+			})
+		}
+	}
+
+	switch format {
+	case "json":
+		out := json.NewEncoder(os.Stdout)
+		out.SetIndent("", "  ")
+		out.Encode(records)
+	case formatNDJSON:
+		out := json.NewEncoder(os.Stdout)
+		for _, r := range records {
+			out.Encode(r)
+		}
+	default:
+		for _, r := range records {
+			fmt.Printf("%s:%d: %s: %s [%s]\n", r.File, r.Line, r.Severity, r.Message, r.Type)
+		}
+	}
+
+	if failing {
+		os.Exit(lintExitIssues)
+	}
+}
+
+func init() {
+	lintCmd.Flags().StringVar(&lintMinSeverity, "severity", "info", "Lowest severity to report: error, warning, or info")
+	lintCmd.Flags().StringVar(&lintFailOn, "fail-on", "error", "Exit 1 when an issue at or above this severity is found")
+	lintCmd.Flags().StringVar(&lintFormat, "format", "text", "Output format: text, json or ndjson")
+	addJSONFlag(lintCmd)
+}
@@ -2,15 +2,45 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/evanschultz/float-rw-client/pkg/bridge"
+	"github.com/evanschultz/float-rw-client/pkg/cache"
+	"github.com/evanschultz/float-rw-client/pkg/calendar"
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/crash"
+	"github.com/evanschultz/float-rw-client/pkg/encrypt"
+	"github.com/evanschultz/float-rw-client/pkg/gitrepo"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/logging"
 	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/scenario"
+	"github.com/evanschultz/float-rw-client/pkg/termcolor"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+	"github.com/evanschultz/float-rw-client/pkg/vault"
 	"github.com/spf13/cobra"
 )
 
 var (
 	testScenario string
+	fileFormat   string
+	vaultDir     string
+	watchExports bool
+	profileName  string
+	accessible   bool
+
+	logLevel string
+	logFile  string
+	logFeed  = logging.NewFeed() // records shown in the debug panel
+	logClose io.Closer
 )
 
 var rootCmd = &cobra.Command{
@@ -19,9 +49,40 @@ var rootCmd = &cobra.Command{
 	Long: `Float Outliner is a terminal-based outliner with built-in consciousness technology integration.
 It automatically detects and captures :: patterns (ctx::, eureka::, decision::, etc.) for FLOAT ecosystem integration.
 
-You can pass either a file to edit directly, or a directory to use as working directory.`,
+You can pass either a file to edit directly, or a directory to use as working directory.
+
+Inside an Obsidian or Logseq vault (or with --vault), [[wikilinks]] resolve to
+pages across the vault: Ctrl+] opens the linked page in a new buffer, creating
+it (or the day's journal) if needed, and Ctrl+^ returns to the previous one.`,
 	Args: cobra.MaximumNArgs(1),
-	Run:  runOutliner,
+	// The log and custom [patterns] must be set up before any command
+	// parses an outline
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if err := config.SetProfile(profileName); err != nil {
+			fmt.Fprintf(os.Stderr, "profile: %v\n", err)
+			os.Exit(1)
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			// runOutliner reports the broken file
+			setupLogging(config.Default())
+			return
+		}
+		setupLogging(cfg)
+		if err := registerPatterns(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "config: %v\n", err)
+		}
+		if _, err := outliner.NewRedactorFromConfig(cfg.Redact); err != nil {
+			fmt.Fprintf(os.Stderr, "config: %v\n", err)
+			os.Exit(1)
+		}
+		// Asked before anything is read or written, never over the TUI
+		if err := encrypt.Setup(cfg.Encryption, encrypt.TerminalPrompt); err != nil {
+			fmt.Fprintf(os.Stderr, "encryption: %v\n", err)
+			os.Exit(1)
+		}
+	},
+	Run: runOutliner,
 }
 
 func runOutliner(cmd *cobra.Command, args []string) {
@@ -35,92 +96,132 @@ func runOutliner(cmd *cobra.Command, args []string) {
 		path = createTestScenario(testScenario)
 		if path == "" {
 			fmt.Printf("Unknown test scenario: %s\n", testScenario)
-			fmt.Println("Available scenarios: reducer-basic, reducer-complex, patterns-all")
+			fmt.Printf("Available scenarios: %s\n", strings.Join(scenario.Names(scenarioDir), ", "))
 			os.Exit(1)
 		}
 	}
 
-	app := NewOutlinerApp(path)
-
-	p := tea.NewProgram(app, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		fmt.Printf("Error running outliner: %v\n", err)
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
 		os.Exit(1)
 	}
-}
-
-func init() {
-	rootCmd.Flags().StringVar(&testScenario, "test", "", "Create test scenario (reducer-basic, reducer-complex, patterns-all)")
-}
-
-// createTestScenario creates a test file for the specified scenario
-func createTestScenario(scenario string) string {
-	var content string
-	var filename string
-
-	switch scenario {
-	case "reducer-basic":
-		filename = "test-reducer-basic.md"
-		content = `# Reducer Basic Test
-
-• reducer:: test collect all actions that mention test
-
-• dispatch:: test pattern one
-• dispatch:: test pattern two  
-• eureka:: test breakthrough!
-• decision:: use test approach [priority:: high]
-
-• dispatch:: unrelated pattern (should not be collected)
-`
-
-	case "reducer-complex":
-		filename = "test-reducer-complex.md"
-		content = `# Reducer Complex Test
 
-• reducer:: door_patterns collect all actions that are bridges or dispatches about door
-• reducer:: tech_stuff collect all decisions and gotchas about technology
-
-• bridge:: [[door]] connects to [[consciousness-tech]] [bridge-id:: DOOR-001]
-• dispatch:: [[door]] system implementation
-• decision:: implement [[technology]] stack [priority:: high]
-• gotcha:: [[technology]] requires careful setup [fix:: documentation]
-• eureka:: unrelated insight (should not be collected)
-
-• selector:: (door_patterns, tech_stuff) => implementation guide for door tech
-`
+	if accessible {
+		cfg.Accessibility.Enabled = true
+	}
+	announcer, err := newAnnouncer(cfg.Accessibility)
+	if err != nil {
+		fmt.Printf("Error in accessibility.notify: %v\n", err)
+		os.Exit(1)
+	}
 
-	case "patterns-all":
-		filename = "test-patterns-all.md"
-		content = `# All Patterns Test
+	colors, err := termcolor.Parse(colorSetting(cfg))
+	if err != nil {
+		fmt.Printf("Error in theme.colors: %v\n", err)
+		os.Exit(1)
+	}
+	termcolor.Apply(colors)
+	if err := i18n.Setup(cfg.I18n.Locale, localesDir()); err != nil {
+		fmt.Printf("Error in i18n.locale: %v\n", err)
+		os.Exit(1)
+	}
+	if colors == termcolor.Mono {
+		historySelectedStyle = historySelectedStyle.UnsetBackground().Reverse(true)
+	}
 
-• ctx:: 2025-08-05 6:00pm [project:: [[test-project]]] [mode:: testing]
-• eureka:: All patterns working! [concept:: [[consciousness-tech]]]
-• decision:: Test all pattern types [priority:: high]
-• highlight:: This is important for testing [importance:: critical]
-• gotcha:: Debug panel needs to be visible [fix:: check-visibility]
-• bridge:: [[test-project]] connects to [[consciousness-tech]] [bridge-id:: TEST-001]
-• dispatch:: raw consciousness fragment [sigil:: ⚡] [imprint:: techcraft]
+	format, err := resolveFormat(fileFormat, path)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
-• reducer:: test_patterns collect all actions about test
-• selector:: (test_patterns) => test summary report
-`
+	app := NewOutlinerApp(path)
+	app.format = format
+	if err := app.openVault(vaultDir, path); err != nil {
+		fmt.Printf("Error opening vault: %v\n", err)
+		os.Exit(1)
+	}
+	app.applyConfig(cfg)
+	app.announcer = announcer
+	app.watchSelectors = watchExports
+	app.logs = logFeed
+	app.registerDoorPlugins(doorPluginDir())
+	if session, err := cache.OpenSession(cache.SessionPath()); err == nil {
+		app.setSession(session)
+	} else {
+		slog.Warn("session not restored", "err", err)
+	}
 
-	default:
-		return ""
+	slog.Info("outliner started", "file", path, "format", format, "colors", colors, "locale", i18n.Locale(), "accessible", cfg.Accessibility.Enabled)
+	_, crashed, err := crash.Run(termcolor.Filter(app), tea.WithAltScreen())
+	outliner.WaitDoorPlugins()
+	if crashed != nil {
+		fmt.Fprintln(os.Stderr, app.recoverSession(crashed))
+		os.Exit(1)
+	}
+	if err != nil {
+		slog.Error("outliner failed", "err", err)
+		fmt.Printf("Error running outliner: %v\n", err)
+		os.Exit(1)
 	}
+}
 
-	// Write test file
-	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
-		fmt.Printf("Error creating test file: %v\n", err)
-		return ""
+// setupLogging opens the diagnostic log at --log-level and --log-file,
+// falling back to the [log] config section. Nothing is logged to the
+// terminal, where it would land on top of the outliner.
+func setupLogging(cfg *config.Config) {
+	level, path := cfg.Log.Level, cfg.Log.File
+	if logLevel != "" {
+		level = logLevel
+	}
+	if logFile != "" {
+		path = logFile
+	}
+	closer, err := logging.Setup("float-outliner", path, level, logFeed)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "log: %v\n", err)
+		logging.Discard()
+		return
 	}
+	logClose = closer
+}
 
-	fmt.Printf("Created test scenario: %s\n", filename)
-	return filename
+func init() {
+	rootCmd.Flags().StringVar(&testScenario, "test", "", "Write a scenario's outline to test-<name>.md and open it")
+	rootCmd.Flags().MarkDeprecated("test", "use `float-outliner scenario run <name>` to play a scenario headlessly")
+	rootCmd.Flags().StringVar(&fileFormat, "format", "", "Save format: markdown or opml (default from the file extension)")
+	rootCmd.Flags().BoolVar(&watchExports, "watch", false, "Re-export selectors with an [output:: path] whenever their output changes")
+	rootCmd.Flags().BoolVar(&accessible, "accessible", false, "Screen reader mode: spoken row roles, no box drawing or color, captures announced (default from [accessibility] config)")
+	rootCmd.Flags().StringVar(&vaultDir, "vault", "", "Treat this directory as a vault (default: detect .obsidian/ or logseq/)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Profile whose config, token, dispatch log and caches to use (default $FLOAT_LINE_PROFILE, else default)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "Diagnostic log level: debug, info, warn or error (default from [log] config, info)")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "Diagnostic log file (default ~/.cache/float-line/float-outliner.log)")
+
+	rootCmd.AddCommand(captureCmd)
+	rootCmd.AddCommand(quickCmd)
+	rootCmd.AddCommand(popupCmd)
+	rootCmd.AddCommand(queryCmd)
+	rootCmd.AddCommand(lintCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(convertCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(decryptCmd)
+	rootCmd.AddCommand(todayCmd)
+	rootCmd.AddCommand(bridgeCmd)
+	rootCmd.AddCommand(scenarioCmd)
+	rootCmd.AddCommand(config.NewCommand())
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	if logClose != nil {
+		logClose.Close()
+	}
+	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -133,12 +234,63 @@ type OutlinerApp struct {
 	width    int
 	height   int
 	saved    bool
+	format   string // formatMarkdown or formatOPML, used when saving
+
+	keymap           map[string]tea.KeyMsg // preset key -> default binding
+	autosaveInterval time.Duration         // zero disables autosave
+	cfg              *config.Config
+
+	// Vault mode: other open buffers and the vault-wide link index
+	vault    *vault.Vault
+	buffers  []buffer
+	current  int
+	previous int
+
+	// Git: status for the active file's repo, optional commit on save
+	repo       *gitrepo.Repo
+	gitStatus  gitrepo.Status
+	autoCommit bool
+	history    *historyBrowser
+
+	// Idle boundaries: a gap of idleBoundary between keypresses closes the
+	// ctx:: block; workStart is when typing last resumed
+	idleBoundary time.Duration
+	lastKey      time.Time
+	workStart    time.Time
+
+	// [calendar] ctx:: captures are annotated from; nil without one
+	calendar *calendar.Feed
+
+	// Bridge registry, synced on save; nil if it couldn't be loaded
+	bridges *bridge.Registry
+
+	watchSelectors bool // re-export annotated selectors after each save
+
+	palette *palette               // Ctrl+K command palette, nil when closed
+	jump    *jumper                // Ctrl+J navigator, nil when closed
+	rename  *renamePreview         // vault-wide concept rename awaiting confirmation, nil when closed
+	diff    *diffView              // structural diff or merge with another version, nil when closed
+	inbox   *inboxView             // capture inbox processing, nil when closed
+	recall  *recallView            // spaced-repetition review of highlights, nil when closed
+	words   *docStatsView          // writing stats popup, nil when closed
+	nodeRef string                 // last [[file#^id]] link copied, for "ref paste"
+	doors   *outliner.DoorRegistry // built-in doors and plugins from ~/.config/float-line/doors
+	door    outliner.Door          // full-screen door (Alt+S stats), nil when closed
+	doorEnd func()                 // unsubscribes the open door from the dispatch bus
+	toasts  components.Toasts      // save/export results in the corner, Alt+N inbox
+	logs    *logging.Feed          // log records moved into the debug panel
+	session *cache.Session         // the debug panel's size and layout, kept between runs
+	layout  int                    // the outlinerLayouts entry in use
+
+	announcer *components.Announcer // screen reader mode's capture announcements, nil when off
 }
 
 // NewOutlinerApp creates a new outliner application
 func NewOutlinerApp(filename string) *OutlinerApp {
 	app := &OutlinerApp{
 		outliner: outliner.New(),
+		doors:    outliner.NewDoorRegistry(),
+		session:  cache.NewSession(),
 		filename: filename,
 		saved:    true,
 	}
@@ -154,28 +306,198 @@ func NewOutlinerApp(filename string) *OutlinerApp {
 
 // Init initializes the application
 func (a *OutlinerApp) Init() tea.Cmd {
-	return nil
+	return tea.Batch(a.autosaveTick(), reducerTick(), a.fetchCalendar(), calendarTick(), a.outliner.Flush())
 }
 
-// Update handles messages
+// Update handles messages; evna sends queued by loads and saves outside
+// the outliner's own Update are flushed with the returned command
 func (a *OutlinerApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	defer a.showLogs()
+	if a.toasts.Update(msg) {
+		return a, a.toasts.Flush()
+	}
+	model, cmd := a.update(msg)
+	a.keepLayout()
+	return model, tea.Batch(cmd, a.outliner.Flush(), a.toasts.Flush())
+}
+
+// setSession restores the layout and the debug panel's size from session
+func (a *OutlinerApp) setSession(session *cache.Session) {
+	a.session = session
+	a.layout = 0
+	for i, preset := range outlinerLayouts {
+		if preset.name == session.OutlinerLayout {
+			a.layout = i
+		}
+	}
+	a.arrange(&a.outliner)
+}
+
+// showLogs moves records logged since the last message into the debug
+// panel
+func (a *OutlinerApp) showLogs() {
+	for _, r := range a.logs.Drain() {
+		level := outliner.DebugLevelInfo
+		switch {
+		case r.Level >= slog.LevelError:
+			level = outliner.DebugLevelError
+		case r.Level >= slog.LevelWarn:
+			level = outliner.DebugLevelWarning
+		}
+		a.outliner.AddDebugMessage("LOG", r.String(), level)
+	}
+}
+
+func (a *OutlinerApp) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case outliner.EvnaResultMsg, outliner.EvnaValidationMsg, outliner.ReducerUpdateMsg, outliner.ReducerPreviewMsg:
+		newOutliner, cmd := a.outliner.Update(msg)
+		a.outliner = newOutliner
+		return a, cmd
+
+	case outliner.DoorCloseMsg:
+		a.closeDoor()
+		return a, nil
+
+	case outliner.PluginReplyMsg:
+		if a.door == nil {
+			return a, nil
+		}
+		door, cmd := a.door.Update(msg)
+		a.door = door
+		return a, cmd
+
+	case outliner.SandboxPromoteMsg:
+		a.promoteSandbox(msg)
+		return a, nil
+
+	case outliner.GraphJumpMsg:
+		a.jumpFromGraph(msg)
+		return a, nil
+
+	case outliner.CaptureNoticeMsg:
+		a.toasts.Push(components.ToastSuccess, msg.Text())
+		return a, a.announcer.Announce(msg.Text())
+
 	case tea.WindowSizeMsg:
 		a.width = msg.Width
 		a.height = msg.Height
-		a.outliner.SetSize(a.width, a.height-2) // Leave room for status bar
+		a.outliner.SetSize(a.width, a.outlinerHeight())
+		if a.door != nil {
+			a.door, _ = a.door.Update(a.doorSize())
+		}
+
+	case reducerTickMsg:
+		a.refreshWindows()
+		return a, reducerTick()
+
+	case calendarTickMsg:
+		return a, tea.Batch(a.fetchCalendar(), calendarTick())
+
+	case calendarMsg:
+		a.useCalendar(msg)
+		return a, nil
+
+	case autosaveTickMsg:
+		if !a.saved && a.filename != "" {
+			a.saveFile()
+		}
+		return a, a.autosaveTick()
 
 	case tea.KeyMsg:
+		a.noteKeypress(time.Now())
+		if msg.String() == "alt+n" {
+			a.toasts.ToggleInbox()
+			return a, nil
+		}
+		if a.toasts.InboxOpen() {
+			switch msg.String() {
+			case "ctrl+c":
+				return a, tea.Quit
+			case "esc", "q":
+				a.toasts.ToggleInbox()
+			}
+			return a, nil
+		}
+		if a.palette != nil {
+			return a.updatePalette(msg)
+		}
+		if a.jump != nil {
+			return a.updateJump(msg)
+		}
+		if a.history != nil {
+			return a.updateHistory(msg)
+		}
+		if a.rename != nil {
+			return a.updateRename(msg)
+		}
+		if a.diff != nil {
+			return a.updateDiff(msg)
+		}
+		if a.inbox != nil {
+			return a.updateInbox(msg)
+		}
+		if a.recall != nil {
+			return a.updateRecall(msg)
+		}
+		if a.words != nil {
+			return a.updateDocStats(msg)
+		}
+		if a.door != nil {
+			return a.updateDoor(msg)
+		}
+		if a.outliner.IsImprintViewOpen() {
+			// Browsing only; q closes the view rather than quitting
+			newOutliner, cmd := a.outliner.Update(msg)
+			a.outliner = newOutliner
+			return a, cmd
+		}
+		if a.outliner.IsEditHistoryOpen() {
+			// q closes the popup; restoring a version is an edit
+			before := a.outliner.GetContent()
+			newOutliner, cmd := a.outliner.Update(msg)
+			a.outliner = newOutliner
+			if a.outliner.GetContent() != before {
+				a.saved = false
+			}
+			return a, cmd
+		}
+		if a.outliner.IsMetadataEditorOpen() || a.outliner.IsCaptureReviewOpen() || a.outliner.IsOrphanPromptOpen() {
+			// Every key belongs to the form, "q" included
+			newOutliner, cmd := a.outliner.Update(msg)
+			a.outliner = newOutliner
+			a.saved = false
+			return a, cmd
+		}
+
+		// A pasted [[file#^id]] link becomes a link node of its own
+		if msg.Paste {
+			if _, _, ok := outliner.ParseNodeRef(string(msg.Runes)); ok {
+				if err := a.pasteNodeRef(string(msg.Runes)); err != nil {
+					a.toasts.PushError(err)
+				}
+				return a, nil
+			}
+		}
+
+		msg = a.translateKey(msg)
 		switch msg.String() {
 		case "ctrl+c", "q":
 			if !a.saved {
+				if a.autosaveInterval > 0 && a.filename != "" {
+					a.saveFile()
+				}
 				// TODO: Add confirmation dialog
 			}
 			return a, tea.Quit
 
 		case "ctrl+s":
 			a.saveFile()
-			a.saved = true
+			if a.saved {
+				a.toasts.Push(components.ToastSuccess, i18n.T("outliner.toast.saved", filepath.Base(a.filename)))
+			}
+			// With evna.review on, new patterns wait here for approval
+			a.outliner.OpenCaptureReview()
 			return a, nil
 
 		case "ctrl+o":
@@ -188,6 +510,88 @@ func (a *OutlinerApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.outliner = newOutliner
 			return a, cmd
 
+		case "ctrl+g":
+			// Toggle diagnostics panel - pass to outliner
+			newOutliner, cmd := a.outliner.Update(msg)
+			a.outliner = newOutliner
+			return a, cmd
+
+		case "ctrl+]":
+			// Follow the [[link]] under the cursor
+			a.followLink()
+			return a, nil
+
+		case "alt+w":
+			// Copy a [[file#^id]] link to the current node
+			a.copyNodeRef()
+			return a, nil
+
+		case "ctrl+^":
+			// Back to the previous buffer
+			a.switchBuffer(a.previous)
+			return a, nil
+
+		case "ctrl+k":
+			// Open the command palette
+			a.palette = &palette{}
+			return a, nil
+
+		case "ctrl+j":
+			// Jump to any node, concept, pattern or buffer
+			a.openJump()
+			return a, nil
+
+		case "alt+r":
+			// Restore the bridge referenced by the current node
+			a.restoreBridgeAtCursor()
+			return a, nil
+
+		case "alt+a":
+			// Archive the subtree under the cursor
+			a.archiveAtCursor()
+			return a, nil
+
+		case "alt+e":
+			// Export the selector under the cursor to its [output::] file
+			a.exportSelector()
+			return a, nil
+
+		case "alt+b":
+			// Jump to the other end of the bridge under the cursor
+			a.jumpBridge()
+			return a, nil
+
+		case "alt+h":
+			// Browse the file's git history
+			a.openHistory()
+			return a, nil
+
+		case "alt+o":
+			// Process what quick and capture-popup left in the inbox
+			a.openInbox()
+			return a, nil
+
+		case "alt+s":
+			// Open the pattern statistics dashboard
+			return a, a.openDoor("stats")
+
+		case "alt+d":
+			// Open today's daily note
+			a.openToday()
+			return a, nil
+
+		case "alt+g":
+			// Switch to the next layout preset
+			a.setLayout("")
+			return a, nil
+
+		case "alt+z":
+			// Toggle zen mode, which takes the status bar's rows too
+			newOutliner, cmd := a.outliner.Update(msg)
+			a.outliner = newOutliner
+			a.outliner.SetSize(a.width, a.outlinerHeight())
+			return a, cmd
+
 		case "ctrl+l":
 			// Toggle debug panel - pass to outliner
 			newOutliner, cmd := a.outliner.Update(msg)
@@ -212,46 +616,119 @@ func (a *OutlinerApp) View() string {
 		return "Loading..."
 	}
 
-	// Main outliner view
+	// Main outliner view; zen mode hides the status bar unless the palette
+	// needs it
 	content := a.outliner.View()
+	zen := a.outliner.IsZen()
+	if a.toasts.InboxOpen() {
+		zen = false
+		content = a.toasts.InboxView(a.width, a.height-2)
+	} else if a.jump != nil {
+		zen = false
+		content = lipgloss.NewStyle().
+			Height(a.height - 2).
+			MaxHeight(a.height - 2).
+			Render(strings.TrimRight(a.renderJump(), "\n"))
+	} else if a.history != nil {
+		zen = false
+		content = lipgloss.NewStyle().
+			Height(a.height - 2).
+			MaxHeight(a.height - 2).
+			Render(strings.TrimRight(a.renderHistory(), "\n"))
+	} else if a.rename != nil {
+		zen = false
+		content = lipgloss.NewStyle().
+			Height(a.height - 2).
+			MaxHeight(a.height - 2).
+			Render(strings.TrimRight(a.renderRename(), "\n"))
+	} else if a.diff != nil {
+		zen = false
+		content = lipgloss.NewStyle().
+			Height(a.height - 2).
+			MaxHeight(a.height - 2).
+			Render(strings.TrimRight(a.renderDiff(), "\n"))
+	} else if a.inbox != nil {
+		zen = false
+		content = lipgloss.NewStyle().
+			Height(a.height - 2).
+			MaxHeight(a.height - 2).
+			Render(strings.TrimRight(a.renderInbox(), "\n"))
+	} else if a.recall != nil {
+		zen = false
+		content = lipgloss.NewStyle().
+			Height(a.height - 2).
+			MaxHeight(a.height - 2).
+			Render(strings.TrimRight(a.renderRecall(), "\n"))
+	} else if a.words != nil {
+		zen = false
+		content = lipgloss.NewStyle().
+			Height(a.height - 2).
+			MaxHeight(a.height - 2).
+			Render(a.renderDocStats())
+	} else if a.door != nil {
+		zen = false
+		size := a.doorSize()
+		content = a.door.View(size.Width, size.Height)
+	}
+	if zen {
+		if a.palette == nil {
+			return a.toasts.Overlay(content, a.width)
+		}
+		content = lipgloss.NewStyle().MaxHeight(a.height - 2).Render(content)
+	}
 
 	// Status bar
 	statusBar := a.renderStatusBar()
 
-	return content + "\n" + statusBar
+	return a.toasts.Overlay(content+"\n"+statusBar, a.width)
+}
+
+// outlinerHeight is the height the outliner gets: all of it in zen mode,
+// otherwise less room for the status bar
+func (a *OutlinerApp) outlinerHeight() int {
+	if a.outliner.IsZen() {
+		return a.height
+	}
+	return a.height - 2
 }
 
 // renderStatusBar creates the bottom status bar
 func (a *OutlinerApp) renderStatusBar() string {
+	if a.palette != nil {
+		return cells.Fit(a.renderPalette(), a.width)
+	}
+
 	filename := a.filename
 	if filename == "" {
-		filename = "[untitled]"
+		filename = i18n.T("outliner.status.untitled")
 	}
 
 	saveStatus := ""
 	if !a.saved {
-		saveStatus = " [modified]"
+		saveStatus = i18n.T("outliner.status.modified")
 	}
 
 	detailMode := ""
 	if a.outliner.IsDetailMode() {
-		detailMode = " [DETAIL]"
+		detailMode = i18n.T("outliner.status.detail")
 	}
 
 	debugMode := ""
 	if a.outliner.IsDebugVisible() {
-		debugMode = " [DEBUG]"
+		debugMode = i18n.T("outliner.status.debug")
 	}
 
-	status := fmt.Sprintf(" %s%s%s%s | Ctrl+S: Save | Ctrl+T: Detail | Ctrl+L: Debug | Q: Quit", filename, saveStatus, detailMode, debugMode)
-
-	// Pad to full width
-	padding := a.width - len(status)
-	if padding > 0 {
-		status += fmt.Sprintf("%*s", padding, "")
+	issues := ""
+	if n := len(a.outliner.Diagnostics()); n > 0 {
+		issues = i18n.N("outliner.status.issues", n)
 	}
 
-	return status
+	status := " " + filename + saveStatus + a.gitStatusText() + a.bufferStatus() + profileStatus() + detailMode + debugMode + issues + a.statusWords() + i18n.T("outliner.status.keys")
+	if cells.Width(status) > a.width {
+		// Too narrow for the whole path; the file's name comes first
+		status = strings.Replace(status, filename, filepath.Base(filename), 1)
+	}
+	return cells.Fit(status, a.width)
 }
 
 // loadFile loads content from the specified file
@@ -263,11 +740,15 @@ func (a *OutlinerApp) loadFile() {
 	content, err := os.ReadFile(a.filename)
 	if err != nil {
 		// File doesn't exist or can't be read - start with empty content
+		if !os.IsNotExist(err) {
+			slog.Warn("read failed, starting empty", "file", a.filename, "err", err)
+		}
 		return
 	}
 
 	a.outliner.SetContent(string(content))
 	a.saved = true
+	a.noticeRecovery()
 }
 
 // saveFile saves the current content to file
@@ -275,19 +756,82 @@ func (a *OutlinerApp) saveFile() {
 	if a.filename == "" {
 		// TODO: Add save-as dialog
 		a.filename = "untitled.md"
+		if a.vault != nil {
+			a.filename = filepath.Join(a.vault.Root, a.filename)
+		}
+	}
+
+	if a.vault != nil {
+		// Followed links may point at pages or journals that don't exist yet
+		if err := os.MkdirAll(filepath.Dir(a.filename), 0755); err != nil {
+			a.saveFailed(err)
+			return
+		}
 	}
 
-	content := a.outliner.GetContent()
+	// New bridge:: nodes get their IDs before anything is written
+	if a.bridges != nil {
+		a.outliner.AssignBridgeIDs(func() string { return a.bridges.NextID(time.Now()) })
+	}
 
-	// Trigger consciousness capture before saving
+	// Trigger consciousness capture before saving, so OPML records it
 	a.outliner.TriggerConsciousnessCapture()
 
-	err := os.WriteFile(a.filename, []byte(content), 0644)
+	content, err := renderContent(a.outliner, a.format, a.filename)
 	if err != nil {
-		// TODO: Show error message
-		fmt.Printf("Error saving file: %v\n", err)
+		a.saveFailed(err)
+		return
+	}
+
+	// The last committed version, for the commit message summary
+	var previous string
+	if a.repo != nil && a.autoCommit {
+		previous, _ = a.repo.Show("HEAD", a.filename)
+	}
+
+	if err := os.WriteFile(a.filename, []byte(content), 0644); err != nil {
+		a.saveFailed(err)
 		return
 	}
 
 	a.saved = true
+	slog.Info("saved", "file", a.filename, "format", a.format, "bytes", len(content))
+	a.logDocStats()
+
+	a.writeReducerResults()
+	a.reexportSelectors()
+
+	if a.bridges != nil {
+		a.bridges.Sync(a.filename, a.outliner.GetContent())
+		if err := a.bridges.Save(); err != nil {
+			slog.Warn("bridge registry not saved", "err", err)
+		}
+	}
+
+	if a.repo == nil {
+		a.refreshGit() // the first save may create the file inside a repo
+	}
+	a.commitSnapshot(previous, content)
+	if a.repo != nil {
+		a.refreshGitStatus()
+	}
+
+	// Keep vault-wide links and backlinks current. A page created by
+	// following a link now resolves, so rows drawn with it dimmed in any
+	// buffer are stale.
+	if a.vault != nil {
+		if err := a.vault.Update(a.filename); err != nil {
+			slog.Warn("vault index not updated", "file", a.filename, "err", err)
+		}
+		a.outliner.ClearRenderCache()
+		for i := range a.buffers {
+			a.buffers[i].outliner.ClearRenderCache()
+		}
+	}
+}
+
+// saveFailed reports an error saving the active file
+func (a *OutlinerApp) saveFailed(err error) {
+	slog.Error("save failed", "file", a.filename, "err", err)
+	a.toasts.PushError(fmt.Errorf("save %s: %w", a.filename, err))
 }
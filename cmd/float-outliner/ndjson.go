@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// formatNDJSON prints one JSON object per line
+const formatNDJSON = "ndjson"
+
+// jsonOutput is --json on capture, quick, query, lint, export and diff
+var jsonOutput bool
+
+// addJSONFlag gives cmd --json, shorthand for --format ndjson
+func addJSONFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print NDJSON, one object per line with a \"kind\" field (same as --format ndjson)")
+}
+
+// outputFormat is the --format a command runs with: ndjson under --json,
+// which can't be combined with another format
+func outputFormat(cmd *cobra.Command, format string) (string, error) {
+	if !jsonOutput {
+		return format, nil
+	}
+	if cmd.Flags().Changed("format") && format != formatNDJSON {
+		return "", fmt.Errorf("--json can't be combined with --format %s", format)
+	}
+	return formatNDJSON, nil
+}
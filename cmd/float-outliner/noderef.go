@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+)
+
+// copyNodeRef copies a [[file#^id]] link to the current node to the
+// clipboard, keeping it for "ref paste" too
+func (a *OutlinerApp) copyNodeRef() {
+	if a.filename == "" {
+		a.toasts.Push(components.ToastWarn, i18n.T("outliner.toast.save_first"))
+		return
+	}
+	before := a.outliner.CurrentText()
+	id, err := a.outliner.AnchorCursor()
+	if err != nil {
+		a.toasts.PushError(err)
+		return
+	}
+	if a.outliner.CurrentText() != before {
+		a.saved = false // the node gained its [id:: ...]
+	}
+
+	path, err := filepath.Abs(a.filename)
+	if err != nil {
+		path = a.filename
+	}
+	a.nodeRef = outliner.FormatNodeRef(path, id)
+	if err := copyToClipboard(a.nodeRef); err != nil {
+		a.toasts.Push(components.ToastWarn, i18n.T("outliner.toast.kept_ref", a.nodeRef, err))
+		return
+	}
+	a.toasts.Push(components.ToastSuccess, i18n.T("outliner.toast.copied", a.nodeRef))
+}
+
+// pasteNodeRef inserts a link node for ref after the current subtree
+func (a *OutlinerApp) pasteNodeRef(ref string) error {
+	if err := a.outliner.PasteLink(ref); err != nil {
+		return err
+	}
+	a.saved = false
+	return nil
+}
+
+// followNodeRef opens the file a [[file#^id]] link points at and moves the
+// cursor to the node. Relative paths are relative to the current file;
+// an empty path is the current file.
+func (a *OutlinerApp) followNodeRef(path, id string) {
+	if path != "" && !sameFile(path, a.filename) {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(a.filename), path)
+		}
+		a.openBuffer(path)
+	}
+	if !a.outliner.JumpToNode(id) {
+		a.toasts.Push(components.ToastWarn, i18n.T("outliner.toast.no_node", id, bufferName(a.filename)))
+	}
+}
+
+// followOrigin opens the file a collected action came from, if it isn't
+// this one, and moves the cursor to its node, or its line for actions from
+// the dispatch log
+func (a *OutlinerApp) followOrigin(origin outliner.Origin) {
+	if origin.NodeID != "" {
+		a.followNodeRef(origin.Path, origin.NodeID)
+		return
+	}
+	if origin.Path != "" && !sameFile(origin.Path, a.filename) {
+		if _, err := os.Stat(origin.Path); err != nil {
+			a.toasts.PushError(err)
+			return
+		}
+		a.openBuffer(origin.Path)
+	}
+	if !a.outliner.JumpToLine(origin.Line) {
+		a.toasts.Push(components.ToastWarn, i18n.T("outliner.toast.no_line", origin.Line, bufferName(a.filename)))
+	}
+}
+
+// copyToClipboard copies text with the platform's clipboard tool
+func copyToClipboard(text string) error {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	case "windows":
+		candidates = [][]string{{"clip"}}
+	default:
+		candidates = [][]string{{"wl-copy"}, {"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}}
+	}
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(c[0], c[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%s: %w: %s", c[0], err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	return fmt.Errorf("no clipboard tool found")
+}
+
+// readClipboard reads text with the platform's clipboard tool
+func readClipboard() (string, error) {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbpaste"}}
+	case "windows":
+		candidates = [][]string{{"powershell", "-NoProfile", "-Command", "Get-Clipboard"}}
+	default:
+		candidates = [][]string{{"wl-paste", "--no-newline"}, {"xclip", "-selection", "clipboard", "-o"}, {"xsel", "--clipboard", "--output"}}
+	}
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c[0]); err != nil {
+			continue
+		}
+		out, err := exec.Command(c[0], c[1:]...).Output()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", c[0], err)
+		}
+		return string(out), nil
+	}
+	return "", fmt.Errorf("no clipboard tool found")
+}
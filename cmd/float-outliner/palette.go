@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+)
+
+var (
+	paletteStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+	paletteHintStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+)
+
+// paletteCommand is a named action runnable from the Ctrl+K palette
+type paletteCommand struct {
+	usage string
+	run   func(a *OutlinerApp, args []string) error
+}
+
+// paletteCommands maps command names (possibly multi-word) to actions
+var paletteCommands = map[string]paletteCommand{
+	"bridge restore": {
+		usage: "bridge restore <id>",
+		run: func(a *OutlinerApp, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("usage: bridge restore <id>")
+			}
+			return a.restoreBridge(args[0])
+		},
+	},
+	"bridge jump": {
+		usage: "bridge jump",
+		run: func(a *OutlinerApp, args []string) error {
+			a.jumpBridge()
+			return nil
+		},
+	},
+	"ref copy": {
+		usage: "ref copy",
+		run: func(a *OutlinerApp, args []string) error {
+			a.copyNodeRef()
+			return nil
+		},
+	},
+	"ref paste": {
+		usage: "ref paste [[[file#^id]]]",
+		run: func(a *OutlinerApp, args []string) error {
+			ref := strings.Join(args, " ")
+			if ref == "" {
+				ref = a.nodeRef
+			}
+			if ref == "" {
+				return fmt.Errorf("no node reference copied (alt+w)")
+			}
+			return a.pasteNodeRef(ref)
+		},
+	},
+	"selector export": {
+		usage: "selector export",
+		run: func(a *OutlinerApp, args []string) error {
+			a.exportSelector()
+			return nil
+		},
+	},
+	"selector watch": {
+		usage: "selector watch",
+		run: func(a *OutlinerApp, args []string) error {
+			a.toggleSelectorWatch()
+			return nil
+		},
+	},
+	"sort": {
+		usage: "sort <alpha|created|modified|type> [desc]",
+		run: func(a *OutlinerApp, args []string) error {
+			if len(args) < 1 || len(args) > 2 || len(args) == 2 && args[1] != "desc" {
+				return fmt.Errorf("usage: sort <%s> [desc]", strings.Join(outliner.SortOrders, "|"))
+			}
+			if err := a.outliner.SortChildren(args[0], len(args) == 2); err != nil {
+				return err
+			}
+			a.saved = false
+			return nil
+		},
+	},
+	"group": {
+		usage: "group",
+		run: func(a *OutlinerApp, args []string) error {
+			if err := a.outliner.GroupChildren(); err != nil {
+				return err
+			}
+			a.saved = false
+			return nil
+		},
+	},
+	"archive": {
+		usage: "archive",
+		run: func(a *OutlinerApp, args []string) error {
+			a.archiveAtCursor()
+			return nil
+		},
+	},
+	"split": {
+		usage: "split [child]",
+		run: func(a *OutlinerApp, args []string) error {
+			if len(args) > 1 || len(args) == 1 && args[0] != "child" {
+				return fmt.Errorf("usage: split [child]")
+			}
+			if err := a.outliner.SplitNode(len(args) == 1); err != nil {
+				return err
+			}
+			a.saved = false
+			return nil
+		},
+	},
+	"join": {
+		usage: "join",
+		run: func(a *OutlinerApp, args []string) error {
+			if err := a.outliner.JoinNext(); err != nil {
+				return err
+			}
+			a.saved = false
+			return nil
+		},
+	},
+	"door": {
+		usage: "door <name>",
+		run: func(a *OutlinerApp, args []string) error {
+			if len(args) != 1 {
+				names := a.doors.GetAvailable()
+				sort.Strings(names)
+				return fmt.Errorf("usage: door <name> (%s)", strings.Join(names, ", "))
+			}
+			a.openDoor(args[0])
+			return nil
+		},
+	},
+	"profile": {
+		usage: "profile <name>",
+		run: func(a *OutlinerApp, args []string) error {
+			if len(args) != 1 {
+				names, err := config.Profiles()
+				if err != nil {
+					return err
+				}
+				return fmt.Errorf("usage: profile <name> (on %s; %s)", config.Profile(), strings.Join(names, ", "))
+			}
+			return a.switchProfile(args[0])
+		},
+	},
+	"layout": {
+		usage: "layout [name]",
+		run: func(a *OutlinerApp, args []string) error {
+			if len(args) > 1 {
+				return fmt.Errorf("usage: layout [name] (%s)", layoutNames())
+			}
+			return a.setLayout(strings.Join(args, ""))
+		},
+	},
+	"export html": {
+		usage: "export html [path]",
+		run: func(a *OutlinerApp, args []string) error {
+			return a.exportHTML(strings.Join(args, " "))
+		},
+	},
+	"readwise push": {
+		usage: "readwise push",
+		run: func(a *OutlinerApp, args []string) error {
+			return a.pushResults()
+		},
+	},
+	"replay": {
+		usage: "replay [time]",
+		run: func(a *OutlinerApp, args []string) error {
+			return a.openReplay(strings.Join(args, " "))
+		},
+	},
+	"stats": {
+		usage: "stats",
+		run: func(a *OutlinerApp, args []string) error {
+			a.openDoor("stats")
+			return nil
+		},
+	},
+	"sandbox": {
+		usage: "sandbox",
+		run: func(a *OutlinerApp, args []string) error {
+			a.openDoor("sandbox")
+			return nil
+		},
+	},
+	"graph": {
+		usage: "graph",
+		run: func(a *OutlinerApp, args []string) error {
+			a.openDoor("graph")
+			return nil
+		},
+	},
+	"today": {
+		usage: "today",
+		run: func(a *OutlinerApp, args []string) error {
+			a.openToday()
+			return nil
+		},
+	},
+	"inbox": {
+		usage: "inbox",
+		run: func(a *OutlinerApp, args []string) error {
+			a.openInbox()
+			return nil
+		},
+	},
+	"recall": {
+		usage: "recall",
+		run: func(a *OutlinerApp, args []string) error {
+			return a.openRecall()
+		},
+	},
+	"words": {
+		usage: "words",
+		run: func(a *OutlinerApp, args []string) error {
+			a.words = &docStatsView{}
+			return nil
+		},
+	},
+	"rename": {
+		usage: "rename <old> <new> (or rename old name -> new name)",
+		run: func(a *OutlinerApp, args []string) error {
+			from, to, ok := parseRename(args)
+			if !ok {
+				return fmt.Errorf("usage: rename <old> <new> (or rename old name -> new name)")
+			}
+			return a.renameConcept(from, to)
+		},
+	},
+	"diff": {
+		usage: "diff [file]",
+		run: func(a *OutlinerApp, args []string) error {
+			return a.openDiff(strings.Join(args, " "))
+		},
+	},
+	"merge": {
+		usage: "merge [file]",
+		run: func(a *OutlinerApp, args []string) error {
+			return a.openMerge(strings.Join(args, " "))
+		},
+	},
+	"history": {
+		usage: "history",
+		run: func(a *OutlinerApp, args []string) error {
+			a.openHistory()
+			return nil
+		},
+	},
+}
+
+// palette is the command line opened with Ctrl+K
+type palette struct {
+	input string
+}
+
+// updatePalette handles keys while the palette is open
+func (a *OutlinerApp) updatePalette(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlK:
+		a.palette = nil
+	case tea.KeyEnter:
+		input := a.palette.input
+		a.palette = nil
+		if err := a.runPaletteCommand(input); err != nil {
+			a.toasts.PushError(err)
+		}
+	case tea.KeyBackspace:
+		if n := len(a.palette.input); n > 0 {
+			a.palette.input = a.palette.input[:n-1]
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		a.palette.input += string(msg.Runes)
+		if msg.Type == tea.KeySpace {
+			a.palette.input += " "
+		}
+	}
+	return a, nil
+}
+
+// runPaletteCommand finds the longest command name prefixing input and
+// runs it with the remaining words
+func (a *OutlinerApp) runPaletteCommand(input string) error {
+	words := strings.Fields(input)
+	for n := len(words); n > 0; n-- {
+		if cmd, ok := paletteCommands[strings.Join(words[:n], " ")]; ok {
+			return cmd.run(a, words[n:])
+		}
+	}
+	if len(words) == 0 {
+		return nil
+	}
+	return fmt.Errorf("unknown command %q", input)
+}
+
+// renderPalette draws the palette prompt with matching commands
+func (a *OutlinerApp) renderPalette() string {
+	var matches []string
+	for name, cmd := range paletteCommands {
+		if strings.HasPrefix(name, strings.TrimSpace(a.palette.input)) ||
+			strings.HasPrefix(strings.TrimSpace(a.palette.input), name) {
+			matches = append(matches, cmd.usage)
+		}
+	}
+	sort.Strings(matches)
+
+	return paletteStyle.Render(" > "+a.palette.input+"│") + "  " + paletteHintStyle.Render(strings.Join(matches, " · "))
+}
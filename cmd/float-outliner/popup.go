@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/spf13/cobra"
+)
+
+var popupCmd = &cobra.Command{
+	Use:   "capture-popup",
+	Short: "Open a one-line capture prompt for a global hotkey",
+	Long: `Capture-popup opens a tiny prompt for a single line instead of the outliner:
+enter appends it to the inbox and dispatches its patterns, as quick does, and
+exits; esc exits without capturing. No outline is loaded, so the prompt is up
+as soon as the terminal is.
+
+Bind it to a global hotkey in a small terminal window, e.g. with skhd, sxhkd
+or a desktop shortcut:
+
+  kitty --class float-capture -o remember_window_size=no \
+    -o initial_window_width=80c -o initial_window_height=4c \
+    float-outliner capture-popup`,
+	Example: `  float-outliner capture-popup
+  float-outliner capture-popup --inbox ~/notes/inbox.md --no-dispatch`,
+	Args: cobra.NoArgs,
+	RunE: runPopup,
+}
+
+// capturePopup is the capture-popup prompt: one line of input, and what
+// was entered once it closes
+type capturePopup struct {
+	input    textinput.Model
+	inbox    string
+	text     string
+	captured bool
+	done     bool
+}
+
+func newCapturePopup(inbox string) capturePopup {
+	input := textinput.New()
+	input.Prompt = "› "
+	input.Placeholder = "eureka:: ..."
+	input.Focus()
+	return capturePopup{input: input, inbox: inbox}
+}
+
+func (p capturePopup) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (p capturePopup) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			p.text = strings.TrimSpace(p.input.Value())
+			p.captured, p.done = p.text != "", true
+			return p, tea.Quit
+		case "esc", "ctrl+c":
+			p.done = true
+			return p, tea.Quit
+		}
+	case tea.WindowSizeMsg:
+		p.input.Width = max(1, msg.Width-lipgloss.Width(p.input.Prompt)-1)
+	}
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	return p, cmd
+}
+
+func (p capturePopup) View() string {
+	if p.done {
+		return "" // nothing is left behind in the terminal
+	}
+	hint := "enter capture • esc cancel"
+	if matches := outliner.Patterns.Match(p.input.Value()); len(matches) > 0 {
+		types := make([]string, len(matches))
+		for i, m := range matches {
+			types[i] = m.Type + "::"
+		}
+		hint = strings.Join(types, " ") + " • " + hint
+	}
+	return historyTitleStyle.Render("capture → "+filepath.Base(p.inbox)) + "\n" +
+		p.input.View() + "\n" +
+		historyDimStyle.Render(hint) + "\n"
+}
+
+func runPopup(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	inbox := quickInbox
+	if inbox == "" {
+		inbox = inboxPath(cfg)
+	}
+
+	final, err := tea.NewProgram(newCapturePopup(inbox)).Run()
+	if err != nil {
+		return fmt.Errorf("capture popup: %w", err)
+	}
+	p := final.(capturePopup)
+	if !p.captured {
+		return nil
+	}
+	return quickCapture(cmd, cfg, inbox, quickLines(p.text))
+}
+
+func init() {
+	popupCmd.Flags().StringVar(&quickInbox, "inbox", "", "File to append to (default outliner.inbox, else inbox.md in the config directory)")
+	popupCmd.Flags().BoolVar(&quickNoDispatch, "no-dispatch", false, "Append without sending the patterns to evna")
+}
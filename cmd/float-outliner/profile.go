@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/encrypt"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+)
+
+// profileStatus names the profile in the status bar, unless it's the
+// default
+func profileStatus() string {
+	if name := config.Profile(); name != config.DefaultProfile {
+		return fmt.Sprintf(" [profile: %s]", name)
+	}
+	return ""
+}
+
+// switchProfile moves the session to another profile: its config, token,
+// dispatch log, imprints, routing, redaction and door plugins. Nothing is
+// switched if the profile's config can't be loaded or its key unlocked.
+func (a *OutlinerApp) switchProfile(name string) error {
+	names, err := config.Profiles()
+	if err != nil {
+		return fmt.Errorf("list profiles: %w", err)
+	}
+	if !slices.Contains(names, name) {
+		return fmt.Errorf("no profile %q (%s); create one with float-outliner --profile %s config set <key> <value>", name, strings.Join(names, ", "), name)
+	}
+	if name == config.Profile() {
+		a.toasts.Push(components.ToastWarn, i18n.T("outliner.toast.same_profile", name))
+		return nil
+	}
+
+	previous := config.Profile()
+	if err := config.SetProfile(name); err != nil {
+		return err
+	}
+	cfg, err := a.loadProfile(name)
+	if err != nil {
+		config.SetProfile(previous)
+		return err
+	}
+
+	if err := registerPatterns(cfg); err != nil {
+		slog.Warn("profile patterns", "profile", name, "err", err)
+	}
+	a.applyConfig(cfg)
+	for i := range a.buffers {
+		if i != a.current {
+			a.configureOutliner(&a.buffers[i].outliner)
+			loadDispatchHistory(&a.buffers[i].outliner, a.buffers[i].filename)
+		}
+	}
+	a.closeDoor()
+	a.doors = outliner.NewDoorRegistry()
+	a.registerDoorPlugins(doorPluginDir())
+
+	slog.Info("profile switched", "from", previous, "to", name)
+	a.toasts.Push(components.ToastSuccess, i18n.T("outliner.toast.switched_profile", name))
+	return nil
+}
+
+// loadProfile reads the selected profile's config, checks its redaction
+// rules and unlocks its encryption key; the TUI can't ask for a
+// passphrase, so one has to come from FLOAT_LINE_PASSPHRASE or a keyfile
+func (a *OutlinerApp) loadProfile(name string) (*config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := outliner.NewRedactorFromConfig(cfg.Redact); err != nil {
+		return nil, err
+	}
+	prompt := func(bool) ([]byte, error) {
+		return nil, fmt.Errorf("set %s or restart with --profile %s to enter profile %s's passphrase", encrypt.PassphraseEnv, name, name)
+	}
+	if err := encrypt.Setup(cfg.Encryption, prompt); err != nil {
+		return nil, fmt.Errorf("encryption: %w", err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/spf13/cobra"
+)
+
+// queryReducerName is the name the ad-hoc reducer is registered under
+const queryReducerName = "query"
+
+var (
+	queryDir      string
+	queryReducer  string
+	querySelector string
+	queryFormat   string
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Run an ad-hoc reducer over every markdown file in a directory",
+	Long: `Query parses every .md file under --dir, dispatches the :: patterns it finds
+through FLOAT.dispatch, and prints what the --reducer collected. With
+--selector the collected actions are rendered under that heading, the same
+way a selector:: line renders in the editor. Nothing is sent to evna.`,
+	Example: `  float-outliner query --dir notes/ --reducer "collect all decisions about auth"
+  float-outliner query --dir notes/ --reducer "collect all bridges about rangle" --selector "rangle bridge map"
+  float-outliner query --dir notes/ --reducer "collect all actions that mention door" --format json
+  float-outliner query --dir notes/ --reducer "collect all decisions" --json | jq -r .content`,
+	Args: cobra.NoArgs,
+	RunE: runQuery,
+}
+
+// queryRecord is the JSON shape of one collected action
+type queryRecord struct {
+	Kind    string `json:"kind"` // "action"
+	Source  string `json:"source"`
+	Type    string `json:"type"`
+	Content string `json:"content"`
+	Imprint string `json:"imprint"`
+	Sigil   string `json:"sigil,omitempty"`
+}
+
+// selectorRecord is the NDJSON line a --selector query ends with
+type selectorRecord struct {
+	Kind    string `json:"kind"` // "selector"
+	Heading string `json:"heading"`
+	Output  string `json:"output"`
+}
+
+func runQuery(cmd *cobra.Command, args []string) error {
+	format, err := outputFormat(cmd, queryFormat)
+	if err != nil {
+		return err
+	}
+	if format != "text" && format != "json" && format != formatNDJSON {
+		return fmt.Errorf("unknown --format %q: use text, json or ndjson", format)
+	}
+
+	parser := outliner.NewParser()
+	dispatch := outliner.NewFloatDispatchSystem()
+	dispatch.AddReducer(queryReducerName, queryReducer, outliner.ReducerMatcher(queryReducer))
+	if querySelector != "" {
+		dispatch.AddSelector(queryReducerName, []string{queryReducerName}, outliner.SelectorTransform(querySelector))
+	}
+
+	files := 0
+	err = filepath.WalkDir(queryDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".md") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+		files++
+
+		for _, pattern := range parser.Parse(string(content)).ConsciousnessData {
+			dispatch.Dispatch(fmt.Sprintf("%s:%d", path, pattern.Line), pattern.Content, pattern.Type)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	actions := dispatch.GetReducerOutput(queryReducerName)
+
+	if format != "text" {
+		records := make([]queryRecord, len(actions))
+		for i, action := range actions {
+			records[i] = queryRecord{
+				Kind:    "action",
+				Source:  action.NodeID,
+				Type:    action.PatternType,
+				Content: action.Content,
+				Imprint: action.Imprint,
+				Sigil:   action.Sigil,
+			}
+		}
+		out := json.NewEncoder(os.Stdout)
+		if format == "json" {
+			out.SetIndent("", "  ")
+			return out.Encode(records)
+		}
+		for _, record := range records {
+			if err := out.Encode(record); err != nil {
+				return err
+			}
+		}
+		if querySelector != "" {
+			return out.Encode(selectorRecord{Kind: "selector", Heading: querySelector, Output: dispatch.GetSelectorOutput(queryReducerName)})
+		}
+		return nil
+	}
+
+	if querySelector != "" {
+		fmt.Print(dispatch.GetSelectorOutput(queryReducerName))
+		return nil
+	}
+
+	for _, action := range actions {
+		fmt.Printf("%s  %s:: %s\n", action.NodeID, action.PatternType, action.Content)
+	}
+	fmt.Fprintf(os.Stderr, "%d actions collected from %d files\n", len(actions), files)
+	return nil
+}
+
+func init() {
+	queryCmd.Flags().StringVar(&queryDir, "dir", ".", "Directory of markdown notes to scan")
+	queryCmd.Flags().StringVar(&queryReducer, "reducer", "", `Reducer query, e.g. "collect all decisions about auth"`)
+	queryCmd.Flags().StringVar(&querySelector, "selector", "", "Render the collected actions under this selector heading")
+	queryCmd.Flags().StringVar(&queryFormat, "format", "text", "Output format: text, json or ndjson")
+	addJSONFlag(queryCmd)
+	queryCmd.MarkFlagRequired("reducer")
+}
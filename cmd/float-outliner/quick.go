@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/evanschultz/float-rw-client/pkg/calendar"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	quickClipboard  bool
+	quickInbox      string
+	quickNoDispatch bool
+)
+
+var quickCmd = &cobra.Command{
+	Use:   "quick [text...]",
+	Short: "Append a node to the inbox and dispatch its patterns",
+	Long: `Quick captures a fragment without opening the TUI: the text is appended to
+the inbox file (outliner.inbox, or inbox.md in the config directory) as a
+node, and its :: patterns are dispatched to evna at once, as capture would.
+
+The text comes from the arguments, from stdin when there are none (or "-"),
+or from the clipboard with --clipboard. Each line of it becomes a node at its
+indent; "- " and "* " list markers become bullets. --no-dispatch only
+appends, for an inbox that watch already dispatches from.`,
+	Example: `  float-outliner quick "eureka:: the cache key was the bug"
+  pbpaste | float-outliner quick
+  float-outliner quick --clipboard --inbox ~/notes/reading.md
+  alias q='float-outliner quick'`,
+	RunE: runQuick,
+}
+
+func runQuick(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	var text string
+	switch {
+	case quickClipboard:
+		if text, err = readClipboard(); err != nil {
+			return fmt.Errorf("read clipboard: %w", err)
+		}
+	case len(args) > 0 && !(len(args) == 1 && args[0] == "-"):
+		text = strings.Join(args, " ")
+	case len(args) == 1 || !term.IsTerminal(os.Stdin.Fd()):
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("read stdin: %w", err)
+		}
+		text = string(data)
+	default:
+		return fmt.Errorf("nothing to capture: pass text, pipe it in, or use --clipboard")
+	}
+	lines := quickLines(text)
+	if len(lines) == 0 {
+		return fmt.Errorf("nothing to capture: the text is empty")
+	}
+
+	return quickCapture(cmd, cfg, quickInbox, lines)
+}
+
+// quickCapture appends lines to the inbox, the configured one unless path
+// is set, dispatches their patterns and prints where each went
+func quickCapture(cmd *cobra.Command, cfg *config.Config, path string, lines []string) error {
+	if path == "" {
+		path = inboxPath(cfg)
+	}
+	path = config.ExpandHome(path)
+	start, err := appendToInbox(path, lines)
+	if err != nil {
+		return err
+	}
+
+	parser := outliner.NewParser()
+	dispatch := outliner.NewFloatDispatchSystem()
+	evna := outliner.NewEvnaDispatcher()
+	applyDispatchConfig(evna, dispatch, cfg)
+	if quickNoDispatch {
+		evna.SetEnabled(false)
+	}
+	failures := 0
+	evna.SetErrorLogger(func(msgType, content string) {
+		failures++
+		fmt.Fprintf(os.Stderr, "%s: %s\n", msgType, content)
+	})
+
+	var cal *calendar.Calendar
+	if calendar.Configured(cfg.Calendar) {
+		if cal, err = calendar.Load(cmd.Context(), cfg.Calendar, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	parsed := parser.Parse(strings.Join(lines, "\n"))
+	for _, pattern := range parsed.ConsciousnessData {
+		pattern.Line += start
+		pattern = outliner.AnnotateMeeting(cal, pattern, time.Now())
+		action := dispatch.DispatchWith(fmt.Sprintf("%s:%d", path, pattern.Line), pattern.Content, pattern.Type, outliner.MeetingMetadata(pattern), time.Now())
+		if err := evna.DispatchPatterns([]outliner.ConsciousnessPattern{pattern}, "float-quick:"+path); err != nil {
+			return fmt.Errorf("dispatch %s:%d: %w", path, pattern.Line, err)
+		}
+
+		if !jsonOutput {
+			fmt.Printf("%s:%d %s:: %s\n", path, pattern.Line, pattern.Type, pattern.Content)
+			continue
+		}
+		if err := out.Encode(captureRecord{
+			Kind:      "pattern",
+			Source:    path,
+			Line:      pattern.Line,
+			Type:      pattern.Type,
+			Content:   pattern.Content,
+			Context:   pattern.Context,
+			ActionID:  action.ID,
+			Imprint:   action.Imprint,
+			Sigil:     action.Sigil,
+			Timestamp: action.Timestamp,
+		}); err != nil {
+			return err
+		}
+	}
+	if len(parsed.ConsciousnessData) == 0 && !jsonOutput {
+		fmt.Printf("%s:%d\n", path, start+1)
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d patterns failed to dispatch to evna", failures)
+	}
+	return nil
+}
+
+// inboxPath returns the file quick captures go to
+func inboxPath(cfg *config.Config) string {
+	if cfg.Outliner.Inbox != "" {
+		return cfg.Outliner.Inbox
+	}
+	return filepath.Join(config.Dir(), "inbox.md")
+}
+
+// quickLines turns captured text into outline lines, one node per line at
+// its indent (relative to the least indented line). List markers become
+// bullets; headings and bullets stay as they are.
+func quickLines(text string) []string {
+	type line struct {
+		level int
+		text  string
+	}
+	var parsed []line
+	least := -1
+	for _, raw := range strings.Split(text, "\n") {
+		raw = strings.ReplaceAll(strings.TrimRight(raw, " \t\r"), "\t", "  ")
+		trimmed := strings.TrimLeft(raw, " ")
+		if trimmed == "" {
+			continue
+		}
+		level := (len(raw) - len(trimmed)) / 2
+		if least < 0 || level < least {
+			least = level
+		}
+		parsed = append(parsed, line{level, trimmed})
+	}
+
+	lines := make([]string, 0, len(parsed))
+	for _, l := range parsed {
+		text := l.text
+		switch {
+		case strings.HasPrefix(text, "• "), strings.HasPrefix(text, "◦ "), strings.HasPrefix(strings.TrimLeft(text, "#"), " "):
+		case strings.HasPrefix(text, "- "), strings.HasPrefix(text, "* "), strings.HasPrefix(text, "+ "):
+			text = "• " + text[2:]
+		default:
+			text = "• " + text
+		}
+		lines = append(lines, strings.Repeat("  ", l.level-least)+text)
+	}
+	return lines
+}
+
+// appendToInbox adds lines to the end of the markdown file at path,
+// creating it, and returns how many lines the file had before. The lines
+// go in one append-only write, so captures racing each other (the popup,
+// a shell alias, watch) both land and a crash can't truncate the inbox.
+func appendToInbox(path string, lines []string) (int, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("read %s: %w", path, err)
+	}
+	if outliner.IsOPML(string(existing)) {
+		return 0, fmt.Errorf("%s is OPML: quick captures go to a markdown inbox", path)
+	}
+
+	var add strings.Builder
+	start := strings.Count(string(existing), "\n")
+	if len(existing) > 0 && existing[len(existing)-1] != '\n' {
+		add.WriteString("\n")
+		start++
+	}
+	add.WriteString(strings.Join(lines, "\n") + "\n")
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := f.WriteString(add.String()); err != nil {
+		f.Close()
+		return 0, fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return 0, fmt.Errorf("write %s: %w", path, err)
+	}
+	return start, nil
+}
+
+func init() {
+	quickCmd.Flags().BoolVar(&quickClipboard, "clipboard", false, "Capture the clipboard's text")
+	quickCmd.Flags().StringVar(&quickInbox, "inbox", "", "File to append to (default outliner.inbox, else inbox.md in the config directory)")
+	quickCmd.Flags().BoolVar(&quickNoDispatch, "no-dispatch", false, "Append without sending the patterns to evna")
+	addJSONFlag(quickCmd)
+}
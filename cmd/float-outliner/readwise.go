@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/evanschultz/float-rw-client/pkg/api"
+	"github.com/evanschultz/float-rw-client/pkg/auth"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/models"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+)
+
+const (
+	// dispatchBook is the synthetic Readwise book pushed results land in
+	dispatchBook   = "FLOAT Dispatches"
+	dispatchAuthor = "float-line"
+
+	// highlightLimit is the most characters Readwise keeps of a highlight
+	// or note
+	highlightLimit = 8191
+)
+
+// dispatchHighlights turns results into highlights for the dispatch book:
+// one per action a reducer collected, or a selector's rendered output
+func dispatchHighlights(results outliner.Results) []models.HighlightCreate {
+	highlight := func(text, note string) models.HighlightCreate {
+		return models.HighlightCreate{
+			Text:       truncateRunes(text, highlightLimit),
+			Title:      dispatchBook,
+			Author:     dispatchAuthor,
+			SourceType: dispatchAuthor,
+			Note:       truncateRunes(note, highlightLimit),
+		}
+	}
+
+	if results.Kind == "selector" {
+		return []models.HighlightCreate{highlight(results.Output, "selector:: "+results.Name)}
+	}
+	var highlights []models.HighlightCreate
+	for _, action := range results.Actions {
+		if strings.TrimSpace(action.Content) == "" {
+			continue
+		}
+		note := fmt.Sprintf("reducer:: %s · %s:: → %s", results.Name, action.PatternType, action.Imprint)
+		h := highlight(action.Content, note)
+		if !action.Timestamp.IsZero() {
+			at := action.Timestamp
+			h.HighlightedAt = &at
+		}
+		highlights = append(highlights, h)
+	}
+	return highlights
+}
+
+// nothingToPush reports whether results would make no highlight with text
+func nothingToPush(results outliner.Results) bool {
+	highlights := dispatchHighlights(results)
+	return len(highlights) == 0 || strings.TrimSpace(highlights[0].Text) == ""
+}
+
+// truncateRunes cuts s to at most n characters
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// redactResults applies the [redact] rules to results about to be pushed,
+// recording what changed in the debug panel
+func (a *OutlinerApp) redactResults(results outliner.Results) (outliner.Results, error) {
+	cfg := config.RedactConfig{}
+	if a.cfg != nil {
+		cfg = a.cfg.Redact
+	}
+	redactor, err := outliner.NewRedactorFromConfig(cfg)
+	if err != nil {
+		return results, err
+	}
+	redacted, redactions := redactor.RedactResults(results)
+	if len(redactions) > 0 {
+		a.outliner.AddRedaction(fmt.Sprintf("%s %s → Readwise", results.Kind, results.Name), redactions, nothingToPush(redacted))
+	}
+	return redacted, nil
+}
+
+// readwiseClient builds a client from the [api] config and the stored
+// token, the way float-rw does
+func (a *OutlinerApp) readwiseClient() (*api.Client, error) {
+	cfg := config.APIConfig{}
+	if a.cfg != nil {
+		cfg = a.cfg.API
+	}
+	token, _, err := auth.Resolve("", cfg.Token, auth.DefaultStore())
+	if err != nil || token == "" {
+		return nil, fmt.Errorf("no Readwise token (run float-rw auth or set READWISE_TOKEN)")
+	}
+	client := api.NewClient(token)
+	if err := client.Configure(cfg); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// pushResults sends the results of the reducer:: or selector:: node under
+// the cursor to Readwise as highlights in the dispatch book
+func (a *OutlinerApp) pushResults() error {
+	results, err := a.outliner.CursorResults()
+	if err != nil {
+		return err
+	}
+	if nothingToPush(results) {
+		return fmt.Errorf("%s %s has nothing to push yet", results.Kind, results.Name)
+	}
+	if results, err = a.redactResults(results); err != nil {
+		return err
+	}
+	if nothingToPush(results) {
+		return fmt.Errorf("redaction left nothing of %s %s to push", results.Kind, results.Name)
+	}
+	highlights := dispatchHighlights(results)
+
+	client, err := a.readwiseClient()
+	if err != nil {
+		return err
+	}
+	if _, err := client.CreateHighlights(context.Background(), highlights); err != nil {
+		return fmt.Errorf("push to Readwise: %w", err)
+	}
+	a.toasts.Push(components.ToastSuccess, i18n.N("outliner.toast.pushed", len(highlights), results.Name, dispatchBook))
+	return nil
+}
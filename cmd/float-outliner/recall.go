@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/evanschultz/float-rw-client/pkg/cache"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/recall"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+)
+
+// recallCollection is the collection whose captures are reviewed, along
+// with eureka:: wherever it's routed
+const recallCollection = "float_highlights"
+
+// recallView is a spaced-repetition review: the cards due, shown one at a
+// time and graded with again/hard/good/easy
+type recallView struct {
+	deck   *recall.Deck
+	due    []recall.Card
+	shown  int                  // the card being reviewed
+	graded map[recall.Grade]int // reviews so far by grade
+}
+
+// recallPath is where the review schedule is kept
+func recallPath() string {
+	return filepath.Join(cache.Dir(), "recall.json")
+}
+
+// openRecall adds the highlights and eurekas dispatched so far to the
+// review deck and starts reviewing the cards due
+func (a *OutlinerApp) openRecall() error {
+	deck, err := recall.Load(recallPath())
+	if err != nil {
+		return err
+	}
+	if a.syncRecall(deck) > 0 {
+		if err := deck.Save(); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	due := deck.Due(now)
+	if len(due) == 0 {
+		next := i18n.T("outliner.toast.recall_none")
+		if at := deck.Next(now); !at.IsZero() {
+			next = i18n.T("outliner.toast.recall_next", at.Format("Mon Jan 2"))
+		}
+		a.toasts.Push(components.ToastInfo, next)
+		return nil
+	}
+	a.recall = &recallView{deck: deck, due: due, graded: map[recall.Grade]int{}}
+	return nil
+}
+
+// syncRecall adds a card for each recallable capture in the buffers'
+// dispatch history and this session, returning how many were new
+func (a *OutlinerApp) syncRecall(deck *recall.Deck) int {
+	added := 0
+	add := func(o *outliner.Outliner, filename string) {
+		for _, action := range slices.Concat(o.Dispatch().GetHistory(), o.Dispatch().GetActions()) {
+			if action.PatternType != "eureka" && o.Evna().Route(action.PatternType) != recallCollection {
+				continue
+			}
+			if deck.Add(action.PatternType, action.Content, recallSource(action, filename), action.Timestamp) {
+				added++
+			}
+		}
+	}
+	add(&a.outliner, a.filename)
+	for i, b := range a.buffers {
+		if i != a.current {
+			add(&b.outliner, b.filename)
+		}
+	}
+	return added
+}
+
+// recallSource is where a capture came from: its book and author for a
+// Readwise highlight, else its file
+func recallSource(action outliner.DispatchAction, filename string) string {
+	if book := action.Metadata["book"]; book != "" {
+		if author := action.Metadata["author"]; author != "" {
+			return book + " — " + author
+		}
+		return book
+	}
+	if action.Source != "" {
+		return bufferName(action.Source)
+	}
+	return bufferName(filename)
+}
+
+// updateRecall handles keys while a review is open
+func (a *OutlinerApp) updateRecall(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	grade, ok := map[string]recall.Grade{
+		"1": recall.Again, "a": recall.Again,
+		"2": recall.Hard, "h": recall.Hard,
+		"3": recall.Good, "g": recall.Good, " ": recall.Good,
+		"4": recall.Easy, "e": recall.Easy,
+	}[msg.String()]
+	switch {
+	case msg.String() == "esc" || msg.String() == "q":
+		a.finishRecall()
+	case ok:
+		a.gradeRecall(grade)
+	}
+	return a, nil
+}
+
+// gradeRecall schedules the card shown, saves the deck and moves on,
+// finishing after the last card
+func (a *OutlinerApp) gradeRecall(grade recall.Grade) {
+	v := a.recall
+	if _, err := v.deck.Grade(v.due[v.shown].Key(), grade, time.Now()); err != nil {
+		a.toasts.PushError(err)
+		return
+	}
+	if err := v.deck.Save(); err != nil {
+		a.toasts.PushError(err)
+		return
+	}
+	v.graded[grade]++
+	if v.shown++; v.shown == len(v.due) {
+		a.finishRecall()
+	}
+}
+
+// finishRecall closes the review, logging what was reviewed as ctx::
+// activity
+func (a *OutlinerApp) finishRecall() {
+	v := a.recall
+	a.recall = nil
+	if v.shown == 0 {
+		return
+	}
+
+	counts := make([]string, 0, len(recall.Grades))
+	for _, grade := range recall.Grades {
+		if n := v.graded[grade]; n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, grade))
+		}
+	}
+	a.outliner.LogActivity(outliner.ConsciousnessPattern{
+		Type:    "ctx",
+		Content: fmt.Sprintf("reviewed %d recall cards (%s)", v.shown, strings.Join(counts, ", ")),
+		Context: map[string]string{"mode": "recall"},
+	}, "float-recall")
+	a.toasts.Push(components.ToastSuccess, i18n.N("outliner.toast.recalled", v.shown))
+}
+
+// recallInterval renders a number of days as the review shows intervals
+func recallInterval(days int) string {
+	switch {
+	case days < 30:
+		return fmt.Sprintf("%dd", days)
+	case days < 365:
+		return fmt.Sprintf("%dmo", days/30)
+	}
+	return fmt.Sprintf("%.1fy", float64(days)/365)
+}
+
+// renderRecall draws the card being reviewed and what each grade would do
+func (a *OutlinerApp) renderRecall() string {
+	v := a.recall
+	card := v.due[v.shown]
+	now := time.Now()
+	var b strings.Builder
+
+	b.WriteString(historyTitleStyle.Render(i18n.T("outliner.recall.title", v.shown+1, len(v.due))) + "\n")
+	history := i18n.T("outliner.recall.new")
+	if !card.Reviewed.IsZero() {
+		history = i18n.T("outliner.recall.reviewed", card.Reviewed.Format("Jan 2"), recallInterval(card.Interval))
+		if card.Lapses > 0 {
+			history += i18n.T("outliner.recall.forgotten", card.Lapses)
+		}
+	}
+	b.WriteString(historyDimStyle.Render(card.Source+" • "+history) + "\n\n")
+
+	b.WriteString(historyTitleStyle.Render(card.Type+"::") + "\n")
+	b.WriteString(lipgloss.NewStyle().Width(max(1, a.width-2)).Render(card.Content) + "\n\n")
+
+	keys := make([]string, 0, len(recall.Grades))
+	for i, grade := range recall.Grades {
+		next := recall.Schedule(card, grade, now)
+		keys = append(keys, fmt.Sprintf("%d %s (%s)", i+1, grade, recallInterval(next.Interval)))
+	}
+	b.WriteString(historyDimStyle.Render(strings.Join(append(keys, i18n.T("outliner.recall.stop")), " • ")) + "\n")
+	return b.String()
+}
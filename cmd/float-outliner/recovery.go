@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/evanschultz/float-rw-client/pkg/crash"
+)
+
+// recoveryPath is where an unsaved outline is written after a crash:
+// beside its file, e.g. notes.md -> notes.recovered.md
+func recoveryPath(filename string) string {
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + ".recovered" + ext
+}
+
+// safely runs f, turning a panic into an error; the state a crash leaves
+// behind may be what panicked
+func safely(f func() (string, error)) (out string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return f()
+}
+
+// recoverSession saves what a panicked session leaves behind. Unsaved
+// buffers are written to recovery files beside their own; the crash
+// report gets the panic, every open outline, the dispatched actions and
+// the debug messages. It returns what to tell the user.
+func (a *OutlinerApp) recoverSession(p *crash.Panic) string {
+	slog.Error("outliner panicked", "panic", p.Value, "stack", string(p.Stack))
+	lines := []string{fmt.Sprintf("float-outliner crashed: %v", p.Value)}
+
+	report, err := crash.NewReport(crash.DefaultDir(), "float-outliner", p)
+	if err != nil {
+		lines = append(lines, fmt.Sprintf("Couldn't write a crash report (%v):\n\n%s", err, p.Stack))
+	}
+	add := func(name, data string) {
+		if report != nil {
+			if err := report.Add(name, []byte(data)); err != nil {
+				slog.Error("crash report incomplete", "file", name, "err", err)
+			}
+		}
+	}
+
+	a.stashBuffer()
+	for i, b := range a.buffers {
+		content, err := safely(func() (string, error) { return renderContent(b.outliner, b.format, b.filename) })
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("Couldn't recover %s: %v", displayName(b.filename), err))
+			continue
+		}
+		ext := ".md"
+		if b.format == formatOPML {
+			ext = ".opml"
+		}
+		add(fmt.Sprintf("outline-%d%s", i+1, ext), content)
+
+		if b.saved {
+			continue
+		}
+		if b.filename == "" {
+			if report != nil {
+				lines = append(lines, fmt.Sprintf("Unsaved outline kept in the crash report as outline-%d%s", i+1, ext))
+			}
+			continue
+		}
+		path := recoveryPath(b.filename)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			lines = append(lines, fmt.Sprintf("Couldn't write %s: %v", path, err))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("Unsaved changes to %s written to %s", displayName(b.filename), path))
+	}
+
+	var dispatched, debug strings.Builder
+	for _, b := range a.buffers {
+		for _, action := range b.outliner.Dispatch().GetActions() {
+			if line, err := json.Marshal(action); err == nil {
+				dispatched.Write(append(line, '\n'))
+			}
+		}
+		for _, msg := range b.outliner.DebugMessages() {
+			fmt.Fprintf(&debug, "%s [%s] %s: %s\n", msg.Timestamp.Format("15:04:05"), msg.Level, msg.Type, msg.Content)
+		}
+	}
+	add("dispatch.jsonl", dispatched.String())
+	add("debug.log", debug.String())
+
+	if report != nil {
+		lines = append(lines, "Crash report (panic, outlines, dispatch log, debug messages): "+report.Dir)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// displayName is a buffer's file as the status bar shows it
+func displayName(filename string) string {
+	if filename == "" {
+		return "[untitled]"
+	}
+	return filename
+}
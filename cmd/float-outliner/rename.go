@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+)
+
+// renamePreview lists what renaming a concept across the vault would change
+// before any file is touched
+type renamePreview struct {
+	from, to string
+	local    []outliner.ConceptChange // nodes in the active buffer
+	files    []renameFile             // other vault files linking to from
+	lines    []string                 // the list as drawn, one row each
+	offset   int                      // first visible row
+}
+
+// renameFile is another vault file a rename rewrites
+type renameFile struct {
+	path    string // absolute
+	buffer  int    // the open buffer holding it, -1 when it's only on disk
+	changes []outliner.ConceptChange
+}
+
+// parseRename reads "rename" arguments: "old new", or "old name -> new name"
+// for concepts with spaces; either may be written as a [[link]]
+func parseRename(args []string) (from, to string, ok bool) {
+	joined := strings.Join(args, " ")
+	if before, after, found := strings.Cut(joined, "->"); found {
+		from, to = before, after
+	} else if len(args) == 2 {
+		from, to = args[0], args[1]
+	} else {
+		return "", "", false
+	}
+	trim := func(name string) string {
+		name = strings.TrimSpace(name)
+		return strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(name, "[["), "]]"))
+	}
+	from, to = trim(from), trim(to)
+	return from, to, from != "" && to != ""
+}
+
+// renameConcept renames [[from]] to [[to]] in the active buffer, or opens a
+// preview of every change first when a vault links other files to it
+func (a *OutlinerApp) renameConcept(from, to string) error {
+	if a.vault == nil {
+		n, err := a.outliner.RenameConcept(from, to)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("no [[%s]] links in this outline", from)
+		}
+		a.saved = false
+		a.toasts.Push(components.ToastSuccess, i18n.N("outliner.toast.renamed", n, from, to))
+		return nil
+	}
+
+	p := &renamePreview{from: from, to: to, local: a.outliner.ConceptRenames(from, to)}
+	files, err := a.renameFiles(from, to)
+	if err != nil {
+		return err
+	}
+	p.files = files
+	if len(p.local) == 0 && len(p.files) == 0 {
+		return fmt.Errorf("no [[%s]] links in this vault", from)
+	}
+	if len(p.files) == 0 {
+		// Nothing beyond this outline, so nothing to confirm
+		return a.applyRename(p, false)
+	}
+	p.lines = a.renameLines(p)
+	a.rename = p
+	return nil
+}
+
+// renameFiles finds the other vault files with [[from]] links and the lines
+// a rename rewrites in each; open buffers are read as edited, not from disk
+func (a *OutlinerApp) renameFiles(from, to string) ([]renameFile, error) {
+	var files []renameFile
+	seen := map[string]bool{}
+	for _, ref := range a.vault.Backlinks(from) {
+		path := filepath.Join(a.vault.Root, ref.Path)
+		if seen[path] || a.filename != "" && sameFile(path, a.filename) {
+			continue
+		}
+		seen[path] = true
+
+		file := renameFile{path: path, buffer: -1}
+		for i, b := range a.buffers {
+			if i != a.current && sameFile(b.filename, path) {
+				file.buffer = i
+			}
+		}
+		if file.buffer >= 0 {
+			file.changes = a.buffers[file.buffer].outliner.ConceptRenames(from, to)
+		} else {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("read %s: %w", ref.Path, err)
+			}
+			for i, line := range strings.Split(string(data), "\n") {
+				if after, n := outliner.RenameLinks(line, from, to); n > 0 {
+					file.changes = append(file.changes, outliner.ConceptChange{Index: i, Before: line, After: after})
+				}
+			}
+		}
+		if len(file.changes) > 0 {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// applyRename makes the previewed rename: the active buffer records the
+// alias, other open buffers are edited unsaved, other files are rewritten
+// and re-indexed. everywhere false keeps to the active buffer.
+func (a *OutlinerApp) applyRename(p *renamePreview, everywhere bool) error {
+	nodes, err := a.outliner.RenameConcept(p.from, p.to)
+	if err != nil {
+		return err
+	}
+	if nodes > 0 {
+		a.saved = false
+	}
+
+	files := 0
+	if everywhere {
+		for _, file := range p.files {
+			n, err := a.renameInFile(file, p.from, p.to)
+			if err != nil {
+				a.toasts.PushError(err)
+				continue
+			}
+			nodes += n
+			files++
+		}
+	}
+
+	text := i18n.N("outliner.toast.renamed", nodes, p.from, p.to)
+	if files > 0 {
+		text += " " + i18n.N("outliner.toast.renamed_files", files)
+	}
+	a.toasts.Push(components.ToastSuccess, text)
+	return nil
+}
+
+// renameInFile rewrites one other file's [[from]] links
+func (a *OutlinerApp) renameInFile(file renameFile, from, to string) (int, error) {
+	if file.buffer >= 0 {
+		b := &a.buffers[file.buffer]
+		n, err := b.outliner.RetargetLinks(from, to)
+		if n > 0 {
+			b.saved = false
+		}
+		return n, err
+	}
+
+	data, err := os.ReadFile(file.path)
+	if err != nil {
+		return 0, err
+	}
+	lines := strings.Split(string(data), "\n")
+	n := 0
+	for i, line := range lines {
+		if after, count := outliner.RenameLinks(line, from, to); count > 0 {
+			lines[i] = after
+			n++
+		}
+	}
+	if err := os.WriteFile(file.path, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		return 0, err
+	}
+	return n, a.vault.Update(file.path)
+}
+
+// renameLines draws the preview's changes, grouped by file
+func (a *OutlinerApp) renameLines(p *renamePreview) []string {
+	var lines []string
+	change := func(c outliner.ConceptChange) {
+		lines = append(lines,
+			diffRemoveStyle.Render(cells.Cut(fmt.Sprintf("  %4d - %s", c.Index+1, strings.TrimSpace(c.Before)), a.width)),
+			diffAddStyle.Render(cells.Cut(fmt.Sprintf("  %4d + %s", c.Index+1, strings.TrimSpace(c.After)), a.width)))
+	}
+	if len(p.local) > 0 {
+		lines = append(lines, diffHunkStyle.Render(bufferName(a.filename)+" (this buffer)"))
+		for _, c := range p.local {
+			change(c)
+		}
+	}
+	for _, file := range p.files {
+		name, err := filepath.Rel(a.vault.Root, file.path)
+		if err != nil {
+			name = file.path
+		}
+		if file.buffer >= 0 {
+			name += " (open)"
+		}
+		lines = append(lines, diffHunkStyle.Render(name))
+		for _, c := range file.changes {
+			change(c)
+		}
+	}
+	return lines
+}
+
+// updateRename handles keys while the rename preview is open
+func (a *OutlinerApp) updateRename(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := a.rename
+	page := max(1, a.height-4)
+	switch msg.String() {
+	case "esc", "q":
+		a.rename = nil
+	case "enter", "y":
+		a.rename = nil
+		if err := a.applyRename(p, true); err != nil {
+			a.toasts.PushError(err)
+		}
+	case "d":
+		a.rename = nil
+		if err := a.applyRename(p, false); err != nil {
+			a.toasts.PushError(err)
+		}
+	case "up", "k":
+		p.offset = max(0, p.offset-1)
+	case "down", "j":
+		p.offset = min(max(0, len(p.lines)-page), p.offset+1)
+	case "pgup":
+		p.offset = max(0, p.offset-page)
+	case "pgdown", " ":
+		p.offset = min(max(0, len(p.lines)-page), p.offset+page)
+	}
+	return a, nil
+}
+
+// renderRename draws the preview's title, keys and visible changes
+func (a *OutlinerApp) renderRename() string {
+	p := a.rename
+	height := max(1, a.height-4)
+	var b strings.Builder
+
+	files := len(p.files)
+	if len(p.local) > 0 {
+		files++
+	}
+	b.WriteString(historyTitleStyle.Render(fmt.Sprintf("Rename [[%s]] → [[%s]] in %d files", p.from, p.to, files)) + "\n")
+	b.WriteString(historyDimStyle.Render("enter rename everywhere • d this buffer only • ↑/↓ scroll • esc cancel") + "\n\n")
+
+	end := min(len(p.lines), p.offset+height)
+	for _, line := range p.lines[p.offset:end] {
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/evanschultz/float-rw-client/pkg/scenario"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scenarioDir   string
+	scenarioQuiet bool
+)
+
+var scenarioCmd = &cobra.Command{
+	Use:   "scenario",
+	Short: "Run scripted outliner sessions headlessly",
+	Long: `A scenario is a YAML file with an initial outline, key presses to play
+against it, and the dispatches, reducer counts, or final content the session
+should produce:
+
+  name: quick-capture
+  content: |
+    • reducer:: shipped collect all decisions
+    • ctx:: reviewing
+  steps:
+    - key: down
+    - key: ctrl+e
+    - key: enter
+    - type: "decision:: ship it"
+  expect:
+    dispatches:
+      - type: decision
+        content: ship it
+        imprint: techcraft
+    reducers:
+      shipped: 1
+
+Keys use Bubble Tea's names (enter, tab, shift+tab, ctrl+s, alt+m, up).
+After the last step the outline is captured as a save would, with evna
+dispatch off. Scenarios are looked up as a file path, then in --dir, then
+among the built-ins (reducer-basic, reducer-complex, patterns-all).`,
+}
+
+var scenarioRunCmd = &cobra.Command{
+	Use:   "run <name|file>...",
+	Short: "Run scenarios, printing a transcript and PASS or FAIL for each",
+	Long: `Run plays each scenario and prints its transcript: the steps, the final
+frame, the dispatches and reducer counts, and PASS or the failed
+expectations. Exits 1 when any scenario fails.`,
+	Example: `  float-outliner scenario run reducer-basic
+  float-outliner scenario run --quiet testdata/scenarios/*.yaml`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		failed := 0
+		for _, arg := range args {
+			s, err := scenario.Find(arg, scenarioDir)
+			if err != nil {
+				return err
+			}
+
+			result := scenario.Run(s)
+			switch {
+			case !scenarioQuiet:
+				fmt.Print(result.Transcript)
+				fmt.Println()
+			case result.Passed():
+				fmt.Printf("PASS %s\n", s.Name)
+			default:
+				for _, failure := range result.Failures {
+					fmt.Printf("FAIL %s: %s\n", s.Name, failure)
+				}
+			}
+			if !result.Passed() {
+				failed++
+			}
+		}
+
+		if failed > 0 {
+			fmt.Fprintf(os.Stderr, "%d of %d scenarios failed\n", failed, len(args))
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+var scenarioListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List built-in scenarios and those in --dir",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, name := range scenario.Names(scenarioDir) {
+			s, err := scenario.Find(name, scenarioDir)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%-20s %s\n", name, s.Description)
+		}
+		return nil
+	},
+}
+
+// createTestScenario writes a scenario's initial outline to test-<name>.md
+// for --test, returning the path, or "" when there is no such scenario
+func createTestScenario(name string) string {
+	s, err := scenario.Find(name, scenarioDir)
+	if err != nil {
+		return ""
+	}
+
+	filename := "test-" + s.Name + ".md"
+	if err := os.WriteFile(filename, []byte(s.Content), 0644); err != nil {
+		fmt.Printf("Error creating test file: %v\n", err)
+		return ""
+	}
+
+	fmt.Printf("Created test scenario: %s\n", filename)
+	return filename
+}
+
+func init() {
+	scenarioCmd.PersistentFlags().StringVar(&scenarioDir, "dir", "testdata/scenarios", "Directory of scenario files to search")
+	scenarioRunCmd.Flags().BoolVar(&scenarioQuiet, "quiet", false, "Print only PASS/FAIL lines")
+
+	scenarioCmd.AddCommand(scenarioRunCmd)
+	scenarioCmd.AddCommand(scenarioListCmd)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/dispatchlog"
+	"github.com/evanschultz/float-rw-client/pkg/dispatchstore"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/server"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr      string
+	serveLogPath   string
+	serveStorePath string
+	serveOrigins   []string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the dispatch system as a local HTTP/JSON service",
+	Long: `Serve exposes FLOAT.dispatch on a local HTTP API so evna, browser extensions,
+and scripts share one set of reducers and selectors:
+
+  POST /dispatch    {"content": "...", "type": "eureka", "source": "..."}
+                    (omit type to parse content for :: patterns)
+  POST /webhook     evna_capture payloads or {"text": "..."}
+  GET  /actions     ?type= &imprint= &q= &since=RFC3339 &limit=
+  GET  /reducers
+  GET  /selectors
+  GET  /events      Server-Sent Events: every action and reducer update
+                    ?since=<seq> (or Last-Event-ID) replays; ?kind= filters
+  GET  /health
+
+With --log, dispatched patterns are appended to a JSONL dispatch log and
+replayed on startup, so state survives restarts and /events can replay any
+sequence from the log.
+
+With --store, actions are kept in a persistent store instead of memory: a
+SQLite database for .db/.sqlite paths, a JSONL dispatch log otherwise.
+/actions is then answered from the store, and reducers and selectors are
+rebuilt from it on startup. --log can still be given for /events replay.
+
+POST bodies must be sent as Content-Type: application/json, and requests
+must name this server in their Host header. Browsers may only call the API
+from the server's own origin or one given with --allow-origin, so a web page
+can't dispatch to it behind your back.`,
+	Example: `  float-outliner serve
+  float-outliner serve --addr 127.0.0.1:7777 --log notes/.float-line/dispatch-log.jsonl
+  float-outliner serve --store ~/.float-line/dispatch.db
+  float-outliner serve --allow-origin chrome-extension://abcdefghijklmnop
+  curl -H 'Content-Type: application/json' -d '{"content":"reducer:: auth collect all decisions about auth"}' localhost:7777/dispatch`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	dispatch := outliner.NewFloatDispatchSystem()
+	evna := outliner.NewEvnaDispatcher()
+	applyDispatchConfig(evna, dispatch, cfg)
+
+	if serveStorePath != "" {
+		if serveLogPath != "" && filepath.Clean(serveLogPath) == filepath.Clean(serveStorePath) {
+			return fmt.Errorf("--log and --store must be different files")
+		}
+		store, err := dispatchstore.Open(serveStorePath)
+		if err != nil {
+			return err
+		}
+		dispatch.SetStore(store)
+	}
+
+	var log *dispatchlog.Log
+	if serveLogPath != "" {
+		log, err = dispatchlog.Open(serveLogPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	srv := server.New(dispatch, evna, log)
+	srv.SetAddr(serveAddr)
+	srv.AllowOrigins(serveOrigins...)
+	srv.SetErrorLogger(func(err error) {
+		fmt.Fprintf(os.Stderr, "serve: %v\n", err)
+	})
+
+	// A store already holds what the log would replay
+	if serveStorePath != "" {
+		if err := srv.Reload(); err != nil {
+			return err
+		}
+		stored, err := dispatch.Query(outliner.DispatchQuery{})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Loaded %d actions from %s\n", len(stored), serveStorePath)
+	} else if log != nil {
+		entries, err := log.ReadSince(0)
+		if err != nil {
+			return err
+		}
+		srv.Restore(entries)
+		fmt.Fprintf(os.Stderr, "Restored %d actions from %s\n", len(entries), log.Path())
+	}
+
+	// Time-window reducers slide even when nothing new is dispatched
+	go func() {
+		for range time.Tick(reducerRefreshInterval) {
+			srv.Recompute()
+		}
+	}()
+
+	fmt.Fprintf(os.Stderr, "Serving FLOAT.dispatch on http://%s\n", serveAddr)
+	return http.ListenAndServe(serveAddr, srv.Handler())
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:7777", "Listen address (keep it on localhost)")
+	serveCmd.Flags().StringVar(&serveLogPath, "log", "", "Dispatch log to append to and restore from")
+	serveCmd.Flags().StringVar(&serveStorePath, "store", "", "Persistent action store (.db/.sqlite for SQLite, JSONL otherwise)")
+	serveCmd.Flags().StringSliceVar(&serveOrigins, "allow-origin", nil, "Browser origin allowed to call the API (repeatable)")
+}
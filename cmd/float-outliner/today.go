@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/vault"
+	"github.com/spf13/cobra"
+)
+
+// defaultDailyTemplate opens the day with a ctx:: header ready for capture
+const defaultDailyTemplate = `# {{.Date}}
+
+• ctx:: {{.Date}} {{.Time}} [project:: {{.Project}}] [mode:: {{.Mode}}]
+• 
+`
+
+var todayDate string
+
+var todayCmd = &cobra.Command{
+	Use:   "today",
+	Short: "Open today's daily note, creating it from the template",
+	Long: `Today opens the daily note for the current date, creating it first from
+daily.template (or a built-in ctx:: header) in daily.dir. Inside a vault the
+note goes in the vault's journals with its daily note naming unless daily.dir
+is set.
+
+Templates are Go text/templates with {{.Date}}, {{.Time}}, {{.Weekday}},
+{{.Project}}, and {{.Mode}}; project and mode come from daily.project and
+daily.mode.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		day := time.Now()
+		if todayDate != "" {
+			t, err := time.ParseInLocation("2006-01-02", todayDate, time.Local)
+			if err != nil {
+				return fmt.Errorf("--date must be YYYY-MM-DD: %w", err)
+			}
+			day = t
+		}
+
+		var v *vault.Vault
+		if root, kind, ok := vault.Detect("."); ok {
+			if v, err = vault.New(root, kind); err != nil {
+				return err
+			}
+		}
+
+		path, err := ensureDailyNote(cfg, v, day)
+		if err != nil {
+			return err
+		}
+
+		runOutliner(cmd, []string{path})
+		return nil
+	},
+}
+
+// dailyNoteData is what daily templates can use
+type dailyNoteData struct {
+	Date    string
+	Time    string
+	Weekday string
+	Project string
+	Mode    string
+}
+
+// dailyNotePath returns where the note for day lives
+func dailyNotePath(cfg *config.Config, v *vault.Vault, day time.Time) string {
+	if cfg.Daily.Dir == "" && v != nil {
+		return v.JournalPath(day)
+	}
+
+	dir := config.ExpandHome(cfg.Daily.Dir)
+	if dir == "" {
+		dir = "journals"
+	}
+	layout := cfg.Daily.Format
+	if layout == "" {
+		layout = "2006-01-02"
+	}
+	return filepath.Join(dir, day.Format(layout)+".md")
+}
+
+// ensureDailyNote creates the day's note from the template if it doesn't
+// exist yet and returns its path
+func ensureDailyNote(cfg *config.Config, v *vault.Vault, day time.Time) (string, error) {
+	path := dailyNotePath(cfg, v, day)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	content, err := renderDailyNote(cfg, day)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("create daily note: %w", err)
+	}
+	return path, nil
+}
+
+// renderDailyNote fills in the daily template for day
+func renderDailyNote(cfg *config.Config, day time.Time) (string, error) {
+	text := defaultDailyTemplate
+	if cfg.Daily.Template != "" {
+		data, err := os.ReadFile(config.ExpandHome(cfg.Daily.Template))
+		if err != nil {
+			return "", fmt.Errorf("read daily template: %w", err)
+		}
+		text = string(data)
+	}
+
+	tmpl, err := template.New("daily").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse daily template: %w", err)
+	}
+
+	project := cfg.Daily.Project
+	if project == "" {
+		if wd, err := os.Getwd(); err == nil {
+			project = filepath.Base(wd)
+		}
+	}
+
+	now := time.Now()
+	var out bytes.Buffer
+	err = tmpl.Execute(&out, dailyNoteData{
+		Date:    day.Format("2006-01-02"),
+		Time:    strings.ToLower(now.Format("3:04PM")),
+		Weekday: day.Format("Monday"),
+		Project: project,
+		Mode:    cfg.Daily.Mode,
+	})
+	if err != nil {
+		return "", fmt.Errorf("render daily template: %w", err)
+	}
+	return out.String(), nil
+}
+
+// openToday opens today's note in a new buffer
+func (a *OutlinerApp) openToday() {
+	cfg := a.cfg
+	if cfg == nil {
+		cfg = config.Default()
+	}
+
+	path, err := ensureDailyNote(cfg, a.vault, time.Now())
+	if err != nil {
+		a.toasts.PushError(err)
+		return
+	}
+	a.openBuffer(path)
+}
+
+func init() {
+	todayCmd.Flags().StringVar(&todayDate, "date", "", "Open the note for this date (YYYY-MM-DD) instead of today")
+}
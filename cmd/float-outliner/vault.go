@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/vault"
+)
+
+// openVault indexes the vault at root and links the active buffer to it.
+// An empty root auto-detects an Obsidian/Logseq vault around path.
+func (a *OutlinerApp) openVault(root, path string) error {
+	kind := vault.KindPlain
+	if root == "" {
+		detected, detectedKind, ok := vault.Detect(path)
+		if !ok {
+			return nil
+		}
+		root, kind = detected, detectedKind
+	} else if _, detectedKind, ok := vault.Detect(root); ok {
+		kind = detectedKind
+	}
+
+	v, err := vault.Open(root, kind)
+	if err != nil {
+		return err
+	}
+	a.vault = v
+	a.outliner.SetLinkIndex(v)
+
+	// A directory opens the vault without a file; new pages save at its root
+	if info, err := os.Stat(a.filename); err == nil && info.IsDir() {
+		a.filename = ""
+	}
+	return nil
+}
+
+// followLink opens the page for the [[link]] under the cursor in a new
+// buffer; a [[file#^id]] link also jumps to its node. On a reducer's
+// collected child without a link, it jumps to where the action came from.
+func (a *OutlinerApp) followLink() {
+	link, ok := a.outliner.LinkAtCursor()
+	if !ok {
+		if origin, ok := a.outliner.CursorOrigin(); ok {
+			a.followOrigin(origin)
+		}
+		return
+	}
+	if path, id, ok := outliner.ParseNodeRef(link); ok {
+		a.followNodeRef(path, id)
+		return
+	}
+	if a.vault == nil {
+		return
+	}
+	a.openBuffer(a.vault.PathFor(link))
+}
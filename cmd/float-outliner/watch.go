@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/evanschultz/float-rw-client/pkg/calendar"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/dispatchlog"
+	"github.com/evanschultz/float-rw-client/pkg/events"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/watch"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchLogPath string
+	watchQuiet   bool
+	watchAddr    string
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch <dir>",
+	Short: "Capture :: patterns from a notes directory in the background",
+	Long: `Watch scans every markdown file under <dir>, then monitors the tree and
+re-parses files as they change. Patterns that haven't been dispatched before
+go to evna (per the [evna] config section) and are appended to a dispatch log
+shared by every file, <dir>/.float-line/dispatch-log.jsonl by default.
+
+Restarting picks up where the log left off, so nothing is dispatched twice.
+With --addr the dispatches are also streamed as Server-Sent Events on
+/events (resume with ?since=<seq>), the same stream ` + "`serve`" + ` offers.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWatch,
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	dispatch := outliner.NewFloatDispatchSystem()
+	evna := outliner.NewEvnaDispatcher()
+	applyDispatchConfig(evna, dispatch, cfg)
+
+	// Created once the log is open; nil when --addr isn't set
+	var hub *events.Hub
+
+	w, err := watch.New(watch.Options{
+		Dir:      args[0],
+		LogPath:  watchLogPath,
+		Calendar: calendar.NewFeed(cfg.Calendar),
+		OnDispatch: func(e dispatchlog.Entry) {
+			if !watchQuiet {
+				fmt.Printf("#%d %s:%d %s:: %s\n", e.Seq, e.Source, e.Line, e.Type, e.Content)
+			}
+			if hub != nil {
+				hub.PublishAction(e)
+			}
+		},
+		OnError: func(err error) {
+			fmt.Fprintf(os.Stderr, "watch: %v\n", err)
+		},
+	}, dispatch, evna)
+	if err != nil {
+		return err
+	}
+
+	if watchAddr != "" {
+		hub = events.NewHub(w.Log())
+		mux := http.NewServeMux()
+		mux.Handle("/events", hub)
+		go func() {
+			if err := http.ListenAndServe(watchAddr, mux); err != nil {
+				fmt.Fprintf(os.Stderr, "watch: event stream: %v\n", err)
+			}
+		}()
+		fmt.Fprintf(os.Stderr, "Streaming events on http://%s/events\n", watchAddr)
+	}
+
+	if err := w.Scan(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Watching %s (log: %s)\n", args[0], w.Log().Path())
+
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	return w.Run(stop)
+}
+
+func init() {
+	watchCmd.Flags().StringVar(&watchLogPath, "log", "", "Dispatch log path (default <dir>/.float-line/dispatch-log.jsonl)")
+	watchCmd.Flags().BoolVar(&watchQuiet, "quiet", false, "Don't print dispatched patterns")
+	watchCmd.Flags().StringVar(&watchAddr, "addr", "", "Also stream dispatches as SSE on this address, e.g. 127.0.0.1:7778")
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evanschultz/float-rw-client/pkg/dispatchlog"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/watch"
+)
+
+// reducerRefreshInterval is how often time-windowed reducers are
+// re-evaluated as their windows slide
+const reducerRefreshInterval = time.Minute
+
+// reducerTickMsg fires every reducerRefreshInterval
+type reducerTickMsg struct{}
+
+// loadDispatchHistory feeds the profile's nearest watch dispatch log to an
+// outliner, so "from the last 7 days" reducers see patterns from earlier
+// sessions; without one, history from another profile is dropped. It also
+// names filename as where the outliner's own actions come from.
+func loadDispatchHistory(o *outliner.Outliner, filename string) {
+	path := filename
+	if path == "" {
+		path = "."
+	} else if abs, err := filepath.Abs(filename); err == nil {
+		o.Dispatch().SetSource(abs)
+	}
+	logPath, ok := watch.FindLog(path)
+	if !ok {
+		if len(o.Dispatch().GetHistory()) > 0 {
+			o.Dispatch().LoadHistory(nil)
+		}
+		return
+	}
+
+	log, err := dispatchlog.Open(logPath)
+	if err != nil {
+		slog.Warn("dispatch log not loaded", "path", logPath, "err", err)
+		return
+	}
+	entries, err := log.ReadSince(0)
+	if err != nil {
+		slog.Warn("dispatch log not loaded", "path", logPath, "err", err)
+		return
+	}
+	slog.Debug("dispatch history loaded", "path", logPath, "entries", len(entries))
+
+	root := watch.LogRoot(logPath)
+	actions := make([]outliner.DispatchAction, len(entries))
+	for i, e := range entries {
+		source := e.Source
+		if !filepath.IsAbs(source) {
+			source = filepath.Join(root, source)
+		}
+		actions[i] = outliner.DispatchAction{
+			ID:          e.ActionID,
+			NodeID:      fmt.Sprintf("%s:%d", e.Source, e.Line),
+			Source:      source,
+			Content:     e.Content,
+			PatternType: e.Type,
+			Imprint:     e.Imprint,
+			Sigil:       e.Sigil,
+			Metadata:    e.Context,
+			Timestamp:   e.Time,
+			State:       outliner.StateDispatch,
+		}
+	}
+	o.Dispatch().LoadHistory(actions)
+}
+
+// reducerTick schedules the next time-window refresh
+func reducerTick() tea.Cmd {
+	return tea.Tick(reducerRefreshInterval, func(time.Time) tea.Msg {
+		return reducerTickMsg{}
+	})
+}
+
+// refreshWindows recomputes windowed reducers in every open buffer
+func (a *OutlinerApp) refreshWindows() {
+	a.outliner.Dispatch().RecomputeReducers()
+	for i := range a.buffers {
+		if i != a.current {
+			a.buffers[i].outliner.Dispatch().RecomputeReducers()
+		}
+	}
+	a.writeReducerResults()
+	a.reexportSelectors()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/evanschultz/float-rw-client/pkg/api"
+	"github.com/evanschultz/float-rw-client/pkg/auth"
+	"github.com/spf13/cobra"
+)
+
+const tokenURL = "https://readwise.io/access_token"
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Set and validate the Readwise token",
+	Long: `Prompts for a Readwise access token (or uses --token), validates it against
+the Readwise /auth/ endpoint, and stores it in the OS keyring when one is
+available, falling back to ~/.config/float-line/token.`,
+	Run: runAuth,
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show where the token comes from and whether it is valid",
+	Run:   runAuthStatus,
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove the stored token",
+	Run:   runAuthLogout,
+}
+
+func runAuth(cmd *cobra.Command, args []string) {
+	t := token
+	if t == "" {
+		var err error
+		t, err = promptToken()
+		if err != nil {
+			fmt.Printf("Error reading token: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := storeValidatedToken(cmd.Context(), t); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func runAuthStatus(cmd *cobra.Command, args []string) {
+	store := auth.DefaultStore()
+	t, source, err := auth.Resolve(token, cfg.API.Token, store)
+	if err != nil {
+		fmt.Println("No token configured. Run `float-rw auth` to set one.")
+		os.Exit(1)
+	}
+
+	fmt.Printf("Token source: %s\n", describeSource(source, store))
+	if err := configuredClient(t).ValidateToken(cmd.Context()); err != nil {
+		fmt.Printf("Token is not valid: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Token is valid.")
+}
+
+func runAuthLogout(cmd *cobra.Command, args []string) {
+	if err := auth.DefaultStore().Delete(); err != nil {
+		fmt.Printf("Error removing token: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Stored token removed.")
+}
+
+// storeValidatedToken checks a token with Readwise before saving it
+func storeValidatedToken(ctx context.Context, t string) error {
+	if err := configuredClient(t).ValidateToken(ctx); err != nil {
+		if errors.Is(err, api.ErrUnauthorized) {
+			return fmt.Errorf("Readwise rejected that token; copy it again from %s", tokenURL)
+		}
+		return fmt.Errorf("could not validate token: %w", err)
+	}
+
+	store := auth.DefaultStore()
+	if err := store.Set(t); err != nil {
+		return fmt.Errorf("could not store token: %w", err)
+	}
+
+	fmt.Printf("Token saved (%s).\n", auth.Location(store))
+	return nil
+}
+
+// promptToken reads a token from the terminal without echoing it
+func promptToken() (string, error) {
+	fmt.Printf("Paste your Readwise access token (from %s): ", tokenURL)
+
+	if term.IsTerminal(os.Stdin.Fd()) {
+		raw, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Println()
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(raw)), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func describeSource(source string, store auth.Store) string {
+	if source == store.Name() {
+		return auth.Location(store)
+	}
+	return source
+}
+
+func init() {
+	authCmd.AddCommand(authStatusCmd)
+	authCmd.AddCommand(authLogoutCmd)
+}
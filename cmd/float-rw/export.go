@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/x/term"
+	"github.com/evanschultz/float-rw-client/pkg/cache"
+	"github.com/evanschultz/float-rw-client/pkg/export"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportSince  string
+	exportOut    string
+	exportFormat string
+	exportFresh  bool
+	exportSeed   bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Dump every book and highlight to per-book files",
+	Long: `Export pages through the Readwise /export/ endpoint and writes one file per
+book to --out. The cursor is stored in <out>/.float-rw/ so an interrupted
+export resumes where it stopped, and later runs only fetch what changed.
+--seed-cache then merges the export into the highlights cache smart views
+read, so they start from it instead of fetching the whole library.`,
+	Example: `  float-rw export --since 2024-01-01 --out backup/
+  float-rw export --out backup/ --format json
+  float-rw export --out backup/ --seed-cache`,
+	Run: runExport,
+}
+
+func runExport(cmd *cobra.Command, args []string) {
+	client := newClient()
+
+	exporter := export.New(client, export.Options{
+		OutDir:   exportOut,
+		Format:   export.Format(exportFormat),
+		Since:    exportSince,
+		Fresh:    exportFresh,
+		Progress: exportProgress(),
+	})
+
+	// Ctrl+C cancels the page in flight; the next run resumes after the last
+	// saved one
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+	result, err := exporter.Run(ctx)
+	if term.IsTerminal(os.Stdout.Fd()) {
+		fmt.Println()
+	}
+	if err != nil {
+		slog.Error("export failed", "out", exportOut, "err", err)
+		fmt.Printf("Export failed: %v\n", err)
+		fmt.Println("Run the same command again to resume from the last completed page.")
+		os.Exit(1)
+	}
+
+	slog.Info("exported", "out", exportOut, "books", result.Books, "highlights", result.Highlights)
+	fmt.Printf("Exported %d books and %d highlights to %s\n", result.Books, result.Highlights, exportOut)
+
+	if exportSeed {
+		if err := seedCache(); err != nil {
+			slog.Error("seeding the highlights cache failed", "out", exportOut, "err", err)
+			fmt.Printf("Seeding the highlights cache failed: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// seedCache merges the export into the highlights cache
+func seedCache() error {
+	highlights, err := cache.OpenHighlights(cache.HighlightsPath())
+	if err != nil {
+		return err
+	}
+	books, err := export.SeedCache(exportOut, highlights)
+	if err != nil {
+		return err
+	}
+	if err := highlights.Save(); err != nil {
+		return err
+	}
+	slog.Info("seeded highlights cache", "path", cache.HighlightsPath(), "books", books)
+	fmt.Printf("Seeded %s with %d books\n", cache.HighlightsPath(), books)
+	return nil
+}
+
+// exportProgress reports each exported page: as a bar redrawn in place on
+// a terminal, one line per page otherwise
+func exportProgress() func(page, pages, books, highlights int) {
+	if !term.IsTerminal(os.Stdout.Fd()) {
+		return func(page, pages, books, highlights int) {
+			fmt.Printf("page %d/%d: %d books, %d highlights\n", page, pages, books, highlights)
+		}
+	}
+	bar := progress.New(progress.WithDefaultGradient(), progress.WithoutPercentage(), progress.WithWidth(30))
+	return func(page, pages, books, highlights int) {
+		fmt.Printf("\r%s %d/%d pages · %d books, %d highlights", bar.ViewAs(float64(page)/float64(pages)), page, pages, books, highlights)
+	}
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportSince, "since", "", "Only export highlights updated after this date (YYYY-MM-DD)")
+	exportCmd.Flags().StringVar(&exportOut, "out", "readwise-export", "Output directory")
+	exportCmd.Flags().StringVar(&exportFormat, "format", "markdown", "Output format: markdown or json")
+	exportCmd.Flags().BoolVar(&exportFresh, "fresh", false, "Ignore the stored cursor and export everything")
+	exportCmd.Flags().BoolVar(&exportSeed, "seed-cache", false, "Merge the export into the highlights cache smart views read")
+}
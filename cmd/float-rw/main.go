@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+	"github.com/evanschultz/float-rw-client/pkg/api"
+	"github.com/evanschultz/float-rw-client/pkg/api/apitest"
+	"github.com/evanschultz/float-rw-client/pkg/auth"
+	"github.com/evanschultz/float-rw-client/pkg/cache"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/crash"
+	"github.com/evanschultz/float-rw-client/pkg/dispatchlog"
+	"github.com/evanschultz/float-rw-client/pkg/encrypt"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/logging"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/termcolor"
+	"github.com/evanschultz/float-rw-client/pkg/tui"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+	"github.com/evanschultz/float-rw-client/pkg/watch"
+	"github.com/spf13/cobra"
+)
+
+var (
+	token      string
+	useClean   bool
+	useDemo    bool
+	captureLog string
+	profile    string
+	accessible bool
+	cfg        *config.Config
+
+	logLevel string
+	logFile  string
+	logClose io.Closer
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "float-rw",
+	Short: "A Readwise client with FLOAT consciousness integration",
+	Long: `float-rw browses and edits Readwise highlights in the terminal and
+exports your library for backup or for use with float-outliner.
+
+The Readwise token is read from --token, the READWISE_TOKEN environment
+variable, api.token in ~/.config/float-line/config.toml, or the token stored
+by ` + "`float-rw auth`" + `.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.SetProfile(profile); err != nil {
+			return err
+		}
+		loaded, err := config.Load()
+		if err != nil {
+			return err
+		}
+		cfg = loaded
+		setupLogging()
+		if err := encrypt.Setup(cfg.Encryption, encrypt.TerminalPrompt); err != nil {
+			return fmt.Errorf("encryption: %w", err)
+		}
+		return nil
+	},
+}
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browse books and highlights",
+	Long: `Browse books and highlights. With --demo the TUI runs against an
+in-memory sample library, so you can try it without a Readwise token; edits
+last until you quit.
+
+Highlights can be captured as highlight:: actions (p, P for the whole book,
+a to capture as they load): they go through FLOAT.dispatch to evna and into
+the dispatch log, so float-outliner's reducers collect them too.`,
+	Run: runTUI,
+}
+
+func runTUI(cmd *cobra.Command, args []string) {
+	var client *api.Client
+	if useDemo {
+		srv := apitest.NewServer()
+		defer srv.Close()
+		client = srv.APIClient()
+	} else {
+		client = newClient()
+	}
+
+	capture := newCapture()
+	library := newLibrary()
+	highlights := newHighlightCache()
+	session := newSession()
+	covers, err := components.ParseImageProtocol(cfg.API.Covers)
+	if err != nil {
+		fmt.Printf("Error in api.covers: %v\n", err)
+		os.Exit(1)
+	}
+	if accessible {
+		cfg.Accessibility.Enabled = true
+	}
+	colors, err := termcolor.Parse(colorSetting(cfg))
+	if err != nil {
+		fmt.Printf("Error in theme.colors: %v\n", err)
+		os.Exit(1)
+	}
+	termcolor.Apply(colors)
+	if err := i18n.Setup(cfg.I18n.Locale, filepath.Join(config.Dir(), "locales")); err != nil {
+		fmt.Printf("Error in i18n.locale: %v\n", err)
+		os.Exit(1)
+	}
+	if cfg.Accessibility.Enabled {
+		announcer, err := components.NewAnnouncer(os.Stdout, cfg.Accessibility.Notify)
+		if err != nil {
+			fmt.Printf("Error in accessibility.notify: %v\n", err)
+			os.Exit(1)
+		}
+		capture.SetAnnouncer(announcer)
+		tui.SetAccessible(true)
+	}
+
+	var model tea.Model
+	if useClean {
+		m := tui.NewCleanModel(client)
+		m.SetCapture(capture)
+		m.SetLibrary(library)
+		m.SetViews(cfg.Views, highlights)
+		m.SetCoverProtocol(covers)
+		model = m
+	} else {
+		m := tui.NewSplitModel(client)
+		m.SetCapture(capture)
+		m.SetLibrary(library)
+		m.SetViews(cfg.Views, highlights)
+		m.SetSession(session)
+		m.SetStackBelow(cfg.Layout.StackBelow)
+		m.SetCoverProtocol(covers)
+		model = m
+	}
+
+	slog.Info("tui started", "demo", useDemo, "clean", useClean, "colors", colors, "locale", i18n.Locale(), "accessible", cfg.Accessibility.Enabled)
+	_, crashed, err := crash.Run(termcolor.Filter(model), tea.WithAltScreen())
+	if crashed != nil {
+		reportCrash(crashed)
+		os.Exit(1)
+	}
+	if err != nil {
+		slog.Error("tui failed", "err", err)
+		fmt.Printf("Error running TUI: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// colorSetting is theme.colors, except that the screen reader mode
+// renders in monochrome unless a level is set
+func colorSetting(cfg *config.Config) string {
+	setting := strings.ToLower(strings.TrimSpace(cfg.Theme.Colors))
+	if cfg.Accessibility.Enabled && (setting == "" || setting == "auto") {
+		return "mono"
+	}
+	return setting
+}
+
+// reportCrash writes a crash report for a panicked TUI and says where it
+// went. Unsaved highlight edits can't be recovered: they're only kept in
+// the editor until saved to Readwise.
+func reportCrash(p *crash.Panic) {
+	slog.Error("tui panicked", "panic", p.Value, "stack", string(p.Stack))
+	fmt.Fprintf(os.Stderr, "float-rw crashed: %v\n", p.Value)
+	report, err := crash.NewReport(crash.DefaultDir(), "float-rw", p)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't write a crash report (%v):\n\n%s", err, p.Stack)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Crash report: %s\n", report.Dir)
+}
+
+// newCapture configures highlight capture from the [evna] section and the
+// dispatch log; the demo library is never sent or recorded
+func newCapture() *tui.HighlightCapture {
+	evna := outliner.NewEvnaDispatcher()
+	evna.SetEnabled(cfg.Evna.Enabled && !useDemo)
+	evna.SetEndpoint(cfg.Evna.Endpoint)
+	evna.SetDefaultCollection(cfg.Evna.DefaultCollection)
+	evna.SetCollectionRouting(cfg.Evna.Collections)
+	redactor, err := outliner.NewRedactorFromConfig(cfg.Redact)
+	if err != nil {
+		fmt.Printf("Error in [redact] config: %v\n", err)
+		os.Exit(1)
+	}
+	evna.SetRedactor(redactor)
+
+	var log *dispatchlog.Log
+	path := captureLog
+	if path == "" {
+		path, _ = watch.FindLog(".")
+	}
+	if path != "" && !useDemo {
+		opened, err := dispatchlog.Open(path)
+		if err != nil {
+			fmt.Printf("Error opening dispatch log: %v\n", err)
+			os.Exit(1)
+		}
+		log = opened
+	}
+
+	capture := tui.NewHighlightCapture(outliner.NewFloatDispatchSystem(), evna, log)
+	capture.SetAuto(cfg.API.AutoCapture)
+	return capture
+}
+
+// newLibrary opens the local library cache holding the book list's sort,
+// filters and archive; the demo library's is kept in memory
+func newLibrary() *cache.Library {
+	if useDemo {
+		return cache.NewLibrary()
+	}
+	library, err := cache.Open(cache.DefaultPath())
+	if err != nil {
+		fmt.Printf("Error opening library cache: %v\n", err)
+		os.Exit(1)
+	}
+	return library
+}
+
+// newHighlightCache opens the cache of every book's highlights the smart
+// views read; demo mode keeps it in memory
+func newHighlightCache() *cache.Highlights {
+	if useDemo {
+		return cache.NewHighlights()
+	}
+	highlights, err := cache.OpenHighlights(cache.HighlightsPath())
+	if err != nil {
+		fmt.Printf("Error opening highlights cache: %v\n", err)
+		os.Exit(1)
+	}
+	return highlights
+}
+
+// newSession opens the session the split layout's pane ratios are kept in;
+// demo mode keeps it in memory
+func newSession() *cache.Session {
+	if useDemo {
+		return cache.NewSession()
+	}
+	session, err := cache.OpenSession(cache.SessionPath())
+	if err != nil {
+		slog.Warn("session not restored", "err", err)
+		return cache.NewSession()
+	}
+	return session
+}
+
+// newClient builds an API client from the flag, environment, config file, or
+// token store. On first run in a terminal it walks the user through
+// `float-rw auth`.
+func newClient() *api.Client {
+	t, _, err := auth.Resolve(token, cfg.API.Token, auth.DefaultStore())
+	if err == nil {
+		return configuredClient(t)
+	}
+
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		fmt.Println("No Readwise token configured. Run `float-rw auth` or set READWISE_TOKEN.")
+		os.Exit(1)
+	}
+
+	fmt.Println("Welcome to float-rw! Let's connect your Readwise account first.")
+	t, err = promptToken()
+	if err != nil {
+		fmt.Printf("Error reading token: %v\n", err)
+		os.Exit(1)
+	}
+	if err := storeValidatedToken(context.Background(), t); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	return configuredClient(t)
+}
+
+// configuredClient applies the [api] config section to a new client
+func configuredClient(t string) *api.Client {
+	client := api.NewClient(t)
+	if err := client.Configure(cfg.API); err != nil {
+		fmt.Printf("Error in %v\n", err)
+		os.Exit(1)
+	}
+	return client
+}
+
+// setupLogging opens the diagnostic log at --log-level and --log-file,
+// falling back to the [log] config section. Nothing is logged to the
+// terminal, where it would land on top of the TUI.
+func setupLogging() {
+	level, path := cfg.Log.Level, cfg.Log.File
+	if logLevel != "" {
+		level = logLevel
+	}
+	if logFile != "" {
+		path = logFile
+	}
+	closer, err := logging.Setup("float-rw", path, level, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "log: %v\n", err)
+		logging.Discard()
+		return
+	}
+	logClose = closer
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&token, "token", "", "Readwise access token")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Profile whose config, token and caches to use (default $FLOAT_LINE_PROFILE, else default)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "Diagnostic log level: debug, info, warn or error (default from [log] config, info)")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "Diagnostic log file (default ~/.cache/float-line/float-rw.log)")
+	tuiCmd.Flags().BoolVar(&useClean, "clean", false, "Use the three-panel outliner layout")
+	tuiCmd.Flags().StringVar(&captureLog, "log", "", "Dispatch log captured highlights are recorded in (default: the nearest watched directory's)")
+	tuiCmd.Flags().BoolVar(&accessible, "accessible", false, "Screen reader mode: no box drawing or color, captures announced (default from [accessibility] config)")
+	tuiCmd.Flags().BoolVar(&useDemo, "demo", false, "Browse a built-in sample library instead of your Readwise account (no token needed)")
+
+	rootCmd.AddCommand(tuiCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(authCmd)
+	rootCmd.AddCommand(config.NewCommand())
+}
+
+func main() {
+	err := rootCmd.Execute()
+	if logClose != nil {
+		logClose.Close()
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
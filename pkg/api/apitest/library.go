@@ -0,0 +1,34 @@
+package apitest
+
+import (
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/models"
+)
+
+// cannedTime is when the canned library was last touched
+var cannedTime = time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+func cannedBooks() []models.Book {
+	at := cannedTime
+	return []models.Book{
+		{ID: 1, Title: "Shacks Not Cathedrals", Author: "Float", Category: "books", Source: "kindle", NumHighlights: 3, LastHighlightAt: &at, Updated: cannedTime,
+			Tags: []models.Tag{{ID: 11, Name: "architecture"}, {ID: 12, Name: "craft"}}, DocumentNote: "Read on the porch over one **long summer**. Start with the chapter on thin walls."},
+		{ID: 2, Title: "The Ritual Stack", Author: "Evan Schultz", Category: "articles", Source: "reader", NumHighlights: 2, LastHighlightAt: &at, Updated: cannedTime, SourceURL: "https://example.com/ritual-stack",
+			Tags: []models.Tag{{ID: 12, Name: "craft"}}},
+		{ID: 3, Title: "Sacred Incompletion", Author: "Anonymous", Category: "podcasts", Source: "snipd", NumHighlights: 1, LastHighlightAt: &at, Updated: cannedTime},
+	}
+}
+
+func cannedHighlights() []models.Highlight {
+	at := cannedTime
+	return []models.Highlight{
+		{ID: 101, BookID: 1, Text: "Build the small thing first, then live in it.", Location: 12, LocationType: "location", HighlightedAt: &at, Updated: cannedTime,
+			Tags: []models.Tag{{ID: 1, Name: "shacks"}}},
+		{ID: 102, BookID: 1, Text: "A cathedral is a shack that forgot who it was for.", Note: "• ctx:: reading on the porch\n• eureka:: structure follows use", Location: 48, LocationType: "location", HighlightedAt: &at, Updated: cannedTime, IsFavorite: true},
+		{ID: 103, BookID: 1, Text: "Leave the walls thin enough to hear the weather.", Location: 97, LocationType: "location", HighlightedAt: &at, Updated: cannedTime},
+		{ID: 201, BookID: 2, Text: "Rituals are interfaces you can walk through.", Location: 3, LocationType: "offset", HighlightedAt: &at, Updated: cannedTime, URL: "https://example.com/ritual-stack#p3"},
+		{ID: 202, BookID: 2, Text: "Dispatch the thought, don't finish it.", Note: "• decision:: keep notes unfinished", Location: 9, LocationType: "offset", HighlightedAt: &at, Updated: cannedTime},
+		{ID: 301, BookID: 3, Text: "Nothing has to be done to be worth keeping.", Location: 1320, LocationType: "time_offset", HighlightedAt: &at, Updated: cannedTime},
+	}
+}
@@ -0,0 +1,437 @@
+package apitest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/api"
+	"github.com/evanschultz/float-rw-client/pkg/models"
+)
+
+// Token is the only token the fake accepts
+const Token = "demo-token"
+
+// Failure is an injected error response
+type Failure struct {
+	Status     int
+	RetryAfter int // seconds, sent with 429s
+}
+
+// Server is an in-memory Readwise API: list, get, create and PATCH
+// highlights, books, /auth/, and /export/, with failures and latency on
+// demand
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	books      []models.Book
+	highlights []models.Highlight
+	failures   []Failure
+	latency    time.Duration
+	requests   int
+
+	// Books per /export/ page, 0 for one page, and the queries received
+	exportPageSize int
+	exports        []url.Values
+}
+
+// NewServer starts a fake loaded with the canned library
+func NewServer() *Server {
+	s := &Server{books: cannedBooks(), highlights: cannedHighlights()}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// APIClient returns a client pointed at the fake with the accepted token
+func (s *Server) APIClient() *api.Client {
+	client := api.NewClient(Token)
+	client.SetBaseURL(s.URL)
+	return client
+}
+
+// Fail makes the next n requests fail with f
+func (s *Server) Fail(f Failure, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, f)
+	}
+}
+
+// SetLatency delays every response by d
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// SetLibrary replaces the books and highlights served
+func (s *Server) SetLibrary(books []models.Book, highlights []models.Highlight) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.books = books
+	s.highlights = highlights
+}
+
+// SetExportPageSize splits /export/ results into pages of n books, the
+// pageCursor being the index of the next book
+func (s *Server) SetExportPageSize(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exportPageSize = n
+}
+
+// ExportQueries returns the query of every /export/ request served so far
+func (s *Server) ExportQueries() []url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]url.Values(nil), s.exports...)
+}
+
+// Requests returns how many requests the fake has received
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// Highlight returns the stored highlight with the given ID
+func (s *Server) Highlight(id int) (models.Highlight, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, h := range s.highlights {
+		if h.ID == id {
+			return h, true
+		}
+	}
+	return models.Highlight{}, false
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	latency := s.latency
+	var failure *Failure
+	if len(s.failures) > 0 {
+		failure = &s.failures[0]
+		s.failures = s.failures[1:]
+	}
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if failure != nil {
+		if failure.Status == http.StatusTooManyRequests && failure.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(failure.RetryAfter))
+		}
+		http.Error(w, `{"detail": "injected failure"}`, failure.Status)
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Token "+Token {
+		http.Error(w, `{"detail": "Invalid token."}`, http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/auth/" && r.Method == http.MethodGet:
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Path == "/books/" && r.Method == http.MethodGet:
+		s.listBooks(w, r)
+	case r.URL.Path == "/highlights/" && r.Method == http.MethodGet:
+		s.listHighlights(w, r)
+	case r.URL.Path == "/highlights/" && r.Method == http.MethodPost:
+		s.createHighlights(w, r)
+	case r.URL.Path == "/export/" && r.Method == http.MethodGet:
+		s.export(w, r)
+	case strings.HasPrefix(r.URL.Path, "/highlights/"):
+		s.highlight(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) listBooks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	books := append([]models.Book(nil), s.books...)
+	s.mu.Unlock()
+
+	start, end, next := page(r, len(books))
+	writeJSON(w, models.BookList{Count: len(books), Next: next, Results: books[start:end]})
+}
+
+func (s *Server) listHighlights(w http.ResponseWriter, r *http.Request) {
+	bookID, _ := strconv.Atoi(r.URL.Query().Get("book_id"))
+
+	s.mu.Lock()
+	var highlights []models.Highlight
+	for _, h := range s.highlights {
+		if bookID == 0 || h.BookID == bookID {
+			highlights = append(highlights, h)
+		}
+	}
+	s.mu.Unlock()
+
+	start, end, next := page(r, len(highlights))
+	writeJSON(w, models.HighlightList{Count: len(highlights), Next: next, Results: highlights[start:end]})
+}
+
+// highlight serves GET and PATCH on /highlights/<id>/
+func (s *Server) highlight(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(r.URL.Path, "/highlights/"), "/"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := -1
+	for j, h := range s.highlights {
+		if h.ID == id {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		http.Error(w, `{"detail": "Not found."}`, http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, s.highlights[i])
+	case http.MethodPatch:
+		var update models.HighlightUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		applyUpdate(&s.highlights[i], update)
+		writeJSON(w, s.highlights[i])
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// createHighlights files new highlights under the book with their title
+// and author, creating it as needed; a highlight the book already has
+// with the same text is updated instead
+func (s *Server) createHighlights(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Highlights []models.HighlightCreate `json:"highlights"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	created := map[int]*models.CreatedBook{}
+	var order []int
+	for _, c := range body.Highlights {
+		if c.Text == "" {
+			http.Error(w, `{"highlights": ["text is required"]}`, http.StatusBadRequest)
+			return
+		}
+		book := s.bookFor(c)
+		if created[book.ID] == nil {
+			created[book.ID] = &models.CreatedBook{ID: book.ID, Title: book.Title, Author: book.Author, Category: book.Category}
+			order = append(order, book.ID)
+		}
+
+		i := -1
+		for j, h := range s.highlights {
+			if h.BookID == book.ID && h.Text == c.Text {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			s.highlights = append(s.highlights, models.Highlight{ID: s.nextHighlightID(), BookID: book.ID, Text: c.Text})
+			i = len(s.highlights) - 1
+			book.NumHighlights++
+		}
+		h := &s.highlights[i]
+		h.Note, h.HighlightedAt, h.Updated = c.Note, c.HighlightedAt, now
+		created[book.ID].ModifiedHighlights = append(created[book.ID].ModifiedHighlights, h.ID)
+		created[book.ID].NumHighlights = book.NumHighlights
+	}
+
+	result := []models.CreatedBook{}
+	for _, id := range order {
+		result = append(result, *created[id])
+	}
+	writeJSON(w, result)
+}
+
+// bookFor finds the book a created highlight belongs to, adding it if it's
+// new; callers hold s.mu
+func (s *Server) bookFor(c models.HighlightCreate) *models.Book {
+	for i, b := range s.books {
+		if b.Title == c.Title && b.Author == c.Author {
+			return &s.books[i]
+		}
+	}
+	id := 1
+	for _, b := range s.books {
+		id = max(id, b.ID+1)
+	}
+	category := c.Category
+	if category == "" {
+		category = "books"
+	}
+	s.books = append(s.books, models.Book{ID: id, Title: c.Title, Author: c.Author, Category: category, Source: c.SourceType})
+	return &s.books[len(s.books)-1]
+}
+
+// nextHighlightID is one past the highest highlight ID; callers hold s.mu
+func (s *Server) nextHighlightID() int {
+	id := 1
+	for _, h := range s.highlights {
+		id = max(id, h.ID+1)
+	}
+	return id
+}
+
+// export serves the whole library, or with updatedAfter the highlights
+// updated since and their books, a page at a time if the page size is set
+func (s *Server) export(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exports = append(s.exports, r.URL.Query())
+
+	start := 0
+	if cursor := r.URL.Query().Get("pageCursor"); cursor != "" {
+		n, err := strconv.Atoi(cursor)
+		if err != nil || n < 0 {
+			http.Error(w, `{"detail":"bad pageCursor"}`, http.StatusBadRequest)
+			return
+		}
+		start = n
+	}
+
+	var since time.Time
+	if after := r.URL.Query().Get("updatedAfter"); after != "" {
+		t, err := time.Parse(time.RFC3339, after)
+		if err != nil {
+			http.Error(w, `{"detail":"bad updatedAfter"}`, http.StatusBadRequest)
+			return
+		}
+		since = t
+	}
+
+	list := models.ExportList{Results: []models.ExportBook{}}
+	for _, b := range s.books {
+		book := models.ExportBook{
+			UserBookID: b.ID,
+			Title:      b.Title,
+			Author:     b.Author,
+			Source:     b.Source,
+			Category:   b.Category,
+			SourceURL:  b.SourceURL,
+			BookTags:   b.Tags,
+		}
+		for _, h := range s.highlights {
+			if h.BookID != b.ID || (!since.IsZero() && !h.Updated.After(since)) {
+				continue
+			}
+			updated := h.Updated
+			book.Highlights = append(book.Highlights, models.ExportHighlight{
+				ID:            h.ID,
+				Text:          h.Text,
+				Note:          h.Note,
+				Location:      h.Location,
+				LocationType:  h.LocationType,
+				HighlightedAt: h.HighlightedAt,
+				UpdatedAt:     &updated,
+				BookID:        h.BookID,
+				Tags:          h.Tags,
+				IsFavorite:    h.IsFavorite,
+			})
+		}
+		if !since.IsZero() && len(book.Highlights) == 0 {
+			continue
+		}
+		list.Results = append(list.Results, book)
+	}
+	list.Count = len(list.Results)
+	start = min(start, len(list.Results))
+	end := len(list.Results)
+	if s.exportPageSize > 0 && start+s.exportPageSize < end {
+		end = start + s.exportPageSize
+		list.NextPageCursor = &end
+	}
+	list.Results = list.Results[start:end]
+	writeJSON(w, list)
+}
+
+func applyUpdate(h *models.Highlight, update models.HighlightUpdate) {
+	if update.Text != "" {
+		h.Text = update.Text
+	}
+	if update.Note != "" {
+		h.Note = update.Note
+	}
+	if update.Location != 0 {
+		h.Location = update.Location
+	}
+	if update.URL != "" {
+		h.URL = update.URL
+	}
+	if update.Color != "" {
+		h.Color = update.Color
+	}
+	if update.IsFavorite != nil {
+		h.IsFavorite = *update.IsFavorite
+	}
+	if update.IsDiscard != nil {
+		h.IsDiscard = *update.IsDiscard
+	}
+	h.Updated = time.Now().UTC()
+}
+
+// page applies the page and page_size parameters to n results, returning
+// the slice bounds and the next page's URL
+func page(r *http.Request, n int) (start, end int, next string) {
+	q := r.URL.Query()
+	size, err := strconv.Atoi(q.Get("page_size"))
+	if err != nil || size <= 0 {
+		size = 100
+	}
+	number, err := strconv.Atoi(q.Get("page"))
+	if err != nil || number <= 0 {
+		number = 1
+	}
+
+	start = min((number-1)*size, n)
+	end = min(start+size, n)
+	if end < n {
+		q.Set("page", strconv.Itoa(number+1))
+		u := *r.URL
+		u.RawQuery = q.Encode()
+		next = "http://" + r.Host + u.String()
+	}
+	return start, end, next
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
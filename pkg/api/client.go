@@ -2,42 +2,142 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
+	"github.com/evanschultz/float-rw-client/pkg/config"
 	"github.com/evanschultz/float-rw-client/pkg/models"
 )
 
 const (
 	baseURL         = "https://readwise.io/api/v2"
 	defaultPageSize = 100
+	defaultTimeout  = 30 * time.Second
 )
 
+// Call names an API operation for per-call timeouts
+type Call string
+
+const (
+	CallAuth       Call = "auth"
+	CallBooks      Call = "books"
+	CallHighlights Call = "highlights"
+	CallHighlight  Call = "highlight"
+	CallUpdate     Call = "update"
+	CallCreate     Call = "create"
+	CallExport     Call = "export"
+)
+
+// calls are every Call, for ParseCall
+var calls = []Call{CallAuth, CallBooks, CallHighlights, CallHighlight, CallUpdate, CallCreate, CallExport}
+
+// ParseCall reads a call name, e.g. from the [api.timeouts] config
+func ParseCall(name string) (Call, error) {
+	for _, call := range calls {
+		if string(call) == name {
+			return call, nil
+		}
+	}
+	names := make([]string, len(calls))
+	for i, call := range calls {
+		names[i] = string(call)
+	}
+	return "", fmt.Errorf("unknown API call %q: use %s", name, strings.Join(names, ", "))
+}
+
+// Client is a Readwise API client. Every call takes a context that
+// cancels it, and runs under its call's timeout.
 type Client struct {
 	httpClient *http.Client
 	token      string
 	baseURL    string
+	pageSize   int
+	timeout    time.Duration
+	timeouts   map[Call]time.Duration
 }
 
 func NewClient(token string) *Client {
 	return &Client{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		token:   token,
-		baseURL: baseURL,
+		httpClient: &http.Client{},
+		token:      token,
+		baseURL:    baseURL,
+		pageSize:   defaultPageSize,
+		timeout:    defaultTimeout,
+		timeouts:   make(map[Call]time.Duration),
+	}
+}
+
+// SetBaseURL points the client at a different API root, e.g. a proxy
+func (c *Client) SetBaseURL(u string) {
+	if u != "" {
+		c.baseURL = strings.TrimRight(u, "/")
+	}
+}
+
+// Configure applies the [api] config section: base URL, page size and
+// timeouts
+func (c *Client) Configure(cfg config.APIConfig) error {
+	c.SetBaseURL(cfg.BaseURL)
+	c.SetPageSize(cfg.PageSize)
+	c.SetTimeout(time.Duration(cfg.Timeout) * time.Second)
+	for name, seconds := range cfg.Timeouts {
+		call, err := ParseCall(name)
+		if err != nil {
+			return fmt.Errorf("api.timeouts: %w", err)
+		}
+		c.SetCallTimeout(call, time.Duration(seconds)*time.Second)
+	}
+	return nil
+}
+
+// SetPageSize sets the page_size used for list requests
+func (c *Client) SetPageSize(n int) {
+	if n > 0 {
+		c.pageSize = n
+	}
+}
+
+// SetTimeout sets how long a call may take when it has no timeout of its
+// own; 0 or less keeps the current one
+func (c *Client) SetTimeout(d time.Duration) {
+	if d > 0 {
+		c.timeout = d
+	}
+}
+
+// SetCallTimeout sets how long one kind of call may take, e.g. a longer
+// limit for export pages; 0 or less goes back to the client's timeout
+func (c *Client) SetCallTimeout(call Call, d time.Duration) {
+	if d > 0 {
+		c.timeouts[call] = d
+	} else {
+		delete(c.timeouts, call)
 	}
 }
 
-func (c *Client) doRequest(method, path string, params url.Values) ([]byte, error) {
-	return c.doRequestWithBody(method, path, params, nil)
+// Timeout returns how long a call may take
+func (c *Client) Timeout(call Call) time.Duration {
+	if d, ok := c.timeouts[call]; ok {
+		return d
+	}
+	return c.timeout
+}
+
+func (c *Client) doRequest(ctx context.Context, call Call, method, path string, params url.Values) ([]byte, error) {
+	return c.doRequestWithBody(ctx, call, method, path, params, nil)
 }
 
-func (c *Client) doRequestWithBody(method, path string, params url.Values, body interface{}) ([]byte, error) {
+func (c *Client) doRequestWithBody(ctx context.Context, call Call, method, path string, params url.Values, body interface{}) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout(call))
+	defer cancel()
+
 	u, err := url.Parse(c.baseURL + path)
 	if err != nil {
 		return nil, err
@@ -56,7 +156,7 @@ func (c *Client) doRequestWithBody(method, path string, params url.Values, body
 		bodyReader = bytes.NewReader(jsonBody)
 	}
 
-	req, err := http.NewRequest(method, u.String(), bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bodyReader)
 	if err != nil {
 		return nil, err
 	}
@@ -64,29 +164,40 @@ func (c *Client) doRequestWithBody(method, path string, params url.Values, body
 	req.Header.Set("Authorization", "Token "+c.token)
 	req.Header.Set("Content-Type", "application/json")
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		slog.Warn("readwise request failed", "call", call, "method", method, "path", path, "err", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
+	slog.Debug("readwise request", "call", call, "method", method, "path", path, "status", resp.StatusCode, "duration", time.Since(start))
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %d - %s", resp.StatusCode, string(body))
+		slog.Warn("readwise request failed", "call", call, "method", method, "path", path, "status", resp.StatusCode)
+		return nil, newAPIError(resp, body)
 	}
 
 	return io.ReadAll(resp.Body)
 }
 
-func (c *Client) GetHighlights(params url.Values) (*models.HighlightList, error) {
+// ValidateToken checks the token against the /auth/ endpoint, returning
+// ErrUnauthorized (wrapped) for a bad token
+func (c *Client) ValidateToken(ctx context.Context) error {
+	_, err := c.doRequest(ctx, CallAuth, "GET", "/auth/", nil)
+	return err
+}
+
+func (c *Client) GetHighlights(ctx context.Context, params url.Values) (*models.HighlightList, error) {
 	if params == nil {
 		params = url.Values{}
 	}
 	if params.Get("page_size") == "" {
-		params.Set("page_size", fmt.Sprintf("%d", defaultPageSize))
+		params.Set("page_size", fmt.Sprintf("%d", c.pageSize))
 	}
 
-	body, err := c.doRequest("GET", "/highlights/", params)
+	body, err := c.doRequest(ctx, CallHighlights, "GET", "/highlights/", params)
 	if err != nil {
 		return nil, err
 	}
@@ -99,15 +210,15 @@ func (c *Client) GetHighlights(params url.Values) (*models.HighlightList, error)
 	return &result, nil
 }
 
-func (c *Client) GetBooks(params url.Values) (*models.BookList, error) {
+func (c *Client) GetBooks(ctx context.Context, params url.Values) (*models.BookList, error) {
 	if params == nil {
 		params = url.Values{}
 	}
 	if params.Get("page_size") == "" {
-		params.Set("page_size", fmt.Sprintf("%d", defaultPageSize))
+		params.Set("page_size", fmt.Sprintf("%d", c.pageSize))
 	}
 
-	body, err := c.doRequest("GET", "/books/", params)
+	body, err := c.doRequest(ctx, CallBooks, "GET", "/books/", params)
 	if err != nil {
 		return nil, err
 	}
@@ -120,8 +231,8 @@ func (c *Client) GetBooks(params url.Values) (*models.BookList, error) {
 	return &result, nil
 }
 
-func (c *Client) GetHighlight(id int) (*models.Highlight, error) {
-	body, err := c.doRequest("GET", fmt.Sprintf("/highlights/%d/", id), nil)
+func (c *Client) GetHighlight(ctx context.Context, id int) (*models.Highlight, error) {
+	body, err := c.doRequest(ctx, CallHighlight, "GET", fmt.Sprintf("/highlights/%d/", id), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -134,8 +245,8 @@ func (c *Client) GetHighlight(id int) (*models.Highlight, error) {
 	return &result, nil
 }
 
-func (c *Client) UpdateHighlight(id int, update models.HighlightUpdate) (*models.Highlight, error) {
-	body, err := c.doRequestWithBody("PATCH", fmt.Sprintf("/highlights/%d/", id), nil, update)
+func (c *Client) UpdateHighlight(ctx context.Context, id int, update models.HighlightUpdate) (*models.Highlight, error) {
+	body, err := c.doRequestWithBody(ctx, CallUpdate, "PATCH", fmt.Sprintf("/highlights/%d/", id), nil, update)
 	if err != nil {
 		return nil, err
 	}
@@ -147,3 +258,35 @@ func (c *Client) UpdateHighlight(id int, update models.HighlightUpdate) (*models
 
 	return &result, nil
 }
+
+// CreateHighlights adds highlights, returning the books they were filed
+// under
+func (c *Client) CreateHighlights(ctx context.Context, highlights []models.HighlightCreate) ([]models.CreatedBook, error) {
+	body, err := c.doRequestWithBody(ctx, CallCreate, "POST", "/highlights/", nil, map[string]any{"highlights": highlights})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []models.CreatedBook
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Export fetches one page of the full-account export. Pass updatedAfter
+// (ISO 8601) for incremental exports and pageCursor to continue paging.
+func (c *Client) Export(ctx context.Context, params url.Values) (*models.ExportList, error) {
+	body, err := c.doRequest(ctx, CallExport, "GET", "/export/", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result models.ExportList
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,155 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/api"
+	"github.com/evanschultz/float-rw-client/pkg/api/apitest"
+	"github.com/evanschultz/float-rw-client/pkg/models"
+)
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	srv := apitest.NewServer()
+	defer srv.Close()
+	client := srv.APIClient()
+
+	if err := client.ValidateToken(ctx); err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+
+	books, err := client.GetBooks(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if books.Count != 3 || len(books.Results) != 3 {
+		t.Fatalf("got %d books", len(books.Results))
+	}
+
+	params := url.Values{}
+	params.Set("book_id", "1")
+	params.Set("page_size", "2")
+	highlights, err := client.GetHighlights(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if highlights.Count != 3 || len(highlights.Results) != 2 || highlights.Next == "" {
+		t.Fatalf("got %d of %d highlights, next %q", len(highlights.Results), highlights.Count, highlights.Next)
+	}
+
+	favorite := false
+	updated, err := client.UpdateHighlight(ctx, 102, models.HighlightUpdate{Note: "edited", IsFavorite: &favorite})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Note != "edited" || updated.IsFavorite {
+		t.Errorf("update returned %+v", updated)
+	}
+	if got, err := client.GetHighlight(ctx, 102); err != nil || got.Note != "edited" {
+		t.Errorf("GetHighlight after update = %+v, %v", got, err)
+	}
+
+	created, err := client.CreateHighlights(ctx, []models.HighlightCreate{
+		{Text: "a new idea", Title: "Dispatches", Author: "float-line"},
+		{Text: "another", Title: "Dispatches", Author: "float-line"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 1 || created[0].NumHighlights != 2 || len(created[0].ModifiedHighlights) != 2 {
+		t.Errorf("create returned %+v", created)
+	}
+
+	export, err := client.Export(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(export.Results) != 4 || len(export.Results[0].Highlights) != 3 || export.NextPageCursor != nil {
+		t.Errorf("export returned %+v", export)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	ctx := context.Background()
+	srv := apitest.NewServer()
+	defer srv.Close()
+
+	bad := api.NewClient("wrong")
+	bad.SetBaseURL(srv.URL)
+	if err := bad.ValidateToken(ctx); !errors.Is(err, api.ErrUnauthorized) {
+		t.Errorf("bad token: got %v", err)
+	}
+
+	client := srv.APIClient()
+	if _, err := client.GetHighlight(ctx, 999); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("missing highlight: got %v", err)
+	}
+
+	srv.Fail(apitest.Failure{Status: http.StatusTooManyRequests, RetryAfter: 7}, 1)
+	_, err := client.GetBooks(ctx, nil)
+	var apiErr *api.APIError
+	if !errors.Is(err, api.ErrRateLimited) || !errors.As(err, &apiErr) || apiErr.RetryAfter != 7*time.Second {
+		t.Errorf("429: got %v", err)
+	}
+
+	srv.Fail(apitest.Failure{Status: http.StatusInternalServerError}, 2)
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetBooks(ctx, nil); !errors.Is(err, api.ErrServer) {
+			t.Errorf("500 #%d: got %v", i+1, err)
+		}
+	}
+	if _, err := client.GetBooks(ctx, nil); err != nil {
+		t.Errorf("after the injected failures: %v", err)
+	}
+}
+
+func TestClientLatency(t *testing.T) {
+	ctx := context.Background()
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.SetLatency(50 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := srv.APIClient().GetBooks(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("request took %v, want at least the injected latency", elapsed)
+	}
+}
+
+func TestClientCancel(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.SetLatency(time.Second)
+	client := srv.APIClient()
+
+	// Canceling the context gives up on the request at once
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := client.GetBooks(ctx, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want the context's error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("canceled request took %v", elapsed)
+	}
+
+	// So does running past its call's timeout, which overrides the client's
+	client.SetTimeout(time.Minute)
+	client.SetCallTimeout(api.CallHighlights, 20*time.Millisecond)
+	if _, err := client.GetHighlights(context.Background(), nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want the call's timeout", err)
+	}
+	if client.Timeout(api.CallBooks) != time.Minute {
+		t.Errorf("books time out after %v, want the client's timeout", client.Timeout(api.CallBooks))
+	}
+	if _, err := api.ParseCall("everything"); err == nil {
+		t.Error("parsed an unknown call")
+	}
+}
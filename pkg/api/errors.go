@@ -0,0 +1,59 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Sentinel errors for the API failure classes callers act on differently.
+// Use errors.Is against an error returned by any Client method.
+var (
+	ErrUnauthorized = errors.New("readwise: unauthorized")
+	ErrRateLimited  = errors.New("readwise: rate limited")
+	ErrNotFound     = errors.New("readwise: not found")
+	ErrServer       = errors.New("readwise: server error")
+)
+
+// APIError is a non-2xx response from Readwise
+type APIError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // set for 429 responses that include Retry-After
+	kind       error
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error: %d - %s", e.StatusCode, e.Body)
+}
+
+// Unwrap exposes the sentinel error so errors.Is works
+func (e *APIError) Unwrap() error {
+	return e.kind
+}
+
+// newAPIError classifies a failed response
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		apiErr.kind = ErrUnauthorized
+	case resp.StatusCode == http.StatusTooManyRequests:
+		apiErr.kind = ErrRateLimited
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			apiErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+	case resp.StatusCode == http.StatusNotFound:
+		apiErr.kind = ErrNotFound
+	case resp.StatusCode >= 500:
+		apiErr.kind = ErrServer
+	}
+
+	return apiErr
+}
@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/evanschultz/float-rw-client/pkg/config"
+)
+
+const (
+	keyringService = "float-line"
+	keyringAccount = "readwise"
+	tokenFileName  = "token"
+)
+
+// ErrNoToken means no store holds a Readwise token
+var ErrNoToken = errors.New("no Readwise token configured")
+
+// Store persists the Readwise token
+type Store interface {
+	Name() string
+	Get() (string, error)
+	Set(token string) error
+	Delete() error
+}
+
+// DefaultStore uses the OS keyring when a keyring tool is installed and
+// falls back to a 0600 file under the config directory
+func DefaultStore() Store {
+	stores := []Store{}
+	if kr := newKeyringStore(); kr != nil {
+		stores = append(stores, kr)
+	}
+	stores = append(stores, &FileStore{Path: filepath.Join(ConfigDir(), tokenFileName)})
+	return &chainStore{stores: stores}
+}
+
+// ConfigDir returns the selected profile's float-line config directory
+func ConfigDir() string {
+	return config.Dir()
+}
+
+// Resolve finds a token, preferring an explicit flag, then READWISE_TOKEN,
+// then api.token from config.toml, then the store. The returned source
+// names where it came from.
+func Resolve(flagToken, configToken string, store Store) (token, source string, err error) {
+	if flagToken != "" {
+		return flagToken, "flag", nil
+	}
+	if env := os.Getenv("READWISE_TOKEN"); env != "" {
+		return env, "READWISE_TOKEN", nil
+	}
+	if configToken != "" {
+		return configToken, "config.toml", nil
+	}
+
+	token, err = store.Get()
+	if err != nil {
+		return "", "", err
+	}
+	return token, store.Name(), nil
+}
+
+// chainStore reads from the first store holding a token and writes to the
+// first store that accepts it
+type chainStore struct {
+	stores []Store
+}
+
+func (c *chainStore) Name() string {
+	names := make([]string, len(c.stores))
+	for i, s := range c.stores {
+		names[i] = s.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+func (c *chainStore) Get() (string, error) {
+	for _, s := range c.stores {
+		if token, err := s.Get(); err == nil && token != "" {
+			return token, nil
+		}
+	}
+	return "", ErrNoToken
+}
+
+func (c *chainStore) Set(token string) error {
+	var errs []error
+	for _, s := range c.stores {
+		err := s.Set(token)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", s.Name(), err))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *chainStore) Delete() error {
+	var errs []error
+	for _, s := range c.stores {
+		if err := s.Delete(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Location describes where a token would be read from, for onboarding output
+func Location(store Store) string {
+	if c, ok := store.(*chainStore); ok {
+		for _, s := range c.stores {
+			if token, err := s.Get(); err == nil && token != "" {
+				return s.Name()
+			}
+		}
+	}
+	return store.Name()
+}
+
+// FileStore keeps the token in a file readable only by the user
+type FileStore struct {
+	Path string
+}
+
+func (f *FileStore) Name() string { return "file:" + f.Path }
+
+func (f *FileStore) Get() (string, error) {
+	data, err := os.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return "", ErrNoToken
+	}
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", ErrNoToken
+	}
+	return token, nil
+}
+
+func (f *FileStore) Set(token string) error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(f.Path, []byte(token+"\n"), 0600)
+}
+
+func (f *FileStore) Delete() error {
+	err := os.Remove(f.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// keyringStore talks to the OS keyring through its command line tool:
+// `security` on macOS and `secret-tool` (libsecret) on Linux
+type keyringStore struct {
+	tool    string
+	account string // keyringAccount, suffixed with the profile outside the default
+}
+
+func newKeyringStore() *keyringStore {
+	var tool string
+	switch runtime.GOOS {
+	case "darwin":
+		tool = "security"
+	case "linux", "freebsd", "openbsd":
+		tool = "secret-tool"
+	default:
+		return nil
+	}
+	if _, err := exec.LookPath(tool); err != nil {
+		return nil
+	}
+	return &keyringStore{tool: tool, account: profileAccount()}
+}
+
+// profileAccount is the keyring account of the selected profile's token
+func profileAccount() string {
+	account := keyringAccount
+	if profile := config.Profile(); profile != config.DefaultProfile {
+		account += "@" + profile
+	}
+	return account
+}
+
+func (k *keyringStore) Name() string { return "keyring" }
+
+func (k *keyringStore) Get() (string, error) {
+	var cmd *exec.Cmd
+	if k.tool == "security" {
+		cmd = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", k.account, "-w")
+	} else {
+		cmd = exec.Command("secret-tool", "lookup", "service", keyringService, "account", k.account)
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", ErrNoToken
+	}
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", ErrNoToken
+	}
+	return token, nil
+}
+
+func (k *keyringStore) Set(token string) error {
+	var cmd *exec.Cmd
+	if k.tool == "security" {
+		// A trailing -w with no value makes security prompt for the password
+		// and its confirmation, so the token never shows up in ps
+		cmd = exec.Command("security", "add-generic-password", "-U", "-s", keyringService, "-a", k.account, "-w")
+		cmd.Stdin = strings.NewReader(token + "\n" + token + "\n")
+	} else {
+		cmd = exec.Command("secret-tool", "store", "--label=float-line Readwise token", "service", keyringService, "account", k.account)
+		cmd.Stdin = strings.NewReader(token)
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w, output: %s", k.tool, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (k *keyringStore) Delete() error {
+	var cmd *exec.Cmd
+	if k.tool == "security" {
+		cmd = exec.Command("security", "delete-generic-password", "-s", keyringService, "-a", k.account)
+	} else {
+		cmd = exec.Command("secret-tool", "clear", "service", keyringService, "account", k.account)
+	}
+	// Deleting a missing entry is not an error worth reporting
+	_ = cmd.Run()
+	return nil
+}
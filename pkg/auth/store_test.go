@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/evanschultz/float-rw-client/pkg/config"
+)
+
+// memStore is a Store in memory; err, if set, fails Set and Delete
+type memStore struct {
+	name  string
+	token string
+	err   error
+}
+
+func (m *memStore) Name() string { return m.name }
+
+func (m *memStore) Get() (string, error) {
+	if m.token == "" {
+		return "", ErrNoToken
+	}
+	return m.token, nil
+}
+
+func (m *memStore) Set(token string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.token = token
+	return nil
+}
+
+func (m *memStore) Delete() error {
+	if m.err != nil {
+		return m.err
+	}
+	m.token = ""
+	return nil
+}
+
+func TestResolve(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		flag, env, config string
+		stored            string
+		token, source     string
+	}{
+		{"flag", "from-flag", "from-env", "from-config", "from-store", "from-flag", "flag"},
+		{"env", "", "from-env", "from-config", "from-store", "from-env", "READWISE_TOKEN"},
+		{"config", "", "", "from-config", "from-store", "from-config", "config.toml"},
+		{"store", "", "", "", "from-store", "from-store", "mem"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("READWISE_TOKEN", tc.env)
+			token, source, err := Resolve(tc.flag, tc.config, &memStore{name: "mem", token: tc.stored})
+			if err != nil || token != tc.token || source != tc.source {
+				t.Errorf("Resolve = %q from %q, %v, want %q from %q", token, source, err, tc.token, tc.source)
+			}
+		})
+	}
+
+	t.Setenv("READWISE_TOKEN", "")
+	if _, _, err := Resolve("", "", &memStore{name: "mem"}); !errors.Is(err, ErrNoToken) {
+		t.Errorf("Resolve with nothing anywhere = %v", err)
+	}
+}
+
+func TestChainStore(t *testing.T) {
+	broken := &memStore{name: "keyring", err: errors.New("locked")}
+	file := &memStore{name: "file"}
+	chain := &chainStore{stores: []Store{broken, file}}
+
+	if chain.Name() != "keyring+file" {
+		t.Errorf("name = %s", chain.Name())
+	}
+	if _, err := chain.Get(); !errors.Is(err, ErrNoToken) {
+		t.Errorf("Get from empty stores = %v", err)
+	}
+	if Location(chain) != "keyring+file" {
+		t.Errorf("location with no token = %s", Location(chain))
+	}
+
+	// Set falls through to the first store that takes it
+	if err := chain.Set("tok"); err != nil || file.token != "tok" {
+		t.Fatalf("Set = %v, file holds %q", err, file.token)
+	}
+	if token, err := chain.Get(); err != nil || token != "tok" {
+		t.Errorf("Get = %q, %v", token, err)
+	}
+	if Location(chain) != "file" {
+		t.Errorf("location = %s, want the store holding the token", Location(chain))
+	}
+
+	// The first store holding a token wins
+	first := &memStore{name: "keyring", token: "newer"}
+	if token, _ := (&chainStore{stores: []Store{first, file}}).Get(); token != "newer" {
+		t.Errorf("Get = %q, want the first store's", token)
+	}
+
+	// Delete clears every store, reporting the ones that failed
+	if err := chain.Delete(); err == nil || file.token != "" {
+		t.Errorf("Delete = %v, file holds %q", err, file.token)
+	}
+	if err := (&chainStore{stores: []Store{&memStore{name: "a"}, &memStore{name: "b", err: errors.New("x")}}}).Set("tok"); err != nil {
+		t.Errorf("Set stopped at a later failing store: %v", err)
+	}
+	if err := (&chainStore{stores: []Store{broken}}).Set("tok"); err == nil || err.Error() != "keyring: locked" {
+		t.Errorf("Set with every store failing = %v", err)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles", "work", "token")
+	f := &FileStore{Path: path}
+
+	if _, err := f.Get(); !errors.Is(err, ErrNoToken) {
+		t.Errorf("Get of a missing file = %v", err)
+	}
+	if err := f.Delete(); err != nil {
+		t.Errorf("Delete of a missing file = %v", err)
+	}
+
+	if err := f.Set("tok"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("token file mode = %o, want 600", perm)
+	}
+	if token, err := f.Get(); err != nil || token != "tok" {
+		t.Errorf("Get = %q, %v", token, err)
+	}
+
+	if err := os.WriteFile(path, []byte("  \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Get(); !errors.Is(err, ErrNoToken) {
+		t.Errorf("Get of a blank file = %v", err)
+	}
+
+	if err := f.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("token file still there: %v", err)
+	}
+}
+
+func TestKeyringStore(t *testing.T) {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		t.Skip("secret-tool not installed")
+	}
+	// A profile of its own keeps the real token out of the way
+	if err := config.SetProfile("float-line-test"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = config.SetProfile("") })
+
+	k := newKeyringStore()
+	if k == nil {
+		t.Skip("no keyring tool")
+	}
+	if err := k.Set("tok"); err != nil {
+		t.Skipf("keyring unavailable: %v", err)
+	}
+	defer k.Delete()
+	if token, err := k.Get(); err != nil || token != "tok" {
+		t.Errorf("Get = %q, %v", token, err)
+	}
+	if err := k.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Get(); !errors.Is(err, ErrNoToken) {
+		t.Errorf("Get after Delete = %v", err)
+	}
+}
+
+func TestProfileAccount(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(config.ProfileEnv, "")
+	t.Cleanup(func() { _ = config.SetProfile("") })
+
+	for _, tc := range []struct {
+		profile, env string
+		account      string
+	}{
+		{"", "", "readwise"},
+		{"default", "", "readwise"},
+		{"work", "", "readwise@work"},
+		{"", "home", "readwise@home"},
+		{"work", "home", "readwise@work"},
+	} {
+		t.Setenv(config.ProfileEnv, tc.env)
+		if err := config.SetProfile(tc.profile); err != nil {
+			t.Fatal(err)
+		}
+		if got := profileAccount(); got != tc.account {
+			t.Errorf("profile %q, %s=%q: account %s, want %s", tc.profile, config.ProfileEnv, tc.env, got, tc.account)
+		}
+	}
+
+	// The file fallback moves with the profile's config dir too
+	if err := config.SetProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "float-line", "profiles", "work"); ConfigDir() != want {
+		t.Errorf("config dir = %s, want %s", ConfigDir(), want)
+	}
+}
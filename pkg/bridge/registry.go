@@ -0,0 +1,239 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// End is one place a bridge appears: the bridge:: node that opened it
+// (the origin) or a later node that references its ID
+type End struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Text   string `json:"text"`
+	Origin bool   `json:"origin,omitempty"`
+}
+
+// Bridge is a registered bridge with every end found so far. Context is the
+// origin's subtree, kept so the bridge can be restored elsewhere.
+type Bridge struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	Ends    []End     `json:"ends"`
+	Context []string  `json:"context,omitempty"`
+}
+
+// Problem is a registry inconsistency found by Validate
+type Problem struct {
+	ID      string
+	End     End
+	Message string
+}
+
+// Registry tracks bridges across files in a JSON file
+type Registry struct {
+	path string
+	mu   sync.Mutex
+
+	Next       int                `json:"next"` // sequence for the next generated ID
+	Bridges    map[string]*Bridge `json:"bridges"`
+	Unassigned []End              `json:"unassigned,omitempty"` // bridge:: nodes without an ID
+}
+
+// Load reads the registry at path; a missing file is an empty registry
+func Load(path string) (*Registry, error) {
+	r := &Registry{path: path, Next: 1, Bridges: make(map[string]*Bridge)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read bridge registry: %w", err)
+	}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("parse bridge registry %s: %w", path, err)
+	}
+	if r.Bridges == nil {
+		r.Bridges = make(map[string]*Bridge)
+	}
+	if r.Next < 1 {
+		r.Next = 1
+	}
+	return r, nil
+}
+
+// Path returns the registry file location
+func (r *Registry) Path() string {
+	return r.path
+}
+
+// Save writes the registry back to disk
+func (r *Registry) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	// Write-then-rename so a crash never leaves a torn registry
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write bridge registry: %w", err)
+	}
+	return os.Rename(tmp, r.path)
+}
+
+// NextID reserves the next sequential ID, CB-YYYYMMDD-HHMM-XXXX
+func (r *Registry) NextID(now time.Time) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for {
+		id := fmt.Sprintf("CB-%s-%04d", now.Format("20060102-1504"), r.Next)
+		r.Next++
+		if _, taken := r.Bridges[id]; !taken {
+			r.Bridges[id] = &Bridge{ID: id, Created: now}
+			return id
+		}
+	}
+}
+
+// Sync replaces everything recorded for file with what content contains
+func (r *Registry) Sync(file, content string) {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	found := Scan(content)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, b := range r.Bridges {
+		kept := b.Ends[:0]
+		for _, end := range b.Ends {
+			if end.File != file {
+				kept = append(kept, end)
+			}
+		}
+		b.Ends = kept
+	}
+	unassigned := r.Unassigned[:0]
+	for _, end := range r.Unassigned {
+		if end.File != file {
+			unassigned = append(unassigned, end)
+		}
+	}
+	r.Unassigned = unassigned
+
+	for _, f := range found {
+		end := End{File: file, Line: f.Line, Text: f.Text, Origin: f.Origin}
+		if f.ID == "" {
+			r.Unassigned = append(r.Unassigned, end)
+			continue
+		}
+
+		b, ok := r.Bridges[f.ID]
+		if !ok {
+			b = &Bridge{ID: f.ID, Created: time.Now()}
+			r.Bridges[f.ID] = b
+		}
+		// The first origin owns the context; later ones are duplicates
+		if f.Origin && !b.hasOrigin() {
+			b.Context = f.Context
+		}
+		b.Ends = append(b.Ends, end)
+	}
+}
+
+// Get returns a bridge by ID
+func (r *Registry) Get(id string) (*Bridge, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.Bridges[id]
+	return b, ok
+}
+
+// List returns bridges ordered by ID, which is also creation order
+func (r *Registry) List() []*Bridge {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bridges := make([]*Bridge, 0, len(r.Bridges))
+	for _, b := range r.Bridges {
+		bridges = append(bridges, b)
+	}
+	sort.Slice(bridges, func(i, j int) bool { return bridges[i].ID < bridges[j].ID })
+	return bridges
+}
+
+// Validate reports duplicate origins, references to bridges nobody opened,
+// and bridge:: nodes without an ID
+func (r *Registry) Validate() []Problem {
+	var problems []Problem
+	for _, b := range r.List() {
+		var origins []End
+		for _, end := range b.Ends {
+			if end.Origin {
+				origins = append(origins, end)
+			}
+		}
+
+		switch {
+		case len(origins) > 1:
+			for _, end := range origins[1:] {
+				problems = append(problems, Problem{ID: b.ID, End: end,
+					Message: fmt.Sprintf("duplicate bridge-id %s, first opened at %s:%d", b.ID, origins[0].File, origins[0].Line)})
+			}
+		case len(origins) == 0 && len(b.Ends) > 0:
+			for _, end := range b.Ends {
+				problems = append(problems, Problem{ID: b.ID, End: end,
+					Message: fmt.Sprintf("references %s but no bridge:: node opens it", b.ID)})
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, end := range r.Unassigned {
+		problems = append(problems, Problem{End: end, Message: "bridge:: has no [bridge-id:: ...]"})
+	}
+	return problems
+}
+
+func (b *Bridge) hasOrigin() bool {
+	for _, end := range b.Ends {
+		if end.Origin {
+			return true
+		}
+	}
+	return false
+}
+
+// OtherEnd returns the end to jump to from the given file and node text:
+// the next end after it, wrapping around
+func (b *Bridge) OtherEnd(file, text string) (End, bool) {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	if len(b.Ends) < 2 {
+		return End{}, false
+	}
+
+	for i, end := range b.Ends {
+		if end.File == file && strings.TrimSpace(end.Text) == strings.TrimSpace(text) {
+			return b.Ends[(i+1)%len(b.Ends)], true
+		}
+	}
+	return b.Ends[0], true
+}
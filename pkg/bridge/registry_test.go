@@ -0,0 +1,142 @@
+package bridge
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		files map[string]string
+		want  []string // "<id> <file>:<line> <message prefix>"
+	}{
+		{
+			name: "consistent",
+			files: map[string]string{
+				"a.md": "• bridge:: restore here [bridge-id:: CB-1]\n  • context",
+				"b.md": "• picked up [bridge-id:: CB-1]",
+			},
+		},
+		{
+			name: "duplicate",
+			files: map[string]string{
+				"a.md": "• bridge:: first [bridge-id:: CB-1]",
+				"b.md": "• notes\n• bridge:: again [bridge-id:: CB-1]",
+			},
+			want: []string{"CB-1 b.md:2 duplicate bridge-id CB-1"},
+		},
+		{
+			name: "dangling",
+			files: map[string]string{
+				"a.md": "• see [bridge-id:: CB-9]\n• and [bridge-id:: CB-9]",
+			},
+			want: []string{"CB-9 a.md:1 references CB-9", "CB-9 a.md:2 references CB-9"},
+		},
+		{
+			name: "unassigned",
+			files: map[string]string{
+				"a.md": "• notes\n  • bridge:: no id yet",
+			},
+			want: []string{" a.md:2 bridge:: has no"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			r, err := Load(filepath.Join(dir, "bridges.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, content := range tc.files {
+				r.Sync(filepath.Join(dir, name), content)
+			}
+
+			var got []string
+			for _, p := range r.Validate() {
+				got = append(got, fmt.Sprintf("%s %s:%d %s", p.ID, filepath.Base(p.End.File), p.End.Line, p.Message))
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("problems = %q, want %q", got, tc.want)
+			}
+			slices.Sort(got)
+			for i := range got {
+				if !strings.HasPrefix(got[i], tc.want[i]) {
+					t.Errorf("problem %q, want %q…", got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSync(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.md"), filepath.Join(dir, "b.md")
+	r, err := Load(filepath.Join(dir, "bridges.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 10, 14, 9, 30, 0, 0, time.UTC)
+	id := r.NextID(now)
+	if id != "CB-20261014-0930-0001" {
+		t.Fatalf("NextID = %s", id)
+	}
+	r.Sync(a, "• bridge:: pick this up [bridge-id:: "+id+"]\n  • the plan\n    • step one\n• elsewhere")
+	r.Sync(b, "• back to it [bridge-id:: "+id+"]\n• bridge:: unnamed")
+
+	bridge, ok := r.Get(id)
+	if !ok || len(bridge.Ends) != 2 || !bridge.Ends[0].Origin || bridge.Ends[1].File != b {
+		t.Fatalf("bridge = %+v", bridge)
+	}
+	if want := []string{"• bridge:: pick this up [bridge-id:: " + id + "]", "  • the plan", "    • step one"}; !slices.Equal(bridge.Context, want) {
+		t.Errorf("context = %q, want %q", bridge.Context, want)
+	}
+	if end, ok := bridge.OtherEnd(a, "bridge:: pick this up [bridge-id:: "+id+"]"); !ok || end.File != b {
+		t.Errorf("OtherEnd from the origin = %+v, %v", end, ok)
+	}
+	if end, ok := bridge.OtherEnd(b, "back to it [bridge-id:: "+id+"]"); !ok || end.File != a {
+		t.Errorf("OtherEnd from the reference = %+v, %v", end, ok)
+	}
+	if len(r.Unassigned) != 1 || r.Unassigned[0].Line != 2 {
+		t.Errorf("unassigned = %+v", r.Unassigned)
+	}
+
+	// Re-syncing a file replaces only its own ends: the moved origin keeps
+	// its bridge, and b.md's unnamed bridge:: got its ID
+	r.Sync(a, "• elsewhere\n• bridge:: moved down [bridge-id:: "+id+"]")
+	r.Sync(b, "• back to it [bridge-id:: "+id+"]\n• bridge:: unnamed [bridge-id:: CB-2]")
+	if bridge, _ := r.Get(id); len(bridge.Ends) != 2 || bridge.Ends[0].Line != 2 || bridge.Ends[0].File != a {
+		t.Errorf("after re-sync, ends = %+v", bridge.Ends)
+	}
+	if bridge, _ := r.Get(id); !slices.Equal(bridge.Context, []string{"• bridge:: moved down [bridge-id:: " + id + "]"}) {
+		t.Errorf("context after the origin moved = %q", bridge.Context)
+	}
+	if len(r.Unassigned) != 0 {
+		t.Errorf("unassigned after naming it = %+v", r.Unassigned)
+	}
+	if problems := r.Validate(); len(problems) != 0 {
+		t.Errorf("problems = %+v", problems)
+	}
+
+	// Removing the origin leaves its references dangling
+	r.Sync(a, "• elsewhere")
+	if problems := r.Validate(); len(problems) != 1 || problems[0].End.File != b {
+		t.Errorf("problems after removing the origin = %+v", problems)
+	}
+
+	// The registry round-trips, keeping the ID sequence
+	if err := r.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(r.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.List()) != 2 || loaded.NextID(now) != "CB-20261014-0930-0002" {
+		t.Errorf("loaded %d bridges, next %d", len(loaded.List()), loaded.Next)
+	}
+}
@@ -0,0 +1,90 @@
+package bridge
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	idRegex     = regexp.MustCompile(`\[bridge-id::\s*([^\]\s]+)\s*\]`)
+	originRegex = regexp.MustCompile(`(^|\s)bridge::`)
+)
+
+// Found is a bridge end in a document
+type Found struct {
+	ID      string // empty for a bridge:: node that has none yet
+	Line    int
+	Text    string
+	Origin  bool
+	Context []string // origin subtree, indented relative to the origin
+}
+
+// ID returns the bridge ID annotated on a line
+func ID(text string) (string, bool) {
+	m := idRegex.FindStringSubmatch(text)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// IsOrigin reports whether a line opens a bridge
+func IsOrigin(text string) bool {
+	return originRegex.MatchString(text)
+}
+
+// Scan finds every bridge:: node and [bridge-id::] reference in an outline
+func Scan(content string) []Found {
+	lines := strings.Split(content, "\n")
+	var found []Found
+
+	for i, line := range lines {
+		level, text := splitLine(line)
+		if text == "" {
+			continue
+		}
+
+		id, hasID := ID(text)
+		origin := IsOrigin(text)
+		if !hasID && !origin {
+			continue
+		}
+
+		f := Found{ID: id, Line: i + 1, Text: text, Origin: origin}
+		if origin {
+			f.Context = subtree(lines, i, level)
+		}
+		found = append(found, f)
+	}
+	return found
+}
+
+// subtree returns the node at start and its descendants as outline lines
+func subtree(lines []string, start, level int) []string {
+	_, text := splitLine(lines[start])
+	context := []string{"• " + text}
+	for _, line := range lines[start+1:] {
+		childLevel, childText := splitLine(line)
+		if childText == "" {
+			continue
+		}
+		if childLevel <= level {
+			break
+		}
+		context = append(context, strings.Repeat("  ", childLevel-level)+"• "+childText)
+	}
+	return context
+}
+
+// splitLine returns an outline line's indent level and text without bullet
+func splitLine(line string) (int, string) {
+	level := 0
+	for strings.HasPrefix(line, "  ") {
+		level++
+		line = line[2:]
+	}
+	line = strings.TrimPrefix(line, "• ")
+	line = strings.TrimPrefix(line, "◦ ")
+	line = strings.TrimPrefix(line, "- ")
+	return level, strings.TrimSpace(line)
+}
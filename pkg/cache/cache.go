@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/evanschultz/float-rw-client/pkg/config"
+)
+
+const libraryFile = "library.json"
+
+// Library is what float-rw keeps locally about the Readwise library: how
+// the book list is sorted and filtered, and which books are archived.
+// Archiving never touches Readwise.
+type Library struct {
+	Sort     string       `json:"sort,omitempty"`     // "recent", "title" or "highlights"
+	Category string       `json:"category,omitempty"` // Readwise category shown; empty shows all
+	Tag      string       `json:"tag,omitempty"`      // book tag shown; empty shows all
+	Archived map[int]bool `json:"archived,omitempty"` // book IDs hidden from the default list
+
+	path string
+}
+
+// Dir returns ~/.cache/float-line, honoring XDG_CACHE_HOME, or the
+// selected profile's directory under it
+func Dir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(".float-line", config.ProfileDir())
+	}
+	return filepath.Join(dir, "float-line", config.ProfileDir())
+}
+
+// DefaultPath is where the library is kept unless told otherwise
+func DefaultPath() string {
+	return filepath.Join(Dir(), libraryFile)
+}
+
+// NewLibrary creates a library kept in memory only
+func NewLibrary() *Library {
+	return &Library{Archived: make(map[int]bool)}
+}
+
+// Open reads the library at path; a missing file is an empty library that
+// Save creates
+func Open(path string) (*Library, error) {
+	l := NewLibrary()
+	l.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read library cache: %w", err)
+	}
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("parse library cache %s: %w", path, err)
+	}
+	if l.Archived == nil {
+		l.Archived = make(map[int]bool)
+	}
+	return l, nil
+}
+
+// IsArchived reports whether a book is hidden from the default list
+func (l *Library) IsArchived(bookID int) bool {
+	return l.Archived[bookID]
+}
+
+// SetArchived archives or restores a book
+func (l *Library) SetArchived(bookID int, archived bool) {
+	if archived {
+		l.Archived[bookID] = true
+	} else {
+		delete(l.Archived, bookID)
+	}
+}
+
+// Save writes the library back to its file; libraries kept in memory
+// aren't written
+func (l *Library) Save() error {
+	if l.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(l.path, data, 0644); err != nil {
+		return fmt.Errorf("write library cache: %w", err)
+	}
+	return nil
+}
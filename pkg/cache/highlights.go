@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/models"
+)
+
+const highlightsFile = "highlights.json"
+
+// Highlights is a local copy of every highlight in the Readwise library,
+// kept current from /export/ so queries across books don't refetch them.
+// It's safe to use from several goroutines.
+type Highlights struct {
+	mu   sync.Mutex
+	path string
+	data highlightsData
+}
+
+// highlightsData is what's kept in the highlights file
+type highlightsData struct {
+	UpdatedAfter string                    `json:"updated_after,omitempty"` // when the last complete sync started, RFC 3339
+	Synced       time.Time                 `json:"synced,omitempty"`        // when it finished
+	Books        map[int]models.ExportBook `json:"books"`
+}
+
+// HighlightsPath is where the highlights are kept unless told otherwise
+func HighlightsPath() string {
+	return filepath.Join(Dir(), highlightsFile)
+}
+
+// NewHighlights creates a highlights cache kept in memory only
+func NewHighlights() *Highlights {
+	return &Highlights{data: highlightsData{Books: make(map[int]models.ExportBook)}}
+}
+
+// OpenHighlights reads the highlights at path; a missing file is an empty
+// cache that Save creates
+func OpenHighlights(path string) (*Highlights, error) {
+	h := NewHighlights()
+	h.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read highlights cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &h.data); err != nil {
+		return nil, fmt.Errorf("parse highlights cache %s: %w", path, err)
+	}
+	if h.data.Books == nil {
+		h.data.Books = make(map[int]models.ExportBook)
+	}
+	return h, nil
+}
+
+// UpdatedAfter is the updatedAfter an export needs to bring the cache
+// up to date; empty before the first complete sync
+func (h *Highlights) UpdatedAfter() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.data.UpdatedAfter
+}
+
+// Fresh reports whether the last complete sync finished within ttl of now
+func (h *Highlights) Fresh(now time.Time, ttl time.Duration) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return !h.data.Synced.IsZero() && now.Sub(h.data.Synced) < ttl
+}
+
+// Merge adds a page of exported books, replacing the highlights it has
+// again and keeping the rest
+func (h *Highlights) Merge(books []models.ExportBook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, book := range books {
+		stored, ok := h.data.Books[book.UserBookID]
+		if !ok {
+			h.data.Books[book.UserBookID] = book
+			continue
+		}
+		at := make(map[int]int, len(stored.Highlights))
+		for i, highlight := range stored.Highlights {
+			at[highlight.ID] = i
+		}
+		highlights := stored.Highlights
+		for _, highlight := range book.Highlights {
+			if i, ok := at[highlight.ID]; ok {
+				highlights[i] = highlight
+			} else {
+				highlights = append(highlights, highlight)
+			}
+		}
+		book.Highlights = highlights
+		h.data.Books[book.UserBookID] = book
+	}
+}
+
+// Complete records a sync that started at started and merged every page
+func (h *Highlights) Complete(started, finished time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.data.UpdatedAfter = started.UTC().Format(time.RFC3339)
+	h.data.Synced = finished
+}
+
+// Each calls fn with every highlight and its book, holding the cache
+// while it runs
+func (h *Highlights) Each(fn func(book models.ExportBook, highlight models.ExportHighlight)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, book := range h.data.Books {
+		for _, highlight := range book.Highlights {
+			fn(book, highlight)
+		}
+	}
+}
+
+// Save writes the highlights back to their file; caches kept in memory
+// aren't written
+func (h *Highlights) Save() error {
+	if h.path == "" {
+		return nil
+	}
+	h.mu.Lock()
+	data, err := json.Marshal(h.data)
+	h.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+	if err := os.WriteFile(h.path, data, 0644); err != nil {
+		return fmt.Errorf("write highlights cache: %w", err)
+	}
+	return nil
+}
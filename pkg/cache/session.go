@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const sessionFile = "session.json"
+
+// Session is the layout both TUIs restore: the pane ratios ctrl+arrows
+// resize and the layout preset in use. A zero ratio is the default layout,
+// an empty preset the custom one those ratios make.
+type Session struct {
+	BookRatio   float64 `json:"book_ratio,omitempty"`   // float-rw split layout: the books pane's share of the width
+	DetailRatio float64 `json:"detail_ratio,omitempty"` // float-rw split layout: the detail pane's share of the width
+	SplitRatio  float64 `json:"split_ratio,omitempty"`  // float-rw split layout: the highlight's share of the detail pane
+	DebugRatio  float64 `json:"debug_ratio,omitempty"`  // float-outliner: the debug panel's share of the height
+
+	Layout         string `json:"layout,omitempty"`          // float-rw: the split layout's preset
+	OutlinerLayout string `json:"outliner_layout,omitempty"` // float-outliner: its preset
+
+	path string
+}
+
+// SessionPath is where the session is kept unless told otherwise
+func SessionPath() string {
+	return filepath.Join(Dir(), sessionFile)
+}
+
+// NewSession creates a session kept in memory only
+func NewSession() *Session {
+	return &Session{}
+}
+
+// OpenSession reads the session at path; a missing file is the default
+// layout, which Update creates
+func OpenSession(path string) (*Session, error) {
+	s := &Session{path: path}
+	if err := s.read(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// read loads the session file over s; a missing file leaves s as it is
+func (s *Session) read() error {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read session: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return fmt.Errorf("parse session %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// Update applies change to the session and writes it back. The file is
+// read again first, so ratios the other TUI saved meanwhile are kept;
+// sessions kept in memory aren't written.
+func (s *Session) Update(change func(s *Session)) error {
+	if s.path == "" {
+		change(s)
+		return nil
+	}
+	if err := s.read(); err != nil {
+		return err
+	}
+	change(s)
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("write session: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Package calendar reads calendars from ICS files, ICS URLs and CalDAV
+// servers so captures can be placed against what was on the calendar
+package calendar
+
+import (
+	"sort"
+	"time"
+)
+
+// Event is one occurrence of a calendar event
+type Event struct {
+	Summary  string
+	Location string
+	Start    time.Time
+	End      time.Time
+	AllDay   bool
+}
+
+// During reports whether the event is on at t; an event with no length is
+// on only at its start
+func (e Event) During(t time.Time) bool {
+	if !e.End.After(e.Start) {
+		return t.Equal(e.Start)
+	}
+	return !t.Before(e.Start) && t.Before(e.End)
+}
+
+// Calendar holds parsed events; recurring ones are expanded when asked for
+// a range. A nil Calendar has no events.
+type Calendar struct {
+	events []vevent
+}
+
+// vevent is a VEVENT as parsed: its first occurrence, recurrence and the
+// occurrences it replaces or drops
+type vevent struct {
+	Event
+	uid        string
+	rule       *rrule
+	exdates    map[int64]bool
+	recurrence time.Time // RECURRENCE-ID: the occurrence of uid this replaces
+}
+
+// Merge adds other's events to c
+func (c *Calendar) Merge(other *Calendar) {
+	if other != nil {
+		c.events = append(c.events, other.events...)
+	}
+}
+
+// Len is how many events c holds, counting a recurring event once
+func (c *Calendar) Len() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.events)
+}
+
+// Between returns the occurrences overlapping [from, to), by start time
+func (c *Calendar) Between(from, to time.Time) []Event {
+	if c == nil {
+		return nil
+	}
+
+	// Occurrences moved or changed on their own replace the series'
+	replaced := map[string]map[int64]bool{}
+	for _, ev := range c.events {
+		if !ev.recurrence.IsZero() {
+			if replaced[ev.uid] == nil {
+				replaced[ev.uid] = map[int64]bool{}
+			}
+			replaced[ev.uid][ev.recurrence.Unix()] = true
+		}
+	}
+
+	var found []Event
+	overlaps := func(e Event) bool {
+		end := e.End
+		if !end.After(e.Start) {
+			end = e.Start.Add(time.Nanosecond)
+		}
+		return e.Start.Before(to) && end.After(from)
+	}
+	for _, ev := range c.events {
+		if ev.rule == nil || !ev.recurrence.IsZero() {
+			if overlaps(ev.Event) {
+				found = append(found, ev.Event)
+			}
+			continue
+		}
+		length := ev.End.Sub(ev.Start)
+		// An occurrence starting before from may still be on at from
+		ev.rule.each(ev.Start, from.Add(-length), to, func(start time.Time) {
+			if ev.exdates[start.Unix()] || replaced[ev.uid][start.Unix()] {
+				return
+			}
+			occurrence := ev.Event
+			occurrence.Start, occurrence.End = start, start.Add(length)
+			if ev.AllDay {
+				// Whole days stay whole across DST changes
+				days := int(length.Hours()+12) / 24
+				occurrence.End = start.AddDate(0, 0, days)
+			}
+			if overlaps(occurrence) {
+				found = append(found, occurrence)
+			}
+		})
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if !found[i].Start.Equal(found[j].Start) {
+			return found[i].Start.Before(found[j].Start)
+		}
+		return found[i].End.Before(found[j].End)
+	})
+	return found
+}
+
+// At returns the timed event on at t; of several, the one that started
+// last. All-day events are left out: they don't say what was happening.
+func (c *Calendar) At(t time.Time) (Event, bool) {
+	var at Event
+	ok := false
+	for _, e := range c.Between(t.Add(-24*time.Hour), t.Add(time.Nanosecond)) {
+		if e.AllDay || !e.During(t) {
+			continue
+		}
+		if !ok || !e.Start.Before(at.Start) {
+			at, ok = e, true
+		}
+	}
+	return at, ok
+}
+
+// Day returns the occurrences on t's day
+func (c *Calendar) Day(t time.Time) []Event {
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return c.Between(start, start.AddDate(0, 0, 1))
+}
@@ -0,0 +1,174 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/config"
+)
+
+const testICS = `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+UID:standup
+DTSTART;TZID=America/New_York:20250804T090000
+DTEND;TZID=America/New_York:20250804T091500
+RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR
+EXDATE;TZID=America/New_York:20250806T090000
+SUMMARY:Standup
+BEGIN:VALARM
+TRIGGER:-PT5M
+SUMMARY:Alarm
+END:VALARM
+END:VEVENT
+BEGIN:VEVENT
+UID:standup
+RECURRENCE-ID;TZID=America/New_York:20250808T090000
+DTSTART;TZID=America/New_York:20250808T100000
+DURATION:PT30M
+SUMMARY:Standup (moved)
+END:VEVENT
+BEGIN:VEVENT
+UID:review
+DTSTART:20250805T180000Z
+DTEND:20250805T193000Z
+SUMMARY:Design review\, float-line
+  planning
+END:VEVENT
+BEGIN:VEVENT
+UID:offsite
+DTSTART;VALUE=DATE:20250805
+SUMMARY:Offsite
+END:VEVENT
+BEGIN:VEVENT
+UID:cancelled
+DTSTART:20250805T190000Z
+DTEND:20250805T200000Z
+STATUS:CANCELLED
+SUMMARY:Cancelled
+END:VEVENT
+BEGIN:VEVENT
+UID:retro
+DTSTART:20250131T150000Z
+DTEND:20250131T160000Z
+RRULE:FREQ=MONTHLY;BYDAY=-1FR;COUNT=8
+SUMMARY:Retro
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestParse(t *testing.T) {
+	c, err := Parse(strings.NewReader(testICS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+
+	var got []string
+	for _, e := range c.Between(time.Date(2025, 8, 4, 0, 0, 0, 0, ny), time.Date(2025, 8, 9, 0, 0, 0, 0, ny)) {
+		// All-day events are dates in local time, wherever the test runs
+		if e.AllDay {
+			got = append(got, fmt.Sprintf("%s %s all day", e.Summary, e.Start.Format("Mon")))
+			continue
+		}
+		got = append(got, fmt.Sprintf("%s %s-%s", e.Summary, e.Start.In(ny).Format("Mon 15:04"), e.End.In(ny).Format("15:04")))
+	}
+	want := []string{
+		"Standup Mon 09:00-09:15",
+		"Offsite Tue all day",
+		"Design review, float-line planning Tue 14:00-15:30",
+		"Standup (moved) Fri 10:00-10:30",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("events:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if e, ok := c.At(time.Date(2025, 8, 5, 14, 45, 0, 0, ny)); !ok || e.Summary != "Design review, float-line planning" {
+		t.Errorf("At(review) = %q, %v", e.Summary, ok)
+	}
+	if e, ok := c.At(time.Date(2025, 8, 5, 12, 0, 0, 0, ny)); ok {
+		t.Errorf("At(noon) = %q; all-day events don't count", e.Summary)
+	}
+	if _, ok := c.At(time.Date(2025, 8, 8, 9, 5, 0, 0, ny)); ok {
+		t.Error("moved standup still on at its old time")
+	}
+}
+
+func TestRecurrence(t *testing.T) {
+	c, err := Parse(strings.NewReader(testICS))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var retros []string
+	for _, e := range c.Between(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		if e.Summary == "Retro" {
+			retros = append(retros, e.Start.UTC().Format("Jan 2"))
+		}
+	}
+	want := "Jan 31 Feb 28 Mar 28 Apr 25 May 30 Jun 27 Jul 25 Aug 29"
+	if got := strings.Join(retros, " "); got != want {
+		t.Errorf("last Fridays = %s, want %s", got, want)
+	}
+
+	if _, err := Parse(strings.NewReader("BEGIN:VEVENT\nDTSTART:20250101T000000Z\nRRULE:FREQ=HOURLY\nEND:VEVENT\n")); err == nil {
+		t.Error("HOURLY rule parsed")
+	}
+}
+
+func TestLoadCalDAV(t *testing.T) {
+	event := strings.Replace(testICS, "\n", "\r\n", -1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); r.Method != "REPORT" || r.Header.Get("Depth") != "1" || user != "evan" || pass != "secret" {
+			http.Error(w, "no", http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprintf(w, `<?xml version="1.0"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response><d:href>/cal/1.ics</d:href>
+    <d:propstat><d:prop><cal:calendar-data>%s</cal:calendar-data></d:prop></d:propstat>
+  </d:response>
+</d:multistatus>`, event)
+	}))
+	defer srv.Close()
+
+	cfg := config.CalendarConfig{CalDAV: srv.URL, Username: "evan", Password: "secret"}
+	c, err := Load(context.Background(), cfg, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 5 {
+		t.Errorf("got %d events, want 5", c.Len())
+	}
+
+	cfg.Password = "wrong"
+	if _, err := Load(context.Background(), cfg, time.Now()); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("bad password: err = %v", err)
+	}
+}
+
+func TestFeed(t *testing.T) {
+	if NewFeed(config.CalendarConfig{}) != nil {
+		t.Error("feed without a calendar")
+	}
+	f := NewFeed(config.CalendarConfig{ICS: "testdata/missing.ics", Refresh: 15})
+	now := time.Now()
+	if !f.Due(now) || f.Due(now.Add(time.Minute)) {
+		t.Error("fetch not claimed")
+	}
+	if err := f.Refresh(context.Background()); err == nil || f.Calendar() != nil {
+		t.Errorf("missing file: err = %v", err)
+	}
+	if !f.Due(now.Add(15 * time.Minute)) {
+		t.Error("not due after refresh minutes")
+	}
+}
@@ -0,0 +1,216 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/config"
+)
+
+// caldavWindow is how far either side of now a CalDAV query asks for
+// events
+const caldavWindow = 30 * 24 * time.Hour
+
+// fetchTimeout bounds one fetch of every configured source
+const fetchTimeout = 30 * time.Second
+
+// Configured reports whether cfg names a calendar to read
+func Configured(cfg config.CalendarConfig) bool {
+	return cfg.ICS != "" || cfg.CalDAV != ""
+}
+
+// Load reads every calendar cfg names: an .ics file or URL, and a CalDAV
+// calendar collection, queried for events within a month of now
+func Load(ctx context.Context, cfg config.CalendarConfig, now time.Time) (*Calendar, error) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	c := &Calendar{}
+	if cfg.ICS != "" {
+		ics, err := loadICS(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("calendar %s: %w", cfg.ICS, err)
+		}
+		c.Merge(ics)
+	}
+	if cfg.CalDAV != "" {
+		dav, err := queryCalDAV(ctx, cfg, now.Add(-caldavWindow), now.Add(caldavWindow))
+		if err != nil {
+			return nil, fmt.Errorf("calendar %s: %w", cfg.CalDAV, err)
+		}
+		c.Merge(dav)
+	}
+	return c, nil
+}
+
+// loadICS reads an .ics file, or fetches one over http(s) or webcal
+func loadICS(ctx context.Context, cfg config.CalendarConfig) (*Calendar, error) {
+	source := cfg.ICS
+	if rest, ok := strings.CutPrefix(source, "webcal://"); ok {
+		source = "https://" + rest
+	}
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		f, err := os.Open(config.ExpandHome(source))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return Parse(f)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := do(req, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(bytes.NewReader(body))
+}
+
+// calendarQuery asks a CalDAV server for the events overlapping a range
+const calendarQuery = `<?xml version="1.0" encoding="utf-8"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><C:calendar-data/></D:prop>
+  <C:filter>
+    <C:comp-filter name="VCALENDAR">
+      <C:comp-filter name="VEVENT">
+        <C:time-range start="%s" end="%s"/>
+      </C:comp-filter>
+    </C:comp-filter>
+  </C:filter>
+</C:calendar-query>`
+
+// multistatus is the part of a CalDAV REPORT reply holding calendar data
+type multistatus struct {
+	Responses []struct {
+		Propstats []struct {
+			Data string `xml:"prop>calendar-data"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// queryCalDAV runs a calendar-query REPORT against a calendar collection
+func queryCalDAV(ctx context.Context, cfg config.CalendarConfig, from, to time.Time) (*Calendar, error) {
+	const layout = "20060102T150405Z"
+	body := fmt.Sprintf(calendarQuery, from.UTC().Format(layout), to.UTC().Format(layout))
+	req, err := http.NewRequestWithContext(ctx, "REPORT", cfg.CalDAV, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	req.Header.Set("Depth", "1")
+
+	reply, err := do(req, cfg)
+	if err != nil {
+		return nil, err
+	}
+	var ms multistatus
+	if err := xml.Unmarshal(reply, &ms); err != nil {
+		return nil, fmt.Errorf("read REPORT reply: %w", err)
+	}
+
+	c := &Calendar{}
+	for _, response := range ms.Responses {
+		for _, propstat := range response.Propstats {
+			if strings.TrimSpace(propstat.Data) == "" {
+				continue
+			}
+			events, err := Parse(strings.NewReader(propstat.Data))
+			if err != nil {
+				return nil, err
+			}
+			c.Merge(events)
+		}
+	}
+	return c, nil
+}
+
+// do sends req with cfg's credentials and returns the body of a 2xx reply
+func do(req *http.Request, cfg config.CalendarConfig) ([]byte, error) {
+	if cfg.Username != "" {
+		req.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	return body, nil
+}
+
+// Feed keeps the configured calendar, fetching it again every refresh
+// minutes. It's safe to use from several goroutines; a nil Feed has no
+// calendar.
+type Feed struct {
+	cfg   config.CalendarConfig
+	every time.Duration
+
+	mu    sync.Mutex
+	cal   *Calendar
+	tried time.Time
+}
+
+// NewFeed returns a feed of cfg's calendar, or nil if none is configured
+func NewFeed(cfg config.CalendarConfig) *Feed {
+	if !Configured(cfg) {
+		return nil
+	}
+	return &Feed{cfg: cfg, every: time.Duration(max(1, cfg.Refresh)) * time.Minute}
+}
+
+// Due reports whether it's time to fetch again, claiming the fetch so it
+// isn't started twice. A failed fetch waits as long as a good one.
+func (f *Feed) Due(now time.Time) bool {
+	if f == nil {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.tried.IsZero() && now.Sub(f.tried) < f.every {
+		return false
+	}
+	f.tried = now
+	return true
+}
+
+// Refresh fetches the calendar now, keeping the last one if it fails
+func (f *Feed) Refresh(ctx context.Context) error {
+	if f == nil {
+		return nil
+	}
+	c, err := Load(ctx, f.cfg, time.Now())
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.cal = c
+	f.mu.Unlock()
+	return nil
+}
+
+// Calendar is the last calendar fetched, nil before the first
+func (f *Feed) Calendar() *Calendar {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.cal
+}
@@ -0,0 +1,193 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// property is one content line: NAME;PARAM=value:VALUE
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse reads VEVENTs from an iCalendar stream. Floating times and time
+// zones that can't be loaded are read in local time; cancelled events are
+// left out.
+func Parse(r io.Reader) (*Calendar, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Calendar{}
+	var ev *vevent
+	var cancelled bool
+	var duration time.Duration // DURATION, which may come before DTSTART
+	depth := 0                 // components nested inside the VEVENT, e.g. VALARM
+	for n, line := range lines {
+		prop, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT") && ev == nil:
+			ev, cancelled, duration, depth = &vevent{}, false, 0, 0
+			continue
+		case ev == nil:
+			continue
+		case prop.name == "BEGIN":
+			depth++
+			continue
+		case prop.name == "END" && depth > 0:
+			depth--
+			continue
+		case prop.name == "END":
+			if ev.Start.IsZero() {
+				return nil, fmt.Errorf("line %d: event %q has no DTSTART", n+1, ev.Summary)
+			}
+			switch {
+			case !ev.End.IsZero():
+			case ev.AllDay && duration%(24*time.Hour) == 0:
+				ev.End = ev.Start.AddDate(0, 0, max(1, int(duration/(24*time.Hour))))
+			default:
+				ev.End = ev.Start.Add(duration)
+			}
+			if !cancelled {
+				c.events = append(c.events, *ev)
+			}
+			ev = nil
+			continue
+		case depth > 0:
+			continue
+		}
+
+		switch prop.name {
+		case "UID":
+			ev.uid = prop.value
+		case "SUMMARY":
+			ev.Summary = unescapeText(prop.value)
+		case "LOCATION":
+			ev.Location = unescapeText(prop.value)
+		case "STATUS":
+			cancelled = strings.EqualFold(prop.value, "CANCELLED")
+		case "DTSTART":
+			ev.Start, ev.AllDay, err = parseTime(prop)
+		case "DTEND":
+			ev.End, _, err = parseTime(prop)
+		case "DURATION":
+			duration, err = parseDuration(prop.value)
+		case "RECURRENCE-ID":
+			ev.recurrence, _, err = parseTime(prop)
+		case "RRULE":
+			ev.rule, err = parseRRule(prop.value, prop.params)
+		case "EXDATE":
+			if ev.exdates == nil {
+				ev.exdates = map[int64]bool{}
+			}
+			for _, value := range strings.Split(prop.value, ",") {
+				var t time.Time
+				if t, _, err = parseTime(property{name: prop.name, params: prop.params, value: value}); err != nil {
+					break
+				}
+				ev.exdates[t.Unix()] = true
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", n+1, prop.name, err)
+		}
+	}
+	return c, nil
+}
+
+// unfold joins continuation lines, which start with a space or tab, onto
+// the line before
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseProperty splits a content line at the first colon outside quotes
+func parseProperty(line string) (property, bool) {
+	quoted := false
+	for i, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ':' && !quoted:
+			parts := strings.Split(line[:i], ";")
+			prop := property{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: line[i+1:]}
+			for _, param := range parts[1:] {
+				if key, value, ok := strings.Cut(param, "="); ok {
+					prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+				}
+			}
+			return prop, true
+		}
+	}
+	return property{}, false
+}
+
+// unescapeText undoes TEXT value escaping
+func unescapeText(s string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+// parseTime reads a DATE or DATE-TIME value in UTC, its TZID or local time
+func parseTime(prop property) (time.Time, bool, error) {
+	value := strings.TrimSpace(prop.value)
+	if prop.params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, time.Local)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	loc := time.Local
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// durationRegex matches an RFC 5545 duration, e.g. PT1H30M or P1DT2H
+var durationRegex = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration reads a DURATION value
+func parseDuration(s string) (time.Duration, error) {
+	match := durationRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return 0, fmt.Errorf("bad duration %q", s)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if n, err := strconv.Atoi(match[i+2]); err == nil {
+			d += time.Duration(n) * unit
+		}
+	}
+	if match[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
@@ -0,0 +1,194 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPeriods bounds how many days, weeks, months or years a rule is walked
+// through looking for occurrences
+const maxPeriods = 100000
+
+// rrule is a recurrence rule: the common FREQ, INTERVAL, COUNT, UNTIL,
+// BYDAY and BYMONTHDAY parts
+type rrule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []weekdayNum
+	byMonthDay []int
+}
+
+// weekdayNum is a BYDAY entry, e.g. MO, or 1MO and -1FR in a month
+type weekdayNum struct {
+	day time.Weekday
+	n   int // 0 is every such day
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseRRule reads an RRULE value
+func parseRRule(value string, params map[string]string) (*rrule, error) {
+	r := &rrule{interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			r.interval, err = strconv.Atoi(val)
+		case "COUNT":
+			r.count, err = strconv.Atoi(val)
+		case "UNTIL":
+			r.until, _, err = parseTime(property{params: params, value: val})
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				day = strings.ToUpper(strings.TrimSpace(day))
+				if len(day) < 2 {
+					return nil, fmt.Errorf("bad BYDAY %q", day)
+				}
+				weekday, ok := weekdays[day[len(day)-2:]]
+				if !ok {
+					return nil, fmt.Errorf("bad BYDAY %q", day)
+				}
+				n := 0
+				if ordinal := day[:len(day)-2]; ordinal != "" {
+					if n, err = strconv.Atoi(ordinal); err != nil {
+						return nil, fmt.Errorf("bad BYDAY %q", day)
+					}
+				}
+				r.byDay = append(r.byDay, weekdayNum{day: weekday, n: n})
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(strings.TrimSpace(day))
+				if err != nil {
+					return nil, fmt.Errorf("bad BYMONTHDAY %q", day)
+				}
+				r.byMonthDay = append(r.byMonthDay, n)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bad %s %q", key, val)
+		}
+	}
+	switch r.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return nil, fmt.Errorf("unsupported FREQ %q", r.freq)
+	}
+	if r.interval < 1 {
+		return nil, fmt.Errorf("bad INTERVAL %d", r.interval)
+	}
+	return r, nil
+}
+
+// each calls fn with the start of every occurrence from dtstart that
+// starts in [from, to), in order; COUNT counts those before from too
+func (r *rrule) each(dtstart, from, to time.Time, fn func(time.Time)) {
+	seen := 0
+	for period := 0; period < maxPeriods; period++ {
+		for _, start := range r.period(dtstart, period) {
+			if start.Before(dtstart) {
+				continue
+			}
+			if !r.until.IsZero() && start.After(r.until) || !start.Before(to) {
+				return
+			}
+			if seen++; r.count > 0 && seen > r.count {
+				return
+			}
+			if !start.Before(from) {
+				fn(start)
+			}
+		}
+	}
+}
+
+// period returns the candidate starts in the period-th day, week, month or
+// year of the rule, in order, at dtstart's time of day
+func (r *rrule) period(dtstart time.Time, period int) []time.Time {
+	step := period * r.interval
+	y, m, d := dtstart.Date()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, dtstart.Location())
+	}
+
+	var starts []time.Time
+	switch r.freq {
+	case "DAILY":
+		return []time.Time{at(y, m, d+step)}
+
+	case "WEEKLY":
+		if len(r.byDay) == 0 {
+			return []time.Time{at(y, m, d+7*step)}
+		}
+		// Weeks start on Monday
+		monday := d - (int(dtstart.Weekday())+6)%7 + 7*step
+		for _, wd := range r.byDay {
+			starts = append(starts, at(y, m, monday+(int(wd.day)+6)%7))
+		}
+
+	case "MONTHLY":
+		first := at(y, m+time.Month(step), 1)
+		days := daysIn(first)
+		switch {
+		case len(r.byMonthDay) > 0:
+			for _, n := range r.byMonthDay {
+				if n < 0 {
+					n = days + n + 1
+				}
+				if n >= 1 && n <= days {
+					starts = append(starts, at(first.Year(), first.Month(), n))
+				}
+			}
+		case len(r.byDay) > 0:
+			for _, wd := range r.byDay {
+				for _, n := range weekdaysIn(first, days, wd) {
+					starts = append(starts, at(first.Year(), first.Month(), n))
+				}
+			}
+		case d <= days:
+			starts = append(starts, at(first.Year(), first.Month(), d))
+		}
+
+	case "YEARLY":
+		if start := at(y+step, m, d); start.Day() == d {
+			starts = append(starts, start)
+		}
+	}
+
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	return starts
+}
+
+// daysIn is how many days first's month has
+func daysIn(first time.Time) int {
+	return first.AddDate(0, 1, -1).Day()
+}
+
+// weekdaysIn returns the days of first's month matching wd: every one, or
+// the nth from the start or, negative, from the end
+func weekdaysIn(first time.Time, days int, wd weekdayNum) []int {
+	var matches []int
+	for d := 1 + (int(wd.day)-int(first.Weekday())+7)%7; d <= days; d += 7 {
+		matches = append(matches, d)
+	}
+	switch {
+	case wd.n > 0 && wd.n <= len(matches):
+		return matches[wd.n-1 : wd.n]
+	case wd.n < 0 && -wd.n <= len(matches):
+		return matches[len(matches)+wd.n : len(matches)+wd.n+1]
+	case wd.n != 0:
+		return nil
+	}
+	return matches
+}
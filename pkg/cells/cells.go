@@ -0,0 +1,62 @@
+// Package cells lays out terminal text by the cells it takes on screen
+// rather than its bytes or runes, so styled and wide text still lines up.
+package cells
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Width is how many cells s takes on screen, ignoring its escape codes
+func Width(s string) int {
+	return lipgloss.Width(s)
+}
+
+// Pad fills s with spaces to width cells; wider text is left as it is
+func Pad(s string, width int) string {
+	if n := width - Width(s); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+	return s
+}
+
+// Cut shortens s to width cells, ending it with "…" when anything was cut
+func Cut(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	return ansi.Truncate(s, width, "…")
+}
+
+// Fit cuts or pads s to exactly width cells, as a status bar needs
+func Fit(s string, width int) string {
+	return Pad(Cut(s, width), width)
+}
+
+// Spread puts left and right at either end of width cells, cutting left
+// when there isn't room for both
+func Spread(left, right string, width int) string {
+	left = Cut(left, width-Width(right)-1)
+	return Pad(left, width-Width(right)) + right
+}
+
+// Inner is the room left for content in a box width cells wide drawn with
+// style, once its border, padding and margins are taken; never below 1
+func Inner(style lipgloss.Style, width int) int {
+	return max(1, width-frame(style))
+}
+
+// Frame sizes style so the box it draws, border and margins included,
+// takes width cells. lipgloss counts padding into Width but not borders.
+func Frame(style lipgloss.Style, width int) lipgloss.Style {
+	return style.Width(max(0, width-frame(style)+style.GetHorizontalPadding()))
+}
+
+// frame is how many cells style's border, padding and margins take across.
+// It's measured, as a border set with BorderStyle alone has no sides
+// lipgloss reports until it draws them.
+func frame(style lipgloss.Style) int {
+	return Width(style.UnsetWidth().UnsetMaxWidth().UnsetHeight().UnsetMaxHeight().Render(""))
+}
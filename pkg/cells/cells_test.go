@@ -0,0 +1,42 @@
+package cells
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestFitCountsCells(t *testing.T) {
+	bold := lipgloss.NewStyle().Bold(true).Render("bold")
+	for _, s := range []string{"plain text", bold + " text", "日本語のテキスト", "café ✓ done", ""} {
+		for _, width := range []int{0, 1, 5, 12, 30} {
+			if got := Width(Fit(s, width)); got != width {
+				t.Errorf("Fit(%q, %d) is %d cells", s, width, got)
+			}
+		}
+	}
+	if got := Cut("日本語", 4); got != "日…" {
+		t.Errorf("Cut split a wide rune: %q", got)
+	}
+}
+
+func TestSpread(t *testing.T) {
+	if got := Spread("left", "right", 12); got != "left   right" {
+		t.Errorf("Spread = %q", got)
+	}
+	if got := Spread("a long title", "mode", 10); got != "a lo… mode" {
+		t.Errorf("Spread didn't cut the left side: %q", got)
+	}
+}
+
+func TestFrameFillsWidth(t *testing.T) {
+	box := lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).Padding(0, 1)
+	for _, width := range []int{10, 40} {
+		if got := lipgloss.Width(Frame(box, width).Render("x")); got != width {
+			t.Errorf("Frame(%d) renders %d wide", width, got)
+		}
+		if got := Inner(box, width); got != width-4 {
+			t.Errorf("Inner(%d) = %d", width, got)
+		}
+	}
+}
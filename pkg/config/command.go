@@ -0,0 +1,96 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCommand builds the `config` command shared by float-rw and
+// float-outliner
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show or change the shared float-line configuration",
+		Long: `float-rw and float-outliner share one config file, by default
+~/.config/float-line/config.toml (override with FLOAT_LINE_CONFIG). Each
+--profile <name> has its own under ~/.config/float-line/profiles/<name>/.
+
+Any scalar key can also be set from the environment as FLOAT_LINE_<SECTION>_<KEY>,
+for example FLOAT_LINE_API_PAGE_SIZE=50 or FLOAT_LINE_OUTLINER_AUTOSAVE=true.`,
+	}
+
+	showCmd := &cobra.Command{
+		Use:   "show",
+		Short: "Print the effective configuration (file, defaults, and env)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := Load()
+			if err != nil {
+				return err
+			}
+			out, err := Show(cfg)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("# %s\n%s", Path(), out)
+			return nil
+		},
+	}
+
+	setCmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Write a key to the config file",
+		Long: `Writes a single key to the config file. Scalar keys:
+
+  ` + strings.Join(Keys(), "\n  ") + `
+
+Map keys: evna.collections.<pattern>, theme.patterns.<pattern>, and
+imprints.<name>.{voice,aesthetic,filters,color,sigil} (filters is a comma list).`,
+		Example: `  float-rw config set api.page_size 50
+  float-outliner config set outliner.keymap workflowy
+  float-outliner config set evna.collections.eureka float_eureka
+  float-outliner config set imprints.zine.filters "zine,chaos"`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := Set(args[0], args[1]); err != nil {
+				return err
+			}
+			fmt.Printf("Set %s in %s\n", args[0], Path())
+			return nil
+		},
+	}
+
+	pathCmd := &cobra.Command{
+		Use:   "path",
+		Short: "Print the config file location",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println(Path())
+		},
+	}
+
+	profilesCmd := &cobra.Command{
+		Use:   "profiles",
+		Short: "List the profiles, marking the one in use",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names, err := Profiles()
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				mark := " "
+				if name == Profile() {
+					mark = "*"
+				}
+				fmt.Printf("%s %s\n", mark, name)
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(showCmd, setCmd, pathCmd, profilesCmd)
+	return cmd
+}
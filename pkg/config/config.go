@@ -0,0 +1,478 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/viper"
+)
+
+const (
+	fileName  = "config.toml"
+	envPrefix = "FLOAT_LINE"
+)
+
+// Config is the shared configuration for float-rw and float-outliner
+type Config struct {
+	API           APIConfig                `mapstructure:"api" toml:"api"`
+	Outliner      OutlinerConfig           `mapstructure:"outliner" toml:"outliner"`
+	Evna          EvnaConfig               `mapstructure:"evna" toml:"evna"`
+	Imprints      map[string]ImprintConfig `mapstructure:"imprints" toml:"imprints"`
+	Theme         ThemeConfig              `mapstructure:"theme" toml:"theme"`
+	Daily         DailyConfig              `mapstructure:"daily" toml:"daily"`
+	Git           GitConfig                `mapstructure:"git" toml:"git"`
+	Bridge        BridgeConfig             `mapstructure:"bridge" toml:"bridge"`
+	Patterns      map[string]PatternConfig `mapstructure:"patterns" toml:"patterns"`
+	Log           LogConfig                `mapstructure:"log" toml:"log"`
+	Reducers      ReducersConfig           `mapstructure:"reducers" toml:"reducers"`
+	Encryption    EncryptionConfig         `mapstructure:"encryption" toml:"encryption"`
+	Redact        RedactConfig             `mapstructure:"redact" toml:"redact"`
+	Calendar      CalendarConfig           `mapstructure:"calendar" toml:"calendar"`
+	Views         map[string]ViewConfig    `mapstructure:"views" toml:"views"`
+	Layout        LayoutConfig             `mapstructure:"layout" toml:"layout"`
+	Accessibility AccessibilityConfig      `mapstructure:"accessibility" toml:"accessibility"`
+	I18n          I18nConfig               `mapstructure:"i18n" toml:"i18n"`
+}
+
+// APIConfig configures the Readwise client
+type APIConfig struct {
+	Token    string `mapstructure:"token" toml:"token"`
+	BaseURL  string `mapstructure:"base_url" toml:"base_url"`
+	PageSize int    `mapstructure:"page_size" toml:"page_size"`
+
+	Timeout  int            `mapstructure:"timeout" toml:"timeout"`   // seconds a request may take
+	Timeouts map[string]int `mapstructure:"timeouts" toml:"timeouts"` // per-call seconds: auth, books, highlights, highlight, update, create, export
+
+	AutoCapture bool   `mapstructure:"auto_capture" toml:"auto_capture"` // dispatch highlights as float-rw tui loads them
+	Covers      string `mapstructure:"covers" toml:"covers"`             // cover images: auto, kitty, iterm, sixel, ascii or off
+}
+
+// OutlinerConfig configures editing behavior
+type OutlinerConfig struct {
+	Keymap           string `mapstructure:"keymap" toml:"keymap"`                       // "default" or "workflowy"
+	Autosave         bool   `mapstructure:"autosave" toml:"autosave"`                   // save modified files automatically
+	AutosaveInterval int    `mapstructure:"autosave_interval" toml:"autosave_interval"` // seconds between autosaves
+	ZenWidth         int    `mapstructure:"zen_width" toml:"zen_width"`                 // column width in zen mode (alt+z)
+	Typewriter       bool   `mapstructure:"typewriter" toml:"typewriter"`               // keep the cursor on the middle row
+	ScrollMargin     int    `mapstructure:"scroll_margin" toml:"scroll_margin"`         // rows kept between the cursor and the edges
+	ArchiveFile      string `mapstructure:"archive_file" toml:"archive_file"`           // file alt+a archives into, relative to the outline; empty uses an archive:: section
+	SearchArchived   bool   `mapstructure:"search_archived" toml:"search_archived"`     // let ctrl+j find archived nodes
+	IdleBoundary     int    `mapstructure:"idle_boundary" toml:"idle_boundary"`         // minutes without a keypress that close the ctx:: block; 0 is off
+	Inbox            string `mapstructure:"inbox" toml:"inbox"`                         // file float-outliner quick appends to; empty uses inbox.md in the config directory
+}
+
+// EvnaConfig configures external consciousness dispatch
+type EvnaConfig struct {
+	Enabled     bool              `mapstructure:"enabled" toml:"enabled"`
+	Endpoint    string            `mapstructure:"endpoint" toml:"endpoint"`       // HTTP endpoint receiving capture payloads
+	Review      bool              `mapstructure:"review" toml:"review"`           // approve patterns in a review on ctrl+s before they're sent
+	Collections map[string]string `mapstructure:"collections" toml:"collections"` // pattern type -> collection
+
+	DefaultCollection string `mapstructure:"default_collection" toml:"default_collection"` // collection for pattern types without a route
+	CollectionsURL    string `mapstructure:"collections_url" toml:"collections_url"`       // lists the backend's collections to validate routing against
+}
+
+// ImprintConfig defines or overrides an imprint
+type ImprintConfig struct {
+	Voice     string   `mapstructure:"voice" toml:"voice"`
+	Aesthetic string   `mapstructure:"aesthetic" toml:"aesthetic"`
+	Filters   []string `mapstructure:"filters" toml:"filters"`
+	Color     string   `mapstructure:"color" toml:"color"`
+	Sigil     string   `mapstructure:"sigil" toml:"sigil"`
+}
+
+// PatternConfig declares a custom :: pattern type, e.g. [patterns.dream]
+type PatternConfig struct {
+	Color      string `mapstructure:"color" toml:"color"`           // highlight color; theme.patterns still wins
+	Collection string `mapstructure:"collection" toml:"collection"` // evna collection; evna.collections still wins
+	Imprint    string `mapstructure:"imprint" toml:"imprint"`       // imprint to route to; empty uses imprint filters
+}
+
+// ThemeConfig overrides UI colors (ANSI 256 color codes or hex)
+type ThemeConfig struct {
+	Accent   string            `mapstructure:"accent" toml:"accent"`     // bullets and focused borders
+	Patterns map[string]string `mapstructure:"patterns" toml:"patterns"` // pattern type -> color
+	Colors   string            `mapstructure:"colors" toml:"colors"`     // auto, truecolor, 256, 16 or mono
+}
+
+// DailyConfig configures `float-outliner today`
+type DailyConfig struct {
+	Dir      string `mapstructure:"dir" toml:"dir"`           // daily note directory; empty uses the vault's journals or ./journals
+	Template string `mapstructure:"template" toml:"template"` // text/template file; empty uses the built-in ctx:: header
+	Format   string `mapstructure:"format" toml:"format"`     // Go time layout for file names
+	Project  string `mapstructure:"project" toml:"project"`   // ctx:: project; empty uses the directory name
+	Mode     string `mapstructure:"mode" toml:"mode"`         // ctx:: mode
+}
+
+// GitConfig configures version control of saved outlines
+type GitConfig struct {
+	AutoCommit bool `mapstructure:"auto_commit" toml:"auto_commit"` // commit each save with a pattern summary
+}
+
+// BridgeConfig configures the bridge registry
+type BridgeConfig struct {
+	Registry string `mapstructure:"registry" toml:"registry"` // registry file; empty uses ~/.config/float-line/bridges.json
+}
+
+// LogConfig configures the diagnostic log both commands write
+type LogConfig struct {
+	Level string `mapstructure:"level" toml:"level"` // debug, info, warn or error
+	File  string `mapstructure:"file" toml:"file"`   // log file; empty uses ~/.cache/float-line/<command>.log
+}
+
+// ReducersConfig configures reducer:: matching
+type ReducersConfig struct {
+	Exec        bool `mapstructure:"exec" toml:"exec"`                 // allow "reducer:: name exec ./match.sh" to run commands
+	ExecTimeout int  `mapstructure:"exec_timeout" toml:"exec_timeout"` // seconds a matcher run may take
+	ExecBatch   int  `mapstructure:"exec_batch" toml:"exec_batch"`     // actions per run for exec --batch matchers
+
+	EmbeddingsURL     string  `mapstructure:"embeddings_url" toml:"embeddings_url"`         // OpenAI-compatible embeddings endpoint for "similar to" reducers
+	EmbeddingsModel   string  `mapstructure:"embeddings_model" toml:"embeddings_model"`     // model name sent with each request
+	EmbeddingsKey     string  `mapstructure:"embeddings_key" toml:"embeddings_key"`         // bearer token, if the endpoint needs one
+	EmbeddingsTimeout int     `mapstructure:"embeddings_timeout" toml:"embeddings_timeout"` // seconds a request may take
+	Similarity        float64 `mapstructure:"similarity" toml:"similarity"`                 // cosine similarity a "similar to" match needs, 0-1
+
+	// Global reducers run for every file and session, by name, e.g.
+	// decisions = "collect all decisions"
+	Global map[string]string `mapstructure:"global" toml:"global"`
+}
+
+// EncryptionConfig configures encryption at rest of the dispatch log,
+// crash reports and selector exports
+type EncryptionConfig struct {
+	Enabled  bool   `mapstructure:"enabled" toml:"enabled"`   // seal what's written; sealed files are read either way
+	Keyfile  string `mapstructure:"keyfile" toml:"keyfile"`   // age identity file (age-keygen); empty asks for a passphrase
+	Identity string `mapstructure:"identity" toml:"identity"` // the passphrase's identity file; empty uses ~/.config/float-line/identity.age
+}
+
+// RedactConfig configures what's stripped or masked before text is sent to
+// evna, an embeddings endpoint or Readwise
+type RedactConfig struct {
+	Emails   bool                  `mapstructure:"emails" toml:"emails"`     // mask email addresses
+	APIKeys  bool                  `mapstructure:"api_keys" toml:"api_keys"` // mask API keys and bearer tokens
+	Patterns []string              `mapstructure:"patterns" toml:"patterns"` // pattern types whose lines are dropped, e.g. private
+	Rules    map[string]RedactRule `mapstructure:"rules" toml:"rules"`       // custom rules, e.g. [redact.rules.phone]
+}
+
+// RedactRule is a custom redaction: what regex matches is replaced
+type RedactRule struct {
+	Regex   string `mapstructure:"regex" toml:"regex"`     // Go regexp syntax
+	Replace string `mapstructure:"replace" toml:"replace"` // may use $1; empty uses [redacted]
+}
+
+// CalendarConfig configures the calendar ctx:: captures are placed
+// against
+type CalendarConfig struct {
+	ICS      string `mapstructure:"ics" toml:"ics"`           // .ics file, or http(s) or webcal URL of one
+	CalDAV   string `mapstructure:"caldav" toml:"caldav"`     // CalDAV calendar collection URL
+	Username string `mapstructure:"username" toml:"username"` // basic auth for either URL
+	Password string `mapstructure:"password" toml:"password"` // or FLOAT_LINE_CALENDAR_PASSWORD; an app password
+	Refresh  int    `mapstructure:"refresh" toml:"refresh"`   // minutes between fetches
+}
+
+// ViewConfig is a saved smart view: the highlights across every book that
+// match all of its filters, listed with the books in float-rw
+type ViewConfig struct {
+	Tags      []string `mapstructure:"tags" toml:"tags"`           // highlight tags, any of which matches
+	Category  string   `mapstructure:"category" toml:"category"`   // books, articles, tweets or podcasts
+	BookTag   string   `mapstructure:"book_tag" toml:"book_tag"`   // a tag of the highlight's book
+	Favorites bool     `mapstructure:"favorites" toml:"favorites"` // favorited highlights only
+	Text      string   `mapstructure:"text" toml:"text"`           // words the highlight or its note contains
+}
+
+// LayoutConfig configures the layout presets both TUIs switch between
+type LayoutConfig struct {
+	StackBelow int `mapstructure:"stack_below" toml:"stack_below"` // float-rw stacks its panes in terminals narrower than this; 0 never does
+}
+
+// AccessibilityConfig configures the screen reader mode of both TUIs
+type AccessibilityConfig struct {
+	Enabled bool   `mapstructure:"enabled" toml:"enabled"` // spoken row roles, no box drawing or color-only signals
+	Notify  string `mapstructure:"notify" toml:"notify"`   // how captures are announced: bell, osc, both or off
+}
+
+// I18nConfig picks the language of both TUIs
+type I18nConfig struct {
+	Locale string `mapstructure:"locale" toml:"locale"` // e.g. de or pt_BR; auto takes LC_ALL, LC_MESSAGES or LANG
+}
+
+// Dir returns ~/.config/float-line, honoring XDG_CONFIG_HOME, or the
+// selected profile's directory under it
+func Dir() string {
+	return filepath.Join(baseDir(), ProfileDir())
+}
+
+// baseDir is the default profile's directory, holding the others
+func baseDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "float-line")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".float-line"
+	}
+	return filepath.Join(home, ".config", "float-line")
+}
+
+// ExpandHome replaces a leading ~/ in a configured path with the home
+// directory
+func ExpandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// Path returns the config file location; FLOAT_LINE_CONFIG only stands in
+// for the default profile's
+func Path() string {
+	if path := os.Getenv(envPrefix + "_CONFIG"); path != "" && Profile() == DefaultProfile {
+		return path
+	}
+	return filepath.Join(Dir(), fileName)
+}
+
+// setDefaults registers every scalar key so env overrides apply to it
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("api.token", "")
+	v.SetDefault("api.base_url", "https://readwise.io/api/v2")
+	v.SetDefault("api.page_size", 100)
+	v.SetDefault("api.timeout", 30)
+	v.SetDefault("api.auto_capture", false)
+	v.SetDefault("api.covers", "auto")
+
+	v.SetDefault("outliner.keymap", "default")
+	v.SetDefault("outliner.autosave", false)
+	v.SetDefault("outliner.autosave_interval", 30)
+	v.SetDefault("outliner.zen_width", 80)
+	v.SetDefault("outliner.typewriter", false)
+	v.SetDefault("outliner.scroll_margin", 0)
+	v.SetDefault("outliner.archive_file", "")
+	v.SetDefault("outliner.search_archived", false)
+	v.SetDefault("outliner.idle_boundary", 0)
+	v.SetDefault("outliner.inbox", "")
+
+	v.SetDefault("evna.enabled", true)
+	v.SetDefault("evna.endpoint", "")
+	v.SetDefault("evna.review", false)
+	v.SetDefault("evna.default_collection", "active_context_stream")
+	v.SetDefault("evna.collections_url", "")
+
+	v.SetDefault("theme.accent", "62")
+	v.SetDefault("theme.colors", "auto")
+
+	v.SetDefault("daily.dir", "")
+	v.SetDefault("daily.template", "")
+	v.SetDefault("daily.format", "2006-01-02")
+	v.SetDefault("daily.project", "")
+	v.SetDefault("daily.mode", "capture")
+
+	v.SetDefault("git.auto_commit", false)
+
+	v.SetDefault("bridge.registry", "")
+
+	v.SetDefault("log.level", "info")
+	v.SetDefault("log.file", "")
+
+	v.SetDefault("reducers.exec", false)
+	v.SetDefault("reducers.exec_timeout", 2)
+	v.SetDefault("reducers.exec_batch", 50)
+	v.SetDefault("reducers.embeddings_url", "")
+	v.SetDefault("reducers.embeddings_model", "")
+	v.SetDefault("reducers.embeddings_key", "")
+	v.SetDefault("reducers.embeddings_timeout", 5)
+	v.SetDefault("reducers.similarity", 0.8)
+
+	v.SetDefault("encryption.enabled", false)
+	v.SetDefault("encryption.keyfile", "")
+	v.SetDefault("encryption.identity", "")
+
+	v.SetDefault("redact.emails", false)
+	v.SetDefault("redact.api_keys", false)
+	v.SetDefault("redact.patterns", []string{})
+
+	v.SetDefault("calendar.ics", "")
+	v.SetDefault("calendar.caldav", "")
+	v.SetDefault("calendar.username", "")
+	v.SetDefault("calendar.password", "")
+	v.SetDefault("calendar.refresh", 15)
+
+	v.SetDefault("layout.stack_below", 90)
+
+	v.SetDefault("accessibility.enabled", false)
+	v.SetDefault("accessibility.notify", "bell")
+	v.SetDefault("i18n.locale", "auto")
+}
+
+func newViper() *viper.Viper {
+	v := viper.New()
+	v.SetConfigType("toml")
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	setDefaults(v)
+	return v
+}
+
+// Load reads the config file (a missing file is fine) and applies
+// FLOAT_LINE_* environment overrides, e.g. FLOAT_LINE_API_PAGE_SIZE=50
+func Load() (*Config, error) {
+	v := newViper()
+	v.SetConfigFile(Path())
+
+	if err := v.ReadInConfig(); err != nil && !os.IsNotExist(err) {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return nil, fmt.Errorf("read %s: %w", Path(), err)
+		}
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", Path(), err)
+	}
+
+	return &cfg, nil
+}
+
+// Default returns the built-in configuration without reading any file
+func Default() *Config {
+	v := newViper()
+	var cfg Config
+	_ = v.Unmarshal(&cfg)
+	return &cfg
+}
+
+// Set writes a single key to the config file, keeping everything else in
+// the file untouched. Values are parsed as bool, int, comma list (for
+// filters), or string.
+func Set(key, value string) error {
+	if !isKnownKey(key) {
+		return fmt.Errorf("unknown config key %q", key)
+	}
+
+	// Only the file's own contents, no defaults or env, get written back
+	v := viper.New()
+	v.SetConfigType("toml")
+	v.SetConfigFile(Path())
+	if err := v.ReadInConfig(); err != nil && !os.IsNotExist(err) {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return fmt.Errorf("read %s: %w", Path(), err)
+		}
+	}
+
+	v.Set(key, parseValue(key, value))
+
+	if err := os.MkdirAll(filepath.Dir(Path()), 0700); err != nil {
+		return err
+	}
+	if err := v.WriteConfigAs(Path()); err != nil {
+		return fmt.Errorf("write %s: %w", Path(), err)
+	}
+	// api.token may live here
+	return os.Chmod(Path(), 0600)
+}
+
+// Show renders the effective configuration as TOML with secrets masked
+func Show(cfg *Config) (string, error) {
+	masked := *cfg
+	if masked.API.Token != "" {
+		masked.API.Token = maskSecret(masked.API.Token)
+	}
+	if masked.Calendar.Password != "" {
+		masked.Calendar.Password = maskSecret(masked.Calendar.Password)
+	}
+	if masked.Reducers.EmbeddingsKey != "" {
+		masked.Reducers.EmbeddingsKey = maskSecret(masked.Reducers.EmbeddingsKey)
+	}
+
+	data, err := toml.Marshal(masked)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Keys lists the scalar keys accepted by Set, for help output
+func Keys() []string {
+	v := newViper()
+	keys := v.AllKeys()
+	sort.Strings(keys)
+	return keys
+}
+
+// isKnownKey accepts scalar keys plus entries inside the map sections
+func isKnownKey(key string) bool {
+	for _, k := range Keys() {
+		if k == key {
+			return true
+		}
+	}
+
+	parts := strings.Split(key, ".")
+	switch {
+	case len(parts) == 3 && parts[0] == "evna" && parts[1] == "collections":
+		return true
+	case len(parts) == 3 && parts[0] == "api" && parts[1] == "timeouts":
+		switch parts[2] {
+		case "auth", "books", "highlights", "highlight", "update", "create", "export":
+			return true
+		}
+	case len(parts) == 3 && parts[0] == "theme" && parts[1] == "patterns":
+		return true
+	case len(parts) == 3 && parts[0] == "reducers" && parts[1] == "global":
+		return true
+	case len(parts) == 4 && parts[0] == "redact" && parts[1] == "rules":
+		return parts[3] == "regex" || parts[3] == "replace"
+	case len(parts) == 3 && parts[0] == "patterns":
+		switch parts[2] {
+		case "color", "collection", "imprint":
+			return true
+		}
+	case len(parts) == 3 && parts[0] == "views":
+		switch parts[2] {
+		case "tags", "category", "book_tag", "favorites", "text":
+			return true
+		}
+	case len(parts) == 3 && parts[0] == "imprints":
+		switch parts[2] {
+		case "voice", "aesthetic", "filters", "color", "sigil":
+			return true
+		}
+	}
+	return false
+}
+
+func parseValue(key, value string) interface{} {
+	if strings.HasSuffix(key, ".filters") || key == "redact.patterns" || (strings.HasPrefix(key, "views.") && strings.HasSuffix(key, ".tags")) {
+		items := strings.Split(value, ",")
+		for i := range items {
+			items[i] = strings.TrimSpace(items[i])
+		}
+		return items
+	}
+	if key == "calendar.username" || key == "calendar.password" || key == "reducers.embeddings_key" || strings.HasPrefix(key, "reducers.global.") || (strings.HasPrefix(key, "views.") && strings.HasSuffix(key, ".text")) {
+		// "0123" or "true" is still a password, and "42" still a reducer
+		return value
+	}
+	if b, err := strconv.ParseBool(value); err == nil {
+		return b
+	}
+	if n, err := strconv.Atoi(value); err == nil && !strings.HasPrefix(key, "theme.") && !strings.HasPrefix(key, "daily.") && !strings.HasPrefix(key, "patterns.") && !strings.HasPrefix(key, "redact.") {
+		return n
+	}
+	return value
+}
+
+func maskSecret(s string) string {
+	if len(s) <= 4 {
+		return "****"
+	}
+	return strings.Repeat("*", len(s)-4) + s[len(s)-4:]
+}
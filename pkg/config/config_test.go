@@ -0,0 +1,103 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useConfigDir points the config, and the default profile's directory, at
+// a temp dir for the test
+func useConfigDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv(envPrefix+"_CONFIG", "")
+	t.Setenv(ProfileEnv, "")
+	if err := SetProfile(""); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "float-line")
+}
+
+func TestShowMasksSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.API.Token = "rw-token-abcd"
+	cfg.Calendar.Password = "hunter2-wxyz"
+	cfg.Reducers.EmbeddingsKey = "sk-embeddings-9876"
+
+	out, err := Show(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{cfg.API.Token, cfg.Calendar.Password, cfg.Reducers.EmbeddingsKey} {
+		if strings.Contains(out, secret) {
+			t.Errorf("show prints %q in the clear", secret)
+		}
+		if masked := maskSecret(secret); !strings.Contains(out, masked) {
+			t.Errorf("show is missing %q", masked)
+		}
+	}
+	if cfg.Reducers.EmbeddingsKey != "sk-embeddings-9876" {
+		t.Error("show masked the config it was given")
+	}
+}
+
+func TestSetKeepsSecretsAsStrings(t *testing.T) {
+	useConfigDir(t)
+	for key, value := range map[string]string{
+		"calendar.password":       "0123",
+		"reducers.embeddings_key": "true",
+	} {
+		if err := Set(key, value); err != nil {
+			t.Fatalf("set %s: %v", key, err)
+		}
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Calendar.Password != "0123" || cfg.Reducers.EmbeddingsKey != "true" {
+		t.Errorf("loaded password %q, embeddings key %q", cfg.Calendar.Password, cfg.Reducers.EmbeddingsKey)
+	}
+}
+
+func TestSetGlobalReducer(t *testing.T) {
+	useConfigDir(t)
+	for key, value := range map[string]string{
+		"reducers.global.decisions": "collect all decisions",
+		"reducers.global.answer":    "42",
+	} {
+		if err := Set(key, value); err != nil {
+			t.Fatalf("set %s: %v", key, err)
+		}
+	}
+	if err := Set("reducers.global.too.deep", "x"); err == nil {
+		t.Error("set a key nested under a global reducer")
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Reducers.Global; len(got) != 2 || got["decisions"] != "collect all decisions" || got["answer"] != "42" {
+		t.Errorf("global reducers = %q", got)
+	}
+}
+
+func TestExpandHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for path, want := range map[string]string{
+		"~/notes/inbox.md": filepath.Join(home, "notes", "inbox.md"),
+		"~notes":           "~notes",
+		"/abs/~/path":      "/abs/~/path",
+		"journals":         "journals",
+		"":                 "",
+	} {
+		if got := ExpandHome(path); got != want {
+			t.Errorf("ExpandHome(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
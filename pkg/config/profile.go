@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync/atomic"
+)
+
+const (
+	// DefaultProfile is the profile kept directly in ~/.config/float-line
+	DefaultProfile = "default"
+
+	// profilesDir holds the other profiles, each a directory of its own
+	profilesDir = "profiles"
+)
+
+// ProfileEnv selects a profile when --profile isn't given
+const ProfileEnv = envPrefix + "_PROFILE"
+
+// profileNameRegex keeps profile names usable as directory names
+var profileNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// profile is the name SetProfile selected; empty defers to ProfileEnv
+var profile atomic.Value
+
+// SetProfile selects the profile whose config, token, dispatch logs and
+// caches are used from now on; "" goes back to FLOAT_LINE_PROFILE or the
+// default
+func SetProfile(name string) error {
+	if name != "" && !profileNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, - and _", name)
+	}
+	profile.Store(name)
+	return nil
+}
+
+// Profile returns the selected profile's name
+func Profile() string {
+	if name, _ := profile.Load().(string); name != "" {
+		return name
+	}
+	if name := os.Getenv(ProfileEnv); name != "" && profileNameRegex.MatchString(name) {
+		return name
+	}
+	return DefaultProfile
+}
+
+// ProfileDir is where the selected profile keeps its files relative to a
+// float-line directory: "" for the default, else profiles/<name>
+func ProfileDir() string {
+	if name := Profile(); name != DefaultProfile {
+		return filepath.Join(profilesDir, name)
+	}
+	return ""
+}
+
+// Profiles lists the default profile and every profile with a directory,
+// sorted after it
+func Profiles() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(baseDir(), profilesDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && e.Name() != DefaultProfile && profileNameRegex.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return append([]string{DefaultProfile}, names...), nil
+}
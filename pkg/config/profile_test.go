@@ -0,0 +1,92 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestProfileDir(t *testing.T) {
+	base := useConfigDir(t)
+	t.Cleanup(func() { _ = SetProfile("") })
+
+	for _, tc := range []struct {
+		profile, env string
+		dir          string
+	}{
+		{"", "", base},
+		{DefaultProfile, "", base},
+		{"work", "", filepath.Join(base, "profiles", "work")},
+		{"", "home", filepath.Join(base, "profiles", "home")},
+		{"work", "home", filepath.Join(base, "profiles", "work")},
+		{"", "../escape", base}, // not a usable name, so ignored
+	} {
+		t.Setenv(ProfileEnv, tc.env)
+		if err := SetProfile(tc.profile); err != nil {
+			t.Fatal(err)
+		}
+		if Dir() != tc.dir || Path() != filepath.Join(tc.dir, fileName) {
+			t.Errorf("profile %q, %s=%q: dir %s, want %s", tc.profile, ProfileEnv, tc.env, Dir(), tc.dir)
+		}
+	}
+	t.Setenv(ProfileEnv, "")
+
+	for _, name := range []string{"../up", "a/b", "-dash", " "} {
+		if err := SetProfile(name); err == nil {
+			t.Errorf("SetProfile(%q) was accepted", name)
+		}
+	}
+
+	// FLOAT_LINE_CONFIG names the default profile's file only
+	t.Setenv(envPrefix+"_CONFIG", filepath.Join(t.TempDir(), "elsewhere.toml"))
+	if err := SetProfile(""); err != nil {
+		t.Fatal(err)
+	}
+	if Path() != os.Getenv(envPrefix+"_CONFIG") {
+		t.Errorf("default profile path = %s", Path())
+	}
+	if err := SetProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	if Path() != filepath.Join(base, "profiles", "work", fileName) {
+		t.Errorf("work profile path = %s", Path())
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	base := useConfigDir(t)
+	for _, dir := range []string{"work", "home", "default", ".hidden"} {
+		if err := os.MkdirAll(filepath.Join(base, profilesDir, dir), 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	profiles, err := Profiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"default", "home", "work"}; !slices.Equal(profiles, want) {
+		t.Errorf("profiles = %q, want %q", profiles, want)
+	}
+
+	// A profile's config is its own
+	t.Cleanup(func() { _ = SetProfile("") })
+	if err := SetProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set("api.page_size", "25"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := Load(); err != nil || cfg.API.PageSize != 25 {
+		t.Fatalf("work profile page size = %+v, %v", cfg, err)
+	}
+	if _, err := os.Stat(filepath.Join(base, profilesDir, "work", fileName)); err != nil {
+		t.Errorf("work profile config not written: %v", err)
+	}
+	if err := SetProfile(""); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, _ := Load(); cfg.API.PageSize != 100 {
+		t.Errorf("default profile page size = %d", cfg.API.PageSize)
+	}
+}
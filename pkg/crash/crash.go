@@ -0,0 +1,186 @@
+package crash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/evanschultz/float-rw-client/pkg/cache"
+	"github.com/evanschultz/float-rw-client/pkg/encrypt"
+)
+
+// Panic is a recovered panic and the stack it was raised on
+type Panic struct {
+	Value interface{}
+	Stack []byte
+	Time  time.Time
+}
+
+func (p *Panic) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// capture records v with the current stack; called from a deferred
+// recover, the stack still shows where the panic happened
+func capture(v interface{}) *Panic {
+	return &Panic{Value: v, Stack: debug.Stack(), Time: time.Now()}
+}
+
+// panicMsg carries a panic from a command's goroutine to the program
+type panicMsg struct {
+	panic *Panic
+}
+
+// Guard wraps a model so that a panic in its Init, Update, View or any of
+// its commands quits the program, which restores the terminal, instead of
+// killing it. The model is kept as it was when the panic hit, so what it
+// held can still be saved.
+type Guard struct {
+	model tea.Model
+	panic *Panic
+	quit  func() // ends the program after a panic in View, which can't return a command
+}
+
+// NewGuard wraps m
+func NewGuard(m tea.Model) *Guard {
+	return &Guard{model: m}
+}
+
+// Model returns the wrapped model
+func (g *Guard) Model() tea.Model {
+	return g.model
+}
+
+// Panic returns the panic that ended the program, nil if none did
+func (g *Guard) Panic() *Panic {
+	return g.panic
+}
+
+func (g *Guard) fail(p *Panic) {
+	if g.panic == nil {
+		g.panic = p
+	}
+}
+
+// recover is deferred by Init and Update, quitting on a panic
+func (g *Guard) recover(cmd *tea.Cmd) {
+	if r := recover(); r != nil {
+		g.fail(capture(r))
+		*cmd = tea.Quit
+	}
+}
+
+// Init initializes the wrapped model
+func (g *Guard) Init() (cmd tea.Cmd) {
+	defer g.recover(&cmd)
+	return wrap(g.model.Init())
+}
+
+// Update passes msg to the wrapped model; once it has panicked nothing
+// more reaches it
+func (g *Guard) Update(msg tea.Msg) (guard tea.Model, cmd tea.Cmd) {
+	guard = g
+	if msg, ok := msg.(panicMsg); ok {
+		g.fail(msg.panic)
+		return g, tea.Quit
+	}
+	if g.panic != nil {
+		return g, nil
+	}
+
+	defer g.recover(&cmd)
+	model, cmd := g.model.Update(msg)
+	g.model = model
+	return g, wrap(cmd)
+}
+
+// View renders the wrapped model, blank once it has panicked
+func (g *Guard) View() (view string) {
+	if g.panic != nil {
+		return ""
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			g.fail(capture(r))
+			view = ""
+			if g.quit != nil {
+				go g.quit()
+			}
+		}
+	}()
+	return g.model.View()
+}
+
+// wrap makes cmd report a panic as a panicMsg rather than crash its
+// goroutine, wrapping the commands of a batch it returns the same way
+func wrap(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() (msg tea.Msg) {
+		defer func() {
+			if r := recover(); r != nil {
+				msg = panicMsg{panic: capture(r)}
+			}
+		}()
+		msg = cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			wrapped := make(tea.BatchMsg, len(batch))
+			for i, c := range batch {
+				wrapped[i] = wrap(c)
+			}
+			return wrapped
+		}
+		return msg
+	}
+}
+
+// Run runs m in a program under a Guard, returning the final model and
+// the panic that ended it, if one did. Bubble Tea's own panic handler is
+// turned off; the guard gets there first.
+func Run(m tea.Model, opts ...tea.ProgramOption) (tea.Model, *Panic, error) {
+	g := NewGuard(m)
+	p := tea.NewProgram(g, append(opts, tea.WithoutCatchPanics())...)
+	g.quit = p.Quit
+	_, err := p.Run()
+	return g.model, g.panic, err
+}
+
+// DefaultDir is where crash reports go: ~/.cache/float-line/crashes
+func DefaultDir() string {
+	return filepath.Join(cache.Dir(), "crashes")
+}
+
+// Report is a crash report directory: panic.txt with the panic and its
+// stack, and whatever state the program adds
+type Report struct {
+	Dir string
+}
+
+// NewReport creates app's report for p in a new directory under dir,
+// named for the time of the panic
+func NewReport(dir, app string, p *Panic) (*Report, error) {
+	r := &Report{Dir: filepath.Join(dir, app+"-"+p.Time.Format("20060102-150405"))}
+	if err := os.MkdirAll(r.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("create crash report: %w", err)
+	}
+	summary := fmt.Sprintf("%s crashed at %s (%s, %s/%s)\n\npanic: %v\n\n%s",
+		app, p.Time.Format(time.RFC3339), runtime.Version(), runtime.GOOS, runtime.GOARCH, p.Value, p.Stack)
+	if err := r.Add("panic.txt", []byte(summary)); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Add writes a file into the report
+func (r *Report) Add(name string, data []byte) error {
+	if err := encrypt.WriteFile(filepath.Join(r.Dir, name), data, 0600); err != nil {
+		return fmt.Errorf("write crash report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package crash
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/teatest"
+)
+
+// panicky panics on "u" in Update and on "c" in a batched command
+type panicky struct {
+	keys string
+}
+
+func (m panicky) Init() tea.Cmd { return nil }
+
+func (m panicky) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "u":
+		panic("update broke")
+	case "c":
+		return m, tea.Batch(nil, func() tea.Msg { panic("command broke") })
+	}
+	m.keys += key.String()
+	return m, nil
+}
+
+func (m panicky) View() string { return "keys: " + m.keys }
+
+func TestGuardRecovers(t *testing.T) {
+	for key, want := range map[string]string{"u": "update broke", "c": "command broke"} {
+		tm := teatest.NewTestModel(t, NewGuard(panicky{}), teatest.WithInitialTermSize(40, 5))
+		tm.Type("ab" + key + "z")
+		g := tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second)).(*Guard)
+
+		p := g.Panic()
+		if p == nil || p.Value != want {
+			t.Fatalf("%s: panic = %v, want %q", key, p, want)
+		}
+		if !strings.Contains(string(p.Stack), "crash.panicky.Update") && key == "u" {
+			t.Errorf("stack doesn't show the panic:\n%s", p.Stack)
+		}
+		// Keys typed before the panic were kept. A command panics on its
+		// own goroutine, so keys typed after it may still arrive first.
+		got := g.Model().(panicky).keys
+		if key == "u" && got != "ab" || !strings.HasPrefix(got, "ab") {
+			t.Errorf("%s: model keys = %q, want ab", key, got)
+		}
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	p := &Panic{Value: "boom", Stack: []byte("goroutine 1 [running]:"), Time: time.Date(2026, 10, 14, 9, 30, 0, 0, time.UTC)}
+	r, err := NewReport(t.TempDir(), "float-outliner", p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(r.Dir) != "float-outliner-20261014-093000" {
+		t.Errorf("report dir = %s", r.Dir)
+	}
+	if err := r.Add("outline.md", []byte("• ctx:: kept\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := os.ReadFile(filepath.Join(r.Dir, "panic.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(summary), "panic: boom") || !strings.Contains(string(summary), "goroutine 1") {
+		t.Errorf("panic.txt = %q", summary)
+	}
+	if _, err := os.Stat(filepath.Join(r.Dir, "outline.md")); err != nil {
+		t.Error(err)
+	}
+}
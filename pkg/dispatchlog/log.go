@@ -0,0 +1,126 @@
+package dispatchlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/encrypt"
+)
+
+// Entry is one dispatched pattern, one JSON object per line in the log
+type Entry struct {
+	Seq      int64             `json:"seq"`
+	Time     time.Time         `json:"time"`
+	Source   string            `json:"source"` // file the pattern came from
+	Line     int               `json:"line"`
+	Type     string            `json:"type"`
+	Content  string            `json:"content"`
+	Context  map[string]string `json:"context,omitempty"`
+	ActionID string            `json:"action_id"`
+	Imprint  string            `json:"imprint"`
+	Sigil    string            `json:"sigil,omitempty"`
+}
+
+// Log is an append-only JSONL dispatch log shared across files; sequence
+// numbers are monotonic so readers can resume from the last one they saw
+type Log struct {
+	path string
+	mu   sync.Mutex
+	seq  int64
+}
+
+// Open opens or creates the log at path and restores the last sequence
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create log dir: %w", err)
+	}
+
+	l := &Log{path: path}
+	entries, err := l.ReadSince(0)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		l.seq = entries[len(entries)-1].Seq
+	}
+	return l, nil
+}
+
+// Path returns the log file location
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append assigns the next sequence number to e and writes it
+func (l *Log) Append(e Entry) (Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seq++
+	e.Seq = l.seq
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	data, err := json.Marshal(e)
+	if err == nil {
+		data, err = encrypt.SealLine(data)
+	}
+	if err != nil {
+		l.seq--
+		return e, err
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		l.seq--
+		return e, fmt.Errorf("open dispatch log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		l.seq--
+		return e, fmt.Errorf("write dispatch log: %w", err)
+	}
+	return e, nil
+}
+
+// ReadSince returns entries with a sequence greater than seq
+func (l *Log) ReadSince(seq int64) ([]Entry, error) {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open dispatch log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line, err := encrypt.OpenLine(scanner.Bytes())
+		if errors.Is(err, encrypt.ErrLocked) {
+			return nil, fmt.Errorf("dispatch log %s is %w", l.path, err)
+		}
+		var e Entry
+		if err != nil || json.Unmarshal(line, &e) != nil {
+			// A torn final write shouldn't make the whole log unreadable
+			continue
+		}
+		if e.Seq > seq {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("read dispatch log: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,115 @@
+package dispatchstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/evanschultz/float-rw-client/pkg/dispatchlog"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+)
+
+// JSONL persists actions in a dispatch log file, the format `watch` and
+// `serve --log` write, keeping them in memory for queries
+type JSONL struct {
+	mu  sync.Mutex
+	log *dispatchlog.Log
+	mem *outliner.MemoryStore
+}
+
+// OpenJSONL opens or creates the log at path and loads its actions
+func OpenJSONL(path string) (*JSONL, error) {
+	log, err := dispatchlog.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := log.ReadSince(0)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &JSONL{log: log, mem: outliner.NewMemoryStore()}
+	for _, e := range entries {
+		s.mem.Append(FromEntry(e))
+	}
+	return s, nil
+}
+
+// Path returns the log file location
+func (s *JSONL) Path() string {
+	return s.log.Path()
+}
+
+// Append writes action to the log, then keeps it
+func (s *JSONL) Append(action outliner.DispatchAction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.log.Append(ToEntry(action)); err != nil {
+		return err
+	}
+	return s.mem.Append(action)
+}
+
+// Query returns the logged actions q selects, oldest first
+func (s *JSONL) Query(q outliner.DispatchQuery) ([]outliner.DispatchAction, error) {
+	return s.mem.Query(q)
+}
+
+// Stream delivers actions q selects as they're appended, until ctx is done
+func (s *JSONL) Stream(ctx context.Context, q outliner.DispatchQuery) <-chan outliner.DispatchAction {
+	return s.mem.Stream(ctx, q)
+}
+
+// Reset empties the log file
+func (s *JSONL) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Truncate(s.log.Path(), 0); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reset dispatch log: %w", err)
+	}
+	return s.mem.Reset()
+}
+
+// ToEntry is action as a dispatch log entry. Node IDs written as
+// source:line, the way watch and serve name them, are split back up.
+func ToEntry(action outliner.DispatchAction) dispatchlog.Entry {
+	source, line := action.NodeID, 0
+	if i := strings.LastIndex(source, ":"); i >= 0 {
+		if n, err := strconv.Atoi(source[i+1:]); err == nil {
+			source, line = source[:i], n
+		}
+	}
+	return dispatchlog.Entry{
+		Time:     action.Timestamp,
+		Source:   source,
+		Line:     line,
+		Type:     action.PatternType,
+		Content:  action.Content,
+		Context:  action.Metadata,
+		ActionID: action.ID,
+		Imprint:  action.Imprint,
+		Sigil:    action.Sigil,
+	}
+}
+
+// FromEntry is the action a dispatch log entry records
+func FromEntry(e dispatchlog.Entry) outliner.DispatchAction {
+	nodeID := e.Source
+	if e.Line > 0 {
+		nodeID = fmt.Sprintf("%s:%d", e.Source, e.Line)
+	}
+	return outliner.DispatchAction{
+		ID:          e.ActionID,
+		NodeID:      nodeID,
+		Content:     e.Content,
+		PatternType: e.Type,
+		Imprint:     e.Imprint,
+		Sigil:       e.Sigil,
+		Metadata:    e.Context,
+		Timestamp:   e.Time,
+		State:       outliner.StateDispatch,
+	}
+}
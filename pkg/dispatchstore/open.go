@@ -0,0 +1,26 @@
+// Package dispatchstore has the persistent outliner.DispatchStore
+// implementations: a JSONL dispatch log and a SQLite database
+package dispatchstore
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/evanschultz/float-rw-client/pkg/encrypt"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+)
+
+// Open opens the store at path: SQLite for .db, .sqlite and .sqlite3
+// files, a JSONL dispatch log otherwise. Only JSONL logs can be encrypted
+// at rest, so SQLite is refused while encryption is on.
+func Open(path string) (outliner.DispatchStore, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".db", ".sqlite", ".sqlite3":
+		if encrypt.Active() != nil {
+			return nil, fmt.Errorf("%s: SQLite stores aren't encrypted at rest; use a .jsonl log with [encryption] on", path)
+		}
+		return OpenSQLite(path)
+	}
+	return OpenJSONL(path)
+}
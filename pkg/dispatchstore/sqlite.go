@@ -0,0 +1,157 @@
+package dispatchstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver, no cgo needed
+
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS actions (
+	seq          INTEGER PRIMARY KEY AUTOINCREMENT,
+	id           TEXT NOT NULL,
+	node_id      TEXT NOT NULL DEFAULT '',
+	content      TEXT NOT NULL,
+	pattern_type TEXT NOT NULL,
+	imprint      TEXT NOT NULL DEFAULT '',
+	sigil        TEXT NOT NULL DEFAULT '',
+	metadata     TEXT NOT NULL DEFAULT '{}',
+	dispatched   INTEGER NOT NULL, -- unix nanoseconds
+	state        TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS actions_pattern_type ON actions (pattern_type, dispatched);
+CREATE INDEX IF NOT EXISTS actions_imprint ON actions (imprint, dispatched);
+CREATE INDEX IF NOT EXISTS actions_dispatched ON actions (dispatched);
+`
+
+// SQLite persists actions in a SQLite database, queried with indexes on
+// pattern type, imprint and time rather than held in memory
+type SQLite struct {
+	outliner.ActionStream
+
+	db   *sql.DB
+	path string
+}
+
+// OpenSQLite opens or creates the database at path
+func OpenSQLite(path string) (*SQLite, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create store dir: %w", err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	// One connection serializes writers, which SQLite would anyway
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema in %s: %w", path, err)
+	}
+	return &SQLite{db: db, path: path}, nil
+}
+
+// Path returns the database location
+func (s *SQLite) Path() string {
+	return s.path
+}
+
+// Close closes the database
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+// Append inserts action
+func (s *SQLite) Append(action outliner.DispatchAction) error {
+	metadata, err := json.Marshal(action.Metadata)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO actions (id, node_id, content, pattern_type, imprint, sigil, metadata, dispatched, state)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		action.ID, action.NodeID, action.Content, action.PatternType, action.Imprint, action.Sigil,
+		string(metadata), action.Timestamp.UnixNano(), string(action.State))
+	if err != nil {
+		return fmt.Errorf("store action: %w", err)
+	}
+	s.Publish(action)
+	return nil
+}
+
+// Query returns the actions q selects, oldest first
+func (s *SQLite) Query(q outliner.DispatchQuery) ([]outliner.DispatchAction, error) {
+	var where []string
+	var args []interface{}
+	if q.PatternType != "" {
+		where, args = append(where, "pattern_type = ?"), append(args, q.PatternType)
+	}
+	if q.Imprint != "" {
+		where, args = append(where, "imprint = ?"), append(args, q.Imprint)
+	}
+	if q.Text != "" {
+		where, args = append(where, "instr(lower(content), ?) > 0"), append(args, strings.ToLower(q.Text))
+	}
+	if !q.Since.IsZero() {
+		where, args = append(where, "dispatched > ?"), append(args, q.Since.UnixNano())
+	}
+	if !q.Until.IsZero() {
+		where, args = append(where, "dispatched < ?"), append(args, q.Until.UnixNano())
+	}
+
+	query := "SELECT id, node_id, content, pattern_type, imprint, sigil, metadata, dispatched, state FROM actions"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	// The most recent Limit, put back in order below
+	query += " ORDER BY seq DESC"
+	if q.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", q.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query actions: %w", err)
+	}
+	defer rows.Close()
+
+	var actions []outliner.DispatchAction
+	for rows.Next() {
+		var action outliner.DispatchAction
+		var metadata, state string
+		var dispatched int64
+		if err := rows.Scan(&action.ID, &action.NodeID, &action.Content, &action.PatternType, &action.Imprint,
+			&action.Sigil, &metadata, &dispatched, &state); err != nil {
+			return nil, fmt.Errorf("query actions: %w", err)
+		}
+		if err := json.Unmarshal([]byte(metadata), &action.Metadata); err != nil {
+			return nil, fmt.Errorf("action %s metadata: %w", action.ID, err)
+		}
+		action.Timestamp = time.Unix(0, dispatched)
+		action.State = outliner.DispatchState(state)
+		actions = append(actions, action)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query actions: %w", err)
+	}
+
+	for i, j := 0, len(actions)-1; i < j; i, j = i+1, j-1 {
+		actions[i], actions[j] = actions[j], actions[i]
+	}
+	return actions, nil
+}
+
+// Reset deletes every action
+func (s *SQLite) Reset() error {
+	if _, err := s.db.Exec("DELETE FROM actions"); err != nil {
+		return fmt.Errorf("reset actions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package dispatchstore
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+)
+
+var base = time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+
+func action(id, pattern, imprint, content string, minute int) outliner.DispatchAction {
+	return outliner.DispatchAction{
+		ID:          id,
+		NodeID:      "notes.md:" + id,
+		Content:     content,
+		PatternType: pattern,
+		Imprint:     imprint,
+		Metadata:    map[string]string{"raw": content},
+		Timestamp:   base.Add(time.Duration(minute) * time.Minute),
+		State:       outliner.StateDispatch,
+	}
+}
+
+// stores opens each implementation, reopening at the same location when
+// called again with the same dir
+var stores = map[string]func(dir string) (outliner.DispatchStore, error){
+	"memory": func(string) (outliner.DispatchStore, error) { return outliner.NewMemoryStore(), nil },
+	"jsonl": func(dir string) (outliner.DispatchStore, error) {
+		return OpenJSONL(filepath.Join(dir, "dispatch.jsonl"))
+	},
+	"sqlite": func(dir string) (outliner.DispatchStore, error) {
+		return OpenSQLite(filepath.Join(dir, "dispatch.db"))
+	},
+}
+
+func ids(actions []outliner.DispatchAction) []string {
+	var out []string
+	for _, a := range actions {
+		out = append(out, a.ID)
+	}
+	return out
+}
+
+func TestStores(t *testing.T) {
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			store, err := open(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, a := range []outliner.DispatchAction{
+				action("1", "ctx", "techcraft", "Morning sync", 0),
+				action("2", "eureka", "techcraft", "Stores are pluggable", 10),
+				action("3", "ctx", "feral_duality", "Afternoon SYNC", 20),
+				action("4", "ctx", "techcraft", "Evening", 30),
+			} {
+				if err := store.Append(a); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, tc := range []struct {
+				q    outliner.DispatchQuery
+				want string
+			}{
+				{outliner.DispatchQuery{}, "1234"},
+				{outliner.DispatchQuery{PatternType: "ctx"}, "134"},
+				{outliner.DispatchQuery{Imprint: "techcraft"}, "124"},
+				{outliner.DispatchQuery{Text: "sync"}, "13"},
+				{outliner.DispatchQuery{Since: base.Add(10 * time.Minute)}, "34"},
+				{outliner.DispatchQuery{Until: base.Add(20 * time.Minute)}, "12"},
+				{outliner.DispatchQuery{PatternType: "ctx", Limit: 2}, "34"},
+			} {
+				got, err := store.Query(tc.q)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if s := strings.Join(ids(got), ""); s != tc.want {
+					t.Errorf("Query(%+v) = %s, want %s", tc.q, s, tc.want)
+				}
+			}
+
+			got, _ := store.Query(outliner.DispatchQuery{Limit: 1})
+			if len(got) != 1 || got[0].Metadata["raw"] != "Evening" || !got[0].Timestamp.Equal(base.Add(30*time.Minute)) ||
+				got[0].NodeID != "notes.md:4" || got[0].State != outliner.StateDispatch {
+				t.Errorf("stored action = %+v", got)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			stream := store.Stream(ctx, outliner.DispatchQuery{PatternType: "eureka"})
+			store.Append(action("5", "ctx", "techcraft", "skipped", 40))
+			store.Append(action("6", "eureka", "techcraft", "streamed", 50))
+			select {
+			case a := <-stream:
+				if a.ID != "6" {
+					t.Errorf("streamed %s, want 6", a.ID)
+				}
+			case <-time.After(time.Second):
+				t.Error("nothing streamed")
+			}
+			cancel()
+
+			if name != "memory" {
+				reopened, err := open(dir)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, _ := reopened.Query(outliner.DispatchQuery{})
+				if s := strings.Join(ids(got), ""); s != "123456" {
+					t.Errorf("reopened store has %s", s)
+				}
+				if c, ok := reopened.(interface{ Close() error }); ok {
+					c.Close()
+				}
+			}
+
+			if err := store.Reset(); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := store.Query(outliner.DispatchQuery{}); len(got) != 0 {
+				t.Errorf("after Reset, %d actions", len(got))
+			}
+		})
+	}
+}
+
+// TestDispatchSystemStore runs a dispatch system on a persistent store
+func TestDispatchSystemStore(t *testing.T) {
+	store, err := OpenSQLite(filepath.Join(t.TempDir(), "dispatch.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	d := outliner.NewFloatDispatchSystem()
+	d.SetStore(store)
+	d.Dispatch("n1", "ctx:: first [project:: store]", "ctx")
+	d.Dispatch("n2", "eureka:: second", "eureka")
+
+	got, err := store.Query(outliner.DispatchQuery{PatternType: "eureka"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].NodeID != "n2" {
+		t.Errorf("store has %+v", got)
+	}
+	if n := len(d.GetActions()); n != 2 {
+		t.Errorf("GetActions = %d actions, want 2", n)
+	}
+}
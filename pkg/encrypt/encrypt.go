@@ -0,0 +1,249 @@
+// Package encrypt keeps consciousness data encrypted at rest with age.
+// Everything is sealed to one X25519 identity: an age keyfile, or an
+// identity kept in a file encrypted with a passphrase, so the passphrase's
+// scrypt work is paid once when unlocking rather than per write.
+package encrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// linePrefix starts a sealed line in a JSONL file, so plain lines written
+// before encryption was turned on still read
+const linePrefix = "age:"
+
+// ErrLocked is returned reading sealed data without a key
+var ErrLocked = errors.New("encrypted at rest; unlock it with [encryption] in config.toml")
+
+// Key seals and opens data for one identity
+type Key struct {
+	identity *age.X25519Identity
+}
+
+// LoadKeyfile reads an age identity file, as age-keygen writes it
+func LoadKeyfile(path string) (*Key, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open keyfile: %w", err)
+	}
+	defer f.Close()
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("read keyfile %s: %w", path, err)
+	}
+	for _, identity := range identities {
+		if x, ok := identity.(*age.X25519Identity); ok {
+			return &Key{identity: x}, nil
+		}
+	}
+	return nil, fmt.Errorf("keyfile %s has no X25519 identity", path)
+}
+
+// Unlock opens the passphrase-protected identity at path, creating one
+// the first time
+func Unlock(path string, passphrase []byte) (*Key, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return create(path, passphrase)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read identity: %w", err)
+	}
+
+	scrypt, err := age.NewScryptIdentity(string(passphrase))
+	if err != nil {
+		return nil, err
+	}
+	r, err := age.Decrypt(armor.NewReader(bytes.NewReader(data)), scrypt)
+	var noMatch *age.NoIdentityMatchError
+	if errors.As(err, &noMatch) {
+		return nil, errors.New("wrong passphrase")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unlock %s: %w", path, err)
+	}
+	secret, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unlock %s: %w", path, err)
+	}
+	identity, err := age.ParseX25519Identity(strings.TrimSpace(string(secret)))
+	if err != nil {
+		return nil, fmt.Errorf("unlock %s: %w", path, err)
+	}
+	return &Key{identity: identity}, nil
+}
+
+// create generates an identity and writes it encrypted with passphrase
+func create(path string, passphrase []byte) (*Key, error) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, err
+	}
+	scrypt, err := age.NewScryptRecipient(string(passphrase))
+	if err != nil {
+		return nil, err
+	}
+	data, err := seal([]byte(identity.String()+"\n"), scrypt)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("create identity: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("create identity: %w", err)
+	}
+	return &Key{identity: identity}, nil
+}
+
+// seal encrypts plain to recipient as an armored age file
+func seal(plain []byte, recipient age.Recipient) ([]byte, error) {
+	var b bytes.Buffer
+	a := armor.NewWriter(&b)
+	w, err := age.Encrypt(a, recipient)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plain); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := a.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Seal encrypts a whole file's contents as armored age
+func (k *Key) Seal(plain []byte) ([]byte, error) {
+	return seal(plain, k.identity.Recipient())
+}
+
+// Open decrypts what Seal wrote; anything else is returned as is
+func (k *Key) Open(data []byte) ([]byte, error) {
+	if !Sealed(data) {
+		return data, nil
+	}
+	r, err := age.Decrypt(armor.NewReader(bytes.NewReader(data)), k.identity)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+	return io.ReadAll(r)
+}
+
+// SealLine encrypts one line of a JSONL file, keeping it one line
+func (k *Key) SealLine(plain []byte) ([]byte, error) {
+	var b bytes.Buffer
+	w, err := age.Encrypt(&b, k.identity.Recipient())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plain); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(linePrefix + base64.StdEncoding.EncodeToString(b.Bytes())), nil
+}
+
+// OpenLine decrypts what SealLine wrote; plain lines are returned as is
+func (k *Key) OpenLine(line []byte) ([]byte, error) {
+	if !SealedLine(line) {
+		return line, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(string(line[len(linePrefix):]))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt line: %w", err)
+	}
+	r, err := age.Decrypt(bytes.NewReader(data), k.identity)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt line: %w", err)
+	}
+	return io.ReadAll(r)
+}
+
+// Sealed reports whether data is an armored age file
+func Sealed(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte(armor.Header))
+}
+
+// SealedLine reports whether line was written by SealLine
+func SealedLine(line []byte) bool {
+	return bytes.HasPrefix(line, []byte(linePrefix))
+}
+
+// active is the key files are kept with, nil when encryption is off
+var active atomic.Pointer[Key]
+
+// Use makes k the key WriteFile, ReadFile and the line helpers use; nil
+// turns encryption off
+func Use(k *Key) {
+	active.Store(k)
+}
+
+// Active returns the key in use, or nil
+func Active() *Key {
+	return active.Load()
+}
+
+// WriteFile writes data to path, sealed when encryption is on
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if k := Active(); k != nil {
+		sealed, err := k.Seal(data)
+		if err != nil {
+			return fmt.Errorf("encrypt %s: %w", path, err)
+		}
+		data = sealed
+	}
+	return os.WriteFile(path, data, perm)
+}
+
+// ReadFile reads path, opening it if it was sealed
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !Sealed(data) {
+		return data, err
+	}
+	k := Active()
+	if k == nil {
+		return nil, fmt.Errorf("%s is %w", path, ErrLocked)
+	}
+	return k.Open(data)
+}
+
+// SealLine seals a JSONL line when encryption is on
+func SealLine(line []byte) ([]byte, error) {
+	if k := Active(); k != nil {
+		return k.SealLine(line)
+	}
+	return line, nil
+}
+
+// OpenLine opens a line SealLine sealed; plain lines pass through
+func OpenLine(line []byte) ([]byte, error) {
+	if !SealedLine(line) {
+		return line, nil
+	}
+	k := Active()
+	if k == nil {
+		return nil, ErrLocked
+	}
+	return k.OpenLine(line)
+}
@@ -0,0 +1,69 @@
+package encrypt
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "identity.age")
+	k, err := Unlock(path, []byte("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); !Sealed(data) {
+		t.Fatalf("identity isn't sealed:\n%s", data)
+	}
+
+	again, err := Unlock(path, []byte("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.identity.String() != k.identity.String() {
+		t.Error("unlocking again gave a different identity")
+	}
+	if _, err := Unlock(path, []byte("battery staple")); err == nil {
+		t.Error("wrong passphrase unlocked the identity")
+	}
+}
+
+func TestSealed(t *testing.T) {
+	k, err := Unlock(filepath.Join(t.TempDir(), "identity.age"), []byte("pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := []byte(`{"id":"a","content":"ctx:: private"}`)
+	path := filepath.Join(t.TempDir(), "out.md")
+
+	Use(k)
+	sealed, err := SealLine(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, []byte("private")) || bytes.ContainsRune(sealed, '\n') {
+		t.Fatalf("sealed line = %q", sealed)
+	}
+	if err := WriteFile(path, []byte("# outline\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := OpenLine(sealed); err != nil || !bytes.Equal(got, line) {
+		t.Errorf("OpenLine = %q, %v", got, err)
+	}
+	if got, err := ReadFile(path); err != nil || string(got) != "# outline\n" {
+		t.Errorf("ReadFile = %q, %v", got, err)
+	}
+
+	Use(nil)
+	if _, err := OpenLine(sealed); !errors.Is(err, ErrLocked) {
+		t.Errorf("OpenLine without a key = %v, want ErrLocked", err)
+	}
+	if _, err := ReadFile(path); !errors.Is(err, ErrLocked) {
+		t.Errorf("ReadFile without a key = %v, want ErrLocked", err)
+	}
+	if got, _ := OpenLine(line); !bytes.Equal(got, line) {
+		t.Errorf("plain line = %q", got)
+	}
+}
@@ -0,0 +1,92 @@
+package encrypt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/x/term"
+
+	"github.com/evanschultz/float-rw-client/pkg/config"
+)
+
+// PassphraseEnv holds the passphrase for scripts and services that can't
+// be asked for it
+const PassphraseEnv = "FLOAT_LINE_PASSPHRASE"
+
+// Prompt asks for the passphrase; create is set when the identity is new
+// and the passphrase should be confirmed
+type Prompt func(create bool) ([]byte, error)
+
+// IdentityPath is the passphrase-protected identity cfg names
+func IdentityPath(cfg config.EncryptionConfig) string {
+	if cfg.Identity != "" {
+		return config.ExpandHome(cfg.Identity)
+	}
+	return filepath.Join(config.Dir(), "identity.age")
+}
+
+// Setup unlocks the key the [encryption] section configures and puts it
+// in use: the keyfile, or the identity behind a passphrase taken from
+// FLOAT_LINE_PASSPHRASE or asked for with prompt. With encryption off no
+// key is used and sealed files can't be read.
+func Setup(cfg config.EncryptionConfig, prompt Prompt) error {
+	if !cfg.Enabled {
+		Use(nil)
+		return nil
+	}
+	if cfg.Keyfile != "" {
+		k, err := LoadKeyfile(config.ExpandHome(cfg.Keyfile))
+		if err != nil {
+			return err
+		}
+		Use(k)
+		return nil
+	}
+
+	path := IdentityPath(cfg)
+	passphrase := []byte(os.Getenv(PassphraseEnv))
+	if len(passphrase) == 0 {
+		_, err := os.Stat(path)
+		if passphrase, err = prompt(errors.Is(err, os.ErrNotExist)); err != nil {
+			return err
+		}
+	}
+	k, err := Unlock(path, passphrase)
+	if err != nil {
+		return err
+	}
+	Use(k)
+	return nil
+}
+
+// TerminalPrompt asks for the passphrase on the terminal without echoing
+// it, twice for a new identity
+func TerminalPrompt(create bool) ([]byte, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return nil, fmt.Errorf("no terminal to ask for the passphrase; set %s", PassphraseEnv)
+	}
+	read := func(prompt string) ([]byte, error) {
+		fmt.Fprint(os.Stderr, prompt)
+		defer fmt.Fprintln(os.Stderr)
+		return term.ReadPassword(os.Stdin.Fd())
+	}
+
+	if create {
+		fmt.Fprintln(os.Stderr, "Choose a passphrase for float-line's encrypted storage.")
+	}
+	passphrase, err := read("Passphrase: ")
+	if err != nil || !create {
+		return passphrase, err
+	}
+	again, err := read("Again: ")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(passphrase, again) {
+		return nil, errors.New("passphrases don't match")
+	}
+	return passphrase, nil
+}
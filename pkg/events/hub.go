@@ -0,0 +1,241 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/dispatchlog"
+)
+
+// Event kinds
+const (
+	KindAction        = "action"
+	KindReducerUpdate = "reducer_update"
+)
+
+const (
+	// defaultBuffer is how many recent events are kept for replay
+	defaultBuffer = 1000
+	// subscriberBuffer is how far a subscriber may fall behind before it
+	// is dropped
+	subscriberBuffer = 256
+	heartbeat        = 15 * time.Second
+)
+
+// Event is one item on the live stream. Reducer updates carry the sequence
+// of the action that triggered them.
+type Event struct {
+	Seq     int64             `json:"seq"`
+	Kind    string            `json:"kind"`
+	Reducer string            `json:"reducer,omitempty"`
+	Action  dispatchlog.Entry `json:"action"`
+}
+
+// Hub fans dispatch activity out to stream subscribers and replays recent
+// history, falling back to the dispatch log for older sequences
+type Hub struct {
+	mu     sync.Mutex
+	log    *dispatchlog.Log // optional
+	seq    int64
+	recent []Event
+	size   int
+	subs   map[chan Event]bool
+}
+
+// NewHub creates a hub; log may be nil, which limits replay to what is
+// still in memory
+func NewHub(log *dispatchlog.Log) *Hub {
+	h := &Hub{
+		log:  log,
+		size: defaultBuffer,
+		subs: make(map[chan Event]bool),
+	}
+	if log != nil {
+		if entries, err := log.ReadSince(0); err == nil && len(entries) > 0 {
+			h.seq = entries[len(entries)-1].Seq
+		}
+	}
+	return h
+}
+
+// PublishAction streams a dispatched action. Entries already appended to
+// the log keep their sequence; others get the next one.
+func (h *Hub) PublishAction(entry dispatchlog.Entry) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if entry.Seq == 0 {
+		entry.Seq = h.seq + 1
+	}
+	if entry.Seq > h.seq {
+		h.seq = entry.Seq
+	}
+	return h.publishLocked(Event{Seq: entry.Seq, Kind: KindAction, Action: entry})
+}
+
+// PublishReducerUpdate streams a reducer collecting an action
+func (h *Hub) PublishReducerUpdate(reducer string, entry dispatchlog.Entry) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.publishLocked(Event{Seq: entry.Seq, Kind: KindReducerUpdate, Reducer: reducer, Action: entry})
+}
+
+func (h *Hub) publishLocked(e Event) Event {
+	h.recent = append(h.recent, e)
+	if len(h.recent) > h.size {
+		h.recent = h.recent[len(h.recent)-h.size:]
+	}
+
+	for ch := range h.subs {
+		select {
+		case ch <- e:
+		default:
+			// Too slow to keep up; the client reconnects with Last-Event-ID
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+	return e
+}
+
+// Subscribe returns events after since (replayed first) followed by live
+// events. Call cancel when done.
+func (h *Hub) Subscribe(since int64) (replay []Event, live <-chan Event, cancel func(), err error) {
+	// Older history comes from the log, read outside the lock
+	var logged []Event
+	h.mu.Lock()
+	oldest := int64(-1)
+	if len(h.recent) > 0 {
+		oldest = h.recent[0].Seq
+	}
+	h.mu.Unlock()
+
+	if h.log != nil && (oldest == -1 || since+1 < oldest) {
+		entries, err := h.log.ReadSince(since)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for _, entry := range entries {
+			if oldest != -1 && entry.Seq >= oldest {
+				break
+			}
+			logged = append(logged, Event{Seq: entry.Seq, Kind: KindAction, Action: entry})
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	replay = logged
+	last := since
+	if len(logged) > 0 {
+		last = logged[len(logged)-1].Seq
+	}
+	for _, e := range h.recent {
+		if e.Seq > last {
+			replay = append(replay, e)
+		}
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	h.subs[ch] = true
+	cancel = func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.subs[ch] {
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+	return replay, ch, cancel, nil
+}
+
+// ServeHTTP streams events as Server-Sent Events. Resume with ?since=<seq>
+// or the Last-Event-ID header; filter with ?kind=action|reducer_update.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	since := int64(0)
+	cursor := r.URL.Query().Get("since")
+	if cursor == "" {
+		cursor = r.Header.Get("Last-Event-ID")
+	}
+	if cursor != "" {
+		n, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || n < 0 {
+			http.Error(w, "since must be a non-negative sequence number", http.StatusBadRequest)
+			return
+		}
+		since = n
+	} else {
+		// Fresh subscribers only get live events
+		h.mu.Lock()
+		since = h.seq
+		h.mu.Unlock()
+	}
+	kind := r.URL.Query().Get("kind")
+
+	replay, live, cancel, err := h.Subscribe(since)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, e := range replay {
+		if err := writeEvent(w, e, kind); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-live:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, e, kind); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes one SSE frame, skipping kinds the client filtered out
+func writeEvent(w http.ResponseWriter, e Event, kind string) error {
+	if kind != "" && e.Kind != kind {
+		return nil
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Kind, data)
+	return err
+}
@@ -0,0 +1,210 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/dispatchlog"
+)
+
+// newLoggedHub returns a hub over a log of n entries, all published, that
+// keeps the last size events in memory; a reducer update follows the
+// second to last action
+func newLoggedHub(t *testing.T, n, size int) *Hub {
+	t.Helper()
+	log, err := dispatchlog.Open(filepath.Join(t.TempDir(), "dispatch-log.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []dispatchlog.Entry
+	for i := 1; i <= n; i++ {
+		entry, err := log.Append(dispatchlog.Entry{Type: "ctx", Content: fmt.Sprint("entry ", i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+
+	h := NewHub(log)
+	h.size = size
+	for i, entry := range entries {
+		h.PublishAction(entry)
+		if i == n-2 {
+			h.PublishReducerUpdate("daily", entry)
+		}
+	}
+	return h
+}
+
+// describe renders events as "<seq><kind initial>" for comparison
+func describe(events []Event) []string {
+	var got []string
+	for _, e := range events {
+		got = append(got, fmt.Sprintf("%d%c", e.Seq, e.Kind[0]))
+	}
+	return got
+}
+
+func TestSubscribeReplay(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		since int64
+		want  []string
+	}{
+		// 1-3 only remain in the log, 4, its update and 5 in memory
+		{"log and memory", 1, []string{"2a", "3a", "4a", "4r", "5a"}},
+		{"log from the start", 0, []string{"1a", "2a", "3a", "4a", "4r", "5a"}},
+		{"memory only", 4, []string{"5a"}},
+		{"caught up", 5, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newLoggedHub(t, 5, 3)
+			replay, _, cancel, err := h.Subscribe(tc.since)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cancel()
+			if got := describe(replay); !slices.Equal(got, tc.want) {
+				t.Errorf("replay since %d = %v, want %v", tc.since, got, tc.want)
+			}
+		})
+	}
+
+	// Without a log, replay is what memory still holds
+	h := NewHub(nil)
+	h.size = 2
+	for i := 0; i < 4; i++ {
+		h.PublishAction(dispatchlog.Entry{Content: fmt.Sprint(i)})
+	}
+	replay, _, cancel, err := h.Subscribe(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+	if got := describe(replay); !slices.Equal(got, []string{"3a", "4a"}) {
+		t.Errorf("replay without a log = %v", got)
+	}
+}
+
+func TestSlowSubscriberDropped(t *testing.T) {
+	h := NewHub(nil)
+	_, slow, cancelSlow, err := h.Subscribe(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, fast, cancelFast, err := h.Subscribe(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancelFast()
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		h.PublishAction(dispatchlog.Entry{})
+		if i < subscriberBuffer {
+			<-fast
+		}
+	}
+
+	// The slow one gets what fit in its buffer, then its channel closes
+	received := 0
+	for range slow {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("slow subscriber received %d events before being dropped, want %d", received, subscriberBuffer)
+	}
+	cancelSlow() // cancelling after being dropped is harmless
+
+	if e, ok := <-fast; !ok || e.Seq != subscriberBuffer+1 {
+		t.Errorf("the subscriber keeping up was dropped: %+v, %v", e, ok)
+	}
+	if len(h.subs) != 1 {
+		t.Errorf("hub has %d subscribers, want 1", len(h.subs))
+	}
+}
+
+// stream GETs the hub's events and returns the status and the first n
+// events as "<id> <kind>"; publish, if set, runs once the stream is open
+func stream(t *testing.T, h *Hub, query, lastEventID string, n int, publish func()) (int, []string) {
+	t.Helper()
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+query, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+	if publish != nil {
+		publish()
+	}
+
+	var events []string
+	id := ""
+	scanner := bufio.NewScanner(resp.Body)
+	for len(events) < n && scanner.Scan() {
+		line := scanner.Text()
+		if v, ok := strings.CutPrefix(line, "id: "); ok {
+			id = v
+		}
+		if v, ok := strings.CutPrefix(line, "event: "); ok {
+			events = append(events, id+" "+v)
+		}
+	}
+	return resp.StatusCode, events
+}
+
+func TestServeHTTP(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		query       string
+		lastEventID string
+		status      int
+		want        []string
+	}{
+		{"since", "?since=3", "", http.StatusOK, []string{"4 action", "4 reducer_update", "5 action"}},
+		{"last event id", "", "4", http.StatusOK, []string{"5 action"}},
+		{"since wins", "?since=4", "1", http.StatusOK, []string{"5 action"}},
+		{"kind", "?since=0&kind=reducer_update", "", http.StatusOK, []string{"4 reducer_update"}},
+		{"not a number", "?since=yesterday", "", http.StatusBadRequest, nil},
+		{"negative", "?since=-1", "", http.StatusBadRequest, nil},
+		{"bad last event id", "", "x", http.StatusBadRequest, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newLoggedHub(t, 5, 3)
+			status, got := stream(t, h, "/events"+tc.query, tc.lastEventID, len(tc.want), nil)
+			if status != tc.status {
+				t.Fatalf("status = %d, want %d", status, tc.status)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("events = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	// With no cursor, a subscriber starts from what's published next
+	h := newLoggedHub(t, 5, 3)
+	_, got := stream(t, h, "/events", "", 1, func() {
+		h.PublishAction(dispatchlog.Entry{Content: "live"})
+	})
+	if !slices.Equal(got, []string{"6 action"}) {
+		t.Errorf("fresh subscriber got %v, want only the live event", got)
+	}
+}
@@ -0,0 +1,435 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/api"
+	"github.com/evanschultz/float-rw-client/pkg/cache"
+	"github.com/evanschultz/float-rw-client/pkg/models"
+)
+
+// Format selects how books are written to disk
+type Format string
+
+const (
+	FormatMarkdown Format = "markdown"
+	FormatJSON     Format = "json"
+)
+
+const (
+	stateDir  = ".float-rw"
+	stateFile = "export-state.json"
+	booksDir  = "books"
+)
+
+// State is the stored cursor that makes exports resumable and incremental
+type State struct {
+	UpdatedAfter string    `json:"updated_after,omitempty"` // lower bound of the run in progress
+	PageCursor   *int      `json:"page_cursor,omitempty"`   // next page of the run in progress
+	RunStarted   time.Time `json:"run_started,omitempty"`   // becomes UpdatedAfter of the next run
+	LastComplete time.Time `json:"last_complete,omitempty"` // when the last run finished
+	Full         bool      `json:"full,omitempty"`          // a run without a lower bound finished, so books/ holds the whole library
+}
+
+// Options configures an export run
+type Options struct {
+	OutDir string
+	Format Format
+	Since  string // YYYY-MM-DD or RFC3339; empty resumes from stored state
+	Fresh  bool   // ignore stored state for this run
+
+	// Progress is called after each page with books written so far and
+	// the pages the run is expected to take
+	Progress func(page, pages, books, highlights int)
+}
+
+// Result summarizes a finished export
+type Result struct {
+	Pages      int
+	Books      int
+	Highlights int
+}
+
+// Exporter dumps a Readwise account to per-book files
+type Exporter struct {
+	client *api.Client
+	opts   Options
+}
+
+// New creates an exporter
+func New(client *api.Client, opts Options) *Exporter {
+	if opts.Format == "" {
+		opts.Format = FormatMarkdown
+	}
+	return &Exporter{client: client, opts: opts}
+}
+
+// Run pages through /export/, merging each book into the output directory
+// and persisting the cursor after every page so an interrupted or canceled
+// run resumes
+func (e *Exporter) Run(ctx context.Context) (*Result, error) {
+	if err := os.MkdirAll(filepath.Join(e.opts.OutDir, stateDir, booksDir), 0755); err != nil {
+		return nil, fmt.Errorf("create export dir: %w", err)
+	}
+
+	state, err := e.loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	if e.opts.Fresh {
+		state = &State{}
+	}
+
+	if e.opts.Since != "" {
+		since, err := parseSince(e.opts.Since)
+		if err != nil {
+			return nil, err
+		}
+		// A new lower bound starts a new run
+		if state.UpdatedAfter != since {
+			state.UpdatedAfter = since
+			state.PageCursor = nil
+			state.RunStarted = time.Time{}
+		}
+	}
+
+	if state.RunStarted.IsZero() {
+		state.RunStarted = time.Now().UTC()
+	}
+
+	unbounded := state.UpdatedAfter == ""
+	result := &Result{}
+	perPage := 0
+	for {
+		params := url.Values{}
+		if state.UpdatedAfter != "" {
+			params.Set("updatedAfter", state.UpdatedAfter)
+		}
+		if state.PageCursor != nil {
+			params.Set("pageCursor", strconv.Itoa(*state.PageCursor))
+		}
+
+		page, err := e.client.Export(ctx, params)
+		if err != nil {
+			return result, fmt.Errorf("export page %d: %w", result.Pages+1, err)
+		}
+
+		for _, book := range page.Results {
+			if err := e.writeBook(book); err != nil {
+				return result, err
+			}
+			result.Books++
+			result.Highlights += len(book.Highlights)
+		}
+		result.Pages++
+
+		state.PageCursor = page.NextPageCursor
+		if err := e.saveState(state); err != nil {
+			return result, err
+		}
+
+		if e.opts.Progress != nil {
+			// Pages before the last are full, so the largest gives the
+			// page size; count is the books the whole run covers
+			perPage = max(perPage, len(page.Results))
+			pages := result.Pages
+			if page.NextPageCursor != nil {
+				pages = max(pages+1, (page.Count+perPage-1)/max(1, perPage))
+			}
+			e.opts.Progress(result.Pages, pages, result.Books, result.Highlights)
+		}
+
+		if page.NextPageCursor == nil {
+			break
+		}
+	}
+
+	// Next run only needs changes since this one started
+	state.UpdatedAfter = state.RunStarted.Format(time.RFC3339)
+	state.RunStarted = time.Time{}
+	state.PageCursor = nil
+	state.LastComplete = time.Now().UTC()
+	state.Full = state.Full || unbounded
+	if err := e.saveState(state); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// writeBook merges the exported highlights into the stored record for the
+// book and rewrites its output file, removing the old one if the book was
+// renamed
+func (e *Exporter) writeBook(book models.ExportBook) error {
+	stored, found, err := loadRecord(e.recordPath(book.UserBookID))
+	if err != nil {
+		return err
+	}
+	merged := book
+	if found {
+		merged = mergeBook(stored, book)
+	}
+
+	if err := writeJSON(e.recordPath(book.UserBookID), merged); err != nil {
+		return err
+	}
+
+	var ext string
+	switch e.opts.Format {
+	case FormatJSON:
+		ext = "json"
+		if err := writeJSON(filepath.Join(e.opts.OutDir, bookFileName(merged, ext)), merged); err != nil {
+			return err
+		}
+	case FormatMarkdown:
+		ext = "md"
+		path := filepath.Join(e.opts.OutDir, bookFileName(merged, ext))
+		if err := os.WriteFile(path, []byte(RenderMarkdown(merged)), 0644); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unknown export format: %s", e.opts.Format)
+	}
+
+	if old := bookFileName(stored, ext); found && old != bookFileName(merged, ext) {
+		path := filepath.Join(e.opts.OutDir, old)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// recordPath is where the merged JSON for a book lives between runs
+func (e *Exporter) recordPath(userBookID int) string {
+	return filepath.Join(e.opts.OutDir, stateDir, booksDir, fmt.Sprintf("%d.json", userBookID))
+}
+
+func (e *Exporter) statePath() string {
+	return filepath.Join(e.opts.OutDir, stateDir, stateFile)
+}
+
+func (e *Exporter) loadState() (*State, error) {
+	data, err := os.ReadFile(e.statePath())
+	if os.IsNotExist(err) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read export state: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parse export state: %w", err)
+	}
+	return &state, nil
+}
+
+func (e *Exporter) saveState(state *State) error {
+	return writeJSON(e.statePath(), state)
+}
+
+// LoadBooks reads every merged book record from an export directory, for
+// seeding caches or other tools
+func LoadBooks(outDir string) ([]models.ExportBook, error) {
+	paths, err := filepath.Glob(filepath.Join(outDir, stateDir, booksDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	books := make([]models.ExportBook, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		var book models.ExportBook
+		if err := json.Unmarshal(data, &book); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		books = append(books, book)
+	}
+	return books, nil
+}
+
+// SeedCache merges every book exported to outDir into the highlights
+// cache c and returns how many there were. When the export holds the whole
+// library and is newer than the cache, the cache takes over its sync
+// point, so views only fetch what changed after it.
+func SeedCache(outDir string, c *cache.Highlights) (int, error) {
+	state, err := (&Exporter{opts: Options{OutDir: outDir}}).loadState()
+	if err != nil {
+		return 0, err
+	}
+	books, err := LoadBooks(outDir)
+	if err != nil {
+		return 0, err
+	}
+	c.Merge(books)
+
+	// Between runs UpdatedAfter is when the last one started
+	if state.Full && state.RunStarted.IsZero() && state.UpdatedAfter > c.UpdatedAfter() {
+		started, err := time.Parse(time.RFC3339, state.UpdatedAfter)
+		if err != nil {
+			return 0, fmt.Errorf("parse export state: %w", err)
+		}
+		c.Complete(started, state.LastComplete)
+	}
+	return len(books), nil
+}
+
+// loadRecord reads the merged record stored for a book; found is false
+// when the book hasn't been exported before
+func loadRecord(recordPath string) (book models.ExportBook, found bool, err error) {
+	data, err := os.ReadFile(recordPath)
+	if os.IsNotExist(err) {
+		return book, false, nil
+	}
+	if err != nil {
+		return book, false, fmt.Errorf("read %s: %w", recordPath, err)
+	}
+	if err := json.Unmarshal(data, &book); err != nil {
+		return book, false, fmt.Errorf("parse %s: %w", recordPath, err)
+	}
+	return book, true, nil
+}
+
+// mergeBook combines a stored book record with freshly exported data;
+// highlights are keyed by ID so incremental pages update in place
+func mergeBook(stored, book models.ExportBook) models.ExportBook {
+	byID := make(map[int]models.ExportHighlight)
+	for _, h := range stored.Highlights {
+		byID[h.ID] = h
+	}
+	for _, h := range book.Highlights {
+		byID[h.ID] = h
+	}
+
+	merged := book
+	merged.Highlights = make([]models.ExportHighlight, 0, len(byID))
+	for _, h := range byID {
+		merged.Highlights = append(merged.Highlights, h)
+	}
+	sort.Slice(merged.Highlights, func(i, j int) bool {
+		if merged.Highlights[i].Location != merged.Highlights[j].Location {
+			return merged.Highlights[i].Location < merged.Highlights[j].Location
+		}
+		return merged.Highlights[i].ID < merged.Highlights[j].ID
+	})
+
+	return merged
+}
+
+// RenderMarkdown renders a book and its highlights in the outliner's
+// highlight:: format so exports can be opened in float-outliner directly
+func RenderMarkdown(book models.ExportBook) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("# %s\n\n", book.Title))
+	if book.Author != "" {
+		b.WriteString(fmt.Sprintf("• author:: %s\n", book.Author))
+	}
+	if book.Category != "" {
+		b.WriteString(fmt.Sprintf("• category:: %s\n", book.Category))
+	}
+	if book.SourceURL != "" {
+		b.WriteString(fmt.Sprintf("• source:: %s\n", book.SourceURL))
+	}
+	if len(book.BookTags) > 0 {
+		b.WriteString(fmt.Sprintf("• tags:: %s\n", tagNames(book.BookTags)))
+	}
+	if book.DocumentNote != "" {
+		b.WriteString(fmt.Sprintf("• note:: %s\n", oneLine(book.DocumentNote)))
+	}
+	b.WriteString("\n")
+
+	for _, h := range book.Highlights {
+		if h.IsDiscard {
+			continue
+		}
+		source := h.URL
+		if source == "" {
+			source = h.ReadwiseURL
+		}
+		if source != "" {
+			b.WriteString(fmt.Sprintf("• highlight:: %s [id:: %d] [source:: %s]\n", oneLine(h.Text), h.ID, source))
+		} else {
+			b.WriteString(fmt.Sprintf("• highlight:: %s [id:: %d]\n", oneLine(h.Text), h.ID))
+		}
+		if h.Note != "" {
+			for _, line := range strings.Split(h.Note, "\n") {
+				if strings.TrimSpace(line) != "" {
+					b.WriteString(fmt.Sprintf("  • note:: %s\n", strings.TrimSpace(line)))
+				}
+			}
+		}
+		if len(h.Tags) > 0 {
+			b.WriteString(fmt.Sprintf("  • tags:: %s\n", tagNames(h.Tags)))
+		}
+		if h.HighlightedAt != nil {
+			b.WriteString(fmt.Sprintf("  • highlighted:: %s\n", h.HighlightedAt.Format("2006-01-02")))
+		}
+	}
+
+	return b.String()
+}
+
+var slugUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// bookFileName builds a stable, filesystem-safe name for a book
+func bookFileName(book models.ExportBook, ext string) string {
+	slug := strings.Trim(slugUnsafe.ReplaceAllString(strings.ToLower(book.Title), "-"), "-")
+	if len(slug) > 60 {
+		slug = strings.TrimRight(slug[:60], "-")
+	}
+	if slug == "" {
+		slug = "untitled"
+	}
+	return fmt.Sprintf("%d-%s.%s", book.UserBookID, slug, ext)
+}
+
+// parseSince accepts a date or full timestamp and returns RFC3339
+func parseSince(since string) (string, error) {
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	t, err := time.Parse("2006-01-02", since)
+	if err != nil {
+		return "", fmt.Errorf("invalid --since %q: use YYYY-MM-DD or RFC3339", since)
+	}
+	return t.UTC().Format(time.RFC3339), nil
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
+func tagNames(tags []models.Tag) string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,210 @@
+package export
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/api/apitest"
+	"github.com/evanschultz/float-rw-client/pkg/cache"
+	"github.com/evanschultz/float-rw-client/pkg/models"
+)
+
+func TestMergeBook(t *testing.T) {
+	stored := models.ExportBook{UserBookID: 1, Title: "Old", Highlights: []models.ExportHighlight{
+		{ID: 1, Text: "kept", Location: 10},
+		{ID: 2, Text: "before", Location: 5},
+	}}
+	fresh := models.ExportBook{UserBookID: 1, Title: "New", Highlights: []models.ExportHighlight{
+		{ID: 2, Text: "after", Location: 5},
+		{ID: 3, Text: "added", Location: 20},
+		{ID: 0, Text: "same place, lower ID", Location: 5},
+	}}
+
+	merged := mergeBook(stored, fresh)
+	if merged.Title != "New" {
+		t.Errorf("title = %q, want the fresh one", merged.Title)
+	}
+	var got []string
+	for _, h := range merged.Highlights {
+		got = append(got, h.Text)
+	}
+	if want := []string{"same place, lower ID", "after", "kept", "added"}; !slices.Equal(got, want) {
+		t.Errorf("highlights = %q, want %q", got, want)
+	}
+}
+
+// readState loads the export state an earlier run left in dir
+func readState(t *testing.T, dir string) *State {
+	t.Helper()
+	state, err := (&Exporter{opts: Options{OutDir: dir}}).loadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func TestRunResumesAfterFailure(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.SetExportPageSize(1)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	// The second of three pages fails
+	failing := New(srv.APIClient(), Options{OutDir: dir, Progress: func(page, pages, books, highlights int) {
+		if page == 1 {
+			srv.Fail(apitest.Failure{Status: http.StatusInternalServerError}, 1)
+		}
+	}})
+	result, err := failing.Run(ctx)
+	if err == nil || result.Pages != 1 || result.Books != 1 {
+		t.Fatalf("interrupted run = %+v, %v", result, err)
+	}
+	state := readState(t, dir)
+	if state.PageCursor == nil || *state.PageCursor != 1 || state.RunStarted.IsZero() || !state.LastComplete.IsZero() {
+		t.Fatalf("state after the failure = %+v", state)
+	}
+	started := state.RunStarted
+
+	// The next run picks up at the failed page and finishes the first
+	// run's window
+	result, err = New(srv.APIClient(), Options{OutDir: dir}).Run(ctx)
+	if err != nil || result.Pages != 2 || result.Books != 2 || result.Highlights != 3 {
+		t.Fatalf("resumed run = %+v, %v", result, err)
+	}
+	var cursors []string
+	for _, q := range srv.ExportQueries() {
+		cursors = append(cursors, q.Get("pageCursor"))
+	}
+	if want := []string{"", "1", "2"}; !slices.Equal(cursors, want) {
+		t.Errorf("page cursors requested = %q, want %q", cursors, want)
+	}
+	state = readState(t, dir)
+	if state.PageCursor != nil || !state.RunStarted.IsZero() || state.UpdatedAfter != started.Format(time.RFC3339) || state.LastComplete.IsZero() {
+		t.Errorf("state after finishing = %+v, want updated_after %s", state, started.Format(time.RFC3339))
+	}
+	books, err := LoadBooks(dir)
+	if err != nil || len(books) != 3 {
+		t.Fatalf("LoadBooks = %d books, %v", len(books), err)
+	}
+
+	// Later runs only ask for what changed, and merge it into the book
+	if _, err := srv.APIClient().UpdateHighlight(ctx, 102, models.HighlightUpdate{Note: "revisited"}); err != nil {
+		t.Fatal(err)
+	}
+	result, err = New(srv.APIClient(), Options{OutDir: dir}).Run(ctx)
+	if err != nil || result.Books != 1 || result.Highlights != 1 {
+		t.Fatalf("incremental run = %+v, %v", result, err)
+	}
+	queries := srv.ExportQueries()
+	if after := queries[len(queries)-1].Get("updatedAfter"); after != started.Format(time.RFC3339) {
+		t.Errorf("incremental run asked for updatedAfter=%q", after)
+	}
+	stored, _, err := loadRecord(filepath.Join(dir, stateDir, booksDir, "1.json"))
+	if err != nil || len(stored.Highlights) != 3 || stored.Highlights[1].Note != "revisited" {
+		t.Errorf("book 1 after the incremental run = %+v, %v", stored.Highlights, err)
+	}
+
+	// A new --since starts a new run rather than resuming the old cursor
+	result, err = New(srv.APIClient(), Options{OutDir: dir, Since: "2020-01-01"}).Run(ctx)
+	if err != nil || result.Books != 3 {
+		t.Fatalf("run since 2020 = %+v, %v", result, err)
+	}
+	queries = srv.ExportQueries()
+	if q := queries[len(queries)-3]; q.Get("updatedAfter") != "2020-01-01T00:00:00Z" || q.Get("pageCursor") != "" {
+		t.Errorf("run since 2020 started with %v", q)
+	}
+}
+
+func TestRenamedBook(t *testing.T) {
+	for _, format := range []Format{FormatMarkdown, FormatJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			srv := apitest.NewServer()
+			defer srv.Close()
+			highlights := []models.Highlight{{ID: 1, BookID: 7, Text: "a line worth keeping"}}
+			dir := t.TempDir()
+			ext := map[Format]string{FormatMarkdown: ".md", FormatJSON: ".json"}[format]
+
+			for _, title := range []string{"Working Title", "The Final Title"} {
+				srv.SetLibrary([]models.Book{{ID: 7, Title: title}}, highlights)
+				if _, err := New(srv.APIClient(), Options{OutDir: dir, Format: format, Fresh: true}).Run(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			matches, err := filepath.Glob(filepath.Join(dir, "*"+ext))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(matches) != 1 || filepath.Base(matches[0]) != "7-the-final-title"+ext {
+				t.Errorf("files after the rename = %q, want only 7-the-final-title%s", matches, ext)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "7-working-title"+ext)); !os.IsNotExist(err) {
+				t.Errorf("the old file is still there: %v", err)
+			}
+		})
+	}
+}
+
+func TestBookFileName(t *testing.T) {
+	for title, want := range map[string]string{
+		"Shacks Not Cathedrals": "3-shacks-not-cathedrals.md",
+		"  ¿Qué?  ":             "3-qu.md",
+		"":                      "3-untitled.md",
+		"!!!":                   "3-untitled.md",
+		"A very long title that goes on and on and on well past the sixty character limit": "3-a-very-long-title-that-goes-on-and-on-and-on-well-past-the-s.md",
+	} {
+		if got := bookFileName(models.ExportBook{UserBookID: 3, Title: title}, "md"); got != want {
+			t.Errorf("bookFileName(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
+
+func TestSeedCache(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	// A run with a lower bound doesn't hold the whole library
+	if _, err := New(srv.APIClient(), Options{OutDir: dir, Since: "2020-01-01"}).Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	c := cache.NewHighlights()
+	if n, err := SeedCache(dir, c); err != nil || n != 3 {
+		t.Fatalf("SeedCache = %d, %v", n, err)
+	}
+	if c.UpdatedAfter() != "" {
+		t.Errorf("a partial export set the cache's sync point to %s", c.UpdatedAfter())
+	}
+
+	// A full one does, and the cache picks up from where it started
+	if _, err := New(srv.APIClient(), Options{OutDir: dir, Fresh: true}).Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	c = cache.NewHighlights()
+	if _, err := SeedCache(dir, c); err != nil {
+		t.Fatal(err)
+	}
+	state := readState(t, dir)
+	if c.UpdatedAfter() != state.UpdatedAfter || !c.Fresh(state.LastComplete, time.Minute) {
+		t.Errorf("cache sync point = %q, want %q", c.UpdatedAfter(), state.UpdatedAfter)
+	}
+	count := 0
+	c.Each(func(book models.ExportBook, highlight models.ExportHighlight) { count++ })
+	if count != 6 {
+		t.Errorf("seeded cache holds %d highlights, want all 6", count)
+	}
+
+	// A cache synced since keeps its own sync point
+	c.Complete(state.LastComplete.Add(time.Hour), state.LastComplete.Add(time.Hour))
+	newer := c.UpdatedAfter()
+	if _, err := SeedCache(dir, c); err != nil || c.UpdatedAfter() != newer {
+		t.Errorf("seeding moved the sync point back to %q, %v", c.UpdatedAfter(), err)
+	}
+}
@@ -0,0 +1,175 @@
+package gitrepo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotRepo is returned when a path isn't inside a git work tree
+var ErrNotRepo = errors.New("not a git repository")
+
+// Repo is a git work tree, driven through the git binary
+type Repo struct {
+	Root string
+}
+
+// Commit is one entry in a file's history
+type Commit struct {
+	Hash    string
+	Time    time.Time
+	Subject string
+}
+
+// Status is the work tree state shown in the status bar
+type Status struct {
+	Branch string
+	Dirty  bool
+}
+
+// Find returns the repository containing path
+func Find(path string) (*Repo, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, ErrNotRepo
+	}
+
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		dir = filepath.Dir(dir) // a file, possibly not saved yet
+	}
+
+	out, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, ErrNotRepo
+	}
+	return &Repo{Root: strings.TrimSpace(out)}, nil
+}
+
+// Status returns the current branch and whether the tree has changes
+func (r *Repo) Status() (Status, error) {
+	out, err := run(r.Root, "status", "--porcelain=v1", "--branch")
+	if err != nil {
+		return Status{}, err
+	}
+
+	var status Status
+	for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		if branch, ok := strings.CutPrefix(line, "## "); ok {
+			// "main...origin/main [ahead 1]" or "No commits yet on main"
+			branch, _, _ = strings.Cut(branch, "...")
+			branch, _, _ = strings.Cut(branch, " [")
+			branch = strings.TrimPrefix(branch, "No commits yet on ")
+			status.Branch = branch
+		} else if line != "" {
+			status.Dirty = true
+		}
+	}
+	return status, nil
+}
+
+// CommitFile stages and commits a single file. It is a no-op when the file
+// has no changes.
+func (r *Repo) CommitFile(path, message string) (bool, error) {
+	rel, err := r.rel(path)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := run(r.Root, "add", "--", rel); err != nil {
+		return false, err
+	}
+	if _, err := run(r.Root, "diff", "--cached", "--quiet", "--", rel); err == nil {
+		return false, nil
+	}
+	if _, err := run(r.Root, "commit", "--quiet", "-m", message, "--", rel); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Log returns up to limit commits touching path, newest first
+func (r *Repo) Log(path string, limit int) ([]Commit, error) {
+	rel, err := r.rel(path)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := run(r.Root, "log", "-n", strconv.Itoa(limit), "--format=%H%x00%ct%x00%s", "--", rel)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		secs, _ := strconv.ParseInt(fields[1], 10, 64)
+		commits = append(commits, Commit{Hash: fields[0], Time: time.Unix(secs, 0), Subject: fields[2]})
+	}
+	return commits, nil
+}
+
+// Show returns path's contents at a commit; a missing file is empty
+func (r *Repo) Show(hash, path string) (string, error) {
+	rel, err := r.rel(path)
+	if err != nil {
+		return "", err
+	}
+	out, err := run(r.Root, "show", hash+":"+filepath.ToSlash(rel))
+	if err != nil {
+		return "", nil
+	}
+	return out, nil
+}
+
+// Diff returns the patch a commit made to path
+func (r *Repo) Diff(hash, path string) (string, error) {
+	rel, err := r.rel(path)
+	if err != nil {
+		return "", err
+	}
+	return run(r.Root, "show", "--format=", "--no-color", hash, "--", rel)
+}
+
+func (r *Repo) rel(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	// Compare against the resolved root; git reports symlink-free paths
+	if resolved, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		abs = filepath.Join(resolved, filepath.Base(abs))
+	}
+	rel, err := filepath.Rel(r.Root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is outside %s", path, r.Root)
+	}
+	return rel, nil
+}
+
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return "", fmt.Errorf("git %s: %w", args[0], err)
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,131 @@
+package gitrepo
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newRepo initializes a repository on main in a temp dir, with git's
+// global and system config out of the way
+func newRepo(t *testing.T) *Repo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "float")
+	t.Setenv("GIT_AUTHOR_EMAIL", "float@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "float")
+	t.Setenv("GIT_COMMITTER_EMAIL", "float@example.com")
+
+	dir := t.TempDir()
+	if _, err := run(dir, "init", "--quiet", "-b", "main"); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := Find(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// git runs a command the test needs in the repo
+func git(t *testing.T, repo *Repo, args ...string) string {
+	t.Helper()
+	out, err := run(repo.Root, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestFind(t *testing.T) {
+	repo := newRepo(t)
+	sub := filepath.Join(repo.Root, "notes")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// From a subdirectory, or a file not saved yet
+	for _, path := range []string{sub, filepath.Join(sub, "new.md")} {
+		if found, err := Find(path); err != nil || found.Root != repo.Root {
+			t.Errorf("Find(%s) = %+v, %v", path, found, err)
+		}
+	}
+	if _, err := Find(t.TempDir()); err != ErrNotRepo {
+		t.Errorf("Find outside a repo: %v", err)
+	}
+	if _, err := repo.CommitFile(filepath.Join(t.TempDir(), "x.md"), "x"); err == nil {
+		t.Error("committed a file outside the repo")
+	}
+}
+
+func TestCommitFile(t *testing.T) {
+	repo := newRepo(t)
+	notes := filepath.Join(repo.Root, "notes.md")
+	other := filepath.Join(repo.Root, "other.md")
+
+	status, err := repo.Status()
+	if err != nil || status.Branch != "main" || status.Dirty {
+		t.Fatalf("status of a new repo = %+v, %v", status, err)
+	}
+
+	// The first commit of an untracked file
+	write(t, notes, "• one\n")
+	if committed, err := repo.CommitFile(notes, "first"); err != nil || !committed {
+		t.Fatalf("CommitFile = %v, %v", committed, err)
+	}
+
+	// Only the file asked for is committed; other staged and unstaged
+	// changes stay as they were
+	write(t, notes, "• one\n• two\n")
+	write(t, other, "staged\n")
+	git(t, repo, "add", "other.md")
+	write(t, filepath.Join(repo.Root, "untracked.md"), "loose\n")
+	if committed, err := repo.CommitFile(notes, "second"); err != nil || !committed {
+		t.Fatalf("CommitFile = %v, %v", committed, err)
+	}
+	if files := git(t, repo, "show", "--name-only", "--format=", "HEAD"); files != "notes.md\n" {
+		t.Errorf("the commit touched %q, want only notes.md", files)
+	}
+	if st := git(t, repo, "status", "--porcelain=v1"); st != "A  other.md\n?? untracked.md\n" {
+		t.Errorf("status after the commit:\n%s", st)
+	}
+	if status, _ := repo.Status(); !status.Dirty {
+		t.Error("status isn't dirty with other.md staged")
+	}
+
+	// Nothing to commit is not an error
+	if committed, err := repo.CommitFile(notes, "again"); err != nil || committed {
+		t.Errorf("CommitFile without changes = %v, %v", committed, err)
+	}
+
+	commits, err := repo.Log(notes, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 2 || commits[0].Subject != "second" || commits[1].Subject != "first" {
+		t.Fatalf("log = %+v", commits)
+	}
+	if content, err := repo.Show(commits[1].Hash, notes); err != nil || content != "• one\n" {
+		t.Errorf("Show(first) = %q, %v", content, err)
+	}
+	if content, _ := repo.Show(commits[1].Hash, other); content != "" {
+		t.Errorf("Show of a file missing at the commit = %q", content)
+	}
+	diff, err := repo.Diff(commits[0].Hash, notes)
+	if err != nil || !strings.Contains(diff, "+• two") || strings.Contains(diff, "other.md") {
+		t.Errorf("Diff(second) = %q, %v", diff, err)
+	}
+}
@@ -0,0 +1,210 @@
+// Package i18n holds the TUIs' user-facing strings in message catalogs,
+// one TOML file per locale. English ships with the binary; translations
+// are read from the config directory's locales/, falling back to English
+// for anything they leave out.
+package i18n
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// BaseLocale is the locale every message is written in first
+const BaseLocale = "en"
+
+// builtins are the catalogs shipped with the binary
+//
+//go:embed locales/*.toml
+var builtins embed.FS
+
+// Catalog is one locale's messages by dotted key, e.g. "status.untitled"
+type Catalog map[string]string
+
+var (
+	base   = mustBuiltin(BaseLocale)
+	chain  = []Catalog{base} // the catalogs T looks in, most specific first
+	locale = BaseLocale
+)
+
+// Parse reads a catalog: nested tables give the keys their dots
+func Parse(data []byte) (Catalog, error) {
+	var tree map[string]any
+	if err := toml.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	c := Catalog{}
+	if err := c.flatten("", tree); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c Catalog) flatten(prefix string, tree map[string]any) error {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch value := value.(type) {
+		case string:
+			c[key] = value
+		case map[string]any:
+			if err := c.flatten(key, value); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s: messages are strings, not %T", key, value)
+		}
+	}
+	return nil
+}
+
+// mustBuiltin is a catalog shipped with the binary, which must parse
+func mustBuiltin(name string) Catalog {
+	data, err := builtins.ReadFile("locales/" + name + ".toml")
+	if err != nil {
+		panic(err)
+	}
+	c, err := Parse(data)
+	if err != nil {
+		panic(fmt.Sprintf("locales/%s.toml: %v", name, err))
+	}
+	return c
+}
+
+// Detect is the locale a setting names: auto (or empty) takes it from
+// LC_ALL, LC_MESSAGES or LANG, e.g. "de_DE.UTF-8" becomes "de_DE"
+func Detect(setting string) string {
+	setting = strings.TrimSpace(setting)
+	if setting != "" && !strings.EqualFold(setting, "auto") {
+		return normalize(setting)
+	}
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return normalize(value)
+		}
+	}
+	return BaseLocale
+}
+
+// normalize trims a locale's encoding and modifier and writes it as
+// language_REGION; C and POSIX are English
+func normalize(value string) string {
+	value, _, _ = strings.Cut(value, ".")
+	value, _, _ = strings.Cut(value, "@")
+	value = strings.ReplaceAll(value, "-", "_")
+	if value == "" || value == "C" || value == "POSIX" {
+		return BaseLocale
+	}
+	lang, region, ok := strings.Cut(value, "_")
+	if !ok {
+		return strings.ToLower(lang)
+	}
+	return strings.ToLower(lang) + "_" + strings.ToUpper(region)
+}
+
+// ErrNoCatalog is returned by Use for a locale with no messages at all
+var ErrNoCatalog = errors.New("no messages for locale")
+
+// Setup makes T speak the locale setting names. A detected locale with no
+// catalog quietly stays in English; one set by name must have a catalog.
+func Setup(setting, dir string) error {
+	err := Use(Detect(setting), dir)
+	setting = strings.ToLower(strings.TrimSpace(setting))
+	if errors.Is(err, ErrNoCatalog) && (setting == "" || setting == "auto") {
+		return nil
+	}
+	return err
+}
+
+// Use makes T speak name: its catalog, then its language's (de for de_AT),
+// then English. Catalogs are looked for in dir, then among the built-ins.
+// Without any, T stays in English and ErrNoCatalog is returned.
+func Use(name, dir string) error {
+	name = normalize(name)
+	lang, _, _ := strings.Cut(name, "_")
+	candidates := []string{name}
+	if lang != name {
+		candidates = append(candidates, lang)
+	}
+
+	var found []Catalog
+	for _, candidate := range candidates {
+		if candidate == BaseLocale {
+			continue
+		}
+		c, err := load(candidate, dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		found = append(found, c)
+	}
+	if len(found) == 0 && lang != BaseLocale {
+		chain, locale = []Catalog{base}, BaseLocale
+		return fmt.Errorf("%w %s", ErrNoCatalog, name)
+	}
+	chain, locale = append(found, base), name
+	return nil
+}
+
+// load reads a locale's catalog from dir, else a built-in one
+func load(name, dir string) (Catalog, error) {
+	if dir != "" {
+		path := filepath.Join(dir, name+".toml")
+		data, err := os.ReadFile(path)
+		if err == nil {
+			c, err := Parse(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			return c, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	data, err := builtins.ReadFile("locales/" + name + ".toml")
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Locale is the locale Use set
+func Locale() string {
+	return locale
+}
+
+// T is the message for key, formatted with args like fmt.Sprintf. A key
+// no catalog has comes back as it is, so a missing message shows up in
+// the UI rather than as a blank.
+func T(key string, args ...any) string {
+	for _, c := range chain {
+		if msg, ok := c[key]; ok {
+			if len(args) == 0 {
+				return msg
+			}
+			return fmt.Sprintf(msg, args...)
+		}
+	}
+	return key
+}
+
+// N is the message for n of something: key.one when n is 1, key.other
+// otherwise, formatted with n and then args
+func N(key string, n int, args ...any) string {
+	form := ".other"
+	if n == 1 {
+		form = ".one"
+	}
+	return T(key+form, append([]any{n}, args...)...)
+}
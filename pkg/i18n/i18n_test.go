@@ -0,0 +1,77 @@
+package i18n
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestBaseHasEveryKey checks each message the code asks for against the
+// English catalog, so a typo'd key can't reach the UI
+func TestBaseHasEveryKey(t *testing.T) {
+	call := regexp.MustCompile(`i18n\.([TN])\("([^"]+)"`)
+	for _, dir := range []string{"../../cmd", "../../pkg"} {
+		err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") {
+				return err
+			}
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			for _, m := range call.FindAllStringSubmatch(string(src), -1) {
+				keys := []string{m[2]}
+				if m[1] == "N" {
+					keys = []string{m[2] + ".one", m[2] + ".other"}
+				}
+				for _, key := range keys {
+					if _, ok := base[key]; !ok {
+						t.Errorf("%s: no message %q in en.toml", path, key)
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTranslationFallsBack(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "de.toml"), []byte("[rw.toast]\nhighlight_saved = \"Markierung gespeichert\"\n"), 0o644)
+	t.Cleanup(func() { Use(BaseLocale, "") })
+
+	if err := Use("de_AT.UTF-8", dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := T("rw.toast.highlight_saved"); got != "Markierung gespeichert" {
+		t.Errorf("de_AT didn't fall back to de: %q", got)
+	}
+	if got := T("rw.toast.archived", "Dune"); got != "Archived Dune" {
+		t.Errorf("an untranslated message isn't English: %q", got)
+	}
+	if got := N("rw.toast.sent", 1); got != "Sent 1 highlight to evna" {
+		t.Errorf("N(1) = %q", got)
+	}
+	if Locale() != "de_AT" {
+		t.Errorf("Locale() = %q", Locale())
+	}
+
+	if err := Use("xx", dir); !errors.Is(err, ErrNoCatalog) || T("rw.toast.highlight_saved") != "Highlight saved" {
+		t.Errorf("an unknown locale kept the last one: %v", err)
+	}
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "xx_YY.UTF-8")
+	if err := Setup("auto", dir); err != nil {
+		t.Errorf("a detected locale without a catalog failed: %v", err)
+	}
+	if err := Setup("xx", dir); err == nil {
+		t.Error("a named locale without a catalog was taken")
+	}
+}
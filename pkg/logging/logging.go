@@ -0,0 +1,163 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/cache"
+)
+
+// maxFeed is how many records a Feed holds before dropping the oldest
+const maxFeed = 200
+
+// DefaultPath is where app logs unless told otherwise, e.g.
+// ~/.cache/float-line/float-outliner.log
+func DefaultPath(app string) string {
+	return filepath.Join(cache.Dir(), app+".log")
+}
+
+// ParseLevel reads a level name: debug, info, warn or error; "" is info
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q: use debug, info, warn or error", s)
+}
+
+// Setup makes the default slog logger append text records at levelName
+// and above to the file at path, app's DefaultPath when path is "".
+// Records at info and above also go to feed when it isn't nil. The
+// returned file is closed on exit.
+func Setup(app, path, levelName string, feed *Feed) (io.Closer, error) {
+	level, err := ParseLevel(levelName)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		path = DefaultPath(app)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create log dir: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("open log: %w", err)
+	}
+
+	var h slog.Handler = slog.NewTextHandler(file, &slog.HandlerOptions{Level: level})
+	if feed != nil {
+		feed.level = max(level, slog.LevelInfo)
+		h = &feedHandler{next: h, feed: feed}
+	}
+	slog.SetDefault(slog.New(h))
+	return file, nil
+}
+
+// Discard makes the default logger drop every record, so a log that can't
+// be opened doesn't fall back to printing over the screen
+func Discard() {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// Record is a log record as a UI shows it
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   string // key=value pairs
+}
+
+// String renders the message and its attributes on one line
+func (r Record) String() string {
+	if r.Attrs == "" {
+		return r.Message
+	}
+	return r.Message + " " + r.Attrs
+}
+
+// Feed collects log records for a UI to show, e.g. float-outliner's debug
+// panel, so they're seen without printing over the screen. It's safe for
+// concurrent use.
+type Feed struct {
+	mu      sync.Mutex
+	level   slog.Level
+	records []Record
+}
+
+// NewFeed creates an empty feed
+func NewFeed() *Feed {
+	return &Feed{level: slog.LevelInfo}
+}
+
+// Drain returns the records collected since the last call, oldest first;
+// a nil feed has none
+func (f *Feed) Drain() []Record {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	records := f.records
+	f.records = nil
+	return records
+}
+
+func (f *Feed) add(r Record) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.records = append(f.records, r)
+	if len(f.records) > maxFeed {
+		f.records = f.records[len(f.records)-maxFeed:]
+	}
+}
+
+// feedHandler passes records to next and copies them to a feed. Groups
+// only apply to the file; the feed shows attributes by their own keys.
+type feedHandler struct {
+	next  slog.Handler
+	feed  *Feed
+	attrs []slog.Attr
+}
+
+func (h *feedHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *feedHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= h.feed.level {
+		var attrs []string
+		add := func(a slog.Attr) bool {
+			attrs = append(attrs, a.Key+"="+a.Value.String())
+			return true
+		}
+		for _, a := range h.attrs {
+			add(a)
+		}
+		r.Attrs(add)
+		h.feed.add(Record{Time: r.Time, Level: r.Level, Message: r.Message, Attrs: strings.Join(attrs, " ")})
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *feedHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &feedHandler{next: h.next.WithAttrs(attrs), feed: h.feed, attrs: append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...)}
+}
+
+func (h *feedHandler) WithGroup(name string) slog.Handler {
+	return &feedHandler{next: h.next.WithGroup(name), feed: h.feed, attrs: h.attrs}
+}
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetupLevels(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	path := filepath.Join(t.TempDir(), "logs", "test.log")
+	feed := NewFeed()
+	closer, err := Setup("test", path, "warn", feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+
+	slog.Info("hidden")
+	slog.With("file", "a.md").Warn("read failed", "err", "denied")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hidden") || !strings.Contains(string(data), "msg=\"read failed\"") {
+		t.Errorf("log file = %q", data)
+	}
+
+	records := feed.Drain()
+	if len(records) != 1 || records[0].String() != "read failed file=a.md err=denied" {
+		t.Fatalf("feed = %+v", records)
+	}
+	if len(feed.Drain()) != 0 {
+		t.Error("Drain kept records")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for name, want := range map[string]slog.Level{"": slog.LevelInfo, "DEBUG": slog.LevelDebug, "warning": slog.LevelWarn} {
+		if got, err := ParseLevel(name); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v", name, got, err)
+		}
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("ParseLevel accepted an unknown level")
+	}
+}
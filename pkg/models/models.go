@@ -55,10 +55,81 @@ type BookList struct {
 	Results  []Book `json:"results"`
 }
 
+// HighlightCreate is a highlight for POST /highlights/. Readwise files it
+// under the book with its title and author, creating the book as needed,
+// and updates rather than duplicates a highlight it already has.
+type HighlightCreate struct {
+	Text          string     `json:"text"`
+	Title         string     `json:"title,omitempty"`
+	Author        string     `json:"author,omitempty"`
+	SourceType    string     `json:"source_type,omitempty"`
+	Category      string     `json:"category,omitempty"`
+	Note          string     `json:"note,omitempty"`
+	HighlightedAt *time.Time `json:"highlighted_at,omitempty"`
+}
+
+// CreatedBook is a book POST /highlights/ added highlights to
+type CreatedBook struct {
+	ID                 int    `json:"id"`
+	Title              string `json:"title"`
+	Author             string `json:"author"`
+	Category           string `json:"category"`
+	NumHighlights      int    `json:"num_highlights"`
+	ModifiedHighlights []int  `json:"modified_highlights"`
+}
+
+// ExportHighlight is a highlight as returned by the /export/ endpoint
+type ExportHighlight struct {
+	ID            int        `json:"id"`
+	Text          string     `json:"text"`
+	Note          string     `json:"note"`
+	Location      int        `json:"location"`
+	LocationType  string     `json:"location_type"`
+	HighlightedAt *time.Time `json:"highlighted_at"`
+	CreatedAt     *time.Time `json:"created_at"`
+	UpdatedAt     *time.Time `json:"updated_at"`
+	URL           string     `json:"url"`
+	Color         string     `json:"color"`
+	BookID        int        `json:"book_id"`
+	Tags          []Tag      `json:"tags"`
+	IsFavorite    bool       `json:"is_favorite"`
+	IsDiscard     bool       `json:"is_discard"`
+	ReadwiseURL   string     `json:"readwise_url"`
+}
+
+// ExportBook is a book with its highlights as returned by the /export/ endpoint
+type ExportBook struct {
+	UserBookID    int               `json:"user_book_id"`
+	Title         string            `json:"title"`
+	Author        string            `json:"author"`
+	ReadableTitle string            `json:"readable_title"`
+	Source        string            `json:"source"`
+	CoverImageURL string            `json:"cover_image_url"`
+	UniqueURL     string            `json:"unique_url"`
+	BookTags      []Tag             `json:"book_tags"`
+	Category      string            `json:"category"`
+	DocumentNote  string            `json:"document_note"`
+	ReadwiseURL   string            `json:"readwise_url"`
+	SourceURL     string            `json:"source_url"`
+	ASIN          string            `json:"asin"`
+	Highlights    []ExportHighlight `json:"highlights"`
+}
+
+// ExportList is one page of the /export/ endpoint
+type ExportList struct {
+	Count          int          `json:"count"`
+	NextPageCursor *int         `json:"nextPageCursor"`
+	Results        []ExportBook `json:"results"`
+}
+
+// HighlightUpdate is the PATCH body for a highlight. Boolean flags are
+// pointers so an explicit false can be sent without omitempty dropping it.
 type HighlightUpdate struct {
-	Text     string `json:"text,omitempty"`
-	Note     string `json:"note,omitempty"`
-	Location int    `json:"location,omitempty"`
-	URL      string `json:"url,omitempty"`
-	Color    string `json:"color,omitempty"`
+	Text       string `json:"text,omitempty"`
+	Note       string `json:"note,omitempty"`
+	Location   int    `json:"location,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Color      string `json:"color,omitempty"`
+	IsFavorite *bool  `json:"is_favorite,omitempty"`
+	IsDiscard  *bool  `json:"is_discard,omitempty"`
 }
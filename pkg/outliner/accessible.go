@@ -0,0 +1,121 @@
+package outliner
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+)
+
+// CaptureNoticeMsg says nodes were just captured, so an accessible app can
+// announce it with a bell or a terminal notification
+type CaptureNoticeMsg struct {
+	Types []string // the captured nodes' pattern types, in outline order
+}
+
+// Text is the announcement, e.g. "Captured 2 patterns: eureka, ctx"
+func (m CaptureNoticeMsg) Text() string {
+	return i18n.N("outliner.toast.captured", len(m.Types), strings.Join(m.Types, ", "))
+}
+
+// SetAccessible turns the screen reader mode on or off: rows trade tree
+// lines, bullets and marker glyphs for a spoken role ("level 2, eureka,
+// uncaptured"), panels lose their box drawing, and captures are announced
+// with a CaptureNoticeMsg
+func (o *Outliner) SetAccessible(on bool) {
+	o.accessible = on
+	border := lipgloss.RoundedBorder()
+	if on {
+		border = lipgloss.HiddenBorder()
+	}
+	o.focusedStyle = o.focusedStyle.BorderStyle(border)
+	o.unfocusedStyle = o.unfocusedStyle.BorderStyle(border)
+	o.debugPanel.focusedStyle = o.debugPanel.focusedStyle.BorderStyle(border)
+	o.debugPanel.unfocusedStyle = o.debugPanel.unfocusedStyle.BorderStyle(border)
+	o.ClearRenderCache()
+}
+
+// Accessible reports whether the screen reader mode is on
+func (o *Outliner) Accessible() bool {
+	return o.accessible
+}
+
+// roleMarker describes node i in words: its level, kind, pattern type,
+// capture state, fold state and worst issue
+func (o *Outliner) roleMarker(i int) string {
+	line := o.lines[i]
+	parts := []string{fmt.Sprintf("level %d", line.Level+1)}
+
+	switch {
+	case line.Kind == KindHeading:
+		parts = append(parts, "heading")
+	case line.Kind == KindCode:
+		parts = append(parts, "code")
+	case line.Kind == KindBlank:
+		parts = append(parts, "blank")
+	case isTableRow(line):
+		parts = append(parts, "table row")
+	}
+	if _, done, ok := parseTask(line.Text); ok && done {
+		parts = append(parts, "done task")
+	} else if ok {
+		parts = append(parts, "task")
+	}
+
+	if patternType := o.detectPatternType(line.Text); patternType != "" {
+		parts = append(parts, patternType)
+		switch {
+		case isPrivate(line.Text):
+			parts = append(parts, "private")
+		case o.archived[line.ID]:
+			parts = append(parts, "archived")
+		case line.Mirror != "":
+			parts = append(parts, "mirror")
+		case !line.Captured:
+			parts = append(parts, "uncaptured")
+		default:
+			parts = append(parts, "captured")
+		}
+	}
+
+	if line.HasChildren && line.Collapsed {
+		parts = append(parts, "collapsed")
+	} else if line.HasChildren {
+		parts = append(parts, "expanded")
+	}
+	if severity := o.lineSeverity(i); severity != "" {
+		parts = append(parts, severity)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// renderAccessibleRow renders node i as its role marker and plain text
+func (o *Outliner) renderAccessibleRow(i int, isCurrentLine bool) string {
+	line := o.lines[i]
+	text := line.Text
+	if isCurrentLine {
+		cursorPos := min(o.cursorPos, len(text))
+		text = text[:cursorPos] + o.cursorStyle.Render("|") + text[cursorPos:]
+	}
+	row := o.roleMarker(i) + ": " + text
+	if isCurrentLine {
+		row = o.highlightStyle.Render(row)
+	}
+	return row
+}
+
+// noticeCaptured queues a CaptureNoticeMsg for nodes newly captured, when
+// the screen reader mode is on
+func (o *Outliner) noticeCaptured(indexes []int) {
+	if !o.accessible || len(indexes) == 0 {
+		return
+	}
+	msg := CaptureNoticeMsg{}
+	for _, i := range indexes {
+		msg.Types = append(msg.Types, o.detectPatternType(o.lines[i].Text))
+	}
+	o.pending.push(func() tea.Msg { return msg })
+}
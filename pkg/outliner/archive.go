@@ -0,0 +1,128 @@
+package outliner
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// archiveSection is the top-level node archived subtrees move under
+const archiveSection = "archive::"
+
+// archivedAnnotationRegex matches the [archived:: time] stamp an archived
+// subtree's root carries
+var archivedAnnotationRegex = regexp.MustCompile(`\[archived::[^\]]*\]`)
+
+// isArchiveRoot reports whether text opens an archived subtree: the
+// archive:: section itself or a node stamped [archived:: time]. Nothing
+// under one is captured.
+func isArchiveRoot(text string) bool {
+	text = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), "•"))
+	return strings.HasPrefix(text, archiveSection) || archivedAnnotationRegex.MatchString(text)
+}
+
+// markArchived records which nodes sit in archived subtrees
+func (o *Outliner) markArchived() {
+	clear(o.archived)
+	archiveLevel := -1
+	for _, line := range o.lines {
+		if line.Kind == KindBlank {
+			continue
+		}
+		if archiveLevel >= 0 && line.Level > archiveLevel {
+			o.archived[line.ID] = true
+			continue
+		}
+		archiveLevel = -1
+		if isArchiveRoot(line.Text) {
+			archiveLevel = line.Level
+			o.archived[line.ID] = true
+		}
+	}
+}
+
+// SetSearchArchived lets the jump navigator find archived nodes
+func (o *Outliner) SetSearchArchived(enabled bool) {
+	o.searchArchived = enabled
+}
+
+// takeArchived removes the subtree under the cursor, undoably, and returns
+// it stamped [archived:: now] with its root at level
+func (o *Outliner) takeArchived(now time.Time, level int) ([]OutlineNode, error) {
+	if o.cursor >= len(o.lines) || o.lines[o.cursor].Kind == KindBlank {
+		return nil, fmt.Errorf("no node to archive")
+	}
+	if o.archived[o.lines[o.cursor].ID] {
+		return nil, fmt.Errorf("node is already archived")
+	}
+
+	start, end := o.cursor, o.cursor+1
+	for end < len(o.lines) && o.lines[end].Level > o.lines[start].Level {
+		end++
+	}
+	o.saveUndo()
+	subtree := slices.Clone(o.lines[start:end])
+	shift := level - subtree[0].Level
+	for i := range subtree {
+		subtree[i].Level += shift
+	}
+	subtree[0].Text = strings.TrimRight(subtree[0].Text, " ") + fmt.Sprintf(" [archived:: %s]", now.Format(doneTimeFormat))
+	subtree[0].ModifiedAt = now
+
+	o.deleteNodes(start, end)
+	if len(o.lines) == 0 {
+		o.lines = []OutlineNode{newNode("", 0)}
+	}
+	o.cursor = min(start, len(o.lines)-1)
+	o.cursorPos = 0
+	return subtree, nil
+}
+
+// ArchiveSubtree moves the subtree under the cursor to the end of the
+// outline's archive:: section, creating it if needed, stamped with when it
+// was archived
+func (o *Outliner) ArchiveSubtree(now time.Time) error {
+	subtree, err := o.takeArchived(now, 1)
+	if err != nil {
+		return err
+	}
+
+	section := slices.IndexFunc(o.lines, func(node OutlineNode) bool {
+		return node.Level == 0 && strings.HasPrefix(strings.TrimSpace(node.Text), archiveSection)
+	})
+	if section < 0 {
+		o.lines = append(o.lines, newNode(archiveSection, 0))
+		section = len(o.lines) - 1
+	}
+	end := section + 1
+	for end < len(o.lines) && o.lines[end].Level > 0 {
+		end++
+	}
+	o.insertNodes(end, subtree...)
+	for i := end; i < end+len(subtree); i++ {
+		o.refreshNodeLinks(i)
+	}
+	o.updateBacklinks()
+	o.structureChanged()
+	return nil
+}
+
+// CutSubtree removes the subtree under the cursor, undoably, and returns it
+// as outline text stamped with when it was archived, for appending to an
+// archive file
+func (o *Outliner) CutSubtree(now time.Time) (string, error) {
+	subtree, err := o.takeArchived(now, 0)
+	if err != nil {
+		return "", err
+	}
+	o.updateBacklinks()
+	o.structureChanged()
+
+	var text strings.Builder
+	for _, node := range subtree {
+		text.WriteString(formatNode(node) + "\n")
+	}
+	return text.String(), nil
+}
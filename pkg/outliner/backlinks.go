@@ -0,0 +1,167 @@
+package outliner
+
+import (
+	"slices"
+	"strings"
+)
+
+// backlinkIndex is the inverted index backlinks are read from. Concepts
+// are keyed lowercased, without #heading or |label, so [[Auth#Tokens]] and
+// [[auth]] are one concept.
+type backlinkIndex struct {
+	linkers map[string][]string // concept -> nodes whose text [[links]] it
+	names   map[string][]string // concept -> nodes linking or defining it, whose backlinks its linkers are
+	keys    map[string]nodeKeys // node ID -> what it's indexed under
+}
+
+// nodeKeys are the concepts a node is indexed under
+type nodeKeys struct {
+	links []string // the concepts it links
+	names []string // the concepts it links or defines
+}
+
+func newBacklinkIndex() *backlinkIndex {
+	return &backlinkIndex{
+		linkers: make(map[string][]string),
+		names:   make(map[string][]string),
+		keys:    make(map[string]nodeKeys),
+	}
+}
+
+// conceptKey is how the index keys a link target
+func conceptKey(link string) string {
+	return strings.ToLower(normalizeLink(link))
+}
+
+// keysFor reads the concepts node links and defines (see definedNames)
+func keysFor(node OutlineNode) nodeKeys {
+	var keys nodeKeys
+	for _, link := range node.Links {
+		if key := conceptKey(link); key != "" && !slices.Contains(keys.links, key) {
+			keys.links = append(keys.links, key)
+		}
+	}
+	keys.names = slices.Clone(keys.links)
+	for _, name := range definedNames(node) {
+		if key := conceptKey(name); key != "" && !slices.Contains(keys.names, key) {
+			keys.names = append(keys.names, key)
+		}
+	}
+	return keys
+}
+
+// index files node under its current concepts, replacing what it was filed
+// under before, and returns the concepts whose linkers changed
+func (idx *backlinkIndex) index(node OutlineNode) []string {
+	old := idx.keys[node.ID]
+	keys := keysFor(node)
+	idx.keys[node.ID] = keys
+
+	unlinked, linked := missing(old.links, keys.links), missing(keys.links, old.links)
+	for _, key := range unlinked {
+		removeID(idx.linkers, key, node.ID)
+	}
+	for _, key := range linked {
+		idx.linkers[key] = append(idx.linkers[key], node.ID)
+	}
+	for _, key := range missing(old.names, keys.names) {
+		removeID(idx.names, key, node.ID)
+	}
+	for _, key := range missing(keys.names, old.names) {
+		idx.names[key] = append(idx.names[key], node.ID)
+	}
+	return append(unlinked, linked...)
+}
+
+// drop takes node id out of the index and returns the concepts it linked
+func (idx *backlinkIndex) drop(id string) []string {
+	old, ok := idx.keys[id]
+	if !ok {
+		return nil
+	}
+	delete(idx.keys, id)
+	for _, key := range old.links {
+		removeID(idx.linkers, key, id)
+	}
+	for _, key := range old.names {
+		removeID(idx.names, key, id)
+	}
+	return old.links
+}
+
+// missing are the keys of from that aren't in to
+func missing(from, to []string) []string {
+	var keys []string
+	for _, key := range from {
+		if !slices.Contains(to, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// removeID takes id off key's list, dropping the key once it's empty
+func removeID(index map[string][]string, key, id string) {
+	if i := slices.Index(index[key], id); i >= 0 {
+		index[key] = slices.Delete(index[key], i, i+1)
+	}
+	if len(index[key]) == 0 {
+		delete(index, key)
+	}
+}
+
+// backlinks are the other nodes linking a concept node id links or defines,
+// in the order they were indexed
+func (idx *backlinkIndex) backlinks(id string) []string {
+	backlinks := []string{}
+	seen := map[string]bool{id: true}
+	for _, key := range idx.keys[id].names {
+		for _, linker := range idx.linkers[key] {
+			if !seen[linker] {
+				seen[linker] = true
+				backlinks = append(backlinks, linker)
+			}
+		}
+	}
+	return backlinks
+}
+
+// updateBacklinks rebuilds the backlink index from every node's links and
+// sets every node's backlinks, after a load or a structural edit
+func (o *Outliner) updateBacklinks() {
+	o.backlinks = newBacklinkIndex()
+	for _, line := range o.lines {
+		o.backlinks.index(line)
+	}
+	for i := range o.lines {
+		o.lines[i].Backlinks = o.backlinks.backlinks(o.lines[i].ID)
+	}
+}
+
+// refreshBacklinks resets the backlinks of the nodes ids and of every node
+// linking or defining one of the concepts changed
+func (o *Outliner) refreshBacklinks(changed []string, ids ...string) {
+	if len(changed) == 0 {
+		// Typing that leaves the links alone, found without a scan
+		for _, id := range ids {
+			if i := o.nodeIndex(id); i >= 0 {
+				o.lines[i].Backlinks = o.backlinks.backlinks(id)
+			}
+		}
+		return
+	}
+	affected := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		affected[id] = true
+	}
+	for _, key := range changed {
+		for _, id := range o.backlinks.names[key] {
+			affected[id] = true
+		}
+	}
+	for i := range o.lines {
+		if affected[o.lines[i].ID] {
+			o.lines[i].Backlinks = o.backlinks.backlinks(o.lines[i].ID)
+		}
+	}
+}
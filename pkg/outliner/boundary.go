@@ -0,0 +1,97 @@
+package outliner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ctxNodeRegex matches a node opening a ctx:: block
+var ctxNodeRegex = regexp.MustCompile(`^\s*ctx::`)
+
+// durationAnnotationRegex matches the [duration:: ...] a closed ctx:: block
+// carries
+var durationAnnotationRegex = regexp.MustCompile(`\[duration::[^\]]*\]`)
+
+// carriedAnnotations are copied from a closed ctx:: block to the next
+var carriedAnnotations = []string{"project", "mode"}
+
+// contextBlock returns the index of the ctx:: node whose block holds the
+// cursor, and where the block ends: at the next ctx:: node or a node
+// shallower than it. ok is false outside any ctx:: block.
+func (o *Outliner) contextBlock() (start, end int, ok bool) {
+	if o.cursor >= len(o.lines) {
+		return 0, 0, false
+	}
+	shallowest := o.lines[o.cursor].Level
+	start = -1
+	for i := o.cursor; i >= 0; i-- {
+		if o.lines[i].Level <= shallowest && ctxNodeRegex.MatchString(o.lines[i].Text) {
+			start = i
+			break
+		}
+		shallowest = min(shallowest, o.lines[i].Level)
+	}
+	if start < 0 {
+		return 0, 0, false
+	}
+
+	level := o.lines[start].Level
+	end = start + 1
+	for end < len(o.lines) {
+		line := o.lines[end]
+		if line.Level < level || line.Level == level && ctxNodeRegex.MatchString(line.Text) {
+			break
+		}
+		end++
+	}
+	return start, end, true
+}
+
+// ContextBoundary closes the ctx:: block the cursor is in with a
+// [duration:: ...] of the time worked in it, then opens a fresh ctx:: for
+// now after it, carrying over [project::] and [mode::], and moves the
+// cursor to an empty node under the new header. It returns false, changing
+// nothing, outside a ctx:: block.
+func (o *Outliner) ContextBoundary(worked time.Duration, now time.Time) bool {
+	start, end, ok := o.contextBlock()
+	if !ok {
+		return false
+	}
+	o.saveUndo()
+
+	closed := &o.lines[start]
+	if !durationAnnotationRegex.MatchString(closed.Text) {
+		closed.Text = strings.TrimRight(closed.Text, " ") + fmt.Sprintf(" [duration:: %s]", FormatWorked(worked))
+		closed.ModifiedAt = now
+		closed.Captured = false
+	}
+
+	header := fmt.Sprintf("ctx:: %s %s", now.Format("2006-01-02"), strings.ToLower(now.Format("3:04PM")))
+	annotations := map[string]string{}
+	for _, match := range contextAnnotationRegex.FindAllStringSubmatch(closed.Text, -1) {
+		annotations[match[1]] = strings.TrimSpace(match[2])
+	}
+	for _, key := range carriedAnnotations {
+		if value := annotations[key]; value != "" {
+			header += fmt.Sprintf(" [%s:: %s]", key, value)
+		}
+	}
+
+	level := closed.Level
+	o.insertNodes(end, newNode(header, level), newNode("", level))
+	o.cursor, o.cursorPos = end+1, 0
+	o.structureChanged()
+	return true
+}
+
+// FormatWorked renders a worked duration to the minute, e.g. "47m" or
+// "1h05m"
+func FormatWorked(d time.Duration) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", max(minutes, 1))
+	}
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
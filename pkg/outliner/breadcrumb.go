@@ -0,0 +1,60 @@
+package outliner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+)
+
+// crumbWidth is how much of a node's text a breadcrumb shows
+const crumbWidth = 24
+
+// ancestors returns the indexes of node i's ancestors, root first
+func (o *Outliner) ancestors(i int) []int {
+	var chain []int
+	level := o.lines[i].Level
+	for j := i - 1; j >= 0 && level > 0; j-- {
+		if o.lines[j].Level < level {
+			chain = append(chain, j)
+			level = o.lines[j].Level
+		}
+	}
+	for l, r := 0, len(chain)-1; l < r; l, r = l+1, r-1 {
+		chain[l], chain[r] = chain[r], chain[l]
+	}
+	return chain
+}
+
+// crumb shortens node text for the breadcrumb
+func crumb(text string) string {
+	return cells.Cut(strings.TrimSpace(text), crumbWidth)
+}
+
+// renderBreadcrumb renders the cursor's ancestry as "1 root ▸ 2 parent ▸
+// current", numbered for alt+1…9; the outermost crumbs give way to "…"
+// when it doesn't fit width
+func (o *Outliner) renderBreadcrumb(width int) string {
+	var crumbs []string
+	for n, i := range o.ancestors(o.cursor) {
+		crumbs = append(crumbs, o.bulletStyle.Render(fmt.Sprint(n+1))+" "+crumb(o.lines[i].Text))
+	}
+	crumbs = append(crumbs, crumb(o.lines[o.cursor].Text))
+
+	line := strings.Join(crumbs, " ▸ ")
+	for dropped := 1; cells.Width(line) > width && dropped < len(crumbs); dropped++ {
+		line = "… ▸ " + strings.Join(crumbs[dropped:], " ▸ ")
+	}
+	return line
+}
+
+// jumpToAncestor moves the cursor to the nth crumb of the breadcrumb
+func (o *Outliner) jumpToAncestor(n int) {
+	chain := o.ancestors(o.cursor)
+	if n < 1 || n > len(chain) {
+		return
+	}
+	o.cursor = chain[n-1]
+	o.cursorPos = len(o.lines[o.cursor].Text)
+	o.scrollToCursor()
+}
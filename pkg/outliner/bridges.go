@@ -0,0 +1,118 @@
+package outliner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AssignBridgeIDs annotates bridge:: nodes that lack a [bridge-id:: ...]
+// with IDs from next and returns how many were assigned
+func (o *Outliner) AssignBridgeIDs(next func() string) int {
+	assigned := 0
+	for i := range o.lines {
+		node := &o.lines[i]
+		if o.detectPatternType(node.Text) != "bridge" || strings.Contains(node.Text, "bridge-id::") {
+			continue
+		}
+
+		id := next()
+		node.Text = strings.TrimRight(node.Text, " ") + fmt.Sprintf(" [bridge-id:: %s]", id)
+		if node.Metadata == nil {
+			node.Metadata = make(map[string]string)
+		}
+		node.Metadata["bridge-id"] = id
+		node.ModifiedAt = time.Now()
+		assigned++
+	}
+	return assigned
+}
+
+// CurrentText returns the text of the node under the cursor
+func (o *Outliner) CurrentText() string {
+	if o.cursor >= len(o.lines) {
+		return ""
+	}
+	return o.lines[o.cursor].Text
+}
+
+// FindNode returns the first node after index `after` whose text contains
+// substr, wrapping around; -1 when there is none besides `after` itself
+func (o *Outliner) FindNode(substr string, after int) int {
+	for n := 1; n <= len(o.lines); n++ {
+		i := (after + n) % len(o.lines)
+		if i < 0 {
+			i += len(o.lines)
+		}
+		if i != after && strings.Contains(o.lines[i].Text, substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Cursor returns the index of the node under the cursor
+func (o *Outliner) Cursor() int {
+	return o.cursor
+}
+
+// SetCursor moves the cursor to the start of node i, unfolding what hides
+// it
+func (o *Outliner) SetCursor(i int) {
+	if i < 0 || i >= len(o.lines) {
+		return
+	}
+	o.cursor = i
+	o.cursorPos = 0
+	o.revealCursor()
+}
+
+// InsertSubtree adds outline lines ("• text", indented two spaces per
+// level) as children of the node under the cursor, after its existing
+// children. The subtree root starts collapsed; metadata is copied onto
+// every inserted node.
+func (o *Outliner) InsertSubtree(lines []string, metadata map[string]string) int {
+	if o.cursor >= len(o.lines) || len(lines) == 0 {
+		return 0
+	}
+	parent := o.lines[o.cursor]
+
+	var nodes []OutlineNode
+	for _, line := range lines {
+		level := 0
+		for strings.HasPrefix(line, "  ") {
+			level++
+			line = line[2:]
+		}
+		text := strings.TrimPrefix(strings.TrimPrefix(line, "• "), "◦ ")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		node := newNode(text, parent.Level+1+level)
+		node.PatternType = o.detectPatternType(text)
+		node.Captured = true // already dispatched where it was written
+		for k, v := range metadata {
+			node.Metadata[k] = v
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 0 {
+		return 0
+	}
+	nodes[0].Collapsed = true
+
+	insertAt := o.cursor + 1
+	for insertAt < len(o.lines) && o.lines[insertAt].Level > parent.Level {
+		insertAt++
+	}
+	o.insertNodes(insertAt, nodes...)
+
+	markChildren(o.lines)
+	o.markArchived()
+	for i := insertAt; i < insertAt+len(nodes); i++ {
+		o.updateNodeLinks(i)
+	}
+	o.refreshDiagnostics()
+	return len(nodes)
+}
@@ -0,0 +1,73 @@
+package outliner
+
+import (
+	"reflect"
+	"slices"
+)
+
+// Bus is the in-process event bus the dispatch system publishes to. The
+// outliner's debug panel, evna, open doors and any other consumer
+// subscribe by event type. Handlers run on the publisher's goroutine, in
+// the order they subscribed, before Publish returns.
+type Bus struct {
+	handlers map[reflect.Type][]*subscription
+}
+
+type subscription struct {
+	handle func(any)
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[reflect.Type][]*subscription)}
+}
+
+// Subscribe calls handler with every event of type E published to b from
+// now on; the returned func unsubscribes it
+func Subscribe[E any](b *Bus, handler func(E)) (unsubscribe func()) {
+	t := reflect.TypeFor[E]()
+	sub := &subscription{handle: func(event any) { handler(event.(E)) }}
+	b.handlers[t] = append(b.handlers[t], sub)
+	return func() {
+		if i := slices.Index(b.handlers[t], sub); i >= 0 {
+			b.handlers[t] = slices.Delete(slices.Clone(b.handlers[t]), i, i+1)
+		}
+	}
+}
+
+// Publish hands event to each of its type's subscribers
+func Publish[E any](b *Bus, event E) {
+	// A handler unsubscribing mid-publish doesn't disturb this loop, as
+	// unsubscribing replaces the list rather than editing it
+	for _, sub := range b.handlers[reflect.TypeFor[E]()] {
+		sub.handle(event)
+	}
+}
+
+// ActionDispatched is published for every action the dispatch system
+// routes, after reducers and selectors have seen it
+type ActionDispatched struct {
+	Action DispatchAction
+}
+
+// ReducerCollected is published when a reducer collects an action, once
+// per reducer
+type ReducerCollected struct {
+	Reducer string
+	Action  DispatchAction
+}
+
+// PatternsCaptured is published when the outliner has dispatched a
+// capture's patterns. Held ones await capture review: evna gets them
+// later, as a capture of their own, once approved.
+type PatternsCaptured struct {
+	Patterns []ConsciousnessPattern
+	Source   string // e.g. "float-dispatch:manual_trigger"
+	Held     bool
+}
+
+// CaptureApproved is published when capture review sends on the patterns
+// it held
+type CaptureApproved struct {
+	Patterns []ConsciousnessPattern
+	Source   string
+}
@@ -0,0 +1,102 @@
+package outliner
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/calendar"
+)
+
+// ctxTimeRegex matches the date and time a ctx:: header opens with, e.g.
+// "2025-08-05 9:00am" or "2025-08-05 @ 14:30"; either may be left out
+var ctxTimeRegex = regexp.MustCompile(`(?i)^\s*(\d{4}-\d{2}-\d{2})?\s*(?:@\s*)?(\d{1,2}:\d{2}\s*(?:[ap]m)?)?`)
+
+// CtxTime reads when a ctx:: pattern's content says it was written. A
+// bare time is on ref's day, and content naming neither was written at
+// ref. ok is false for a bare date other than ref's day: it was written
+// then, but not when.
+func CtxTime(content string, ref time.Time) (time.Time, bool) {
+	match := ctxTimeRegex.FindStringSubmatch(content)
+	date, clock := match[1], strings.ToLower(strings.ReplaceAll(match[2], " ", ""))
+
+	day := ref
+	if date != "" {
+		t, err := time.ParseInLocation("2006-01-02", date, ref.Location())
+		if err != nil {
+			return ref, true
+		}
+		day = t
+	}
+	for _, layout := range []string{"3:04pm", "15:04"} {
+		if t, err := time.Parse(layout, clock); err == nil {
+			return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, ref.Location()), true
+		}
+	}
+	if date != "" && !sameDay(day, ref) {
+		return day, false
+	}
+	return ref, true
+}
+
+// sameDay reports whether a and b fall on the same date in a's location
+func sameDay(a, b time.Time) bool {
+	b = b.In(a.Location())
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+// AnnotateMeeting adds [meeting:: title] to a ctx:: pattern written during
+// a calendar event: at the time its header names, else at. A pattern
+// already carrying a [meeting::] is left as it is.
+func AnnotateMeeting(cal *calendar.Calendar, pattern ConsciousnessPattern, at time.Time) ConsciousnessPattern {
+	if cal == nil || pattern.Type != "ctx" || pattern.Context["meeting"] != "" {
+		return pattern
+	}
+	t, ok := CtxTime(pattern.Content, at)
+	if !ok {
+		return pattern
+	}
+	event, ok := cal.At(t)
+	if !ok || event.Summary == "" {
+		return pattern
+	}
+
+	// The parser's map may be cached with the line it came from
+	context := make(map[string]string, len(pattern.Context)+1)
+	for k, v := range pattern.Context {
+		context[k] = v
+	}
+	context["meeting"] = event.Summary
+	pattern.Context = context
+	return pattern
+}
+
+// MeetingMetadata is the dispatch metadata for a pattern's [meeting::],
+// nil without one
+func MeetingMetadata(pattern ConsciousnessPattern) map[string]string {
+	if meeting := pattern.Context["meeting"]; meeting != "" {
+		return map[string]string{"meeting": meeting}
+	}
+	return nil
+}
+
+// SetCalendar sets the calendar ctx:: captures take a [meeting::] from
+// and the timeline door shows; nil turns both off
+func (o *Outliner) SetCalendar(cal *calendar.Calendar) {
+	o.calendar = cal
+}
+
+// Calendar is the calendar set with SetCalendar
+func (o *Outliner) Calendar() *calendar.Calendar {
+	return o.calendar
+}
+
+// annotateMeeting annotates pattern with the meeting on when its node was
+// written
+func (o *Outliner) annotateMeeting(pattern ConsciousnessPattern) ConsciousnessPattern {
+	at := time.Now()
+	if pattern.Line <= len(o.lines) && !o.lines[pattern.Line-1].CreatedAt.IsZero() {
+		at = o.lines[pattern.Line-1].CreatedAt
+	}
+	return AnnotateMeeting(o.calendar, pattern, at)
+}
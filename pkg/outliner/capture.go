@@ -0,0 +1,91 @@
+package outliner
+
+import (
+	"regexp"
+	"strings"
+)
+
+// privateAnnotationRegex matches the [private:: ...] annotation that keeps
+// a node out of capture
+var privateAnnotationRegex = regexp.MustCompile(`\s*\[private::[^\]]*\]`)
+
+// isPrivate reports whether node text opts out of capture
+func isPrivate(text string) bool {
+	return privateAnnotationRegex.MatchString(text)
+}
+
+// togglePrivate adds or removes [private:: true] on the current node. The
+// flag is an annotation so it survives saving as markdown as well as OPML.
+func (o *Outliner) togglePrivate() {
+	if o.cursor >= len(o.lines) || !o.lines[o.cursor].Kind.Captured() {
+		return
+	}
+	line := &o.lines[o.cursor]
+	if isPrivate(line.Text) {
+		line.Text = privateAnnotationRegex.ReplaceAllString(line.Text, "")
+	} else {
+		line.Text = strings.TrimRight(line.Text, " ") + " [private:: true]"
+	}
+	o.cursorPos = min(o.cursorPos, len(line.Text))
+	line.Captured = false
+}
+
+// captureNode re-captures node i on its own, dispatching its patterns now
+// rather than at the next save
+func (o *Outliner) captureNode(i int) {
+	if o.parser == nil || i >= len(o.lines) {
+		return
+	}
+	node := o.lines[i]
+	if !node.Kind.Captured() || isPrivate(node.Text) || o.archived[node.ID] {
+		o.debugPanel.AddMessage("CAPTURE_SKIPPED", "Node isn't capturable: "+node.Text, DebugLevelWarning)
+		return
+	}
+
+	patterns := o.parser.Parse(formatNode(node)).ConsciousnessData
+	for j := range patterns {
+		patterns[j].Line = i + 1
+	}
+	o.dispatchPatterns(patterns, "node_recapture")
+}
+
+// needsReview reports whether node i holds a pattern that hasn't been
+// captured yet
+func (o *Outliner) needsReview(i int) bool {
+	node := o.lines[i]
+	return node.Kind.Captured() && !node.Captured && !isPrivate(node.Text) && !o.archived[node.ID] && node.Mirror == "" && o.detectPatternType(node.Text) != ""
+}
+
+// toggleReview switches the filter that shows only uncaptured pattern
+// nodes, moving the cursor onto one
+func (o *Outliner) toggleReview() {
+	o.reviewMode = !o.reviewMode
+	o.offset = 0
+	if o.reviewMode {
+		o.keepCursorVisible()
+	}
+}
+
+// keepCursorVisible moves the cursor off a node the review filter hides:
+// forward when there's a node to review there, else back
+func (o *Outliner) keepCursorVisible() {
+	if !o.isHidden(o.cursor) {
+		return
+	}
+	cursor := o.cursor
+	if o.moveCursor(1); o.cursor == cursor {
+		o.moveCursor(-1)
+	}
+	o.cursorPos = min(o.cursorPos, len(o.lines[o.cursor].Text))
+}
+
+// reviewCount is how many nodes the review filter shows
+func (o *Outliner) reviewCount() int {
+	n := 0
+	for i := range o.lines {
+		if o.needsReview(i) {
+			n++
+		}
+	}
+	return n
+}
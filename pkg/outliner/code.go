@@ -0,0 +1,162 @@
+package outliner
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// codeHighlightStyle is the chroma style code blocks are colored with
+var codeHighlightStyle = styles.Get("monokai")
+
+// isFence reports whether a code node is a ``` line
+func isFence(node OutlineNode) bool {
+	return node.Kind == KindCode && strings.HasPrefix(strings.TrimSpace(node.Text), codeFence)
+}
+
+// codeLanguage returns the language named on the fence opening node i's
+// code block, "" when there's none
+func (o *Outliner) codeLanguage(i int) string {
+	for j := i; j >= 0 && o.lines[j].Kind == KindCode; j-- {
+		if isFence(o.lines[j]) && !o.closesFence(j) {
+			return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(o.lines[j].Text), codeFence))
+		}
+	}
+	return ""
+}
+
+// renderCode renders code node i: fences dimmed, code highlighted for its
+// block's language
+func (o *Outliner) renderCode(i int) string {
+	node := o.lines[i]
+	if isFence(node) {
+		return o.codeStyle.Render(node.Text)
+	}
+	return highlightCode(node.Text, o.codeLanguage(i), o.codeStyle)
+}
+
+// highlightCode colors one line of code with chroma. Lines are lexed on
+// their own, so a construct spanning lines (a block comment) is only
+// colored where it starts. Unknown languages render in base.
+func highlightCode(line, language string, base lipgloss.Style) string {
+	lexer := lexers.Get(language)
+	if lexer == nil || line == "" {
+		return base.Render(line)
+	}
+	tokens, err := chroma.Coalesce(lexer).Tokenise(nil, line)
+	if err != nil {
+		return base.Render(line)
+	}
+
+	var out strings.Builder
+	for _, token := range tokens.Tokens() {
+		value := strings.TrimSuffix(token.Value, "\n")
+		if value == "" {
+			continue
+		}
+		style := base
+		entry := codeHighlightStyle.Get(token.Type)
+		if entry.Colour.IsSet() {
+			style = style.Foreground(lipgloss.Color(entry.Colour.String()))
+		}
+		if entry.Bold == chroma.Yes {
+			style = style.Bold(true)
+		}
+		if entry.Italic == chroma.Yes {
+			style = style.Italic(true)
+		}
+		out.WriteString(style.Render(value))
+	}
+	return out.String()
+}
+
+// leadingSpace returns the whitespace a line of code starts with
+func leadingSpace(text string) string {
+	return text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+}
+
+// codeEnter handles enter on the current node when it's code, or a bullet
+// that starts a fence; it reports false for enter to work as usual.
+// Typing ``` (and a language) on a bullet and pressing enter opens a block
+// with an empty line of code and its closing fence.
+func (o *Outliner) codeEnter() bool {
+	line := &o.lines[o.cursor]
+	switch {
+	case line.Kind == KindBullet && strings.HasPrefix(line.Text, codeFence):
+		line.Kind = KindCode
+		line.PatternType = ""
+		body := newNode("", line.Level+1)
+		body.Kind = KindCode
+		closing := newNode(codeFence, line.Level)
+		closing.Kind = KindCode
+		o.insertNodes(o.cursor+1, body, closing)
+
+	case line.Kind != KindCode || o.closesFence(o.cursor):
+		return false
+
+	case isFence(*line):
+		// On the opening fence, start the block's first line
+		body := newNode("", line.Level+1)
+		body.Kind = KindCode
+		o.insertNodes(o.cursor+1, body)
+
+	default:
+		// Split the line at the cursor, keeping its indentation
+		indent := leadingSpace(line.Text)
+		at := min(o.cursorPos, len(line.Text))
+		rest := line.Text[at:]
+		line.Text = line.Text[:at]
+		body := newNode(indent+rest, line.Level)
+		body.Kind = KindCode
+		o.insertNodes(o.cursor+1, body)
+		o.cursor++
+		o.cursorPos = len(indent)
+		return true
+	}
+
+	o.cursor++
+	o.cursorPos = 0
+	return true
+}
+
+// codeIndent handles tab and shift+tab on code: on the opening fence the
+// whole block moves, inside it the code itself is indented rather than the
+// node. It reports false on other nodes.
+func (o *Outliner) codeIndent(outdent bool) bool {
+	line := &o.lines[o.cursor]
+	switch {
+	case line.Kind != KindCode:
+		return false
+	case o.closesFence(o.cursor):
+		return true
+	case isFence(*line):
+		delta := 1
+		if outdent {
+			delta = -1
+		}
+		if line.Level+delta < 0 || line.Level+delta > 6 {
+			return true
+		}
+		for i := o.cursor; i < len(o.lines) && o.lines[i].Kind == KindCode; i++ {
+			o.lines[i].Level += delta
+			if o.closesFence(i) {
+				break
+			}
+		}
+		return true
+	}
+
+	if !outdent {
+		at := min(o.cursorPos, len(line.Text))
+		line.Text = line.Text[:at] + "  " + line.Text[at:]
+		o.cursorPos = at + 2
+		return true
+	}
+	removed := len(line.Text) - len(strings.TrimPrefix(strings.TrimPrefix(line.Text, " "), " "))
+	line.Text = line.Text[removed:]
+	o.cursorPos = max(o.cursorPos-removed, 0)
+	return true
+}
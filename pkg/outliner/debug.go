@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
 )
 
 // DebugMessage represents a consciousness debug message
@@ -148,7 +150,7 @@ func (cdp *ConsciousnessDebugPanel) View(width, height int) string {
 	var content strings.Builder
 
 	// Header
-	header := cdp.headerStyle.Render("🧠 Consciousness Debug Panel")
+	header := cdp.headerStyle.Render(i18n.T("debug.panel"))
 	content.WriteString(header + "\n\n")
 
 	// Show recent messages (last 10 for display)
@@ -158,7 +160,7 @@ func (cdp *ConsciousnessDebugPanel) View(width, height int) string {
 	}
 
 	if displayCount == 0 {
-		content.WriteString("No consciousness activity yet...")
+		content.WriteString(i18n.T("debug.empty"))
 	} else {
 		startIndex := len(cdp.messages) - displayCount
 		for i := startIndex; i < len(cdp.messages); i++ {
@@ -0,0 +1,136 @@
+package outliner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// severityColors colors gutter markers and panel entries
+var severityColors = map[string]string{
+	"error":   "9",
+	"warning": "11",
+	"info":    "12",
+}
+
+// severityMarkers are the gutter glyphs per severity
+var severityMarkers = map[string]string{
+	"error":   "✖",
+	"warning": "▲",
+	"info":    "ℹ",
+}
+
+// refreshDiagnostics re-lints the outline; called after every edit, so
+// only nodes whose text changed are checked again
+func (o *Outliner) refreshDiagnostics() {
+	if o.parser == nil {
+		return
+	}
+	o.diagnostics = o.parser.LintIncremental(o.patternContent(), o.lineKeys())
+
+	// GetContent writes one line per node, so lint lines map to nodes
+	o.severities = make(map[int]string)
+	for _, issue := range o.diagnostics {
+		if index := issue.Line - 1; index >= 0 && SeverityRank(issue.Severity) > SeverityRank(o.severities[index]) {
+			o.severities[index] = issue.Severity
+		}
+	}
+}
+
+// Diagnostics returns the current lint issues
+func (o *Outliner) Diagnostics() []LintIssue {
+	return o.diagnostics
+}
+
+// IsDiagnosticsVisible returns whether the diagnostics panel is shown
+func (o *Outliner) IsDiagnosticsVisible() bool {
+	return o.showDiagnostics
+}
+
+// SetDiagnosticsVisible shows or hides the diagnostics panel
+func (o *Outliner) SetDiagnosticsVisible(visible bool) {
+	o.showDiagnostics = visible
+}
+
+// lineSeverity returns the worst severity reported for a node, or ""
+func (o *Outliner) lineSeverity(index int) string {
+	return o.severities[index]
+}
+
+// renderGutter returns the two-column marker shown left of a node
+func (o *Outliner) renderGutter(index int) string {
+	severity := o.lineSeverity(index)
+	if severity == "" {
+		return "  "
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(severityColors[severity])).Render(severityMarkers[severity]) + " "
+}
+
+// underlineIssue underlines node text carrying an error or warning
+func (o *Outliner) underlineIssue(index int, text string) string {
+	severity := o.lineSeverity(index)
+	if SeverityRank(severity) < SeverityRank("warning") {
+		return text
+	}
+	return lipgloss.NewStyle().
+		Underline(true).
+		UnderlineSpaces(false).
+		Foreground(lipgloss.Color(severityColors[severity])).
+		Render(text)
+}
+
+// diagnosticsPanelHeight is the number of rows the panel takes, title and
+// borders included
+func (o *Outliner) diagnosticsPanelHeight() int {
+	if !o.showDiagnostics {
+		return 0
+	}
+	rows := len(o.diagnostics)
+	if rows == 0 {
+		rows = 1
+	}
+	if maxRows := o.height / 4; rows > maxRows {
+		rows = maxRows
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	return rows + 3
+}
+
+// renderDiagnosticsPanel lists lint issues, worst first
+func (o *Outliner) renderDiagnosticsPanel(width int) string {
+	height := o.diagnosticsPanelHeight() - 3
+
+	var lines []string
+	if len(o.diagnostics) == 0 {
+		lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render("✓ No issues"))
+	}
+
+	for _, severity := range []string{"error", "warning", "info"} {
+		for _, issue := range o.diagnostics {
+			if issue.Severity != severity {
+				continue
+			}
+			location := "doc"
+			if issue.Line > 0 {
+				location = fmt.Sprintf("L%d", issue.Line)
+			}
+			marker := lipgloss.NewStyle().Foreground(lipgloss.Color(severityColors[severity])).Render(severityMarkers[severity])
+			lines = append(lines, fmt.Sprintf("%s %-4s %s [%s]", marker, location, issue.Message, issue.Type))
+		}
+	}
+
+	if len(lines) > height {
+		more := len(lines) - height + 1
+		lines = append(lines[:height-1], fmt.Sprintf("… %d more", more))
+	}
+
+	title := fmt.Sprintf(" Diagnostics (%d) ", len(o.diagnostics))
+	return lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		Width(width - 2).
+		Render(title + "\n" + strings.Join(lines, "\n"))
+}
@@ -0,0 +1,557 @@
+package outliner
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ChangeKind is what happened to a node between two versions of an outline
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeEdited  ChangeKind = "edited"
+	ChangeMoved   ChangeKind = "moved"
+	ChangeLevel   ChangeKind = "level" // indented or outdented in place
+)
+
+// editSimilarity is how alike two texts' words must be for a node to count
+// as edited rather than removed and added
+const editSimilarity = 0.6
+
+// OutlineChange is one structural difference. A subtree that's added,
+// removed or moved is one change, at its root; a node both moved and edited
+// is two.
+type OutlineChange struct {
+	Kind      ChangeKind
+	Before    int    // the node's index in the old version, -1 when added
+	After     int    // its index in the new version, -1 when removed
+	Text      string // the node's text, as it was unless it was added
+	NewText   string // an edited node's new text
+	FromLevel int
+	ToLevel   int
+	ToParent  string // a moved node's new parent's text, "" at the top level
+	Nodes     int    // how many nodes the change carries, its root included
+}
+
+// OutlineDiff compares two versions of an outline as trees: nodes are
+// matched by [id:: ...], then by text, then by similar text within the
+// same stretch of the outline, so moved subtrees, level changes and edits
+// show as such rather than as lines removed and added
+type OutlineDiff struct {
+	Before  []OutlineNode
+	After   []OutlineNode
+	Changes []OutlineChange
+
+	pair, back   []int // before -> after and after -> before node indexes, -1 unmatched
+	parentBefore []int
+	parentAfter  []int
+	inOrder      []bool // new nodes in the longest run that kept its old order
+}
+
+// outlineNodes parses OPML or plain content the way SetContent does
+func outlineNodes(content string) []OutlineNode {
+	if IsOPML(content) {
+		if nodes, err := ParseOPML(content); err == nil {
+			return nodes
+		}
+	}
+	return parseNodes(content)
+}
+
+// DiffOutlines compares two versions of an outline's content
+func DiffOutlines(before, after string) *OutlineDiff {
+	return newOutlineDiff(outlineNodes(before), outlineNodes(after))
+}
+
+// DiffWith compares the outline with other content; merging the diff keeps
+// this outline's nodes, their IDs and histories, wherever it can
+func (o *Outliner) DiffWith(content string) *OutlineDiff {
+	return newOutlineDiff(append([]OutlineNode(nil), o.lines...), outlineNodes(content))
+}
+
+func newOutlineDiff(before, after []OutlineNode) *OutlineDiff {
+	d := &OutlineDiff{
+		Before:       before,
+		After:        after,
+		pair:         filled(len(before), -1),
+		back:         filled(len(after), -1),
+		parentBefore: parents(before),
+		parentAfter:  parents(after),
+	}
+	d.match()
+	d.compare()
+	return d
+}
+
+func filled(n, value int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = value
+	}
+	return s
+}
+
+// parents are each node's parent index, -1 at the top level
+func parents(nodes []OutlineNode) []int {
+	parent := make([]int, len(nodes))
+	var stack []int
+	for i, node := range nodes {
+		for len(stack) > 0 && nodes[stack[len(stack)-1]].Level >= node.Level {
+			stack = stack[:len(stack)-1]
+		}
+		parent[i] = -1
+		if len(stack) > 0 {
+			parent[i] = stack[len(stack)-1]
+		}
+		if node.Kind != KindBlank {
+			stack = append(stack, i)
+		}
+	}
+	return parent
+}
+
+func (d *OutlineDiff) link(i, j int) {
+	d.pair[i], d.back[j] = j, i
+}
+
+// match pairs up the versions' nodes
+func (d *OutlineDiff) match() {
+	// A node's [id:: ...] is who it is, whatever else changed
+	ids := map[string]int{}
+	for j, node := range d.After {
+		if id := stableID(node.Text); id != "" {
+			if _, dup := ids[id]; !dup {
+				ids[id] = j
+			}
+		}
+	}
+	for i, node := range d.Before {
+		if id := stableID(node.Text); id != "" {
+			if j, ok := ids[id]; ok && d.back[j] < 0 {
+				d.link(i, j)
+			}
+		}
+	}
+
+	// Then unchanged text, the nth copy of a line with the nth
+	type nodeKey struct {
+		kind NodeKind
+		text string
+	}
+	key := func(node OutlineNode) nodeKey { return nodeKey{node.Kind, storedText(node)} }
+	unmatched := map[nodeKey][]int{}
+	for j, node := range d.After {
+		if d.back[j] < 0 {
+			unmatched[key(node)] = append(unmatched[key(node)], j)
+		}
+	}
+	for i, node := range d.Before {
+		if d.pair[i] >= 0 {
+			continue
+		}
+		if js := unmatched[key(node)]; len(js) > 0 {
+			d.link(i, js[0])
+			unmatched[key(node)] = js[1:]
+		}
+	}
+
+	// Then edits: an unmatched node most like one of the unmatched nodes
+	// between its matched neighbours, under the same parent
+	for j, node := range d.After {
+		if d.back[j] >= 0 || node.Kind == KindBlank {
+			continue
+		}
+		parent := -1 // the old node new node j's parent matches
+		if p := d.parentAfter[j]; p >= 0 {
+			if parent = d.back[p]; parent < 0 {
+				continue
+			}
+		}
+		lo, hi := 0, len(d.Before)
+		for k := j - 1; k >= 0; k-- {
+			if d.back[k] >= 0 {
+				lo = d.back[k] + 1
+				break
+			}
+		}
+		for k := j + 1; k < len(d.After); k++ {
+			if d.back[k] >= 0 {
+				hi = d.back[k]
+				break
+			}
+		}
+		similar := func(lo, hi int) int {
+			best, score := -1, editSimilarity
+			for i := lo; i < hi; i++ {
+				if d.pair[i] >= 0 || d.Before[i].Kind != node.Kind || d.parentBefore[i] != parent {
+					continue
+				}
+				if s := textSimilarity(d.Before[i].Text, node.Text); s >= score {
+					best, score = i, s
+				}
+			}
+			return best
+		}
+		// Failing that, an edited node that also moved
+		best := similar(lo, hi)
+		if best < 0 {
+			best = similar(0, len(d.Before))
+		}
+		if best >= 0 {
+			d.link(best, j)
+		}
+	}
+
+	// Matched nodes in the longest run keeping their old order stayed put;
+	// the rest moved
+	var seq []int
+	for j := range d.After {
+		if d.back[j] >= 0 {
+			seq = append(seq, j)
+		}
+	}
+	d.inOrder = make([]bool, len(d.After))
+	for _, j := range longestIncreasing(seq, d.back) {
+		d.inOrder[j] = true
+	}
+}
+
+// longestIncreasing picks the longest subsequence of seq whose values
+// rank[j] increase, in O(n log n)
+func longestIncreasing(seq []int, rank []int) []int {
+	var tails []int // tails[k] is the index in seq ending the best run of length k+1
+	prev := make([]int, len(seq))
+	for n, j := range seq {
+		k := sort.Search(len(tails), func(k int) bool { return rank[seq[tails[k]]] >= rank[j] })
+		prev[n] = -1
+		if k > 0 {
+			prev[n] = tails[k-1]
+		}
+		if k == len(tails) {
+			tails = append(tails, n)
+		} else {
+			tails[k] = n
+		}
+	}
+	run := make([]int, len(tails))
+	for k, n := len(tails)-1, -1; k >= 0; k-- {
+		if n < 0 {
+			n = tails[k]
+		} else {
+			n = prev[n]
+		}
+		run[k] = seq[n]
+	}
+	return run
+}
+
+// textSimilarity is how much of two texts' words they share, from 0 to 1
+func textSimilarity(a, b string) float64 {
+	words := func(s string) map[string]int {
+		counts := map[string]int{}
+		for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+			counts[w]++
+		}
+		return counts
+	}
+	wa, wb := words(a), words(b)
+	total, shared := 0, 0
+	for w, n := range wa {
+		total += n
+		shared += min(n, wb[w])
+	}
+	for _, n := range wb {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	return 2 * float64(shared) / float64(total)
+}
+
+// keptParent reports whether after node j sits under the node its match
+// sat under
+func (d *OutlineDiff) keptParent(j int) bool {
+	i, p := d.back[j], d.parentAfter[j]
+	if p < 0 {
+		return d.parentBefore[i] < 0
+	}
+	return d.back[p] >= 0 && d.back[p] == d.parentBefore[i]
+}
+
+// compare lists the changes, each where it lands in the new version
+func (d *OutlineDiff) compare() {
+	type placed struct {
+		change OutlineChange
+		at     int
+	}
+	var changes []placed
+	roots := map[int]int{} // a subtree's root node -> its change in changes
+	moved := make([]bool, len(d.After))
+	carried := make([]bool, len(d.After)) // moved along with a moved parent
+
+	for j, node := range d.After {
+		i := d.back[j]
+		p := d.parentAfter[j]
+		if i < 0 {
+			if node.Kind == KindBlank {
+				continue
+			}
+			if p >= 0 && d.back[p] < 0 {
+				if r, ok := roots[p]; ok {
+					changes[r].change.Nodes++
+					roots[j] = r
+				}
+				continue
+			}
+			roots[j] = len(changes)
+			changes = append(changes, placed{OutlineChange{Kind: ChangeAdded, Before: -1, After: j, Text: node.Text, FromLevel: node.Level, ToLevel: node.Level, Nodes: 1}, 2*j + 1})
+			continue
+		}
+
+		old := d.Before[i]
+		if !d.inOrder[j] {
+			if d.keptParent(j) && p >= 0 && (moved[p] || carried[p]) {
+				carried[j] = true
+				if r, ok := roots[p]; ok {
+					changes[r].change.Nodes++
+					roots[j] = r
+				}
+			} else if node.Kind != KindBlank {
+				moved[j] = true
+				parent := ""
+				if p >= 0 {
+					parent = d.After[p].Text
+				}
+				roots[j] = len(changes)
+				changes = append(changes, placed{OutlineChange{Kind: ChangeMoved, Before: i, After: j, Text: old.Text, FromLevel: old.Level, ToLevel: node.Level, ToParent: parent, Nodes: 1}, 2*j + 1})
+			}
+		} else if old.Level != node.Level && !d.keptParent(j) && node.Kind != KindBlank {
+			changes = append(changes, placed{OutlineChange{Kind: ChangeLevel, Before: i, After: j, Text: old.Text, FromLevel: old.Level, ToLevel: node.Level, Nodes: 1}, 2*j + 1})
+		}
+		if storedText(old) != storedText(node) {
+			changes = append(changes, placed{OutlineChange{Kind: ChangeEdited, Before: i, After: j, Text: old.Text, NewText: node.Text, FromLevel: old.Level, ToLevel: node.Level, Nodes: 1}, 2*j + 1})
+		}
+	}
+
+	// Removals go after the last node before them that's in both versions
+	removed := map[int]int{}
+	for i, node := range d.Before {
+		if d.pair[i] >= 0 || node.Kind == KindBlank {
+			continue
+		}
+		if p := d.parentBefore[i]; p >= 0 && d.pair[p] < 0 {
+			if r, ok := removed[p]; ok {
+				changes[r].change.Nodes++
+				removed[i] = r
+			}
+			continue
+		}
+		at := 0
+		for k := i - 1; k >= 0; k-- {
+			if d.pair[k] >= 0 {
+				at = 2*d.pair[k] + 2
+				break
+			}
+		}
+		removed[i] = len(changes)
+		changes = append(changes, placed{OutlineChange{Kind: ChangeRemoved, Before: i, After: -1, Text: node.Text, FromLevel: node.Level, ToLevel: node.Level, Nodes: 1}, at})
+	}
+
+	sort.SliceStable(changes, func(a, b int) bool { return changes[a].at < changes[b].at })
+	for _, c := range changes {
+		d.Changes = append(d.Changes, c.change)
+	}
+}
+
+// Additive picks every change but removals: merging it keeps everything
+// either version has, taking the new version's text and places
+func (d *OutlineDiff) Additive() []bool {
+	take := make([]bool, len(d.Changes))
+	for i, c := range d.Changes {
+		take[i] = c.Kind != ChangeRemoved
+	}
+	return take
+}
+
+// Merge is the old version with the changes take picks applied, as content
+func (d *OutlineDiff) Merge(take []bool) string {
+	var result strings.Builder
+	for _, node := range d.merge(take) {
+		result.WriteString(formatNode(node) + "\n")
+	}
+	return result.String()
+}
+
+// mergeNode is a node of a merge in progress, with where it came from
+type mergeNode struct {
+	OutlineNode
+	before, after int // its indexes in the two versions, -1 for the one it isn't in
+}
+
+// merge applies the changes take picks to the old version's nodes
+func (d *OutlineDiff) merge(take []bool) []OutlineNode {
+	nodes := make([]mergeNode, len(d.Before))
+	for i, node := range d.Before {
+		nodes[i] = mergeNode{OutlineNode: node, before: i, after: d.pair[i]}
+	}
+	find := func(match func(mergeNode) bool) int {
+		for k, node := range nodes {
+			if match(node) {
+				return k
+			}
+		}
+		return -1
+	}
+	old := func(i int) int { return find(func(n mergeNode) bool { return n.before == i }) }
+	descends := func(i, root int) bool {
+		for p := d.parentBefore[i]; p >= 0; p = d.parentBefore[p] {
+			if p == root {
+				return true
+			}
+		}
+		return false
+	}
+	// family is the node at k and the nodes still under it that were under
+	// it in the old version; other nodes a level change left under it stay
+	family := func(k int) []int {
+		members := []int{k}
+		for n := k + 1; n < len(nodes) && nodes[n].Level > nodes[k].Level; n++ {
+			if nodes[n].before >= 0 && descends(nodes[n].before, nodes[k].before) {
+				members = append(members, n)
+			}
+		}
+		return members
+	}
+	shift := func(members []int, delta int) {
+		for _, k := range members {
+			nodes[k].Level = max(0, nodes[k].Level+delta)
+		}
+	}
+	// insert puts merged nodes where new node j lands: after the nearest
+	// node before it that's in the merge, past that node's deeper children
+	insert := func(j int, add []mergeNode) {
+		at := 0
+		for k := j - 1; k >= 0; k-- {
+			if n := find(func(n mergeNode) bool { return n.after == k }); n >= 0 {
+				at = n + 1
+				for at < len(nodes) && nodes[at].Level > d.After[j].Level {
+					at++
+				}
+				break
+			}
+		}
+		nodes = append(nodes[:at], append(add, nodes[at:]...)...)
+	}
+	picked := func(kind ChangeKind) []OutlineChange {
+		var changes []OutlineChange
+		for n, c := range d.Changes {
+			if c.Kind == kind && n < len(take) && take[n] {
+				changes = append(changes, c)
+			}
+		}
+		return changes
+	}
+
+	now := time.Now()
+	for _, c := range picked(ChangeEdited) {
+		node := &nodes[old(c.Before)]
+		node.addEdit(NodeEdit{Text: node.Text, At: node.ModifiedAt})
+		node.Text = d.After[c.After].Text
+		node.Mirror = d.After[c.After].Mirror
+		node.ModifiedAt = now
+		node.Captured = false
+	}
+	for _, c := range picked(ChangeRemoved) {
+		// The subtree's nodes that are only in the old version go; any
+		// matched elsewhere stay for their own changes
+		for i := c.Before; i < len(d.Before); i++ {
+			if i > c.Before && !descends(i, c.Before) {
+				break
+			}
+			if k := old(i); k >= 0 && d.pair[i] < 0 {
+				nodes = append(nodes[:k], nodes[k+1:]...)
+			}
+		}
+	}
+	for _, c := range picked(ChangeLevel) {
+		shift(family(old(c.Before)), c.ToLevel-c.FromLevel)
+	}
+
+	// Moves and additions in the new version's order, so each lands after
+	// what it follows there
+	var placed []OutlineChange
+	placed = append(placed, picked(ChangeMoved)...)
+	placed = append(placed, picked(ChangeAdded)...)
+	sort.SliceStable(placed, func(a, b int) bool { return placed[a].After < placed[b].After })
+	for _, c := range placed {
+		var add []mergeNode
+		if c.Kind == ChangeMoved {
+			members := family(old(c.Before))
+			shift(members, c.ToLevel-c.FromLevel)
+			for n := len(members) - 1; n >= 0; n-- {
+				k := members[n]
+				add = append([]mergeNode{nodes[k]}, add...)
+				nodes = append(nodes[:k], nodes[k+1:]...)
+			}
+		} else {
+			for j := c.After; j < len(d.After); j++ {
+				if j > c.After && d.After[j].Level <= d.After[c.After].Level {
+					break
+				}
+				if d.back[j] < 0 {
+					node := d.After[j]
+					node.ID = generateNodeID()
+					add = append(add, mergeNode{OutlineNode: node, before: -1, after: j})
+				}
+			}
+		}
+		insert(c.After, add)
+	}
+
+	merged := make([]OutlineNode, len(nodes))
+	for k, node := range nodes {
+		merged[k] = node.OutlineNode
+	}
+	markChildren(merged)
+	return merged
+}
+
+// ApplyMerge replaces the outline with d's merge of the changes take picks,
+// as one undo step, and returns how many changes it made; d must come from
+// this outline's DiffWith
+func (o *Outliner) ApplyMerge(d *OutlineDiff, take []bool) int {
+	n := 0
+	for i := range d.Changes {
+		if i < len(take) && take[i] {
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	o.saveUndo()
+	o.lines = d.merge(take)
+	if len(o.lines) == 0 {
+		o.lines = []OutlineNode{newNode("", 0)}
+	}
+	for i := range o.lines {
+		if o.lines[i].Kind.Captured() {
+			o.lines[i].PatternType = o.detectPatternType(o.lines[i].Text)
+		}
+	}
+	o.cursor = min(o.cursor, len(o.lines)-1)
+	o.cursorPos = min(o.cursorPos, len(o.lines[o.cursor].Text))
+	clear(o.linkRegistry)
+	for i := range o.lines {
+		o.refreshNodeLinks(i)
+	}
+	o.updateBacklinks()
+	o.structureChanged()
+	o.ClearRenderCache()
+	return n
+}
@@ -1,8 +1,11 @@
 package outliner
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 )
@@ -11,6 +14,7 @@ import (
 type DispatchAction struct {
 	ID          string            // Unique dispatch ID
 	NodeID      string            // Source node ID
+	Source      string            // File the source node is in, when known
 	Content     string            // Raw consciousness content
 	PatternType string            // ctx, eureka, dispatch, etc.
 	Imprint     string            // Ritual container (techcraft, feral_duality, etc.)
@@ -47,28 +51,51 @@ type ConsciousnessReducer struct {
 	Matcher func(action DispatchAction) bool // Function to match actions
 	Actions []DispatchAction                 // Collected actions
 	State   map[string]interface{}           // Computed state
+
+	// Windowed reducers ("from the last 7 days") also draw on history and
+	// are re-evaluated as their window slides
+	Windowed bool
+
+	// Prefetch, when set, readies Matcher for many actions at once, e.g.
+	// an exec matcher running its command over a batch
+	Prefetch func(actions []DispatchAction)
+
+	// Global reducers are defined in config rather than a document and
+	// collect from the whole dispatch log
+	Global bool
 }
 
-// ConsciousnessSelector computes derived state from reducers
+// ConsciousnessSelector computes derived state from reducers and other
+// selectors
 type ConsciousnessSelector struct {
 	Name      string                                          // Selector identifier
-	Inputs    []string                                        // Reducer names to use as input
+	Inputs    []string                                        // Reducer or selector names to use as input
 	Transform func(inputs map[string][]DispatchAction) string // Transformation function
 	Output    string                                          // Current computed output
+	Actions   []DispatchAction                                // Everything its inputs supplied, for selectors built on it
 }
 
-// ReducerUpdateCallback is called when a reducer collects a new action
-type ReducerUpdateCallback func(reducerName string, action DispatchAction)
-
 // FloatDispatchSystem is the core consciousness compiler
 type FloatDispatchSystem struct {
 	imprints  map[string]*Imprint
+	order     []string // imprint names in registration order, for routing
 	reducers  map[string]*ConsciousnessReducer
 	selectors map[string]*ConsciousnessSelector
-	actions   []DispatchAction
+	store     DispatchStore     // this session's actions
+	history   []DispatchAction  // persisted actions from earlier sessions
+	routes    map[string]string // pattern type -> imprint, from config
+	exec      ExecConfig        // exec reducer matchers, from config
+	source    string            // file actions are dispatched from, for provenance
+
+	// exec matchers by query, so redefining a reducer keeps its answers
+	execMatchers map[string]*ExecMatcher
 
-	// Callback for visual tree updates
-	onReducerUpdate ReducerUpdateCallback
+	// similarity reducers; nil embeddings leaves them undefined
+	embeddings *Embeddings
+	similarity float64
+
+	// where dispatches are announced; see Bus
+	bus *Bus
 
 	// Built-in imprints
 	techcraft       *Imprint
@@ -84,7 +111,12 @@ func NewFloatDispatchSystem() *FloatDispatchSystem {
 		imprints:  make(map[string]*Imprint),
 		reducers:  make(map[string]*ConsciousnessReducer),
 		selectors: make(map[string]*ConsciousnessSelector),
-		actions:   []DispatchAction{},
+		store:     NewMemoryStore(),
+		routes:    make(map[string]string),
+		exec:      DefaultExecConfig(),
+
+		execMatchers: make(map[string]*ExecMatcher),
+		bus:          NewBus(),
 	}
 
 	// Initialize built-in imprints
@@ -93,9 +125,43 @@ func NewFloatDispatchSystem() *FloatDispatchSystem {
 	return fds
 }
 
-// SetReducerUpdateCallback sets the callback for reducer updates
-func (fds *FloatDispatchSystem) SetReducerUpdateCallback(callback ReducerUpdateCallback) {
-	fds.onReducerUpdate = callback
+// SetStore keeps actions in store from now on, recomputing reducers from
+// the actions it already holds
+func (fds *FloatDispatchSystem) SetStore(store DispatchStore) {
+	fds.store = store
+	fds.RecomputeReducers()
+}
+
+// SetSource names the file actions are dispatched from from now on, so
+// what reducers collect can lead back to it
+func (fds *FloatDispatchSystem) SetSource(path string) {
+	fds.source = path
+}
+
+// Store returns where actions are kept
+func (fds *FloatDispatchSystem) Store() DispatchStore {
+	return fds.store
+}
+
+// Query returns the stored actions q selects, oldest first
+func (fds *FloatDispatchSystem) Query(q DispatchQuery) ([]DispatchAction, error) {
+	return fds.store.Query(q)
+}
+
+// sessionActions is every stored action; a store that can't be read is
+// logged and treated as empty
+func (fds *FloatDispatchSystem) sessionActions() []DispatchAction {
+	actions, err := fds.store.Query(DispatchQuery{})
+	if err != nil {
+		slog.Error("dispatch store unreadable", "err", err)
+	}
+	return actions
+}
+
+// Bus is where the system publishes ActionDispatched and ReducerCollected
+// events, for whatever reacts to dispatches to subscribe to
+func (fds *FloatDispatchSystem) Bus() *Bus {
+	return fds.bus
 }
 
 // initializeImprints sets up the core FLOAT imprints
@@ -141,23 +207,38 @@ func (fds *FloatDispatchSystem) initializeImprints() {
 	}
 
 	// Register all imprints
-	fds.imprints["techcraft"] = fds.techcraft
-	fds.imprints["ritual_computing"] = fds.ritualComputing
-	fds.imprints["feral_duality"] = fds.feralDuality
-	fds.imprints["dispatch_bay"] = fds.dispatchBay
-	fds.imprints["queer_hauntology"] = fds.queerHauntology
+	for _, imprint := range []*Imprint{fds.techcraft, fds.ritualComputing, fds.feralDuality, fds.dispatchBay, fds.queerHauntology} {
+		fds.imprints[imprint.Name] = imprint
+		fds.order = append(fds.order, imprint.Name)
+	}
 }
 
 // Dispatch processes a consciousness fragment through the FLOAT system
 func (fds *FloatDispatchSystem) Dispatch(nodeID, content, patternType string) *DispatchAction {
+	return fds.DispatchAt(nodeID, content, patternType, time.Now())
+}
+
+// DispatchAt dispatches a fragment captured at an earlier time, e.g. when
+// replaying a dispatch log
+func (fds *FloatDispatchSystem) DispatchAt(nodeID, content, patternType string, at time.Time) *DispatchAction {
+	return fds.DispatchWith(nodeID, content, patternType, nil, at)
+}
+
+// DispatchWith dispatches a fragment carrying metadata from outside the
+// outline, e.g. a Readwise highlight's book and author
+func (fds *FloatDispatchSystem) DispatchWith(nodeID, content, patternType string, metadata map[string]string, at time.Time) *DispatchAction {
 	action := DispatchAction{
 		ID:          generateDispatchID(),
 		NodeID:      nodeID,
+		Source:      fds.source,
 		Content:     content,
 		PatternType: patternType,
-		Timestamp:   time.Now(),
+		Timestamp:   at,
 		State:       StateCapture,
-		Metadata:    make(map[string]string),
+		Metadata:    make(map[string]string, len(metadata)),
+	}
+	for k, v := range metadata {
+		action.Metadata[k] = v
 	}
 
 	// Extract imprint and sigil from content
@@ -173,7 +254,9 @@ func (fds *FloatDispatchSystem) Dispatch(nodeID, content, patternType string) *D
 	action.State = StateDispatch
 
 	// Add to actions log
-	fds.actions = append(fds.actions, action)
+	if err := fds.store.Append(action); err != nil {
+		slog.Error("dispatch store append failed", "action", action.ID, "err", err)
+	}
 
 	// Update reducers
 	fds.updateReducers(action)
@@ -181,6 +264,7 @@ func (fds *FloatDispatchSystem) Dispatch(nodeID, content, patternType string) *D
 	// Update selectors
 	fds.updateSelectors()
 
+	Publish(fds.bus, ActionDispatched{Action: action})
 	return &action
 }
 
@@ -204,9 +288,14 @@ func (fds *FloatDispatchSystem) extractSigil(content string) string {
 
 // routeToImprint automatically routes consciousness to appropriate imprint
 func (fds *FloatDispatchSystem) routeToImprint(patternType string) string {
-	// Default routing logic based on pattern type
-	for name, imprint := range fds.imprints {
-		for _, filter := range imprint.Filters {
+	if name, ok := fds.routes[patternType]; ok {
+		return name
+	}
+
+	// Default routing logic based on pattern type; when several imprints
+	// filter for it, the first registered wins
+	for _, name := range fds.order {
+		for _, filter := range fds.imprints[name].Filters {
 			if filter == patternType {
 				return name
 			}
@@ -217,36 +306,304 @@ func (fds *FloatDispatchSystem) routeToImprint(patternType string) string {
 	return "dispatch_bay"
 }
 
+// ResetConfig drops the imprints, routes, exec and embeddings config
+// applied so far, keeping the built-in imprints and this session's
+// reducers, selectors and actions
+func (fds *FloatDispatchSystem) ResetConfig() {
+	fds.imprints = make(map[string]*Imprint)
+	fds.order = nil
+	fds.initializeImprints()
+	fds.routes = make(map[string]string)
+	fds.exec = DefaultExecConfig()
+	fds.execMatchers = make(map[string]*ExecMatcher)
+	fds.embeddings = nil
+	fds.similarity = 0
+}
+
+// SetImprintRoute sends a pattern type to an imprint, ahead of imprint
+// filters
+func (fds *FloatDispatchSystem) SetImprintRoute(patternType, imprint string) {
+	fds.routes[patternType] = imprint
+}
+
+// SetExecConfig configures reducers that match with external commands
+func (fds *FloatDispatchSystem) SetExecConfig(config ExecConfig) {
+	fds.exec = config
+	fds.execMatchers = make(map[string]*ExecMatcher)
+}
+
+// SetEmbeddings enables "similar to" reducers, matching actions whose
+// cosine similarity to the seed phrase reaches threshold
+func (fds *FloatDispatchSystem) SetEmbeddings(embeddings *Embeddings, threshold float64) {
+	fds.embeddings = embeddings
+	fds.similarity = threshold
+}
+
+// DefineReducer adds the reducer a reducer:: definition describes: a
+// natural-language query, "similar to <phrase>" to match by meaning when
+// embeddings are configured, or "exec ./match.sh" to match with a command
+// when exec matchers are enabled
+func (fds *FloatDispatchSystem) DefineReducer(name, query string) error {
+	if existing, ok := fds.reducers[name]; ok && existing.Global {
+		return fmt.Errorf("reducer %s is global, defined in [reducers.global]; give this one another name", name)
+	}
+	if _, _, ok := ParseSimilarityQuery(query); ok {
+		return fds.defineSimilarityReducer(name, query)
+	}
+	if _, _, ok := ParseExecQuery(query); !ok {
+		fds.AddReducer(name, query, ReducerMatcher(query))
+		return nil
+	}
+	if !fds.exec.Enabled {
+		return fmt.Errorf("reducer %s: exec matchers are disabled; set [reducers] exec = true to run %q", name, query)
+	}
+	matcher, ok := fds.execMatchers[query]
+	if !ok {
+		var err error
+		if matcher, err = NewExecMatcher(query, fds.exec); err != nil {
+			return fmt.Errorf("reducer %s: %w", name, err)
+		}
+		fds.execMatchers[query] = matcher
+	}
+	fds.addReducer(&ConsciousnessReducer{
+		Name:     name,
+		Query:    query,
+		Matcher:  matcher.Match,
+		Prefetch: matcher.Prefetch,
+	})
+	return nil
+}
+
+// defineSimilarityReducer adds a "similar to" reducer
+func (fds *FloatDispatchSystem) defineSimilarityReducer(name, query string) error {
+	if fds.embeddings == nil {
+		return fmt.Errorf("reducer %s: similarity matching needs an embeddings endpoint; set [reducers] embeddings_url", name)
+	}
+	matcher, err := NewSimilarityMatcher(query, fds.embeddings, fds.similarity)
+	if err != nil {
+		return fmt.Errorf("reducer %s: %w", name, err)
+	}
+	fds.addReducer(&ConsciousnessReducer{
+		Name:     name,
+		Query:    query,
+		Matcher:  matcher.Match,
+		Prefetch: matcher.Prefetch,
+		Windowed: matcher.windowed,
+	})
+	return nil
+}
+
 // AddReducer registers a new consciousness reducer
 func (fds *FloatDispatchSystem) AddReducer(name, query string, matcher func(DispatchAction) bool) {
-	reducer := &ConsciousnessReducer{
-		Name:    name,
-		Query:   query,
-		Matcher: matcher,
-		Actions: []DispatchAction{},
-		State:   make(map[string]interface{}),
+	_, windowed := ParseTimeWindow(query)
+	fds.addReducer(&ConsciousnessReducer{
+		Name:     name,
+		Query:    query,
+		Matcher:  matcher,
+		Windowed: windowed,
+	})
+}
+
+// PreviewReducer is what a natural-language reducer query would collect
+// now, and how many actions it looked at, without defining the reducer
+func (fds *FloatDispatchSystem) PreviewReducer(query string) (matched []DispatchAction, considered int) {
+	_, windowed := ParseTimeWindow(query)
+	reducer := &ConsciousnessReducer{Query: query, Matcher: ReducerMatcher(query), Windowed: windowed}
+	return fds.collect(reducer), len(fds.candidates(reducer))
+}
+
+// SetGlobalReducers replaces the reducers defined in config with queries,
+// by name. They run for every file and session, drawing on the dispatch
+// log as windowed reducers do. Definitions that fail are skipped and
+// returned together.
+func (fds *FloatDispatchSystem) SetGlobalReducers(queries map[string]string) error {
+	for name, reducer := range fds.reducers {
+		if reducer.Global {
+			delete(fds.reducers, name)
+		}
+	}
+
+	names := make([]string, 0, len(queries))
+	for name := range queries {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	fds.reducers[name] = reducer
+	var errs []error
+	for _, name := range names {
+		if err := fds.DefineReducer(name, queries[name]); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		reducer := fds.reducers[name]
+		reducer.Global, reducer.Windowed = true, true
+		reducer.Actions = fds.collect(reducer)
+	}
+	fds.updateSelectors()
+	return errors.Join(errs...)
+}
+
+// DryRunReducer is what a reducer query would collect from this session
+// and the whole dispatch log, windowed or not, without defining it
+func (fds *FloatDispatchSystem) DryRunReducer(query string) (matched []DispatchAction, considered int) {
+	reducer := &ConsciousnessReducer{Query: query, Matcher: ReducerMatcher(query), Windowed: true}
+	return fds.collect(reducer), len(fds.candidates(reducer))
+}
+
+// addReducer registers reducer and collects the actions it matches so far
+func (fds *FloatDispatchSystem) addReducer(reducer *ConsciousnessReducer) {
+	reducer.State = make(map[string]interface{})
+	fds.reducers[reducer.Name] = reducer
 
 	// Apply to existing actions
-	for _, action := range fds.actions {
-		if matcher(action) {
-			reducer.Actions = append(reducer.Actions, action)
+	reducer.Actions = fds.collect(reducer)
+}
+
+// collect returns the candidates reducer matches
+func (fds *FloatDispatchSystem) collect(reducer *ConsciousnessReducer) []DispatchAction {
+	candidates := fds.candidates(reducer)
+	if reducer.Prefetch != nil {
+		reducer.Prefetch(candidates)
+	}
+	collected := []DispatchAction{}
+	for _, action := range candidates {
+		if reducer.Matcher(action) {
+			collected = append(collected, action)
 		}
 	}
+	return collected
 }
 
-// AddSelector registers a new consciousness selector
-func (fds *FloatDispatchSystem) AddSelector(name string, inputs []string, transform func(map[string][]DispatchAction) string) {
+// LoadHistory sets the persisted actions windowed reducers draw on, then
+// recomputes reducers and selectors
+func (fds *FloatDispatchSystem) LoadHistory(actions []DispatchAction) {
+	fds.history = actions
+	fds.RecomputeReducers()
+}
+
+// RecomputeReducers re-evaluates every reducer from scratch, dropping
+// actions that have aged out of a time window
+func (fds *FloatDispatchSystem) RecomputeReducers() {
+	for _, reducer := range fds.reducers {
+		reducer.Actions = fds.collect(reducer)
+	}
+	fds.updateSelectors()
+}
+
+// candidates returns the actions a reducer considers: this session's, plus
+// history for windowed reducers, skipping history the session re-dispatched
+func (fds *FloatDispatchSystem) candidates(reducer *ConsciousnessReducer) []DispatchAction {
+	actions := fds.sessionActions()
+	if !reducer.Windowed || len(fds.history) == 0 {
+		return actions
+	}
+
+	live := make(map[string]bool, len(actions))
+	for _, action := range actions {
+		live[action.PatternType+"\x00"+action.Content] = true
+	}
+
+	candidates := make([]DispatchAction, 0, len(fds.history)+len(actions))
+	for _, action := range fds.history {
+		if !live[action.PatternType+"\x00"+action.Content] {
+			candidates = append(candidates, action)
+		}
+	}
+	return append(candidates, actions...)
+}
+
+// AddSelector registers a consciousness selector, replacing any of the same
+// name. Inputs name reducers or other selectors, a reducer winning when both
+// share a name. A selector that would depend on itself, directly or through
+// others, is rejected and the previous definition kept.
+func (fds *FloatDispatchSystem) AddSelector(name string, inputs []string, transform func(map[string][]DispatchAction) string) error {
 	selector := &ConsciousnessSelector{
 		Name:      name,
 		Inputs:    inputs,
 		Transform: transform,
 	}
 
+	previous, replacing := fds.selectors[name]
 	fds.selectors[name] = selector
-	fds.updateSelector(selector)
+	if _, err := fds.selectorOrder(); err != nil {
+		if replacing {
+			fds.selectors[name] = previous
+		} else {
+			delete(fds.selectors, name)
+		}
+		return err
+	}
+
+	// Selectors built on this one change with it
+	fds.updateSelectors()
+	return nil
+}
+
+// selectorDeps is the selectors a selector reads from
+func (fds *FloatDispatchSystem) selectorDeps(selector *ConsciousnessSelector) []string {
+	var deps []string
+	for _, input := range selector.Inputs {
+		if _, isReducer := fds.reducers[input]; isReducer {
+			continue
+		}
+		if _, isSelector := fds.selectors[input]; isSelector {
+			deps = append(deps, input)
+		}
+	}
+	return deps
+}
+
+// selectorOrder sorts selectors so each comes after the selectors it reads
+// from, or reports the first cycle found
+func (fds *FloatDispatchSystem) selectorOrder() ([]*ConsciousnessSelector, error) {
+	names := make([]string, 0, len(fds.selectors))
+	for name := range fds.selectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(names))
+	order := make([]*ConsciousnessSelector, 0, len(names))
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			start := 0
+			for path[start] != name {
+				start++
+			}
+			cycle := append(append([]string(nil), path[start:]...), name)
+			return fmt.Errorf("selector cycle: %s", strings.Join(cycle, " -> "))
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		selector := fds.selectors[name]
+		for _, dep := range fds.selectorDeps(selector) {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		order = append(order, selector)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
 }
 
 // updateReducers updates all reducers with new action
@@ -254,35 +611,102 @@ func (fds *FloatDispatchSystem) updateReducers(action DispatchAction) {
 	for name, reducer := range fds.reducers {
 		if reducer.Matcher(action) {
 			reducer.Actions = append(reducer.Actions, action)
+			Publish(fds.bus, ReducerCollected{Reducer: name, Action: action})
+		}
+	}
+}
 
-			// Notify visual tree of update
-			if fds.onReducerUpdate != nil {
-				fds.onReducerUpdate(name, action)
-			}
+// ResetActions clears dispatched actions and everything reducers
+// collected, for callers that re-dispatch a whole document; global
+// reducers keep what they collected from the dispatch log
+func (fds *FloatDispatchSystem) ResetActions() {
+	if err := fds.store.Reset(); err != nil {
+		slog.Error("dispatch store reset failed", "err", err)
+	}
+	for _, reducer := range fds.reducers {
+		reducer.Actions = nil
+		if reducer.Global {
+			reducer.Actions = fds.collect(reducer)
 		}
 	}
 }
 
-// updateSelectors updates all selectors
+// updateSelectors updates all selectors, each after the selectors it reads
 func (fds *FloatDispatchSystem) updateSelectors() {
-	for _, selector := range fds.selectors {
+	order, err := fds.selectorOrder()
+	if err != nil {
+		// AddSelector keeps cycles out, so this is a bug
+		slog.Error("selectors not updated", "err", err)
+		return
+	}
+	for _, selector := range order {
 		fds.updateSelector(selector)
 	}
 }
 
-// updateSelector updates a specific selector
+// updateSelector updates a specific selector; the selectors it reads must
+// be current
 func (fds *FloatDispatchSystem) updateSelector(selector *ConsciousnessSelector) {
 	inputs := make(map[string][]DispatchAction)
+	seen := make(map[string]bool)
+	var actions []DispatchAction
 
 	for _, inputName := range selector.Inputs {
+		var input []DispatchAction
 		if reducer, exists := fds.reducers[inputName]; exists {
-			inputs[inputName] = reducer.Actions
+			input = reducer.Actions
+		} else if upstream, exists := fds.selectors[inputName]; exists && upstream != selector {
+			input = upstream.Actions
+		} else {
+			continue
+		}
+		inputs[inputName] = input
+
+		for _, action := range input {
+			if !seen[action.ID] {
+				seen[action.ID] = true
+				actions = append(actions, action)
+			}
 		}
 	}
 
+	selector.Actions = actions
 	selector.Output = selector.Transform(inputs)
 }
 
+// RegisterImprint adds an imprint or replaces one with the same name.
+// Empty fields on an existing imprint keep their current values.
+func (fds *FloatDispatchSystem) RegisterImprint(imprint *Imprint) {
+	existing, ok := fds.imprints[imprint.Name]
+	if !ok {
+		if imprint.Metadata == nil {
+			imprint.Metadata = make(map[string]string)
+		}
+		fds.imprints[imprint.Name] = imprint
+		fds.order = append(fds.order, imprint.Name)
+		return
+	}
+
+	if imprint.Voice != "" {
+		existing.Voice = imprint.Voice
+	}
+	if imprint.Aesthetic != "" {
+		existing.Aesthetic = imprint.Aesthetic
+	}
+	if len(imprint.Filters) > 0 {
+		existing.Filters = imprint.Filters
+	}
+	for k, v := range imprint.Metadata {
+		existing.Metadata[k] = v
+	}
+}
+
+// ImprintNames lists imprints in registration order, the order routing
+// tries them
+func (fds *FloatDispatchSystem) ImprintNames() []string {
+	return append([]string(nil), fds.order...)
+}
+
 // GetImprint returns an imprint by name
 func (fds *FloatDispatchSystem) GetImprint(name string) *Imprint {
 	return fds.imprints[name]
@@ -296,6 +720,18 @@ func (fds *FloatDispatchSystem) GetReducerOutput(name string) []DispatchAction {
 	return []DispatchAction{}
 }
 
+// GetInputActions returns what a selector input supplies: a reducer's
+// collected actions, or everything another selector's inputs supplied
+func (fds *FloatDispatchSystem) GetInputActions(name string) []DispatchAction {
+	if reducer, exists := fds.reducers[name]; exists {
+		return reducer.Actions
+	}
+	if selector, exists := fds.selectors[name]; exists {
+		return selector.Actions
+	}
+	return nil
+}
+
 // GetSelectorOutput returns the current output of a selector
 func (fds *FloatDispatchSystem) GetSelectorOutput(name string) string {
 	if selector, exists := fds.selectors[name]; exists {
@@ -335,15 +771,9 @@ func (fds *FloatDispatchSystem) RenderDispatchSummary() string {
 	}
 
 	// Show recent dispatches
-	if len(fds.actions) > 0 {
+	if recent, _ := fds.store.Query(DispatchQuery{Limit: 5}); len(recent) > 0 {
 		summary.WriteString("📡 Recent Dispatches:\n")
-		recentCount := 5
-		if len(fds.actions) < recentCount {
-			recentCount = len(fds.actions)
-		}
-
-		for i := len(fds.actions) - recentCount; i < len(fds.actions); i++ {
-			action := fds.actions[i]
+		for _, action := range recent {
 			summary.WriteString(fmt.Sprintf("  • [%s] %s → %s\n",
 				action.PatternType,
 				action.Content[:min(50, len(action.Content))],
@@ -359,7 +789,17 @@ func (fds *FloatDispatchSystem) GetReducers() map[string]*ConsciousnessReducer {
 	return fds.reducers
 }
 
+// GetSelectors returns all selectors
+func (fds *FloatDispatchSystem) GetSelectors() map[string]*ConsciousnessSelector {
+	return fds.selectors
+}
+
 // GetActions returns all dispatched actions (for testing)
 func (fds *FloatDispatchSystem) GetActions() []DispatchAction {
-	return fds.actions
+	return fds.sessionActions()
+}
+
+// GetHistory returns actions loaded from the persisted dispatch log
+func (fds *FloatDispatchSystem) GetHistory() []DispatchAction {
+	return fds.history
 }
@@ -1,55 +1,25 @@
 package outliner
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 )
 
-// createReducerMatcher extracts the matcher logic for testing
-func createReducerMatcher(query string) func(DispatchAction) bool {
-	return func(action DispatchAction) bool {
-		content := strings.ToLower(action.Content)
-		queryLower := strings.ToLower(query)
-
-		// Parse query for keywords and pattern types
-		var keywords []string
-		if strings.Contains(queryLower, "about ") {
-			parts := strings.Split(queryLower, "about ")
-			if len(parts) > 1 {
-				keywords = strings.Fields(parts[1])
-			}
-		} else if strings.Contains(queryLower, "that mention ") {
-			parts := strings.Split(queryLower, "that mention ")
-			if len(parts) > 1 {
-				keywords = strings.Fields(parts[1])
-			}
-		}
-
-		// Check if content contains any of the keywords
-		for _, keyword := range keywords {
-			if strings.Contains(content, keyword) {
-				return true
-			}
-		}
-
-		// Legacy hardcoded patterns for backward compatibility
-		if strings.Contains(queryLower, "bridges") && action.PatternType == "bridge" {
-			return true
-		}
-		if strings.Contains(queryLower, "rangle") && strings.Contains(content, "rangle") {
-			return true
-		}
-
-		return false
-	}
-}
-
 func TestReducerMatching(t *testing.T) {
 	tests := []struct {
 		name          string
 		query         string
 		actionContent string
 		actionType    string
+		actionAge     time.Duration
 		shouldMatch   bool
 	}{
 		{
@@ -80,17 +50,48 @@ func TestReducerMatching(t *testing.T) {
 			actionType:    "bridge",
 			shouldMatch:   true,
 		},
+		{
+			name:          "pattern type filter",
+			query:         "collect all decisions about auth",
+			actionContent: "auth tokens expire",
+			actionType:    "eureka",
+			shouldMatch:   false,
+		},
+		{
+			name:          "inside time window",
+			query:         "collect all ctx from the last 7 days",
+			actionContent: "standup notes",
+			actionType:    "ctx",
+			actionAge:     3 * 24 * time.Hour,
+			shouldMatch:   true,
+		},
+		{
+			name:          "outside time window",
+			query:         "collect all ctx from the last 7 days",
+			actionContent: "standup notes",
+			actionType:    "ctx",
+			actionAge:     8 * 24 * time.Hour,
+			shouldMatch:   false,
+		},
+		{
+			name:          "possessive calendar window",
+			query:         "collect this week's eurekas",
+			actionContent: "found it",
+			actionType:    "eureka",
+			shouldMatch:   true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Test the matcher logic directly
-			matcher := createReducerMatcher(tt.query)
+			matcher := ReducerMatcher(tt.query)
 
 			// Create a test action
 			action := DispatchAction{
 				Content:     tt.actionContent,
 				PatternType: tt.actionType,
+				Timestamp:   time.Now().Add(-tt.actionAge),
 			}
 
 			// Test the matcher
@@ -114,10 +115,9 @@ func TestConsciousnessCapture(t *testing.T) {
 	outliner := New()
 	outliner.SetContent(content)
 
-	// Capture callback to track reducer updates
 	var collectedActions []DispatchAction
-	outliner.dispatch.SetReducerUpdateCallback(func(reducerName string, action DispatchAction) {
-		collectedActions = append(collectedActions, action)
+	Subscribe(outliner.dispatch.Bus(), func(e ReducerCollected) {
+		collectedActions = append(collectedActions, e.Action)
 	})
 
 	// Debug: Check content was set
@@ -173,3 +173,271 @@ func TestConsciousnessCapture(t *testing.T) {
 		}
 	}
 }
+
+func TestSelectorComposition(t *testing.T) {
+	fds := NewFloatDispatchSystem()
+	fds.AddReducer("wins", "collect all eurekas", ReducerMatcher("collect all eurekas"))
+	fds.AddReducer("calls", "collect all decisions", ReducerMatcher("collect all decisions"))
+
+	// Defined before the selector it reads; ordering catches up
+	if err := fds.AddSelector("weekly", []string{"daily", "calls"}, SelectorTransform("weekly summary")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fds.AddSelector("daily", []string{"wins", "calls"}, SelectorTransform("daily summary")); err != nil {
+		t.Fatal(err)
+	}
+
+	fds.Dispatch("n1", "selectors compose", "eureka")
+	fds.Dispatch("n2", "ship it", "decision")
+
+	weekly := fds.GetSelectors()["weekly"]
+	if len(weekly.Actions) != 2 {
+		t.Errorf("weekly has %d actions, want 2 (shared inputs counted once)", len(weekly.Actions))
+	}
+	if out := weekly.Output; !strings.Contains(out, "## From daily (2 items)") || !strings.Contains(out, "selectors compose") {
+		t.Errorf("weekly output:\n%s", out)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		inputs []string
+		want   string
+	}{
+		{"daily", []string{"weekly"}, "selector cycle: daily -> weekly -> daily"},
+		{"solo", []string{"solo"}, "selector cycle: solo -> solo"},
+	} {
+		err := fds.AddSelector(tc.name, tc.inputs, SelectorTransform(tc.name))
+		if err == nil || err.Error() != tc.want {
+			t.Errorf("AddSelector(%s, %v) = %v, want %q", tc.name, tc.inputs, err, tc.want)
+		}
+	}
+	if _, ok := fds.GetSelectors()["solo"]; ok {
+		t.Error("rejected selector was registered")
+	}
+	if got := fds.GetSelectors()["daily"].Inputs; strings.Join(got, ",") != "wins,calls" {
+		t.Errorf("daily inputs = %v, want the definition kept", got)
+	}
+}
+
+func TestGlobalReducers(t *testing.T) {
+	fds := NewFloatDispatchSystem()
+	fds.LoadHistory([]DispatchAction{{ID: "h1", Content: "keep the old api", PatternType: "decision", Timestamp: time.Now().Add(-30 * 24 * time.Hour)}})
+	if err := fds.SetGlobalReducers(map[string]string{"decisions": "collect all decisions"}); err != nil {
+		t.Fatal(err)
+	}
+	fds.Dispatch("n1", "ship it", "decision")
+
+	decisions := fds.GetReducers()["decisions"]
+	if !decisions.Global || len(decisions.Actions) != 2 {
+		t.Fatalf("global reducer collected %d actions, global %v", len(decisions.Actions), decisions.Global)
+	}
+
+	// A document re-dispatching itself doesn't lose the log's actions
+	fds.ResetActions()
+	if len(decisions.Actions) != 1 {
+		t.Errorf("after a reset, %d actions", len(decisions.Actions))
+	}
+	if err := fds.DefineReducer("decisions", "collect all eurekas"); err == nil {
+		t.Error("a document redefined a global reducer")
+	}
+
+	// New config replaces the old globals
+	if err := fds.SetGlobalReducers(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fds.GetReducers()["decisions"]; ok {
+		t.Error("a global reducer outlived its config")
+	}
+}
+
+func TestExecReducer(t *testing.T) {
+	dir := t.TempDir()
+	script := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	runs := filepath.Join(dir, "runs")
+	single := script("single.sh", "echo run >> "+runs+"\ngrep -q urgent\n")
+	batch := script("batch.sh", "echo run >> "+runs+"\ngrep urgent | sed 's/.*\"id\":\"\\([^\"]*\\)\".*/\\1/'\n")
+	slow := script("slow.sh", "sleep 5\n")
+
+	fds := NewFloatDispatchSystem()
+	if err := fds.DefineReducer("hot", "exec "+single); err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Fatalf("exec reducer while disabled: %v", err)
+	}
+
+	fds.SetExecConfig(ExecConfig{Enabled: true, Timeout: 200 * time.Millisecond, BatchSize: 10})
+	for _, content := range []string{"urgent: fix auth", "someday: tidy", "urgent: ship it"} {
+		fds.Dispatch("n", content, "ctx")
+	}
+	countRuns := func() int {
+		data, _ := os.ReadFile(runs)
+		os.Remove(runs)
+		return strings.Count(string(data), "run")
+	}
+
+	for _, tc := range []struct {
+		query string
+		runs  int
+	}{
+		{"exec " + single, 3},
+		{"exec --batch " + batch, 1},
+	} {
+		if err := fds.DefineReducer("hot", tc.query); err != nil {
+			t.Fatal(err)
+		}
+		if got := len(fds.GetReducerOutput("hot")); got != 2 {
+			t.Errorf("%s collected %d actions, want 2", tc.query, got)
+		}
+		if got := countRuns(); got != tc.runs {
+			t.Errorf("%s ran %d times, want %d", tc.query, got, tc.runs)
+		}
+
+		// Cached by content: recomputing doesn't rerun the command
+		fds.RecomputeReducers()
+		if got := countRuns(); got != 0 {
+			t.Errorf("%s reran %d times on recompute", tc.query, got)
+		}
+	}
+
+	if err := fds.DefineReducer("stuck", "exec "+slow); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(fds.GetReducerOutput("stuck")); got != 0 {
+		t.Errorf("timed-out matcher collected %d actions", got)
+	}
+}
+
+func TestSimilarityReducer(t *testing.T) {
+	// Vectors count a few words, so texts sharing them point the same way
+	words := []string{"auth", "token", "login", "coffee"}
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var req struct{ Input []string }
+		json.NewDecoder(r.Body).Decode(&req)
+		var resp embedResponse
+		for i, text := range req.Input {
+			v := make([]float32, len(words))
+			for j, word := range words {
+				v[j] = float32(strings.Count(strings.ToLower(text), word))
+			}
+			resp.Data = append(resp.Data, struct {
+				Index     int       `json:"index"`
+				Embedding []float32 `json:"embedding"`
+			}{i, v})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	fds := NewFloatDispatchSystem()
+	query := "collect all decisions similar to auth token login"
+	if err := fds.DefineReducer("auth", query); err == nil {
+		t.Fatal("similarity reducer defined without embeddings")
+	}
+
+	fds.SetEmbeddings(NewEmbeddings(&HTTPEmbedder{URL: srv.URL}, time.Second), 0.7)
+	fds.Dispatch("n1", "auth token refresh on login", "decision")
+	fds.Dispatch("n2", "more coffee", "decision")
+	fds.Dispatch("n3", "auth login flow", "eureka")
+	if err := fds.DefineReducer("auth", query); err != nil {
+		t.Fatal(err)
+	}
+
+	got := fds.GetReducerOutput("auth")
+	if len(got) != 1 || got[0].Content != "auth token refresh on login" {
+		t.Errorf("collected %+v", got)
+	}
+	if requests != 1 {
+		t.Errorf("%d embeddings requests, want 1 batch", requests)
+	}
+
+	fds.Dispatch("n4", "login token expiry for auth", "decision")
+	fds.RecomputeReducers()
+	if got := len(fds.GetReducerOutput("auth")); got != 2 {
+		t.Errorf("collected %d after a new decision, want 2", got)
+	}
+	if requests != 2 {
+		t.Errorf("%d embeddings requests, want cached vectors reused", requests)
+	}
+}
+
+func TestReducerUpdatesFlushInOrder(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	var content strings.Builder
+	content.WriteString("• reducer:: shipped collect all decisions\n")
+	for i := range 150 {
+		fmt.Fprintf(&content, "• decision:: ship %d\n", i)
+	}
+	o.SetContent(content.String())
+
+	// More than the old channel held before dropping, each in order
+	var collected []string
+	for msg := range runCmd(o.Flush()) {
+		if update, ok := msg.(ReducerUpdateMsg); ok && update.ReducerName == "shipped" {
+			collected = append(collected, update.Action.Content)
+		}
+	}
+	if len(collected) != 150 {
+		t.Fatalf("%d reducer updates, want 150", len(collected))
+	}
+	for i, got := range collected {
+		if want := fmt.Sprintf("ship %d", i); got != want {
+			t.Fatalf("update %d is %q, want %q", i, got, want)
+		}
+	}
+	if cmd := o.Flush(); cmd != nil {
+		t.Error("updates flushed twice")
+	}
+}
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	var got []string
+	Subscribe(bus, func(e ActionDispatched) { got = append(got, "first "+e.Action.Content) })
+	var stop func()
+	stop = Subscribe(bus, func(e ActionDispatched) {
+		got = append(got, "second "+e.Action.Content)
+		stop() // unsubscribing mid-publish
+	})
+	Subscribe(bus, func(e ReducerCollected) { got = append(got, "reducer "+e.Reducer) })
+	Subscribe(bus, func(e ActionDispatched) { got = append(got, "third "+e.Action.Content) })
+
+	Publish(bus, ActionDispatched{Action: DispatchAction{Content: "a"}})
+	Publish(bus, ActionDispatched{Action: DispatchAction{Content: "b"}})
+	Publish(bus, ReducerCollected{Reducer: "r"})
+	want := []string{"first a", "second a", "third a", "first b", "third b", "reducer r"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestPatternsCapturedOnBus(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	var dispatched []string
+	var captured []PatternsCaptured
+	Subscribe(o.dispatch.Bus(), func(e ActionDispatched) { dispatched = append(dispatched, e.Action.Content) })
+	Subscribe(o.dispatch.Bus(), func(e PatternsCaptured) { captured = append(captured, e) })
+
+	o.SetContent("• ctx:: on the bus\n• eureka:: subscribers!")
+	dispatched, captured = nil, nil
+	o.TriggerConsciousnessCapture()
+	if !slices.Equal(dispatched, []string{"on the bus", "subscribers!"}) {
+		t.Fatalf("dispatched %q", dispatched)
+	}
+	if len(captured) != 1 || len(captured[0].Patterns) != 2 || captured[0].Held {
+		t.Fatalf("captured %+v, want both patterns, not held", captured)
+	}
+	if captured[0].Source != "float-dispatch:manual_trigger" {
+		t.Errorf("source %q", captured[0].Source)
+	}
+	if !slices.ContainsFunc(o.debugPanel.messages, func(m DebugMessage) bool { return m.Type == "FLOAT_DISPATCH" }) {
+		t.Error("debug panel saw no dispatches")
+	}
+}
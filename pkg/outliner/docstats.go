@@ -0,0 +1,142 @@
+package outliner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DocumentStats are an outline's writing statistics, with archived
+// subtrees left out
+type DocumentStats struct {
+	Words      int            // in bullets, paragraphs and headings, without type:: markers or annotations
+	Nodes      int            // with text, code lines included
+	Patterns   map[string]int // pattern matches by type
+	Matches    int            // pattern matches of every type
+	Patterned  int            // nodes capture looks at that carry a pattern
+	Captured   int            // of those, the ones dispatched
+	AddedToday int            // nodes created today
+	Sections   []SectionStats // one per heading, in outline order
+}
+
+// SectionStats counts what's under a heading, up to the next heading at
+// its level or above
+type SectionStats struct {
+	Title string
+	Level int // the heading's #s
+	Words int // the heading's own included
+	Nodes int
+}
+
+// CaptureRatio is the share of pattern nodes dispatched, 0 without any
+func (s DocumentStats) CaptureRatio() float64 {
+	if s.Patterned == 0 {
+		return 0
+	}
+	return float64(s.Captured) / float64(s.Patterned)
+}
+
+// Density is how many patterns there are per 1,000 words
+func (s DocumentStats) Density() float64 {
+	if s.Words == 0 {
+		return 0
+	}
+	return float64(s.Matches) * 1000 / float64(s.Words)
+}
+
+// PatternTypes lists the types matched, most matched first
+func (s DocumentStats) PatternTypes() []string {
+	entries := sortedCounts(s.Patterns)
+	types := make([]string, len(entries))
+	for i, entry := range entries {
+		types[i] = entry.label
+	}
+	return types
+}
+
+// Summary is the stats on one line, as the debug panel shows them on save
+func (s DocumentStats) Summary() string {
+	parts := []string{fmt.Sprintf("%d words", s.Words), fmt.Sprintf("%d nodes", s.Nodes)}
+	if s.Patterned > 0 {
+		var types []string
+		for _, entry := range sortedCounts(s.Patterns) {
+			types = append(types, fmt.Sprintf("%d %s", entry.count, entry.label))
+		}
+		parts = append(parts,
+			fmt.Sprintf("patterns: %s", strings.Join(types, ", ")),
+			fmt.Sprintf("%.0f%% captured", s.CaptureRatio()*100))
+	}
+	if s.AddedToday > 0 {
+		parts = append(parts, fmt.Sprintf("%d added today", s.AddedToday))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// DocumentStats counts the outline's words, nodes and patterns as of now
+func (o *Outliner) DocumentStats(now time.Time) DocumentStats {
+	stats := DocumentStats{Patterns: map[string]int{}}
+	year, month, day := now.Date()
+	var open []int // sections the current node is in, by index
+
+	for _, node := range o.lines {
+		if o.archived[node.ID] || strings.TrimSpace(node.Text) == "" {
+			continue
+		}
+		stats.Nodes++
+		words := 0
+		if node.Kind != KindCode {
+			words = proseWords(node.Text)
+			stats.Words += words
+		}
+
+		if node.Kind == KindHeading {
+			level := len(node.Text) - len(strings.TrimLeft(node.Text, "#"))
+			for len(open) > 0 && stats.Sections[open[len(open)-1]].Level >= level {
+				open = open[:len(open)-1]
+			}
+			stats.Sections = append(stats.Sections, SectionStats{Title: strings.TrimSpace(node.Text[level:]), Level: level})
+			open = append(open, len(stats.Sections)-1)
+		}
+		for _, s := range open {
+			stats.Sections[s].Words += words
+			stats.Sections[s].Nodes++
+		}
+
+		if y, m, d := node.CreatedAt.Date(); y == year && m == month && d == day && node.CreatedAt.After(o.untimedAt) {
+			stats.AddedToday++
+		}
+
+		if !node.Kind.Captured() {
+			continue
+		}
+		matches := Patterns.Match(node.Text)
+		for _, match := range matches {
+			stats.Patterns[match.Type]++
+			stats.Matches++
+		}
+		if len(matches) > 0 && !isPrivate(node.Text) && node.Mirror == "" {
+			stats.Patterned++
+			if node.Captured {
+				stats.Captured++
+			}
+		}
+	}
+	return stats
+}
+
+// proseWords counts the words in a node's text, leaving out its task box,
+// [key:: value] annotations, type:: markers and marks like # or →
+func proseWords(text string) int {
+	end, _, _ := parseTask(text)
+	n := 0
+	for _, field := range strings.Fields(contextAnnotationRegex.ReplaceAllString(text[end:], " ")) {
+		if strings.HasSuffix(field, "::") || !strings.ContainsFunc(field, func(r rune) bool {
+			return unicode.IsLetter(r) || unicode.IsDigit(r)
+		}) {
+			continue
+		}
+		n++
+	}
+	return n
+}
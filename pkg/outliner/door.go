@@ -3,6 +3,9 @@ package outliner
 import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
 )
 
 // Door represents a pluggable interface that can be embedded in the outliner
@@ -32,7 +35,8 @@ type Door interface {
 	// SetState restores door from serialized state
 	SetState(state map[string]interface{})
 
-	// OnConsciousnessCapture is called when consciousness patterns are detected
+	// OnConsciousnessCapture is called with each capture's patterns while
+	// the door is open
 	OnConsciousnessCapture(patterns []ConsciousnessPattern)
 }
 
@@ -52,6 +56,10 @@ func NewDoorRegistry() *DoorRegistry {
 	registry.Register("repl", func() Door { return NewReplDoor() })
 	registry.Register("markdown", func() Door { return NewMarkdownDoor() })
 	registry.Register("consciousness", func() Door { return NewConsciousnessDoor() })
+	registry.Register("stats", func() Door { return NewStatsDoor() })
+	registry.Register("timeline", func() Door { return NewTimelineDoor() })
+	registry.Register("sandbox", func() Door { return NewSandboxDoor() })
+	registry.Register("graph", func() Door { return NewGraphDoor() })
 
 	return registry
 }
@@ -151,7 +159,7 @@ func (cd *ChatDoor) View(width, height int) string {
 		content += "> " + cd.input
 	}
 
-	return cd.style.Width(width - 4).Height(height - 4).Render(content)
+	return cells.Frame(cd.style, width).Height(height - 2).Render(content)
 }
 
 func (cd *ChatDoor) IsActive() bool { return cd.active }
@@ -196,7 +204,7 @@ func (rd *ReplDoor) Name() string                          { return "repl" }
 func (rd *ReplDoor) Init(params map[string]string) tea.Cmd { return nil }
 func (rd *ReplDoor) Update(msg tea.Msg) (Door, tea.Cmd)    { return rd, nil }
 func (rd *ReplDoor) View(width, height int) string {
-	return rd.style.Width(width - 4).Height(height - 4).Render("REPL Door - Coming Soon!")
+	return cells.Frame(rd.style, width).Height(height - 2).Render("REPL Door - Coming Soon!")
 }
 func (rd *ReplDoor) IsActive() bool                                         { return rd.active }
 func (rd *ReplDoor) Activate()                                              { rd.active = true }
@@ -221,7 +229,7 @@ func (md *MarkdownDoor) Name() string                          { return "markdow
 func (md *MarkdownDoor) Init(params map[string]string) tea.Cmd { return nil }
 func (md *MarkdownDoor) Update(msg tea.Msg) (Door, tea.Cmd)    { return md, nil }
 func (md *MarkdownDoor) View(width, height int) string {
-	return md.style.Width(width - 4).Height(height - 4).Render("Markdown Door - Coming Soon!")
+	return cells.Frame(md.style, width).Height(height - 2).Render(i18n.T("outliner.door.markdown"))
 }
 func (md *MarkdownDoor) IsActive() bool                                         { return md.active }
 func (md *MarkdownDoor) Activate()                                              { md.active = true }
@@ -246,7 +254,7 @@ func (cd *ConsciousnessDoor) Name() string                          { return "co
 func (cd *ConsciousnessDoor) Init(params map[string]string) tea.Cmd { return nil }
 func (cd *ConsciousnessDoor) Update(msg tea.Msg) (Door, tea.Cmd)    { return cd, nil }
 func (cd *ConsciousnessDoor) View(width, height int) string {
-	return cd.style.Width(width - 4).Height(height - 4).Render("Consciousness Door - Pattern Visualization Coming Soon!")
+	return cells.Frame(cd.style, width).Height(height - 2).Render(i18n.T("outliner.door.consciousness"))
 }
 func (cd *ConsciousnessDoor) IsActive() bool                                         { return cd.active }
 func (cd *ConsciousnessDoor) Activate()                                              { cd.active = true }
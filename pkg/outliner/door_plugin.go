@@ -0,0 +1,673 @@
+package outliner
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+)
+
+const (
+	// DefaultPluginTimeout is how long a door plugin may take to answer
+	DefaultPluginTimeout = 2 * time.Second
+
+	// pluginReplyLimit bounds one reply line, frames included
+	pluginReplyLimit = 1 << 20
+
+	// pluginStderrLimit is how much of a plugin's stderr a crash shows
+	pluginStderrLimit = 2048
+)
+
+// pluginEnv are the variables a door plugin inherits; the rest of the
+// environment, tokens included, stays with the outliner
+var pluginEnv = []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "TERM", "TMPDIR", "TZ"}
+
+// pluginRequest is a line the outliner writes to a door plugin's stdin:
+// "init", "key", "render", "capture", "save" or "close"
+type pluginRequest struct {
+	Type     string                 `json:"type"`
+	Width    int                    `json:"width,omitempty"`
+	Height   int                    `json:"height,omitempty"`
+	Params   map[string]string      `json:"params,omitempty"`   // init
+	State    map[string]interface{} `json:"state,omitempty"`    // init: what the last save returned
+	Key      string                 `json:"key,omitempty"`      // key: e.g. "a", "enter", "ctrl+n"
+	Patterns []pluginPattern        `json:"patterns,omitempty"` // capture
+}
+
+// pluginPattern is a captured pattern as plugins see it
+type pluginPattern struct {
+	Type    string            `json:"type"`
+	Content string            `json:"content"`
+	Line    int               `json:"line"`
+	Context map[string]string `json:"context,omitempty"`
+}
+
+// pluginReply is the line a plugin answers each request with. A frame
+// replaces what the door shows, state replaces what save keeps, and close
+// asks the outliner to close the door.
+type pluginReply struct {
+	Frame *string                `json:"frame"`
+	State map[string]interface{} `json:"state"`
+	Close bool                   `json:"close"`
+	Error string                 `json:"error"`
+
+	err error // the line couldn't be read
+}
+
+// DoorCloseMsg asks the app to close the open door
+type DoorCloseMsg struct{}
+
+// DiscoverDoorPlugins lists the executables in dir, sorted; a missing dir
+// has none. Hidden files and directories are skipped.
+func DiscoverDoorPlugins(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read door plugins: %w", err)
+	}
+	var paths []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.Mode()&0111 == 0 {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// PluginName is the door name a plugin registers as: its file name
+// without extension
+func PluginName(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// RegisterPlugins registers each plugin in dir as a door, giving each a
+// working directory under stateDir. Plugins can't replace built-in doors.
+// It returns the names registered.
+func (dr *DoorRegistry) RegisterPlugins(dir, stateDir string, timeout time.Duration) ([]string, error) {
+	paths, err := DiscoverDoorPlugins(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names, shadowed []string
+	for _, path := range paths {
+		name := PluginName(path)
+		if _, taken := dr.doors[name]; taken {
+			shadowed = append(shadowed, name)
+			continue
+		}
+		path := path
+		dr.Register(name, func() Door { return NewPluginDoor(name, path, filepath.Join(stateDir, name), timeout) })
+		names = append(names, name)
+	}
+	if len(shadowed) > 0 {
+		return names, fmt.Errorf("door plugins %s skipped: a door already has that name", strings.Join(shadowed, ", "))
+	}
+	return names, nil
+}
+
+// PluginReplyMsg carries a door plugin's reply, or why it stopped, back to
+// Update; the app hands it to the open door
+type PluginReplyMsg struct {
+	conn  *pluginConn
+	reply pluginReply
+	state map[string]interface{} // with init's reply: the state sent
+	err   error                  // the plugin stopped and was reaped
+}
+
+// PluginDoor is a door run by an external program speaking JSON lines
+// over stdin and stdout, one reply per request. The program runs in its
+// own working directory with a trimmed environment; if it crashes, hangs
+// past the timeout or answers garbage it's killed and the door shows why
+// until r restarts it. Requests are queued and sent off the UI goroutine,
+// their replies arriving as PluginReplyMsgs, so a slow plugin never holds
+// up the editor.
+type PluginDoor struct {
+	name    string
+	path    string
+	dir     string // working directory, holding state.json between sessions
+	timeout time.Duration
+
+	active bool
+	params map[string]string
+	state  map[string]interface{}
+	frame  string
+	width  int // size requests are rendered at
+	height int
+	err    error       // why the plugin stopped, nil while it runs
+	conn   *pluginConn // the plugin running, nil when stopped
+
+	style lipgloss.Style
+	dim   lipgloss.Style
+	fail  lipgloss.Style
+}
+
+// NewPluginDoor creates a door for the plugin at path; nothing runs until
+// Init
+func NewPluginDoor(name, path, dir string, timeout time.Duration) *PluginDoor {
+	if timeout <= 0 {
+		timeout = DefaultPluginTimeout
+	}
+	return &PluginDoor{
+		name:    name,
+		path:    path,
+		dir:     dir,
+		timeout: timeout,
+		style:   lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1),
+		dim:     lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
+		fail:    lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true),
+	}
+}
+
+func (pd *PluginDoor) Name() string { return pd.name }
+
+// Init starts the plugin, which is sent params and the state it last
+// saved
+func (pd *PluginDoor) Init(params map[string]string) tea.Cmd {
+	pd.params = params
+	return pd.start()
+}
+
+// start runs the plugin, queues init and listens for replies
+func (pd *PluginDoor) start() tea.Cmd {
+	pd.err = nil
+	conn, err := startPlugin(pd.name, pd.path, pd.dir, pd.timeout)
+	if err != nil {
+		pd.err = err
+		return nil
+	}
+	pd.conn = conn
+	go conn.run(pluginRequest{Type: "init", Width: pd.width, Height: pd.height, Params: pd.params, State: pd.state})
+	return conn.listen()
+}
+
+func (pd *PluginDoor) Update(msg tea.Msg) (Door, tea.Cmd) {
+	switch msg := msg.(type) {
+	case PluginReplyMsg:
+		return pd, pd.receive(msg)
+	case tea.WindowSizeMsg:
+		if msg.Width != pd.width || msg.Height != pd.height {
+			pd.width, pd.height = msg.Width, msg.Height
+			pd.request(pluginRequest{Type: "render", Width: pd.width, Height: pd.height})
+		}
+	case tea.KeyMsg:
+		if !pd.active {
+			return pd, nil
+		}
+		if pd.conn == nil {
+			if msg.String() == "r" {
+				return pd, pd.start()
+			}
+			return pd, nil
+		}
+		pd.request(pluginRequest{Type: "key", Key: msg.String(), Width: pd.width, Height: pd.height})
+	}
+	return pd, nil
+}
+
+// request queues a request for the running plugin, if any
+func (pd *PluginDoor) request(req pluginRequest) {
+	if pd.conn != nil {
+		pd.conn.enqueue(req)
+	}
+}
+
+// receive applies a reply and listens for the next one
+func (pd *PluginDoor) receive(msg PluginReplyMsg) tea.Cmd {
+	if msg.conn == nil || msg.conn != pd.conn {
+		// From a run since stopped or restarted
+		return nil
+	}
+	if msg.err != nil {
+		pd.conn, pd.err = nil, msg.err
+		return nil
+	}
+	if msg.state != nil && pd.state == nil {
+		pd.state = msg.state
+	}
+	reply := msg.reply
+	if reply.Frame != nil {
+		pd.frame = *reply.Frame
+	}
+	if reply.State != nil {
+		pd.state = reply.State
+	}
+	if reply.Error != "" {
+		pd.frame = pd.fail.Render(reply.Error) + "\n" + pd.frame
+	}
+	if reply.Close {
+		return func() tea.Msg { return DoorCloseMsg{} }
+	}
+	return pd.conn.listen()
+}
+
+func (pd *PluginDoor) View(width, height int) string {
+	var b strings.Builder
+	if pd.err != nil {
+		b.WriteString(pd.fail.Render(fmt.Sprintf("door %s stopped: %v", pd.name, pd.err)))
+		b.WriteString("\n" + pd.dim.Render("r restart · Esc close") + "\n\n")
+	}
+	b.WriteString(pd.frame)
+	return cells.Frame(pd.style, width).Height(height - 2).MaxHeight(height).Render(strings.TrimRight(b.String(), "\n"))
+}
+
+func (pd *PluginDoor) IsActive() bool { return pd.active }
+func (pd *PluginDoor) Activate()      { pd.active = true }
+
+// Deactivate asks the plugin to save and close, keeping what it saved for
+// the next session. That goes on after the door closes: the next session
+// waits for it before starting from the saved state.
+func (pd *PluginDoor) Deactivate() {
+	pd.active = false
+	if pd.conn != nil {
+		pd.conn.shutdown()
+		pd.conn = nil
+		return
+	}
+	if err := saveState(pd.dir, pd.state); err != nil {
+		slog.Warn("door plugin state", "door", pd.name, "err", err)
+	}
+}
+
+// GetState is the state from the plugin's last reply
+func (pd *PluginDoor) GetState() map[string]interface{} {
+	return pd.state
+}
+
+func (pd *PluginDoor) SetState(state map[string]interface{}) {
+	pd.state = state
+}
+
+func (pd *PluginDoor) OnConsciousnessCapture(patterns []ConsciousnessPattern) {
+	req := pluginRequest{Type: "capture", Width: pd.width, Height: pd.height}
+	for _, p := range patterns {
+		req.Patterns = append(req.Patterns, pluginPattern{Type: p.Type, Content: p.Content, Line: p.Line, Context: p.Context})
+	}
+	pd.request(req)
+}
+
+// pluginRuns is the latest run of each plugin working directory, so a
+// session waits for the last one to save before starting
+var pluginRuns sync.Map
+
+// WaitDoorPlugins waits for the door plugins closing to save their state,
+// so quitting right after closing a door doesn't lose it
+func WaitDoorPlugins() {
+	pluginRuns.Range(func(_, run interface{}) bool {
+		c := run.(*pluginConn)
+		c.mu.Lock()
+		closing := c.closing
+		c.mu.Unlock()
+		if closing {
+			<-c.done
+		}
+		return true
+	})
+}
+
+// pluginConn is one run of a plugin. Its goroutine sends the queued
+// requests one at a time, each waiting up to the timeout to be written
+// and answered, and delivers the replies in order to the door's listener.
+type pluginConn struct {
+	name    string
+	dir     string
+	timeout time.Duration
+
+	proc    *exec.Cmd
+	stdin   io.WriteCloser
+	replies chan pluginReply
+	stderr  *tailBuffer
+	prev    *pluginConn // the run before in the same directory
+
+	mu      sync.Mutex
+	queue   []pluginRequest
+	closing bool          // shutdown asked for: save and close once the queue is sent
+	wake    chan struct{} // something was queued or shutdown asked for
+
+	results    chan PluginReplyMsg
+	detached   chan struct{} // the door stopped listening
+	detachOnce sync.Once
+	done       chan struct{} // the plugin was reaped and its state saved
+
+	state map[string]interface{} // the run's goroutine's: last sent or answered
+}
+
+// startPlugin runs the plugin at path in dir
+func startPlugin(name, path, dir string, timeout time.Duration) (*pluginConn, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("working directory: %w", err)
+	}
+
+	proc := exec.Command(path)
+	proc.Dir = dir
+	proc.Env = []string{"FLOAT_DOOR_NAME=" + name, "FLOAT_DOOR_DIR=" + dir}
+	for _, key := range pluginEnv {
+		if value, ok := os.LookupEnv(key); ok {
+			proc.Env = append(proc.Env, key+"="+value)
+		}
+	}
+	stderr := &tailBuffer{limit: pluginStderrLimit}
+	proc.Stderr = stderr
+	// Children the plugin started may hold its output open after it exits
+	proc.WaitDelay = 100 * time.Millisecond
+	stdin, err := proc.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := proc.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := proc.Start(); err != nil {
+		return nil, fmt.Errorf("start: %w", err)
+	}
+
+	c := &pluginConn{
+		name:     name,
+		dir:      dir,
+		timeout:  timeout,
+		proc:     proc,
+		stdin:    stdin,
+		replies:  make(chan pluginReply),
+		stderr:   stderr,
+		wake:     make(chan struct{}, 1),
+		results:  make(chan PluginReplyMsg),
+		detached: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if prev, ok := pluginRuns.Swap(dir, c); ok {
+		c.prev = prev.(*pluginConn)
+	}
+	go readPluginReplies(stdout, c.replies)
+	return c, nil
+}
+
+// readPluginReplies decodes reply lines until the plugin's stdout closes
+func readPluginReplies(r io.Reader, replies chan<- pluginReply) {
+	defer close(replies)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), pluginReplyLimit)
+	for scanner.Scan() {
+		var reply pluginReply
+		if err := json.Unmarshal(scanner.Bytes(), &reply); err != nil {
+			reply.err = fmt.Errorf("bad reply: %w", err)
+		}
+		replies <- reply
+	}
+	if err := scanner.Err(); err != nil {
+		replies <- pluginReply{err: fmt.Errorf("bad reply: %w", err)}
+	}
+}
+
+// run sends init with the state the last run saved, then each queued
+// request, until shutdown or the plugin fails
+func (c *pluginConn) run(init pluginRequest) {
+	defer close(c.done)
+	if c.prev != nil {
+		<-c.prev.done
+		c.prev = nil
+	}
+	if init.State == nil {
+		init.State = loadState(c.dir)
+	}
+	c.state = init.State
+
+	req, sent := init, init.State
+	for {
+		reply, err := c.call(req)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		if reply.State != nil {
+			c.state = reply.State
+		}
+		c.deliver(PluginReplyMsg{conn: c, reply: reply, state: sent})
+		sent = nil
+
+		var ok bool
+		if req, ok = c.next(); !ok {
+			c.finish()
+			return
+		}
+	}
+}
+
+// enqueue queues a request without waiting on the plugin
+func (c *pluginConn) enqueue(req pluginRequest) {
+	c.mu.Lock()
+	c.queue = append(c.queue, req)
+	c.mu.Unlock()
+	c.notify()
+}
+
+// shutdown has the run save and close the plugin once the requests queued
+// are sent; replies from then on go nowhere
+func (c *pluginConn) shutdown() {
+	c.mu.Lock()
+	c.closing = true
+	c.mu.Unlock()
+	c.detachOnce.Do(func() { close(c.detached) })
+	c.notify()
+}
+
+func (c *pluginConn) notify() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next waits for the next queued request; ok is false once the queue is
+// sent and shutdown was asked for
+func (c *pluginConn) next() (req pluginRequest, ok bool) {
+	for {
+		c.mu.Lock()
+		if len(c.queue) > 0 {
+			req, c.queue = c.queue[0], c.queue[1:]
+			c.mu.Unlock()
+			return req, true
+		}
+		closing := c.closing
+		c.mu.Unlock()
+		if closing {
+			return pluginRequest{}, false
+		}
+		<-c.wake
+	}
+}
+
+// listen waits for the run's next reply
+func (c *pluginConn) listen() tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case msg := <-c.results:
+			return msg
+		case <-c.detached:
+			return nil
+		}
+	}
+}
+
+// deliver hands a reply to the door's listener, or drops it once the door
+// has stopped listening
+func (c *pluginConn) deliver(msg PluginReplyMsg) {
+	select {
+	case c.results <- msg:
+	case <-c.detached:
+	}
+}
+
+// call sends a request and waits for its reply, up to the timeout
+func (c *pluginConn) call(req pluginRequest) (pluginReply, error) {
+	timeout := time.NewTimer(c.timeout)
+	defer timeout.Stop()
+	if err := c.write(req, timeout.C); err != nil {
+		return pluginReply{}, err
+	}
+
+	select {
+	case reply, ok := <-c.replies:
+		switch {
+		case !ok:
+			return pluginReply{}, errors.New("exited")
+		case reply.err != nil:
+			return pluginReply{}, reply.err
+		}
+		return reply, nil
+	case <-timeout.C:
+		return pluginReply{}, fmt.Errorf("no answer to %s within %s", req.Type, c.timeout)
+	}
+}
+
+// write sends a request line, giving up at timeout: a plugin that stops
+// reading would block the write for good
+func (c *pluginConn) write(req pluginRequest, timeout <-chan time.Time) error {
+	line, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	written := make(chan error, 1)
+	go func() {
+		_, err := c.stdin.Write(append(line, '\n'))
+		written <- err
+	}()
+	select {
+	case err := <-written:
+		if err != nil {
+			return fmt.Errorf("write %s: %w", req.Type, err)
+		}
+		return nil
+	case <-timeout:
+		return fmt.Errorf("%s not read within %s", req.Type, c.timeout)
+	}
+}
+
+// fail stops the plugin and tells the door why, with what it last wrote
+// to stderr
+func (c *pluginConn) fail(err error) {
+	c.stop()
+	if msg := strings.TrimSpace(c.stderr.String()); msg != "" {
+		err = fmt.Errorf("%w: %s", err, msg)
+	}
+	c.saveState()
+	c.deliver(PluginReplyMsg{conn: c, err: err})
+}
+
+// finish asks the plugin to save and close, then keeps what it saved
+func (c *pluginConn) finish() {
+	if reply, err := c.call(pluginRequest{Type: "save"}); err == nil {
+		if reply.State != nil {
+			c.state = reply.State
+		}
+		// close gets no answer: the plugin exits, or is killed after the timeout
+		c.write(pluginRequest{Type: "close"}, time.After(c.timeout))
+	}
+	c.stop()
+	c.saveState()
+}
+
+// stop closes the plugin's stdin and reaps it, killing it if it hasn't
+// exited within the timeout
+func (c *pluginConn) stop() {
+	c.stdin.Close()
+	exited := make(chan struct{})
+	go func() {
+		c.proc.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(c.timeout):
+		c.proc.Process.Kill()
+		<-exited
+	}
+	// Drain replies so the reader can finish
+	for range c.replies {
+	}
+}
+
+// saveState keeps the run's last state for the plugin's next session
+func (c *pluginConn) saveState() {
+	if err := saveState(c.dir, c.state); err != nil {
+		slog.Warn("door plugin state", "door", c.name, "err", err)
+	}
+}
+
+// statePath is where a plugin working in dir has its saved state kept
+func statePath(dir string) string {
+	return filepath.Join(dir, "state.json")
+}
+
+// loadState reads the state the plugin saved last session, if any
+func loadState(dir string) map[string]interface{} {
+	data, err := os.ReadFile(statePath(dir))
+	if err != nil {
+		return nil
+	}
+	var state map[string]interface{}
+	if json.Unmarshal(data, &state) != nil {
+		return nil
+	}
+	return state
+}
+
+// saveState keeps a plugin's state for its next session
+func saveState(dir string, state map[string]interface{}) error {
+	if state == nil {
+		return nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(statePath(dir), data, 0644); err != nil {
+		return fmt.Errorf("save state: %w", err)
+	}
+	return nil
+}
+
+// tailBuffer keeps the last limit bytes written to it
+type tailBuffer struct {
+	mu    sync.Mutex
+	limit int
+	data  []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.data = append(t.data, p...)
+	if len(t.data) > t.limit {
+		t.data = t.data[len(t.data)-t.limit:]
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.data)
+}
@@ -0,0 +1,253 @@
+package outliner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+)
+
+// editHistoryLimit bounds how many earlier versions a node keeps
+const editHistoryLimit = 20
+
+var (
+	diffDeleteStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Strikethrough(true)
+	diffInsertStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Bold(true)
+)
+
+// NodeEdit is an earlier version of a node's text and when it was written
+type NodeEdit struct {
+	Text string
+	At   time.Time
+}
+
+// editBase is the node under the cursor and its text when the cursor got
+// there; leaving the node changed makes that text a version
+type editBase struct {
+	node string
+	edit NodeEdit
+}
+
+// baseAt records node i as the one being edited
+func (o *Outliner) baseAt(i int) {
+	if i >= len(o.lines) {
+		o.editBase = editBase{}
+		return
+	}
+	at := o.lines[i].ModifiedAt
+	if at.IsZero() {
+		at = o.lines[i].CreatedAt
+	}
+	o.editBase = editBase{node: o.lines[i].ID, edit: NodeEdit{Text: o.lines[i].Text, At: at}}
+}
+
+// recordEdit adds the edited node's earlier text to its history once the
+// cursor has moved off it, so a burst of typing is one version
+func (o *Outliner) recordEdit() {
+	if o.cursor < len(o.lines) && o.lines[o.cursor].ID == o.editBase.node {
+		return
+	}
+	if i := o.nodeIndex(o.editBase.node); i >= 0 && o.lines[i].Text != o.editBase.edit.Text && o.lines[i].Mirror == "" {
+		o.lines[i].addEdit(o.editBase.edit)
+	}
+	o.baseAt(o.cursor)
+}
+
+// addEdit appends an earlier version, dropping the oldest past the limit
+func (node *OutlineNode) addEdit(edit NodeEdit) {
+	node.History = append(node.History, edit)
+	if len(node.History) > editHistoryLimit {
+		node.History = node.History[len(node.History)-editHistoryLimit:]
+	}
+}
+
+// Versions returns a node's texts oldest first, ending with the current one
+func (o *Outliner) Versions(nodeID string) []NodeEdit {
+	i := o.nodeIndex(nodeID)
+	if i < 0 {
+		return nil
+	}
+	node := o.lines[i]
+	versions := append([]NodeEdit(nil), node.History...)
+	if i == o.cursor && node.ID == o.editBase.node && node.Text != o.editBase.edit.Text {
+		versions = append(versions, o.editBase.edit) // not recorded until the cursor leaves
+	}
+	return append(versions, NodeEdit{Text: node.Text, At: node.ModifiedAt})
+}
+
+// DiffOp is a run of words a diff keeps (' '), deletes ('-') or inserts ('+')
+type DiffOp struct {
+	Op   byte
+	Text string
+}
+
+// WordDiff diffs two texts word by word, by longest common subsequence
+func WordDiff(old, new string) []DiffOp {
+	a, b := strings.Fields(old), strings.Fields(new)
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []DiffOp
+	add := func(op byte, word string) {
+		if n := len(ops); n > 0 && ops[n-1].Op == op {
+			ops[n-1].Text += " " + word
+			return
+		}
+		ops = append(ops, DiffOp{Op: op, Text: word})
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			add(' ', a[i])
+			i, j = i+1, j+1
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			add('+', b[j])
+			j++
+		default:
+			add('-', a[i])
+			i++
+		}
+	}
+	return ops
+}
+
+// renderDiff styles a word diff: deletions struck through, insertions bold
+func renderDiff(ops []DiffOp) string {
+	parts := make([]string, len(ops))
+	for i, op := range ops {
+		switch op.Op {
+		case '-':
+			parts[i] = diffDeleteStyle.Render(op.Text)
+		case '+':
+			parts[i] = diffInsertStyle.Render(op.Text)
+		default:
+			parts[i] = op.Text
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// editHistoryView lists a node's versions, newest first, and diffs the
+// selected one against the version after it
+type editHistoryView struct {
+	node     string
+	selected int // index into Versions, counted from the newest
+}
+
+// IsEditHistoryOpen reports whether the node history popup is showing
+func (o *Outliner) IsEditHistoryOpen() bool {
+	return o.editHistory != nil
+}
+
+// toggleEditHistory opens the history of the node under the cursor
+func (o *Outliner) toggleEditHistory() {
+	if o.editHistory != nil || o.cursor >= len(o.lines) {
+		o.editHistory = nil
+		return
+	}
+	o.editHistory = &editHistoryView{node: o.lines[o.cursor].ID}
+}
+
+// updateEditHistory moves through the versions; r restores the selected one
+func (o *Outliner) updateEditHistory(msg tea.KeyMsg) {
+	view := o.editHistory
+	versions := o.Versions(view.node)
+	switch msg.String() {
+	case "up", "k", "ctrl+p":
+		view.selected = max(0, view.selected-1)
+	case "down", "j", "ctrl+n":
+		view.selected = min(len(versions)-1, view.selected+1)
+	case "r", "enter":
+		i := o.nodeIndex(view.node)
+		if i < 0 || view.selected == 0 {
+			break
+		}
+		o.setNodeText(i, versions[len(versions)-1-view.selected].Text)
+		o.cursorPos = min(o.cursorPos, len(o.lines[o.cursor].Text))
+		o.baseAt(o.cursor)
+		o.editHistory = nil
+	case "esc", "q", "alt+t":
+		o.editHistory = nil
+	}
+}
+
+// recordEditNow records node i's pending edit, if any, and its current text
+// as versions before it's overwritten
+func (o *Outliner) recordEditNow(i int) {
+	line := &o.lines[i]
+	if line.ID == o.editBase.node && line.Text != o.editBase.edit.Text {
+		line.addEdit(o.editBase.edit)
+	}
+	line.addEdit(NodeEdit{Text: line.Text, At: line.ModifiedAt})
+}
+
+// setNodeText rewrites node i's text outside typing: its old text becomes
+// a version, and its pattern, links and modified time follow the new text
+func (o *Outliner) setNodeText(i int, text string) {
+	line := &o.lines[i]
+	if i == o.cursor {
+		o.recordEditNow(i)
+	} else {
+		line.addEdit(NodeEdit{Text: line.Text, At: line.ModifiedAt})
+	}
+	line.Text = text
+	line.ModifiedAt = time.Now()
+	line.PatternType = o.detectPatternType(text)
+	line.Captured = false
+	o.updateNodeLinks(i)
+	// Leaving the node later mustn't record the old text a second time
+	if line.ID == o.editBase.node {
+		o.baseAt(i)
+	}
+}
+
+// renderEditHistory renders the version list and the selected diff in
+// rows rows
+func (o *Outliner) renderEditHistory(rows int) string {
+	view := o.editHistory
+	versions := o.Versions(view.node)
+	if len(versions) == 0 {
+		return i18n.T("outliner.edit_history.deleted")
+	}
+
+	var b strings.Builder
+	b.WriteString(i18n.N("outliner.edit_history.title", len(versions)))
+	listRows := max(1, rows-3)
+	start := max(0, min(view.selected-listRows/2, len(versions)-listRows))
+	for r := start; r < len(versions) && r < start+listRows; r++ {
+		v := versions[len(versions)-1-r]
+		label := cells.Pad(i18n.T("outliner.edit_history.now"), 5)
+		if r > 0 {
+			label = v.At.Format("15:04")
+		}
+		text := fmt.Sprintf("  %s  %s", label, v.Text)
+		if r == view.selected {
+			text = o.highlightStyle.Render(text)
+		}
+		b.WriteString("\n" + text)
+	}
+
+	if view.selected > 0 {
+		older := versions[len(versions)-1-view.selected]
+		newer := versions[len(versions)-view.selected]
+		b.WriteString("\n\n  " + renderDiff(WordDiff(older.Text, newer.Text)))
+	}
+	return b.String()
+}
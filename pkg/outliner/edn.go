@@ -0,0 +1,192 @@
+package outliner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ednKeyword is an EDN :keyword, without its colon
+type ednKeyword string
+
+// ednMap is an EDN map's key/value pairs in the order written; keys can be
+// vectors, which a Go map can't hold
+type ednMap [][2]any
+
+// get returns the value under keyword key
+func (m ednMap) get(key string) any {
+	for _, pair := range m {
+		if k, ok := pair[0].(ednKeyword); ok && string(k) == key {
+			return pair[1]
+		}
+	}
+	return nil
+}
+
+// ednReader reads the EDN Roam's export is written in: maps, vectors,
+// lists and sets (all but maps read as []any), strings, numbers, keywords,
+// symbols, characters and tagged values, whose tag is dropped
+type ednReader struct {
+	s   string
+	pos int
+}
+
+// parseEDN reads the first value in data
+func parseEDN(data string) (any, error) {
+	r := &ednReader{s: data}
+	return r.value()
+}
+
+func (r *ednReader) value() (any, error) {
+	r.skip()
+	if r.pos >= len(r.s) {
+		return nil, fmt.Errorf("parse EDN: unexpected end of input")
+	}
+	switch c := r.s[r.pos]; c {
+	case '{':
+		r.pos++
+		items, err := r.seq('}')
+		if err != nil {
+			return nil, err
+		}
+		if len(items)%2 != 0 {
+			return nil, fmt.Errorf("parse EDN: map with a key and no value at %d", r.pos)
+		}
+		m := make(ednMap, 0, len(items)/2)
+		for i := 0; i < len(items); i += 2 {
+			m = append(m, [2]any{items[i], items[i+1]})
+		}
+		return m, nil
+	case '[', '(':
+		r.pos++
+		return r.seq(map[byte]byte{'[': ']', '(': ')'}[c])
+	case '#':
+		r.pos++
+		if r.pos < len(r.s) && r.s[r.pos] == '{' {
+			r.pos++
+			return r.seq('}')
+		}
+		if r.pos < len(r.s) && r.s[r.pos] == '_' {
+			// #_ discards the value after it
+			r.pos++
+			if _, err := r.value(); err != nil {
+				return nil, err
+			}
+			return r.value()
+		}
+		r.token() // the tag
+		return r.value()
+	case '"':
+		return r.str()
+	case ':':
+		r.pos++
+		return ednKeyword(r.token()), nil
+	case '\\':
+		r.pos++
+		_, size := utf8.DecodeRuneInString(r.s[r.pos:])
+		r.pos += size
+		return r.s[r.pos-size:r.pos] + r.token(), nil
+	case '}', ']', ')':
+		return nil, fmt.Errorf("parse EDN: unexpected %q at %d", c, r.pos)
+	}
+
+	token := r.token()
+	switch token {
+	case "nil":
+		return nil, nil
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if n, err := strconv.ParseInt(strings.TrimSuffix(token, "N"), 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(strings.TrimSuffix(token, "M"), 64); err == nil {
+		return f, nil
+	}
+	return token, nil // a symbol
+}
+
+// seq reads values up to the closing delimiter end
+func (r *ednReader) seq(end byte) ([]any, error) {
+	var items []any
+	for {
+		r.skip()
+		if r.pos >= len(r.s) {
+			return nil, fmt.Errorf("parse EDN: missing %q", end)
+		}
+		if r.s[r.pos] == end {
+			r.pos++
+			return items, nil
+		}
+		item, err := r.value()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+}
+
+// str reads a string, unescaping it
+func (r *ednReader) str() (string, error) {
+	var b strings.Builder
+	for r.pos++; r.pos < len(r.s); r.pos++ {
+		c := r.s[r.pos]
+		switch {
+		case c == '"':
+			r.pos++
+			return b.String(), nil
+		case c == '\\' && r.pos+1 < len(r.s):
+			r.pos++
+			switch e := r.s[r.pos]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'u':
+				if r.pos+4 < len(r.s) {
+					if n, err := strconv.ParseUint(r.s[r.pos+1:r.pos+5], 16, 32); err == nil {
+						b.WriteRune(rune(n))
+						r.pos += 4
+						continue
+					}
+				}
+				b.WriteByte(e)
+			default:
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("parse EDN: unterminated string")
+}
+
+// token reads up to the next whitespace or delimiter
+func (r *ednReader) token() string {
+	start := r.pos
+	for r.pos < len(r.s) && !strings.ContainsRune(" \t\r\n,;{}[]()\"", rune(r.s[r.pos])) {
+		r.pos++
+	}
+	return r.s[start:r.pos]
+}
+
+// skip passes whitespace, commas and ; comments
+func (r *ednReader) skip() {
+	for r.pos < len(r.s) {
+		switch r.s[r.pos] {
+		case ' ', '\t', '\r', '\n', ',':
+			r.pos++
+		case ';':
+			for r.pos < len(r.s) && r.s[r.pos] != '\n' {
+				r.pos++
+			}
+		default:
+			return
+		}
+	}
+}
@@ -1,27 +1,106 @@
 package outliner
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os/exec"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 // EvnaDispatcher handles consciousness pattern dispatch to evna collections
 type EvnaDispatcher struct {
-	enabled  bool
-	logError func(string, string) // callback for logging errors
+	enabled        bool
+	endpoint       string               // HTTP endpoint receiving payloads; empty keeps capture local
+	collectionsURL string               // lists the backend's collections, for validation
+	routing        map[string]string    // pattern type -> collection
+	fallback       string               // collection for pattern types without a route
+	redactor       *Redactor            // applied to each payload's text before it's sent
+	logError       func(string, string) // callback for logging errors
+}
+
+// defaultCollectionRouting maps pattern types to evna collections
+var defaultCollectionRouting = map[string]string{
+	"ctx":       "active_context_stream",
+	"highlight": "float_highlights",
+	"eureka":    "float_highlights",
+	"decision":  "float_dispatch_bay",
+	"gotcha":    "active_context_stream",
+	"bridge":    "float_bridges",
+	"mode":      "active_context_stream",
+	"project":   "active_context_stream",
+	"concept":   "float_highlights",
+	"aka":       "float_highlights",
 }
 
 // NewEvnaDispatcher creates a new evna dispatcher
 func NewEvnaDispatcher() *EvnaDispatcher {
+	routing := make(map[string]string, len(defaultCollectionRouting))
+	for k, v := range defaultCollectionRouting {
+		routing[k] = v
+	}
+
 	return &EvnaDispatcher{
-		enabled:  true,                    // TODO: make configurable
+		enabled:  true,
+		routing:  routing,
+		fallback: "active_context_stream",
 		logError: func(string, string) {}, // no-op by default
 	}
 }
 
+// ResetConfig puts the dispatcher back as NewEvnaDispatcher made it,
+// keeping the error logger, so config can be applied over a clean slate
+func (ed *EvnaDispatcher) ResetConfig() {
+	logError := ed.logError
+	*ed = *NewEvnaDispatcher()
+	ed.logError = logError
+}
+
+// SetEnabled turns dispatch on or off
+func (ed *EvnaDispatcher) SetEnabled(enabled bool) {
+	ed.enabled = enabled
+}
+
+// SetEndpoint sets the HTTP endpoint capture payloads are POSTed to
+func (ed *EvnaDispatcher) SetEndpoint(endpoint string) {
+	ed.endpoint = endpoint
+}
+
+// SetCollectionRouting overrides collections per pattern type, keeping the
+// defaults for types not listed
+func (ed *EvnaDispatcher) SetCollectionRouting(routing map[string]string) {
+	for patternType, collection := range routing {
+		ed.routing[patternType] = collection
+	}
+}
+
+// SetDefaultCollection sets the collection for pattern types without a
+// route
+func (ed *EvnaDispatcher) SetDefaultCollection(collection string) {
+	if collection != "" {
+		ed.fallback = collection
+	}
+}
+
+// SetCollectionsURL sets where ValidateCollectionsCmd lists the backend's
+// collections, e.g. chroma's /api/v1/collections
+func (ed *EvnaDispatcher) SetCollectionsURL(url string) {
+	ed.collectionsURL = url
+}
+
+// SetRedactor sets the rules each pattern's text passes through before
+// it's sent; nil sends it as is
+func (ed *EvnaDispatcher) SetRedactor(r *Redactor) {
+	ed.redactor = r
+}
+
 // SetErrorLogger sets the error logging callback
 func (ed *EvnaDispatcher) SetErrorLogger(logError func(string, string)) {
 	ed.logError = logError
@@ -34,7 +113,11 @@ func (ed *EvnaDispatcher) DispatchPatterns(patterns []ConsciousnessPattern, sour
 	}
 
 	for _, pattern := range patterns {
-		if err := ed.dispatchSinglePattern(pattern, source); err != nil {
+		req := ed.preparePattern(pattern, source)
+		if len(req.redacted) > 0 {
+			slog.Info("redacted before sending to evna", "pattern", pattern.Type, "withheld", req.withheld, "redactions", req.redacted.String())
+		}
+		if err := req.send(ed.endpoint); err != nil {
 			// Log error but continue with other patterns
 			ed.logError("EVNA_DISPATCH_WARNING", fmt.Sprintf("Failed to dispatch pattern %s: %v", pattern.Type, err))
 		}
@@ -43,8 +126,74 @@ func (ed *EvnaDispatcher) DispatchPatterns(patterns []ConsciousnessPattern, sour
 	return nil
 }
 
-// dispatchSinglePattern sends a single pattern to appropriate evna collection
-func (ed *EvnaDispatcher) dispatchSinglePattern(pattern ConsciousnessPattern, source string) error {
+// EvnaResult is the outcome of sending one pattern to evna
+type EvnaResult struct {
+	Type       string
+	Collection string
+	Err        error
+
+	Redacted Redactions // what the redaction rules changed before sending
+	Withheld bool       // redaction left nothing, so nothing was sent
+}
+
+// EvnaResultMsg carries a DispatchCmd's results back to Update
+type EvnaResultMsg struct {
+	Source  string
+	Results []EvnaResult
+}
+
+// evnaRequest is a payload built on the caller's goroutine, ready to send
+type evnaRequest struct {
+	patternType string
+	collection  string
+	payload     []byte
+	err         error
+	redacted    Redactions
+	withheld    bool
+}
+
+// send posts the request's payload unless building it failed or
+// redaction withheld it
+func (req evnaRequest) send(endpoint string) error {
+	if req.err != nil || req.withheld {
+		return req.err
+	}
+	return postEvna(endpoint, req.payload)
+}
+
+// DispatchCmd builds the payloads for patterns now and sends them when the
+// returned command runs, so the HTTP calls stay off the UI goroutine and
+// never touch the dispatcher's state. It returns nil when dispatch is
+// disabled or there is nothing to send.
+func (ed *EvnaDispatcher) DispatchCmd(patterns []ConsciousnessPattern, source string) tea.Cmd {
+	if !ed.enabled || len(patterns) == 0 {
+		return nil
+	}
+
+	endpoint := ed.endpoint
+	requests := make([]evnaRequest, len(patterns))
+	for i, pattern := range patterns {
+		requests[i] = ed.preparePattern(pattern, source)
+	}
+
+	return func() tea.Msg {
+		results := make([]EvnaResult, len(requests))
+		for i, req := range requests {
+			results[i] = EvnaResult{
+				Type:       req.patternType,
+				Collection: req.collection,
+				Err:        req.send(endpoint),
+				Redacted:   req.redacted,
+				Withheld:   req.withheld,
+			}
+		}
+		return EvnaResultMsg{Source: source, Results: results}
+	}
+}
+
+// preparePattern picks the pattern's collection and builds its payload
+// from the redacted dispatch text
+func (ed *EvnaDispatcher) preparePattern(pattern ConsciousnessPattern, source string) evnaRequest {
 	// Build the dispatch text in FLOAT format
 	timestamp := time.Now().Format("2006-01-02 3:04pm")
 
@@ -61,39 +210,146 @@ func (ed *EvnaDispatcher) dispatchSinglePattern(pattern ConsciousnessPattern, so
 	// Add source metadata
 	dispatchText.WriteString(fmt.Sprintf(" [source:: %s] [timestamp:: %s]", source, timestamp))
 
-	// Route to appropriate collection based on pattern type
-	collection := ed.routeToCollection(pattern.Type)
+	req := evnaRequest{patternType: pattern.Type, collection: ed.collectionFor(pattern)}
+	text, redacted := ed.redactor.Redact(dispatchText.String())
+	req.redacted = redacted
+	if len(redacted) > 0 && strings.TrimSpace(text) == "" {
+		req.withheld = true
+		return req
+	}
+	req.payload, req.err = evnaPayload(text, req.collection)
+	return req
+}
 
-	// Use evna MCP to capture the pattern
-	return ed.callEvnaMCP(dispatchText.String(), collection)
+// collectionFor is the collection a pattern goes to: a [collection:: x]
+// annotation (or the capture review's choice), else the route for its type
+func (ed *EvnaDispatcher) collectionFor(pattern ConsciousnessPattern) string {
+	if collection := pattern.Context["collection"]; collection != "" {
+		return collection
+	}
+	return ed.routeToCollection(pattern.Type)
+}
+
+// Collections lists every collection patterns are routed to, sorted
+func (ed *EvnaDispatcher) Collections() []string {
+	seen := map[string]bool{ed.fallback: true}
+	for _, collection := range ed.routing {
+		seen[collection] = true
+	}
+	collections := make([]string, 0, len(seen))
+	for collection := range seen {
+		collections = append(collections, collection)
+	}
+	sort.Strings(collections)
+	return collections
+}
+
+// Route returns the collection patterns of patternType go to without a
+// [collection:: x] of their own
+func (ed *EvnaDispatcher) Route(patternType string) string {
+	return ed.routeToCollection(patternType)
 }
 
 // routeToCollection determines which evna collection to use for a pattern type
 func (ed *EvnaDispatcher) routeToCollection(patternType string) string {
-	routing := map[string]string{
-		"ctx":       "active_context_stream",
-		"highlight": "float_highlights",
-		"eureka":    "float_highlights",
-		"decision":  "float_dispatch_bay",
-		"gotcha":    "active_context_stream",
-		"bridge":    "float_bridges",
-		"mode":      "active_context_stream",
-		"project":   "active_context_stream",
-		"concept":   "float_highlights",
-		"aka":       "float_highlights",
-	}
-
-	if collection, exists := routing[patternType]; exists {
+	if collection, exists := ed.routing[patternType]; exists {
 		return collection
 	}
 
-	// Default to active_context_stream for unknown patterns
-	return "active_context_stream"
+	return ed.fallback
+}
+
+// collectionNameRegex is chroma's rule for collection names: 3-63
+// characters of letters, digits, ".", "_" and "-", starting and ending
+// with a letter or digit
+var collectionNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{1,61}[a-zA-Z0-9]$`)
+
+// EvnaValidationMsg carries ValidateCollectionsCmd's warnings back to Update
+type EvnaValidationMsg struct {
+	Warnings []string
 }
 
-// callEvnaMCP invokes evna pattern capture via structured output
-func (ed *EvnaDispatcher) callEvnaMCP(text string, collection string) error {
-	// Create the evna capture payload in FLOAT format
+// ValidateCollectionsCmd checks every routed collection name now, and
+// against the backend's collection list when the command runs if a
+// collections URL is set. It returns nil when dispatch is disabled.
+func (ed *EvnaDispatcher) ValidateCollectionsCmd() tea.Cmd {
+	if !ed.enabled {
+		return nil
+	}
+
+	// Which pattern types use each collection, for the warnings
+	uses := map[string][]string{ed.fallback: {"unrouted"}}
+	for patternType, collection := range ed.routing {
+		uses[collection] = append(uses[collection], patternType+"::")
+	}
+	collections := make([]string, 0, len(uses))
+	for collection := range uses {
+		collections = append(collections, collection)
+		sort.Strings(uses[collection])
+	}
+	sort.Strings(collections)
+
+	var warnings []string
+	for _, collection := range collections {
+		if !collectionNameRegex.MatchString(collection) || strings.Contains(collection, "..") {
+			warnings = append(warnings, fmt.Sprintf("collection %q (%s) isn't a valid collection name", collection, strings.Join(uses[collection], ", ")))
+		}
+	}
+
+	url := ed.collectionsURL
+	return func() tea.Msg {
+		if url == "" {
+			return EvnaValidationMsg{Warnings: warnings}
+		}
+		existing, err := fetchCollections(url)
+		if err != nil {
+			return EvnaValidationMsg{Warnings: append(warnings, fmt.Sprintf("couldn't list collections: %v", err))}
+		}
+		for _, collection := range collections {
+			if !existing[collection] {
+				warnings = append(warnings, fmt.Sprintf("collection %q (%s) doesn't exist on the backend", collection, strings.Join(uses[collection], ", ")))
+			}
+		}
+		return EvnaValidationMsg{Warnings: warnings}
+	}
+}
+
+// fetchCollections lists the backend's collections: a JSON array of names,
+// or of objects with a name as chroma returns
+func fetchCollections(url string) (map[string]bool, error) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("backend unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("backend returned %s", resp.Status)
+	}
+
+	var list []json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode collections: %w", err)
+	}
+	names := make(map[string]bool, len(list))
+	for _, item := range list {
+		var name string
+		if json.Unmarshal(item, &name) != nil {
+			var collection struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(item, &collection); err != nil {
+				return nil, fmt.Errorf("failed to decode collection: %w", err)
+			}
+			name = collection.Name
+		}
+		names[name] = true
+	}
+	return names, nil
+}
+
+// evnaPayload builds the evna capture payload in FLOAT format
+func evnaPayload(text string, collection string) ([]byte, error) {
 	payload := map[string]interface{}{
 		"action":     "evna_capture",
 		"text":       text,
@@ -103,16 +359,30 @@ func (ed *EvnaDispatcher) callEvnaMCP(text string, collection string) error {
 		"iso_time":   time.Now().Format(time.RFC3339),
 	}
 
-	_, err := json.Marshal(payload)
+	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal evna payload: %w", err)
+		return nil, fmt.Errorf("failed to marshal evna payload: %w", err)
+	}
+	return jsonPayload, nil
+}
+
+// postEvna sends a payload to the evna endpoint
+func postEvna(endpoint string, payload []byte) error {
+	// Without an endpoint, capture is only logged via the debug panel
+	if endpoint == "" {
+		return nil
 	}
 
-	// Output structured data for external processing (commented out to avoid console spam)
-	// This can be captured by shell scripts, log processors, or MCP bridges
-	// fmt.Printf("CONSCIOUSNESS_CAPTURE: %s\n", string(jsonPayload))
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Post(endpoint, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("evna endpoint unreachable: %w", err)
+	}
+	defer resp.Body.Close()
 
-	// Note: Consciousness capture is now logged via the debug panel in the outliner
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("evna endpoint returned %s", resp.Status)
+	}
 	return nil
 }
 
@@ -0,0 +1,201 @@
+package outliner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExecConfig controls reducers defined as "name exec ./match.sh"
+type ExecConfig struct {
+	Enabled   bool          // off by default: opening an outline shouldn't run its programs
+	Timeout   time.Duration // per command run
+	BatchSize int           // actions per run for --batch matchers
+}
+
+// DefaultExecConfig disables exec matchers
+func DefaultExecConfig() ExecConfig {
+	return ExecConfig{Timeout: 2 * time.Second, BatchSize: 50}
+}
+
+// execAction is the JSON an exec matcher reads for each action
+type execAction struct {
+	ID          string            `json:"id"`
+	NodeID      string            `json:"node_id,omitempty"`
+	Content     string            `json:"content"`
+	PatternType string            `json:"type"`
+	Imprint     string            `json:"imprint"`
+	Sigil       string            `json:"sigil,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Timestamp   time.Time         `json:"timestamp"`
+}
+
+// ExecMatcher matches actions with an external command. Each run gets one
+// action as JSON on stdin and exits 0 to accept it or 1 to reject it. A
+// --batch matcher gets many actions as JSON lines instead and prints the
+// IDs of those it accepts, one per line. Answers are cached by pattern type
+// and content, so re-dispatching an outline doesn't rerun the command; a
+// run that fails or times out rejects its actions and is cached too.
+type ExecMatcher struct {
+	args   []string
+	batch  bool
+	config ExecConfig
+
+	mu    sync.Mutex
+	cache map[string]bool
+}
+
+// ParseExecQuery splits a reducer query of the form "exec [--batch]
+// command args..."; ok is false for ordinary queries
+func ParseExecQuery(query string) (args []string, batch, ok bool) {
+	fields := strings.Fields(query)
+	if len(fields) == 0 || fields[0] != "exec" {
+		return nil, false, false
+	}
+	fields = fields[1:]
+	if len(fields) > 0 && fields[0] == "--batch" {
+		batch, fields = true, fields[1:]
+	}
+	return fields, batch, true
+}
+
+// NewExecMatcher creates a matcher for an exec query
+func NewExecMatcher(query string, config ExecConfig) (*ExecMatcher, error) {
+	args, batch, ok := ParseExecQuery(query)
+	if !ok || len(args) == 0 {
+		return nil, fmt.Errorf("exec reducer needs a command: %q", query)
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultExecConfig().Timeout
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultExecConfig().BatchSize
+	}
+	return &ExecMatcher{args: args, batch: batch, config: config, cache: make(map[string]bool)}, nil
+}
+
+func execKey(action DispatchAction) string {
+	return action.PatternType + "\x00" + action.Content
+}
+
+// Match reports whether the command accepts action
+func (m *ExecMatcher) Match(action DispatchAction) bool {
+	m.mu.Lock()
+	accepted, cached := m.cache[execKey(action)]
+	m.mu.Unlock()
+	if cached {
+		return accepted
+	}
+
+	m.Prefetch([]DispatchAction{action})
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cache[execKey(action)]
+}
+
+// Prefetch runs the command over the uncached actions, in batches for
+// --batch matchers, so the Match calls that follow are answered from cache
+func (m *ExecMatcher) Prefetch(actions []DispatchAction) {
+	var pending []DispatchAction
+	queued := make(map[string]bool)
+	m.mu.Lock()
+	for _, action := range actions {
+		key := execKey(action)
+		if _, cached := m.cache[key]; !cached && !queued[key] {
+			queued[key] = true
+			pending = append(pending, action)
+		}
+	}
+	m.mu.Unlock()
+
+	size := 1
+	if m.batch {
+		size = m.config.BatchSize
+	}
+	for len(pending) > 0 {
+		n := min(size, len(pending))
+		results, err := m.run(pending[:n])
+		if err != nil {
+			slog.Warn("exec matcher failed", "command", strings.Join(m.args, " "), "actions", n, "err", err)
+		}
+		m.mu.Lock()
+		for i, action := range pending[:n] {
+			// A failure rejects rather than rerunning on every capture
+			m.cache[execKey(action)] = err == nil && results[i]
+		}
+		m.mu.Unlock()
+		pending = pending[n:]
+	}
+}
+
+// run runs the command once over actions
+func (m *ExecMatcher) run(actions []DispatchAction) ([]bool, error) {
+	var stdin bytes.Buffer
+	enc := json.NewEncoder(&stdin)
+	for _, a := range actions {
+		enc.Encode(execAction{
+			ID:          a.ID,
+			NodeID:      a.NodeID,
+			Content:     a.Content,
+			PatternType: a.PatternType,
+			Imprint:     a.Imprint,
+			Sigil:       a.Sigil,
+			Metadata:    a.Metadata,
+			Timestamp:   a.Timestamp,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.config.Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, m.args[0], m.args[1:]...)
+	cmd.Stdin = &stdin
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	// Children the command started may hold its output open after a kill
+	cmd.WaitDelay = 100 * time.Millisecond
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("timed out after %s", m.config.Timeout)
+	}
+
+	if !m.batch {
+		var exit *exec.ExitError
+		switch {
+		case err == nil:
+			return []bool{true}, nil
+		case errors.As(err, &exit) && exit.ExitCode() == 1:
+			return []bool{false}, nil
+		}
+		return nil, commandError(err, stderr)
+	}
+
+	if err != nil {
+		return nil, commandError(err, stderr)
+	}
+	accepted := make(map[string]bool)
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		accepted[strings.TrimSpace(scanner.Text())] = true
+	}
+	results := make([]bool, len(actions))
+	for i, a := range actions {
+		results[i] = accepted[a.ID]
+	}
+	return results, nil
+}
+
+// commandError adds the command's stderr, when it wrote any, to err
+func commandError(err error, stderr bytes.Buffer) error {
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return fmt.Errorf("%w: %s", err, msg)
+	}
+	return err
+}
@@ -0,0 +1,244 @@
+package outliner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/encrypt"
+)
+
+var slugRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// SelectorExport is a selector written to an artifact file
+type SelectorExport struct {
+	Selector string
+	Path     string // as annotated; relative paths are resolved against the outline's directory
+	NodeID   string
+}
+
+// selectorExportJSON is the .json artifact format
+type selectorExportJSON struct {
+	Selector   string                      `json:"selector"`
+	Output     string                      `json:"output"`
+	Inputs     map[string][]DispatchAction `json:"inputs"`
+	ExportedAt time.Time                   `json:"exported_at"`
+}
+
+func selectorNameFor(nodeID string) string {
+	if len(nodeID) > 8 {
+		nodeID = nodeID[:8]
+	}
+	return "selector_" + nodeID
+}
+
+// SelectorExports lists selectors that have an [output:: path] annotation
+func (o *Outliner) SelectorExports() []SelectorExport {
+	exports := make([]SelectorExport, 0, len(o.selectorExports))
+	for _, e := range o.selectorExports {
+		exports = append(exports, e)
+	}
+	sort.Slice(exports, func(i, j int) bool { return exports[i].Path < exports[j].Path })
+	return exports
+}
+
+// ExportSelectors writes every annotated selector's output under baseDir.
+// With onlyChanged, files whose output hasn't changed since the last
+// export are skipped. It returns the paths written.
+func (o *Outliner) ExportSelectors(baseDir string, onlyChanged bool) ([]string, error) {
+	var written []string
+	for _, e := range o.SelectorExports() {
+		path, err := o.exportSelector(e, baseDir, onlyChanged)
+		if err != nil {
+			return written, err
+		}
+		if path != "" {
+			written = append(written, path)
+		}
+	}
+	return written, nil
+}
+
+// ExportSelectorAtCursor exports the selector:: node under the cursor,
+// first adding an [output:: <slug>.md] annotation if it has none so the
+// export can be repeated
+func (o *Outliner) ExportSelectorAtCursor(baseDir string) (string, error) {
+	if o.cursor >= len(o.lines) {
+		return "", fmt.Errorf("no node selected")
+	}
+	node := &o.lines[o.cursor]
+	_, definition, found := strings.Cut(node.Text, "selector::")
+	_, format, ok := ParseSelectorDefinition(strings.TrimSpace(definition))
+	if !found || !ok {
+		return "", fmt.Errorf("not a selector:: node")
+	}
+
+	if !strings.Contains(node.Text, "[output::") {
+		slug := strings.Trim(slugRegex.ReplaceAllString(strings.ToLower(format), "-"), "-")
+		if slug == "" {
+			slug = selectorNameFor(node.ID)
+		}
+		node.Text = strings.TrimRight(node.Text, " ") + fmt.Sprintf(" [output:: %s.md]", slug)
+		node.ModifiedAt = time.Now()
+	}
+
+	// Re-capture so the selector and its annotation are registered
+	o.captureConsciousness("selector_export")
+
+	e, ok := o.selectorExports[selectorNameFor(node.ID)]
+	if !ok {
+		return "", fmt.Errorf("selector has no output path")
+	}
+	return o.exportSelector(e, baseDir, false)
+}
+
+func (o *Outliner) exportSelector(e SelectorExport, baseDir string, onlyChanged bool) (string, error) {
+	selector, ok := o.dispatch.GetSelectors()[e.Selector]
+	if !ok {
+		return "", nil
+	}
+
+	path := e.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+
+	content := selector.Output
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		inputs := make(map[string][]DispatchAction)
+		for _, name := range selector.Inputs {
+			inputs[name] = o.dispatch.GetInputActions(name)
+		}
+		data, err := json.MarshalIndent(selectorExportJSON{
+			Selector:   e.Selector,
+			Output:     selector.Output,
+			Inputs:     inputs,
+			ExportedAt: time.Now(),
+		}, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		content = string(data) + "\n"
+	}
+
+	// JSON carries a timestamp, so compare the rendered output instead
+	if onlyChanged && o.exported[path] == selector.Output {
+		return "", nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := encrypt.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("export %s: %w", e.Selector, err)
+	}
+	o.exported[path] = selector.Output
+	o.debugPanel.AddMessage("SELECTOR_EXPORT", fmt.Sprintf("%s → %s", e.Selector, path), DebugLevelSuccess)
+	return path, nil
+}
+
+// ReducerExport is a reducer whose collected actions are written to a
+// results file instead of under its node
+type ReducerExport struct {
+	Reducer  string
+	Path     string // as annotated; relative paths are resolved against the outline's directory
+	NodeID   string
+	Children bool // [children:: true]: collected actions are added under the node too
+}
+
+// reducerResultsJSON is the .json results file format
+type reducerResultsJSON struct {
+	Reducer string           `json:"reducer"`
+	Query   string           `json:"query"`
+	Actions []DispatchAction `json:"actions"`
+}
+
+// ReducerExports lists reducers that have an [output:: path] annotation
+func (o *Outliner) ReducerExports() []ReducerExport {
+	exports := make([]ReducerExport, 0, len(o.reducerExports))
+	for _, e := range o.reducerExports {
+		exports = append(exports, e)
+	}
+	sort.Slice(exports, func(i, j int) bool { return exports[i].Path < exports[j].Path })
+	return exports
+}
+
+// ExportReducers writes every annotated reducer's collected actions under
+// baseDir, skipping files whose results haven't changed since they were
+// last written. It returns the paths written.
+func (o *Outliner) ExportReducers(baseDir string) ([]string, error) {
+	var written []string
+	for _, e := range o.ReducerExports() {
+		path, err := o.exportReducer(e, baseDir)
+		if err != nil {
+			return written, err
+		}
+		if path != "" {
+			written = append(written, path)
+		}
+	}
+	return written, nil
+}
+
+func (o *Outliner) exportReducer(e ReducerExport, baseDir string) (string, error) {
+	reducer, ok := o.dispatch.GetReducers()[e.Reducer]
+	if !ok {
+		return "", nil
+	}
+
+	path := e.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+
+	var content string
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		data, err := json.MarshalIndent(reducerResultsJSON{
+			Reducer: e.Reducer,
+			Query:   reducer.Query,
+			Actions: append([]DispatchAction{}, reducer.Actions...),
+		}, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		content = string(data) + "\n"
+	} else {
+		content = reducerResultsMarkdown(e.Reducer, reducer)
+	}
+
+	if o.exported[path] == content {
+		return "", nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := encrypt.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("export %s: %w", e.Reducer, err)
+	}
+	o.exported[path] = content
+	o.debugPanel.AddMessage("REDUCER_EXPORT", fmt.Sprintf("%s → %s", e.Reducer, path), DebugLevelSuccess)
+	return path, nil
+}
+
+// reducerResultsMarkdown renders a reducer's collected actions as an
+// outline, in the order they were collected
+func reducerResultsMarkdown(name string, reducer *ConsciousnessReducer) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n> reducer::%s %s\n\n", name, name, reducer.Query)
+	if len(reducer.Actions) == 0 {
+		b.WriteString("_Nothing collected yet._\n")
+	}
+	for _, action := range reducer.Actions {
+		content := strings.Join(strings.Fields(action.Content), " ")
+		if !strings.HasPrefix(content, action.PatternType+"::") {
+			content = action.PatternType + ":: " + content
+		}
+		fmt.Fprintf(&b, "- %s\n", content)
+	}
+	return b.String()
+}
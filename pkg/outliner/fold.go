@@ -0,0 +1,49 @@
+package outliner
+
+// isHidden reports whether node i sits under a collapsed ancestor, or
+// the review filter leaves it out
+func (o *Outliner) isHidden(i int) bool {
+	if o.reviewMode {
+		return !o.needsReview(i)
+	}
+	level := o.lines[i].Level
+	for j := i - 1; j >= 0 && level > 0; j-- {
+		if o.lines[j].Level < level {
+			if o.lines[j].Collapsed {
+				return true
+			}
+			level = o.lines[j].Level
+		}
+	}
+	return false
+}
+
+// setCollapsed folds or unfolds the node under the cursor
+func (o *Outliner) setCollapsed(collapsed bool) {
+	if o.cursor >= len(o.lines) || !o.lines[o.cursor].HasChildren {
+		return
+	}
+	o.lines[o.cursor].Collapsed = collapsed
+}
+
+// moveCursor steps to the next visible node in direction dir (+1 or -1)
+func (o *Outliner) moveCursor(dir int) {
+	for i := o.cursor + dir; i >= 0 && i < len(o.lines); i += dir {
+		if !o.isHidden(i) {
+			o.cursor = i
+			return
+		}
+	}
+}
+
+// revealCursor unfolds the cursor's ancestors and scrolls to it
+func (o *Outliner) revealCursor() {
+	level := o.lines[o.cursor].Level
+	for j := o.cursor - 1; j >= 0 && level > 0; j-- {
+		if o.lines[j].Level < level {
+			o.lines[j].Collapsed = false
+			level = o.lines[j].Level
+		}
+	}
+	o.scrollToCursor()
+}
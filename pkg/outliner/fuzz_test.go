@@ -0,0 +1,130 @@
+package outliner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fuzzSeeds are malformed inputs the parsers must survive, on top of the
+// scenario outlines
+var fuzzSeeds = []string{
+	"",
+	"• ctx:: unterminated [[link",
+	"• eureka:: [[nested [[links]] inside]]",
+	"• decision:: [priority:: high",
+	"• ctx:: [[a]] [b:: [c:: d]] ]]",
+	"[::]",
+	"[key::]",
+	"::",
+	"•",
+	"• reducer::",
+	"• reducer:: name",
+	"• selector:: (a, b => c => d",
+	"• reducer:: big collect all ctx from the last 99999999999999999999 days",
+	"• reducer:: old collect all ctx from the past 9999999999 weeks",
+	"\t• ctx:: \x00 tab\r\n\r\n  • highlight:: ✂ 🌀",
+	strings.Repeat("[[", 500) + strings.Repeat("]]", 3),
+	"• ctx:: " + strings.Repeat("x", 64*1024),
+}
+
+// addScenarioSeeds seeds f with every line of the scenario outlines, and
+// the outlines themselves when whole is set
+func addScenarioSeeds(f *testing.F, whole bool) {
+	files, _ := filepath.Glob("../scenario/testdata/*.yaml")
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		var s struct {
+			Content string `yaml:"content"`
+		}
+		if err := yaml.Unmarshal(data, &s); err != nil {
+			f.Fatalf("%s: %v", file, err)
+		}
+		if whole {
+			f.Add(s.Content)
+		}
+		for _, line := range strings.Split(s.Content, "\n") {
+			f.Add(line)
+		}
+	}
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	addScenarioSeeds(f, true)
+	f.Fuzz(func(t *testing.T, content string) {
+		result := NewParser().Parse(content)
+		lines := strings.Count(content, "\n") + 1
+		for _, pattern := range result.ConsciousnessData {
+			if pattern.Line < 1 || pattern.Line > lines {
+				t.Fatalf("pattern %q on line %d of %d", pattern.Type, pattern.Line, lines)
+			}
+		}
+
+		keys := make([]string, lines)
+		for i := range keys {
+			keys[i] = string(rune('a' + i%26))
+		}
+		incremental := NewParser().ParseIncremental(content, keys)
+		if len(incremental.ConsciousnessData) != len(result.ConsciousnessData) {
+			t.Fatalf("incremental parse found %d patterns, full parse %d", len(incremental.ConsciousnessData), len(result.ConsciousnessData))
+		}
+	})
+}
+
+func FuzzExtractContextAnnotations(f *testing.F) {
+	addScenarioSeeds(f, false)
+	f.Fuzz(func(t *testing.T, text string) {
+		for key, value := range NewParser().extractContextAnnotations(text) {
+			if key == "" || value != strings.TrimSpace(value) {
+				t.Fatalf("annotation %q = %q", key, value)
+			}
+		}
+	})
+}
+
+func FuzzReducerQuery(f *testing.F) {
+	addScenarioSeeds(f, false)
+	for _, q := range []string{
+		"shipped collect all decisions about auth from the last 7 days",
+		"recent collect this week's ctx",
+		"mentions collect all actions that mention about that mention",
+	} {
+		f.Add(q)
+	}
+	action := DispatchAction{PatternType: "ctx", Content: "auth test rangle", Timestamp: time.Now()}
+	f.Fuzz(func(t *testing.T, definition string) {
+		definition = strings.TrimPrefix(strings.TrimSpace(definition), "• ")
+		definition = strings.TrimPrefix(definition, "reducer:: ")
+		if name, query, ok := ParseReducerDefinition(definition); ok {
+			if name+" "+query != definition {
+				t.Fatalf("split %q into %q and %q", definition, name, query)
+			}
+			ReducerMatcher(query)(action)
+		}
+
+		if window, ok := ParseTimeWindow(definition); ok {
+			start, end := window.Bounds(time.Now())
+			if window.Last < 0 || start.After(end) {
+				t.Fatalf("window %+v runs from %v to %v", window, start, end)
+			}
+		}
+
+		if inputs, _, ok := ParseSelectorDefinition(definition); ok {
+			for _, input := range inputs {
+				if input != strings.TrimSpace(input) {
+					t.Fatalf("selector input %q", input)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,263 @@
+package outliner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+)
+
+// GraphEntry is one finding of the graph report
+type GraphEntry struct {
+	Label  string // the concept, the node's text, or the bridge-id
+	NodeID string // where it jumps: the first node linking the concept, the node itself, or the bridge's one node
+	Count  int    // for orphans, how many nodes link the concept
+}
+
+// GraphReport is the outline's link upkeep: concepts linked but defined
+// nowhere, nodes linked to nothing, and bridge-ids only one node carries
+type GraphReport struct {
+	Orphans     []GraphEntry
+	Unlinked    []GraphEntry
+	LoneBridges []GraphEntry
+}
+
+// GraphJumpMsg asks the app to close the graph report and move the cursor
+// to a node it lists
+type GraphJumpMsg struct {
+	NodeID string
+}
+
+// GraphReport finds the outline's orphan concepts, unlinked nodes and lone
+// bridges. A concept is defined by a node whose whole text names it (a
+// heading, say), a [concept:: name] annotation, an aka:: node, or, with a
+// link index, a page it resolves to. Archived nodes and collected children
+// are left out.
+func (o *Outliner) GraphReport() GraphReport {
+	var report GraphReport
+	defined := map[string]bool{}
+	bridges := map[string][]string{}
+	referenced := map[string]bool{} // nodes with a [[file#^id]], ((id)) or bridge-id tying them to others
+	for _, line := range o.lines {
+		if o.archived[line.ID] {
+			continue
+		}
+		for _, name := range definedNames(line) {
+			defined[strings.ToLower(name)] = true
+		}
+		_, annotations := ParseAnnotations(line.Text)
+		for _, a := range annotations {
+			if a.Key == "bridge-id" && a.Value != "" {
+				bridges[a.Value] = append(bridges[a.Value], line.ID)
+				referenced[line.ID] = true
+			}
+		}
+		for _, m := range wikiLinkRegex.FindAllStringSubmatch(line.Text, -1) {
+			if _, id, ok := ParseNodeRef(m[1]); ok {
+				referenced[id] = true
+			}
+		}
+		if line.Mirror != "" {
+			referenced[line.Mirror] = true
+		}
+	}
+
+	// Concepts, grouped as links are resolved: without #heading or |label
+	linkers := map[string][]string{}
+	labels := map[string]string{}
+	for _, line := range o.lines {
+		if o.archived[line.ID] || line.Origin != nil {
+			continue
+		}
+		seen := map[string]bool{}
+		for _, link := range line.Links {
+			name := normalizeLink(link)
+			key := strings.ToLower(name)
+			if name == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			if _, ok := labels[key]; !ok {
+				labels[key] = name
+			}
+			linkers[key] = append(linkers[key], line.ID)
+		}
+	}
+	for key, ids := range linkers {
+		if defined[key] {
+			continue
+		}
+		if o.linkIndex != nil {
+			if _, ok := o.linkIndex.Resolve(labels[key]); ok {
+				continue
+			}
+		}
+		report.Orphans = append(report.Orphans, GraphEntry{Label: labels[key], NodeID: ids[0], Count: len(ids)})
+	}
+	sort.Slice(report.Orphans, func(i, j int) bool {
+		return strings.ToLower(report.Orphans[i].Label) < strings.ToLower(report.Orphans[j].Label)
+	})
+
+	for _, line := range o.lines {
+		if o.archived[line.ID] || line.Origin != nil || line.Kind == KindBlank || line.Kind == KindCode || strings.TrimSpace(line.Text) == "" {
+			continue
+		}
+		if len(line.Links) > 0 || len(line.Backlinks) > 0 || wikiLinkRegex.MatchString(line.Text) || referenced[line.ID] {
+			continue
+		}
+		report.Unlinked = append(report.Unlinked, GraphEntry{Label: strings.TrimSpace(line.Text), NodeID: line.ID})
+	}
+
+	for id, ids := range bridges {
+		if len(ids) == 1 {
+			report.LoneBridges = append(report.LoneBridges, GraphEntry{Label: id, NodeID: ids[0]})
+		}
+	}
+	sort.Slice(report.LoneBridges, func(i, j int) bool { return report.LoneBridges[i].Label < report.LoneBridges[j].Label })
+	return report
+}
+
+// definedNames are the concepts a node defines: its whole text, bar heading
+// marks, annotations and a trailing colon; [concept:: name] annotations;
+// and the old names an aka:: node gives
+func definedNames(line OutlineNode) []string {
+	plain, annotations := ParseAnnotations(line.Text)
+	var names []string
+	for _, a := range annotations {
+		if a.Key == "concept" {
+			names = append(names, normalizeLink(strings.Trim(a.Value, "[]")))
+		}
+	}
+	if rest, ok := strings.CutPrefix(plain, "aka::"); ok {
+		aliases, _, _ := strings.Cut(rest, "→")
+		for _, alias := range strings.Split(aliases, ",") {
+			names = append(names, strings.Trim(strings.TrimSpace(alias), "[]"))
+		}
+		return names
+	}
+	if !wikiLinkRegex.MatchString(plain) {
+		text := strings.TrimSpace(strings.TrimSuffix(strings.TrimLeft(plain, "# "), ":"))
+		if text != "" {
+			names = append(names, text)
+		}
+	}
+	return names
+}
+
+// GraphDoor - Orphan and dead-link report door: concepts with no defining
+// node, nodes with no links in or out, bridge-ids with one end
+type GraphDoor struct {
+	active   bool
+	report   GraphReport
+	selected int
+
+	style lipgloss.Style
+	title lipgloss.Style
+	dim   lipgloss.Style
+	pick  lipgloss.Style
+}
+
+func NewGraphDoor() Door {
+	return &GraphDoor{
+		style: lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1),
+		title: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("62")),
+		dim:   lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
+		pick:  lipgloss.NewStyle().Reverse(true),
+	}
+}
+
+// SetReport gives the door the report it lists
+func (gd *GraphDoor) SetReport(report GraphReport) {
+	gd.report = report
+	gd.selected = min(gd.selected, max(0, len(gd.entries())-1))
+}
+
+// entries are the report's findings in the order they're listed
+func (gd *GraphDoor) entries() []GraphEntry {
+	entries := append([]GraphEntry(nil), gd.report.Orphans...)
+	entries = append(entries, gd.report.Unlinked...)
+	return append(entries, gd.report.LoneBridges...)
+}
+
+func (gd *GraphDoor) Name() string                          { return "graph" }
+func (gd *GraphDoor) Init(params map[string]string) tea.Cmd { return nil }
+
+func (gd *GraphDoor) Update(msg tea.Msg) (Door, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok || !gd.active {
+		return gd, nil
+	}
+	entries := gd.entries()
+	switch key.String() {
+	case "up", "k":
+		gd.selected = max(0, gd.selected-1)
+	case "down", "j":
+		gd.selected = min(max(0, len(entries)-1), gd.selected+1)
+	case "enter":
+		if gd.selected < len(entries) {
+			jump := GraphJumpMsg{NodeID: entries[gd.selected].NodeID}
+			return gd, func() tea.Msg { return jump }
+		}
+	}
+	return gd, nil
+}
+
+func (gd *GraphDoor) View(width, height int) string {
+	inner := max(20, cells.Inner(gd.style, width))
+	var rows []string
+	start := -1 // the row of the selected entry
+	n := 0
+	section := func(title string, entries []GraphEntry, describe func(GraphEntry) string) {
+		rows = append(rows, "", gd.title.Render(fmt.Sprintf("%s (%d)", title, len(entries))))
+		if len(entries) == 0 {
+			rows = append(rows, gd.dim.Render("  "+i18n.T("outliner.graph.none")))
+		}
+		for _, entry := range entries {
+			row := cells.Cut("  "+describe(entry), inner)
+			if n == gd.selected {
+				row, start = gd.pick.Render(row), len(rows)
+			}
+			rows = append(rows, row)
+			n++
+		}
+	}
+	section(i18n.T("outliner.graph.orphans"), gd.report.Orphans, func(e GraphEntry) string {
+		return "[[" + e.Label + "]]  " + gd.dim.Render(i18n.N("outliner.graph.linked_from", e.Count))
+	})
+	section(i18n.T("outliner.graph.unlinked"), gd.report.Unlinked, func(e GraphEntry) string {
+		return e.Label
+	})
+	section(i18n.T("outliner.graph.lone_bridges"), gd.report.LoneBridges, func(e GraphEntry) string {
+		return e.Label
+	})
+
+	// Keep the selection in view under the two header rows
+	body := max(1, height-6)
+	offset := 0
+	if start >= body {
+		offset = start - body + 1
+	}
+	rows = rows[offset:min(len(rows), offset+body)]
+
+	head := gd.title.Render(i18n.T("outliner.graph.title")) + "\n" + gd.dim.Render(cells.Cut(i18n.T("outliner.graph.help"), inner))
+	content := head + "\n" + strings.Join(rows, "\n")
+	return cells.Frame(gd.style, width).Height(height - 2).MaxHeight(height).Render(content)
+}
+
+func (gd *GraphDoor) IsActive() bool { return gd.active }
+func (gd *GraphDoor) Activate()      { gd.active = true }
+func (gd *GraphDoor) Deactivate()    { gd.active = false }
+func (gd *GraphDoor) GetState() map[string]interface{} {
+	return map[string]interface{}{
+		"orphans":      len(gd.report.Orphans),
+		"unlinked":     len(gd.report.Unlinked),
+		"lone_bridges": len(gd.report.LoneBridges),
+	}
+}
+func (gd *GraphDoor) SetState(state map[string]interface{})                  {}
+func (gd *GraphDoor) OnConsciousnessCapture(patterns []ConsciousnessPattern) {}
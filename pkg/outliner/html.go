@@ -0,0 +1,295 @@
+package outliner
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ansiColors are the hex values of the 16 standard terminal colors, for
+// showing theme colors in HTML
+var ansiColors = [16]string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// cssColor converts a terminal color (a name, an ANSI number or hex) to CSS
+func cssColor(color string) string {
+	if code, ok := colorNames[strings.ToLower(color)]; ok {
+		color = code
+	}
+	if strings.HasPrefix(color, "#") {
+		return color
+	}
+	n, err := strconv.Atoi(color)
+	switch {
+	case err != nil || n < 0 || n > 255:
+		return ansiColors[8]
+	case n < 16:
+		return ansiColors[n]
+	case n < 232:
+		// The 6x6x6 color cube
+		level := func(v int) int {
+			if v == 0 {
+				return 0
+			}
+			return 55 + v*40
+		}
+		n -= 16
+		return fmt.Sprintf("#%02x%02x%02x", level(n/36), level(n/6%6), level(n%6))
+	}
+	gray := 8 + (n-232)*10
+	return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+}
+
+// conceptAnchor is the id of a concept's entry in the page's index
+func conceptAnchor(concept string) string {
+	return "concept-" + strings.Trim(slugRegex.ReplaceAllString(strings.ToLower(concept), "-"), "-")
+}
+
+// htmlNode is a node and its children as the page shows them
+type htmlNode struct {
+	ID        string
+	Pattern   string
+	Text      template.HTML
+	Code      bool
+	Collapsed bool
+	Badge     string // imprint sigil or name
+	Imprint   string
+	Color     string // imprint color
+	Children  []*htmlNode
+}
+
+// htmlConcept is a [[concept]] and the nodes mentioning it
+type htmlConcept struct {
+	Name   string
+	Anchor string
+	Nodes  []htmlMention
+}
+
+// htmlMention links to a node from the concept index
+type htmlMention struct {
+	ID   string
+	Text string
+}
+
+// NodeRecord is a node as exports describe it: in the HTML page's
+// embedded JSON and export --json
+type NodeRecord struct {
+	ID       string            `json:"id"`
+	Parent   string            `json:"parent,omitempty"`
+	Level    int               `json:"level"`
+	Text     string            `json:"text"`
+	Pattern  string            `json:"pattern,omitempty"`
+	Imprint  string            `json:"imprint,omitempty"`
+	Captured bool              `json:"captured"`
+	Created  time.Time         `json:"created"`
+	Modified time.Time         `json:"modified"`
+	Links    []string          `json:"links,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// htmlData is the page's embedded JSON, for downstream tooling
+type htmlData struct {
+	Title      string       `json:"title"`
+	ExportedAt time.Time    `json:"exported_at"`
+	Nodes      []NodeRecord `json:"nodes"`
+}
+
+// NodeRecords describes the outline's nodes in order, leaving out blank
+// lines and private and archived subtrees. Levels are nesting depths, so a
+// level jump nests under the node before it.
+func (o Outliner) NodeRecords() []NodeRecord {
+	records, _ := o.nodeRecords()
+	return records
+}
+
+// nodeRecords is NodeRecords with the index in o.lines of each record
+func (o Outliner) nodeRecords() ([]NodeRecord, []int) {
+	var records []NodeRecord
+	var lines []int
+	var ids []string // ids[i] is the node open at depth i
+	skipBelow := -1
+	for i, line := range o.lines {
+		if line.Kind == KindBlank || skipBelow >= 0 && line.Level > skipBelow {
+			continue
+		}
+		skipBelow = -1
+		if isPrivate(line.Text) || o.archived[line.ID] {
+			skipBelow = line.Level
+			continue
+		}
+
+		depth := min(line.Level, len(ids))
+		ids = ids[:depth]
+		parent := ""
+		if depth > 0 {
+			parent = ids[depth-1]
+		}
+		ids = append(ids, line.ID)
+
+		record := NodeRecord{
+			ID: line.ID, Parent: parent, Level: depth, Text: line.Text, Pattern: o.detectPatternType(line.Text),
+			Captured: line.Captured, Created: line.CreatedAt, Modified: line.ModifiedAt,
+			Links: o.extractLinks(line.Text), Metadata: line.Metadata,
+		}
+		if imprint := o.nodeImprint(line); imprint != nil {
+			record.Imprint = imprint.Name
+		}
+		records = append(records, record)
+		lines = append(lines, i)
+	}
+	return records, lines
+}
+
+// GetHTML returns the outline as a standalone HTML page: a collapsible
+// tree in the theme's pattern colors with imprint badges, [[links]] to a
+// concept index, and the nodes' metadata as JSON. Private and archived
+// subtrees are left out.
+func (o Outliner) GetHTML(title string) (string, error) {
+	records, lines := o.nodeRecords()
+	data := htmlData{Title: title, ExportedAt: time.Now(), Nodes: records}
+	var roots []*htmlNode
+	mentions := make(map[string][]htmlMention)
+
+	// stack[i] is the node open at depth i
+	var stack []*htmlNode
+	for r, record := range records {
+		line := o.lines[lines[r]]
+		node := &htmlNode{
+			ID:        record.ID,
+			Pattern:   record.Pattern,
+			Text:      o.htmlText(line.Text),
+			Code:      line.Kind == KindCode,
+			Collapsed: line.Collapsed,
+		}
+		if imprint := o.nodeImprint(line); imprint != nil {
+			node.Imprint = imprint.Name
+			node.Badge = imprint.Metadata["sigil"]
+			if node.Badge == "" {
+				node.Badge = imprint.Name
+			}
+			if color := imprint.Metadata["color"]; color != "" {
+				node.Color = cssColor(color)
+			}
+		}
+
+		stack = stack[:record.Level]
+		if record.Level == 0 {
+			roots = append(roots, node)
+		} else {
+			stack[record.Level-1].Children = append(stack[record.Level-1].Children, node)
+		}
+		stack = append(stack, node)
+
+		for _, link := range record.Links {
+			mentions[link] = append(mentions[link], htmlMention{ID: record.ID, Text: crumb(line.Text)})
+		}
+	}
+
+	concepts := make([]htmlConcept, 0, len(mentions))
+	for name, nodes := range mentions {
+		concepts = append(concepts, htmlConcept{Name: name, Anchor: conceptAnchor(name), Nodes: nodes})
+	}
+	sort.Slice(concepts, func(i, j int) bool { return concepts[i].Name < concepts[j].Name })
+
+	var css strings.Builder
+	patterns := make([]string, 0, len(o.theme.Patterns))
+	for pattern := range o.theme.Patterns {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		weight := "normal"
+		if boldPatterns[pattern] {
+			weight = "bold"
+		}
+		fmt.Fprintf(&css, ".p-%[1]s > .node > .text, .p-%[1]s > details > .node > .text { color: %[2]s; font-weight: %[3]s; }\n", pattern, cssColor(o.theme.Patterns[pattern]), weight)
+	}
+
+	var out strings.Builder
+	err := htmlTemplate.Execute(&out, map[string]any{
+		"Title":    title,
+		"Accent":   template.CSS(cssColor(o.theme.Accent)),
+		"Patterns": template.CSS(css.String()),
+		"Nodes":    roots,
+		"Concepts": concepts,
+		"Data":     data,
+	})
+	if err != nil {
+		return "", fmt.Errorf("render HTML: %w", err)
+	}
+	return out.String(), nil
+}
+
+// htmlText escapes node text, turning [[concepts]] into links to the
+// concept index and [[file#^id]] links into links to the node's page
+func (o Outliner) htmlText(text string) template.HTML {
+	escaped := html.EscapeString(text)
+	return template.HTML(wikiLinkRegex.ReplaceAllStringFunc(escaped, func(match string) string {
+		target := html.UnescapeString(strings.TrimSpace(match[2 : len(match)-2]))
+		href := "#" + conceptAnchor(target)
+		if path, id, ok := ParseNodeRef(target); ok {
+			href = "#node-" + id
+			if path != "" {
+				href = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + ".html" + href
+			}
+		}
+		return `<a class="link" href="` + html.EscapeString(href) + `">` + match + `</a>`
+	}))
+}
+
+var htmlTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { background: #1c1c1c; color: #d0d0d0; font: 15px/1.6 ui-monospace, Menlo, Consolas, monospace; margin: 2rem auto; max-width: 60rem; padding: 0 1rem; }
+h1 { color: {{.Accent}}; font-size: 1.3rem; }
+ul { list-style: none; margin: 0; padding-left: 1.5rem; }
+summary { cursor: pointer; }
+summary::marker { color: {{.Accent}}; }
+li > div.node::before { content: "• "; color: {{.Accent}}; }
+.link { color: #87afff; text-decoration: none; }
+.link:hover { text-decoration: underline; }
+.badge { border-radius: 0.3rem; font-size: 0.8rem; margin-left: 0.5rem; padding: 0 0.3rem; border: 1px solid currentColor; }
+pre { margin: 0; }
+:target > .node, :target > details > summary { background: #303030; }
+{{.Patterns}}
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<ul class="outline">
+{{- range .Nodes}}{{template "node" .}}{{end}}
+</ul>
+{{- if .Concepts}}
+<h1>Concepts</h1>
+<ul class="concepts">
+{{- range .Concepts}}
+<li id="{{.Anchor}}">[[{{.Name}}]]: {{range $i, $n := .Nodes}}{{if $i}}, {{end}}<a class="link" href="#node-{{$n.ID}}">{{$n.Text}}</a>{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+<script type="application/json" id="float-metadata">{{.Data}}</script>
+</body>
+</html>
+{{define "node"}}
+<li id="node-{{.ID}}"{{if .Pattern}} class="p-{{.Pattern}}"{{end}}>
+{{- if .Children}}<details{{if not .Collapsed}} open{{end}}><summary class="node">{{template "text" .}}</summary>
+<ul>{{range .Children}}{{template "node" .}}{{end}}</ul>
+</details>
+{{- else}}<div class="node">{{template "text" .}}</div>{{end}}
+</li>
+{{- end}}
+{{define "text"}}<span class="text">{{if .Code}}<pre><code>{{.Text}}</code></pre>{{else}}{{.Text}}{{end}}</span>
+{{- if .Badge}}<span class="badge" title="{{.Imprint}}"{{if .Color}} style="color: {{.Color}}"{{end}}>{{.Badge}}</span>{{end}}
+{{- end}}
+`))
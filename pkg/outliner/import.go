@@ -0,0 +1,582 @@
+package outliner
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Outliners whose exports Import reads
+const (
+	ImportRoam      = "roam"      // JSON or EDN export
+	ImportWorkflowy = "workflowy" // OPML export or JSON backup
+	ImportDynalist  = "dynalist"  // OPML export or the API's document JSON
+)
+
+// ImportFormats lists the outliners Import reads, for help and errors
+var ImportFormats = []string{ImportRoam, ImportWorkflowy, ImportDynalist}
+
+// importBlock is a block of another outliner's export on its way to
+// becoming nodes
+type importBlock struct {
+	id          string
+	text        string // may span lines; a ``` fence becomes code nodes
+	note        string
+	heading     int
+	task, done  bool
+	created     time.Time
+	modified    time.Time
+	annotations []Annotation
+	children    []importBlock
+}
+
+var (
+	// importRefRegex matches a ((block)) ref or a [[#^node]] link, which
+	// need the node they name to keep its ID
+	importRefRegex = regexp.MustCompile(`\(\(([\w-]+)\)\)|\[\[#\^([\w-]+)\]\]`)
+
+	// annotationKeyRegex is what an attribute's key must become to be
+	// written as a [key:: value] annotation
+	annotationKeyRegex = regexp.MustCompile(`^[\w-]+$`)
+)
+
+// ParseImport converts an export from another outliner into nodes. Blocks
+// keep their nesting, IDs and timestamps; attributes, dates and completion
+// become annotations and [ ]/[x] tasks.
+func ParseImport(from string, data []byte) ([]OutlineNode, error) {
+	var blocks []importBlock
+	var err error
+	switch from {
+	case ImportRoam:
+		blocks, err = parseRoam(data)
+	case ImportWorkflowy:
+		blocks, err = parseWorkflowy(data)
+	case ImportDynalist:
+		blocks, err = parseDynalist(data)
+	default:
+		return nil, fmt.Errorf("unknown import format %q: use %s", from, strings.Join(ImportFormats, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := map[string]bool{}
+	var refs func(blocks []importBlock)
+	refs = func(blocks []importBlock) {
+		for _, b := range blocks {
+			for _, match := range importRefRegex.FindAllStringSubmatch(b.text, -1) {
+				referenced[match[1]+match[2]] = true
+			}
+			refs(b.children)
+		}
+	}
+	refs(blocks)
+
+	var nodes []OutlineNode
+	var walk func(blocks []importBlock, level int)
+	walk = func(blocks []importBlock, level int) {
+		for _, b := range blocks {
+			nodes = append(nodes, b.nodes(level, referenced[b.id])...)
+			for _, line := range strings.Split(b.note, "\n") {
+				if strings.TrimSpace(line) != "" {
+					nodes = append(nodes, newNode("note:: "+strings.TrimSpace(line), level+1))
+				}
+			}
+			walk(b.children, level+1)
+		}
+	}
+	walk(blocks, 0)
+
+	for i := range nodes {
+		if nodes[i].Kind.Captured() {
+			nodes[i].PatternType = Patterns.Type(nodes[i].Text)
+		}
+	}
+	markChildren(nodes)
+	return nodes, nil
+}
+
+// Import replaces the outline with an export from another outliner
+func (o *Outliner) Import(from string, data []byte) error {
+	nodes, err := ParseImport(from, data)
+	if err != nil {
+		return err
+	}
+	o.loadNodes(nodes)
+	return nil
+}
+
+// nodes is the block as nodes at level: one, or a fence and its code when
+// the block is a code block. A block other nodes refer to keeps its ID as
+// an [id:: ...] annotation.
+func (b importBlock) nodes(level int, referenced bool) []OutlineNode {
+	lines := strings.Split(strings.TrimSpace(b.text), "\n")
+	first := strings.TrimSpace(lines[0])
+
+	annotations := b.annotations
+	if referenced {
+		annotations = append(annotations, Annotation{Key: "id", Value: b.id})
+	}
+	switch {
+	case b.done:
+		first = "[x] " + first
+	case b.task:
+		first = "[ ] " + first
+	}
+	if b.heading > 0 {
+		first = strings.Repeat("#", min(b.heading, 6)) + " " + first
+	}
+
+	node := newNode(FormatAnnotations(first, Patterns.Type(first), annotations), level)
+	if b.id != "" {
+		node.ID = b.id
+	}
+	if !b.created.IsZero() {
+		node.CreatedAt, node.ModifiedAt = b.created, b.created
+	}
+	if !b.modified.IsZero() {
+		node.ModifiedAt = b.modified
+	}
+	if b.heading > 0 {
+		node.Kind = KindHeading
+	}
+	nodes := []OutlineNode{node}
+	if len(lines) == 1 {
+		return nodes
+	}
+
+	if strings.HasPrefix(first, codeFence) {
+		// The code goes under its fence, which is closed at its level
+		nodes[0].Kind = KindCode
+		for _, line := range lines[1:] {
+			line = strings.TrimSuffix(line, codeFence)
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			code := newNode(line, level+1)
+			code.Kind = KindCode
+			nodes = append(nodes, code)
+		}
+		fence := newNode(codeFence, level)
+		fence.Kind = KindCode
+		return append(nodes, fence)
+	}
+
+	// Soft line breaks: the rest of the block goes under its first line
+	for _, line := range lines[1:] {
+		if line = strings.TrimSpace(line); line != "" {
+			more := newNode(line, level+1)
+			more.Kind = KindParagraph
+			nodes = append(nodes, more)
+		}
+	}
+	return nodes
+}
+
+// annotationKey is an attribute name as an annotation key: "Due Date"
+// becomes "due-date"
+func annotationKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), "-"))
+}
+
+// msTime is a Unix time in milliseconds, zero for 0
+func msTime(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// Roam
+
+// roamBlock is a page or block of a Roam JSON export; EDN exports are read
+// into the same shape
+type roamBlock struct {
+	Title    string      `json:"title"`
+	String   string      `json:"string"`
+	UID      string      `json:"uid"`
+	Heading  int         `json:"heading"`
+	Created  int64       `json:"create-time"`
+	Edited   int64       `json:"edit-time"`
+	Children []roamBlock `json:"children"`
+	order    int
+}
+
+var (
+	// roamAttrRegex matches a Roam attribute block, "Name:: value"
+	roamAttrRegex = regexp.MustCompile(`^([^:\[\]\n]+?)::\s*(.*)$`)
+
+	// roamTaskRegex matches a Roam to-do's {{[[TODO]]}} or {{[[DONE]]}}
+	roamTaskRegex = regexp.MustCompile(`^\{\{(?:\[\[)?(TODO|DONE)(?:\]\])?\}\}\s*`)
+
+	// roamEmbedRegex matches an embed of one block, {{embed: ((uid))}}
+	roamEmbedRegex = regexp.MustCompile(`^\{\{(?:\[\[)?embed(?:\]\])?:\s*(\(\([\w-]+\)\))\s*\}\}$`)
+
+	// roamRefRegex matches a ((uid)) block ref within text
+	roamRefRegex = regexp.MustCompile(`\(\(([\w-]+)\)\)`)
+
+	// roamTagRegex matches a #[[multi word]] tag
+	roamTagRegex = regexp.MustCompile(`#\[\[([^\]]+)\]\]`)
+
+	// roamDailyRegex matches a daily note page's title, "May 1st, 2024"
+	roamDailyRegex = regexp.MustCompile(`^([A-Z][a-z]+) (\d{1,2})(?:st|nd|rd|th), (\d{4})$`)
+)
+
+// parseRoam reads a Roam JSON export, a list of pages, or an EDN one, a
+// dump of the graph's datoms
+func parseRoam(data []byte) ([]importBlock, error) {
+	var pages []roamBlock
+	if content := strings.TrimSpace(string(data)); strings.HasPrefix(content, "[") {
+		if err := json.Unmarshal(data, &pages); err != nil {
+			return nil, fmt.Errorf("parse Roam JSON: %w", err)
+		}
+	} else {
+		var err error
+		if pages, err = parseRoamEDN(content); err != nil {
+			return nil, err
+		}
+	}
+
+	blocks := make([]importBlock, 0, len(pages))
+	for _, page := range pages {
+		b := roamToBlock(page)
+		b.text, b.heading = page.Title, 1
+		if m := roamDailyRegex.FindStringSubmatch(page.Title); m != nil {
+			if day, err := time.Parse("January 2 2006", m[1]+" "+m[2]+" "+m[3]); err == nil {
+				b.annotations = append(b.annotations, Annotation{Key: "date", Value: day.Format("2006-01-02")})
+			}
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, nil
+}
+
+// roamToBlock converts a Roam block. Attribute children, "Status:: done",
+// become annotations on it unless they name a pattern type or have
+// children of their own.
+func roamToBlock(r roamBlock) importBlock {
+	b := importBlock{
+		id:       r.UID,
+		text:     roamText(r.String),
+		heading:  r.Heading,
+		created:  msTime(r.Created),
+		modified: msTime(r.Edited),
+	}
+	if m := roamTaskRegex.FindStringSubmatch(r.String); m != nil {
+		b.task, b.done = true, m[1] == "DONE"
+	}
+
+	for _, child := range r.Children {
+		if m := roamAttrRegex.FindStringSubmatch(child.String); m != nil && len(child.Children) == 0 {
+			key := annotationKey(m[1])
+			// Annotation values can't hold brackets, so links lose theirs
+			value := strings.NewReplacer("#[[", "", "[[", "", "]]", "").Replace(m[2])
+			if !Patterns.Known(key) && annotationKeyRegex.MatchString(key) && !strings.ContainsAny(value, "[]") {
+				b.annotations = append(b.annotations, Annotation{Key: key, Value: strings.TrimSpace(value)})
+				continue
+			}
+			if Patterns.Known(key) {
+				child.String = key + ":: " + m[2]
+			}
+		}
+		b.children = append(b.children, roamToBlock(child))
+	}
+	return b
+}
+
+// roamText rewrites Roam markup the outliner reads differently: block
+// embeds become mirrors, refs within text [[#^uid]] links, and #[[tags]]
+// plain links
+func roamText(s string) string {
+	s = roamTaskRegex.ReplaceAllString(s, "")
+	if m := roamEmbedRegex.FindStringSubmatch(s); m != nil {
+		return m[1]
+	}
+	if mirrorRefRegex.MatchString(s) {
+		return s
+	}
+	s = roamRefRegex.ReplaceAllString(s, "[[#^$1]]")
+	return roamTagRegex.ReplaceAllString(s, "[[$1]]")
+}
+
+// parseRoamEDN reads the pages out of an EDN export's datoms, each an
+// [entity attribute value transaction] fact
+func parseRoamEDN(content string) ([]roamBlock, error) {
+	value, err := parseEDN(content)
+	if err != nil {
+		return nil, err
+	}
+	db, ok := value.(ednMap)
+	if !ok {
+		return nil, fmt.Errorf("parse Roam EDN: not a database export")
+	}
+	datoms, ok := db.get("datoms").([]any)
+	if !ok {
+		return nil, fmt.Errorf("parse Roam EDN: no :datoms")
+	}
+
+	entities := map[int64]*roamBlock{}
+	children := map[int64][]int64{}
+	entity := func(id int64) *roamBlock {
+		if entities[id] == nil {
+			entities[id] = &roamBlock{}
+		}
+		return entities[id]
+	}
+	for _, d := range datoms {
+		datom, ok := d.([]any)
+		if !ok || len(datom) < 3 {
+			continue
+		}
+		id, _ := datom[0].(int64)
+		attr, _ := datom[1].(ednKeyword)
+		str, _ := datom[2].(string)
+		n, _ := datom[2].(int64)
+		switch e := entity(id); attr {
+		case "node/title":
+			e.Title = str
+		case "block/string":
+			e.String = str
+		case "block/uid":
+			e.UID = str
+		case "block/heading":
+			e.Heading = int(n)
+		case "block/order":
+			e.order = int(n)
+		case "create/time":
+			e.Created = n
+		case "edit/time":
+			e.Edited = n
+		case "block/children":
+			children[id] = append(children[id], n)
+		}
+	}
+
+	var build func(id int64) roamBlock
+	build = func(id int64) roamBlock {
+		b := *entities[id]
+		for _, child := range children[id] {
+			if entities[child] != nil {
+				b.Children = append(b.Children, build(child))
+			}
+		}
+		sort.SliceStable(b.Children, func(i, j int) bool { return b.Children[i].order < b.Children[j].order })
+		return b
+	}
+	var pages []roamBlock
+	for id, e := range entities {
+		if e.Title != "" {
+			pages = append(pages, build(id))
+		}
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].Title < pages[j].Title })
+	return pages, nil
+}
+
+// Workflowy
+
+// workflowyItem is an item of a Workflowy backup. Its times count from the
+// account's creation, which the backup doesn't record, so they're dropped.
+type workflowyItem struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"nm"`
+	Note      string          `json:"no"`
+	Completed json.RawMessage `json:"cp"`
+	Children  []workflowyItem `json:"ch"`
+}
+
+var (
+	// workflowyTimeRegex matches a date Workflowy writes into text
+	workflowyTimeRegex = regexp.MustCompile(`<time\s([^>]*)>.*?</time>`)
+
+	// htmlAttrRegex matches an HTML tag's name="value" attribute
+	htmlAttrRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+	// workflowyMarkup is Workflowy's inline HTML and its markdown
+	workflowyMarkup = []struct {
+		regex *regexp.Regexp
+		repl  string
+	}{
+		{regexp.MustCompile(`<b>(.*?)</b>`), "**$1**"},
+		{regexp.MustCompile(`<i>(.*?)</i>`), "*$1*"},
+		{regexp.MustCompile(`<s>(.*?)</s>`), "~~$1~~"},
+		{regexp.MustCompile(`<code>(.*?)</code>`), "`$1`"},
+		{regexp.MustCompile(`<a\s[^>]*href="([^"]*)"[^>]*>(.*?)</a>`), "[$2]($1)"},
+		{regexp.MustCompile(`</?[a-zA-Z][^>]*>`), ""}, // <u>, <span class="colored ...">
+	}
+)
+
+// parseWorkflowy reads a Workflowy OPML export or JSON backup
+func parseWorkflowy(data []byte) ([]importBlock, error) {
+	content := strings.TrimSpace(string(data))
+	if strings.HasPrefix(content, "<") {
+		return parseOutlinerOPML(content, workflowyText)
+	}
+
+	var items []workflowyItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("parse Workflowy backup: %w", err)
+	}
+	var convert func(items []workflowyItem) []importBlock
+	convert = func(items []workflowyItem) []importBlock {
+		blocks := make([]importBlock, 0, len(items))
+		for _, item := range items {
+			text, annotations := workflowyText(item.Name)
+			done := len(item.Completed) > 0 && string(item.Completed) != "null"
+			blocks = append(blocks, importBlock{
+				id:          item.ID,
+				text:        text,
+				note:        html.UnescapeString(item.Note),
+				done:        done,
+				annotations: annotations,
+				children:    convert(item.Children),
+			})
+		}
+		return blocks
+	}
+	return convert(items), nil
+}
+
+// workflowyText turns Workflowy's inline HTML into markdown, and the dates
+// in it into a [date:: ...] annotation
+func workflowyText(s string) (string, []Annotation) {
+	var dates []string
+	s = workflowyTimeRegex.ReplaceAllStringFunc(s, func(tag string) string {
+		attrs := map[string]int{}
+		for _, attr := range htmlAttrRegex.FindAllStringSubmatch(tag, -1) {
+			attrs[attr[1]], _ = strconv.Atoi(attr[2])
+		}
+		if attrs["startYear"] == 0 {
+			return tag
+		}
+		date := fmt.Sprintf("%04d-%02d-%02d", attrs["startYear"], max(attrs["startMonth"], 1), max(attrs["startDay"], 1))
+		if _, ok := attrs["startHour"]; ok {
+			date += fmt.Sprintf(" %02d:%02d", attrs["startHour"], attrs["startMinute"])
+		}
+		dates = append(dates, date)
+		return ""
+	})
+	for _, m := range workflowyMarkup {
+		s = m.regex.ReplaceAllString(s, m.repl)
+	}
+	s = strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+	if len(dates) == 0 {
+		return s, nil
+	}
+	return s, []Annotation{{Key: "date", Value: strings.Join(dates, ", ")}}
+}
+
+// parseOutlinerOPML reads a Workflowy or Dynalist OPML export, whose
+// _note, _complete (Workflowy) and checked/checkbox (Dynalist) attributes
+// become notes and tasks; text converts the outline's text
+func parseOutlinerOPML(content string, text func(string) (string, []Annotation)) ([]importBlock, error) {
+	var doc opmlDocument
+	if err := xml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, fmt.Errorf("parse OPML: %w", err)
+	}
+	var convert func(outlines []opmlOutline) []importBlock
+	convert = func(outlines []opmlOutline) []importBlock {
+		blocks := make([]importBlock, 0, len(outlines))
+		for _, outline := range outlines {
+			b := importBlock{note: opmlAttr(outline.Attrs, opmlAttrNote), children: convert(outline.Children)}
+			b.text, b.annotations = text(outline.Text)
+			b.done = opmlAttr(outline.Attrs, "_complete") == "true" || opmlAttr(outline.Attrs, "checked") == "true"
+			b.task = opmlAttr(outline.Attrs, "checkbox") == "true"
+			b.heading, _ = strconv.Atoi(opmlAttr(outline.Attrs, "heading"))
+			blocks = append(blocks, b)
+		}
+		return blocks
+	}
+	return convert(doc.Body.Children), nil
+}
+
+// Dynalist
+
+// dynalistDocument is a document as Dynalist's API reads it out: a flat
+// list of nodes naming their children, under one with the ID "root"
+type dynalistDocument struct {
+	Nodes []struct {
+		ID       string   `json:"id"`
+		Content  string   `json:"content"`
+		Note     string   `json:"note"`
+		Checked  bool     `json:"checked"`
+		Checkbox bool     `json:"checkbox"`
+		Heading  int      `json:"heading"`
+		Created  int64    `json:"created"`
+		Modified int64    `json:"modified"`
+		Children []string `json:"children"`
+	} `json:"nodes"`
+}
+
+// dynalistDateRegex matches a Dynalist date, "!(2024-05-01 10:00)", with
+// any repeat after its time
+var dynalistDateRegex = regexp.MustCompile(`\s*!\((\d{4}-\d{2}-\d{2}(?: \d{1,2}:\d{2})?)[^)]*\)`)
+
+// parseDynalist reads a Dynalist OPML export or API document
+func parseDynalist(data []byte) ([]importBlock, error) {
+	content := strings.TrimSpace(string(data))
+	if strings.HasPrefix(content, "<") {
+		return parseOutlinerOPML(content, dynalistText)
+	}
+
+	var doc dynalistDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse Dynalist document: %w", err)
+	}
+	byID := map[string]int{}
+	for i, n := range doc.Nodes {
+		byID[n.ID] = i
+	}
+	root, ok := byID["root"]
+	if !ok {
+		return nil, fmt.Errorf("parse Dynalist document: no root node")
+	}
+
+	seen := map[string]bool{} // a malformed document can't loop forever
+	var convert func(ids []string) []importBlock
+	convert = func(ids []string) []importBlock {
+		var blocks []importBlock
+		for _, id := range ids {
+			i, ok := byID[id]
+			if !ok || seen[id] {
+				continue
+			}
+			seen[id] = true
+			n := doc.Nodes[i]
+			b := importBlock{
+				id:       n.ID,
+				note:     n.Note,
+				heading:  n.Heading,
+				task:     n.Checkbox,
+				done:     n.Checked,
+				created:  msTime(n.Created),
+				modified: msTime(n.Modified),
+				children: convert(n.Children),
+			}
+			b.text, b.annotations = dynalistText(n.Content)
+			blocks = append(blocks, b)
+		}
+		return blocks
+	}
+	return convert(doc.Nodes[root].Children), nil
+}
+
+// dynalistText moves a Dynalist item's dates into a [date:: ...]
+// annotation; the rest is markdown already
+func dynalistText(s string) (string, []Annotation) {
+	var dates []string
+	for _, m := range dynalistDateRegex.FindAllStringSubmatch(s, -1) {
+		dates = append(dates, m[1])
+	}
+	if len(dates) == 0 {
+		return s, nil
+	}
+	s = strings.TrimSpace(dynalistDateRegex.ReplaceAllString(s, ""))
+	return s, []Annotation{{Key: "date", Value: strings.Join(dates, ", ")}}
+}
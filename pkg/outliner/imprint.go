@@ -0,0 +1,194 @@
+package outliner
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// colorNames maps the color names imprint metadata uses to terminal colors;
+// anything else (ANSI numbers, hex) is used as is
+var colorNames = map[string]string{
+	"red":     "9",
+	"green":   "10",
+	"yellow":  "11",
+	"blue":    "12",
+	"magenta": "13",
+	"pink":    "13",
+	"cyan":    "14",
+	"white":   "15",
+	"purple":  "5",
+	"orange":  "208",
+	"gray":    "8",
+}
+
+// colorCode resolves a color name to a lipgloss color
+func colorCode(color string) lipgloss.Color {
+	if code, ok := colorNames[strings.ToLower(color)]; ok {
+		return lipgloss.Color(code)
+	}
+	return lipgloss.Color(color)
+}
+
+// nodeImprint returns the imprint node was dispatched to, nil when it
+// hasn't been or has changed since
+func (o *Outliner) nodeImprint(node OutlineNode) *Imprint {
+	name := o.imprintOf[node.ID]
+	if name == "" || !node.Captured {
+		return nil
+	}
+	return o.dispatch.GetImprint(name)
+}
+
+// imprintBadge renders an imprint's sigil in its color, or its name when
+// it has no sigil
+func imprintBadge(imprint *Imprint) string {
+	badge := imprint.Metadata["sigil"]
+	if badge == "" {
+		badge = imprint.Name
+	}
+	style := lipgloss.NewStyle()
+	if color := imprint.Metadata["color"]; color != "" {
+		style = style.Foreground(colorCode(color))
+	}
+	return style.Render(badge)
+}
+
+// imprintView lists captured nodes grouped by imprint, in imprint order,
+// for reviewing what each ritual container received
+type imprintView struct {
+	rows   []imprintRow
+	cursor int // into rows, always on a node
+}
+
+// imprintRow is a group heading (no node) or a node in the group above.
+// Nodes are kept by ID: reducer updates may insert nodes while it's open.
+type imprintRow struct {
+	imprint string
+	count   int // nodes in the group, for headings
+	node    string
+}
+
+// IsImprintViewOpen reports whether the grouped imprint view is shown
+func (o *Outliner) IsImprintViewOpen() bool {
+	return o.imprintView != nil
+}
+
+// toggleImprintView opens the imprint view on the current node, or closes it
+func (o *Outliner) toggleImprintView() {
+	if o.imprintView != nil {
+		o.imprintView = nil
+		return
+	}
+
+	groups := make(map[string][]int)
+	for i := range o.lines {
+		if imprint := o.nodeImprint(o.lines[i]); imprint != nil {
+			groups[imprint.Name] = append(groups[imprint.Name], i)
+		}
+	}
+
+	view := &imprintView{cursor: -1}
+	for _, name := range o.dispatch.ImprintNames() {
+		nodes := groups[name]
+		if len(nodes) == 0 {
+			continue
+		}
+		view.rows = append(view.rows, imprintRow{imprint: name, count: len(nodes)})
+		for _, i := range nodes {
+			if i == o.cursor || view.cursor < 0 {
+				view.cursor = len(view.rows)
+			}
+			view.rows = append(view.rows, imprintRow{imprint: name, node: o.lines[i].ID})
+		}
+	}
+	o.imprintView = view
+}
+
+// updateImprintView moves through the view; enter jumps to the node
+func (o *Outliner) updateImprintView(msg tea.KeyMsg) {
+	view := o.imprintView
+	switch msg.String() {
+	case "up", "k", "ctrl+p":
+		view.move(-1)
+	case "down", "j", "ctrl+n":
+		view.move(1)
+	case "enter":
+		if i := o.nodeIndex(view.selected()); i >= 0 {
+			o.cursor = i
+			o.cursorPos = len(o.lines[i].Text)
+			o.revealCursor()
+		}
+		o.imprintView = nil
+	case "esc", "q", "alt+i":
+		o.imprintView = nil
+	}
+}
+
+// selected is the node ID under the cursor, "" when there are none
+func (v *imprintView) selected() string {
+	if v.cursor < 0 {
+		return ""
+	}
+	return v.rows[v.cursor].node
+}
+
+// move steps the cursor by delta nodes, skipping headings
+func (v *imprintView) move(delta int) {
+	for i := v.cursor + delta; i >= 0 && i < len(v.rows); i += delta {
+		if v.rows[i].node != "" {
+			v.cursor = i
+			return
+		}
+	}
+}
+
+// renderImprintView renders rows visible rows of the view around its cursor
+func (o *Outliner) renderImprintView(rows int) string {
+	view := o.imprintView
+	if view.cursor < 0 {
+		return "Imprint view: no captured nodes yet (esc to close)"
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Imprint view: %d captured (↑↓ move, enter jump, esc close)", len(view.rows)-o.imprintGroups()))
+	start := max(0, min(view.cursor-rows/2, len(view.rows)-rows))
+	for r := start; r < len(view.rows) && r < start+rows; r++ {
+		row := view.rows[r]
+		b.WriteString("\n")
+		imprint := o.dispatch.GetImprint(row.imprint)
+		if row.node == "" {
+			style := lipgloss.NewStyle().Bold(true)
+			if color := imprint.Metadata["color"]; color != "" {
+				style = style.Foreground(colorCode(color))
+			}
+			b.WriteString(style.Render(fmt.Sprintf("%s %s (%d)", imprint.Metadata["sigil"], row.imprint, row.count)))
+			continue
+		}
+
+		i := o.nodeIndex(row.node)
+		if i < 0 {
+			b.WriteString("  (deleted)")
+			continue
+		}
+		text := "  " + imprintBadge(imprint) + " " + o.theme.patternStyle(o.detectPatternType(o.lines[i].Text)).Render(o.lines[i].Text)
+		if r == view.cursor {
+			text = o.highlightStyle.Render(text)
+		}
+		b.WriteString(text)
+	}
+	return b.String()
+}
+
+// imprintGroups is how many group headings the view has
+func (o *Outliner) imprintGroups() int {
+	n := 0
+	for _, row := range o.imprintView.rows {
+		if row.node == "" {
+			n++
+		}
+	}
+	return n
+}
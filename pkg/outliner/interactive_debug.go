@@ -11,6 +11,9 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
 )
 
 // InteractiveDebugPanel is an enhanced version of ConsciousnessDebugPanel
@@ -186,7 +189,8 @@ func NewInteractiveDebugPanel() *InteractiveDebugPanel {
 		Foreground(lipgloss.Color("255"))
 
 	idp.messageList = list.New([]list.Item{}, delegate, 0, 0)
-	idp.messageList.Title = "🧠 Consciousness Debug Messages"
+	idp.messageList.Title = i18n.T("debug.title")
+	idp.messageList.SetStatusBarItemName(i18n.T("debug.item"), i18n.T("debug.items"))
 	idp.messageList.SetShowHelp(false)
 	idp.messageList.DisableQuitKeybindings()
 	idp.messageList.SetFilteringEnabled(false) // We'll handle filtering ourselves
@@ -235,6 +239,17 @@ func (idp *InteractiveDebugPanel) AddConsciousnessCapture(action, collection str
 	idp.AddMessage("CONSCIOUSNESS_CAPTURE", content, DebugLevelInfo)
 }
 
+// AddRedaction records what the redaction rules changed in something sent
+// out of the process, or that they left nothing to send
+func (idp *InteractiveDebugPanel) AddRedaction(target string, redactions Redactions, withheld bool) {
+	content := fmt.Sprintf("%s: %s", target, redactions)
+	if withheld {
+		idp.AddMessage("REDACTED", content+" (withheld)", DebugLevelWarning)
+		return
+	}
+	idp.AddMessage("REDACTED", content, DebugLevelInfo)
+}
+
 // AddReducerCreated adds a reducer creation message
 func (idp *InteractiveDebugPanel) AddReducerCreated(name, query string) {
 	content := fmt.Sprintf("%s: %s", name, query)
@@ -420,30 +435,31 @@ func (idp *InteractiveDebugPanel) View(width, height int) string {
 	if idp.focused {
 		switch idp.viewMode {
 		case ViewModeList:
-			helpText = "↑/↓: navigate • enter: inspect • f: filter • esc: exit focus"
+			helpText = i18n.T("debug.list_help")
 		case ViewModeDetail:
-			helpText = "↑/↓: scroll • c: copy • esc: back"
+			helpText = i18n.T("debug.detail_help")
 		}
 	} else {
-		helpText = "ctrl+l: focus debug panel"
+		helpText = i18n.T("debug.focus_help")
 	}
 
 	helpStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("241")).
-		Align(lipgloss.Center)
+		Align(lipgloss.Center).
+		Width(cells.Inner(style, width))
 
 	// Calculate available space
-	availableWidth := width - 4   // Account for padding and borders
 	availableHeight := height - 4 // Account for padding, borders, and help text
 
-	// Render panel with content and help text
-	return style.
-		Width(availableWidth).
+	// Render panel with content and help text; a list taller than a short
+	// panel is cut off
+	content = lipgloss.NewStyle().MaxHeight(max(1, availableHeight-1)).Render(content)
+	return cells.Frame(style, width).
 		Height(availableHeight).
 		Render(lipgloss.JoinVertical(
 			lipgloss.Left,
 			content,
-			helpStyle.Render(helpText),
+			helpStyle.Render(cells.Cut(helpText, cells.Inner(style, width))), // one row, however narrow
 		))
 }
 
@@ -505,23 +521,23 @@ func (idp *InteractiveDebugPanel) updateDetailView() {
 	var detailContent strings.Builder
 
 	// Header
-	detailContent.WriteString(idp.headerStyle.Render(fmt.Sprintf("Message Details: %s\n\n", idp.expandedMsg.Type)))
+	detailContent.WriteString(idp.headerStyle.Render(i18n.T("debug.details", idp.expandedMsg.Type) + "\n\n"))
 
 	// Basic info
 	detailContent.WriteString(fmt.Sprintf("%s %s\n",
-		idp.keyStyle.Render("Timestamp:"),
+		idp.keyStyle.Render(i18n.T("debug.timestamp")),
 		idp.valueStyle.Render(idp.expandedMsg.Timestamp.Format("2006-01-02 15:04:05.000"))))
 
 	detailContent.WriteString(fmt.Sprintf("%s %s\n",
-		idp.keyStyle.Render("Type:"),
+		idp.keyStyle.Render(i18n.T("debug.type")),
 		idp.valueStyle.Render(idp.expandedMsg.Type)))
 
 	detailContent.WriteString(fmt.Sprintf("%s %s\n",
-		idp.keyStyle.Render("Level:"),
+		idp.keyStyle.Render(i18n.T("debug.level")),
 		idp.valueStyle.Render(string(idp.expandedMsg.Level))))
 
 	detailContent.WriteString(fmt.Sprintf("%s %s\n\n",
-		idp.keyStyle.Render("Content:"),
+		idp.keyStyle.Render(i18n.T("debug.content")),
 		idp.valueStyle.Render(idp.expandedMsg.Content)))
 
 	// For FLOAT_DISPATCH messages, parse and display structured data
@@ -548,18 +564,18 @@ func (idp *InteractiveDebugPanel) updateDetailView() {
 			}
 
 			// Display structured data
-			detailContent.WriteString(idp.headerStyle.Render("Structured Data:\n\n"))
+			detailContent.WriteString(idp.headerStyle.Render(i18n.T("debug.structured") + "\n\n"))
 			detailContent.WriteString(fmt.Sprintf("%s %s\n",
-				idp.keyStyle.Render("Pattern Type:"),
+				idp.keyStyle.Render(i18n.T("debug.pattern_type")),
 				idp.valueStyle.Render(patternType)))
 			detailContent.WriteString(fmt.Sprintf("%s %s\n",
-				idp.keyStyle.Render("Imprint:"),
+				idp.keyStyle.Render(i18n.T("debug.imprint")),
 				idp.valueStyle.Render(imprint)))
 			detailContent.WriteString(fmt.Sprintf("%s %s\n",
-				idp.keyStyle.Render("Sigil:"),
+				idp.keyStyle.Render(i18n.T("debug.sigil")),
 				idp.valueStyle.Render(sigil)))
 			detailContent.WriteString(fmt.Sprintf("%s %s\n\n",
-				idp.keyStyle.Render("Dispatch ID:"),
+				idp.keyStyle.Render(i18n.T("debug.dispatch_id")),
 				idp.valueStyle.Render(dispatchID)))
 		}
 	}
@@ -567,7 +583,7 @@ func (idp *InteractiveDebugPanel) updateDetailView() {
 	// Add JSON representation for advanced inspection
 	jsonData, err := json.MarshalIndent(idp.expandedMsg, "", "  ")
 	if err == nil {
-		detailContent.WriteString(idp.headerStyle.Render("JSON Representation:\n\n"))
+		detailContent.WriteString(idp.headerStyle.Render(i18n.T("debug.json") + "\n\n"))
 		detailContent.WriteString(idp.valueStyle.Render(string(jsonData)))
 	}
 
@@ -578,7 +594,7 @@ func (idp *InteractiveDebugPanel) updateDetailView() {
 
 // updateComponentSizes updates the sizes of UI components
 func (idp *InteractiveDebugPanel) updateComponentSizes(width, height int) {
-	availableWidth := width - 6   // Account for padding and borders
+	availableWidth := cells.Inner(idp.focusedStyle, width)
 	availableHeight := height - 6 // Account for padding, borders, and help text
 
 	// Update list size
@@ -594,6 +610,11 @@ func (idp *InteractiveDebugPanel) GetMessageCount() int {
 	return len(idp.messages)
 }
 
+// Messages returns the kept messages, oldest first
+func (idp *InteractiveDebugPanel) Messages() []DebugMessage {
+	return idp.messages
+}
+
 // Clear clears all debug messages
 func (idp *InteractiveDebugPanel) Clear() {
 	idp.messages = []DebugMessage{}
@@ -0,0 +1,128 @@
+package outliner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// JumpTarget is a place in the outline the jump navigator can go to
+type JumpTarget struct {
+	Kind      string // "node", "concept" or "pattern"
+	Node      int    // node the cursor moves to
+	Label     string
+	Context   string // where the match sits, e.g. the node's ancestry
+	Score     int
+	Positions []int // rune indexes of Label the query matched
+}
+
+// FuzzyMatch reports whether query's characters appear in text in order,
+// ignoring case, scoring runs of consecutive characters and word starts
+// higher. Positions are the rune indexes of text that matched.
+func FuzzyMatch(query, text string) (score int, positions []int, ok bool) {
+	q := []rune(strings.ToLower(strings.TrimSpace(query)))
+	if len(q) == 0 {
+		return 0, nil, true
+	}
+	t := []rune(strings.ToLower(text))
+
+	// Matching from the first place the query could start can miss a
+	// better match later on, so try each
+	for start := range t {
+		if t[start] != q[0] {
+			continue
+		}
+		if s, p, matched := fuzzyFrom(q, t, start); matched && (!ok || s > score) {
+			score, positions, ok = s, p, true
+		}
+	}
+	if !ok {
+		return 0, nil, false
+	}
+	if strings.Contains(string(t), string(q)) {
+		score += 2 * len(q)
+	}
+	// Shorter texts win ties
+	return score - len(t)/16, positions, true
+}
+
+// fuzzyFrom matches q against t greedily from start
+func fuzzyFrom(q, t []rune, start int) (score int, positions []int, ok bool) {
+	qi := 0
+	for ti := start; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+		score++
+		if len(positions) > 0 && positions[len(positions)-1] == ti-1 {
+			score += 5 // continues a run
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]) {
+			score += 8 // starts a word
+		}
+		positions = append(positions, ti)
+		qi++
+	}
+	return score, positions, qi == len(q)
+}
+
+// JumpTargets fuzzy-searches node text, [[concepts]] and pattern types for
+// query, best match first
+func (o *Outliner) JumpTargets(query string) []JumpTarget {
+	var targets []JumpTarget
+	add := func(kind string, node int, label, context string) {
+		if score, positions, ok := FuzzyMatch(query, label); ok {
+			targets = append(targets, JumpTarget{Kind: kind, Node: node, Label: label, Context: context, Score: score, Positions: positions})
+		}
+	}
+
+	concepts := make(map[string]int) // concept -> first node mentioning it
+	patterns := make(map[string][]int)
+	for i, line := range o.lines {
+		if strings.TrimSpace(line.Text) == "" || o.archived[line.ID] && !o.searchArchived {
+			continue
+		}
+		add("node", i, line.Text, o.jumpContext(i))
+		for _, link := range o.extractLinks(line.Text) {
+			if _, seen := concepts[link]; !seen {
+				concepts[link] = i
+			}
+		}
+		if patternType := o.detectPatternType(line.Text); patternType != "" {
+			patterns[patternType] = append(patterns[patternType], i)
+		}
+	}
+	for concept, i := range concepts {
+		add("concept", i, "[["+concept+"]]", fmt.Sprintf("%d mentions", o.LinkMentions(concept)))
+	}
+	for patternType, nodes := range patterns {
+		add("pattern", nodes[0], patternType+"::", fmt.Sprintf("%d nodes, first: %s", len(nodes), crumb(o.lines[nodes[0]].Text)))
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Score != targets[j].Score {
+			return targets[i].Score > targets[j].Score
+		}
+		if targets[i].Node != targets[j].Node {
+			return targets[i].Node < targets[j].Node
+		}
+		if targets[i].Kind != targets[j].Kind {
+			return targets[i].Kind < targets[j].Kind
+		}
+		return targets[i].Label < targets[j].Label
+	})
+	return targets
+}
+
+// jumpContext describes where node i sits: its ancestry, or "top level"
+func (o *Outliner) jumpContext(i int) string {
+	var path []string
+	for _, j := range o.ancestors(i) {
+		path = append(path, crumb(o.lines[j].Text))
+	}
+	if len(path) == 0 {
+		return "top level"
+	}
+	return strings.Join(path, " ▸ ")
+}
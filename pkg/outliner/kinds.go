@@ -0,0 +1,150 @@
+package outliner
+
+import (
+	"regexp"
+	"strings"
+)
+
+// NodeKind is how a node is written to plain content and read back
+type NodeKind string
+
+const (
+	KindBullet    NodeKind = ""          // "• text", the default
+	KindHeading   NodeKind = "heading"   // "# Title"
+	KindParagraph NodeKind = "paragraph" // a line without a bullet
+	KindCode      NodeKind = "code"      // a ``` fence, or a line of code under one
+	KindBlank     NodeKind = "blank"     // an empty line
+)
+
+// headingRegex matches a markdown ATX heading
+var headingRegex = regexp.MustCompile(`^#{1,6}(\s|$)`)
+
+// codeFence opens and closes a code block
+const codeFence = "```"
+
+// Captured reports whether pattern capture and lint look at nodes of this
+// kind; headings and code are prose and source, not consciousness
+func (k NodeKind) Captured() bool {
+	return k == KindBullet || k == KindParagraph
+}
+
+// parseNodes reads plain content into nodes, one per line. A final newline
+// doesn't make a blank node, so GetContent's output loads back unchanged.
+func parseNodes(content string) []OutlineNode {
+	if content == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	nodes := make([]OutlineNode, 0, len(lines))
+
+	fenceLevel := -1 // level of the open fence, -1 outside one
+	for _, line := range lines {
+		if fenceLevel >= 0 {
+			// Code is kept verbatim apart from the fence's own indent, as
+			// children of the opening fence
+			text := strings.TrimPrefix(line, strings.Repeat("  ", fenceLevel))
+			node := newNode(text, fenceLevel+1)
+			node.Kind = KindCode
+			if isFence(node) {
+				node.Level = fenceLevel
+				fenceLevel = -1
+			}
+			nodes = append(nodes, node)
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			node := newNode("", 0)
+			node.Kind = KindBlank
+			nodes = append(nodes, node)
+			continue
+		}
+
+		// Count leading spaces to determine level
+		level := 0
+		trimmed := line
+		for strings.HasPrefix(trimmed, "  ") {
+			level++
+			trimmed = trimmed[2:]
+		}
+
+		kind := KindParagraph
+		switch {
+		case trimmed == "•" || trimmed == "◦":
+			kind, trimmed = KindBullet, ""
+		case strings.HasPrefix(trimmed, "• "):
+			// Remove one bullet; a second is part of the text
+			kind, trimmed = KindBullet, trimmed[len("• "):]
+		case strings.HasPrefix(trimmed, "◦ "):
+			kind, trimmed = KindBullet, trimmed[len("◦ "):]
+		case strings.HasPrefix(trimmed, codeFence):
+			kind, fenceLevel = KindCode, level
+		case headingRegex.MatchString(trimmed):
+			kind = KindHeading
+		}
+
+		node := newNode(trimmed, level)
+		node.Kind = kind
+		nodes = append(nodes, node)
+	}
+
+	// A blank line takes the level of what follows it, so one between a
+	// node and its children doesn't cut them off
+	next := 0
+	for i := len(nodes) - 1; i >= 0; i-- {
+		if nodes[i].Kind == KindBlank {
+			nodes[i].Level = next
+			continue
+		}
+		next = nodes[i].Level
+	}
+	return nodes
+}
+
+// formatNode writes a node as its line of plain content; mirrors write
+// their ((ref))
+func formatNode(node OutlineNode) string {
+	node.Text = storedText(node)
+	indent := strings.Repeat("  ", node.Level)
+	switch node.Kind {
+	case KindBlank:
+		return ""
+	case KindCode:
+		// Code lines sit a level under their fence but are written at its
+		// indent
+		if !isFence(node) && node.Level > 0 {
+			indent = indent[2:]
+		}
+		return indent + node.Text
+	case KindHeading, KindParagraph:
+		return indent + node.Text
+	}
+	return indent + "• " + node.Text
+}
+
+// closesFence reports whether code node i is the ``` that ends its block
+func (o *Outliner) closesFence(i int) bool {
+	if !isFence(o.lines[i]) {
+		return false
+	}
+	fences := 0
+	for j := i; j >= 0 && o.lines[j].Kind == KindCode; j-- {
+		if isFence(o.lines[j]) {
+			fences++
+		}
+	}
+	return fences%2 == 0
+}
+
+// patternContent is the outline as GetContent writes it, with the lines
+// of nodes capture skips left empty so line numbers still match nodes
+func (o *Outliner) patternContent() string {
+	var result strings.Builder
+	for _, line := range o.lines {
+		if line.Kind.Captured() {
+			result.WriteString(formatNode(line))
+		}
+		result.WriteString("\n")
+	}
+	return result.String()
+}
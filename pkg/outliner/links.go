@@ -0,0 +1,52 @@
+package outliner
+
+import (
+	"regexp"
+	"strings"
+)
+
+var wikiLinkRegex = regexp.MustCompile(`\[\[([^\]]+)\]\]`)
+
+// LinkIndex resolves [[links]] beyond the open file, e.g. across a vault
+type LinkIndex interface {
+	// Resolve reports whether a link target exists
+	Resolve(link string) (path string, ok bool)
+	// Mentions counts references to a target across every indexed file
+	Mentions(link string) int
+}
+
+// SetLinkIndex makes link rendering and mention counts index-wide
+func (o *Outliner) SetLinkIndex(index LinkIndex) {
+	o.linkIndex = index
+	o.ClearRenderCache()
+}
+
+// LinkAtCursor returns the [[link]] under the cursor, or the first link on
+// the current node when the cursor isn't inside one
+func (o *Outliner) LinkAtCursor() (string, bool) {
+	if o.cursor >= len(o.lines) {
+		return "", false
+	}
+	text := o.lines[o.cursor].Text
+
+	matches := wikiLinkRegex.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return "", false
+	}
+	for _, m := range matches {
+		if o.cursorPos >= m[0] && o.cursorPos <= m[1] {
+			return strings.TrimSpace(text[m[2]:m[3]]), true
+		}
+	}
+	m := matches[0]
+	return strings.TrimSpace(text[m[2]:m[3]]), true
+}
+
+// LinkMentions counts references to a concept: vault-wide when an index is
+// set, otherwise within this outline
+func (o *Outliner) LinkMentions(concept string) int {
+	if o.linkIndex != nil {
+		return o.linkIndex.Mentions(concept)
+	}
+	return len(o.linkRegistry[concept])
+}
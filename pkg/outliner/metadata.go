@@ -0,0 +1,317 @@
+package outliner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// suggestedKeys are the annotations each pattern type usually carries; they
+// come first, in this order, when annotations are written back
+var suggestedKeys = map[string][]string{
+	"ctx":       {"project", "mode"},
+	"decision":  {"priority", "status", "owner"},
+	"gotcha":    {"fix", "cause"},
+	"bridge":    {"bridge-id", "connects"},
+	"eureka":    {"concept"},
+	"highlight": {"source"},
+	"dispatch":  {"sigil", "imprint"},
+}
+
+// Annotation is one [key:: value] on a node
+type Annotation struct {
+	Key   string
+	Value string
+}
+
+// ParseAnnotations splits node text into its plain text and annotations
+func ParseAnnotations(text string) (string, []Annotation) {
+	var annotations []Annotation
+	for _, match := range annotationRegex.FindAllString(text, -1) {
+		key, value, _ := strings.Cut(strings.Trim(match, "[]"), "::")
+		annotations = append(annotations, Annotation{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+	}
+	plain := strings.Join(strings.Fields(annotationRegex.ReplaceAllString(text, "")), " ")
+	return plain, annotations
+}
+
+// FormatAnnotations appends annotations to text in canonical order: the
+// pattern type's suggested keys first, then the rest alphabetically.
+// Annotations with empty values are dropped.
+func FormatAnnotations(text, patternType string, annotations []Annotation) string {
+	rank := map[string]int{}
+	for i, key := range suggestedKeys[patternType] {
+		rank[key] = i + 1
+	}
+	sorted := append([]Annotation(nil), annotations...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, rj := rank[sorted[i].Key], rank[sorted[j].Key]
+		switch {
+		case ri > 0 && rj > 0:
+			return ri < rj
+		case ri > 0 || rj > 0:
+			return ri > 0
+		}
+		return sorted[i].Key < sorted[j].Key
+	})
+
+	var b strings.Builder
+	b.WriteString(text)
+	for _, a := range sorted {
+		if a.Key == "" || a.Value == "" {
+			continue
+		}
+		fmt.Fprintf(&b, " [%s:: %s]", a.Key, a.Value)
+	}
+	return b.String()
+}
+
+// metadataEditor is the form for the current node's annotations. Rows are
+// the node's annotations, then unused suggested keys, then "add".
+type metadataEditor struct {
+	node        int
+	patternType string
+	text        string // node text without annotations
+	fields      []Annotation
+	selected    int
+	editing     bool
+	editKey     bool // editing the key rather than the value
+	input       string
+}
+
+// openMetadataEditor starts editing the current node's annotations
+func (o *Outliner) openMetadataEditor() {
+	if o.cursor >= len(o.lines) {
+		return
+	}
+	text, fields := ParseAnnotations(o.lines[o.cursor].Text)
+	o.metaEditor = &metadataEditor{
+		node:        o.cursor,
+		patternType: o.detectPatternType(o.lines[o.cursor].Text),
+		text:        text,
+		fields:      fields,
+	}
+}
+
+// IsMetadataEditorOpen reports whether the metadata form has focus
+func (o *Outliner) IsMetadataEditorOpen() bool {
+	return o.metaEditor != nil
+}
+
+// suggestions returns the pattern's suggested keys not yet on the node
+func (e *metadataEditor) suggestions() []string {
+	var unused []string
+	for _, key := range suggestedKeys[e.patternType] {
+		if !e.has(key) {
+			unused = append(unused, key)
+		}
+	}
+	return unused
+}
+
+func (e *metadataEditor) has(key string) bool {
+	for _, field := range e.fields {
+		if field.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// rows is the number of selectable rows, "add" included
+func (e *metadataEditor) rows() int {
+	return len(e.fields) + len(e.suggestions()) + 1
+}
+
+// writeBackMetadata rewrites the node text with the form's annotations,
+// as an edit Ctrl+Z and the node's history both see
+func (o *Outliner) writeBackMetadata() {
+	e := o.metaEditor
+	if e.node >= len(o.lines) {
+		return
+	}
+	if text := FormatAnnotations(e.text, e.patternType, e.fields); text != o.lines[e.node].Text {
+		o.saveUndo()
+		o.setNodeText(e.node, text)
+	}
+	node := &o.lines[e.node]
+	o.cursorPos = min(o.cursorPos, len(node.Text))
+
+	// Show the fields in their written order, keeping the selection
+	selectedKey := ""
+	if e.selected < len(e.fields) {
+		selectedKey = e.fields[e.selected].Key
+	}
+	_, e.fields = ParseAnnotations(node.Text)
+	for i, field := range e.fields {
+		if field.Key == selectedKey {
+			e.selected = i
+		}
+	}
+	e.selected = min(e.selected, e.rows()-1)
+}
+
+// updateMetadataEditor handles keys while the metadata form is open
+func (o *Outliner) updateMetadataEditor(msg tea.KeyMsg) {
+	e := o.metaEditor
+
+	if e.editing {
+		switch msg.String() {
+		case "esc":
+			e.editing = false
+			// A new row left without a key or value is abandoned
+			if field := e.fields[e.selected]; field.Key == "" || field.Value == "" {
+				e.fields = append(e.fields[:e.selected], e.fields[e.selected+1:]...)
+			}
+		case "enter", "tab":
+			field := &e.fields[e.selected]
+			if e.editKey {
+				field.Key = strings.Join(strings.Fields(e.input), "-")
+				if field.Key == "" {
+					return
+				}
+				// Move on to the value
+				e.editKey = false
+				e.input = field.Value
+				return
+			}
+			field.Value = strings.TrimSpace(e.input)
+			e.editing = false
+			if field.Value == "" {
+				e.fields = append(e.fields[:e.selected], e.fields[e.selected+1:]...)
+			}
+			o.writeBackMetadata()
+		case "backspace":
+			if len(e.input) > 0 {
+				e.input = e.input[:len(e.input)-1]
+			}
+		default:
+			if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+				// Brackets would end the annotation early
+				e.input += strings.NewReplacer("[", "", "]", "").Replace(string(msg.Runes))
+			}
+		}
+		return
+	}
+
+	switch msg.String() {
+	case "esc", "alt+m":
+		o.metaEditor = nil
+	case "up", "k":
+		e.selected = max(0, e.selected-1)
+	case "down", "j":
+		e.selected = min(e.rows()-1, e.selected+1)
+	case "enter", "e":
+		suggestions := e.suggestions()
+		switch {
+		case e.selected < len(e.fields):
+			e.input = e.fields[e.selected].Value
+		case e.selected < len(e.fields)+len(suggestions):
+			key := suggestions[e.selected-len(e.fields)]
+			e.fields = append(e.fields, Annotation{Key: key})
+			e.selected = len(e.fields) - 1
+			e.input = ""
+		default:
+			e.fields = append(e.fields, Annotation{})
+			e.selected = len(e.fields) - 1
+			e.input = ""
+			e.editKey = true
+		}
+		e.editing = true
+	case "a":
+		e.fields = append(e.fields, Annotation{})
+		e.selected = len(e.fields) - 1
+		e.input = ""
+		e.editing, e.editKey = true, true
+	case "r":
+		// Rename the selected key
+		if e.selected < len(e.fields) {
+			e.input = e.fields[e.selected].Key
+			e.editing, e.editKey = true, true
+		}
+	case "d", "delete", "backspace":
+		if e.selected < len(e.fields) {
+			e.fields = append(e.fields[:e.selected], e.fields[e.selected+1:]...)
+			o.writeBackMetadata()
+		}
+	}
+}
+
+// bottomPanelHeight is the height of the panel under the outline: the
+// capture review or metadata form while open, else the diagnostics panel
+func (o *Outliner) bottomPanelHeight() int {
+	if o.capturePanel != nil {
+		return o.capturePanelHeight()
+	}
+	if o.metaEditor != nil {
+		return o.metadataPanelHeight()
+	}
+	if height := o.previewPanelHeight(); height > 0 {
+		return height
+	}
+	return o.diagnosticsPanelHeight()
+}
+
+// metadataPanelHeight is the number of rows the form takes, borders included
+func (o *Outliner) metadataPanelHeight() int {
+	if o.metaEditor == nil {
+		return 0
+	}
+	return o.metaEditor.rows() + 4
+}
+
+// renderMetadataPanel draws the form under the outline
+func (o *Outliner) renderMetadataPanel(width int) string {
+	e := o.metaEditor
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	selected := o.theme.selectedStyle()
+
+	keyWidth := 10
+	for _, field := range e.fields {
+		keyWidth = max(keyWidth, len(field.Key))
+	}
+
+	var lines []string
+	row := func(i int, text string) {
+		if i == e.selected {
+			text = selected.Render("› " + text)
+		} else {
+			text = "  " + text
+		}
+		lines = append(lines, text)
+	}
+
+	for i, field := range e.fields {
+		key, value := field.Key, field.Value
+		if e.editing && i == e.selected {
+			if e.editKey {
+				key = e.input + "│"
+			} else {
+				value = e.input + "│"
+			}
+		}
+		row(i, fmt.Sprintf("%-*s  %s", keyWidth, key, value))
+	}
+	for i, key := range e.suggestions() {
+		row(len(e.fields)+i, dim.Render(fmt.Sprintf("%-*s  (suggested)", keyWidth, key)))
+	}
+	row(e.rows()-1, dim.Render("+ add annotation"))
+
+	help := "Enter: edit · a: add · r: rename · d: delete · Esc: close"
+	if e.editing {
+		help = "Enter/Tab: next · Esc: cancel"
+	}
+
+	title := " Metadata "
+	if e.patternType != "" {
+		title = fmt.Sprintf(" Metadata · %s:: ", e.patternType)
+	}
+	return lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color(o.theme.Accent)).
+		Width(width - 2).
+		Render(title + "\n" + strings.Join(lines, "\n") + "\n" + dim.Render(help))
+}
@@ -0,0 +1,257 @@
+package outliner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var (
+	// mirrorRefRegex matches a node that is nothing but a ((node-id)) ref
+	mirrorRefRegex = regexp.MustCompile(`^\(\(([\w-]+)\)\)$`)
+
+	// idAnnotationRegex matches the [id:: ...] annotation that gives a
+	// mirrored node an ID that survives saving as markdown
+	idAnnotationRegex = regexp.MustCompile(`\s*\[id::\s*([\w-]+)\s*\]`)
+)
+
+// mirrorGlyph marks mirrors, and sources with their mirror count
+const mirrorGlyph = "⧉"
+
+// storedText is what a node saves as: a mirror is only its ((ref))
+func storedText(node OutlineNode) string {
+	if node.Mirror != "" {
+		return "((" + node.Mirror + "))"
+	}
+	return node.Text
+}
+
+// stableID returns the ID a node's [id:: ...] annotation gives it, or ""
+func stableID(text string) string {
+	if match := idAnnotationRegex.FindStringSubmatch(text); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// orphanPrompt asks what to do with the mirrors of a deleted source
+type orphanPrompt struct {
+	source  string
+	mirrors []string // node IDs
+}
+
+// MirrorCount is how many mirrors transclude the node with this ID
+func (o *Outliner) MirrorCount(nodeID string) int {
+	return len(o.mirrorRefs[nodeID])
+}
+
+// IsOrphanPromptOpen reports whether deleted-source mirrors await a choice
+func (o *Outliner) IsOrphanPromptOpen() bool {
+	return o.orphans != nil
+}
+
+// syncMirrors keeps mirrors and their sources in step: it gives annotated
+// nodes their stable IDs, turns typed ((id)) refs into mirrors, copies the
+// text of node edited (-1 for none) to the rest of its group, and rebuilds
+// the reference registry, asking about mirrors whose source is gone
+func (o *Outliner) syncMirrors(edited int) {
+	defer func() {
+		if o.cursor < len(o.lines) {
+			o.cursorPos = min(o.cursorPos, len(o.lines[o.cursor].Text))
+		}
+	}()
+
+	byID := make(map[string]int, len(o.lines))
+	for i, line := range o.lines {
+		byID[line.ID] = i
+	}
+
+	// A node that carries a source's annotation takes over as the source,
+	// e.g. after the source was merged into the line above it
+	for i, line := range o.lines {
+		if id := stableID(line.Text); id != "" && line.Mirror == "" && id != line.ID {
+			if _, taken := byID[id]; !taken {
+				delete(byID, line.ID)
+				o.lines[i].ID = id
+				byID[id] = i
+			}
+		}
+	}
+
+	for i, line := range o.lines {
+		if line.Mirror != "" || !line.Kind.Captured() {
+			continue
+		}
+		match := mirrorRefRegex.FindStringSubmatch(strings.TrimSpace(line.Text))
+		if match == nil {
+			continue
+		}
+		j, ok := byID[match[1]]
+		if !ok || j == i {
+			continue
+		}
+		source := o.lines[j].ID
+		if o.lines[j].Mirror != "" {
+			source = o.lines[j].Mirror
+		}
+		o.lines[i].Mirror = source
+		edited = -1 // the source's text wins
+	}
+
+	clear(o.mirrorRefs)
+	for _, line := range o.lines {
+		if line.Mirror != "" {
+			o.mirrorRefs[line.Mirror] = append(o.mirrorRefs[line.Mirror], line.ID)
+		}
+	}
+	if len(o.mirrorRefs) == 0 {
+		return
+	}
+
+	// Edits to any member of a group reach all of them
+	if edited >= 0 && edited < len(o.lines) {
+		group := o.lines[edited].Mirror
+		if group == "" {
+			group = o.lines[edited].ID
+		}
+		if _, mirrored := o.mirrorRefs[group]; mirrored {
+			o.setGroupText(group, o.lines[edited].Text, byID)
+		}
+	}
+
+	for source, mirrors := range o.mirrorRefs {
+		i, ok := byID[source]
+		if !ok {
+			if o.orphans == nil {
+				o.orphans = &orphanPrompt{source: source, mirrors: mirrors}
+			}
+			continue
+		}
+		// Sources keep the annotation their mirrors find them by
+		text := o.lines[i].Text
+		if stableID(text) != source {
+			text = strings.TrimRight(idAnnotationRegex.ReplaceAllString(text, ""), " ") + fmt.Sprintf(" [id:: %s]", source)
+		}
+		o.setGroupText(source, text, byID)
+	}
+}
+
+// setGroupText sets the text of a source and its mirrors, marking the ones
+// that changed for capture
+func (o *Outliner) setGroupText(source, text string, byID map[string]int) {
+	members := append([]string{source}, o.mirrorRefs[source]...)
+	for _, id := range members {
+		i, ok := byID[id]
+		if !ok || o.lines[i].Text == text {
+			continue
+		}
+		o.lines[i].Text = text
+		o.lines[i].ModifiedAt = time.Now()
+		o.lines[i].PatternType = o.detectPatternType(text)
+		o.lines[i].Captured = false
+		o.updateNodeLinks(i)
+	}
+}
+
+// updateOrphanPrompt promotes the first orphaned mirror to be the new
+// source (p, enter, esc) or detaches them all into plain copies (d)
+func (o *Outliner) updateOrphanPrompt(msg tea.KeyMsg) {
+	prompt := o.orphans
+	switch msg.String() {
+	case "p", "enter", "esc":
+		for i := range o.lines {
+			if o.lines[i].Mirror == prompt.source && o.lines[i].ID == prompt.mirrors[0] {
+				o.lines[i].Mirror = ""
+				o.lines[i].ID = prompt.source
+				break
+			}
+		}
+	case "d":
+		for i := range o.lines {
+			if o.lines[i].Mirror == prompt.source {
+				o.lines[i].Mirror = ""
+				o.lines[i].Text = idAnnotationRegex.ReplaceAllString(o.lines[i].Text, "")
+				o.lines[i].Captured = false
+			}
+		}
+	default:
+		return
+	}
+	o.orphans = nil
+	o.syncMirrors(-1)
+}
+
+// AnchorCursor gives the node under the cursor a stable [id:: ...] that
+// survives saving, returning its ID; a mirror answers with its source's
+func (o *Outliner) AnchorCursor() (string, error) {
+	if o.cursor >= len(o.lines) || !o.lines[o.cursor].Kind.Captured() {
+		return "", fmt.Errorf("no node to reference")
+	}
+	line := &o.lines[o.cursor]
+	if line.Mirror != "" {
+		return line.Mirror, nil
+	}
+	if stableID(line.Text) != line.ID {
+		line.Text = strings.TrimRight(idAnnotationRegex.ReplaceAllString(line.Text, ""), " ") + fmt.Sprintf(" [id:: %s]", line.ID)
+		o.syncMirrors(o.cursor)
+	}
+	return line.ID, nil
+}
+
+// CopyReference remembers the node under the cursor for PasteMirror; it
+// returns the node's ID
+func (o *Outliner) CopyReference() (string, error) {
+	id, err := o.AnchorCursor()
+	if err != nil {
+		return "", err
+	}
+	o.reference = id
+	return id, nil
+}
+
+// PasteMirror inserts a mirror of the copied node after the cursor's
+// subtree, at the cursor's level
+func (o *Outliner) PasteMirror() error {
+	if o.reference == "" {
+		return fmt.Errorf("no node reference copied")
+	}
+	if o.nodeIndex(o.reference) < 0 {
+		return fmt.Errorf("referenced node %s is gone", o.reference)
+	}
+	o.insertAfterSubtree("((" + o.reference + "))")
+	return nil
+}
+
+// insertAfterSubtree inserts a node with text after the cursor's subtree,
+// at the cursor's level, and moves the cursor to it
+func (o *Outliner) insertAfterSubtree(text string) {
+	o.saveUndo()
+	at := o.cursor + 1
+	for at < len(o.lines) && o.lines[at].Level > o.lines[o.cursor].Level {
+		at++
+	}
+	o.insertNodes(at, newNode(text, o.lines[o.cursor].Level))
+	o.cursor, o.cursorPos = at, 0
+	o.structureChanged()
+	o.refreshNodeLinks(at)
+	o.updateBacklinks()
+}
+
+// mirrorMark prefixes a mirror's row
+func (o *Outliner) mirrorMark(line OutlineNode) string {
+	if line.Mirror == "" {
+		return ""
+	}
+	return o.treeLineStyle.Render(mirrorGlyph + " ")
+}
+
+// mirrorBadge follows a source's row with how many mirrors it has
+func (o *Outliner) mirrorBadge(line OutlineNode) string {
+	if n := o.MirrorCount(line.ID); n > 0 {
+		return o.treeLineStyle.Render(fmt.Sprintf(" %s%d", mirrorGlyph, n))
+	}
+	return ""
+}
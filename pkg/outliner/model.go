@@ -0,0 +1,114 @@
+package outliner
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SetContentMsg replaces the outline, as SetContent does
+type SetContentMsg struct {
+	Content string
+}
+
+// CaptureRequestMsg asks for a consciousness capture; Trigger names the
+// cause in the dispatch source and defaults to "manual_trigger"
+type CaptureRequestMsg struct {
+	Trigger string
+}
+
+// NodeChangedMsg reports a node whose text or level an edit changed, or
+// that an edit removed (Removed set, other fields as they were)
+type NodeChangedMsg struct {
+	ID          string
+	Text        string
+	Level       int
+	PatternType string
+	Removed     bool
+}
+
+// Model wraps an Outliner as a tea.Model, so hosts can hold it like any
+// other component and drive it with SetContentMsg and CaptureRequestMsg.
+// Edits are reported back as NodeChangedMsg.
+type Model struct {
+	Outliner
+	init tea.Cmd
+}
+
+// NewModel wraps o; commands o has queued, such as a capture from
+// SetContent, run from Init
+func NewModel(o Outliner) Model {
+	return Model{init: o.Flush(), Outliner: o}
+}
+
+// Init runs the commands queued before the model was wrapped
+func (m Model) Init() tea.Cmd {
+	return m.init
+}
+
+// Update handles the model's messages and passes everything else to the
+// outliner
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case SetContentMsg:
+		m.SetContent(msg.Content)
+		return m, m.Flush()
+
+	case CaptureRequestMsg:
+		trigger := msg.Trigger
+		if trigger == "" {
+			trigger = "manual_trigger"
+		}
+		m.captureConsciousness(trigger)
+		return m, m.Flush()
+
+	case tea.WindowSizeMsg:
+		m.SetSize(msg.Width, msg.Height)
+	}
+
+	before, ok := m.currentNode()
+	var cmd tea.Cmd
+	m.Outliner, cmd = m.Outliner.Update(msg)
+	if ok {
+		if changed, ok := m.nodeChange(before); ok {
+			cmd = tea.Batch(cmd, func() tea.Msg { return changed })
+		}
+	}
+	return m, cmd
+}
+
+// currentNode returns a copy of the node under the cursor
+func (o *Outliner) currentNode() (OutlineNode, bool) {
+	if o.cursor >= len(o.lines) {
+		return OutlineNode{}, false
+	}
+	return o.lines[o.cursor], true
+}
+
+// nodeChange compares a node from before an edit with its current state.
+// Edits only move a node by a line or so, so it's looked up near the cursor
+// first.
+func (o *Outliner) nodeChange(before OutlineNode) (NodeChangedMsg, bool) {
+	i := o.nodeIndex(before.ID)
+	if i < 0 {
+		return NodeChangedMsg{ID: before.ID, Text: before.Text, Level: before.Level, PatternType: before.PatternType, Removed: true}, true
+	}
+
+	node := o.lines[i]
+	if node.Text == before.Text && node.Level == before.Level {
+		return NodeChangedMsg{}, false
+	}
+	return NodeChangedMsg{ID: node.ID, Text: node.Text, Level: node.Level, PatternType: node.PatternType}, true
+}
+
+// nodeIndex finds a node by ID, searching outward from the cursor; -1
+// when it's gone
+func (o *Outliner) nodeIndex(id string) int {
+	for d := 0; d <= len(o.lines); d++ {
+		if i := o.cursor - d; i >= 0 && i < len(o.lines) && o.lines[i].ID == id {
+			return i
+		}
+		if i := o.cursor + d; i < len(o.lines) && o.lines[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
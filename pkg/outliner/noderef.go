@@ -0,0 +1,54 @@
+package outliner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// nodeRefRegex matches the target of a [[file#^node-id]] link: a file path
+// (empty for this file) and a node's stable ID
+var nodeRefRegex = regexp.MustCompile(`^(.*)#\^([\w-]+)$`)
+
+// FormatNodeRef returns the [[path#^id]] link to a node in the file at path
+func FormatNodeRef(path, id string) string {
+	return "[[" + path + "#^" + id + "]]"
+}
+
+// ParseNodeRef splits a link target, or a whole [[...]] link, into the file
+// path and node ID it refers to
+func ParseNodeRef(link string) (path, id string, ok bool) {
+	link = strings.TrimSpace(link)
+	if strings.HasPrefix(link, "[[") && strings.HasSuffix(link, "]]") {
+		link = link[2 : len(link)-2]
+	}
+	match := nodeRefRegex.FindStringSubmatch(link)
+	if match == nil {
+		return "", "", false
+	}
+	return strings.TrimSpace(match[1]), match[2], true
+}
+
+// PasteLink inserts a node holding a [[file#^id]] link after the cursor's
+// subtree
+func (o *Outliner) PasteLink(ref string) error {
+	if _, _, ok := ParseNodeRef(ref); !ok {
+		return fmt.Errorf("not a node reference: %s", ref)
+	}
+	if o.cursor >= len(o.lines) {
+		return fmt.Errorf("no node to paste after")
+	}
+	o.insertAfterSubtree(strings.TrimSpace(ref))
+	return nil
+}
+
+// JumpToNode moves the cursor to the node with this ID, unfolding its
+// ancestors; it reports whether the node exists
+func (o *Outliner) JumpToNode(id string) bool {
+	i := o.nodeIndex(id)
+	if i < 0 {
+		return false
+	}
+	o.SetCursor(i)
+	return true
+}
@@ -0,0 +1,187 @@
+package outliner
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OPML attributes carrying consciousness metadata. Workflowy, Dynalist, and
+// OmniOutliner keep unknown attributes, so metadata survives a round trip.
+const (
+	opmlAttrID         = "_floatId"
+	opmlAttrPattern    = "_floatPattern"
+	opmlAttrCaptured   = "_floatCaptured"
+	opmlAttrCreated    = "_floatCreated"
+	opmlAttrModified   = "_floatModified"
+	opmlAttrMetaPrefix = "_floatMeta_"
+
+	// opmlAttrNote is the de facto note attribute used by Workflowy/Dynalist
+	opmlAttrNote = "_note"
+)
+
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    opmlHead `xml:"head"`
+	Body    opmlBody `xml:"body"`
+}
+
+type opmlBody struct {
+	Children []opmlOutline `xml:"outline"`
+}
+
+type opmlHead struct {
+	Title string `xml:"title,omitempty"`
+}
+
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Attrs    []xml.Attr    `xml:",any,attr"`
+	Children []opmlOutline `xml:"outline"`
+}
+
+// IsOPML reports whether content looks like an OPML document
+func IsOPML(content string) bool {
+	head := strings.TrimSpace(content)
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	return strings.HasPrefix(head, "<opml") ||
+		(strings.HasPrefix(head, "<?xml") && strings.Contains(head, "<opml"))
+}
+
+// ParseOPML converts an OPML document into outline nodes, restoring
+// consciousness metadata stored by ToOPML
+func ParseOPML(content string) ([]OutlineNode, error) {
+	var doc opmlDocument
+	if err := xml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, fmt.Errorf("parse OPML: %w", err)
+	}
+
+	var nodes []OutlineNode
+	var walk func(outlines []opmlOutline, level int)
+	walk = func(outlines []opmlOutline, level int) {
+		for _, outline := range outlines {
+			nodes = append(nodes, opmlToNode(outline, level))
+
+			// Workflowy notes become a child line so they stay visible
+			if note := opmlAttr(outline.Attrs, opmlAttrNote); note != "" {
+				for _, line := range strings.Split(note, "\n") {
+					if strings.TrimSpace(line) != "" {
+						nodes = append(nodes, newNode("note:: "+strings.TrimSpace(line), level+1))
+					}
+				}
+			}
+
+			walk(outline.Children, level+1)
+		}
+	}
+	walk(doc.Body.Children, 0)
+
+	markChildren(nodes)
+	return nodes, nil
+}
+
+// ToOPML renders nodes as an OPML 2.0 document
+func ToOPML(nodes []OutlineNode, title string) (string, error) {
+	doc := opmlDocument{Version: "2.0", Head: opmlHead{Title: title}}
+
+	// stack[i] is the child list open at depth i
+	stack := []*[]opmlOutline{&doc.Body.Children}
+	for _, node := range nodes {
+		depth := node.Level + 1
+		if depth > len(stack) {
+			depth = len(stack) // a level jump nests under the last node
+		}
+		stack = stack[:depth]
+
+		siblings := stack[depth-1]
+		*siblings = append(*siblings, nodeToOPML(node))
+		stack = append(stack, &(*siblings)[len(*siblings)-1].Children)
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("render OPML: %w", err)
+	}
+	return xml.Header + string(data) + "\n", nil
+}
+
+// GetOPML returns the outline as OPML
+func (o Outliner) GetOPML(title string) (string, error) {
+	return ToOPML(o.lines, title)
+}
+
+func nodeToOPML(node OutlineNode) opmlOutline {
+	attrs := []xml.Attr{
+		{Name: xml.Name{Local: opmlAttrID}, Value: node.ID},
+	}
+	if node.PatternType != "" {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: opmlAttrPattern}, Value: node.PatternType})
+	}
+	if node.Captured {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: opmlAttrCaptured}, Value: "true"})
+	}
+	if !node.CreatedAt.IsZero() {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: opmlAttrCreated}, Value: node.CreatedAt.Format(time.RFC3339)})
+	}
+	if !node.ModifiedAt.IsZero() {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: opmlAttrModified}, Value: node.ModifiedAt.Format(time.RFC3339)})
+	}
+	keys := make([]string, 0, len(node.Metadata))
+	for key := range node.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: opmlAttrMetaPrefix + key}, Value: node.Metadata[key]})
+	}
+
+	return opmlOutline{Text: storedText(node), Attrs: attrs}
+}
+
+func opmlToNode(outline opmlOutline, level int) OutlineNode {
+	node := newNode(outline.Text, level)
+
+	for _, attr := range outline.Attrs {
+		switch name := attr.Name.Local; {
+		case name == opmlAttrID && attr.Value != "":
+			node.ID = attr.Value
+		case name == opmlAttrPattern:
+			node.PatternType = attr.Value
+		case name == opmlAttrCaptured:
+			node.Captured, _ = strconv.ParseBool(attr.Value)
+		case name == opmlAttrCreated:
+			if t, err := time.Parse(time.RFC3339, attr.Value); err == nil {
+				node.CreatedAt = t
+			}
+		case name == opmlAttrModified:
+			if t, err := time.Parse(time.RFC3339, attr.Value); err == nil {
+				node.ModifiedAt = t
+			}
+		case strings.HasPrefix(name, opmlAttrMetaPrefix):
+			node.Metadata[strings.TrimPrefix(name, opmlAttrMetaPrefix)] = attr.Value
+		}
+	}
+	return node
+}
+
+func opmlAttr(attrs []xml.Attr, name string) string {
+	for _, attr := range attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// markChildren sets HasChildren from levels
+func markChildren(nodes []OutlineNode) {
+	for i := range nodes {
+		nodes[i].HasChildren = i+1 < len(nodes) && nodes[i+1].Level > nodes[i].Level
+	}
+}
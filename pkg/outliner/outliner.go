@@ -4,12 +4,16 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
-	"regexp"
+	"maps"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/calendar"
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
 )
 
 // ReducerUpdateMsg represents a reducer collecting a new action
@@ -20,11 +24,15 @@ type ReducerUpdateMsg struct {
 
 // OutlineNode represents a single line in the outline with consciousness metadata
 type OutlineNode struct {
-	ID          string // Unique identifier for this node
-	Text        string // Display text
-	Level       int    // 0 = root level, 1 = indented once, etc.
-	Collapsed   bool   // true if this node's children are hidden
-	HasChildren bool   // true if this node has child nodes
+	ID          string     // Unique identifier for this node
+	Text        string     // Display text
+	Level       int        // 0 = root level, 1 = indented once, etc.
+	Kind        NodeKind   // bullet (the zero value), heading, paragraph, code, or blank
+	Collapsed   bool       // true if this node's children are hidden
+	HasChildren bool       // true if this node has child nodes
+	Mirror      string     // ID of the node this one transcludes as ((id)), kept in its text
+	Origin      *Origin    // for a reducer's collected child, where the action was captured
+	History     []NodeEdit // earlier versions of Text, oldest first
 
 	// Consciousness metadata
 	CreatedAt   time.Time         // When this node was created
@@ -35,7 +43,7 @@ type OutlineNode struct {
 
 	// Bidirectional linking
 	Links     []string // [[concept]] links found in this node's text
-	Backlinks []string // Node IDs that link to this node
+	Backlinks []string // IDs of other nodes linking a concept this node links or defines
 
 	// Display state
 	DetailMode bool // Whether to show full metadata in display
@@ -58,12 +66,91 @@ type Outliner struct {
 	// FLOAT.dispatch system
 	dispatch   *FloatDispatchSystem
 	debugPanel *InteractiveDebugPanel
-
-	// Reducer update channel for Elm-style message passing
-	reducerUpdates chan ReducerUpdateMsg
+	debugRatio float64 // the debug panel's share of the height; 0 is a third
+
+	// Commands queued outside Update (evna sends from SetContent and
+	// captures), handed to the host by the next Update or Flush; shared
+	// by copies, as bus subscribers queue to it
+	pending *cmdQueue
+
+	// Selectors with an [output:: path] annotation, by selector name, and
+	// the content last written to each path
+	selectorExports map[string]SelectorExport
+	reducerExports  map[string]ReducerExport
+	exported        map[string]string
+
+	// Metadata form for the current node, nil when closed
+	metaEditor *metadataEditor
+
+	// Show only uncaptured pattern nodes
+	reviewMode bool
+
+	// Imprint each node was last dispatched to, by node ID, and the view
+	// grouping nodes by it while open
+	imprintOf   map[string]string
+	imprintView *imprintView
+	editHistory *editHistoryView
+	editBase    editBase
+
+	// Hold evna sends for the capture review, and the review while open
+	holdCaptures bool
+	capturePanel *captureReview
+
+	// Lint diagnostics, recomputed on edit, and the worst severity by node
+	// index
+	diagnostics     []LintIssue
+	severities      map[int]string
+	showDiagnostics bool
+
+	// Structural edits Ctrl+Z can take back, oldest first
+	undo []undoState
+
+	// Node IDs under archive:: or an [archived::] root, never captured
+	// and searched only with searchArchived
+	archived       map[string]bool
+	searchArchived bool
+
+	// When markdown, which keeps no timestamps, was last loaded: its nodes
+	// were all created then, so they don't count as added that day
+	untimedAt time.Time
+
+	// Mirrors: source ID -> mirror node IDs, the node CopyReference
+	// remembered, and the question about mirrors of a deleted source
+	mirrorRefs map[string][]string
+	reference  string
+	orphans    *orphanPrompt
+
+	// Zen mode: a borderless, centered column of zenWidth; zenDebug is
+	// whether to bring the debug panel back after
+	zen      bool
+	zenWidth int
+	zenDebug bool
+
+	// accessible renders rows as spoken roles rather than glyphs and
+	// announces captures; see SetAccessible
+	accessible bool
+
+	// preview shows what the reducer:: line being typed would collect
+	preview *reducerPreview
+
+	// Viewport: the first visible node, and styled rows by renderKey;
+	// scrolling keeps scrollMargin rows around the cursor, or with
+	// typewriter the cursor in the middle
+	offset       int
+	renderCache  map[string]string
+	scrollMargin int
+	typewriter   bool
 
 	// Bidirectional linking
-	linkRegistry   map[string][]string // concept -> []nodeIDs that mention it	// Styles
+	linkRegistry map[string][]string // concept -> []nodeIDs that mention it
+	backlinks    *backlinkIndex      // what Backlinks are read from
+	linkIndex    LinkIndex           // optional, e.g. a vault
+
+	// Calendar ctx:: captures take a [meeting::] from, nil without one
+	calendar *calendar.Calendar
+
+	// Styles
+	theme          Theme
 	bulletStyle    lipgloss.Style
 	textStyle      lipgloss.Style
 	cursorStyle    lipgloss.Style
@@ -71,6 +158,8 @@ type Outliner struct {
 	unfocusedStyle lipgloss.Style
 	highlightStyle lipgloss.Style // For current row
 	treeLineStyle  lipgloss.Style // For tree connection lines
+	headingStyle   lipgloss.Style
+	codeStyle      lipgloss.Style
 }
 
 // generateNodeID creates a unique ID for a node
@@ -107,17 +196,26 @@ func New() Outliner {
 		cursorPos:    0,
 		detailMode:   false,
 		linkRegistry: make(map[string][]string),
+		backlinks:    newBacklinkIndex(),
+		renderCache:  make(map[string]string),
+		zenWidth:     defaultZenWidth,
+		archived:     make(map[string]bool),
+		mirrorRefs:   make(map[string][]string),
+		imprintOf:    make(map[string]string),
+
+		selectorExports: make(map[string]SelectorExport),
+		reducerExports:  make(map[string]ReducerExport),
+		exported:        make(map[string]string),
 
 		// Consciousness integration
 		parser:     NewParser(),
 		evna:       NewEvnaDispatcher(),
 		dispatch:   NewFloatDispatchSystem(),
 		debugPanel: NewInteractiveDebugPanel(),
-
-		// Elm-style message channel
-		reducerUpdates: make(chan ReducerUpdateMsg, 100),
+		pending:    &cmdQueue{},
 
 		// Default styles
+		theme:       DefaultTheme(),
 		bulletStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("62")),
 		textStyle:   lipgloss.NewStyle(),
 		cursorStyle: lipgloss.NewStyle().Background(lipgloss.Color("62")).Foreground(lipgloss.Color("15")),
@@ -125,6 +223,8 @@ func New() Outliner {
 			Background(lipgloss.Color("236")).
 			Foreground(lipgloss.Color("15")),
 		treeLineStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("240")),
+		headingStyle:  lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true),
+		codeStyle:     lipgloss.NewStyle().Foreground(lipgloss.Color("250")).Background(lipgloss.Color("235")),
 		focusedStyle: lipgloss.NewStyle().
 			BorderStyle(lipgloss.RoundedBorder()).
 			BorderForeground(lipgloss.Color("62")).
@@ -134,37 +234,50 @@ func New() Outliner {
 			BorderForeground(lipgloss.Color("240")).
 			Padding(1),
 	}
-
-	// Set up error logging callback for evna dispatcher
-	o.evna.SetErrorLogger(func(msgType, content string) {
-		o.debugPanel.AddError(msgType, content)
-	})
-
-	// Set up reducer update callback for Elm-style message passing
-	o.dispatch.SetReducerUpdateCallback(func(reducerName string, action DispatchAction) {
-		// Send message through channel instead of direct mutation
-		select {
-		case o.reducerUpdates <- ReducerUpdateMsg{ReducerName: reducerName, Action: action}:
-			// Message sent successfully
-		default:
-			// Channel full, skip this update (non-blocking)
-		}
-	})
+	o.subscribe()
 
 	return o
 }
 
-// listenForReducerUpdates creates a command that listens for reducer updates
-func (o *Outliner) listenForReducerUpdates() tea.Cmd {
-	return func() tea.Msg {
-		return <-o.reducerUpdates
+// cmdQueue holds the commands Flush hands over, and the ReducerUpdateMsgs
+// it sends in order after them
+type cmdQueue struct {
+	cmds    []tea.Cmd
+	updates []tea.Cmd
+}
+
+func (q *cmdQueue) push(cmd tea.Cmd) {
+	if cmd != nil {
+		q.cmds = append(q.cmds, cmd)
 	}
 }
 
+// subscribe hooks the debug panel and evna up to the dispatch bus. The
+// handlers hold only what copies of the outliner share.
+func (o *Outliner) subscribe() {
+	bus, queue, panel, evna := o.dispatch.Bus(), o.pending, o.debugPanel, o.evna
+	Subscribe(bus, func(e ActionDispatched) {
+		panel.AddFloatDispatch(e.Action.PatternType, e.Action.Imprint, e.Action.Sigil, e.Action.ID)
+	})
+	Subscribe(bus, func(e ReducerCollected) {
+		update := ReducerUpdateMsg{ReducerName: e.Reducer, Action: e.Action}
+		queue.updates = append(queue.updates, func() tea.Msg { return update })
+	})
+	// evna's results come back to Update as an EvnaResultMsg
+	Subscribe(bus, func(e PatternsCaptured) {
+		if !e.Held {
+			queue.push(evna.DispatchCmd(e.Patterns, e.Source))
+		}
+	})
+	Subscribe(bus, func(e CaptureApproved) {
+		queue.push(evna.DispatchCmd(e.Patterns, e.Source))
+	})
+}
+
 // Focus gives focus to the outliner
 func (o *Outliner) Focus() tea.Cmd {
 	o.focused = true
-	return o.listenForReducerUpdates() // Start listening for reducer updates
+	return nil
 }
 
 // Blur removes focus from the outliner
@@ -182,10 +295,47 @@ func (o Outliner) Focused() bool {
 func (o *Outliner) SetSize(width, height int) {
 	o.width = width
 	o.height = height
+	o.scrollToCursor()
+}
+
+// Flush returns the commands queued since the last Update, such as the
+// evna sends from SetContent or TriggerConsciousnessCapture, and clears
+// the queue; hosts that change the outliner outside Update should run it.
+// What reducers collected meanwhile comes back as ReducerUpdateMsgs, in
+// the order it was collected.
+func (o *Outliner) Flush() tea.Cmd {
+	cmds, updates := o.pending.cmds, o.pending.updates
+	o.pending.cmds, o.pending.updates = nil, nil
+	if len(updates) > 0 {
+		cmds = append(cmds, tea.Sequence(updates...))
+	}
+	return tea.Batch(cmds...)
 }
 
-// Update handles key presses and other messages
+// Update handles key presses and other messages; commands queued while
+// handling the message are batched into the returned command
 func (o Outliner) Update(msg tea.Msg) (Outliner, tea.Cmd) {
+	// evna results arrive whether or not the outliner has focus
+	if msg, ok := msg.(EvnaResultMsg); ok {
+		o.handleEvnaResult(msg)
+		return o, o.Flush()
+	}
+	if msg, ok := msg.(ReducerPreviewMsg); ok {
+		o.updatePreview(msg)
+		return o, nil
+	}
+	if msg, ok := msg.(EvnaValidationMsg); ok {
+		for _, warning := range msg.Warnings {
+			o.debugPanel.AddMessage("EVNA_COLLECTION_WARNING", warning, DebugLevelWarning)
+		}
+		return o, nil
+	}
+
+	o, cmd := o.update(msg)
+	return o, tea.Batch(cmd, o.Flush())
+}
+
+func (o Outliner) update(msg tea.Msg) (Outliner, tea.Cmd) {
 	// Always handle window size messages
 	if _, ok := msg.(tea.WindowSizeMsg); ok {
 		if o.debugPanel.IsVisible() {
@@ -194,8 +344,13 @@ func (o Outliner) Update(msg tea.Msg) (Outliner, tea.Cmd) {
 		}
 	}
 
-	// If debug panel is focused, send all messages to it first
+	// If debug panel is focused, send all messages to it first; ctrl+↑/↓
+	// move its top edge
 	if o.debugPanel.IsVisible() && o.debugPanel.Focused() {
+		if key, ok := msg.(tea.KeyMsg); ok && (key.String() == "ctrl+up" || key.String() == "ctrl+down") {
+			o.resizeDebug(key.String() == "ctrl+up")
+			return o, nil
+		}
 		cmd := o.debugPanel.Update(msg)
 		return o, cmd
 	}
@@ -207,10 +362,40 @@ func (o Outliner) Update(msg tea.Msg) (Outliner, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		o.recordEdit() // the cursor may have been moved from outside
+
+		// The node typed into, if a key only changed one node's text, and
+		// the node before
+		typed := -1
+		var before OutlineNode
+		if o.capturePanel != nil {
+			o.updateCaptureReview(msg)
+			return o, o.Flush()
+		}
+		if o.imprintView != nil {
+			o.updateImprintView(msg)
+			return o, nil
+		}
+		if o.orphans != nil {
+			o.updateOrphanPrompt(msg)
+			return o, nil
+		}
+		if o.editHistory != nil {
+			o.updateEditHistory(msg)
+			o.syncMirrors(o.cursor)
+			return o, nil
+		}
+		if o.metaEditor != nil {
+			o.updateMetadataEditor(msg)
+			o.refreshDiagnostics()
+			o.scrollToCursor()
+			return o, nil
+		}
+
 		switch msg.String() {
 		case "tab":
 			// CORE FEATURE: Indent current line
-			if o.cursor < len(o.lines) {
+			if o.cursor < len(o.lines) && !o.codeIndent(false) {
 				o.lines[o.cursor].Level++
 				// Limit max indentation
 				if o.lines[o.cursor].Level > 6 {
@@ -220,26 +405,26 @@ func (o Outliner) Update(msg tea.Msg) (Outliner, tea.Cmd) {
 
 		case "shift+tab":
 			// CORE FEATURE: Outdent current line
-			if o.cursor < len(o.lines) && o.lines[o.cursor].Level > 0 {
+			if o.cursor < len(o.lines) && !o.codeIndent(true) && o.lines[o.cursor].Level > 0 {
 				o.lines[o.cursor].Level--
 			}
 
 		case "enter":
 			// Create new line at same level
-			if o.cursor < len(o.lines) {
+			if o.cursor < len(o.lines) && !o.codeEnter() {
 				currentLevel := o.lines[o.cursor].Level
 				newNodeObj := newNode("", currentLevel)
 
 				// Insert after current line
-				o.lines = append(o.lines[:o.cursor+1], append([]OutlineNode{newNodeObj}, o.lines[o.cursor+1:]...)...)
+				o.insertNodes(o.cursor+1, newNodeObj)
 				o.cursor++
 				o.cursorPos = 0
 			}
 
 		case "up", "ctrl+p":
-			// Move to previous line
+			// Move to previous visible line
 			if o.cursor > 0 {
-				o.cursor--
+				o.moveCursor(-1)
 				// Adjust cursor position if new line is shorter
 				if o.cursorPos > len(o.lines[o.cursor].Text) {
 					o.cursorPos = len(o.lines[o.cursor].Text)
@@ -247,9 +432,9 @@ func (o Outliner) Update(msg tea.Msg) (Outliner, tea.Cmd) {
 			}
 
 		case "down", "ctrl+n":
-			// Move to next line
+			// Move to next visible line
 			if o.cursor < len(o.lines)-1 {
-				o.cursor++
+				o.moveCursor(1)
 				// Adjust cursor position if new line is shorter
 				if o.cursorPos > len(o.lines[o.cursor].Text) {
 					o.cursorPos = len(o.lines[o.cursor].Text)
@@ -284,6 +469,7 @@ func (o Outliner) Update(msg tea.Msg) (Outliner, tea.Cmd) {
 				if o.cursorPos > 0 {
 					// Delete character in current line
 					line := &o.lines[o.cursor]
+					typed, before = o.cursor, *line
 					line.Text = line.Text[:o.cursorPos-1] + line.Text[o.cursorPos:]
 					o.cursorPos--
 				} else if o.cursor > 0 {
@@ -292,9 +478,13 @@ func (o Outliner) Update(msg tea.Msg) (Outliner, tea.Cmd) {
 					currentLine := o.lines[o.cursor]
 					o.cursorPos = len(prevLine.Text)
 					prevLine.Text += currentLine.Text
+					if prevLine.Kind == KindBlank {
+						prevLine.Kind = currentLine.Kind
+					}
 					// Remove current line
-					o.lines = append(o.lines[:o.cursor], o.lines[o.cursor+1:]...)
+					o.deleteNodes(o.cursor, o.cursor+1)
 					o.cursor--
+					o.updateNodeLinks(o.cursor)
 				}
 			}
 
@@ -303,20 +493,104 @@ func (o Outliner) Update(msg tea.Msg) (Outliner, tea.Cmd) {
 			if o.cursor < len(o.lines) {
 				line := &o.lines[o.cursor]
 				if o.cursorPos < len(line.Text) {
+					typed, before = o.cursor, *line
 					line.Text = line.Text[:o.cursorPos] + line.Text[o.cursorPos+1:]
 				}
 			}
 
+		case "ctrl+up":
+			// Collapse the current node's children
+			o.setCollapsed(true)
+
+		case "ctrl+down":
+			// Expand the current node's children
+			o.setCollapsed(false)
+
+		case "alt+c":
+			// Capture the current node now
+			o.captureNode(o.cursor)
+			if o.reviewMode {
+				o.keepCursorVisible()
+			}
+
+		case "alt+p":
+			// Keep the current node out of capture, or let it back in
+			o.togglePrivate()
+			if o.reviewMode {
+				o.keepCursorVisible()
+			}
+
+		case "alt+u":
+			// Review uncaptured pattern nodes
+			o.toggleReview()
+
+		case "alt+i":
+			// Group captured nodes by the imprint they went to
+			o.toggleImprintView()
+
+		case "alt+x":
+			// Toggle the current node's task checkbox
+			o.toggleTask(time.Now())
+
+		case "alt+m":
+			// Edit the current node's [key:: value] annotations
+			o.openMetadataEditor()
+
+		case "alt+t":
+			// Browse this node's earlier versions
+			o.toggleEditHistory()
+
+		case "alt+y":
+			// Copy a reference to this node for alt+v
+			if _, err := o.CopyReference(); err != nil {
+				o.debugPanel.AddMessage("MIRROR", err.Error(), DebugLevelWarning)
+			}
+
+		case "alt+v":
+			// Paste a mirror of the copied node
+			if err := o.PasteMirror(); err != nil {
+				o.debugPanel.AddMessage("MIRROR", err.Error(), DebugLevelWarning)
+			}
+
+		case "alt+enter":
+			// Split the node at the cursor into two siblings
+			if err := o.SplitNode(false); err != nil {
+				o.debugPanel.AddMessage("SPLIT", err.Error(), DebugLevelWarning)
+			}
+
+		case "alt+j":
+			// Join the next node onto this one
+			if err := o.JoinNext(); err != nil {
+				o.debugPanel.AddMessage("JOIN", err.Error(), DebugLevelWarning)
+			}
+
+		case "ctrl+z":
+			// Take back the last structural edit
+			o.Undo()
+
+		case "alt+1", "alt+2", "alt+3", "alt+4", "alt+5", "alt+6", "alt+7", "alt+8", "alt+9":
+			// Jump to an ancestor in the breadcrumb
+			o.jumpToAncestor(int(msg.String()[4] - '0'))
+
+		case "alt+z":
+			// Toggle distraction-free writing
+			o.toggleZen()
+
 		case "ctrl+t":
 			// Toggle detail mode
 			o.detailMode = !o.detailMode
 
+		case "ctrl+g":
+			// Toggle diagnostics panel
+			o.showDiagnostics = !o.showDiagnostics
+
 		case "ctrl+l":
 			// Toggle debug panel (log)
 			o.debugPanel.Toggle()
 
-		case "ctrl+shift+l":
-			// Toggle focus on debug panel
+		case "ctrl+shift+l", "alt+l":
+			// Toggle focus on debug panel; most terminals can't send
+			// ctrl+shift+l, so alt+l does the same
 			if o.debugPanel.IsVisible() {
 				if o.debugPanel.Focused() {
 					o.debugPanel.Blur()
@@ -330,9 +604,14 @@ func (o Outliner) Update(msg tea.Msg) (Outliner, tea.Cmd) {
 				char := msg.String()
 				if o.cursor < len(o.lines) {
 					line := &o.lines[o.cursor]
+					typed, before = o.cursor, *line
 					// Insert character at cursor position
 					line.Text = line.Text[:o.cursorPos] + char + line.Text[o.cursorPos:]
 					line.ModifiedAt = time.Now()
+					// Typing on an empty line starts a bullet
+					if line.Kind == KindBlank {
+						line.Kind = KindBullet
+					}
 					line.Captured = false // Mark as needing re-capture
 					o.cursorPos++
 
@@ -342,130 +621,216 @@ func (o Outliner) Update(msg tea.Msg) (Outliner, tea.Cmd) {
 			}
 		}
 
+		o.recordEdit()
+		if typed >= 0 {
+			o.refreshTyped(typed, before)
+		} else {
+			o.syncMirrors(o.cursor)
+			markChildren(o.lines)
+			o.markArchived()
+		}
+		o.refreshDiagnostics()
+		o.scrollToCursor()
+		o.schedulePreview()
+
 	case ReducerUpdateMsg:
-		// Handle reducer update message (Elm-style)
 		o.handleReducerUpdateMessage(msg)
-		return o, o.listenForReducerUpdates() // Continue listening
 	}
 
 	return o, nil
 }
 
-// View renders the outliner with enhanced visual feedback
+// View renders the outliner with enhanced visual feedback. Only the rows in
+// the viewport are styled, and rows other than the cursor's come from the
+// render cache, so large outlines cost the same per frame as small ones.
 func (o Outliner) View() string {
 	if len(o.lines) == 0 {
 		return ""
 	}
 
+	if o.zen && o.imprintView == nil && o.editHistory == nil {
+		return o.renderZen()
+	}
+
 	var content strings.Builder
 
 	// Debug info (can be removed later)
-	content.WriteString(fmt.Sprintf("Lines: %d, Cursor: %d\n", len(o.lines), o.cursor))
+	rows := o.visibleRows()
+	switch {
+	case o.imprintView != nil:
+		// The grouped view stands in for the outline rows
+		content.WriteString(o.renderImprintView(o.outlineRows()))
+		rows = nil
+	case o.editHistory != nil:
+		content.WriteString(o.renderEditHistory(o.outlineRows()))
+		rows = nil
+	case o.orphans != nil:
+		content.WriteString(i18n.N("outliner.orphans", len(o.orphans.mirrors)) + "\n")
+	case o.reviewMode:
+		content.WriteString(i18n.T("outliner.status.review", o.reviewCount()) + "\n")
+	case o.cursor < len(o.lines) && o.lines[o.cursor].Level > 0:
+		// Nested, the header shows where the cursor is
+		content.WriteString(o.renderBreadcrumb(cells.Inner(o.unfocusedStyle, o.width)) + "\n")
+	default:
+		content.WriteString(i18n.T("outliner.status.position", len(o.lines), o.cursor) + "\n")
+	}
 
-	for i, line := range o.lines {
+	for rendered, i := range rows {
 		isCurrentLine := i == o.cursor && o.focused
-
-		// Build tree structure with connection lines
-		var treePrefix strings.Builder
-
-		// Add tree connection lines for nested items
-		for level := 0; level < line.Level; level++ {
-			if level == line.Level-1 {
-				// Last level - show branch
-				treePrefix.WriteString(o.treeLineStyle.Render("├─ "))
-			} else {
-				// Intermediate levels - show vertical line
-				treePrefix.WriteString(o.treeLineStyle.Render("│  "))
-			}
+		if rendered > 0 {
+			content.WriteString("\n")
+		}
+		if !isCurrentLine {
+			content.WriteString(o.cachedRow(i, func() string { return o.renderRow(i, false) }))
+			continue
 		}
+		content.WriteString(o.renderRow(i, true))
+	}
 
-		// Choose bullet based on level and expand state
-		var bullet string
-		if line.HasChildren {
-			// Show expand/collapse indicator for nodes with children
-			if line.Collapsed {
-				bullet = "▶" // Collapsed (children hidden)
-			} else {
-				bullet = "▼" // Expanded (children visible)
-			}
-		} else {
-			// Regular bullets for leaf nodes
-			switch line.Level {
-			case 0:
-				bullet = "●" // Solid bullet for root items
-			case 1:
-				bullet = "○" // Hollow bullet for level 1
-			case 2:
-				bullet = "◦" // Small bullet for level 2
-			default:
-				bullet = "·" // Tiny bullet for deeper levels
-			}
+	// Diagnostics panel (or the metadata form, or the capture review) sits
+	// directly under the outline
+	diagnosticsHeight := o.bottomPanelHeight()
+	diagnosticsPanel := ""
+	if o.capturePanel != nil {
+		diagnosticsPanel = "\n" + o.renderCapturePanel(o.width)
+	} else if o.metaEditor != nil {
+		diagnosticsPanel = "\n" + o.renderMetadataPanel(o.width)
+	} else if o.previewPanelHeight() > 0 {
+		diagnosticsPanel = "\n" + o.renderPreviewPanel(o.width)
+	} else if diagnosticsHeight > 0 {
+		diagnosticsPanel = "\n" + o.renderDiagnosticsPanel(o.width)
+	}
+
+	// Calculate heights based on debug panel visibility; a narrow terminal
+	// shows one of the outline and debug panel under a switcher row
+	if o.narrow() {
+		switcher := o.renderPanelSwitcher()
+		if o.debugPanel.Focused() {
+			return switcher + "\n" + o.debugPanel.View(o.width, o.height-1)
 		}
+		mainHeight := o.height - 5 - diagnosticsHeight
+		return switcher + "\n" + o.renderMain(content.String(), mainHeight, o.focused) + diagnosticsPanel
+	}
+	if o.debugPanel.IsVisible() {
+		debugPanelHeight := o.debugHeight()
+		mainHeight := o.height - debugPanelHeight - 4 - diagnosticsHeight
+
+		// Style the main content based on focus state, and render the debug
+		// panel with appropriate focus
+		mainContent := o.renderMain(content.String(), mainHeight, o.focused && !o.debugPanel.Focused())
+		debugContent := o.debugPanel.View(o.width, debugPanelHeight)
+		return mainContent + diagnosticsPanel + "\n" + debugContent
+	}
+	// Full height when debug panel is hidden
+	mainHeight := o.height - 4 - diagnosticsHeight
+	return o.renderMain(content.String(), mainHeight, o.focused) + diagnosticsPanel
+}
 
-		// Style the bullet
-		styledBullet := o.bulletStyle.Render(bullet + " ")
+// renderMain frames the outline rows in the main panel, height rows inside
+// its border. Rows a narrow terminal wraps are cut off at the bottom rather
+// than pushing the panels under it off screen.
+func (o Outliner) renderMain(content string, height int, focused bool) string {
+	style := o.unfocusedStyle
+	if focused {
+		style = o.focusedStyle
+	}
+	if o.width > 0 && height > 0 {
+		// Until it's sized the outliner renders every row
+		content = lipgloss.NewStyle().Width(cells.Inner(style, o.width)).MaxHeight(height).Render(content)
+		style = cells.Frame(style, o.width)
+	}
+	return style.Height(height).Render(content)
+}
 
-		// Build the text content with consciousness metadata
-		textContent := o.renderNodeContent(line)
+// renderRow renders node i as one outline row: gutter, tree lines, bullet,
+// and text, with the cursor and row highlight on the current line
+func (o *Outliner) renderRow(i int, isCurrentLine bool) string {
+	if o.accessible {
+		return o.renderAccessibleRow(i, isCurrentLine)
+	}
+	line := o.lines[i]
 
-		// Add cursor if this is the current line
-		if isCurrentLine {
-			cursorPos := o.cursorPos
-			if cursorPos > len(line.Text) {
-				cursorPos = len(line.Text)
-			}
+	// Build tree structure with connection lines
+	var treePrefix strings.Builder
 
-			// Insert cursor character
-			beforeCursor := line.Text[:cursorPos]
-			afterCursor := line.Text[cursorPos:]
-			textContent = beforeCursor + o.cursorStyle.Render("│") + afterCursor
+	// Add tree connection lines for nested items
+	for level := 0; level < line.Level; level++ {
+		if level == line.Level-1 {
+			// Last level - show branch
+			treePrefix.WriteString(o.treeLineStyle.Render("├─ "))
+		} else {
+			// Intermediate levels - show vertical line
+			treePrefix.WriteString(o.treeLineStyle.Render("│  "))
 		}
+	}
 
-		// Combine all parts
-		lineContent := treePrefix.String() + styledBullet + textContent
+	bullet := nodeBullet(line)
 
-		// Apply row highlighting for current line
-		if isCurrentLine {
-			// Pad to full width and highlight entire row
-			padding := o.width - lipgloss.Width(lineContent) - 6 // Account for border
-			if padding > 0 {
-				lineContent += strings.Repeat(" ", padding)
-			}
-			lineContent = o.highlightStyle.Render(lineContent)
-		}
+	// Style the bullet, in the color of the imprint a captured node went
+	// to; only bullet nodes show one when they have no children to fold
+	bulletStyle := o.bulletStyle
+	if imprint := o.nodeImprint(line); imprint != nil && imprint.Metadata["color"] != "" {
+		bulletStyle = bulletStyle.Foreground(colorCode(imprint.Metadata["color"]))
+	}
+	styledBullet := bulletStyle.Render(bullet + " ")
+	if line.Kind != KindBullet && !line.HasChildren {
+		styledBullet = "  "
+	}
 
-		content.WriteString(lineContent)
-		if i < len(o.lines)-1 {
-			content.WriteString("\n")
+	// Build the text content with consciousness metadata
+	var textContent string
+	if line.Kind == KindCode {
+		textContent = o.renderCode(i)
+	} else if isTableRow(line) {
+		textContent = o.renderTableRow(i)
+	} else {
+		textContent = o.underlineIssue(i, o.renderNodeContent(line))
+	}
+
+	// Add cursor if this is the current line
+	if isCurrentLine {
+		cursorPos := o.cursorPos
+		if cursorPos > len(line.Text) {
+			cursorPos = len(line.Text)
 		}
+
+		// Insert cursor character
+		beforeCursor := line.Text[:cursorPos]
+		afterCursor := line.Text[cursorPos:]
+		textContent = beforeCursor + o.cursorStyle.Render("│") + afterCursor
 	}
 
-	// Calculate heights based on debug panel visibility
-	var mainHeight int
-	var mainContent string
+	// Combine all parts
+	lineContent := o.renderGutter(i) + treePrefix.String() + styledBullet + o.mirrorMark(line) + textContent + o.mirrorBadge(line)
 
-	if o.debugPanel.IsVisible() {
-		debugPanelHeight := o.height / 3
-		mainHeight = o.height - debugPanelHeight - 4
+	// Apply row highlighting for current line
+	if isCurrentLine {
+		// Pad to the panel's width and highlight the entire row
+		lineContent = o.highlightStyle.Render(cells.Pad(lineContent, cells.Inner(o.focusedStyle, o.width)))
+	}
 
-		// Style the main content based on focus state
-		if o.focused && !o.debugPanel.Focused() {
-			mainContent = o.focusedStyle.Width(o.width - 4).Height(mainHeight).Render(content.String())
-		} else {
-			mainContent = o.unfocusedStyle.Width(o.width - 4).Height(mainHeight).Render(content.String())
-		}
+	return lineContent
+}
 
-		// Render debug panel with appropriate focus
-		debugContent := o.debugPanel.View(o.width, debugPanelHeight)
-		return mainContent + "\n" + debugContent
-	} else {
-		// Full height when debug panel is hidden
-		if o.focused {
-			mainContent = o.focusedStyle.Width(o.width - 4).Height(o.height - 4).Render(content.String())
-		} else {
-			mainContent = o.unfocusedStyle.Width(o.width - 4).Height(o.height - 4).Render(content.String())
+// nodeBullet chooses a node's bullet from its level and fold state
+func nodeBullet(line OutlineNode) string {
+	if line.HasChildren {
+		// Show expand/collapse indicator for nodes with children
+		if line.Collapsed {
+			return "▶" // Collapsed (children hidden)
 		}
-		return mainContent
+		return "▼" // Expanded (children visible)
+	}
+	// Regular bullets for leaf nodes
+	switch line.Level {
+	case 0:
+		return "●" // Solid bullet for root items
+	case 1:
+		return "○" // Hollow bullet for level 1
+	case 2:
+		return "◦" // Small bullet for level 2
+	default:
+		return "·" // Tiny bullet for deeper levels
 	}
 }
 
@@ -473,8 +838,7 @@ func (o Outliner) View() string {
 func (o Outliner) GetContent() string {
 	var result strings.Builder
 	for _, line := range o.lines {
-		indent := strings.Repeat("  ", line.Level)
-		result.WriteString(indent + "• " + line.Text + "\n")
+		result.WriteString(formatNode(line) + "\n")
 	}
 	return result.String()
 }
@@ -484,6 +848,12 @@ func (o *Outliner) handleReducerUpdateMessage(msg ReducerUpdateMsg) {
 	// Debug: Log that message was received
 	o.debugPanel.AddMessage("REDUCER_UPDATE", fmt.Sprintf("Reducer '%s' collected: %s", msg.ReducerName, msg.Action.Content), DebugLevelSuccess)
 
+	// A reducer writing a results file keeps the outline clean, unless
+	// [children:: true] asks for both
+	if e, ok := o.reducerExports[msg.ReducerName]; ok && !e.Children {
+		return
+	}
+
 	// Find the reducer node in the outline
 	for i, line := range o.lines {
 		if line.PatternType == "reducer" && strings.Contains(line.Text, msg.ReducerName) {
@@ -501,8 +871,9 @@ func (o *Outliner) handleReducerUpdateMessage(msg ReducerUpdateMsg) {
 				CreatedAt:   time.Now(),
 				ModifiedAt:  time.Now(),
 				PatternType: msg.Action.PatternType,
-				Metadata:    msg.Action.Metadata,
+				Metadata:    maps.Clone(msg.Action.Metadata),
 				Captured:    true, // Already captured by reducer
+				Origin:      actionOrigin(msg.Action),
 			}
 
 			// Insert child node after the reducer (expand downward for now)
@@ -515,43 +886,48 @@ func (o *Outliner) handleReducerUpdateMessage(msg ReducerUpdateMsg) {
 			}
 
 			// Insert the new child
-			o.lines = append(o.lines[:insertIndex], append([]OutlineNode{childNode}, o.lines[insertIndex:]...)...)
+			o.insertNodes(insertIndex, childNode)
 
 			break
 		}
 	}
 }
 
-// SetContent loads content into the outliner
+// SetContent loads content into the outliner; OPML documents are detected
+// and imported with their metadata. Lines without a bullet keep their
+// form as headings, paragraphs, code, and blank lines.
 func (o *Outliner) SetContent(content string) {
-	lines := strings.Split(content, "\n")
-	o.lines = make([]OutlineNode, 0, len(lines))
-
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
-
-		// Count leading spaces to determine level
-		level := 0
-		trimmed := line
-		for strings.HasPrefix(trimmed, "  ") {
-			level++
-			trimmed = trimmed[2:]
+	if IsOPML(content) {
+		if nodes, err := ParseOPML(content); err == nil {
+			for i := range nodes {
+				if nodes[i].PatternType == "" {
+					nodes[i].PatternType = o.detectPatternType(nodes[i].Text)
+				}
+			}
+			o.untimedAt = time.Time{}
+			o.loadNodes(nodes)
+			return
 		}
+		// Malformed OPML falls through and loads as plain text
+	}
 
-		// Remove bullet if present
-		trimmed = strings.TrimPrefix(trimmed, "• ")
-		trimmed = strings.TrimPrefix(trimmed, "◦ ")
-
-		node := newNode(trimmed, level)
+	nodes := parseNodes(content)
+	for i := range nodes {
 		// Detect if this is a consciousness pattern and mark it
-		if patternType := o.detectPatternType(trimmed); patternType != "" {
-			node.PatternType = patternType
+		if nodes[i].Kind.Captured() {
+			nodes[i].PatternType = o.detectPatternType(nodes[i].Text)
 		}
-		o.lines = append(o.lines, node)
 	}
 
+	o.untimedAt = time.Now()
+	o.loadNodes(nodes)
+}
+
+// loadNodes replaces the outline and refreshes links, diagnostics, and
+// capture state
+func (o *Outliner) loadNodes(nodes []OutlineNode) {
+	o.lines = nodes
+
 	// Ensure we have at least one line
 	if len(o.lines) == 0 {
 		o.lines = []OutlineNode{newNode("", 0)}
@@ -559,54 +935,133 @@ func (o *Outliner) SetContent(content string) {
 
 	o.cursor = 0
 	o.cursorPos = 0
-
-	// Update all links after loading content
+	o.offset = 0
+	o.undo = nil
+	o.orphans = nil
+	o.editHistory = nil
+	o.syncMirrors(-1)
+	o.baseAt(0)
+	markChildren(o.lines)
+	o.markArchived()
+	o.ClearRenderCache()
+	clear(o.linkRegistry)
+
+	// Update all links after loading content, then backlinks once
 	for i := range o.lines {
-		o.updateNodeLinks(i)
+		o.refreshNodeLinks(i)
 	}
+	o.updateBacklinks()
+
+	o.refreshDiagnostics()
 
 	// Trigger consciousness capture on content load
 	o.captureConsciousness("content_load")
 }
 
+// lineKeys returns the node IDs in order. GetContent writes one line per
+// node, so they key the lines for the parser's incremental caches and only
+// edited nodes are checked again.
+func (o *Outliner) lineKeys() []string {
+	keys := make([]string, len(o.lines))
+	for i, line := range o.lines {
+		keys[i] = line.ID
+	}
+	return keys
+}
+
 // captureConsciousness analyzes content for :: patterns and dispatches through FLOAT system
 func (o *Outliner) captureConsciousness(trigger string) {
 	if o.parser == nil || o.evna == nil || o.dispatch == nil {
 		return
 	}
 
-	content := o.GetContent()
-	parsed := o.parser.Parse(content)
+	parsed := o.parser.ParseIncremental(o.patternContent(), o.lineKeys())
 
-	if len(parsed.ConsciousnessData) > 0 {
-		// Process through FLOAT.dispatch system
-		for _, pattern := range parsed.ConsciousnessData {
-			// Find the corresponding node
-			nodeID := ""
-			if pattern.Line <= len(o.lines) {
-				nodeID = o.lines[pattern.Line-1].ID
-			}
+	// Each capture re-dispatches the whole outline, so start over rather
+	// than collecting the same nodes twice
+	o.dispatch.ResetActions()
+	clear(o.imprintOf)
 
-			// Handle special FLOAT patterns
-			o.handleFloatPattern(pattern, nodeID)
+	// Private nodes are never dispatched
+	var patterns []ConsciousnessPattern
+	for _, pattern := range parsed.ConsciousnessData {
+		if pattern.Line <= len(o.lines) && isPrivate(o.lines[pattern.Line-1].Text) {
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	o.dispatchPatterns(patterns, trigger)
+}
 
-			// Dispatch through FLOAT system
-			action := o.dispatch.Dispatch(nodeID, pattern.Content, pattern.Type)
+// dispatchPatterns sends patterns through the FLOAT.dispatch system and
+// publishes them for evna and open doors, then marks their nodes captured
+func (o *Outliner) dispatchPatterns(patterns []ConsciousnessPattern, trigger string) {
+	if len(patterns) == 0 {
+		return
+	}
 
-			// Also send to evna for external consciousness integration
-			source := fmt.Sprintf("float-dispatch:%s", trigger)
-			if err := o.evna.DispatchPatterns([]ConsciousnessPattern{pattern}, source); err != nil {
-				o.debugPanel.AddError("EVNA_DISPATCH_ERROR", err.Error())
-			} else {
-				o.debugPanel.AddConsciousnessCapture(action.PatternType, "evna")
-			}
+	// Process through FLOAT.dispatch system
+	for i, pattern := range patterns {
+		// Find the corresponding node
+		nodeID := ""
+		if pattern.Line <= len(o.lines) {
+			nodeID = o.lines[pattern.Line-1].ID
+		}
+
+		// Handle special FLOAT patterns
+		o.handleFloatPattern(pattern, nodeID)
+
+		// A ctx:: written during a meeting says so
+		pattern = o.annotateMeeting(pattern)
+		patterns[i] = pattern
 
-			// Log the FLOAT dispatch
-			o.debugPanel.AddFloatDispatch(action.PatternType, action.Imprint, action.Sigil, action.ID)
+		// Dispatch through FLOAT system
+		action := o.dispatch.DispatchWith(nodeID, pattern.Content, pattern.Type, MeetingMetadata(pattern), time.Now())
+		if nodeID != "" {
+			o.imprintOf[nodeID] = action.Imprint
 		}
+	}
+
+	// With capture review on, evna only gets what's approved there
+	held := o.holdCaptures && trigger != "node_recapture"
+	Publish(o.dispatch.Bus(), PatternsCaptured{Patterns: patterns, Source: fmt.Sprintf("float-dispatch:%s", trigger), Held: held})
+	if held {
+		return
+	}
+
+	// Mark nodes as captured after successful dispatch
+	o.markNodesAsCaptured(patterns)
+}
+
+// LogActivity dispatches a pattern that isn't in the outline, such as a
+// ctx:: recording something done in the app, and publishes it from source
+// for evna and open doors; run Flush to send it
+func (o *Outliner) LogActivity(pattern ConsciousnessPattern, source string) {
+	pattern = AnnotateMeeting(o.calendar, pattern, time.Now())
+	o.dispatch.DispatchWith(source, pattern.Content, pattern.Type, MeetingMetadata(pattern), time.Now())
+	Publish(o.dispatch.Bus(), PatternsCaptured{Patterns: []ConsciousnessPattern{pattern}, Source: source})
+}
+
+// ValidateCollections queues a check of the evna collection routing; its
+// warnings land in the debug panel
+func (o *Outliner) ValidateCollections() {
+	o.pending.push(o.evna.ValidateCollectionsCmd())
+}
 
-		// Mark nodes as captured after successful dispatch
-		o.markNodesAsCaptured(parsed.ConsciousnessData)
+// handleEvnaResult logs each evna send to the debug panel
+func (o *Outliner) handleEvnaResult(msg EvnaResultMsg) {
+	for _, result := range msg.Results {
+		if len(result.Redacted) > 0 {
+			o.debugPanel.AddRedaction(result.Type+":: → evna", result.Redacted, result.Withheld)
+		}
+		if result.Withheld {
+			continue
+		}
+		if result.Err != nil {
+			o.debugPanel.AddError("EVNA_DISPATCH_ERROR", fmt.Sprintf("Failed to dispatch pattern %s: %v", result.Type, result.Err))
+			continue
+		}
+		o.debugPanel.AddConsciousnessCapture(result.Type, "evna")
 	}
 }
 
@@ -618,17 +1073,17 @@ func (o *Outliner) markNodesAsCaptured(patterns []ConsciousnessPattern) {
 		capturedLines[pattern.Line] = true
 	}
 
-	// Mark corresponding nodes as captured
-	lineNum := 1
+	// Pattern lines are node positions: one line per node
+	var captured []int
 	for i := range o.lines {
-		node := &o.lines[i]
-		if node.Text != "" { // Only count non-empty lines
-			if capturedLines[lineNum] {
-				node.Captured = true
+		if capturedLines[i+1] {
+			if !o.lines[i].Captured {
+				captured = append(captured, i)
 			}
-			lineNum++
+			o.lines[i].Captured = true
 		}
 	}
+	o.noticeCaptured(captured)
 }
 
 // TriggerConsciousnessCapture manually triggers consciousness pattern analysis
@@ -636,6 +1091,16 @@ func (o *Outliner) TriggerConsciousnessCapture() {
 	o.captureConsciousness("manual_trigger")
 }
 
+// Evna returns the dispatcher used for consciousness capture
+func (o *Outliner) Evna() *EvnaDispatcher {
+	return o.evna
+}
+
+// Dispatch returns the FLOAT.dispatch system
+func (o *Outliner) Dispatch() *FloatDispatchSystem {
+	return o.dispatch
+}
+
 // IsDetailMode returns whether detail mode is enabled
 func (o *Outliner) IsDetailMode() bool {
 	return o.detailMode
@@ -646,6 +1111,28 @@ func (o *Outliner) IsDebugVisible() bool {
 	return o.debugPanel.IsVisible()
 }
 
+// SetDebugVisible shows or hides the debug panel
+func (o *Outliner) SetDebugVisible(visible bool) {
+	o.debugPanel.SetVisible(visible)
+}
+
+// AddDebugMessage shows a message from outside the outliner, such as a
+// log record, in the debug panel
+func (o *Outliner) AddDebugMessage(msgType, content string, level DebugLevel) {
+	o.debugPanel.AddMessage(msgType, content, level)
+}
+
+// AddRedaction records in the debug panel what the redaction rules
+// changed in something sent to target
+func (o *Outliner) AddRedaction(target string, redactions Redactions, withheld bool) {
+	o.debugPanel.AddRedaction(target, redactions, withheld)
+}
+
+// DebugMessages returns the debug panel's messages, oldest first
+func (o *Outliner) DebugMessages() []DebugMessage {
+	return o.debugPanel.Messages()
+}
+
 // handleFloatPattern processes special FLOAT patterns (reducer::, selector::)
 func (o *Outliner) handleFloatPattern(pattern ConsciousnessPattern, nodeID string) {
 	switch pattern.Type {
@@ -659,148 +1146,89 @@ func (o *Outliner) handleFloatPattern(pattern ConsciousnessPattern, nodeID strin
 // handleReducerPattern creates a new consciousness reducer
 func (o *Outliner) handleReducerPattern(pattern ConsciousnessPattern, nodeID string) {
 	// Parse reducer definition: "reducer::name collect all actions that are bridges about rangle"
-	parts := strings.SplitN(pattern.Content, " ", 2)
-	if len(parts) < 2 {
+	reducerName, query, ok := ParseReducerDefinition(pattern.Content)
+	if query = stripAnnotations(query); !ok || query == "" {
 		return
 	}
 
-	reducerName := parts[0]
-	query := parts[1]
-
-	// Create matcher based on query (simplified for now)
-	matcher := func(action DispatchAction) bool {
-		content := strings.ToLower(action.Content)
-		queryLower := strings.ToLower(query)
-
-		// Parse query for keywords and pattern types
-		// Example: "collect all actions that mention test" -> look for "test" in content
-		// Example: "collect all bridges about rangle" -> look for bridges with "rangle"
-
-		// Extract keywords after "about" or "that mention"
-		var keywords []string
-		if strings.Contains(queryLower, "about ") {
-			parts := strings.Split(queryLower, "about ")
-			if len(parts) > 1 {
-				keywords = strings.Fields(parts[1])
-			}
-		} else if strings.Contains(queryLower, "that mention ") {
-			parts := strings.Split(queryLower, "that mention ")
-			if len(parts) > 1 {
-				keywords = strings.Fields(parts[1])
-			}
-		}
-
-		// Check if content contains any of the keywords
-		for _, keyword := range keywords {
-			if strings.Contains(content, keyword) {
-				return true
-			}
-		}
-
-		// Legacy hardcoded patterns for backward compatibility
-		if strings.Contains(queryLower, "bridges") && action.PatternType == "bridge" {
-			return true
-		}
-		if strings.Contains(queryLower, "rangle") && strings.Contains(content, "rangle") {
-			return true
-		}
-
-		return false
+	if err := o.dispatch.DefineReducer(reducerName, query); err != nil {
+		o.debugPanel.AddError("reducer_error", err.Error())
+		return
 	}
-
-	o.dispatch.AddReducer(reducerName, query, matcher)
 	o.debugPanel.AddReducerCreated(reducerName, query)
+
+	if path := pattern.Context["output"]; path != "" {
+		o.reducerExports[reducerName] = ReducerExport{Reducer: reducerName, Path: path, NodeID: nodeID, Children: pattern.Context["children"] == "true"}
+	} else {
+		delete(o.reducerExports, reducerName)
+	}
 }
 
 // handleSelectorPattern creates a new consciousness selector
 func (o *Outliner) handleSelectorPattern(pattern ConsciousnessPattern, nodeID string) {
 	// Parse selector definition: "selector:: (name_a, name_b) => toc for tech craft zine"
-	content := pattern.Content
-
-	// Extract inputs and output format (simplified parsing)
-	if strings.Contains(content, "=>") {
-		parts := strings.Split(content, "=>")
-		if len(parts) == 2 {
-			inputPart := strings.TrimSpace(parts[0])
-			outputFormat := strings.TrimSpace(parts[1])
-
-			// Extract reducer names from (name_a, name_b) format
-			inputPart = strings.Trim(inputPart, "()")
-			inputs := strings.Split(inputPart, ",")
-			for i, input := range inputs {
-				inputs[i] = strings.TrimSpace(input)
-			}
-
-			// Create transform function
-			transform := func(reducerInputs map[string][]DispatchAction) string {
-				var result strings.Builder
-				result.WriteString(fmt.Sprintf("# %s\n\n", outputFormat))
-
-				for reducerName, actions := range reducerInputs {
-					result.WriteString(fmt.Sprintf("## From %s (%d items)\n", reducerName, len(actions)))
-					for _, action := range actions {
-						result.WriteString(fmt.Sprintf("- %s: %s\n", action.PatternType, action.Content))
-					}
-					result.WriteString("\n")
-				}
+	inputs, outputFormat, ok := ParseSelectorDefinition(pattern.Content)
+	if !ok {
+		return
+	}
 
-				return result.String()
-			}
+	// Named after the node so re-capturing replaces rather than duplicates,
+	// unless [name::] names it for other selectors to build on
+	selectorName := SelectorName(pattern.Content)
+	if selectorName == "" {
+		selectorName = selectorNameFor(nodeID)
+	}
+	if err := o.dispatch.AddSelector(selectorName, inputs, SelectorTransform(outputFormat)); err != nil {
+		o.debugPanel.AddError("selector_error", err.Error())
+		return
+	}
+	o.debugPanel.AddSelectorCreated(selectorName, outputFormat)
 
-			selectorName := fmt.Sprintf("selector_%s", generateNodeID()[:8])
-			o.dispatch.AddSelector(selectorName, inputs, transform)
-			o.debugPanel.AddSelectorCreated(selectorName, outputFormat)
-		}
+	if path := pattern.Context["output"]; path != "" {
+		o.selectorExports[selectorName] = SelectorExport{Selector: selectorName, Path: path, NodeID: nodeID}
+	} else {
+		delete(o.selectorExports, selectorName)
 	}
 }
 
 // renderNodeContent renders node text with consciousness metadata based on detail mode
 func (o *Outliner) renderNodeContent(node OutlineNode) string {
-	baseText := o.renderLinksInText(node.Text)
+	switch node.Kind {
+	case KindHeading:
+		return o.headingStyle.Render(node.Text)
+	case KindBlank:
+		return ""
+	}
+
+	baseText := renderTask(o.renderLinksInText(node.Text))
 
 	// Detect pattern type from text
 	patternType := o.detectPatternType(baseText)
 
-	if !o.detailMode {
+	if !o.detailMode || o.zen {
 		// Simple mode - show text with color coding and capture indicators
 		if patternType != "" {
-			var style lipgloss.Style
-
-			// Add color coding for different pattern types
-			switch patternType {
-			case "ctx":
-				style = lipgloss.NewStyle().Foreground(lipgloss.Color("14")) // cyan
-			case "eureka":
-				style = lipgloss.NewStyle().Foreground(lipgloss.Color("11")) // yellow
-			case "decision":
-				style = lipgloss.NewStyle().Foreground(lipgloss.Color("9")) // red
-			case "highlight":
-				style = lipgloss.NewStyle().Foreground(lipgloss.Color("10")) // green
-			case "gotcha":
-				style = lipgloss.NewStyle().Foreground(lipgloss.Color("13")) // magenta
-			case "bridge":
-				style = lipgloss.NewStyle().Foreground(lipgloss.Color("12")) // blue
-			case "dispatch":
-				style = lipgloss.NewStyle().Foreground(lipgloss.Color("15")).Bold(true) // bright white, bold
-			case "reducer":
-				style = lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Bold(true) // bright cyan, bold
-			case "selector":
-				style = lipgloss.NewStyle().Foreground(lipgloss.Color("5")).Bold(true) // bright magenta, bold
-			case "imprint":
-				style = lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Bold(true) // bright yellow, bold
-			default:
-				style = lipgloss.NewStyle().Foreground(lipgloss.Color("8")) // gray
+			style := o.theme.patternStyle(patternType)
+			if o.zen {
+				return style.Render(baseText)
 			}
 
-			// Add subtle capture indicator
-			text := baseText
-			if !node.Captured {
-				text += " ●" // Uncaptured indicator
-			} else {
-				text += " ○" // Captured indicator
+			// Add subtle capture indicator; captured nodes wear their
+			// imprint's sigil instead
+			switch imprint := o.nodeImprint(node); {
+			case isPrivate(node.Text):
+				return style.Render(baseText + " ⊘") // Never captured
+			case o.archived[node.ID]:
+				return style.Render(baseText) // Archived, out of capture
+			case node.Mirror != "":
+				return style.Render(baseText) // Captured as its source
+			case !node.Captured:
+				return style.Render(baseText + " ●") // Uncaptured indicator
+			case imprint != nil:
+				return style.Render(baseText) + " " + imprintBadge(imprint)
+			default:
+				return style.Render(baseText + " ○") // Captured indicator
 			}
-
-			return style.Render(text)
 		}
 		return baseText
 	}
@@ -813,42 +1241,51 @@ func (o *Outliner) renderNodeContent(node OutlineNode) string {
 		details.WriteString(fmt.Sprintf(" [%s]", patternType))
 	}
 
-	if !node.Captured {
-		details.WriteString(" [uncaptured]")
+	if isPrivate(node.Text) {
+		details.WriteString(i18n.T("outliner.detail.private"))
+	} else if o.archived[node.ID] {
+		details.WriteString(i18n.T("outliner.detail.archived"))
+	} else if !node.Captured {
+		details.WriteString(i18n.T("outliner.detail.uncaptured"))
+	} else if imprint := o.nodeImprint(node); imprint != nil {
+		details.WriteString(i18n.T("outliner.detail.imprint", imprint.Name))
+	}
+
+	for _, link := range node.Links {
+		details.WriteString(i18n.N("outliner.detail.refs", o.LinkMentions(link), link))
+	}
+
+	if node.Origin != nil {
+		details.WriteString(i18n.T("outliner.detail.from", node.Origin))
 	}
 
-	details.WriteString(fmt.Sprintf(" [id:%s]", node.ID[:8])) // Show short ID
+	shortID := node.ID
+	if len(shortID) > 8 {
+		shortID = shortID[:8]
+	}
+	details.WriteString(fmt.Sprintf(" [id:%s]", shortID)) // Show short ID
 	details.WriteString(fmt.Sprintf(" [%s]", node.ModifiedAt.Format("15:04")))
+	if n := len(node.History); n > 0 {
+		details.WriteString(i18n.N("outliner.detail.edits", n))
+	}
 
 	return details.String()
 }
 
 // detectPatternType identifies the consciousness pattern type from text
 func (o *Outliner) detectPatternType(text string) string {
-	patterns := []string{
-		"ctx::", "eureka::", "decision::", "highlight::", "gotcha::", "bridge::", "concept::", "mode::", "project::",
-		"dispatch::", "reducer::", "selector::", "imprint::", "sigil::",
-	}
-
-	for _, pattern := range patterns {
-		if strings.Contains(text, pattern) {
-			return strings.TrimSuffix(pattern, "::")
-		}
-	}
-
-	return ""
+	return Patterns.Type(text)
 }
 
 // extractLinks finds all [[concept]] links in text
 func (o *Outliner) extractLinks(text string) []string {
-	linkRegex := regexp.MustCompile(`\[\[([^\]]+)\]\]`)
-	matches := linkRegex.FindAllStringSubmatch(text, -1)
+	matches := wikiLinkRegex.FindAllStringSubmatch(text, -1)
 
 	var links []string
 	for _, match := range matches {
 		if len(match) >= 2 {
 			concept := strings.TrimSpace(match[1])
-			if concept != "" {
+			if _, _, isRef := ParseNodeRef(concept); concept != "" && !isRef {
 				links = append(links, concept)
 			}
 		}
@@ -857,12 +1294,37 @@ func (o *Outliner) extractLinks(text string) []string {
 	return links
 }
 
-// updateNodeLinks updates a node's links and the global link registry
+// refreshTyped updates what hangs on node i after typing changed its text
+// from before's, rather than re-walking the outline: no level moved, so
+// children stand, and archived subtrees and mirror groups only change when
+// the node opens or belongs to one
+func (o *Outliner) refreshTyped(i int, before OutlineNode) {
+	line := o.lines[i]
+	_, source := o.mirrorRefs[line.ID]
+	if source || line.Mirror != "" || strings.Contains(line.Text, "((") || stableID(line.Text) != "" || stableID(before.Text) != "" {
+		o.syncMirrors(i)
+	}
+	if line.Kind != before.Kind || isArchiveRoot(line.Text) != isArchiveRoot(before.Text) {
+		o.markArchived()
+	}
+}
+
+// updateNodeLinks updates a node's links, the global link registry, and
+// backlinks
 func (o *Outliner) updateNodeLinks(nodeIndex int) {
 	if nodeIndex >= len(o.lines) {
 		return
 	}
+	o.refreshNodeLinks(nodeIndex)
 
+	// Only nodes naming a concept this one linked or stopped linking gain
+	// or lose it as a backlink
+	node := o.lines[nodeIndex]
+	o.refreshBacklinks(o.backlinks.index(node), node.ID)
+}
+
+// refreshNodeLinks re-extracts a node's links into the link registry
+func (o *Outliner) refreshNodeLinks(nodeIndex int) {
 	node := &o.lines[nodeIndex]
 
 	// Remove old links from registry
@@ -878,9 +1340,6 @@ func (o *Outliner) updateNodeLinks(nodeIndex int) {
 	for _, link := range newLinks {
 		o.addLinkToRegistry(link, node.ID)
 	}
-
-	// Update backlinks for all nodes
-	o.updateBacklinks()
 }
 
 // addLinkToRegistry adds a node ID to a concept's registry
@@ -918,46 +1377,25 @@ func (o *Outliner) removeLinkFromRegistry(concept, nodeID string) {
 	}
 }
 
-// updateBacklinks updates backlink information for all nodes
-func (o *Outliner) updateBacklinks() {
-	// Clear all backlinks
-	for i := range o.lines {
-		o.lines[i].Backlinks = []string{}
-	}
-
-	// Rebuild backlinks from link registry
-	for concept, nodeIDs := range o.linkRegistry {
-		// Find nodes that contain this concept as text (not just links)
-		for i, node := range o.lines {
-			if strings.Contains(strings.ToLower(node.Text), strings.ToLower(concept)) {
-				// This node mentions the concept, so all nodes linking to this concept
-				// should have this node as a backlink
-				for _, linkingNodeID := range nodeIDs {
-					// Find the linking node and add this node as a backlink
-					for j := range o.lines {
-						if o.lines[j].ID == linkingNodeID {
-							o.lines[i].Backlinks = append(o.lines[i].Backlinks, linkingNodeID)
-							break
-						}
-					}
-				}
-			}
-		}
-	}
-}
-
-// renderLinksInText applies visual styling to [[links]] in text
+// renderLinksInText applies visual styling to [[links]] in text. With a
+// link index, links to missing pages are dimmed.
 func (o *Outliner) renderLinksInText(text string) string {
-	linkRegex := regexp.MustCompile(`\[\[([^\]]+)\]\]`)
-
 	// Style for links
 	linkStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("4")). // Blue
 		Underline(true)
+	unresolvedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Underline(true)
 
-	return linkRegex.ReplaceAllStringFunc(text, func(match string) string {
+	return wikiLinkRegex.ReplaceAllStringFunc(text, func(match string) string {
 		// Extract the concept name
 		concept := strings.Trim(match, "[]")
+		if o.linkIndex != nil {
+			if _, ok := o.linkIndex.Resolve(concept); !ok {
+				return unresolvedStyle.Render("[[" + concept + "]]")
+			}
+		}
 		return linkStyle.Render("[[" + concept + "]]")
 	})
 }
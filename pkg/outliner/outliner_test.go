@@ -0,0 +1,2219 @@
+package outliner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/calendar"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+)
+
+// runCmd runs cmd the way the Bubble Tea runtime would, off the caller's
+// goroutine, expanding batches and sequences into their messages
+func runCmd(cmd tea.Cmd) <-chan tea.Msg {
+	out := make(chan tea.Msg, 16)
+	go func() {
+		defer close(out)
+		var run func(tea.Cmd)
+		run = func(cmd tea.Cmd) {
+			if cmd == nil {
+				return
+			}
+			msg := cmd()
+			if batch, ok := msg.(tea.BatchMsg); ok {
+				for _, c := range batch {
+					run(c)
+				}
+				return
+			}
+			// tea.Sequence's message is unexported, but it's a []tea.Cmd too
+			if v := reflect.ValueOf(msg); v.Kind() == reflect.Slice && v.Type().Elem() == reflect.TypeOf(cmd) {
+				for i := range v.Len() {
+					run(v.Index(i).Interface().(tea.Cmd))
+				}
+				return
+			}
+			out <- msg
+		}
+		run(cmd)
+	}()
+	return out
+}
+
+// Run with -race: the evna sends happen in commands while Update and View
+// keep going on the test goroutine, and only Update touches the outliner
+func TestEvnaDispatchRunsInCommands(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		if hits.Add(1) == 2 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	o := New()
+	o.Evna().SetEndpoint(srv.URL)
+	o.Focus()
+	o.SetSize(80, 24)
+	o.SetContent("ctx:: race test\ndecision:: keep Update single-threaded")
+
+	cmd := o.Flush()
+	if cmd == nil {
+		t.Fatal("SetContent queued no evna command")
+	}
+	if hits.Load() != 0 {
+		t.Fatal("evna was called before the command ran")
+	}
+	before := o.debugPanel.GetMessageCount()
+
+	msgs := runCmd(cmd)
+	for i := 0; i < 20; i++ {
+		var cmd tea.Cmd
+		o, cmd = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+		_ = o.View()
+		// Typing doesn't re-dispatch, so nothing new is queued
+		if cmd != nil {
+			t.Fatalf("keypress %d returned a command", i)
+		}
+	}
+
+	var results []EvnaResult
+	o.Blur() // results are handled without focus too
+	for msg := range msgs {
+		result, ok := msg.(EvnaResultMsg)
+		if !ok {
+			t.Fatalf("unexpected message %T", msg)
+		}
+		results = append(results, result.Results...)
+		o, _ = o.Update(result)
+	}
+
+	if len(results) != 2 || hits.Load() != 2 {
+		t.Fatalf("got %d results from %d requests, want 2", len(results), hits.Load())
+	}
+	if results[0].Err != nil || results[1].Err == nil {
+		t.Errorf("want the second send to fail, got %v, %v", results[0].Err, results[1].Err)
+	}
+	if results[1].Collection != "float_dispatch_bay" {
+		t.Errorf("decision routed to %q", results[1].Collection)
+	}
+	if got := o.debugPanel.GetMessageCount() - before; got != 2 {
+		t.Errorf("debug panel got %d messages, want 2", got)
+	}
+}
+
+func TestEvnaCollectionRouting(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"name": "active_context_stream"}, "float_highlights", {"name": "float_dispatch_bay"}, {"name": "float_bridges"}]`))
+	}))
+	defer srv.Close()
+
+	ed := NewEvnaDispatcher()
+	ed.SetCollectionRouting(map[string]string{"dream": "float_dreams", "aka": "x"})
+	ed.SetDefaultCollection("float_misc")
+	ed.SetCollectionsURL(srv.URL)
+
+	for _, tc := range []struct {
+		pattern ConsciousnessPattern
+		want    string
+	}{
+		{ConsciousnessPattern{Type: "eureka"}, "float_highlights"},
+		{ConsciousnessPattern{Type: "unknown"}, "float_misc"},
+		{ConsciousnessPattern{Type: "eureka", Context: map[string]string{"collection": "float_dreams"}}, "float_dreams"},
+	} {
+		if got := ed.collectionFor(tc.pattern); got != tc.want {
+			t.Errorf("%+v routed to %q, want %q", tc.pattern, got, tc.want)
+		}
+	}
+
+	msg := ed.ValidateCollectionsCmd()().(EvnaValidationMsg)
+	want := []string{
+		`collection "x" (aka::) isn't a valid collection name`,
+		`collection "float_dreams" (dream::) doesn't exist on the backend`,
+		`collection "float_misc" (unrouted) doesn't exist on the backend`,
+		`collection "x" (aka::) doesn't exist on the backend`,
+	}
+	if strings.Join(msg.Warnings, "\n") != strings.Join(want, "\n") {
+		t.Errorf("warnings:\n%s", strings.Join(msg.Warnings, "\n"))
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	r, err := NewRedactor([]string{"private", "dream::"}, map[string]RedactRule{
+		"emails":   BuiltinRedactions["emails"],
+		"api_keys": BuiltinRedactions["api_keys"],
+		"ticket":   {Regex: `TKT-(\d+)`, Replace: "TKT-#"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, redactions := r.Redact("mail ada@example.com or bob@example.org\nprivate:: the real plan\nkey sk-abcdefghijklmnop1234 for TKT-42\nnote [dream:: flying]\nmyprivate:: stays")
+	want := "mail [email] or [email]\nkey [api key] for TKT-#\nmyprivate:: stays"
+	if got != want {
+		t.Errorf("redacted:\n%s", got)
+	}
+	if s := redactions.String(); s != "private:: ×1, dream:: ×1, api_keys ×1, emails ×2, ticket ×1" {
+		t.Errorf("redactions = %s", s)
+	}
+	if _, err := NewRedactor(nil, map[string]RedactRule{"bad": {Regex: "("}}); err == nil {
+		t.Error("a broken regex was accepted")
+	}
+
+	// A [redact] section turns on the same rules by name
+	fromConfig, err := NewRedactorFromConfig(config.RedactConfig{
+		Emails:   true,
+		Patterns: []string{"private"},
+		Rules:    map[string]config.RedactRule{"ticket": {Regex: `TKT-(\d+)`, Replace: "TKT-#"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := fromConfig.Redact("mail ada@example.com\nprivate:: plan\nkey sk-abcdefghijklmnop1234 for TKT-42"); got != "mail [email]\nkey sk-abcdefghijklmnop1234 for TKT-#" {
+		t.Errorf("redacted from config:\n%s", got)
+	}
+
+	results, redactions := r.RedactResults(Results{Kind: "reducer", Actions: []DispatchAction{
+		{PatternType: "ctx", Content: "call ada@example.com"},
+		{PatternType: "private", Content: "hidden"},
+		{PatternType: "ctx", Content: "private:: hidden too"},
+	}})
+	if len(results.Actions) != 1 || results.Actions[0].Content != "call [email]" || len(redactions) != 2 {
+		t.Errorf("results = %+v, redactions = %s", results.Actions, redactions)
+	}
+
+	// A pattern redaction empties is withheld from evna, and both are
+	// recorded in the debug panel
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+	o := New()
+	o.Evna().SetEndpoint(srv.URL)
+	o.Evna().SetRedactor(r)
+	before := o.debugPanel.GetMessageCount()
+	o.SetContent("ctx:: planning [dream:: flying]\neureka:: mailed ada@example.com")
+	for msg := range runCmd(o.Flush()) {
+		o, _ = o.Update(msg)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("evna got %d requests, want 1", hits.Load())
+	}
+	var recorded []string
+	for _, msg := range o.DebugMessages()[before:] {
+		if msg.Type == "REDACTED" {
+			recorded = append(recorded, msg.Content)
+		}
+	}
+	if strings.Join(recorded, "\n") != "ctx:: → evna: dream:: ×1 (withheld)\neureka:: → evna: emails ×1" {
+		t.Errorf("recorded:\n%s", strings.Join(recorded, "\n"))
+	}
+}
+
+func TestModel(t *testing.T) {
+	o := New()
+	o.Focus()
+	var model tea.Model = NewModel(o)
+
+	model, _ = model.Update(SetContentMsg{Content: "• first\n  • ctx:: second"})
+	if got := model.(Model).GetContent(); got != "• first\n  • ctx:: second\n" {
+		t.Fatalf("content = %q", got)
+	}
+
+	model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("!")})
+	if cmd == nil {
+		t.Fatal("edit reported no change")
+	}
+	changed, ok := cmd().(NodeChangedMsg)
+	if !ok || changed.Text != "!first" || changed.Removed {
+		t.Fatalf("got %+v", changed)
+	}
+
+	// Moving the cursor changes nothing
+	model, cmd = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if cmd != nil {
+		if msg, ok := cmd().(NodeChangedMsg); ok {
+			t.Fatalf("cursor move reported %+v", msg)
+		}
+	}
+
+	model, _ = model.Update(CaptureRequestMsg{})
+	actions := model.(Model).dispatch.GetActions()
+	if len(actions) != 1 || actions[0].PatternType != "ctx" {
+		t.Errorf("capture dispatched %+v", actions)
+	}
+}
+
+func TestNodeKindsSkipCapture(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.SetContent("# ctx:: not a pattern\n\nctx:: a paragraph\n```\nctx:: in code\n```\n• eureka:: a bullet\n")
+
+	var types []string
+	for _, action := range o.Dispatch().GetActions() {
+		types = append(types, action.PatternType+":: "+action.Content)
+	}
+	if got := strings.Join(types, ", "); got != "ctx:: a paragraph, eureka:: a bullet" {
+		t.Errorf("dispatched %s", got)
+	}
+	if o.lines[4].Kind != KindCode || o.lines[4].PatternType != "" {
+		t.Errorf("code line loaded as %+v", o.lines[4])
+	}
+}
+
+func TestCodeBlockEditing(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.Focus()
+	o.SetContent("• notes")
+
+	keys := func(msgs ...tea.KeyMsg) {
+		for _, msg := range msgs {
+			o, _ = o.Update(msg)
+		}
+	}
+	typeText := func(s string) {
+		for _, r := range s {
+			keys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+	enter, tab := tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyTab}
+
+	// ``` and enter on a new bullet opens a block
+	keys(tea.KeyMsg{Type: tea.KeyEnd}, enter)
+	typeText("```go")
+	keys(enter)
+	typeText("if ok {")
+	keys(enter, tab)
+	typeText("ctx:: not captured")
+	keys(enter)
+	typeText("}")
+
+	want := "• notes\n```go\nif ok {\n  ctx:: not captured\n  }\n```\n"
+	if got := o.GetContent(); got != want {
+		t.Fatalf("content = %q, want %q", got, want)
+	}
+	if !o.lines[1].HasChildren || o.lines[2].Level != 1 || o.lines[5].Level != 0 {
+		t.Errorf("code isn't a child region of its fence: %+v", o.lines)
+	}
+	if o.codeLanguage(3) != "go" {
+		t.Errorf("language = %q", o.codeLanguage(3))
+	}
+
+	o.TriggerConsciousnessCapture()
+	if actions := o.Dispatch().GetActions(); len(actions) != 0 {
+		t.Errorf("code was captured: %+v", actions)
+	}
+}
+
+func TestToggleTask(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.SetContent("• decision:: follow up with ops\n- [ ] paragraph task")
+	now := time.Date(2024, 6, 1, 9, 30, 0, 0, time.UTC)
+
+	o.toggleTask(now)
+	o.toggleTask(now)
+	if got := o.lines[0].Text; got != "[x] decision:: follow up with ops [done:: 2024-06-01 09:30]" {
+		t.Fatalf("checked task = %q", got)
+	}
+	if got := renderTask(o.lines[0].Text); !strings.HasPrefix(got, "☑ ") {
+		t.Errorf("checked task renders as %q", got)
+	}
+	o.toggleTask(now)
+	if got := o.lines[0].Text; got != "[ ] decision:: follow up with ops" {
+		t.Errorf("unchecked task = %q", got)
+	}
+
+	o.cursor = 1
+	o.toggleTask(now)
+	if got := o.GetContent(); !strings.HasSuffix(got, "- [x] paragraph task [done:: 2024-06-01 09:30]\n") {
+		t.Errorf("content = %q", got)
+	}
+}
+
+func TestTableRows(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.SetContent("| pattern | count |\n|---|---|\n| ctx | 12 |")
+
+	rows := []string{o.renderTableRow(0), o.renderTableRow(1), o.renderTableRow(2)}
+	want := []string{
+		"│ pattern │ count │",
+		"├─────────┼───────┤",
+		"│ ctx     │ 12    │",
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("row %d = %q, want %q", i, rows[i], want[i])
+		}
+	}
+	if got := o.GetContent(); got != "| pattern | count |\n|---|---|\n| ctx | 12 |\n" {
+		t.Errorf("table saved as %q", got)
+	}
+}
+
+func TestCaptureInteractions(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.Focus()
+	o.SetSize(80, 24)
+	o.SetContent("• ctx:: morning\n• plain\n• eureka:: secret idea\n• decision:: ship [private:: true]")
+	alt := func(r rune) {
+		o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}, Alt: true})
+	}
+
+	if o.lines[3].Captured || len(o.Dispatch().GetActions()) != 2 {
+		t.Fatalf("private node was captured: %+v", o.Dispatch().GetActions())
+	}
+
+	// As if both were edited since: make the eureka private and review
+	// what's left
+	o.lines[0].Captured = false
+	o.lines[2].Captured = false
+	o.cursor = 2
+	alt('p')
+	if !isPrivate(o.lines[2].Text) {
+		t.Fatalf("alt+p left %q", o.lines[2].Text)
+	}
+	alt('u')
+	if o.cursor != 0 || o.reviewCount() != 1 {
+		t.Fatalf("review has cursor %d over %d nodes", o.cursor, o.reviewCount())
+	}
+	if view := o.View(); strings.Contains(view, "plain") || !strings.Contains(view, "ctx:: morning") {
+		t.Errorf("review shows:\n%s", view)
+	}
+
+	// Capturing the last node to review dispatches it on its own
+	before := len(o.Dispatch().GetActions())
+	alt('c')
+	if !o.lines[0].Captured || len(o.Dispatch().GetActions()) != before+1 || o.reviewCount() != 0 {
+		t.Errorf("alt+c: captured %t, %d new actions", o.lines[0].Captured, len(o.Dispatch().GetActions())-before)
+	}
+}
+
+func TestCaptureReview(t *testing.T) {
+	o := New()
+	o.SetCaptureReview(true)
+	o.Focus()
+	o.SetSize(100, 24)
+	o.SetContent("• ctx:: morning\n• eureka:: keep this local\n• decision:: ship it")
+	if cmd := o.Flush(); cmd != nil || o.lines[0].Captured {
+		t.Fatal("capture went to evna without review")
+	}
+	if len(o.Dispatch().GetActions()) != 3 {
+		t.Errorf("local dispatch got %d actions", len(o.Dispatch().GetActions()))
+	}
+
+	o.OpenCaptureReview()
+	if !o.IsCaptureReviewOpen() || len(o.capturePanel.entries) != 3 {
+		t.Fatalf("review open %t", o.IsCaptureReviewOpen())
+	}
+	if view := o.View(); !strings.Contains(view, "3 of 3 to evna") || !strings.Contains(view, "→ float_dispatch_bay") {
+		t.Errorf("review shows:\n%s", view)
+	}
+
+	var cmd tea.Cmd
+	key := func(k string) {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		switch k {
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		}
+		o, cmd = o.Update(msg)
+	}
+	// Leave the eureka out and send the decision to the context stream
+	key("down")
+	key(" ")
+	key("down")
+	for o.capturePanel.entries[2].collection != "active_context_stream" {
+		key("c")
+	}
+	key("enter")
+
+	if o.IsCaptureReviewOpen() || !o.lines[0].Captured || o.lines[1].Captured || !o.lines[2].Captured {
+		t.Fatalf("captured %t %t %t", o.lines[0].Captured, o.lines[1].Captured, o.lines[2].Captured)
+	}
+	var results []EvnaResult
+	for msg := range runCmd(cmd) {
+		results = append(results, msg.(EvnaResultMsg).Results...)
+	}
+	if len(results) != 2 || results[1].Type != "decision" || results[1].Collection != "active_context_stream" {
+		t.Errorf("sent %+v", results)
+	}
+
+	// The left-out node comes up again next time
+	o.OpenCaptureReview()
+	if !o.IsCaptureReviewOpen() || len(o.capturePanel.entries) != 1 || o.capturePanel.entries[0].pattern.Type != "eureka" {
+		t.Fatalf("second review: %+v", o.capturePanel)
+	}
+}
+
+func TestImprintView(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.Focus()
+	o.SetSize(80, 24)
+	o.SetContent("• decision:: ship it\n• eureka:: spark\n• gotcha:: careful\n• plain")
+	press := func(msg tea.KeyMsg) {
+		o, _ = o.Update(msg)
+	}
+
+	// Captured nodes wear their imprint's sigil
+	if view := o.View(); !strings.Contains(view, "careful ⚡") || !strings.Contains(view, "spark 🌀") {
+		t.Errorf("outline shows:\n%s", view)
+	}
+
+	o.cursor = 2
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}, Alt: true})
+	if !o.IsImprintViewOpen() {
+		t.Fatal("alt+i didn't open the imprint view")
+	}
+	view := o.View()
+	techcraft, feral := strings.Index(view, "⚡ techcraft (2)"), strings.Index(view, "🌀 feral_duality (1)")
+	if techcraft < 0 || feral < techcraft || !strings.Contains(view, "3 captured") || strings.Contains(view, "plain") {
+		t.Errorf("imprint view shows:\n%s", view)
+	}
+	if strings.Index(view, "careful") > feral {
+		t.Errorf("gotcha isn't grouped under techcraft:\n%s", view)
+	}
+
+	// The view opens on the cursor's node; moving skips group headings
+	if got := o.imprintView.selected(); got != o.lines[2].ID {
+		t.Errorf("view opened on %q", got)
+	}
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if o.IsImprintViewOpen() || o.cursor != 1 {
+		t.Errorf("enter left the view open %t, cursor on %d, want the eureka", o.IsImprintViewOpen(), o.cursor)
+	}
+}
+
+func TestZenMode(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.Focus()
+	o.SetSize(100, 20)
+	o.SetZenWidth(40)
+	o.SetDebugVisible(true)
+	o.SetContent("• eureka:: spark\n  • child one\n• other root")
+	o.cursor = 1
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'z'}, Alt: true})
+	if !o.IsZen() || o.IsDebugVisible() {
+		t.Fatalf("alt+z: zen %t, debug panel %t", o.IsZen(), o.IsDebugVisible())
+	}
+
+	view := o.View()
+	lines := strings.Split(view, "\n")
+	if len(lines) != 20 {
+		t.Errorf("zen view is %d rows, want 20", len(lines))
+	}
+	for _, chrome := range []string{"╭", "Lines:", "├─", "spark ○", "spark ●"} {
+		if strings.Contains(view, chrome) {
+			t.Errorf("zen view shows %q:\n%s", chrome, view)
+		}
+	}
+	// The column is centered: (100-40)/2 columns of margin
+	if !strings.HasPrefix(lines[0], strings.Repeat(" ", 30)+"▼ eureka:: spark") {
+		t.Errorf("first row isn't centered: %q", lines[0])
+	}
+
+	// Only the cursor's top-level subtree stays bright
+	if start, end := o.currentSubtree(); start != 0 || end != 2 {
+		t.Errorf("current subtree is %d..%d, want 0..2", start, end)
+	}
+
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'z'}, Alt: true})
+	if o.IsZen() || !o.IsDebugVisible() {
+		t.Errorf("leaving zen: zen %t, debug panel %t", o.IsZen(), o.IsDebugVisible())
+	}
+}
+
+func TestScrollMarginAndTypewriter(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.Focus()
+	o.SetSize(80, 14)
+	var content strings.Builder
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&content, "• line %d\n", i)
+	}
+	o.SetContent(content.String())
+	rows := o.outlineRows()
+	moveTo := func(target int) {
+		for o.cursor < target {
+			o, _ = o.Update(tea.KeyMsg{Type: tea.KeyDown})
+		}
+		for o.cursor > target {
+			o, _ = o.Update(tea.KeyMsg{Type: tea.KeyUp})
+		}
+	}
+
+	o.SetScrollMargin(2)
+	moveTo(rows + 5)
+	if got := o.cursor - o.offset; got != rows-3 {
+		t.Errorf("moving down, cursor is on row %d of %d, want two above the bottom", got, rows)
+	}
+	moveTo(o.offset + 1)
+	if got := o.cursor - o.offset; got != 2 {
+		t.Errorf("moving up, cursor is on row %d, want two below the top", got)
+	}
+
+	o.SetScrollMargin(0)
+	o.SetTypewriter(true)
+	moveTo(2)
+	if o.offset != 0 {
+		t.Errorf("near the top the viewport scrolled to %d", o.offset)
+	}
+	moveTo(40)
+	if got := o.cursor - o.offset; got != rows/2 {
+		t.Errorf("typewriter cursor is on row %d, want the middle %d", got, rows/2)
+	}
+	// At the end the outline scrolls past its last node
+	moveTo(49)
+	if got := o.cursor - o.offset; got != rows/2 {
+		t.Errorf("typewriter cursor is on row %d at the end, want %d", got, rows/2)
+	}
+}
+
+func TestResizeDebugPanel(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.Focus()
+	o.SetSize(80, 30)
+	o.SetContent("• one\n• two\n")
+	if !o.debugPanel.IsVisible() {
+		o, _ = o.Update(tea.KeyMsg{Type: tea.KeyCtrlL})
+	}
+	full := o.outlineRows()
+	if o.debugHeight() != 10 {
+		t.Fatalf("debug panel is %d rows, want a third", o.debugHeight())
+	}
+
+	// ctrl+↑/↓ only resize while the panel has focus; otherwise they fold
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyCtrlUp})
+	if o.DebugRatio() != 0 {
+		t.Fatal("resized from the outline")
+	}
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'l'}, Alt: true})
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyCtrlUp})
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyCtrlUp})
+	if o.debugHeight() <= 10 || o.outlineRows() >= full {
+		t.Errorf("grown panel is %d rows, outline %d", o.debugHeight(), o.outlineRows())
+	}
+	if !strings.Contains(o.View(), "one") || strings.Count(o.View(), "\n") >= 30 {
+		t.Errorf("resized view doesn't fit:\n%s", o.View())
+	}
+	for i := 0; i < 20; i++ {
+		o, _ = o.Update(tea.KeyMsg{Type: tea.KeyCtrlDown})
+	}
+	if o.DebugRatio() != minDebugRatio {
+		t.Errorf("shrunk to %v, want the minimum", o.DebugRatio())
+	}
+}
+
+func TestBreadcrumb(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.Focus()
+	o.SetSize(80, 24)
+	o.SetContent("• project\n  • auth rollout\n    • a node with a rather long text to shorten\n  • sibling\n• other")
+	o.cursor = 2
+
+	view := o.View()
+	if !strings.Contains(view, "1 project ▸ 2 auth rollout ▸ a node with a rather lo…") {
+		t.Errorf("breadcrumb missing:\n%s", view)
+	}
+	if got := o.renderBreadcrumb(48); !strings.HasPrefix(got, "… ▸ 2 auth rollout") {
+		t.Errorf("narrow breadcrumb is %q", got)
+	}
+
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'2'}, Alt: true})
+	if o.cursor != 1 {
+		t.Errorf("alt+2 moved the cursor to %d, want the parent", o.cursor)
+	}
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'1'}, Alt: true})
+	if o.cursor != 0 || strings.Contains(o.View(), "▸") {
+		t.Errorf("alt+1 moved the cursor to %d", o.cursor)
+	}
+}
+
+func TestJumpTargets(t *testing.T) {
+	if _, _, ok := FuzzyMatch("dcsn", "decision:: ship"); !ok {
+		t.Error("subsequence didn't match")
+	}
+	if _, _, ok := FuzzyMatch("xyz", "decision:: ship"); ok {
+		t.Error("missing letters matched")
+	}
+	word, _, _ := FuzzyMatch("ship", "decision:: ship it")
+	scattered, _, _ := FuzzyMatch("ship", "sharp hints in places")
+	if word <= scattered {
+		t.Errorf("word match scored %d, scattered %d", word, scattered)
+	}
+
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.SetContent("• project\n  • decision:: use [[sqlite]]\n  • decision:: ship it\n• notes on [[sqlite]]")
+	targets := o.JumpTargets("sqlite")
+	kinds := map[string]bool{}
+	for _, target := range targets {
+		kinds[target.Kind] = true
+	}
+	if !kinds["node"] || !kinds["concept"] {
+		t.Errorf("targets = %+v", targets)
+	}
+	for _, target := range targets {
+		if target.Kind == "concept" && (target.Node != 1 || target.Context != "2 mentions") {
+			t.Errorf("concept target = %+v", target)
+		}
+		if target.Kind == "node" && target.Node == 1 && target.Context != "project" {
+			t.Errorf("node context = %q", target.Context)
+		}
+	}
+
+	patterns := o.JumpTargets("decision::")
+	if len(patterns) == 0 || patterns[0].Kind != "pattern" || !strings.HasPrefix(patterns[0].Context, "2 nodes, first: decision:: use") {
+		t.Errorf("pattern targets = %+v", patterns)
+	}
+}
+
+func TestSortAndGroupChildren(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.SetContent("• project\n  • eureka:: zebra\n    • detail\n  • plain aside\n  • decision:: apple\n  • eureka:: mango\n• after")
+	original := o.GetContent()
+
+	if err := o.SortChildren("alpha", false); err != nil {
+		t.Fatal(err)
+	}
+	want := "• project\n  • decision:: apple\n  • eureka:: mango\n  • eureka:: zebra\n    • detail\n  • plain aside\n• after\n"
+	if got := o.GetContent(); got != want {
+		t.Errorf("sorted:\n%s\nwant:\n%s", got, want)
+	}
+	if err := o.SortChildren("type", true); err != nil {
+		t.Fatal(err)
+	}
+	if got := o.GetContent(); !strings.HasPrefix(got, "• project\n  • plain aside\n  • eureka:: mango") {
+		t.Errorf("sorted by type, reversed:\n%s", got)
+	}
+	if err := o.SortChildren("size", false); err == nil {
+		t.Error("unknown order accepted")
+	}
+
+	if err := o.GroupChildren(); err != nil {
+		t.Fatal(err)
+	}
+	want = "• project\n  • eureka\n    • eureka:: mango\n    • eureka:: zebra\n      • detail\n  • decision\n    • decision:: apple\n  • plain aside\n• after\n"
+	if got := o.GetContent(); got != want {
+		t.Errorf("grouped:\n%s\nwant:\n%s", got, want)
+	}
+	if !o.lines[1].HasChildren {
+		t.Error("group header has no children")
+	}
+
+	// Ctrl+Z takes the edits back one at a time
+	o.Focus()
+	for range 3 {
+		o, _ = o.Update(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	}
+	if got := o.GetContent(); got != original || o.CanUndo() {
+		t.Errorf("after undo:\n%s", got)
+	}
+}
+
+func TestArchiveSubtree(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.SetContent("• decision:: ship it\n  • eureka:: it works\n• ctx:: keep going")
+	now := time.Date(2026, 10, 14, 9, 30, 0, 0, time.UTC)
+
+	if err := o.ArchiveSubtree(now); err != nil {
+		t.Fatal(err)
+	}
+	want := "• ctx:: keep going\n• archive::\n  • decision:: ship it [archived:: 2026-10-14 09:30]\n    • eureka:: it works\n"
+	if got := o.GetContent(); got != want {
+		t.Fatalf("archived:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Archived nodes are out of capture and, by default, search
+	o.TriggerConsciousnessCapture()
+	for _, action := range o.Dispatch().GetActions() {
+		if action.PatternType != "ctx" {
+			t.Errorf("archived %s:: was dispatched", action.PatternType)
+		}
+	}
+	if targets := o.JumpTargets("it works"); len(targets) != 0 {
+		t.Errorf("search found archived nodes: %+v", targets)
+	}
+	o.SetSearchArchived(true)
+	if targets := o.JumpTargets("it works"); len(targets) == 0 {
+		t.Error("search_archived didn't find the archived node")
+	}
+	o.SetCursor(2)
+	if err := o.ArchiveSubtree(now); err == nil {
+		t.Error("archived an archived node again")
+	}
+
+	// Cutting for an archive file leaves no section behind
+	o.Undo()
+	o.SetCursor(0)
+	text, err := o.CutSubtree(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "• decision:: ship it [archived:: 2026-10-14 09:30]\n  • eureka:: it works\n" || o.GetContent() != "• ctx:: keep going\n" {
+		t.Errorf("cut %q, left %q", text, o.GetContent())
+	}
+}
+
+func TestTypingRelintsOneNode(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.Focus()
+	o.SetContent("• ctx:: a\n• plain\n  • child\n• decision:")
+
+	typeAt := func(line int, s string) {
+		o.cursor, o.cursorPos = line, len(o.lines[line].Text)
+		for _, r := range s {
+			o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	typeAt(3, ":")
+	if stats := o.parser.LastLintStats(); stats.Rescanned != 1 || stats.Reused != 3 {
+		t.Errorf("lint stats = %+v, want only the typed node rescanned", stats)
+	}
+	if got := o.lineSeverity(3); got != "info" {
+		t.Errorf("severity of the empty annotation = %q, want info", got)
+	}
+	if got, want := fmt.Sprint(o.Diagnostics()), fmt.Sprint(NewParser().Lint(o.patternContent())); got != want {
+		t.Errorf("incremental diagnostics %s, full lint %s", got, want)
+	}
+
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	if len(o.Diagnostics()) != 0 || o.lineSeverity(3) != "" {
+		t.Errorf("diagnostics after fixing the line: %+v", o.Diagnostics())
+	}
+
+	// Typing a node into an archive root still archives its subtree
+	typeAt(1, " [archived:: now]")
+	if !o.archived[o.lines[1].ID] || !o.archived[o.lines[2].ID] || o.archived[o.lines[3].ID] {
+		t.Errorf("archived = %v after typing an archive stamp", o.archived)
+	}
+}
+
+func TestSplitAndJoin(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.Focus()
+	o.SetContent("• decision:: use [[sqlite]] then eureka:: it scales [private:: true]\n  • detail\n• next")
+	id := o.lines[0].ID
+	o.cursor, o.cursorPos = 0, strings.Index(o.lines[0].Text, " then")
+
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+	want := "• decision:: use [[sqlite]] [private:: true]\n• then eureka:: it scales [private:: true]\n  • detail\n• next\n"
+	if got := o.GetContent(); got != want {
+		t.Fatalf("split:\n%s\nwant:\n%s", got, want)
+	}
+	if o.lines[0].ID != id || o.cursor != 1 || o.lines[1].PatternType != "eureka" {
+		t.Errorf("split node %+v, cursor %d", o.lines[1], o.cursor)
+	}
+	if o.LinkMentions("sqlite") != 1 || len(o.lines[0].Links) != 1 || len(o.lines[1].Links) != 0 {
+		t.Errorf("links after split: %v / %v", o.lines[0].Links, o.lines[1].Links)
+	}
+
+	// Joining a child: its children move up under the result
+	o.SetContent("• parent\n  • child\n    • grandchild\n  • sibling")
+	o.cursor = 1
+	if err := o.JoinNext(); err != nil {
+		t.Fatal(err)
+	}
+	o.cursor = 0
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}, Alt: true})
+	want = "• parent child grandchild\n  • sibling\n"
+	if got := o.GetContent(); got != want || o.cursorPos != len("parent ") {
+		t.Errorf("joined:\n%s\nwant:\n%s (cursor at %d)", got, want, o.cursorPos)
+	}
+
+	o.SetContent("• parent\n  • child\n    • grandchild")
+	o.cursor = 0
+	o.JoinNext()
+	if got := o.GetContent(); got != "• parent child\n  • grandchild\n" {
+		t.Errorf("joined a child with children:\n%s", got)
+	}
+	o.Undo()
+	if got := o.GetContent(); got != "• parent\n  • child\n    • grandchild\n" {
+		t.Errorf("undo join:\n%s", got)
+	}
+}
+
+func TestMirrors(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.Focus()
+	o.SetContent("• decision:: x [id:: abc]\n• notes\n  • ((abc))")
+	if o.lines[0].ID != "abc" || o.lines[2].Mirror != "abc" || o.lines[2].Text != o.lines[0].Text {
+		t.Fatalf("mirror not resolved: %+v", o.lines[2])
+	}
+	if o.MirrorCount("abc") != 1 {
+		t.Errorf("mirror count %d", o.MirrorCount("abc"))
+	}
+
+	// Editing the mirror edits the source; saving keeps the ref
+	o.cursor, o.cursorPos = 2, len("decision:: x")
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	want := "• decision:: xy [id:: abc]\n• notes\n  • ((abc))\n"
+	if got := o.GetContent(); got != want {
+		t.Fatalf("after editing the mirror:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Copy and paste a reference to an unannotated node
+	o.cursor = 1
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}, Alt: true})
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}, Alt: true})
+	id := o.lines[1].ID
+	if o.lines[3].Mirror != id || o.lines[3].Text != "notes [id:: "+id+"]" || o.cursor != 3 {
+		t.Errorf("pasted mirror %+v, cursor %d", o.lines[3], o.cursor)
+	}
+
+	// Deleting a source asks about its mirrors; promoting keeps them linked
+	o.SetContent("• source [id:: s1]\n• ((s1))\n• ((s1))")
+	o.lines = o.lines[1:]
+	o.structureChanged()
+	if !o.IsOrphanPromptOpen() {
+		t.Fatal("no prompt for orphaned mirrors")
+	}
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	if o.IsOrphanPromptOpen() || o.lines[0].Mirror != "" || o.lines[1].Mirror != "s1" {
+		t.Errorf("after promoting: %+v", o.lines)
+	}
+	if got := o.GetContent(); got != "• source [id:: s1]\n• ((s1))\n" {
+		t.Errorf("promoted:\n%s", got)
+	}
+
+	// Detaching leaves plain copies
+	o.SetContent("• source [id:: s1]\n• ((s1))")
+	o.lines = o.lines[1:]
+	o.structureChanged()
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	if got := o.GetContent(); got != "• source\n" {
+		t.Errorf("detached:\n%s", got)
+	}
+}
+
+func TestDetailModeShortID(t *testing.T) {
+	o := New()
+	o.detailMode = true
+	o.SetContent("• decision:: x [id:: abc]")
+	if got := o.renderNodeContent(o.lines[0]); !strings.Contains(got, "[id:abc]") {
+		t.Errorf("detail view = %q", got)
+	}
+}
+
+func TestNodeEditHistory(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.Focus()
+	o.SetContent("• decision:: use postgres\n• next")
+	id := o.lines[0].ID
+
+	// Typing is one version; it's recorded when the cursor leaves
+	o.cursorPos = len(o.lines[0].Text)
+	for _, r := range " for now" {
+		o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if len(o.lines[0].History) != 0 || len(o.Versions(id)) != 2 {
+		t.Fatalf("before leaving: history %v, versions %v", o.lines[0].History, o.Versions(id))
+	}
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if h := o.lines[0].History; len(h) != 1 || h[0].Text != "decision:: use postgres" {
+		t.Fatalf("history %v", h)
+	}
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyDown}) // unchanged nodes keep none
+	if len(o.lines[1].History) != 0 {
+		t.Errorf("unchanged node got history %v", o.lines[1].History)
+	}
+
+	diff := WordDiff("decision:: use postgres", "decision:: use sqlite for now")
+	want := []DiffOp{{' ', "decision:: use"}, {'+', "sqlite for now"}, {'-', "postgres"}}
+	if fmt.Sprint(diff) != fmt.Sprint(want) {
+		t.Errorf("diff %v, want %v", diff, want)
+	}
+
+	// The popup restores the older version, keeping the newer in history
+	o.cursor = 0
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}, Alt: true})
+	if !o.IsEditHistoryOpen() || !strings.Contains(o.View(), "2 versions") {
+		t.Fatalf("history popup not shown:\n%s", o.View())
+	}
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyDown})
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	if o.IsEditHistoryOpen() || o.lines[0].Text != "decision:: use postgres" || len(o.Versions(id)) != 3 {
+		t.Errorf("after restoring: %q, versions %v", o.lines[0].Text, o.Versions(id))
+	}
+
+	for i := 0; i < editHistoryLimit+5; i++ {
+		o.lines[1].addEdit(NodeEdit{Text: fmt.Sprint(i)})
+	}
+	if h := o.lines[1].History; len(h) != editHistoryLimit || h[0].Text != "5" {
+		t.Errorf("history not bounded: %d versions from %q", len(h), h[0].Text)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	at := func(clock string) time.Time {
+		t, _ := time.Parse("2006-01-02 15:04", "2026-10-01 "+clock)
+		return t
+	}
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.Dispatch().LoadHistory([]DispatchAction{
+		{ID: "h1", NodeID: "notes.md:1", Content: "decision:: old plan", PatternType: "decision", Timestamp: at("09:00")},
+		{ID: "h2", NodeID: "other.md:1", Content: "ctx:: elsewhere", PatternType: "ctx", Timestamp: at("09:30")},
+	})
+	o.SetContent("• decision:: new plan\n• ctx:: later")
+	o.Dispatch().ResetActions() // drop the load's own captures
+	for i := range o.lines {
+		o.lines[i].CreatedAt, o.lines[i].ModifiedAt = at("10:00"), at("10:45")
+	}
+	o.lines[0].History = []NodeEdit{{Text: "decision:: draft", At: at("10:00")}}
+	o.lines[0].ModifiedAt = at("10:30")
+	o.Dispatch().DispatchAt(o.lines[0].ID, "decision:: new plan", "decision", at("10:31"))
+
+	rd := o.NewReplay("notes.md")
+	rd.Activate()
+	if len(rd.captures) != 2 || rd.step != 1 {
+		t.Fatalf("captures %v, step %d", rd.captures, rd.step)
+	}
+	if rows := rd.Rows(); len(rows) != 1 || rows[0].text != "decision:: new plan" || !rows[0].captured || !rows[0].current {
+		t.Errorf("at the last capture: %+v", rows)
+	}
+
+	// Before the session, the outline comes from the dispatch log
+	rd.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	if rows := rd.Rows(); rd.step != 0 || len(rows) != 1 || rows[0].text != "decision:: old plan" || !rows[0].current {
+		t.Errorf("at the first capture: %+v", rows)
+	}
+
+	when, err := ParseReplayTime("10:15", rd.at)
+	if err != nil || !when.Equal(at("10:15")) {
+		t.Fatalf("parsed %v, %v", when, err)
+	}
+	rd.Seek(when)
+	if rows := rd.Rows(); rd.step != 0 || len(rows) != 1 || rows[0].text != "decision:: draft" || rows[0].captured {
+		t.Errorf("at 10:15: %+v", rows)
+	}
+	rd.Seek(at("11:00"))
+	if rows := rd.Rows(); len(rows) != 2 || !strings.Contains(rd.View(80, 20), "ctx:: later") {
+		t.Errorf("at 11:00: %+v", rows)
+	}
+}
+
+func TestHTMLExport(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.SetContent("• decision:: use [[SQLite]] <now>\n  • ctx:: see [[notes.md#^abc]]\n• secret [private:: true]\n  • hidden\n• eureka:: done")
+	page, err := o.GetHTML("My <notes>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<title>My &lt;notes&gt;</title>",
+		`<li id="node-` + o.lines[0].ID + `" class="p-decision"><details open>`,
+		`<a class="link" href="#concept-sqlite">[[SQLite]]</a> &lt;now&gt;`,
+		`<a class="link" href="notes.html#node-abc">`,
+		`<li id="concept-sqlite">`,
+		`class="badge" title="techcraft"`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page is missing %s", want)
+		}
+	}
+	if strings.Contains(page, "secret") || strings.Contains(page, "hidden") {
+		t.Error("private subtree exported")
+	}
+
+	_, blob, _ := strings.Cut(page, `<script type="application/json" id="float-metadata">`)
+	blob, _, _ = strings.Cut(blob, "</script>")
+	var data htmlData
+	if err := json.Unmarshal([]byte(blob), &data); err != nil {
+		t.Fatalf("metadata JSON: %v\n%s", err, blob)
+	}
+	if len(data.Nodes) != 3 || data.Nodes[1].Parent != o.lines[0].ID || data.Nodes[0].Text != "decision:: use [[SQLite]] <now>" {
+		t.Errorf("metadata nodes %+v", data.Nodes)
+	}
+}
+
+func TestContextBoundary(t *testing.T) {
+	o := New()
+	o.SetContent("• plan\n• ctx:: 2025-08-05 9:00am [project:: float-line] [mode:: deep-work]\n• wrote the parser\n  • with tests\n• ctx:: 2025-08-05 2:00pm\n• review")
+	now := time.Date(2025, 8, 5, 10, 20, 0, 0, time.Local)
+
+	o.SetCursor(0)
+	if o.ContextBoundary(time.Hour, now) {
+		t.Error("closed a block outside any ctx::")
+	}
+	o.SetCursor(3)
+	if !o.ContextBoundary(65*time.Minute, now) {
+		t.Fatal("no block closed")
+	}
+	want := "• plan\n" +
+		"• ctx:: 2025-08-05 9:00am [project:: float-line] [mode:: deep-work] [duration:: 1h05m]\n" +
+		"• wrote the parser\n  • with tests\n" +
+		"• ctx:: 2025-08-05 10:20am [project:: float-line] [mode:: deep-work]\n" +
+		"• \n" +
+		"• ctx:: 2025-08-05 2:00pm\n• review\n"
+	if got := o.GetContent(); got != want {
+		t.Errorf("content:\n%s", got)
+	}
+	if o.cursor != 5 {
+		t.Errorf("cursor on %d, want the new block's empty node", o.cursor)
+	}
+	if got := FormatWorked(20 * time.Second); got != "1m" {
+		t.Errorf("FormatWorked = %s", got)
+	}
+}
+
+func TestMeetingTimeline(t *testing.T) {
+	cal, err := calendar.Parse(strings.NewReader("BEGIN:VCALENDAR\n" +
+		"BEGIN:VEVENT\nDTSTART:20250805T090000\nDTEND:20250805T093000\nSUMMARY:Standup\nEND:VEVENT\n" +
+		"BEGIN:VEVENT\nDTSTART:20250805T140000\nDURATION:PT1H\nSUMMARY:Design review\nEND:VEVENT\n" +
+		"END:VCALENDAR\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := New()
+	o.SetCalendar(cal)
+	o.SetContent("• ctx:: 2025-08-05 9:10am [project:: float-line]\n" +
+		"• ctx:: 2025-08-05 11:00am\n" +
+		"• ctx:: 2025-08-05 2:30pm [meeting:: 1:1 instead]\n" +
+		"• eureka:: 2025-08-05 9:15am not a ctx")
+
+	meetings := map[string]string{}
+	for _, action := range o.Dispatch().GetActions() {
+		meetings[action.Content] = action.Metadata["meeting"]
+	}
+	want := map[string]string{
+		"2025-08-05 9:10am [project:: float-line]":  "Standup",
+		"2025-08-05 11:00am":                        "",
+		"2025-08-05 2:30pm [meeting:: 1:1 instead]": "1:1 instead",
+		"2025-08-05 9:15am not a ctx":               "",
+	}
+	for content, meeting := range want {
+		if got, ok := meetings[content]; !ok || got != meeting {
+			t.Errorf("%q: meeting %q, want %q", content, got, meeting)
+		}
+	}
+
+	door := NewTimelineDoor().(*TimelineDoor)
+	door.SetDispatch(o.Dispatch())
+	door.SetCalendar(cal)
+	door.now = func() time.Time { return time.Date(2025, 8, 5, 18, 0, 0, 0, time.Local) }
+	if entries := door.Entries(); len(entries) != 3 || entries[0].Time.Hour() != 9 {
+		t.Fatalf("entries = %+v", entries)
+	}
+	view := door.View(100, 20)
+	for _, row := range []string{"9:00am ▌ Standup", "9:10am │ ctx:: 2025-08-05 9:10am", "11:00am   ctx::", "2:00pm ▌ Design review", "2:30pm │ ctx::"} {
+		if !strings.Contains(view, row) {
+			t.Errorf("timeline lacks %q:\n%s", row, view)
+		}
+	}
+	door.Activate()
+	door.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	if !strings.Contains(door.View(100, 20), "No ctx:: captures or events this day") {
+		t.Errorf("day before:\n%s", door.View(100, 20))
+	}
+}
+
+func TestNodeRecords(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.SetContent("• top\n      • jumped in\n• note [private:: true]\n  • hidden\n\n• eureka:: [[it]] works")
+	records := o.NodeRecords()
+	if len(records) != 3 {
+		t.Fatalf("got %d records: %+v", len(records), records)
+	}
+	if records[1].Level != 1 || records[1].Parent != records[0].ID {
+		t.Errorf("a level jump nests under the node before it: %+v", records[1])
+	}
+	if last := records[2]; last.Level != 0 || last.Parent != "" || last.Pattern != "eureka" || len(last.Links) != 1 {
+		t.Errorf("last record %+v", last)
+	}
+}
+
+func TestPluginDoor(t *testing.T) {
+	dir, state := t.TempDir(), t.TempDir()
+	counter := `#!/bin/sh
+count=0
+while read -r line; do
+	case "$line" in
+	*'"type":"init"'*'"count":'*) count=$(echo "$line" | sed 's/.*"count":\([0-9]*\).*/\1/') ;;
+	*'"key":"+"'*) count=$((count + 1)) ;;
+	*'"key":"q"'*) echo '{"close":true}'; continue ;;
+	*'"key":"x"'*) echo boom >&2; exit 3 ;;
+	*'"key":"h"'*) sleep 5 ;;
+	*'"type":"close"'*) exit 0 ;;
+	esac
+	echo "{\"frame\":\"count $count\",\"state\":{\"count\":$count}}"
+done
+`
+	os.WriteFile(filepath.Join(dir, "counter.sh"), []byte(counter), 0755)
+	os.WriteFile(filepath.Join(dir, "stats"), []byte(counter), 0755)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a plugin"), 0644)
+
+	registry := NewDoorRegistry()
+	names, err := registry.RegisterPlugins(dir, state, 500*time.Millisecond)
+	if strings.Join(names, ",") != "counter" || err == nil || !strings.Contains(err.Error(), "stats") {
+		t.Fatalf("registered %v, %v", names, err)
+	}
+
+	// Replies come back as messages from the listener each one re-arms
+	var listen tea.Cmd
+	await := func(door Door) tea.Cmd {
+		t.Helper()
+		_, cmd := door.Update(listen())
+		listen = cmd
+		return cmd
+	}
+	open := func() Door {
+		door := registry.Create("counter")
+		door.Update(tea.WindowSizeMsg{Width: 40, Height: 10})
+		door.Activate()
+		listen = door.Init(nil)
+		await(door)
+		return door
+	}
+	key := func(door Door, k string) tea.Cmd {
+		_, cmd := door.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		return cmd
+	}
+
+	door := open()
+	key(door, "+")
+	key(door, "+")
+	await(door)
+	await(door)
+	if view := door.View(40, 10); !strings.Contains(view, "count 2") {
+		t.Fatalf("after two keys:\n%s", view)
+	}
+	door.Deactivate()
+
+	// The next session starts from the state the last one saved
+	door = open()
+	if view := door.View(40, 10); !strings.Contains(view, "count 2") {
+		t.Fatalf("state not restored:\n%s", view)
+	}
+	key(door, "q")
+	if cmd := await(door); cmd == nil || cmd() != (DoorCloseMsg{}) {
+		t.Error("close reply didn't ask to close the door")
+	}
+	door.Deactivate()
+
+	// A crash is shown in the door, and r restarts the plugin
+	door = open()
+	key(door, "x")
+	await(door)
+	if view := door.View(40, 10); !strings.Contains(view, "stopped") || !strings.Contains(view, "boom") {
+		t.Fatalf("after the plugin crashed:\n%s", view)
+	}
+	listen = key(door, "r")
+	await(door)
+	key(door, "+")
+	await(door)
+	if view := door.View(40, 10); strings.Contains(view, "stopped") || !strings.Contains(view, "count 3") {
+		t.Fatalf("after restarting:\n%s", view)
+	}
+
+	// So is a plugin that stops answering, without keys, resizes or
+	// drawing waiting on it
+	began := time.Now()
+	key(door, "h")
+	key(door, "+")
+	door.Update(tea.WindowSizeMsg{Width: 60, Height: 20})
+	door.View(60, 20)
+	if waited := time.Since(began); waited > 100*time.Millisecond {
+		t.Errorf("the door waited %s on a hung plugin", waited)
+	}
+	await(door)
+	if view := door.View(40, 10); !strings.Contains(view, "no answer") {
+		t.Fatalf("after the plugin hung:\n%s", view)
+	}
+	door.Deactivate()
+	WaitDoorPlugins()
+}
+
+func TestMonochromeTheme(t *testing.T) {
+	o := New()
+	o.SetTheme(Theme{Monochrome: true})
+
+	seen := map[string]string{}
+	for _, pattern := range []string{"ctx", "eureka", "decision", "highlight", "gotcha", "bridge", "dispatch"} {
+		style := o.theme.patternStyle(pattern)
+		if _, ok := style.GetForeground().(lipgloss.NoColor); !ok {
+			t.Errorf("%s:: has a color in a monochrome theme", pattern)
+		}
+		attrs := fmt.Sprint(style.GetBold(), style.GetItalic(), style.GetUnderline(), style.GetReverse())
+		if other, ok := seen[attrs]; ok {
+			t.Errorf("%s:: looks like %s::", pattern, other)
+		}
+		seen[attrs] = pattern
+	}
+	if !o.cursorStyle.GetReverse() {
+		t.Error("the cursor needs reverse video without color")
+	}
+}
+
+func TestAccessibleMode(t *testing.T) {
+	o := New()
+	o.SetAccessible(true)
+	o.Focus()
+	o.SetSize(80, 24)
+	o.SetContent("• ctx:: planning\n  • eureka:: found it")
+
+	var notice *CaptureNoticeMsg
+	for msg := range runCmd(o.Flush()) {
+		if msg, ok := msg.(CaptureNoticeMsg); ok {
+			notice = &msg
+		}
+	}
+	if notice == nil || notice.Text() != "Captured 2 patterns: ctx, eureka" {
+		t.Fatalf("capture notice = %+v", notice)
+	}
+
+	o.lines[1].Captured = false
+	o.ClearRenderCache()
+	view := o.View()
+	if !strings.Contains(view, "level 2, eureka, uncaptured: eureka:: found it") {
+		t.Errorf("no role marker in the row:\n%s", view)
+	}
+	if strings.ContainsAny(view, "╭╮╰╯│├└─") {
+		t.Errorf("screen reader mode drew boxes or tree lines:\n%s", view)
+	}
+}
+
+func TestReducerPreviewWhileTyping(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.Focus()
+	o.SetSize(100, 40)
+	o.SetContent("• decision:: ship auth on monday\n• decision:: pick sqlite\n• ctx:: auth review")
+
+	keys := func(msgs ...tea.KeyMsg) {
+		for _, msg := range msgs {
+			o, _ = o.Update(msg)
+		}
+	}
+	keys(tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyEnd}, tea.KeyMsg{Type: tea.KeyEnter})
+	for _, r := range "reducer:: auth collect all decisions about auth" {
+		keys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if o.preview == nil || o.preview.ready {
+		t.Fatal("the preview was computed before typing paused")
+	}
+
+	// A tick from before the last keystroke is stale
+	stale := ReducerPreviewMsg{seq: o.preview.seq - 1}
+	o, _ = o.Update(stale)
+	if o.preview.ready {
+		t.Fatal("a stale tick recomputed the preview")
+	}
+	o, _ = o.Update(ReducerPreviewMsg{seq: o.preview.seq})
+	view := o.View()
+	for _, want := range []string{"Reducer preview · auth", "1 of 3 actions matches", "types: decision · keywords: auth", "ship auth on monday"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("preview lacks %q:\n%s", want, view)
+		}
+	}
+
+	// Moving off the line takes the preview away
+	keys(tea.KeyMsg{Type: tea.KeyUp})
+	if o.preview != nil || strings.Contains(o.View(), "Reducer preview") {
+		t.Error("the preview stayed off its reducer:: line")
+	}
+}
+
+func TestBar(t *testing.T) {
+	for _, tc := range []struct {
+		value, limit, width int
+		want                string
+	}{
+		{4, 4, 4, "████"},
+		{2, 4, 4, "██"},
+		{1, 8, 4, "▌"},
+		{3, 8, 4, "█▌"},
+		{0, 4, 4, ""},
+		{1, 0, 4, ""},
+	} {
+		if got := bar(tc.value, tc.limit, tc.width); got != tc.want {
+			t.Errorf("bar(%d, %d, %d) = %q, want %q", tc.value, tc.limit, tc.width, got, tc.want)
+		}
+	}
+}
+
+func TestStatsDoor(t *testing.T) {
+	now := time.Date(2026, 3, 12, 9, 0, 0, 0, time.Local)
+	fds := NewFloatDispatchSystem()
+	fds.LoadHistory([]DispatchAction{
+		{ID: "h1", Content: "keep the old [[auth]] api", PatternType: "decision", Imprint: "techcraft", Timestamp: now.AddDate(0, 0, -1)},
+		{ID: "h2", Content: "[[auth|login]] flow", PatternType: "ctx", Imprint: "techcraft", Timestamp: now.AddDate(0, 0, -30)},
+	})
+	fds.AddReducer("decisions", "collect all decisions", ReducerMatcher("collect all decisions"))
+	fds.DispatchAt("n1", "ship [[auth#tokens]] on monday", "decision", now)
+	fds.DispatchAt("n2", "what about [[billing]]?", "eureka", now)
+
+	sd := NewDoorRegistry().Create("stats").(*StatsDoor)
+	sd.SetDispatch(fds)
+	sd.now = func() time.Time { return now }
+	sd.Activate()
+
+	for _, tc := range []struct {
+		scope     StatsScope
+		total     int
+		decisions int
+		auth      int
+		imprints  int
+	}{
+		{ScopeSession, 2, 1, 1, 1},
+		{ScopeHistory, 2, 1, 2, 2},
+		{ScopeAll, 4, 2, 3, 3},
+	} {
+		t.Run(tc.scope.String(), func(t *testing.T) {
+			sd.SetState(map[string]interface{}{"scope": int(tc.scope)})
+			stats := sd.Stats()
+			if stats.Total != tc.total || stats.Types["decision"] != tc.decisions || stats.Concepts["auth"] != tc.auth ||
+				stats.Imprints["techcraft"] != tc.imprints {
+				t.Errorf("stats = %+v", stats)
+			}
+			if len(stats.Reducers) != 1 || stats.Reducers[0].Hits != tc.decisions ||
+				stats.Reducers[0].Rate != float64(tc.decisions)/float64(tc.total) {
+				t.Errorf("reducers = %+v", stats.Reducers)
+			}
+		})
+	}
+
+	sd.SetState(map[string]interface{}{"scope": int(ScopeAll)})
+	view := sd.View(80, 40)
+	for _, want := range []string{"all (4 patterns)", "Thu Mar 12", "Wed Mar 11", "Fri Mar 6", "techcraft", "50%"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view is missing %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "Thu Mar 5") {
+		t.Error("per-day chart goes back more than a week")
+	}
+
+	// Tab cycles the scope both ways, and the state keeps it
+	sd.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if sd.scope != ScopeSession {
+		t.Errorf("tab from all = %s", sd.scope)
+	}
+	sd.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+	if sd.GetState()["scope"] != int(ScopeAll) {
+		t.Errorf("shift+tab from session = %v", sd.GetState()["scope"])
+	}
+}
+
+func TestSandboxDoor(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.Dispatch().LoadHistory([]DispatchAction{
+		{ID: "h1", NodeID: "old.md:1", Content: "decision:: rotate auth tokens", PatternType: "decision"},
+		{ID: "h2", NodeID: "old.md:2", Content: "ctx:: auth review", PatternType: "ctx"},
+	})
+	o.SetContent("• decision:: ship auth on monday\n• decision:: pick sqlite")
+
+	sd := NewDoorRegistry().Create("sandbox").(*SandboxDoor)
+	sd.SetDispatch(o.Dispatch())
+	sd.Activate()
+	typing := func(text string) {
+		for _, r := range text {
+			sd.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+	typing("collect all decisions about auth")
+	if len(sd.matched) != 2 || sd.considered != 4 {
+		t.Fatalf("dry run matched %d of %d: %v", len(sd.matched), sd.considered, sd.matched)
+	}
+	if len(o.Dispatch().GetReducers()) != 0 {
+		t.Fatal("the dry run registered a reducer")
+	}
+
+	sd.Update(tea.KeyMsg{Type: tea.KeyTab})
+	typing("auth decisions")
+	view := sd.View(100, 40)
+	for _, want := range []string{"2 of 4 logged actions match", "rotate auth tokens", "# auth decisions", "From sandbox (2 items)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("sandbox lacks %q:\n%s", want, view)
+		}
+	}
+
+	// A name with a space can't be a reducer's
+	sd.Update(tea.KeyMsg{Type: tea.KeyTab})
+	typing(" auth")
+	if _, cmd := sd.Update(tea.KeyMsg{Type: tea.KeyEnter}); cmd != nil || sd.err == "" {
+		t.Fatal("promoted a two-word name")
+	}
+	sd.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+	typing("auth")
+	_, cmd := sd.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatalf("enter didn't promote: %s", sd.err)
+	}
+	promote, ok := cmd().(SandboxPromoteMsg)
+	if !ok {
+		t.Fatalf("promoting sent %T", cmd())
+	}
+
+	if n := o.PromoteDefinitions(promote.Nodes); n != 2 {
+		t.Fatalf("promoted %d nodes", n)
+	}
+	// After the cursor's node, pushing the rest down
+	if got := o.lines[1].Text; got != "reducer::auth collect all decisions about auth" || !o.lines[1].Captured || o.cursor != 1 {
+		t.Errorf("reducer node %q, captured %v, cursor %d", got, o.lines[1].Captured, o.cursor)
+	}
+	if got := o.lines[2].Text; got != "selector:: (auth) => auth decisions" {
+		t.Errorf("selector node %q", got)
+	}
+	if _, ok := o.Dispatch().GetReducers()["auth"]; !ok {
+		t.Error("the promoted reducer wasn't registered")
+	}
+}
+
+func TestReducerResultsFile(t *testing.T) {
+	dir := t.TempDir()
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.SetContent("• reducer::auth collect all decisions about auth [output:: reducers/auth.md]\n• decision:: ship auth on monday\n• decision:: pick sqlite")
+
+	reducer := o.Dispatch().GetReducers()["auth"]
+	if reducer == nil || reducer.Query != "collect all decisions about auth" {
+		t.Fatalf("the annotation leaked into the reducer: %+v", reducer)
+	}
+	written, err := o.ExportReducers(dir)
+	if err != nil || len(written) != 1 {
+		t.Fatalf("wrote %v, %v", written, err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "reducers", "auth.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "- decision:: ship auth on monday\n"; !strings.Contains(string(data), want) || strings.Contains(string(data), "sqlite") {
+		t.Errorf("results file:\n%s", data)
+	}
+
+	// Unchanged results aren't rewritten, and the outline stays clean
+	if written, _ := o.ExportReducers(dir); len(written) != 0 {
+		t.Errorf("rewrote unchanged results: %v", written)
+	}
+	o.handleReducerUpdateMessage(ReducerUpdateMsg{ReducerName: "auth", Action: DispatchAction{PatternType: "decision", Content: "rotate auth tokens"}})
+	if len(o.lines) != 3 {
+		t.Errorf("a results file reducer grew children: %d nodes", len(o.lines))
+	}
+}
+
+func TestSelectorExport(t *testing.T) {
+	dir := t.TempDir()
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.SetContent("• reducer::auth collect all decisions about auth\n• decision:: ship auth on monday\n" +
+		"• selector:: (auth) => Auth decisions [output:: out/auth.md]\n" +
+		"• selector:: (auth) => Auth log [output:: out/auth.json]\n" +
+		"• selector:: (auth) => Scratch Notes")
+
+	// Export paths come from the annotation, which stays out of the heading
+	exports := o.SelectorExports()
+	if len(exports) != 2 || exports[0].Path != "out/auth.json" || exports[1].Path != "out/auth.md" {
+		t.Fatalf("exports = %+v", exports)
+	}
+	if _, format, _ := ParseSelectorDefinition("(auth) => Auth decisions [output:: out/auth.md]"); format != "Auth decisions" {
+		t.Errorf("output format = %q", format)
+	}
+
+	written, err := o.ExportSelectors(dir, false)
+	if err != nil || len(written) != 2 {
+		t.Fatalf("wrote %v, %v", written, err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "out", "auth.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Auth decisions") || !strings.Contains(string(data), "ship auth on monday") {
+		t.Errorf("markdown artifact:\n%s", data)
+	}
+	var artifact selectorExportJSON
+	data, err = os.ReadFile(filepath.Join(dir, "out", "auth.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &artifact); err != nil || len(artifact.Inputs["auth"]) != 1 || artifact.Output == "" {
+		t.Errorf("json artifact = %+v, %v", artifact, err)
+	}
+
+	// Watching only rewrites what changed
+	if written, _ := o.ExportSelectors(dir, true); len(written) != 0 {
+		t.Errorf("rewrote unchanged artifacts: %v", written)
+	}
+	o.lines = append(o.lines, newNode("decision:: rotate auth tokens", 0))
+	o.captureConsciousness("test")
+	if written, _ := o.ExportSelectors(dir, true); len(written) != 2 {
+		t.Errorf("after a new decision wrote %v", written)
+	}
+
+	// Exporting the one under the cursor annotates it first
+	o.cursor = 4
+	path, err := o.ExportSelectorAtCursor(dir)
+	if err != nil || path != filepath.Join(dir, "scratch-notes.md") {
+		t.Fatalf("ExportSelectorAtCursor = %s, %v", path, err)
+	}
+	if !strings.HasSuffix(o.lines[4].Text, " [output:: scratch-notes.md]") || len(o.SelectorExports()) != 3 {
+		t.Errorf("selector after export: %q", o.lines[4].Text)
+	}
+	o.cursor = 1
+	if _, err := o.ExportSelectorAtCursor(dir); err == nil {
+		t.Error("exported a node that isn't a selector")
+	}
+}
+
+func TestCollectedChildOrigin(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.Dispatch().SetSource("/notes/today.md")
+	o.SetContent("• reducer::shipped collect all decisions\n• decision:: ship it")
+
+	action := o.Dispatch().GetActions()[1]
+	if action.Source != "/notes/today.md" || action.NodeID != o.lines[1].ID {
+		t.Fatalf("dispatched from %q node %q", action.Source, action.NodeID)
+	}
+	o.handleReducerUpdateMessage(ReducerUpdateMsg{ReducerName: "shipped", Action: action})
+	o.handleReducerUpdateMessage(ReducerUpdateMsg{ReducerName: "shipped", Action: DispatchAction{NodeID: "old.md:3", PatternType: "decision", Content: "cut scope"}})
+
+	o.SetCursor(1)
+	if origin, ok := o.CursorOrigin(); !ok || origin.Path != "/notes/today.md" || origin.NodeID != o.lines[3].ID {
+		t.Errorf("first child's origin %+v, %v", origin, ok)
+	}
+	if origin := o.lines[2].Origin; origin == nil || origin.Path != "old.md" || origin.Line != 3 {
+		t.Errorf("logged child's origin %+v", origin)
+	}
+
+	o.detailMode = true
+	if got := o.renderNodeContent(o.lines[1]); !strings.Contains(got, "[from today.md#^"+o.lines[3].ID+"]") {
+		t.Errorf("detail mode shows %q", got)
+	}
+	if got := o.renderNodeContent(o.lines[2]); !strings.Contains(got, "[from old.md:3]") {
+		t.Errorf("detail mode shows %q", got)
+	}
+	if o.JumpToLine(4); o.Cursor() != 3 {
+		t.Errorf("line 4 is node %d", o.Cursor())
+	}
+}
+
+func TestRenameConcept(t *testing.T) {
+	if got, n := RenameLinks("see [[Auth]] and [[auth#Tokens|the tokens]], not [[author]] or [[auth.md#^3f9a1c2e]]", "auth", "identity"); n != 2 ||
+		got != "see [[identity]] and [[identity#Tokens|the tokens]], not [[author]] or [[auth.md#^3f9a1c2e]]" {
+		t.Errorf("RenameLinks = %q, %d", got, n)
+	}
+
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.SetContent("• decision:: ship [[auth]]\n  • [[auth]] review\n• unrelated")
+
+	if _, err := o.RenameConcept("auth", "Auth"); err == nil {
+		t.Error("renamed a concept to itself")
+	}
+	n, err := o.RenameConcept("auth", "identity")
+	if err != nil || n != 2 {
+		t.Fatalf("RenameConcept = %d, %v", n, err)
+	}
+	want := "• decision:: ship [[identity]]\n  • [[identity]] review\n• unrelated\n• aka:: auth → [[identity]]\n"
+	if got := o.GetContent(); got != want {
+		t.Errorf("renamed content = %q", got)
+	}
+	if len(o.linkRegistry["auth"]) != 0 || len(o.linkRegistry["identity"]) != 3 {
+		t.Errorf("link registry = %v", o.linkRegistry)
+	}
+	if o.lines[3].PatternType != "aka" {
+		t.Errorf("alias pattern = %q", o.lines[3].PatternType)
+	}
+
+	// The alias isn't recorded twice when the old name comes back
+	o.lines[2].Text = "[[auth]] again"
+	o.updateNodeLinks(2)
+	o.RenameConcept("auth", "identity")
+	if got := strings.Count(o.GetContent(), "aka::"); got != 1 {
+		t.Errorf("%d aka:: nodes after renaming again", got)
+	}
+
+	o.Undo()
+	o.Undo()
+	if got := o.GetContent(); got != "• decision:: ship [[auth]]\n  • [[auth]] review\n• unrelated\n" {
+		t.Errorf("undone content = %q", got)
+	}
+}
+
+func TestGraphReport(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.SetContent(strings.Join([]string{
+		"# Auth",
+		"• decision:: rotate [[auth]] tokens, see [[Billing#Plans]]",
+		"• eureka:: caching [concept:: [[cache]]]",
+		"• [[cache]] warms on start",
+		"• aka:: payments → [[billing]]",
+		"• [[payments]] moved",
+		"• a stray thought",
+		"• bridge:: handoff [bridge-id:: CB-1]",
+		"• bridge:: [bridge-id:: CB-2] opened",
+		"• picked up [bridge-id:: CB-2]",
+	}, "\n"))
+
+	report := o.GraphReport()
+	if len(report.Orphans) != 1 || report.Orphans[0].Label != "Billing" || report.Orphans[0].Count != 2 {
+		t.Errorf("orphans = %+v", report.Orphans)
+	}
+	// Bridge ends are reported as lone bridges, not unlinked nodes
+	if len(report.Unlinked) != 1 || report.Unlinked[0].Label != "a stray thought" {
+		t.Errorf("unlinked = %+v", report.Unlinked)
+	}
+	if len(report.LoneBridges) != 1 || report.LoneBridges[0].Label != "CB-1" {
+		t.Errorf("lone bridges = %+v", report.LoneBridges)
+	}
+
+	gd := NewDoorRegistry().Create("graph").(*GraphDoor)
+	gd.SetReport(report)
+	gd.Activate()
+	_, cmd := gd.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("Enter on an orphan sent nothing")
+	}
+	if msg, ok := cmd().(GraphJumpMsg); !ok || msg.NodeID != report.Orphans[0].NodeID {
+		t.Errorf("Enter sent %+v", cmd())
+	}
+}
+
+func TestBacklinks(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.SetContent("# Auth\n• rotate [[auth]] tokens\n• read [[Auth#Tokens|the token notes]]\n• auth is mentioned, not linked\n• [[billing]] only")
+	backlinks := func(i int) []string {
+		var texts []string
+		for _, id := range o.lines[i].Backlinks {
+			texts = append(texts, o.lines[o.nodeIndex(id)].Text)
+		}
+		return texts
+	}
+
+	if got := backlinks(0); !slices.Equal(got, []string{"rotate [[auth]] tokens", "read [[Auth#Tokens|the token notes]]"}) {
+		t.Errorf("heading backlinks = %q", got)
+	}
+	if got := backlinks(1); !slices.Equal(got, []string{"read [[Auth#Tokens|the token notes]]"}) {
+		t.Errorf("linking node's backlinks = %q", got)
+	}
+	if got := backlinks(3); len(got) != 0 {
+		t.Errorf("a plain mention has backlinks %q", got)
+	}
+	if got := backlinks(4); len(got) != 0 {
+		t.Errorf("a concept linked once has backlinks %q", got)
+	}
+
+	// Relinking a node updates the nodes on both concepts
+	o.lines[2].Text = "read [[billing]] too"
+	o.updateNodeLinks(2)
+	if got := backlinks(0); !slices.Equal(got, []string{"rotate [[auth]] tokens"}) {
+		t.Errorf("heading backlinks after relinking = %q", got)
+	}
+	if got := backlinks(4); !slices.Equal(got, []string{"read [[billing]] too"}) {
+		t.Errorf("billing backlinks after relinking = %q", got)
+	}
+
+	o.deleteNodes(1, 2)
+	if got := backlinks(0); len(got) != 0 {
+		t.Errorf("heading backlinks after deleting its linker = %q", got)
+	}
+
+	// The incremental index agrees with one built from scratch
+	want := make([][]string, len(o.lines))
+	for i := range o.lines {
+		want[i] = slices.Clone(o.lines[i].Backlinks)
+	}
+	o.updateBacklinks()
+	for i := range o.lines {
+		if !slices.Equal(o.lines[i].Backlinks, want[i]) {
+			t.Errorf("node %d: incremental backlinks %v, rebuilt %v", i, want[i], o.lines[i].Backlinks)
+		}
+	}
+}
+
+func BenchmarkUpdateNodeLinks(b *testing.B) {
+	content, _ := benchmarkOutline(2000)
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.SetContent(content)
+	texts := []string{"eureka:: insight about [[parsing]] and [[lexing]]", "eureka:: insight about [[parsing]]"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		o.lines[1].Text = texts[i%2]
+		o.updateNodeLinks(1)
+	}
+}
+
+func BenchmarkTyping(b *testing.B) {
+	content, _ := benchmarkOutline(10000)
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.Focus()
+	o.SetContent(content)
+	o.cursor = 100
+	key := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		o.cursorPos = len(o.lines[o.cursor].Text)
+		o, _ = o.Update(key)
+	}
+}
+
+// BenchmarkInsertNode compares insertNodes with the splice it replaced,
+// which copied the tail into a new slice on every insert. Both still move
+// the tail; insertNodes does it without allocating while capacity lasts.
+func BenchmarkInsertNode(b *testing.B) {
+	content, _ := benchmarkOutline(10000)
+	for _, bc := range []struct {
+		name   string
+		insert func(o *Outliner, at int, node OutlineNode)
+	}{
+		{"splice", func(o *Outliner, at int, node OutlineNode) {
+			o.lines = append(o.lines[:at], append([]OutlineNode{node}, o.lines[at:]...)...)
+		}},
+		{"in place", func(o *Outliner, at int, node OutlineNode) {
+			o.insertNodes(at, node)
+		}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			o := New()
+			o.Evna().SetEnabled(false)
+			o.SetContent(content)
+			node := newNode("ctx:: inserted", 0)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				bc.insert(&o, 100, node)
+				o.lines = slices.Delete(o.lines, 100, 101)
+			}
+		})
+	}
+}
+
+func TestDiffOutlines(t *testing.T) {
+	before := `# Plan
+• ship the parser today
+  • lexer first
+• review notes
+• deploy
+  • staging
+• scratch ideas
+  • half a thought
+`
+	after := `# Plan
+• review notes
+• ship the parser this week
+  • lexer first
+  • deploy
+    • staging
+• fresh start
+  • whole thought
+`
+	d := DiffOutlines(before, after)
+	type change struct {
+		kind       ChangeKind
+		text       string
+		nodes      int
+		from, to   int
+		newText    string
+		toParent   string
+		beforeLine int
+	}
+	var got []change
+	for _, c := range d.Changes {
+		got = append(got, change{c.Kind, c.Text, c.Nodes, c.FromLevel, c.ToLevel, c.NewText, c.ToParent, c.Before})
+	}
+	want := []change{
+		{ChangeMoved, "review notes", 1, 0, 0, "", "", 3},
+		{ChangeEdited, "ship the parser today", 1, 0, 0, "ship the parser this week", "", 1},
+		{ChangeLevel, "deploy", 1, 0, 1, "", "", 4},
+		{ChangeRemoved, "scratch ideas", 2, 0, 0, "", "", 6},
+		{ChangeAdded, "fresh start", 2, 0, 0, "", "", -1},
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("changes\n%+v\nwant\n%+v", got, want)
+	}
+
+	// An [id:: ...] keeps a node itself however much it's rewritten
+	d = DiffOutlines("• draft [id:: n1]\n• other\n", "• other\n  • final wording [id:: n1]\n")
+	for _, c := range d.Changes {
+		if c.Kind == ChangeAdded || c.Kind == ChangeRemoved {
+			t.Errorf("id-matched node %s: %+v", c.Kind, c)
+		}
+	}
+	if !slices.ContainsFunc(d.Changes, func(c OutlineChange) bool {
+		return c.Kind == ChangeEdited && c.NewText == "final wording [id:: n1]"
+	}) {
+		t.Errorf("id-matched node not edited: %+v", d.Changes)
+	}
+
+	if d := DiffOutlines(before, before); len(d.Changes) != 0 {
+		t.Errorf("identical outlines differ: %+v", d.Changes)
+	}
+}
+
+func TestMergeOutlines(t *testing.T) {
+	cases := []struct{ before, after string }{
+		{"• a\n• b\n  • b1\n• c\n", "• c\n  • b\n    • b1\n• a\n"},
+		{"• a\n  • a1\n  • a2\n• b\n", "• b\n  • a2\n• a\n  • a1\n"},
+		{"# H\n\n• x one two\n• y\n", "# H\n\n• y\n• x one two three\n• z\n"},
+		{"• p\n  • q\n    • r\n• s\n", "• s\n• p\n• q\n  • r\n"},
+		{"• one\n• two\n• three\n", "• three\n• two\n• one\n"},
+	}
+	for _, c := range cases {
+		d := DiffOutlines(c.before, c.after)
+		all := make([]bool, len(d.Changes))
+		for i := range all {
+			all[i] = true
+		}
+		if got := d.Merge(all); got != c.after {
+			t.Errorf("taking every change of\n%s= %q, want %q", c.before, got, c.after)
+		}
+		if got := d.Merge(nil); got != c.before {
+			t.Errorf("taking none = %q, want %q", got, c.before)
+		}
+	}
+
+	// Recovering keeps what only the file has and takes the rest
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.SetContent("• saved after the crash\n• draft intro\n")
+	id := o.lines[1].ID
+	d := o.DiffWith("• draft intro, finished\n• written before the crash\n")
+	if n := o.ApplyMerge(d, d.Additive()); n != 2 {
+		t.Fatalf("merged %d changes, want 2: %+v", n, d.Changes)
+	}
+	want := "• saved after the crash\n• draft intro, finished\n• written before the crash\n"
+	if got := o.GetContent(); got != want {
+		t.Errorf("merged content = %q, want %q", got, want)
+	}
+	if o.lines[1].ID != id || len(o.Versions(id)) == 0 {
+		t.Error("the edited node lost its ID or its earlier text")
+	}
+	if !o.Undo() || o.GetContent() != "• saved after the crash\n• draft intro\n" {
+		t.Errorf("undoing the merge left %q", o.GetContent())
+	}
+}
+
+func TestImportRoam(t *testing.T) {
+	export := `[{"title": "May 1st, 2024", "children": [
+		{"string": "{{[[TODO]]}} ask #[[Ada Lovelace]] about ((b2))", "uid": "b1", "create-time": 1714550400000},
+		{"string": "Plan", "uid": "b3", "heading": 2, "children": [
+			{"string": "Status:: [[in progress]]", "uid": "b4"},
+			{"string": "Eureka:: it was DNS", "uid": "b5"},
+			{"string": "the cause", "uid": "b2"},
+			{"string": "{{embed: ((b2))}}", "uid": "b6"}]}]}]`
+	want := "# May 1st, 2024 [date:: 2024-05-01]\n" +
+		"  • [ ] ask [[Ada Lovelace]] about [[#^b2]]\n" +
+		"  ## Plan [status:: in progress]\n" +
+		"    • eureka:: it was DNS\n" +
+		"    • the cause [id:: b2]\n" +
+		"    • ((b2))\n"
+
+	o := New()
+	o.Evna().SetEnabled(false)
+	if err := o.Import(ImportRoam, []byte(export)); err != nil {
+		t.Fatal(err)
+	}
+	if got := o.GetContent(); got != want {
+		t.Errorf("imported\n%s\nwant\n%s", got, want)
+	}
+	if o.lines[1].ID != "b1" || !o.lines[1].CreatedAt.Equal(time.UnixMilli(1714550400000)) {
+		t.Errorf("block kept ID %q, created %v", o.lines[1].ID, o.lines[1].CreatedAt)
+	}
+	if o.lines[3].PatternType != "eureka" || o.lines[5].Mirror != "b2" {
+		t.Errorf("pattern %q, mirror %q", o.lines[3].PatternType, o.lines[5].Mirror)
+	}
+
+	edn := `#datascript/DB {:schema {:block/uid {:db/unique :db.unique/identity}}
+		:datoms [[1 :node/title "Page" 536870913] [1 :block/children 2 536870913]
+		[1 :block/children 3 536870913] [2 :block/string "second" 536870913]
+		[2 :block/order 1 536870913] [3 :block/string "first, \"quoted\"" 536870913]
+		[3 :block/order 0 536870913]]}`
+	nodes, err := ParseImport(ImportRoam, []byte(edn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, n := range nodes {
+		got = append(got, formatNode(n))
+	}
+	if want := []string{"# Page", `  • first, "quoted"`, "  • second"}; !slices.Equal(got, want) {
+		t.Errorf("EDN import = %q, want %q", got, want)
+	}
+}
+
+func TestImportWorkflowy(t *testing.T) {
+	opml := `<?xml version="1.0"?><opml version="2.0"><body>
+		<outline text="Meet &lt;b&gt;Bob&lt;/b&gt; &lt;time startYear=&quot;2024&quot; startMonth=&quot;5&quot; startDay=&quot;1&quot;&gt;Wed, May 1, 2024&lt;/time&gt; #work" _note="bring notes">
+			<outline text="book room" _complete="true"/>
+		</outline></body></opml>`
+	backup := `[{"id": "w1", "nm": "Meet <b>Bob</b> <time startYear=\"2024\" startMonth=\"5\" startDay=\"1\">Wed, May 1, 2024</time> #work", "no": "bring notes",
+		"ch": [{"id": "w2", "nm": "book room", "cp": 1200}]}]`
+	want := "• Meet **Bob** #work [date:: 2024-05-01]\n  • note:: bring notes\n  • [x] book room\n"
+
+	for name, export := range map[string]string{"OPML": opml, "backup": backup} {
+		o := New()
+		o.Evna().SetEnabled(false)
+		if err := o.Import(ImportWorkflowy, []byte(export)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got := o.GetContent(); got != want {
+			t.Errorf("%s imported %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestImportDynalist(t *testing.T) {
+	doc := `{"_code": "Ok", "nodes": [
+		{"id": "root", "content": "Doc", "children": ["a", "b"]},
+		{"id": "a", "content": "Ship it !(2024-05-01 | 1w) #release", "checkbox": true, "modified": 1714550400000, "children": ["c"]},
+		{"id": "c", "content": "decision:: go", "checked": true},
+		{"id": "b", "content": "Notes", "heading": 1, "note": "one\ntwo"}]}`
+	want := "• [ ] Ship it #release [date:: 2024-05-01]\n  • [x] decision:: go\n# Notes\n  • note:: one\n  • note:: two\n"
+
+	o := New()
+	o.Evna().SetEnabled(false)
+	if err := o.Import(ImportDynalist, []byte(doc)); err != nil {
+		t.Fatal(err)
+	}
+	if got := o.GetContent(); got != want {
+		t.Errorf("imported %q, want %q", got, want)
+	}
+	if o.lines[1].PatternType != "decision" || !o.lines[0].ModifiedAt.Equal(time.UnixMilli(1714550400000)) {
+		t.Errorf("pattern %q, modified %v", o.lines[1].PatternType, o.lines[0].ModifiedAt)
+	}
+
+	if _, err := ParseImport("logseq", []byte(doc)); err == nil {
+		t.Error("an unknown format imported")
+	}
+}
+
+func TestRefileSubtree(t *testing.T) {
+	inbox := New()
+	inbox.Evna().SetEnabled(false)
+	inbox.SetContent("# Inbox\n• [ ] eureka:: call back\n  • details\n• read later\n")
+	items := inbox.InboxItems()
+	if len(items) != 2 || items[0].Node != 1 || items[0].Nodes != 2 || items[0].PatternType != "eureka" {
+		t.Fatalf("inbox items = %+v", items)
+	}
+
+	target := New()
+	target.Evna().SetEnabled(false)
+	target.SetContent("• Projects\n  • Alpha\n• Other\n")
+	subtree, err := inbox.TakeSubtree(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := target.RefileSubtree(subtree, 0); err != nil {
+		t.Fatal(err)
+	}
+	want := "• Projects\n  • Alpha\n  • [ ] eureka:: call back\n    • details\n• Other\n"
+	if got := target.GetContent(); got != want {
+		t.Errorf("refiled under a node: %q, want %q", got, want)
+	}
+	if got := inbox.GetContent(); got != "# Inbox\n• read later\n" {
+		t.Errorf("inbox after taking = %q", got)
+	}
+
+	if err := inbox.SetPatternType(1, "gotcha"); err != nil {
+		t.Fatal(err)
+	}
+	if err := target.SetPatternType(2, "decision"); err != nil {
+		t.Fatal(err)
+	}
+	if got := target.lines[2].Text; got != "[ ] decision:: call back" {
+		t.Errorf("converted task = %q", got)
+	}
+	if inbox.lines[1].Text != "gotcha:: read later" || inbox.SetPatternType(1, "nonsense") == nil {
+		t.Errorf("converted %q", inbox.lines[1].Text)
+	}
+
+	empty := New()
+	empty.Evna().SetEnabled(false)
+	if err := empty.RefileSubtree(subtree, -1); err != nil || empty.GetContent() != "• [ ] eureka:: call back\n  • details\n" {
+		t.Errorf("refiled into an empty outline: %q, %v", empty.GetContent(), err)
+	}
+	inbox.Undo()
+	inbox.Undo()
+	if got := inbox.GetContent(); got != "# Inbox\n• [ ] eureka:: call back\n  • details\n• read later\n" {
+		t.Errorf("undone inbox = %q", got)
+	}
+}
+
+func TestDocumentStats(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.SetContent("# Zine\n• ctx:: drafting the intro [status:: draft]\n• eureka:: layouts follow the selectors\n  • [x] print 40 copies\n## Back matter\n• plain thanks to → everyone\n```\ncode words here\n```\n")
+	o.TriggerConsciousnessCapture()
+
+	stats := o.DocumentStats(time.Now())
+	if stats.Words != 17 || stats.Nodes != 9 || stats.AddedToday != 0 {
+		t.Errorf("words %d, nodes %d, added today %d", stats.Words, stats.Nodes, stats.AddedToday)
+	}
+	if stats.Patterns["ctx"] != 1 || stats.Patterns["eureka"] != 1 || stats.Patterned != 2 || stats.CaptureRatio() != 1 {
+		t.Errorf("patterns %v, %d of %d captured", stats.Patterns, stats.Captured, stats.Patterned)
+	}
+	want := []SectionStats{{Title: "Zine", Level: 1, Words: 17, Nodes: 9}, {Title: "Back matter", Level: 2, Words: 6, Nodes: 5}}
+	if !slices.Equal(stats.Sections, want) {
+		t.Errorf("sections = %+v", stats.Sections)
+	}
+
+	// A node written since loading was added today, and isn't captured yet
+	if err := o.RefileSubtree([]OutlineNode{newNode("decision:: ship it", 0)}, -1); err != nil {
+		t.Fatal(err)
+	}
+	stats = o.DocumentStats(time.Now())
+	if stats.AddedToday != 1 || stats.Patterned != 3 || stats.Captured != 2 || stats.Matches != 3 {
+		t.Errorf("after adding: %+v", stats)
+	}
+	if got := stats.Summary(); got != "19 words · 10 nodes · patterns: 1 ctx, 1 decision, 1 eureka · 67% captured · 1 added today" {
+		t.Errorf("summary = %q", got)
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		text        string
+		patternType string
+		plain       string
+		annotations []Annotation
+		formatted   string
+	}{
+		{
+			name:  "none",
+			text:  "ctx:: morning review",
+			plain: "ctx:: morning review",
+		},
+		{
+			name:        "suggested keys first",
+			text:        "decision:: use postgres [owner:: sam] [area:: db] [priority:: high]",
+			patternType: "decision",
+			plain:       "decision:: use postgres",
+			annotations: []Annotation{{"owner", "sam"}, {"area", "db"}, {"priority", "high"}},
+			formatted:   "decision:: use postgres [priority:: high] [owner:: sam] [area:: db]",
+		},
+		{
+			name:        "others alphabetically",
+			text:        "[zeta:: 1] eureka:: thin walls [alpha::  spaced out ]",
+			patternType: "eureka",
+			plain:       "eureka:: thin walls",
+			annotations: []Annotation{{"zeta", "1"}, {"alpha", "spaced out"}},
+			formatted:   "eureka:: thin walls [alpha:: spaced out] [zeta:: 1]",
+		},
+		{
+			name:        "empty values dropped",
+			text:        "gotcha:: cache key [fix::] [cause:: stale hash]",
+			patternType: "gotcha",
+			plain:       "gotcha:: cache key",
+			annotations: []Annotation{{"fix", ""}, {"cause", "stale hash"}},
+			formatted:   "gotcha:: cache key [cause:: stale hash]",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			plain, annotations := ParseAnnotations(tc.text)
+			if plain != tc.plain || !slices.Equal(annotations, tc.annotations) {
+				t.Fatalf("ParseAnnotations = %q, %v, want %q, %v", plain, annotations, tc.plain, tc.annotations)
+			}
+			formatted := FormatAnnotations(plain, tc.patternType, annotations)
+			want := tc.formatted
+			if want == "" {
+				want = tc.plain
+			}
+			if formatted != want {
+				t.Fatalf("FormatAnnotations = %q, want %q", formatted, want)
+			}
+
+			// Formatted text parses back to the same annotations and
+			// formats the same again
+			again, reparsed := ParseAnnotations(formatted)
+			if FormatAnnotations(again, tc.patternType, reparsed) != formatted || again != plain {
+				t.Errorf("round trip of %q gave %q, %v", formatted, again, reparsed)
+			}
+		})
+	}
+}
+
+func TestMetadataWriteBack(t *testing.T) {
+	o := New()
+	o.Evna().SetEnabled(false)
+	o.Focus()
+	o.SetContent("• ctx:: planning [owner:: sam]\n• next")
+	id := o.lines[0].ID
+	before := o.lines[0].ModifiedAt
+	typeText := func(s string) {
+		for _, r := range s {
+			o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	// Add a project:: from the suggestions
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'m'}, Alt: true})
+	if !o.IsMetadataEditorOpen() {
+		t.Fatal("metadata editor didn't open")
+	}
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyDown})
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	typeText("float")
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	node := o.lines[0]
+	if node.Text != "ctx:: planning [project:: float] [owner:: sam]" {
+		t.Fatalf("text = %q", node.Text)
+	}
+	if node.PatternType != "ctx" || !node.ModifiedAt.After(before) || node.Captured {
+		t.Errorf("node after the write-back: pattern %q, modified %v, captured %v", node.PatternType, node.ModifiedAt, node.Captured)
+	}
+	if versions := o.Versions(id); len(versions) != 2 || versions[0].Text != "ctx:: planning [owner:: sam]" {
+		t.Errorf("versions = %v", versions)
+	}
+
+	// Deleting one is a version too; leaving the node doesn't add another
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	o, _ = o.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if o.lines[0].Text != "ctx:: planning [owner:: sam]" || len(o.Versions(id)) != 3 {
+		t.Errorf("after deleting project: %q, versions %v", o.lines[0].Text, o.Versions(id))
+	}
+
+	// and Ctrl+Z takes each back
+	for _, want := range []string{"ctx:: planning [project:: float] [owner:: sam]", "ctx:: planning [owner:: sam]"} {
+		if !o.Undo() || o.lines[0].Text != want {
+			t.Errorf("after undo: %q, want %q", o.lines[0].Text, want)
+		}
+	}
+	if o.CanUndo() {
+		t.Error("an unchanged write-back saved an undo step")
+	}
+}
@@ -1,6 +1,8 @@
 package outliner
 
 import (
+	"hash/fnv"
+	"maps"
 	"regexp"
 	"strings"
 )
@@ -33,6 +35,27 @@ type AnnotationPattern struct {
 // Parser handles structured annotation parsing
 type Parser struct {
 	patterns []AnnotationPattern
+
+	// Pattern detection results by line key, for ParseIncremental
+	lineCache map[string]cachedLine
+	stats     ParseStats
+
+	// Lint results by line key, for LintIncremental
+	lintCache map[string]cachedLint
+	lintStats ParseStats
+}
+
+// cachedLine is the pattern detection result for one keyed line
+type cachedLine struct {
+	hash     uint64
+	patterns []ConsciousnessPattern
+}
+
+// ParseStats counts lines ParseIncremental or LintIncremental reused
+// versus rescanned
+type ParseStats struct {
+	Reused    int
+	Rescanned int
 }
 
 // NewParser creates a new parser with default patterns
@@ -78,8 +101,76 @@ func (p *Parser) RegisterPattern(name string, pattern string, handler func(strin
 	})
 }
 
+// Section header and meta item rules, compiled once
+var (
+	sectionHighlightRegex = regexp.MustCompile(`^•\s*highlight::\s*(.+)$`)
+	sectionNoteRegex      = regexp.MustCompile(`^•\s*note::\s*(.*)$`)
+	sectionTagsRegex      = regexp.MustCompile(`^•\s*tags::\s*(.+)$`)
+	sectionMetaRegex      = regexp.MustCompile(`^•\s*meta::\s*$`)
+	metaItemRegex         = regexp.MustCompile(`^(\w+)::\s*(.+)$`)
+)
+
 // Parse extracts structured content from outliner text
 func (p *Parser) Parse(content string) *StructuredContent {
+	return p.parse(content, func(lineNum int, line string) []ConsciousnessPattern {
+		return p.detectConsciousnessPatterns(line, lineNum+1)
+	})
+}
+
+// ParseIncremental parses content like Parse, but only re-runs pattern
+// detection on lines whose text changed since the last call. keys[i]
+// identifies line i across edits (the outliner passes node IDs); lines
+// without a key are always rescanned. Lines whose keys are absent from a
+// call are forgotten, so the cache tracks the current document. Like the
+// rest of Parser, it is not safe for concurrent use.
+func (p *Parser) ParseIncremental(content string, keys []string) *StructuredContent {
+	previous := p.lineCache
+	p.lineCache = make(map[string]cachedLine, len(keys))
+	p.stats = ParseStats{}
+
+	return p.parse(content, func(lineNum int, line string) []ConsciousnessPattern {
+		if lineNum >= len(keys) || keys[lineNum] == "" {
+			p.stats.Rescanned++
+			return p.detectConsciousnessPatterns(line, lineNum+1)
+		}
+
+		key, hash := keys[lineNum], hashLine(line)
+		cached, ok := previous[key]
+		if !ok || cached.hash != hash {
+			cached = cachedLine{hash: hash, patterns: p.detectConsciousnessPatterns(line, 0)}
+			p.stats.Rescanned++
+		} else {
+			p.stats.Reused++
+		}
+		p.lineCache[key] = cached
+
+		// Lines move as nodes are inserted, so numbers are filled per call
+		patterns := make([]ConsciousnessPattern, len(cached.patterns))
+		for i, pattern := range cached.patterns {
+			pattern.Line = lineNum + 1
+			pattern.Context = maps.Clone(pattern.Context)
+			patterns[i] = pattern
+		}
+		return patterns
+	})
+}
+
+// LastParseStats reports how much of the last ParseIncremental call was
+// served from the cache
+func (p *Parser) LastParseStats() ParseStats {
+	return p.stats
+}
+
+// hashLine fingerprints a line for the incremental cache
+func hashLine(line string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(line))
+	return h.Sum64()
+}
+
+// parse walks content line by line; detect supplies each line's
+// consciousness patterns
+func (p *Parser) parse(content string, detect func(lineNum int, line string) []ConsciousnessPattern) *StructuredContent {
 	result := &StructuredContent{
 		Meta:              make(map[string]string),
 		Raw:               content,
@@ -88,32 +179,70 @@ func (p *Parser) Parse(content string) *StructuredContent {
 
 	lines := strings.Split(content, "\n")
 	currentSection := ""
+	sectionDepth := 0  // indent level of the current section's header
+	archiveDepth := -1 // indent level of the archived subtree being skipped
+	var note []string
+
+	for lineNum, raw := range lines {
+		line := strings.TrimSpace(raw)
+		depth := indentDepth(raw)
+
+		// Archived subtrees keep their patterns out of capture
+		if line != "" {
+			if archiveDepth >= 0 && depth > archiveDepth {
+				continue
+			}
+			archiveDepth = -1
+			if isArchiveRoot(line) {
+				archiveDepth = depth
+				continue
+			}
+		}
 
-	for lineNum, line := range lines {
 		// Detect consciousness patterns first
-		p.detectConsciousnessPatterns(line, lineNum+1, result)
-		line = strings.TrimSpace(line)
+		result.ConsciousnessData = append(result.ConsciousnessData, detect(lineNum, raw)...)
 		if line == "" {
 			continue
 		}
 
+		// Lines under a section header belong to it; note:: keeps their
+		// nesting, relative to its first level
+		if currentSection != "" && depth > sectionDepth {
+			text := strings.TrimPrefix(strings.TrimPrefix(line, "•"), " ")
+			switch currentSection {
+			case "note":
+				note = append(note, strings.Repeat("  ", depth-sectionDepth-1)+text)
+				continue
+			case "meta":
+				if match := metaItemRegex.FindStringSubmatch(text); match != nil {
+					result.Meta[strings.TrimSpace(match[1])] = strings.TrimSpace(match[2])
+				}
+				continue
+			}
+		}
+		currentSection = ""
+		sectionDepth = depth
+
 		// Check for main section headers
-		if match := regexp.MustCompile(`^•\s*highlight::\s*(.+)$`).FindStringSubmatch(line); match != nil {
-			result.Highlight = strings.TrimSpace(match[1])
+		if match := sectionHighlightRegex.FindStringSubmatch(line); match != nil {
+			// [source:: url] and other annotations are metadata, not text
+			for _, annotation := range contextAnnotationRegex.FindAllStringSubmatch(match[1], -1) {
+				result.Meta[annotation[1]] = strings.TrimSpace(annotation[2])
+			}
+			result.Highlight = strings.TrimSpace(contextAnnotationRegex.ReplaceAllString(match[1], ""))
 			currentSection = "highlight"
 			continue
 		}
 
-		if match := regexp.MustCompile(`^•\s*note::\s*(.*)$`).FindStringSubmatch(line); match != nil {
-			noteContent := strings.TrimSpace(match[1])
-			if noteContent != "" {
-				result.Note = noteContent
+		if match := sectionNoteRegex.FindStringSubmatch(line); match != nil {
+			if noteContent := strings.TrimSpace(match[1]); noteContent != "" {
+				note = append(note, noteContent)
 			}
 			currentSection = "note"
 			continue
 		}
 
-		if match := regexp.MustCompile(`^•\s*tags::\s*(.+)$`).FindStringSubmatch(line); match != nil {
+		if match := sectionTagsRegex.FindStringSubmatch(line); match != nil {
 			tags := strings.Split(match[1], ",")
 			for i, tag := range tags {
 				tags[i] = strings.TrimSpace(tag)
@@ -123,82 +252,106 @@ func (p *Parser) Parse(content string) *StructuredContent {
 			continue
 		}
 
-		if regexp.MustCompile(`^•\s*meta::\s*$`).MatchString(line) {
+		if sectionMetaRegex.MatchString(line) {
 			currentSection = "meta"
-			continue
-		}
-
-		// Handle sub-items based on current section
-		if strings.HasPrefix(line, "  •") || strings.HasPrefix(line, "    •") {
-			subContent := strings.TrimPrefix(line, "  •")
-			subContent = strings.TrimPrefix(subContent, "    •")
-			subContent = strings.TrimSpace(subContent)
-
-			switch currentSection {
-			case "note":
-				if result.Note == "" {
-					result.Note = subContent
-				} else {
-					result.Note += "\n" + subContent
-				}
-
-			case "meta":
-				// Parse key-value pairs in meta section
-				if match := regexp.MustCompile(`^(\w+)::\s*(.+)$`).FindStringSubmatch(subContent); match != nil {
-					key := strings.TrimSpace(match[1])
-					value := strings.TrimSpace(match[2])
-					result.Meta[key] = value
-				}
-			}
 		}
 	}
+	result.Note = strings.Join(note, "\n")
 
 	return result
 }
 
+// indentDepth counts the two-space indents a line starts with
+func indentDepth(line string) int {
+	trimmed := strings.TrimLeft(line, " ")
+	return (len(line) - len(trimmed)) / 2
+}
+
+// Lint rules, compiled once; Lint runs on every keystroke
+var (
+	lintHighlightRegex  = regexp.MustCompile(`^•\s*highlight::`)
+	lintNoteRegex       = regexp.MustCompile(`^•\s*note::`)
+	lintBulletedRegex   = regexp.MustCompile(`^\s*•.*::`)
+	lintEmptyValueRegex = regexp.MustCompile(`^•\s*(\w+)::\s*$`)
+)
+
 // Lint checks for common issues in structured content
 func (p *Parser) Lint(content string) []LintIssue {
-	var issues []LintIssue
+	return p.lint(content, func(lineNum int, line string) lineLint {
+		return lintLine(line)
+	})
+}
 
-	lines := strings.Split(content, "\n")
+// LintIncremental lints content like Lint, but only re-checks lines whose
+// text changed since the last call, keyed as for ParseIncremental
+func (p *Parser) LintIncremental(content string, keys []string) []LintIssue {
+	previous := p.lintCache
+	p.lintCache = make(map[string]cachedLint, len(keys))
+	p.lintStats = ParseStats{}
+
+	return p.lint(content, func(lineNum int, line string) lineLint {
+		if lineNum >= len(keys) || keys[lineNum] == "" {
+			p.lintStats.Rescanned++
+			return lintLine(line)
+		}
+
+		key, hash := keys[lineNum], hashLine(line)
+		cached, ok := previous[key]
+		if !ok || cached.hash != hash {
+			cached = cachedLint{hash: hash, lint: lintLine(line)}
+			p.lintStats.Rescanned++
+		} else {
+			p.lintStats.Reused++
+		}
+		p.lintCache[key] = cached
+		return cached.lint
+	})
+}
+
+// LastLintStats reports how much of the last LintIncremental call was
+// served from the cache
+func (p *Parser) LastLintStats() ParseStats {
+	return p.lintStats
+}
+
+// lineLint is what linting one line found: its issues, numbered by lint,
+// and whether it opens a highlight:: or note:: section
+type lineLint struct {
+	issues    []LintIssue
+	highlight bool
+	note      bool
+}
+
+// cachedLint is the lint result for one keyed line
+type cachedLint struct {
+	hash uint64
+	lint lineLint
+}
+
+// lint walks content's non-empty lines, trimmed; check lints each one
+func (p *Parser) lint(content string, check func(lineNum int, line string) lineLint) []LintIssue {
+	var issues []LintIssue
 	hasHighlight := false
 	hasNote := false
 
-	for i, line := range lines {
+	for i, line := range strings.Split(content, "\n") {
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
 		}
 
-		// Check for required sections
-		if regexp.MustCompile(`^•\s*highlight::`).MatchString(line) {
-			hasHighlight = true
-		}
-		if regexp.MustCompile(`^•\s*note::`).MatchString(line) {
-			hasNote = true
-		}
-
-		// Check for malformed annotations
-		if strings.Contains(line, "::") && !regexp.MustCompile(`^\s*•.*::`).MatchString(line) {
-			issues = append(issues, LintIssue{
-				Line:     i + 1,
-				Type:     "format",
-				Message:  "Annotation should start with bullet point",
-				Severity: "warning",
-			})
+		result := check(i, line)
+		hasHighlight = hasHighlight || result.highlight
+		hasNote = hasNote || result.note
+		for _, issue := range result.issues {
+			issue.Line = i + 1
+			issues = append(issues, issue)
 		}
+	}
 
-		// Check for empty annotation values
-		if match := regexp.MustCompile(`^•\s*(\w+)::\s*$`).FindStringSubmatch(line); match != nil {
-			if match[1] != "note" && match[1] != "meta" { // These can be empty
-				issues = append(issues, LintIssue{
-					Line:     i + 1,
-					Type:     "content",
-					Message:  "Empty annotation: " + match[1],
-					Severity: "info",
-				})
-			}
-		}
+	// Readwise notes need both sections; plain outlines need neither
+	if !hasHighlight && !hasNote {
+		return issues
 	}
 
 	// Check for missing required sections
@@ -223,6 +376,36 @@ func (p *Parser) Lint(content string) []LintIssue {
 	return issues
 }
 
+// lintLine checks one trimmed, non-empty line
+func lintLine(line string) lineLint {
+	result := lineLint{
+		// Check for required sections
+		highlight: lintHighlightRegex.MatchString(line),
+		note:      lintNoteRegex.MatchString(line),
+	}
+
+	// Check for malformed annotations
+	if strings.Contains(line, "::") && !lintBulletedRegex.MatchString(line) {
+		result.issues = append(result.issues, LintIssue{
+			Type:     "format",
+			Message:  "Annotation should start with bullet point",
+			Severity: "warning",
+		})
+	}
+
+	// Check for empty annotation values
+	if match := lintEmptyValueRegex.FindStringSubmatch(line); match != nil {
+		if match[1] != "note" && match[1] != "meta" { // These can be empty
+			result.issues = append(result.issues, LintIssue{
+				Type:     "content",
+				Message:  "Empty annotation: " + match[1],
+				Severity: "info",
+			})
+		}
+	}
+	return result
+}
+
 // LintIssue represents a problem found during linting
 type LintIssue struct {
 	Line     int    // 0 for general issues
@@ -231,50 +414,44 @@ type LintIssue struct {
 	Severity string // "error", "warning", "info"
 }
 
-// detectConsciousnessPatterns finds :: patterns for evna dispatch
-func (p *Parser) detectConsciousnessPatterns(line string, lineNum int, result *StructuredContent) {
-	// Common consciousness patterns
-	patterns := map[string]*regexp.Regexp{
-		"ctx":       regexp.MustCompile(`ctx::\s*(.+)`),
-		"highlight": regexp.MustCompile(`highlight::\s*(.+)`),
-		"eureka":    regexp.MustCompile(`eureka::\s*(.+)`),
-		"decision":  regexp.MustCompile(`decision::\s*(.+)`),
-		"gotcha":    regexp.MustCompile(`gotcha::\s*(.+)`),
-		"bridge":    regexp.MustCompile(`bridge::\s*(.+)`),
-		"mode":      regexp.MustCompile(`mode::\s*(.+)`),
-		"project":   regexp.MustCompile(`project::\s*(.+)`),
-		"concept":   regexp.MustCompile(`concept::\s*(.+)`),
-		"aka":       regexp.MustCompile(`aka::\s*(.+)`),
-		// FLOAT system patterns
-		"dispatch": regexp.MustCompile(`dispatch::\s*(.+)`),
-		"reducer":  regexp.MustCompile(`reducer::\s*(.+)`),
-		"selector": regexp.MustCompile(`selector::\s*(.+)`),
-		"imprint":  regexp.MustCompile(`imprint::\s*(.+)`),
+// SeverityRank orders lint severities so they can be compared; unknown
+// severities rank below info
+func SeverityRank(severity string) int {
+	switch severity {
+	case "error":
+		return 3
+	case "warning":
+		return 2
+	case "info":
+		return 1
 	}
+	return 0
+}
 
-	for patternType, regex := range patterns {
-		if match := regex.FindStringSubmatch(line); match != nil {
+// detectConsciousnessPatterns finds :: patterns for evna dispatch
+func (p *Parser) detectConsciousnessPatterns(line string, lineNum int) []ConsciousnessPattern {
+	var found []ConsciousnessPattern
+	for _, match := range Patterns.Match(line) {
+		found = append(found, ConsciousnessPattern{
+			Type:    match.Type,
+			Content: match.Content,
+			Line:    lineNum,
 			// Extract context annotations [key:: value]
-			context := p.extractContextAnnotations(line)
-
-			pattern := ConsciousnessPattern{
-				Type:    patternType,
-				Content: strings.TrimSpace(match[1]),
-				Line:    lineNum,
-				Context: context,
-			}
-			result.ConsciousnessData = append(result.ConsciousnessData, pattern)
-		}
+			Context: p.extractContextAnnotations(line),
+		})
 	}
+	return found
 }
 
+// contextAnnotationRegex captures the key and value of [key:: value]
+var contextAnnotationRegex = regexp.MustCompile(`\[(\w+)::\s*([^\]]+)\]`)
+
 // extractContextAnnotations finds [key:: value] patterns in text
 func (p *Parser) extractContextAnnotations(text string) map[string]string {
 	context := make(map[string]string)
 
 	// Match [key:: value] patterns
-	contextRegex := regexp.MustCompile(`\[(\w+)::\s*([^\]]+)\]`)
-	matches := contextRegex.FindAllStringSubmatch(text, -1)
+	matches := contextAnnotationRegex.FindAllStringSubmatch(text, -1)
 
 	for _, match := range matches {
 		if len(match) >= 3 {
@@ -0,0 +1,155 @@
+package outliner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// benchmarkOutline builds an n-line outline mixing plain text and patterns
+func benchmarkOutline(n int) (string, []string) {
+	var content strings.Builder
+	keys := make([]string, n)
+	for i := 0; i < n; i++ {
+		switch i % 4 {
+		case 0:
+			fmt.Fprintf(&content, "• ctx:: working on item %d [project:: float]\n", i)
+		case 1:
+			fmt.Fprintf(&content, "  • eureka:: insight number %d about [[parsing]]\n", i)
+		case 2:
+			fmt.Fprintf(&content, "  • plain note %d with no pattern\n", i)
+		default:
+			fmt.Fprintf(&content, "• decision:: choose option %d\n", i)
+		}
+		keys[i] = fmt.Sprintf("node-%d", i)
+	}
+	return content.String(), keys
+}
+
+// patternSet renders patterns in a stable order for comparison
+func patternSet(patterns []ConsciousnessPattern) []string {
+	var set []string
+	for _, p := range patterns {
+		set = append(set, fmt.Sprintf("%d %s %s %v", p.Line, p.Type, p.Content, p.Context))
+	}
+	sort.Strings(set)
+	return set
+}
+
+func TestParseIncremental(t *testing.T) {
+	content, keys := benchmarkOutline(40)
+	parser := NewParser()
+
+	first := parser.ParseIncremental(content, keys)
+	if got, want := patternSet(first.ConsciousnessData), patternSet(NewParser().Parse(content).ConsciousnessData); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("incremental parse differs from full parse:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if stats := parser.LastParseStats(); stats.Reused != 0 || stats.Rescanned != 41 {
+		t.Errorf("first parse stats = %+v, want everything rescanned", stats)
+	}
+
+	// Edit one node and insert another before it
+	lines := strings.Split(content, "\n")
+	lines[4] = "• gotcha:: edited line"
+	lines = append(lines[:2], append([]string{"• bridge:: inserted"}, lines[2:]...)...)
+	keys = append(keys[:2], append([]string{"node-new"}, keys[2:]...)...)
+	edited := strings.Join(lines, "\n")
+
+	second := parser.ParseIncremental(edited, keys)
+	if got, want := patternSet(second.ConsciousnessData), patternSet(NewParser().Parse(edited).ConsciousnessData); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("incremental parse after edit differs from full parse:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	// The edited and inserted nodes, plus the unkeyed trailing line
+	if stats := parser.LastParseStats(); stats.Rescanned != 3 || stats.Reused != 39 {
+		t.Errorf("second parse stats = %+v, want 3 rescanned and 39 reused", stats)
+	}
+}
+
+func TestLintIncremental(t *testing.T) {
+	content := "• highlight:: x\n• tags::\n• note::\n• plain"
+	keys := []string{"a", "b", "c", "d"}
+	parser := NewParser()
+
+	if got, want := fmt.Sprint(parser.LintIncremental(content, keys)), fmt.Sprint(NewParser().Lint(content)); got != want {
+		t.Fatalf("incremental lint %s, full lint %s", got, want)
+	}
+
+	// Dropping the note:: section and inserting a line above the empty
+	// tags:: renumbers its issue and reports the missing section
+	edited := "• highlight:: x\n• ctx::\n• tags::\n• plain"
+	keys = []string{"a", "e", "b", "d"}
+	got, want := fmt.Sprint(parser.LintIncremental(edited, keys)), fmt.Sprint(NewParser().Lint(edited))
+	if got != want {
+		t.Fatalf("incremental lint after edit %s, full lint %s", got, want)
+	}
+	if stats := parser.LastLintStats(); stats.Rescanned != 1 || stats.Reused != 3 {
+		t.Errorf("lint stats = %+v, want 1 rescanned and 3 reused", stats)
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	content, _ := benchmarkOutline(2000)
+	parser := NewParser()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		parser.Parse(content)
+	}
+}
+
+func BenchmarkParseIncremental(b *testing.B) {
+	content, keys := benchmarkOutline(2000)
+	parser := NewParser()
+	parser.ParseIncremental(content, keys)
+
+	// One edited line per parse, as when typing
+	lines := strings.Split(content, "\n")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lines[100] = fmt.Sprintf("• ctx:: typing %d", i)
+		parser.ParseIncremental(strings.Join(lines, "\n"), keys)
+	}
+}
+
+func TestPatternMatcher(t *testing.T) {
+	matcher := NewPatternMatcher(builtinPatternTypes...)
+	if err := matcher.Register("gratitude"); err != nil {
+		t.Fatal(err)
+	}
+	if err := matcher.Register("Bad Name"); err == nil {
+		t.Error("expected an invalid pattern name to be rejected")
+	}
+
+	tests := []struct {
+		line string
+		want []string // "type: content"
+	}{
+		{"• ctx:: working [project:: float]", []string{"ctx: working [project:: float]"}},
+		{"• eureka:: found it decision:: ship", []string{"eureka: found it decision:: ship", "decision: ship"}},
+		{"• my-project:: not a project", nil},
+		{"• reducer::wins collect all eurekas", []string{"reducer: wins collect all eurekas"}},
+		{"• gratitude:: morning coffee", []string{"gratitude: morning coffee"}},
+		{"• plain text", nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, match := range matcher.Match(tt.line) {
+			got = append(got, match.Type+": "+match.Content)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("Match(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseSkipsArchived(t *testing.T) {
+	content := "• ctx:: active\n• archive::\n  • decision:: old\n\n    • eureka:: older\n• eureka:: live\n  • gotcha:: done [archived:: 2026-10-14 09:30]\n    • aka:: gone\n  • bridge:: kept"
+	var types []string
+	for _, pattern := range NewParser().Parse(content).ConsciousnessData {
+		types = append(types, pattern.Type)
+	}
+	if got := strings.Join(types, ","); got != "ctx,eureka,bridge" {
+		t.Errorf("captured %s, want ctx,eureka,bridge", got)
+	}
+}
@@ -0,0 +1,154 @@
+package outliner
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// builtinPatternTypes are the :: patterns every matcher starts with
+var builtinPatternTypes = []string{
+	"ctx", "highlight", "eureka", "decision", "gotcha", "bridge",
+	"mode", "project", "concept", "aka",
+	// FLOAT system patterns
+	"dispatch", "reducer", "selector", "imprint",
+}
+
+// patternNameRegex is what a registered pattern type may be called
+var patternNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// PatternMatch is one "type:: content" found in a line
+type PatternMatch struct {
+	Type    string
+	Content string // rest of the line after "type::", trimmed
+	Start   int    // byte offset of the type name
+}
+
+// PatternMatcher finds consciousness patterns with a single compiled
+// alternation over every registered type. Patterns inside [key:: value]
+// annotations are metadata, not patterns, and are skipped.
+type PatternMatcher struct {
+	mu    sync.RWMutex
+	types []string
+	regex *regexp.Regexp
+}
+
+// Patterns is the matcher shared by Parser and Outliner
+var Patterns = NewPatternMatcher(builtinPatternTypes...)
+
+// RegisterPatternType adds a pattern type to the shared matcher and lets
+// reducer queries name it ("collect all dreams"). Register custom types at
+// startup, before any outline is parsed.
+func RegisterPatternType(name string) error {
+	if err := Patterns.Register(name); err != nil {
+		return err
+	}
+	if _, ok := queryPatternNouns[name+"s"]; !ok {
+		queryPatternNouns[name+"s"] = name
+	}
+	return nil
+}
+
+// NewPatternMatcher creates a matcher for the given pattern types
+func NewPatternMatcher(types ...string) *PatternMatcher {
+	m := &PatternMatcher{}
+	for _, name := range types {
+		if err := m.Register(name); err != nil {
+			panic(err)
+		}
+	}
+	return m
+}
+
+// Register adds a pattern type; registering a known type is a no-op
+func (m *PatternMatcher) Register(name string) error {
+	if !patternNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid pattern type %q: use lowercase letters, digits, and underscores", name)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, known := range m.types {
+		if known == name {
+			return nil
+		}
+	}
+	m.types = append(m.types, name)
+
+	// Longest first, so no type can shadow another it prefixes
+	alternatives := append([]string(nil), m.types...)
+	sort.Slice(alternatives, func(i, j int) bool { return len(alternatives[i]) > len(alternatives[j]) })
+	m.regex = regexp.MustCompile(`\b(` + strings.Join(alternatives, "|") + `)::`)
+	return nil
+}
+
+// Types returns the registered pattern types in registration order
+func (m *PatternMatcher) Types() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]string(nil), m.types...)
+}
+
+// Known reports whether name is a registered pattern type
+func (m *PatternMatcher) Known(name string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, known := range m.types {
+		if known == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns the patterns in line, left to right, at most one per type
+func (m *PatternMatcher) Match(line string) []PatternMatch {
+	m.mu.RLock()
+	regex := m.regex
+	m.mu.RUnlock()
+	if regex == nil || !strings.Contains(line, "::") {
+		return nil
+	}
+
+	annotations := annotationRegex.FindAllStringIndex(line, -1)
+	inAnnotation := func(pos int) bool {
+		for _, span := range annotations {
+			if pos > span[0] && pos < span[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	var matches []PatternMatch
+	seen := map[string]bool{}
+	for _, loc := range regex.FindAllStringSubmatchIndex(line, -1) {
+		start, end := loc[2], loc[1]
+		patternType := line[loc[2]:loc[3]]
+
+		// "my-project::" is a different key, not a project:: pattern
+		if start > 0 && line[start-1] == '-' {
+			continue
+		}
+		if seen[patternType] || inAnnotation(start) || end == len(line) {
+			continue
+		}
+		seen[patternType] = true
+		matches = append(matches, PatternMatch{
+			Type:    patternType,
+			Content: strings.TrimSpace(line[end:]),
+			Start:   start,
+		})
+	}
+	return matches
+}
+
+// Type returns the first pattern type in line, or ""
+func (m *PatternMatcher) Type(line string) string {
+	if matches := m.Match(line); len(matches) > 0 {
+		return matches[0].Type
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package outliner
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+)
+
+const (
+	// reducerPreviewDelay is how long typing on a reducer:: line pauses
+	// before its preview is recomputed
+	reducerPreviewDelay = 300 * time.Millisecond
+
+	// previewSamples is how many matching actions the preview lists
+	previewSamples = 3
+)
+
+// ReducerPreviewMsg recomputes the preview of the reducer:: line being
+// typed, once typing has paused for reducerPreviewDelay
+type ReducerPreviewMsg struct {
+	seq int
+}
+
+// reducerPreview is the panel under the outline showing what the reducer::
+// line under the cursor would collect
+type reducerPreview struct {
+	definition string // the text after reducer:: it was scheduled for
+	seq        int    // bumped per change, so only the last tick recomputes
+	ready      bool   // computed at least once
+
+	name       string
+	matched    []DispatchAction
+	considered int
+	described  string // how the query was read: types, keywords, window
+	note       string // why there's no count, for queries matched on capture
+}
+
+// cursorReducerDefinition is the text after reducer:: on the cursor's node
+func (o *Outliner) cursorReducerDefinition() (string, bool) {
+	if o.cursor >= len(o.lines) {
+		return "", false
+	}
+	_, definition, found := strings.Cut(o.lines[o.cursor].Text, "reducer::")
+	return strings.TrimSpace(definition), found
+}
+
+// schedulePreview queues a recompute of the reducer preview when the
+// cursor's reducer:: line changed, and drops the preview off one
+func (o *Outliner) schedulePreview() {
+	definition, ok := o.cursorReducerDefinition()
+	if !ok {
+		o.preview = nil
+		return
+	}
+	if o.preview == nil {
+		o.preview = &reducerPreview{}
+	} else if o.preview.definition == definition {
+		return
+	}
+	o.preview.definition = definition
+	o.preview.seq++
+	seq := o.preview.seq
+	o.pending.push(tea.Tick(reducerPreviewDelay, func(time.Time) tea.Msg {
+		return ReducerPreviewMsg{seq: seq}
+	}))
+}
+
+// updatePreview recomputes the preview, unless typing has gone on since
+// msg was scheduled
+func (o *Outliner) updatePreview(msg ReducerPreviewMsg) {
+	p := o.preview
+	if p == nil || msg.seq != p.seq {
+		return
+	}
+	p.ready = true
+	p.matched, p.considered, p.described, p.note = nil, 0, "", ""
+
+	name, query, ok := ParseReducerDefinition(p.definition)
+	query = stripAnnotations(query)
+	p.name = name
+	switch {
+	case !ok || query == "":
+		p.note = i18n.T("outliner.preview.incomplete")
+	case isSimilarityQuery(query), isExecQuery(query):
+		// Both reach outside the outliner, too slow to run per keystroke
+		p.note = i18n.T("outliner.preview.on_capture")
+	default:
+		p.matched, p.considered = o.dispatch.PreviewReducer(query)
+		p.described = describeReducerQuery(query)
+	}
+	o.ClearRenderCache()
+}
+
+func isSimilarityQuery(query string) bool {
+	_, _, ok := ParseSimilarityQuery(query)
+	return ok
+}
+
+func isExecQuery(query string) bool {
+	_, _, ok := ParseExecQuery(query)
+	return ok
+}
+
+// describeReducerQuery says how ReducerMatcher reads query, e.g. "types:
+// decision · keywords: auth · window: last 7 days"
+func describeReducerQuery(query string) string {
+	queryLower := strings.ToLower(timeWindowRegex.ReplaceAllString(query, " "))
+
+	types := []string{}
+	for patternType := range queryPatternTypes(queryLower) {
+		types = append(types, patternType)
+	}
+	sort.Strings(types)
+	typeList := i18n.T("outliner.preview.all_types")
+	if len(types) > 0 {
+		typeList = strings.Join(types, ", ")
+	}
+	parts := []string{i18n.T("outliner.preview.types", typeList)}
+
+	if keywords := queryKeywords(queryLower); len(keywords) > 0 {
+		parts = append(parts, i18n.T("outliner.preview.keywords", strings.Join(keywords, ", ")))
+	}
+	if window := timeWindowRegex.FindString(query); window != "" {
+		parts = append(parts, i18n.T("outliner.preview.window", strings.ToLower(window)))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// previewPanelHeight is the number of rows the preview takes, borders
+// included; nothing until it's been computed
+func (o *Outliner) previewPanelHeight() int {
+	if o.preview == nil || !o.preview.ready {
+		return 0
+	}
+	return len(o.previewLines(o.width)) + 3
+}
+
+// previewLines are the preview's rows under its title
+func (o *Outliner) previewLines(width int) []string {
+	p := o.preview
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	inner := width - 4
+	if p.note != "" {
+		return []string{dim.Render(cells.Cut(p.note, inner))}
+	}
+
+	lines := []string{
+		cells.Cut(i18n.N("outliner.preview.matched", len(p.matched), p.considered), inner),
+		dim.Render(cells.Cut(p.described, inner)),
+	}
+	if len(p.matched) == 0 {
+		return append(lines, dim.Render(cells.Cut(i18n.T("outliner.preview.none"), inner)))
+	}
+	// The latest first, as a reducer's newest actions are the ones to check
+	for i := len(p.matched) - 1; i >= 0 && i >= len(p.matched)-previewSamples; i-- {
+		action := p.matched[i]
+		text := o.theme.patternStyle(action.PatternType).Render(action.PatternType+"::") + " " + strings.Join(strings.Fields(action.Content), " ")
+		lines = append(lines, "  "+cells.Cut(text, inner-2))
+	}
+	return lines
+}
+
+// renderPreviewPanel draws the preview under the outline
+func (o *Outliner) renderPreviewPanel(width int) string {
+	title := i18n.T("outliner.preview.title")
+	if o.preview.name != "" {
+		title = i18n.T("outliner.preview.title_named", o.preview.name)
+	}
+	return lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color(o.theme.Accent)).
+		Width(width - 2).
+		Render(title + "\n" + strings.Join(o.previewLines(width), "\n"))
+}
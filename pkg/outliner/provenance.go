@@ -0,0 +1,59 @@
+package outliner
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Origin is where a reducer's collected child came from: a node captured
+// this session, or a line recorded in the dispatch log
+type Origin struct {
+	Path   string // the file; "" when it isn't known
+	NodeID string // the source node, for actions captured this session
+	Line   int    // the source line, for actions named "source:line"
+}
+
+// actionOrigin is where action was captured, nil when nothing says
+func actionOrigin(action DispatchAction) *Origin {
+	if path, line, ok := replaySlot(action.NodeID); ok {
+		if action.Source != "" {
+			path = action.Source
+		}
+		return &Origin{Path: path, Line: line}
+	}
+	if action.NodeID == "" {
+		return nil
+	}
+	return &Origin{Path: action.Source, NodeID: action.NodeID}
+}
+
+// String is the origin as detail mode shows it, e.g. "notes.md#^3f9a1c2e"
+// or "notes.md:12"
+func (og Origin) String() string {
+	name := filepath.Base(og.Path)
+	if og.Path == "" {
+		name = "this file"
+	}
+	if og.NodeID != "" {
+		return name + "#^" + og.NodeID
+	}
+	return fmt.Sprintf("%s:%d", name, og.Line)
+}
+
+// CursorOrigin is where the collected child under the cursor came from
+func (o *Outliner) CursorOrigin() (Origin, bool) {
+	if o.cursor >= len(o.lines) || o.lines[o.cursor].Origin == nil {
+		return Origin{}, false
+	}
+	return *o.lines[o.cursor].Origin, true
+}
+
+// JumpToLine moves the cursor to the node written on line (1-based, one
+// line per node), unfolding its ancestors; it reports whether there's one
+func (o *Outliner) JumpToLine(line int) bool {
+	if line < 1 || line > len(o.lines) {
+		return false
+	}
+	o.SetCursor(line - 1)
+	return true
+}
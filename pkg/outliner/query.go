@@ -0,0 +1,243 @@
+package outliner
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// annotationRegex matches [key:: value] annotations
+var annotationRegex = regexp.MustCompile(`\[[\w-]+::[^\]]*\]`)
+
+// ParseReducerDefinition splits "name collect all ..." into name and query
+func ParseReducerDefinition(content string) (name, query string, ok bool) {
+	parts := strings.SplitN(content, " ", 2)
+	if len(parts) < 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// stripAnnotations drops annotations like [output:: auth.md] from a
+// reducer query: they configure the reducer, they aren't part of the query
+func stripAnnotations(query string) string {
+	return strings.Join(strings.Fields(annotationRegex.ReplaceAllString(query, "")), " ")
+}
+
+// ReducerMatcher builds a matcher from a natural-language reducer query.
+// Time windows ("from the last 7 days", "this week's") are checked against
+// the action's timestamp when the matcher runs, so results slide with time.
+func ReducerMatcher(query string) func(DispatchAction) bool {
+	window, windowed := ParseTimeWindow(query)
+	queryLower := strings.ToLower(timeWindowRegex.ReplaceAllString(query, " "))
+
+	keywords := queryKeywords(queryLower)
+	types := queryPatternTypes(queryLower)
+
+	return func(action DispatchAction) bool {
+		content := strings.ToLower(action.Content)
+
+		// "collect all decisions about auth" only considers decisions
+		if len(types) > 0 && !types[action.PatternType] {
+			return false
+		}
+
+		if windowed && !window.Contains(action.Timestamp, time.Now()) {
+			return false
+		}
+
+		// Without keywords, the type and time filters are the whole query:
+		// "collect all ctx from the last 7 days"
+		if len(keywords) == 0 && (len(types) > 0 || windowed) {
+			return true
+		}
+
+		// Check if content contains any of the keywords
+		for _, keyword := range keywords {
+			if strings.Contains(content, keyword) {
+				return true
+			}
+		}
+
+		// Legacy hardcoded patterns for backward compatibility
+		if strings.Contains(queryLower, "bridges") && action.PatternType == "bridge" {
+			return true
+		}
+		if strings.Contains(queryLower, "rangle") && strings.Contains(content, "rangle") {
+			return true
+		}
+
+		return false
+	}
+}
+
+// queryKeywords extracts the keywords after "about" or "that mention":
+// "collect all actions that mention test" looks for "test" in content,
+// "collect all bridges about rangle" for bridges with "rangle"
+func queryKeywords(queryLower string) []string {
+	for _, sep := range []string{"about ", "that mention "} {
+		if parts := strings.Split(queryLower, sep); len(parts) > 1 {
+			return strings.Fields(parts[1])
+		}
+	}
+	return nil
+}
+
+// queryPatternNouns maps the plural nouns a query may use to pattern types
+var queryPatternNouns = map[string]string{
+	"ctx":        "ctx",
+	"contexts":   "ctx",
+	"decisions":  "decision",
+	"gotchas":    "gotcha",
+	"bridges":    "bridge",
+	"eurekas":    "eureka",
+	"highlights": "highlight",
+	"dispatches": "dispatch",
+	"concepts":   "concept",
+	"projects":   "project",
+}
+
+// queryPatternTypes finds the pattern types named before "about" or
+// "that mention"; "actions" or no nouns at all means every type
+func queryPatternTypes(queryLower string) map[string]bool {
+	head := queryLower
+	for _, sep := range []string{"about ", "that mention "} {
+		if i := strings.Index(head, sep); i >= 0 {
+			head = head[:i]
+		}
+	}
+
+	types := map[string]bool{}
+	for _, word := range strings.FieldsFunc(head, func(r rune) bool { return r == ' ' || r == ',' }) {
+		word = strings.TrimSuffix(word, "'s")
+		if patternType, ok := queryPatternNouns[word]; ok {
+			types[patternType] = true
+		}
+	}
+	return types
+}
+
+// TimeWindow is a span of time relative to now, from a reducer query
+type TimeWindow struct {
+	Last   time.Duration // "last 7 days": now-Last to now
+	Period string        // "today", "yesterday", "week", or "month": calendar periods
+}
+
+// timeWindowRegex matches the time phrases ParseTimeWindow understands
+var timeWindowRegex = regexp.MustCompile(`(?i)\b(?:(?:from|in|during|over)\s+)?(?:the\s+)?(?:(?:last|past)\s+(\d+\s+)?(minute|hour|day|week|month)s?|(today|yesterday)|this\s+(week|month))(?:'s)?\b`)
+
+var windowUnits = map[string]time.Duration{
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+	"month":  30 * 24 * time.Hour,
+}
+
+// ParseTimeWindow finds a time window in a reducer query
+func ParseTimeWindow(query string) (TimeWindow, bool) {
+	m := timeWindowRegex.FindStringSubmatch(query)
+	if m == nil {
+		return TimeWindow{}, false
+	}
+
+	switch {
+	case m[2] != "":
+		n := 1
+		if m[1] != "" {
+			n, _ = strconv.Atoi(strings.TrimSpace(m[1]))
+		}
+		// Counts too big for a Duration mean "everything"
+		unit := windowUnits[strings.ToLower(m[2])]
+		if n > int(math.MaxInt64/unit) {
+			return TimeWindow{Last: math.MaxInt64}, true
+		}
+		return TimeWindow{Last: time.Duration(n) * unit}, true
+	case m[3] != "":
+		return TimeWindow{Period: strings.ToLower(m[3])}, true
+	default:
+		return TimeWindow{Period: strings.ToLower(m[4])}, true
+	}
+}
+
+// Bounds returns the window's start and end as of now
+func (w TimeWindow) Bounds(now time.Time) (start, end time.Time) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch w.Period {
+	case "today":
+		return midnight, now
+	case "yesterday":
+		return midnight.AddDate(0, 0, -1), midnight
+	case "week":
+		// Weeks start on Monday
+		offset := (int(now.Weekday()) + 6) % 7
+		return midnight.AddDate(0, 0, -offset), now
+	case "month":
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), now
+	}
+	return now.Add(-w.Last), now
+}
+
+// Contains reports whether t falls inside the window as of now
+func (w TimeWindow) Contains(t, now time.Time) bool {
+	if t.IsZero() {
+		return false
+	}
+	start, end := w.Bounds(now)
+	return !t.Before(start) && !t.After(end)
+}
+
+// ParseSelectorDefinition parses "(name_a, name_b) => output format"
+func ParseSelectorDefinition(content string) (inputs []string, outputFormat string, ok bool) {
+	if !strings.Contains(content, "=>") {
+		return nil, "", false
+	}
+	parts := strings.Split(content, "=>")
+	if len(parts) != 2 {
+		return nil, "", false
+	}
+
+	inputPart := strings.Trim(strings.TrimSpace(parts[0]), "()")
+	inputs = strings.Split(inputPart, ",")
+	for i, input := range inputs {
+		inputs[i] = strings.TrimSpace(input)
+	}
+	// Annotations like [output:: toc.md] configure the selector, they
+	// aren't part of its heading
+	outputFormat = strings.Join(strings.Fields(annotationRegex.ReplaceAllString(parts[1], "")), " ")
+	return inputs, outputFormat, true
+}
+
+// SelectorName is the name a selector definition gives itself with a
+// [name:: daily] annotation, so other selectors can take it as an input;
+// "" when it has none
+func SelectorName(content string) string {
+	_, annotations := ParseAnnotations(content)
+	for _, a := range annotations {
+		if a.Key == "name" {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// SelectorTransform renders reducer inputs under an output format heading
+func SelectorTransform(outputFormat string) func(map[string][]DispatchAction) string {
+	return func(reducerInputs map[string][]DispatchAction) string {
+		var result strings.Builder
+		result.WriteString(fmt.Sprintf("# %s\n\n", outputFormat))
+
+		for reducerName, actions := range reducerInputs {
+			result.WriteString(fmt.Sprintf("## From %s (%d items)\n", reducerName, len(actions)))
+			for _, action := range actions {
+				result.WriteString(fmt.Sprintf("- %s: %s\n", action.PatternType, action.Content))
+			}
+			result.WriteString("\n")
+		}
+
+		return result.String()
+	}
+}
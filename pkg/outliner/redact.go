@@ -0,0 +1,208 @@
+package outliner
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/evanschultz/float-rw-client/pkg/config"
+)
+
+// defaultRedaction replaces what a rule without a replacement matches
+const defaultRedaction = "[redacted]"
+
+// RedactRule masks what Regex matches with Replace, which may refer to
+// groups as $1 or ${name}; an empty Replace uses [redacted]
+type RedactRule struct {
+	Regex   string
+	Replace string
+}
+
+// BuiltinRedactions are the rules [redact] turns on by name
+var BuiltinRedactions = map[string]RedactRule{
+	"emails": {
+		Regex:   `[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`,
+		Replace: "[email]",
+	},
+	"api_keys": {
+		Regex:   `\b(?:sk-[A-Za-z0-9_-]{16,}|gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,}|xox[abprs]-[A-Za-z0-9-]{10,}|AKIA[0-9A-Z]{16}|AIza[0-9A-Za-z_-]{35})\b|(?i:bearer)\s+[A-Za-z0-9._~+/-]{16,}=*`,
+		Replace: "[api key]",
+	},
+}
+
+// Redaction is how many times one rule changed a text; dropped lines are
+// counted under their pattern type, e.g. "private::"
+type Redaction struct {
+	Rule  string
+	Count int
+}
+
+// Redactions lists a text's redactions in the order they were applied
+type Redactions []Redaction
+
+// String summarizes redactions, e.g. "private:: ×1, emails ×2"
+func (rs Redactions) String() string {
+	parts := make([]string, len(rs))
+	for i, r := range rs {
+		parts[i] = fmt.Sprintf("%s ×%d", r.Rule, r.Count)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Merge adds more to rs, counting each rule once
+func (rs Redactions) Merge(more Redactions) Redactions {
+	for _, r := range more {
+		rs = rs.add(r)
+	}
+	return rs
+}
+
+// add adds r to rs, counting it with the same rule's
+func (rs Redactions) add(r Redaction) Redactions {
+	for i := range rs {
+		if rs[i].Rule == r.Rule {
+			rs[i].Count += r.Count
+			return rs
+		}
+	}
+	return append(rs, r)
+}
+
+// redactRule is a compiled RedactRule
+type redactRule struct {
+	name    string
+	regex   *regexp.Regexp
+	replace string
+}
+
+// Redactor strips and masks text before it leaves the process for evna,
+// an embeddings endpoint or Readwise. A nil Redactor changes nothing.
+type Redactor struct {
+	dropped map[string]bool
+	drop    *regexp.Regexp // lines tagged with a dropped pattern type
+	rules   []redactRule
+}
+
+// NewRedactor drops lines tagged with any of the pattern types in drop,
+// then applies the rules in name order
+func NewRedactor(drop []string, rules map[string]RedactRule) (*Redactor, error) {
+	r := &Redactor{dropped: make(map[string]bool, len(drop))}
+
+	if len(drop) > 0 {
+		types := make([]string, len(drop))
+		for i, patternType := range drop {
+			patternType = strings.TrimSuffix(strings.TrimSpace(patternType), "::")
+			r.dropped[patternType] = true
+			types[i] = regexp.QuoteMeta(patternType)
+		}
+		// A type:: at the start of a word or inside an [annotation::]
+		r.drop = regexp.MustCompile(`(?:^|[^\w-])(` + strings.Join(types, "|") + `)::`)
+	}
+
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rule := rules[name]
+		if rule.Regex == "" {
+			return nil, fmt.Errorf("redact rule %s: no regex", name)
+		}
+		re, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("redact rule %s: %w", name, err)
+		}
+		replace := rule.Replace
+		if replace == "" {
+			replace = defaultRedaction
+		}
+		r.rules = append(r.rules, redactRule{name: name, regex: re, replace: replace})
+	}
+	return r, nil
+}
+
+// NewRedactorFromConfig builds the redactor a [redact] section describes:
+// its dropped pattern types, the built-in rules it turns on and its own
+func NewRedactorFromConfig(cfg config.RedactConfig) (*Redactor, error) {
+	rules := map[string]RedactRule{}
+	if cfg.Emails {
+		rules["emails"] = BuiltinRedactions["emails"]
+	}
+	if cfg.APIKeys {
+		rules["api_keys"] = BuiltinRedactions["api_keys"]
+	}
+	for name, rule := range cfg.Rules {
+		rules[name] = RedactRule{Regex: rule.Regex, Replace: rule.Replace}
+	}
+	return NewRedactor(cfg.Patterns, rules)
+}
+
+// Redact returns text with tagged lines dropped and rules applied, and
+// what was changed. It never reports what it removed, only where.
+func (r *Redactor) Redact(text string) (string, Redactions) {
+	if r == nil {
+		return text, nil
+	}
+	var redactions Redactions
+	count := func(rule string, n int) {
+		redactions = redactions.add(Redaction{Rule: rule, Count: n})
+	}
+
+	if r.drop != nil {
+		lines := strings.Split(text, "\n")
+		kept := lines[:0]
+		for _, line := range lines {
+			if match := r.drop.FindStringSubmatch(line); match != nil {
+				count(match[1]+"::", 1)
+				continue
+			}
+			kept = append(kept, line)
+		}
+		text = strings.Join(kept, "\n")
+	}
+
+	for _, rule := range r.rules {
+		if n := len(rule.regex.FindAllStringIndex(text, -1)); n > 0 {
+			text = rule.regex.ReplaceAllString(text, rule.replace)
+			count(rule.name, n)
+		}
+	}
+	return text, redactions
+}
+
+// Drops reports whether patternType's lines are dropped
+func (r *Redactor) Drops(patternType string) bool {
+	return r != nil && r.dropped[patternType]
+}
+
+// RedactResults redacts a reducer's actions or a selector's output, leaving
+// out actions of dropped pattern types and any left empty
+func (r *Redactor) RedactResults(results Results) (Results, Redactions) {
+	if r == nil {
+		return results, nil
+	}
+	var redactions Redactions
+	if results.Kind == "selector" {
+		results.Output, redactions = r.Redact(results.Output)
+		return results, redactions
+	}
+
+	var kept []DispatchAction
+	for _, action := range results.Actions {
+		if r.Drops(action.PatternType) {
+			redactions = redactions.add(Redaction{Rule: action.PatternType + "::", Count: 1})
+			continue
+		}
+		content, redacted := r.Redact(action.Content)
+		redactions = redactions.Merge(redacted)
+		if len(redacted) > 0 && strings.TrimSpace(content) == "" {
+			continue
+		}
+		action.Content = content
+		kept = append(kept, action)
+	}
+	results.Actions = kept
+	return results, redactions
+}
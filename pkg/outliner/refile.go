@@ -0,0 +1,121 @@
+package outliner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// InboxItem is a top-level node of an inbox outline waiting to be
+// processed
+type InboxItem struct {
+	Node        int
+	Text        string
+	PatternType string
+	Nodes       int // in its subtree, itself included
+}
+
+// InboxItems lists the outline's top-level nodes, leaving out headings,
+// code, blank lines and what's archived
+func (o *Outliner) InboxItems() []InboxItem {
+	var items []InboxItem
+	for i := 0; i < len(o.lines); i++ {
+		node := o.lines[i]
+		if node.Level != 0 || !node.Kind.Captured() || o.archived[node.ID] || strings.TrimSpace(node.Text) == "" {
+			continue
+		}
+		item := InboxItem{Node: i, Text: node.Text, PatternType: o.detectPatternType(node.Text), Nodes: 1}
+		for i+1 < len(o.lines) && o.lines[i+1].Level > 0 {
+			item.Nodes++
+			i++
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// TakeSubtree removes node i and its children, undoably, and returns them
+// with the root at level 0
+func (o *Outliner) TakeSubtree(i int) ([]OutlineNode, error) {
+	if i < 0 || i >= len(o.lines) || o.lines[i].Kind == KindBlank {
+		return nil, fmt.Errorf("no node to take")
+	}
+	end := i + 1
+	for end < len(o.lines) && o.lines[end].Level > o.lines[i].Level {
+		end++
+	}
+	o.saveUndo()
+	subtree := slices.Clone(o.lines[i:end])
+	for j := range subtree {
+		subtree[j].Level -= o.lines[i].Level
+	}
+
+	o.deleteNodes(i, end)
+	if len(o.lines) == 0 {
+		o.lines = []OutlineNode{newNode("", 0)}
+	}
+	o.cursor = min(i, len(o.lines)-1)
+	o.cursorPos = 0
+	o.structureChanged()
+	return subtree, nil
+}
+
+// RefileSubtree adds a subtree TakeSubtree took, undoably, as the last
+// child of node parent, or at the end of the outline when parent is -1
+func (o *Outliner) RefileSubtree(subtree []OutlineNode, parent int) error {
+	if parent >= len(o.lines) || len(subtree) == 0 {
+		return fmt.Errorf("no node to refile under")
+	}
+	level, at := 0, len(o.lines)
+	if parent >= 0 {
+		level, at = o.lines[parent].Level+1, parent+1
+		for at < len(o.lines) && o.lines[at].Level >= level {
+			at++
+		}
+	}
+	o.saveUndo()
+	// An empty outline's one blank node gives way
+	if len(o.lines) == 1 && parent < 0 && strings.TrimSpace(o.lines[0].Text) == "" {
+		at = 0
+		o.lines = o.lines[:0]
+	}
+
+	nodes := slices.Clone(subtree)
+	for j := range nodes {
+		nodes[j].Level += level
+	}
+	o.insertNodes(at, nodes...)
+	for j := at; j < at+len(nodes); j++ {
+		o.refreshNodeLinks(j)
+	}
+	o.updateBacklinks()
+	o.cursor, o.cursorPos = at, 0
+	o.structureChanged()
+	return nil
+}
+
+// SetPatternType makes node i a patternType:: pattern, replacing the type
+// its text starts with (after any task box), undoably
+func (o *Outliner) SetPatternType(i int, patternType string) error {
+	if i < 0 || i >= len(o.lines) || !o.lines[i].Kind.Captured() {
+		return fmt.Errorf("no node to convert")
+	}
+	if !Patterns.Known(patternType) {
+		return fmt.Errorf("unknown pattern type %q", patternType)
+	}
+	o.saveUndo()
+	node := &o.lines[i]
+	end, _, _ := parseTask(node.Text)
+	box, text := node.Text[:end], node.Text[end:]
+	if matches := Patterns.Match(text); len(matches) > 0 && matches[0].Start == 0 {
+		text = strings.TrimSpace(text[len(matches[0].Type)+len("::"):])
+	}
+	node.Text = box + patternType + ":: " + text
+	node.PatternType = patternType
+	node.Captured = false
+	node.ModifiedAt = time.Now()
+	o.updateNodeLinks(i)
+	o.structureChanged()
+	return nil
+}
@@ -0,0 +1,117 @@
+package outliner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RenameLinks rewrites text's [[from]] links as [[to]], matching the target
+// case-insensitively and keeping any #heading or |label; it returns the new
+// text and how many links changed. Node refs are left alone.
+func RenameLinks(text, from, to string) (string, int) {
+	count := 0
+	renamed := wikiLinkRegex.ReplaceAllStringFunc(text, func(link string) string {
+		inner := link[2 : len(link)-2]
+		if _, _, isRef := ParseNodeRef(inner); isRef {
+			return link
+		}
+		cut := len(inner)
+		if i := strings.IndexAny(inner, "|#"); i >= 0 {
+			cut = i
+		}
+		if !strings.EqualFold(strings.TrimSpace(inner[:cut]), from) {
+			return link
+		}
+		count++
+		return "[[" + to + inner[cut:] + "]]"
+	})
+	return renamed, count
+}
+
+// ConceptChange is a node a concept rename rewrites
+type ConceptChange struct {
+	Index  int
+	Before string
+	After  string
+}
+
+// ConceptRenames lists the nodes renaming [[from]] to [[to]] would rewrite
+func (o *Outliner) ConceptRenames(from, to string) []ConceptChange {
+	var changes []ConceptChange
+	for i, line := range o.lines {
+		if after, n := RenameLinks(line.Text, from, to); n > 0 {
+			changes = append(changes, ConceptChange{Index: i, Before: line.Text, After: after})
+		}
+	}
+	return changes
+}
+
+// checkConceptNames refuses renames that can't be written as a link
+func checkConceptNames(from, to string) error {
+	if from == "" || to == "" {
+		return errors.New("rename needs an old and a new concept name")
+	}
+	if strings.EqualFold(from, to) {
+		return fmt.Errorf("[[%s]] already has that name", from)
+	}
+	if strings.ContainsAny(to, "[]|#") {
+		return fmt.Errorf("%q can't be a link target", to)
+	}
+	return nil
+}
+
+// RenameConcept rewrites every [[from]] link in the outline as [[to]] and
+// records the old name with an "aka:: from → [[to]]" node at the end, so it
+// stays findable; it returns how many nodes changed. It's one undo step.
+func (o *Outliner) RenameConcept(from, to string) (int, error) {
+	return o.renameConcept(from, to, true)
+}
+
+// RetargetLinks rewrites every [[from]] link as [[to]] without recording an
+// alias, for outlines renamed along with another that keeps it
+func (o *Outliner) RetargetLinks(from, to string) (int, error) {
+	return o.renameConcept(from, to, false)
+}
+
+func (o *Outliner) renameConcept(from, to string, alias bool) (int, error) {
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if err := checkConceptNames(from, to); err != nil {
+		return 0, err
+	}
+	changes := o.ConceptRenames(from, to)
+	if len(changes) == 0 {
+		return 0, nil
+	}
+	o.saveUndo()
+
+	for _, change := range changes {
+		o.setNodeText(change.Index, change.After)
+	}
+	if alias {
+		o.recordAlias(from, to)
+	}
+	if o.cursor < len(o.lines) {
+		o.cursorPos = min(o.cursorPos, len(o.lines[o.cursor].Text))
+	}
+	o.refreshDiagnostics()
+	o.ClearRenderCache()
+	return len(changes), nil
+}
+
+// recordAlias appends a root-level aka:: node for from, unless the outline
+// already has it
+func (o *Outliner) recordAlias(from, to string) {
+	text := "aka:: " + from + " → [[" + to + "]]"
+	for _, line := range o.lines {
+		if strings.EqualFold(strings.TrimSpace(line.Text), text) {
+			return
+		}
+	}
+	node := newNode(text, 0)
+	node.PatternType = o.detectPatternType(text)
+	o.insertNodes(len(o.lines), node)
+	markChildren(o.lines)
+	o.markArchived()
+	o.updateNodeLinks(len(o.lines) - 1)
+}
@@ -0,0 +1,293 @@
+package outliner
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+)
+
+// replayTimeLayouts are the times the replay prompt accepts; clock times
+// are on the day of the frame being shown
+var replayTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02", "15:04:05", "15:04"}
+
+// replayRow is a node as it stood at the replayed time
+type replayRow struct {
+	level    int
+	text     string
+	captured bool
+	current  bool // the capture being replayed
+}
+
+// ReplayDoor steps through a session's captures, rebuilding the outline as
+// it was at each one: before the session from the file's dispatch log
+// entries, during it from each node's edit history
+type ReplayDoor struct {
+	active   bool
+	nodes    []OutlineNode
+	versions map[string][]NodeEdit
+	history  []DispatchAction // this file's entries from the dispatch log
+	session  []DispatchAction
+	captures []DispatchAction // history and session, oldest first
+	started  time.Time        // when this session's nodes were loaded
+
+	at     time.Time
+	step   int     // index into captures of the last one at or before at, -1 for none
+	prompt *string // time being typed after t, nil when closed
+
+	style lipgloss.Style
+	title lipgloss.Style
+	dim   lipgloss.Style
+	mark  lipgloss.Style
+}
+
+// NewReplay snapshots the outline, its node histories and the captures the
+// dispatch log holds for source, starting at the latest capture
+func (o *Outliner) NewReplay(source string) *ReplayDoor {
+	rd := &ReplayDoor{
+		nodes:    make([]OutlineNode, len(o.lines)),
+		versions: make(map[string][]NodeEdit, len(o.lines)),
+		started:  time.Now(),
+		style:    lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1),
+		title:    lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("62")),
+		dim:      lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
+		mark:     lipgloss.NewStyle().Reverse(true),
+	}
+	copy(rd.nodes, o.lines)
+	for _, node := range o.lines {
+		rd.versions[node.ID] = o.Versions(node.ID)
+		if node.CreatedAt.Before(rd.started) {
+			rd.started = node.CreatedAt
+		}
+	}
+
+	for _, action := range o.dispatch.GetHistory() {
+		if path, _, ok := replaySlot(action.NodeID); ok && sameSource(path, source) {
+			rd.history = append(rd.history, action)
+		}
+	}
+	rd.session = append(rd.session, o.dispatch.GetActions()...)
+	rd.captures = append(append(rd.captures, rd.history...), rd.session...)
+	sort.SliceStable(rd.captures, func(i, j int) bool {
+		return rd.captures[i].Timestamp.Before(rd.captures[j].Timestamp)
+	})
+
+	rd.step = len(rd.captures) - 1
+	rd.at = time.Now()
+	if rd.step >= 0 {
+		rd.at = rd.captures[rd.step].Timestamp
+	}
+	return rd
+}
+
+// replaySlot splits a dispatch log node ID, "source:line", into its parts
+func replaySlot(nodeID string) (path string, line int, ok bool) {
+	i := strings.LastIndex(nodeID, ":")
+	if i < 0 {
+		return "", 0, false
+	}
+	line, err := strconv.Atoi(nodeID[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return nodeID[:i], line, true
+}
+
+// sameSource reports whether two paths name the same file
+func sameSource(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// ParseReplayTime reads a time typed at the replay prompt; clock times are
+// taken on ref's day
+func ParseReplayTime(s string, ref time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range replayTimeLayouts {
+		t, err := time.ParseInLocation(layout, s, ref.Location())
+		if err != nil {
+			continue
+		}
+		if !strings.HasPrefix(layout, "2006") && layout != time.RFC3339 {
+			t = time.Date(ref.Year(), ref.Month(), ref.Day(), t.Hour(), t.Minute(), t.Second(), 0, ref.Location())
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("can't read time %q (try 15:04 or 2006-01-02 15:04)", s)
+}
+
+// Seek replays the outline as it was at t
+func (rd *ReplayDoor) Seek(t time.Time) {
+	rd.at = t
+	rd.step = sort.Search(len(rd.captures), func(i int) bool {
+		return rd.captures[i].Timestamp.After(t)
+	}) - 1
+}
+
+// Step moves delta captures forward or back
+func (rd *ReplayDoor) Step(delta int) {
+	if len(rd.captures) == 0 {
+		return
+	}
+	rd.step = min(len(rd.captures)-1, max(0, rd.step+delta))
+	rd.at = rd.captures[rd.step].Timestamp
+}
+
+// Rows rebuilds the outline as it stood at the replayed time
+func (rd *ReplayDoor) Rows() []replayRow {
+	var current *DispatchAction
+	if rd.step >= 0 {
+		current = &rd.captures[rd.step]
+	}
+
+	if rd.at.Before(rd.started) {
+		// Before this session only the dispatch log knows the outline
+		byLine := make(map[int]replayRow)
+		for _, action := range rd.history {
+			if action.Timestamp.After(rd.at) {
+				continue
+			}
+			_, line, _ := replaySlot(action.NodeID)
+			text, _, _ := strings.Cut(action.Content, "\n")
+			byLine[line] = replayRow{text: text, captured: true, current: current != nil && action.ID == current.ID}
+		}
+		lines := make([]int, 0, len(byLine))
+		for line := range byLine {
+			lines = append(lines, line)
+		}
+		sort.Ints(lines)
+		rows := make([]replayRow, len(lines))
+		for i, line := range lines {
+			rows[i] = byLine[line]
+		}
+		return rows
+	}
+
+	var rows []replayRow
+	for _, node := range rd.nodes {
+		edit, ok := versionAt(rd.versions[node.ID], rd.at)
+		if !ok || !node.Kind.Captured() {
+			continue
+		}
+		row := replayRow{level: node.Level, text: edit.Text}
+		for _, action := range rd.session {
+			if action.NodeID == node.ID && !action.Timestamp.After(rd.at) && !action.Timestamp.Before(edit.At) {
+				row.captured = true
+				row.current = row.current || current != nil && action.ID == current.ID
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// versionAt is the version of a node that was current at t
+func versionAt(versions []NodeEdit, t time.Time) (NodeEdit, bool) {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if !versions[i].At.After(t) {
+			return versions[i], true
+		}
+	}
+	return NodeEdit{}, false
+}
+
+func (rd *ReplayDoor) Name() string                          { return "replay" }
+func (rd *ReplayDoor) Init(params map[string]string) tea.Cmd { return nil }
+
+func (rd *ReplayDoor) Update(msg tea.Msg) (Door, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok || !rd.active {
+		return rd, nil
+	}
+	if rd.prompt != nil {
+		switch key.Type {
+		case tea.KeyEnter:
+			if t, err := ParseReplayTime(*rd.prompt, rd.at); err == nil {
+				rd.Seek(t)
+			}
+			rd.prompt = nil
+		case tea.KeyBackspace:
+			if p := *rd.prompt; p != "" {
+				*rd.prompt = p[:len(p)-1]
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			*rd.prompt += string(key.Runes)
+			if key.Type == tea.KeySpace {
+				*rd.prompt += " "
+			}
+		}
+		return rd, nil
+	}
+
+	switch key.String() {
+	case "left", "h", "p":
+		rd.Step(-1)
+	case "right", "l", "n":
+		rd.Step(1)
+	case "home", "g":
+		rd.Step(-len(rd.captures))
+	case "end", "G":
+		rd.Step(len(rd.captures))
+	case "t":
+		rd.prompt = new(string)
+	}
+	return rd, nil
+}
+
+func (rd *ReplayDoor) View(width, height int) string {
+	var b strings.Builder
+	b.WriteString(rd.title.Render(fmt.Sprintf("⏪ Replay — %s · capture %d/%d", rd.at.Format("Mon Jan 2 15:04:05"), rd.step+1, len(rd.captures))))
+	if rd.prompt != nil {
+		b.WriteString("\n" + "Replay at: " + *rd.prompt + "│")
+	} else {
+		b.WriteString("\n" + rd.dim.Render("←/→ step · g/G first/last · t go to time · Esc: close"))
+	}
+	b.WriteString("\n\n")
+
+	rows := rd.Rows()
+	if len(rows) == 0 {
+		b.WriteString(rd.dim.Render("  (nothing written yet)") + "\n")
+	}
+	for _, row := range rows {
+		glyph := "●"
+		if row.captured {
+			glyph = "✓"
+		}
+		line := strings.Repeat("  ", row.level) + glyph + " " + row.text
+		if row.current {
+			line = rd.mark.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	if rd.step >= 0 {
+		action := rd.captures[rd.step]
+		b.WriteString("\n" + rd.dim.Render(fmt.Sprintf("%s %s:: → %s", action.Sigil, action.PatternType, action.Imprint)) + "\n")
+	}
+	return cells.Frame(rd.style, width).Height(height - 2).MaxHeight(height).Render(strings.TrimRight(b.String(), "\n"))
+}
+
+func (rd *ReplayDoor) IsActive() bool { return rd.active }
+func (rd *ReplayDoor) Activate()      { rd.active = true }
+func (rd *ReplayDoor) Deactivate()    { rd.active = false }
+func (rd *ReplayDoor) GetState() map[string]interface{} {
+	return map[string]interface{}{"at": rd.at}
+}
+func (rd *ReplayDoor) OnConsciousnessCapture(patterns []ConsciousnessPattern) {}
+
+func (rd *ReplayDoor) SetState(state map[string]interface{}) {
+	if at, ok := state["at"].(time.Time); ok {
+		rd.Seek(at)
+	}
+}
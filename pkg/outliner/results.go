@@ -0,0 +1,52 @@
+package outliner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Results is what a reducer:: or selector:: node has computed
+type Results struct {
+	Kind    string // "reducer" or "selector"
+	Name    string
+	Actions []DispatchAction // a reducer's collected actions
+	Output  string           // a selector's rendered output
+}
+
+// CursorResults returns the results of the reducer:: or selector:: node
+// under the cursor, as of its last capture
+func (o *Outliner) CursorResults() (Results, error) {
+	if o.cursor >= len(o.lines) {
+		return Results{}, fmt.Errorf("no node selected")
+	}
+	node := o.lines[o.cursor]
+
+	if _, definition, found := strings.Cut(node.Text, "reducer::"); found {
+		name, _, ok := ParseReducerDefinition(strings.TrimSpace(definition))
+		if !ok {
+			return Results{}, fmt.Errorf("not a reducer:: definition")
+		}
+		reducer, ok := o.dispatch.GetReducers()[name]
+		if !ok {
+			return Results{}, fmt.Errorf("reducer %s isn't defined yet (capture the node first)", name)
+		}
+		return Results{Kind: "reducer", Name: name, Actions: append([]DispatchAction(nil), reducer.Actions...)}, nil
+	}
+
+	if _, definition, found := strings.Cut(node.Text, "selector::"); found {
+		if _, _, ok := ParseSelectorDefinition(strings.TrimSpace(definition)); !ok {
+			return Results{}, fmt.Errorf("not a selector:: definition")
+		}
+		name := SelectorName(definition)
+		if name == "" {
+			name = selectorNameFor(node.ID)
+		}
+		selector, ok := o.dispatch.GetSelectors()[name]
+		if !ok {
+			return Results{}, fmt.Errorf("selector %s isn't defined yet (capture the node first)", name)
+		}
+		return Results{Kind: "selector", Name: name, Actions: append([]DispatchAction(nil), selector.Actions...), Output: selector.Output}, nil
+	}
+
+	return Results{}, fmt.Errorf("not a reducer:: or selector:: node")
+}
@@ -0,0 +1,205 @@
+package outliner
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// reviewEntry is one pattern waiting for approval before it goes to evna
+type reviewEntry struct {
+	pattern    ConsciousnessPattern
+	include    bool
+	collection string
+	imprint    string
+}
+
+// captureReview is the list of newly detected patterns shown before
+// anything is sent to evna
+type captureReview struct {
+	entries     []reviewEntry
+	collections []string
+	imprints    []string
+	selected    int
+}
+
+// SetCaptureReview holds evna sends until they're approved in the capture
+// review. Patterns still go through FLOAT.dispatch locally.
+func (o *Outliner) SetCaptureReview(enabled bool) {
+	o.holdCaptures = enabled
+}
+
+// IsCaptureReviewOpen reports whether the capture review has focus
+func (o *Outliner) IsCaptureReviewOpen() bool {
+	return o.capturePanel != nil
+}
+
+// OpenCaptureReview lists the patterns on nodes not captured yet, all
+// included, so they can be checked before sending. It does nothing when
+// review is off or there's nothing new.
+func (o *Outliner) OpenCaptureReview() {
+	if !o.holdCaptures || o.parser == nil {
+		return
+	}
+
+	review := &captureReview{collections: o.evna.Collections(), imprints: o.dispatch.order}
+	for _, pattern := range o.parser.Parse(o.patternContent()).ConsciousnessData {
+		if pattern.Line > len(o.lines) {
+			continue
+		}
+		node := o.lines[pattern.Line-1]
+		if node.Captured || isPrivate(node.Text) {
+			continue
+		}
+		pattern = o.annotateMeeting(pattern)
+		imprint := pattern.Context["imprint"]
+		if imprint == "" {
+			imprint = o.dispatch.extractImprint(pattern.Content)
+		}
+		if imprint == "" {
+			imprint = o.dispatch.routeToImprint(pattern.Type)
+		}
+		review.entries = append(review.entries, reviewEntry{
+			pattern:    pattern,
+			include:    true,
+			collection: o.evna.collectionFor(pattern),
+			imprint:    imprint,
+		})
+	}
+	if len(review.entries) == 0 {
+		return
+	}
+	o.capturePanel = review
+}
+
+// sendReviewed queues the included entries for evna with their chosen
+// routing and marks their nodes captured; excluded nodes stay uncaptured
+// for the next review
+func (o *Outliner) sendReviewed() {
+	var patterns []ConsciousnessPattern
+	for _, entry := range o.capturePanel.entries {
+		if !entry.include {
+			continue
+		}
+		pattern := entry.pattern
+		pattern.Context = make(map[string]string, len(entry.pattern.Context)+2)
+		for k, v := range entry.pattern.Context {
+			pattern.Context[k] = v
+		}
+		pattern.Context["collection"] = entry.collection
+		pattern.Context["imprint"] = entry.imprint
+		patterns = append(patterns, pattern)
+	}
+	o.capturePanel = nil
+
+	Publish(o.dispatch.Bus(), CaptureApproved{Patterns: patterns, Source: "float-dispatch:capture_review"})
+	o.markNodesAsCaptured(patterns)
+	o.debugPanel.AddMessage("CAPTURE_REVIEW", fmt.Sprintf("Sent %d reviewed patterns to evna", len(patterns)), DebugLevelInfo)
+}
+
+// cycle returns the option after (or before) current, wrapping around
+func cycle(options []string, current string, delta int) string {
+	if len(options) == 0 {
+		return current
+	}
+	at := -1
+	for i, option := range options {
+		if option == current {
+			at = i
+		}
+	}
+	if at < 0 && delta < 0 {
+		at = 0
+	}
+	return options[((at+delta)%len(options)+len(options))%len(options)]
+}
+
+// updateCaptureReview handles keys while the capture review is open
+func (o *Outliner) updateCaptureReview(msg tea.KeyMsg) {
+	r := o.capturePanel
+	entry := &r.entries[r.selected]
+	switch msg.String() {
+	case "esc":
+		// Nothing is sent; the nodes stay up for review
+		o.capturePanel = nil
+	case "enter":
+		o.sendReviewed()
+	case "up", "k":
+		r.selected = max(0, r.selected-1)
+	case "down", "j":
+		r.selected = min(len(r.entries)-1, r.selected+1)
+	case " ", "x":
+		entry.include = !entry.include
+	case "a":
+		// Include everything, or nothing when everything already is
+		all := true
+		for _, e := range r.entries {
+			all = all && e.include
+		}
+		for i := range r.entries {
+			r.entries[i].include = !all
+		}
+	case "c", "right", "l":
+		entry.collection = cycle(r.collections, entry.collection, 1)
+	case "C", "left", "h":
+		entry.collection = cycle(r.collections, entry.collection, -1)
+	case "i":
+		entry.imprint = cycle(r.imprints, entry.imprint, 1)
+	case "I":
+		entry.imprint = cycle(r.imprints, entry.imprint, -1)
+	}
+}
+
+// capturePanelHeight is the number of rows the review takes, borders
+// included
+func (o *Outliner) capturePanelHeight() int {
+	if o.capturePanel == nil {
+		return 0
+	}
+	return len(o.capturePanel.entries) + 4
+}
+
+// renderCapturePanel draws the capture review under the outline
+func (o *Outliner) renderCapturePanel(width int) string {
+	r := o.capturePanel
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	selected := o.theme.selectedStyle()
+
+	typeWidth := 0
+	for _, entry := range r.entries {
+		typeWidth = max(typeWidth, len(entry.pattern.Type)+2)
+	}
+
+	included := 0
+	lines := make([]string, 0, len(r.entries))
+	for i, entry := range r.entries {
+		box := "☐"
+		if entry.include {
+			box = "☑"
+			included++
+		}
+		routing := dim.Render(fmt.Sprintf("→ %s · %s", entry.collection, entry.imprint))
+		content := entry.pattern.Content
+		if room := width - typeWidth - lipgloss.Width(routing) - 14; room > 0 && lipgloss.Width(content) > room {
+			runes := []rune(content)
+			content = string(runes[:min(room-1, len(runes))]) + "…"
+		}
+		text := fmt.Sprintf("%s %-*s %s  %s", box, typeWidth, entry.pattern.Type+"::", content, routing)
+		if i == r.selected {
+			text = selected.Render("› " + text)
+		} else {
+			text = "  " + text
+		}
+		lines = append(lines, text)
+	}
+
+	title := fmt.Sprintf(" Capture review · %d of %d to evna ", included, len(r.entries))
+	help := "Space: include · a: all · c/C: collection · i/I: imprint · Enter: send · Esc: cancel"
+	return lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color(o.theme.Accent)).
+		Width(width - 2).
+		Render(title + "\n" + strings.Join(lines, "\n") + "\n" + dim.Render(help))
+}
@@ -0,0 +1,102 @@
+package outliner
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// roundTripWords are the pieces random node text is built from, heavy on
+// the characters the content format treats specially
+var roundTripWords = []string{
+	"plain", "ctx::", "eureka::", "decision::", "reducer::", "[[link]]", "[[open",
+	"[priority:: high]", "•", "◦", "• ", "◦ ", " ", "  ", "\t", "::", "-", "🌀", "<opml>",
+}
+
+// randomWords joins up to n random words
+func randomWords(r *rand.Rand, n int) string {
+	var text strings.Builder
+	for w := r.IntN(n + 1); w > 0; w-- {
+		text.WriteString(roundTripWords[r.IntN(len(roundTripWords))])
+	}
+	return text.String()
+}
+
+// randomOutline builds about n random nodes of every kind; a level may
+// jump up to three deeper than the one before, as pasted or imported
+// outlines can
+func randomOutline(r *rand.Rand, n int) []OutlineNode {
+	var nodes []OutlineNode
+	add := func(kind NodeKind, text string, level int) {
+		node := newNode(text, level)
+		node.Kind = kind
+		if kind.Captured() {
+			node.PatternType = Patterns.Type(text)
+		}
+		nodes = append(nodes, node)
+	}
+
+	level := 0
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			level = r.IntN(level + 4)
+		}
+		switch r.IntN(10) {
+		case 0:
+			add(KindBlank, "", 0)
+		case 1:
+			add(KindHeading, "## "+randomWords(r, 3), level)
+		case 2:
+			add(KindParagraph, "plain"+randomWords(r, 4), level)
+		case 3:
+			add(KindCode, "```go", level)
+			for j := r.IntN(3); j > 0; j-- {
+				add(KindCode, randomWords(r, 3), level+1)
+			}
+			add(KindCode, "```", level)
+		default:
+			add(KindBullet, randomWords(r, 4), level)
+		}
+	}
+
+	// Blank lines take the level of what follows, as SetContent reads them
+	next := 0
+	for i := len(nodes) - 1; i >= 0; i-- {
+		if nodes[i].Kind == KindBlank {
+			nodes[i].Level = next
+			continue
+		}
+		next = nodes[i].Level
+	}
+	return nodes
+}
+
+// SetContent(GetContent()) must give back the same nodes. IDs aren't
+// written to plain content, so they aren't compared.
+func TestContentRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < 500; i++ {
+		o := New()
+		o.Evna().SetEnabled(false)
+		o.loadNodes(randomOutline(r, 1+r.IntN(12)))
+		content := o.GetContent()
+
+		loaded := New()
+		loaded.Evna().SetEnabled(false)
+		loaded.SetContent(content)
+
+		if len(loaded.lines) != len(o.lines) {
+			t.Fatalf("%q loaded as %d nodes, want %d", content, len(loaded.lines), len(o.lines))
+		}
+		for j, want := range o.lines {
+			got := loaded.lines[j]
+			if got.Text != want.Text || got.Level != want.Level || got.Kind != want.Kind || got.PatternType != want.PatternType {
+				t.Fatalf("%q: node %d = {%q %d %q %q}, want {%q %d %q %q}", content, j,
+					got.Text, got.Level, got.Kind, got.PatternType, want.Text, want.Level, want.Kind, want.PatternType)
+			}
+		}
+		if again := loaded.GetContent(); again != content {
+			t.Fatalf("content changed across a round trip:\n%q\n%q", content, again)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package outliner
+
+import (
+	"errors"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+)
+
+// sandboxSamples is how many matching actions the sandbox lists
+const sandboxSamples = 5
+
+// The sandbox's fields, in Tab order
+const (
+	sandboxName = iota
+	sandboxQuery
+	sandboxTemplate
+	sandboxFields
+)
+
+// SandboxPromoteMsg asks the app to write a sandbox definition into the
+// outline as real nodes
+type SandboxPromoteMsg struct {
+	Name  string
+	Nodes []string // the reducer:: line, then a selector:: line on it if a template was written
+}
+
+// SandboxDoor - Reducer and selector dry-run door: queries and templates
+// are tried against the dispatch log without being registered
+type SandboxDoor struct {
+	active   bool
+	dispatch *FloatDispatchSystem
+	fields   [sandboxFields]string
+	focus    int
+	err      string // why the last promote was refused
+
+	// The last dry run
+	matched    []DispatchAction
+	considered int
+	described  string
+	note       string
+
+	style lipgloss.Style
+	title lipgloss.Style
+	label lipgloss.Style
+	dim   lipgloss.Style
+}
+
+func NewSandboxDoor() Door {
+	return &SandboxDoor{
+		fields: [sandboxFields]string{"sandbox", "", ""},
+		focus:  sandboxQuery,
+		style:  lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1),
+		title:  lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("62")),
+		label:  lipgloss.NewStyle().Bold(true),
+		dim:    lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
+	}
+}
+
+// SetDispatch points the sandbox at the dispatch system whose session and
+// history it runs against
+func (sd *SandboxDoor) SetDispatch(fds *FloatDispatchSystem) {
+	sd.dispatch = fds
+	sd.run()
+}
+
+// run dry-runs the query, as a windowed reducer so the whole dispatch log
+// is considered
+func (sd *SandboxDoor) run() {
+	sd.matched, sd.considered, sd.described, sd.note = nil, 0, "", ""
+	query := strings.TrimSpace(sd.fields[sandboxQuery])
+	switch {
+	case query == "":
+		sd.note = i18n.T("outliner.sandbox.no_query")
+	case isSimilarityQuery(query), isExecQuery(query):
+		sd.note = i18n.T("outliner.sandbox.on_capture")
+	case sd.dispatch != nil:
+		sd.matched, sd.considered = sd.dispatch.DryRunReducer(query)
+		sd.described = describeReducerQuery(query)
+	}
+}
+
+// Definition is the nodes promoting would write: "reducer::name query",
+// and "selector:: (name) => template" when a template was written
+func (sd *SandboxDoor) Definition() ([]string, error) {
+	name := strings.TrimSpace(sd.fields[sandboxName])
+	query := strings.Join(strings.Fields(sd.fields[sandboxQuery]), " ")
+	template := strings.Join(strings.Fields(sd.fields[sandboxTemplate]), " ")
+	if name == "" || query == "" {
+		return nil, errors.New(i18n.T("outliner.sandbox.no_definition"))
+	}
+	if strings.ContainsAny(name, " \t") {
+		return nil, errors.New(i18n.T("outliner.sandbox.bad_name"))
+	}
+	nodes := []string{"reducer::" + name + " " + query}
+	if template != "" {
+		nodes = append(nodes, "selector:: ("+name+") => "+template)
+	}
+	return nodes, nil
+}
+
+func (sd *SandboxDoor) Name() string                          { return "sandbox" }
+func (sd *SandboxDoor) Init(params map[string]string) tea.Cmd { return nil }
+
+func (sd *SandboxDoor) Update(msg tea.Msg) (Door, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok || !sd.active {
+		return sd, nil
+	}
+
+	field := &sd.fields[sd.focus]
+	switch key.Type {
+	case tea.KeyTab:
+		sd.focus = (sd.focus + 1) % sandboxFields
+		return sd, nil
+	case tea.KeyShiftTab:
+		sd.focus = (sd.focus + sandboxFields - 1) % sandboxFields
+		return sd, nil
+	case tea.KeyEnter:
+		nodes, err := sd.Definition()
+		if err != nil {
+			sd.err = err.Error()
+			return sd, nil
+		}
+		sd.err = ""
+		promote := SandboxPromoteMsg{Name: strings.TrimSpace(sd.fields[sandboxName]), Nodes: nodes}
+		return sd, func() tea.Msg { return promote }
+	case tea.KeyBackspace:
+		if runes := []rune(*field); len(runes) > 0 {
+			*field = string(runes[:len(runes)-1])
+		}
+	case tea.KeyCtrlU:
+		*field = ""
+	case tea.KeySpace:
+		*field += " "
+	case tea.KeyRunes:
+		*field += string(key.Runes)
+	default:
+		return sd, nil
+	}
+	sd.err = ""
+	if sd.focus == sandboxQuery {
+		sd.run()
+	}
+	return sd, nil
+}
+
+func (sd *SandboxDoor) View(width, height int) string {
+	inner := max(20, cells.Inner(sd.style, width))
+	labelWidth := 10
+
+	var b strings.Builder
+	b.WriteString(sd.title.Render(i18n.T("outliner.sandbox.title")))
+	b.WriteString("\n" + sd.dim.Render(cells.Cut(i18n.T("outliner.sandbox.help"), inner)) + "\n\n")
+
+	labels := []string{i18n.T("outliner.sandbox.name"), i18n.T("outliner.sandbox.query"), i18n.T("outliner.sandbox.template")}
+	for i, label := range labels {
+		label = cells.Pad(label, labelWidth)
+		value := sd.fields[i]
+		marker := "  "
+		if i == sd.focus {
+			marker = "› "
+			value += "│"
+		}
+		b.WriteString(marker + sd.label.Render(label) + cells.Cut(value, inner-labelWidth-2) + "\n")
+	}
+	if sd.err != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render(cells.Cut(sd.err, inner)) + "\n")
+	}
+
+	b.WriteString("\n")
+	if sd.note != "" {
+		b.WriteString(sd.dim.Render(cells.Cut(sd.note, inner)) + "\n")
+		return sd.frame(width, height, b.String())
+	}
+	b.WriteString(cells.Cut(i18n.N("outliner.sandbox.matched", len(sd.matched), sd.considered), inner) + "\n")
+	b.WriteString(sd.dim.Render(cells.Cut(sd.described, inner)) + "\n")
+	if len(sd.matched) == 0 {
+		b.WriteString(sd.dim.Render(cells.Cut(i18n.T("outliner.sandbox.none"), inner)) + "\n")
+		return sd.frame(width, height, b.String())
+	}
+
+	b.WriteString("\n" + sd.title.Render(i18n.T("outliner.sandbox.matches")) + "\n")
+	for i := len(sd.matched) - 1; i >= 0 && i >= len(sd.matched)-sandboxSamples; i-- {
+		action := sd.matched[i]
+		b.WriteString("  " + cells.Cut(action.PatternType+":: "+strings.Join(strings.Fields(action.Content), " "), inner-2) + "\n")
+	}
+
+	b.WriteString("\n" + sd.title.Render(i18n.T("outliner.sandbox.output")) + "\n")
+	template := strings.Join(strings.Fields(sd.fields[sandboxTemplate]), " ")
+	if template == "" {
+		b.WriteString(sd.dim.Render(cells.Cut(i18n.T("outliner.sandbox.no_template"), inner)) + "\n")
+		return sd.frame(width, height, b.String())
+	}
+	name := strings.TrimSpace(sd.fields[sandboxName])
+	output := SelectorTransform(template)(map[string][]DispatchAction{name: sd.matched})
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		b.WriteString("  " + cells.Cut(line, inner-2) + "\n")
+	}
+	return sd.frame(width, height, b.String())
+}
+
+// frame draws the door's border around content, cut to height
+func (sd *SandboxDoor) frame(width, height int, content string) string {
+	return cells.Frame(sd.style, width).Height(height - 2).MaxHeight(height).Render(strings.TrimRight(content, "\n"))
+}
+
+func (sd *SandboxDoor) IsActive() bool { return sd.active }
+func (sd *SandboxDoor) Activate()      { sd.active = true }
+func (sd *SandboxDoor) Deactivate()    { sd.active = false }
+func (sd *SandboxDoor) GetState() map[string]interface{} {
+	return map[string]interface{}{
+		"name":     sd.fields[sandboxName],
+		"query":    sd.fields[sandboxQuery],
+		"template": sd.fields[sandboxTemplate],
+	}
+}
+func (sd *SandboxDoor) OnConsciousnessCapture(patterns []ConsciousnessPattern) {}
+
+func (sd *SandboxDoor) SetState(state map[string]interface{}) {
+	for i, key := range []string{"name", "query", "template"} {
+		if value, ok := state[key].(string); ok {
+			sd.fields[i] = value
+		}
+	}
+	sd.run()
+}
+
+// PromoteDefinitions writes texts as nodes after the cursor's subtree, at
+// its level, and captures them so the reducers and selectors they define
+// register now. The cursor moves onto the first.
+func (o *Outliner) PromoteDefinitions(texts []string) int {
+	if len(texts) == 0 {
+		return 0
+	}
+	o.saveUndo()
+
+	level, insertAt := 0, len(o.lines)
+	if o.cursor < len(o.lines) {
+		level = o.lines[o.cursor].Level
+		insertAt = o.cursor + 1
+		for insertAt < len(o.lines) && o.lines[insertAt].Level > level {
+			insertAt++
+		}
+	}
+	nodes := make([]OutlineNode, len(texts))
+	for i, text := range texts {
+		nodes[i] = newNode(text, level)
+		nodes[i].PatternType = o.detectPatternType(text)
+	}
+	o.insertNodes(insertAt, nodes...)
+
+	markChildren(o.lines)
+	o.markArchived()
+	for i := insertAt; i < insertAt+len(nodes); i++ {
+		o.updateNodeLinks(i)
+	}
+	// The reducer first, so the selector on it finds its input
+	for i := insertAt; i < insertAt+len(nodes); i++ {
+		o.captureNode(i)
+	}
+	o.refreshDiagnostics()
+	o.cursor, o.cursorPos = insertAt, 0
+	o.ClearRenderCache()
+	return len(nodes)
+}
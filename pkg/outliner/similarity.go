@@ -0,0 +1,290 @@
+package outliner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// embedBatch is how many texts go in one embeddings request
+const embedBatch = 64
+
+// embedRetry is how long embeddings stay off after a failed request, so
+// captures don't each wait out the timeout while the endpoint is down
+const embedRetry = time.Minute
+
+// Embedder turns texts into vectors
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// HTTPEmbedder calls an OpenAI-compatible embeddings endpoint, e.g.
+// https://api.openai.com/v1/embeddings or a local Ollama's
+// http://localhost:11434/v1/embeddings
+type HTTPEmbedder struct {
+	URL    string
+	Model  string
+	APIKey string // sent as a bearer token when set
+	Client *http.Client
+
+	Redactor *Redactor // applied to each text before it's sent
+}
+
+type embedRequest struct {
+	Model string   `json:"model,omitempty"`
+	Input []string `json:"input"`
+}
+
+type embedResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed returns one vector per text, in order
+func (e *HTTPEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if e.Redactor != nil {
+		texts = e.redact(texts)
+	}
+	body, err := json.Marshal(embedRequest{Model: e.Model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embeddings request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embeddings request: %s", resp.Status)
+	}
+
+	var decoded embedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("embeddings response: %w", err)
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range decoded.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embeddings response: index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return nil, fmt.Errorf("embeddings response: no vector for input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// redact passes texts through the embedder's redactor, logging what it
+// changed; a text left empty is sent as a single space so every input
+// still gets a vector
+func (e *HTTPEmbedder) redact(texts []string) []string {
+	redacted := make([]string, len(texts))
+	var all Redactions
+	for i, text := range texts {
+		text, rs := e.Redactor.Redact(text)
+		if strings.TrimSpace(text) == "" {
+			text = " "
+		}
+		redacted[i] = text
+		all = all.Merge(rs)
+	}
+	if len(all) > 0 {
+		slog.Info("redacted before embedding", "url", e.URL, "redactions", all.String())
+	}
+	return redacted
+}
+
+// Embeddings caches an embedder's vectors by text, shared by every
+// similarity reducer of a dispatch system
+type Embeddings struct {
+	embedder Embedder
+	timeout  time.Duration
+
+	mu         sync.Mutex
+	vectors    map[string][]float32
+	retryAfter time.Time
+}
+
+// NewEmbeddings caches embedder's vectors; each request may take timeout
+func NewEmbeddings(embedder Embedder, timeout time.Duration) *Embeddings {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &Embeddings{embedder: embedder, timeout: timeout, vectors: make(map[string][]float32)}
+}
+
+// Prefetch embeds the texts not cached yet, in batches
+func (e *Embeddings) Prefetch(texts []string) error {
+	e.mu.Lock()
+	if time.Now().Before(e.retryAfter) {
+		e.mu.Unlock()
+		return nil
+	}
+	var pending []string
+	queued := make(map[string]bool)
+	for _, text := range texts {
+		if _, cached := e.vectors[text]; !cached && !queued[text] {
+			queued[text] = true
+			pending = append(pending, text)
+		}
+	}
+	e.mu.Unlock()
+
+	for len(pending) > 0 {
+		n := min(embedBatch, len(pending))
+		ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+		vectors, err := e.embedder.Embed(ctx, pending[:n])
+		cancel()
+
+		e.mu.Lock()
+		if err != nil {
+			e.retryAfter = time.Now().Add(embedRetry)
+			e.mu.Unlock()
+			return err
+		}
+		for i, text := range pending[:n] {
+			e.vectors[text] = vectors[i]
+		}
+		e.mu.Unlock()
+		pending = pending[n:]
+	}
+	return nil
+}
+
+// Vector returns text's vector, embedding it if needed; nil when the
+// embedder failed
+func (e *Embeddings) Vector(text string) []float32 {
+	e.mu.Lock()
+	v, cached := e.vectors[text]
+	e.mu.Unlock()
+	if cached {
+		return v
+	}
+	if err := e.Prefetch([]string{text}); err != nil {
+		slog.Warn("embedding failed", "err", err)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.vectors[text]
+}
+
+// CosineSimilarity is the cosine of the angle between a and b, 0 when
+// either is empty or they differ in length
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// ParseSimilarityQuery splits "collect all decisions similar to auth
+// rollout from the last 7 days" into the filters before "similar to" and
+// the seed phrase after it, time window removed; ok is false for queries
+// without "similar to"
+func ParseSimilarityQuery(query string) (filter, seed string, ok bool) {
+	i := strings.Index(strings.ToLower(query), " similar to ")
+	if i < 0 {
+		return "", "", false
+	}
+	seed = strings.Join(strings.Fields(timeWindowRegex.ReplaceAllString(query[i+len(" similar to "):], " ")), " ")
+	return query[:i], seed, seed != ""
+}
+
+// SimilarityMatcher collects actions whose content is close in meaning to
+// a seed phrase, filtered by the pattern types and time window the query
+// names like any reducer
+type SimilarityMatcher struct {
+	embeddings *Embeddings
+	seed       string
+	threshold  float64
+	types      map[string]bool
+	window     TimeWindow
+	windowed   bool
+}
+
+// NewSimilarityMatcher creates a matcher for a similarity query, matching
+// at or above threshold
+func NewSimilarityMatcher(query string, embeddings *Embeddings, threshold float64) (*SimilarityMatcher, error) {
+	filter, seed, ok := ParseSimilarityQuery(query)
+	if !ok {
+		return nil, fmt.Errorf("similarity reducer needs a phrase after \"similar to\": %q", query)
+	}
+	window, windowed := ParseTimeWindow(query)
+	return &SimilarityMatcher{
+		embeddings: embeddings,
+		seed:       seed,
+		threshold:  threshold,
+		types:      queryPatternTypes(strings.ToLower(filter)),
+		window:     window,
+		windowed:   windowed,
+	}, nil
+}
+
+// Match reports whether action passes the filters and is similar enough
+// to the seed
+func (m *SimilarityMatcher) Match(action DispatchAction) bool {
+	if !m.considers(action) {
+		return false
+	}
+	seed := m.embeddings.Vector(m.seed)
+	if seed == nil {
+		return false
+	}
+	return CosineSimilarity(seed, m.embeddings.Vector(action.Content)) >= m.threshold
+}
+
+// Prefetch embeds the seed and every candidate that passes the filters in
+// as few requests as possible
+func (m *SimilarityMatcher) Prefetch(actions []DispatchAction) {
+	texts := []string{m.seed}
+	for _, action := range actions {
+		if m.considers(action) {
+			texts = append(texts, action.Content)
+		}
+	}
+	if err := m.embeddings.Prefetch(texts); err != nil {
+		slog.Warn("embedding failed", "texts", len(texts), "err", err)
+	}
+}
+
+// considers applies the query's type and time filters
+func (m *SimilarityMatcher) considers(action DispatchAction) bool {
+	if len(m.types) > 0 && !m.types[action.PatternType] {
+		return false
+	}
+	return !m.windowed || m.window.Contains(action.Timestamp, time.Now())
+}
@@ -0,0 +1,118 @@
+package outliner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SortOrders are the orders SortChildren accepts
+var SortOrders = []string{"alpha", "created", "modified", "type"}
+
+// childBlocks splits the subtree of node i into its children, each with
+// its own descendants, and returns where the subtree ends
+func (o *Outliner) childBlocks(i int) (blocks [][]OutlineNode, end int) {
+	level := o.lines[i].Level
+	end = i + 1
+	for end < len(o.lines) && o.lines[end].Level > level {
+		if end == i+1 || o.lines[end].Level <= level+1 {
+			blocks = append(blocks, nil)
+		}
+		blocks[len(blocks)-1] = append(blocks[len(blocks)-1], o.lines[end])
+		end++
+	}
+	return blocks, end
+}
+
+// replaceChildren swaps the subtree of node i for blocks, kept undoable
+func (o *Outliner) replaceChildren(i, end int, blocks [][]OutlineNode) {
+	o.saveUndo()
+	o.lines = slices.Replace(o.lines, i+1, end, slices.Concat(blocks...)...)
+	o.cursor = i
+	o.structureChanged()
+}
+
+// SortChildren reorders the children of the node under the cursor, each
+// with its subtree, by text ("alpha"), creation or modification time, or
+// pattern type with untyped children last; desc reverses the order
+func (o *Outliner) SortChildren(by string, desc bool) error {
+	if !slices.Contains(SortOrders, by) {
+		return fmt.Errorf("unknown sort order %q (want %s)", by, strings.Join(SortOrders, ", "))
+	}
+	if o.cursor >= len(o.lines) {
+		return fmt.Errorf("no node to sort")
+	}
+	blocks, end := o.childBlocks(o.cursor)
+	if len(blocks) < 2 {
+		return fmt.Errorf("node has no children to sort")
+	}
+
+	compare := func(a, b OutlineNode) int {
+		switch by {
+		case "created":
+			return a.CreatedAt.Compare(b.CreatedAt)
+		case "modified":
+			return a.ModifiedAt.Compare(b.ModifiedAt)
+		case "type":
+			ta, tb := o.detectPatternType(a.Text), o.detectPatternType(b.Text)
+			if (ta == "") != (tb == "") {
+				if ta == "" {
+					return 1
+				}
+				return -1
+			}
+			return strings.Compare(ta, tb)
+		}
+		return strings.Compare(strings.ToLower(a.Text), strings.ToLower(b.Text))
+	}
+	slices.SortStableFunc(blocks, func(a, b []OutlineNode) int {
+		if desc {
+			return compare(b[0], a[0])
+		}
+		return compare(a[0], b[0])
+	})
+	o.replaceChildren(o.cursor, end, blocks)
+	return nil
+}
+
+// GroupChildren moves the children of the node under the cursor under a
+// header node per pattern type, in order of first appearance; untyped
+// children stay after the groups
+func (o *Outliner) GroupChildren() error {
+	if o.cursor >= len(o.lines) {
+		return fmt.Errorf("no node to group")
+	}
+	blocks, end := o.childBlocks(o.cursor)
+	groups := make(map[string][][]OutlineNode)
+	var types []string
+	var untyped [][]OutlineNode
+	for _, block := range blocks {
+		patternType := o.detectPatternType(block[0].Text)
+		if patternType == "" {
+			untyped = append(untyped, block)
+			continue
+		}
+		if groups[patternType] == nil {
+			types = append(types, patternType)
+		}
+		groups[patternType] = append(groups[patternType], block)
+	}
+	if len(types) == 0 {
+		return fmt.Errorf("no pattern children to group")
+	}
+
+	level := o.lines[o.cursor].Level + 1
+	var grouped [][]OutlineNode
+	for _, patternType := range types {
+		grouped = append(grouped, []OutlineNode{newNode(patternType, level)})
+		for _, block := range groups[patternType] {
+			moved := slices.Clone(block)
+			for k := range moved {
+				moved[k].Level++
+			}
+			grouped = append(grouped, moved)
+		}
+	}
+	o.replaceChildren(o.cursor, end, append(grouped, untyped...))
+	return nil
+}
@@ -0,0 +1,122 @@
+package outliner
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+)
+
+// SplitNode splits the node under the cursor at the cursor: the text after
+// it moves to a new node right below, a sibling that takes over the
+// children or, with asChild, the first child. The node keeps its ID so
+// links and bridges to it hold; both halves keep its metadata, stay
+// private if it was, and are captured again.
+func (o *Outliner) SplitNode(asChild bool) error {
+	if o.cursor >= len(o.lines) {
+		return fmt.Errorf("no node to split")
+	}
+	line := o.lines[o.cursor]
+	if line.Kind == KindCode || isTableRow(line) {
+		return fmt.Errorf("can't split %s lines", line.Kind)
+	}
+
+	// Never split an annotation in two
+	pos := min(o.cursorPos, len(line.Text))
+	for _, span := range annotationRegex.FindAllStringIndex(line.Text, -1) {
+		if pos > span[0] && pos < span[1] {
+			pos = span[1]
+		}
+	}
+	before, after := strings.TrimRight(line.Text[:pos], " "), strings.TrimLeft(line.Text[pos:], " ")
+	if isPrivate(line.Text) {
+		if !isPrivate(before) {
+			before = strings.TrimRight(before, " ") + " [private:: true]"
+		}
+		if !isPrivate(after) {
+			after = strings.TrimRight(after, " ") + " [private:: true]"
+		}
+	}
+
+	o.saveUndo()
+	now := time.Now()
+	level := line.Level
+	if asChild {
+		level++
+	}
+	second := newNode(after, level)
+	second.Kind = line.Kind
+	second.Metadata = maps.Clone(line.Metadata)
+	if second.Metadata == nil {
+		second.Metadata = make(map[string]string)
+	}
+	second.PatternType = o.detectPatternType(after)
+
+	first := &o.lines[o.cursor]
+	first.Text = before
+	first.ModifiedAt = now
+	first.PatternType = o.detectPatternType(before)
+	first.Captured = false
+
+	o.insertNodes(o.cursor+1, second)
+	o.refreshNodeLinks(o.cursor)
+	o.refreshNodeLinks(o.cursor + 1)
+	o.updateBacklinks()
+	o.cursor++
+	o.cursorPos = 0
+	o.structureChanged()
+	return nil
+}
+
+// JoinNext joins the node after the cursor's onto it, separated by a
+// space. The joined node's children move to stay under the result;
+// metadata is merged, the cursor's node winning, and it stays captured
+// only if both were.
+func (o *Outliner) JoinNext() error {
+	i := o.cursor
+	if i+1 >= len(o.lines) {
+		return fmt.Errorf("no next node to join")
+	}
+	line, next := o.lines[i], o.lines[i+1]
+	if line.Kind == KindCode || next.Kind == KindCode {
+		return fmt.Errorf("can't join code lines")
+	}
+
+	o.saveUndo()
+	end := i + 2
+	for end < len(o.lines) && o.lines[end].Level > next.Level {
+		end++
+	}
+	// The joined node's children become the result's
+	for k := i + 2; k < end; k++ {
+		o.lines[k].Level += line.Level - next.Level
+	}
+
+	joined := &o.lines[i]
+	joinAt := len(strings.TrimRight(line.Text, " "))
+	if text := strings.TrimSpace(next.Text); text != "" {
+		joined.Text = strings.TrimRight(line.Text, " ") + " " + text
+		joinAt++
+	}
+	if joined.Kind == KindBlank {
+		joined.Kind = next.Kind
+	}
+	for k, v := range next.Metadata {
+		if _, ok := joined.Metadata[k]; !ok {
+			if joined.Metadata == nil {
+				joined.Metadata = make(map[string]string)
+			}
+			joined.Metadata[k] = v
+		}
+	}
+	joined.Captured = line.Captured && next.Captured
+	joined.PatternType = o.detectPatternType(joined.Text)
+	joined.ModifiedAt = time.Now()
+
+	o.deleteNodes(i+1, i+2)
+	o.refreshNodeLinks(i)
+	o.updateBacklinks()
+	o.cursorPos = joinAt
+	o.structureChanged()
+	return nil
+}
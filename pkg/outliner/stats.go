@@ -0,0 +1,278 @@
+package outliner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+)
+
+// statsDays is how many days of captures the dashboard charts
+const statsDays = 7
+
+// statsTop caps the concept and imprint charts
+const statsTop = 5
+
+// barBlocks are the partial blocks used for fractional bar ends
+var barBlocks = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// StatsScope selects which actions the dashboard counts
+type StatsScope int
+
+const (
+	ScopeSession StatsScope = iota // patterns dispatched this session
+	ScopeHistory                   // patterns from the dispatch log
+	ScopeAll                       // both
+)
+
+func (s StatsScope) String() string {
+	switch s {
+	case ScopeHistory:
+		return "history"
+	case ScopeAll:
+		return "all"
+	}
+	return "session"
+}
+
+// ReducerStat is how many of the counted actions a reducer matches
+type ReducerStat struct {
+	Name   string
+	Hits   int
+	Rate   float64 // Hits / total actions counted
+	Global bool    // defined in config rather than the document
+}
+
+// PatternStats summarizes a set of dispatched actions
+type PatternStats struct {
+	Total    int
+	Types    map[string]int
+	Days     map[string]int // "2006-01-02" -> captures
+	Concepts map[string]int // [[concept]] -> mentions
+	Imprints map[string]int
+	Reducers []ReducerStat
+}
+
+// ComputeStats counts actions by type, day, concept, and imprint, and how
+// often each reducer matches them
+func ComputeStats(actions []DispatchAction, reducers map[string]*ConsciousnessReducer) PatternStats {
+	stats := PatternStats{
+		Total:    len(actions),
+		Types:    map[string]int{},
+		Days:     map[string]int{},
+		Concepts: map[string]int{},
+		Imprints: map[string]int{},
+	}
+
+	for _, action := range actions {
+		stats.Types[action.PatternType]++
+		if !action.Timestamp.IsZero() {
+			stats.Days[action.Timestamp.Format("2006-01-02")]++
+		}
+		for _, match := range wikiLinkRegex.FindAllStringSubmatch(action.Content, -1) {
+			stats.Concepts[normalizeLink(match[1])]++
+		}
+		if action.Imprint != "" {
+			stats.Imprints[action.Imprint]++
+		}
+	}
+
+	for name, reducer := range reducers {
+		stat := ReducerStat{Name: name, Global: reducer.Global}
+		for _, action := range actions {
+			if reducer.Matcher(action) {
+				stat.Hits++
+			}
+		}
+		if len(actions) > 0 {
+			stat.Rate = float64(stat.Hits) / float64(len(actions))
+		}
+		stats.Reducers = append(stats.Reducers, stat)
+	}
+	sort.Slice(stats.Reducers, func(i, j int) bool {
+		if stats.Reducers[i].Hits != stats.Reducers[j].Hits {
+			return stats.Reducers[i].Hits > stats.Reducers[j].Hits
+		}
+		return stats.Reducers[i].Name < stats.Reducers[j].Name
+	})
+
+	return stats
+}
+
+// normalizeLink strips a [[link|label]] alias and #heading
+func normalizeLink(link string) string {
+	if i := strings.IndexAny(link, "|#"); i >= 0 {
+		link = link[:i]
+	}
+	return strings.TrimSpace(link)
+}
+
+// bar renders value as a block-character bar scaled so limit fills width
+func bar(value, limit, width int) string {
+	if limit <= 0 || width <= 0 {
+		return ""
+	}
+	eighths := value * width * 8 / limit
+	return strings.Repeat("█", eighths/8) + barBlocks[eighths%8]
+}
+
+// countEntry is one row of a bar chart
+type countEntry struct {
+	label string
+	count int
+	note  string // shown after the bar
+}
+
+// sortedCounts orders counts largest first, ties by label
+func sortedCounts(counts map[string]int) []countEntry {
+	entries := make([]countEntry, 0, len(counts))
+	for label, count := range counts {
+		entries = append(entries, countEntry{label: label, count: count})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].count != entries[j].count {
+			return entries[i].count > entries[j].count
+		}
+		return entries[i].label < entries[j].label
+	})
+	return entries
+}
+
+// StatsDoor - Pattern statistics dashboard door
+type StatsDoor struct {
+	active   bool
+	dispatch *FloatDispatchSystem
+	scope    StatsScope
+	now      func() time.Time
+	style    lipgloss.Style
+	title    lipgloss.Style
+	barStyle lipgloss.Style
+	dim      lipgloss.Style
+}
+
+func NewStatsDoor() Door {
+	return &StatsDoor{
+		now:      time.Now,
+		style:    lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1),
+		title:    lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("62")),
+		barStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("6")),
+		dim:      lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
+	}
+}
+
+// SetDispatch points the dashboard at the dispatch system it summarizes
+func (sd *StatsDoor) SetDispatch(fds *FloatDispatchSystem) {
+	sd.dispatch = fds
+}
+
+// Stats computes statistics for the current scope
+func (sd *StatsDoor) Stats() PatternStats {
+	if sd.dispatch == nil {
+		return ComputeStats(nil, nil)
+	}
+
+	var actions []DispatchAction
+	if sd.scope != ScopeHistory {
+		actions = append(actions, sd.dispatch.GetActions()...)
+	}
+	if sd.scope != ScopeSession {
+		actions = append(actions, sd.dispatch.GetHistory()...)
+	}
+	return ComputeStats(actions, sd.dispatch.GetReducers())
+}
+
+func (sd *StatsDoor) Name() string                          { return "stats" }
+func (sd *StatsDoor) Init(params map[string]string) tea.Cmd { return nil }
+
+func (sd *StatsDoor) Update(msg tea.Msg) (Door, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok && sd.active {
+		switch msg.String() {
+		case "tab":
+			sd.scope = (sd.scope + 1) % 3
+		case "shift+tab":
+			sd.scope = (sd.scope + 2) % 3
+		}
+	}
+	return sd, nil
+}
+
+func (sd *StatsDoor) View(width, height int) string {
+	stats := sd.Stats()
+	inner := max(20, cells.Inner(sd.style, width))
+	labelWidth := min(20, inner/3)
+	barWidth := max(1, inner-labelWidth-14)
+
+	var b strings.Builder
+	b.WriteString(sd.title.Render(i18n.N("outliner.stats.title", stats.Total, sd.scope)))
+	b.WriteString("\n" + sd.dim.Render(i18n.T("outliner.stats.help")) + "\n")
+
+	chart := func(heading string, entries []countEntry, limit int) {
+		b.WriteString("\n" + sd.title.Render(heading) + "\n")
+		if len(entries) == 0 {
+			b.WriteString(sd.dim.Render("  "+i18n.T("outliner.stats.none")) + "\n")
+			return
+		}
+		if limit > 0 && len(entries) > limit {
+			entries = entries[:limit]
+		}
+		peak := 0
+		for _, entry := range entries {
+			peak = max(peak, entry.count)
+		}
+		for _, entry := range entries {
+			label := cells.Pad(cells.Cut(entry.label, labelWidth), labelWidth)
+			line := fmt.Sprintf("  %s %5d %s", label, entry.count,
+				sd.barStyle.Render(bar(entry.count, peak, barWidth)))
+			if entry.note != "" {
+				line += " " + sd.dim.Render(entry.note)
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	chart(i18n.T("outliner.stats.by_type"), sortedCounts(stats.Types), 0)
+
+	// Days run oldest to newest, including days with no captures
+	today := sd.now()
+	var days []countEntry
+	for i := statsDays - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i)
+		days = append(days, countEntry{label: day.Format("Mon Jan 2"), count: stats.Days[day.Format("2006-01-02")]})
+	}
+	chart(i18n.T("outliner.stats.per_day"), days, 0)
+
+	chart(i18n.T("outliner.stats.concepts"), sortedCounts(stats.Concepts), statsTop)
+	chart(i18n.T("outliner.stats.imprints"), sortedCounts(stats.Imprints), statsTop)
+
+	var reducers []countEntry
+	for _, reducer := range stats.Reducers {
+		note := fmt.Sprintf("%.0f%%", reducer.Rate*100)
+		if reducer.Global {
+			note += i18n.T("outliner.stats.global")
+		}
+		reducers = append(reducers, countEntry{label: reducer.Name, count: reducer.Hits, note: note})
+	}
+	chart(i18n.T("outliner.stats.reducers"), reducers, 0)
+
+	return cells.Frame(sd.style, width).Height(height - 2).MaxHeight(height).Render(strings.TrimRight(b.String(), "\n"))
+}
+
+func (sd *StatsDoor) IsActive() bool { return sd.active }
+func (sd *StatsDoor) Activate()      { sd.active = true }
+func (sd *StatsDoor) Deactivate()    { sd.active = false }
+func (sd *StatsDoor) GetState() map[string]interface{} {
+	return map[string]interface{}{"scope": int(sd.scope)}
+}
+func (sd *StatsDoor) OnConsciousnessCapture(patterns []ConsciousnessPattern) {}
+
+func (sd *StatsDoor) SetState(state map[string]interface{}) {
+	if scope, ok := state["scope"].(int); ok {
+		sd.scope = StatsScope(scope)
+	}
+}
@@ -0,0 +1,148 @@
+package outliner
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// streamBuffer is how far a Stream subscriber may fall behind before
+// actions are dropped for it
+const streamBuffer = 256
+
+// DispatchQuery selects stored actions; zero fields match everything
+type DispatchQuery struct {
+	PatternType string
+	Imprint     string
+	Text        string    // content substring, case-insensitive
+	Since       time.Time // dispatched after
+	Until       time.Time // dispatched before
+	Limit       int       // the most recent this many; 0 for all
+}
+
+// Matches reports whether action is selected by q, ignoring Limit
+func (q DispatchQuery) Matches(action DispatchAction) bool {
+	switch {
+	case q.PatternType != "" && action.PatternType != q.PatternType:
+		return false
+	case q.Imprint != "" && action.Imprint != q.Imprint:
+		return false
+	case q.Text != "" && !strings.Contains(strings.ToLower(action.Content), strings.ToLower(q.Text)):
+		return false
+	case !q.Since.IsZero() && !action.Timestamp.After(q.Since):
+		return false
+	case !q.Until.IsZero() && !action.Timestamp.Before(q.Until):
+		return false
+	}
+	return true
+}
+
+// DispatchStore keeps a dispatch system's actions. MemoryStore is the
+// default; pkg/dispatchstore has JSONL and SQLite stores that persist
+// them. Every method is safe for concurrent use.
+type DispatchStore interface {
+	// Append records a dispatched action
+	Append(action DispatchAction) error
+	// Query returns the stored actions q selects, oldest first
+	Query(q DispatchQuery) ([]DispatchAction, error)
+	// Stream delivers actions q selects as they're appended, until ctx
+	// is done
+	Stream(ctx context.Context, q DispatchQuery) <-chan DispatchAction
+	// Reset drops every stored action
+	Reset() error
+}
+
+// ActionStream fans appended actions out to Stream subscribers; stores
+// embed it and Publish each action they append
+type ActionStream struct {
+	mu   sync.Mutex
+	subs map[chan DispatchAction]DispatchQuery
+}
+
+// Stream subscribes to actions q selects until ctx is done. A subscriber
+// that falls streamBuffer actions behind misses the ones after.
+func (s *ActionStream) Stream(ctx context.Context, q DispatchQuery) <-chan DispatchAction {
+	ch := make(chan DispatchAction, streamBuffer)
+	s.mu.Lock()
+	if s.subs == nil {
+		s.subs = make(map[chan DispatchAction]DispatchQuery)
+	}
+	s.subs[ch] = q
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subs, ch)
+		close(ch)
+	}()
+	return ch
+}
+
+// Publish delivers action to the subscribers it matches
+func (s *ActionStream) Publish(action DispatchAction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch, q := range s.subs {
+		if !q.Matches(action) {
+			continue
+		}
+		select {
+		case ch <- action:
+		default:
+		}
+	}
+}
+
+// MemoryStore keeps actions in memory for the life of the process
+type MemoryStore struct {
+	ActionStream
+
+	mu      sync.Mutex
+	actions []DispatchAction
+}
+
+// NewMemoryStore creates an empty store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Append records action
+func (m *MemoryStore) Append(action DispatchAction) error {
+	m.mu.Lock()
+	m.actions = append(m.actions, action)
+	m.mu.Unlock()
+	m.Publish(action)
+	return nil
+}
+
+// Query returns the actions q selects, oldest first
+func (m *MemoryStore) Query(q DispatchQuery) ([]DispatchAction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var matched []DispatchAction
+	for _, action := range m.actions {
+		if q.Matches(action) {
+			matched = append(matched, action)
+		}
+	}
+	return LimitActions(matched, q.Limit), nil
+}
+
+// Reset drops every action
+func (m *MemoryStore) Reset() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.actions = nil
+	return nil
+}
+
+// LimitActions keeps the last limit actions, all of them when limit is 0
+func LimitActions(actions []DispatchAction, limit int) []DispatchAction {
+	if limit > 0 && len(actions) > limit {
+		return actions[len(actions)-limit:]
+	}
+	return actions
+}
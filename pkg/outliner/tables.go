@@ -0,0 +1,89 @@
+package outliner
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tableSeparatorRegex matches a header separator cell: ---, :--, --:, :-:
+var tableSeparatorRegex = regexp.MustCompile(`^:?-+:?$`)
+
+// isTableRow reports whether a node is a line of a | pipe | table |
+func isTableRow(node OutlineNode) bool {
+	return node.Kind == KindParagraph && strings.HasPrefix(node.Text, "|")
+}
+
+// tableCells splits a table row into its trimmed cells
+func tableCells(text string) []string {
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, "|")
+	text = strings.TrimSuffix(text, "|")
+	cells := strings.Split(text, "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(cell)
+	}
+	return cells
+}
+
+// isTableSeparator reports whether cells are a header separator row
+func isTableSeparator(cells []string) bool {
+	for _, cell := range cells {
+		if !tableSeparatorRegex.MatchString(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+// tableWidths returns the column widths of the table node i is a row of:
+// the run of table rows around it at its level
+func (o *Outliner) tableWidths(i int) []int {
+	level := o.lines[i].Level
+	inTable := func(j int) bool { return isTableRow(o.lines[j]) && o.lines[j].Level == level }
+
+	start := i
+	for start > 0 && inTable(start-1) {
+		start--
+	}
+	var widths []int
+	for j := start; j < len(o.lines) && inTable(j); j++ {
+		cells := tableCells(o.lines[j].Text)
+		if isTableSeparator(cells) {
+			continue
+		}
+		for c, cell := range cells {
+			if c == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[c] = max(widths[c], lipgloss.Width(cell))
+		}
+	}
+	return widths
+}
+
+// renderTableRow draws table row i with its cells padded to the table's
+// column widths; the row under the cursor is edited as raw text instead
+func (o *Outliner) renderTableRow(i int) string {
+	widths := o.tableWidths(i)
+	cells := tableCells(o.lines[i].Text)
+
+	parts := make([]string, len(widths))
+	if isTableSeparator(cells) {
+		for c, w := range widths {
+			parts[c] = strings.Repeat("─", w+2)
+		}
+		return o.treeLineStyle.Render("├" + strings.Join(parts, "┼") + "┤")
+	}
+
+	bar := o.treeLineStyle.Render("│")
+	for c, w := range widths {
+		cell := ""
+		if c < len(cells) {
+			cell = cells[c]
+		}
+		parts[c] = " " + cell + strings.Repeat(" ", w-lipgloss.Width(cell)) + " "
+	}
+	return bar + strings.Join(parts, bar) + bar
+}
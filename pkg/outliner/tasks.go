@@ -0,0 +1,75 @@
+package outliner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// taskRegex matches a task marker at the start of node text: "[ ] " or
+// "[x] ", after "- " in a markdown paragraph
+var taskRegex = regexp.MustCompile(`^(- )?\[([ xX])\]( |$)`)
+
+// checkedRegex matches a checked task's box
+var checkedRegex = regexp.MustCompile(`\[[xX]\]`)
+
+// doneAnnotationRegex matches the completion time a checked task carries
+var doneAnnotationRegex = regexp.MustCompile(`\s*\[done::[^\]]*\]`)
+
+// doneTimeFormat is how completion times are written
+const doneTimeFormat = "2006-01-02 15:04"
+
+// doneStyle renders the text of checked tasks
+var doneStyle = lipgloss.NewStyle().Strikethrough(true).Faint(true)
+
+// parseTask finds the task marker in text, returning where it ends and
+// whether it's checked
+func parseTask(text string) (end int, done, ok bool) {
+	m := taskRegex.FindStringSubmatchIndex(text)
+	if m == nil {
+		return 0, false, false
+	}
+	return m[1], text[m[4]:m[5]] != " ", true
+}
+
+// toggleTask cycles the current node from plain text to an open task to a
+// checked one and back to open. Checking stamps [done:: time], unchecking
+// removes it.
+func (o *Outliner) toggleTask(now time.Time) {
+	if o.cursor >= len(o.lines) || !o.lines[o.cursor].Kind.Captured() {
+		return
+	}
+	line := &o.lines[o.cursor]
+	before := len(line.Text)
+
+	end, done, ok := parseTask(line.Text)
+	marker := line.Text[:end]
+	switch {
+	case !ok:
+		line.Text = "[ ] " + line.Text
+		o.cursorPos += len(line.Text) - before
+	case done:
+		line.Text = checkedRegex.ReplaceAllString(marker, "[ ]") + doneAnnotationRegex.ReplaceAllString(line.Text[end:], "")
+		o.cursorPos = min(o.cursorPos, len(line.Text))
+	default:
+		line.Text = strings.Replace(marker, "[ ]", "[x]", 1) + line.Text[end:] + fmt.Sprintf(" [done:: %s]", now.Format(doneTimeFormat))
+	}
+	line.ModifiedAt = now
+	line.Captured = false
+}
+
+// renderTask draws a task marker as a checkbox, striking through checked
+// tasks; other text is returned as is
+func renderTask(text string) string {
+	end, done, ok := parseTask(text)
+	if !ok {
+		return text
+	}
+	if !done {
+		return "☐ " + text[end:]
+	}
+	return "☑ " + doneStyle.Render(text[end:])
+}
@@ -0,0 +1,103 @@
+package outliner
+
+import "github.com/charmbracelet/lipgloss"
+
+// Theme holds the colors used to render the outline
+type Theme struct {
+	Accent   string            // bullets, cursor, and focused border
+	Patterns map[string]string // pattern type -> foreground color
+
+	// Monochrome tells pattern types apart by bold, italic and underline,
+	// and the cursor and focus by reverse video and heavy borders, for
+	// terminals that show no color
+	Monochrome bool
+}
+
+// boldPatterns render bold so FLOAT.dispatch structure stands out
+var boldPatterns = map[string]bool{
+	"dispatch": true,
+	"reducer":  true,
+	"selector": true,
+	"imprint":  true,
+}
+
+// monoPatterns are the attributes pattern types get in a monochrome
+// theme; the FLOAT.dispatch ones are bold, others faint
+var monoPatterns = map[string]lipgloss.Style{
+	"ctx":       lipgloss.NewStyle().Italic(true),
+	"eureka":    lipgloss.NewStyle().Bold(true).Underline(true),
+	"decision":  lipgloss.NewStyle().Bold(true).Italic(true),
+	"highlight": lipgloss.NewStyle().Underline(true),
+	"gotcha":    lipgloss.NewStyle().Reverse(true),
+	"bridge":    lipgloss.NewStyle().Italic(true).Underline(true),
+}
+
+// DefaultTheme returns the built-in pattern colors
+func DefaultTheme() Theme {
+	return Theme{
+		Accent: "62",
+		Patterns: map[string]string{
+			"ctx":       "14", // cyan
+			"eureka":    "11", // yellow
+			"decision":  "9",  // red
+			"highlight": "10", // green
+			"gotcha":    "13", // magenta
+			"bridge":    "12", // blue
+			"dispatch":  "15", // bright white
+			"reducer":   "6",  // cyan
+			"selector":  "5",  // magenta
+			"imprint":   "3",  // yellow
+		},
+	}
+}
+
+// patternStyle returns the style for a pattern type, gray when unthemed
+func (t Theme) patternStyle(patternType string) lipgloss.Style {
+	if t.Monochrome {
+		if style, ok := monoPatterns[patternType]; ok {
+			return style
+		}
+		return lipgloss.NewStyle().Bold(boldPatterns[patternType]).Faint(!boldPatterns[patternType])
+	}
+	color, ok := t.Patterns[patternType]
+	if !ok {
+		color = "8"
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Bold(boldPatterns[patternType])
+}
+
+// selectedStyle marks the selected row of a panel's list
+func (t Theme) selectedStyle() lipgloss.Style {
+	if t.Monochrome {
+		return lipgloss.NewStyle().Reverse(true)
+	}
+	return lipgloss.NewStyle().Background(lipgloss.Color("237"))
+}
+
+// SetTheme overrides colors; empty fields keep the defaults
+func (o *Outliner) SetTheme(theme Theme) {
+	if theme.Accent != "" {
+		o.theme.Accent = theme.Accent
+		o.bulletStyle = o.bulletStyle.Foreground(lipgloss.Color(theme.Accent))
+		o.cursorStyle = o.cursorStyle.Background(lipgloss.Color(theme.Accent))
+		o.focusedStyle = o.focusedStyle.BorderForeground(lipgloss.Color(theme.Accent))
+	}
+
+	patterns := make(map[string]string, len(o.theme.Patterns)+len(theme.Patterns))
+	for k, v := range o.theme.Patterns {
+		patterns[k] = v
+	}
+	for k, v := range theme.Patterns {
+		patterns[k] = v
+	}
+	o.theme.Patterns = patterns
+
+	if theme.Monochrome {
+		o.theme.Monochrome = true
+		o.cursorStyle = lipgloss.NewStyle().Reverse(true)
+		o.highlightStyle = lipgloss.NewStyle().Bold(true)
+		o.focusedStyle = o.focusedStyle.BorderStyle(lipgloss.ThickBorder())
+		o.debugPanel.focusedStyle = o.debugPanel.focusedStyle.BorderStyle(lipgloss.ThickBorder())
+	}
+	o.ClearRenderCache()
+}
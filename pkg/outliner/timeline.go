@@ -0,0 +1,210 @@
+package outliner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/calendar"
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+)
+
+// TimelineEntry is a ctx:: capture placed on the timeline
+type TimelineEntry struct {
+	Time    time.Time
+	Content string
+}
+
+// TimelineDoor - A day's ctx:: captures against the calendar
+type TimelineDoor struct {
+	active   bool
+	dispatch *FloatDispatchSystem
+	calendar *calendar.Calendar
+	day      time.Time
+	offset   int
+	now      func() time.Time
+	style    lipgloss.Style
+	title    lipgloss.Style
+	event    lipgloss.Style
+	dim      lipgloss.Style
+}
+
+func NewTimelineDoor() Door {
+	return &TimelineDoor{
+		now:   time.Now,
+		style: lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1),
+		title: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("62")),
+		event: lipgloss.NewStyle().Foreground(lipgloss.Color("6")),
+		dim:   lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
+	}
+}
+
+// SetDispatch points the timeline at the dispatch system holding the
+// captures
+func (td *TimelineDoor) SetDispatch(fds *FloatDispatchSystem) {
+	td.dispatch = fds
+}
+
+// SetCalendar sets the calendar the captures are shown against
+func (td *TimelineDoor) SetCalendar(cal *calendar.Calendar) {
+	td.calendar = cal
+}
+
+// Day is the day shown, today until moved
+func (td *TimelineDoor) Day() time.Time {
+	if td.day.IsZero() {
+		return td.now()
+	}
+	return td.day
+}
+
+// Entries returns the day's ctx:: captures, by the time their headers
+// name, each once
+func (td *TimelineDoor) Entries() []TimelineEntry {
+	if td.dispatch == nil {
+		return nil
+	}
+	day := td.Day()
+	seen := map[string]bool{}
+	var entries []TimelineEntry
+	actions := append([]DispatchAction{}, td.dispatch.GetHistory()...)
+	for _, action := range append(actions, td.dispatch.GetActions()...) {
+		if action.PatternType != "ctx" || seen[action.Content] {
+			continue
+		}
+		t, ok := CtxTime(action.Content, action.Timestamp)
+		if !ok || !sameDay(day, t) {
+			continue
+		}
+		seen[action.Content] = true
+		entries = append(entries, TimelineEntry{Time: t, Content: action.Content})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries
+}
+
+func (td *TimelineDoor) Name() string                          { return "timeline" }
+func (td *TimelineDoor) Init(params map[string]string) tea.Cmd { return nil }
+
+func (td *TimelineDoor) Update(msg tea.Msg) (Door, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok && td.active {
+		switch msg.String() {
+		case "left", "h", "p":
+			td.day, td.offset = td.Day().AddDate(0, 0, -1), 0
+		case "right", "l", "n":
+			td.day, td.offset = td.Day().AddDate(0, 0, 1), 0
+		case "t":
+			td.day, td.offset = time.Time{}, 0
+		case "up", "k":
+			td.offset = max(0, td.offset-1)
+		case "down", "j":
+			td.offset++
+		}
+	}
+	return td, nil
+}
+
+// clock formats a time on the timeline, e.g. " 9:05am"
+func clock(t time.Time) string {
+	return fmt.Sprintf("%7s", strings.ToLower(t.Format("3:04PM")))
+}
+
+// rows lays out the day: all-day events, then timed events and captures
+// by time, captures during an event marked beside it
+func (td *TimelineDoor) rows(width int) []string {
+	events := td.calendar.Day(td.Day())
+	entries := td.Entries()
+
+	var rows []string
+	var timed []calendar.Event
+	for _, e := range events {
+		if e.AllDay {
+			rows = append(rows, fmt.Sprintf("%7s %s", "all day", td.event.Render("▒ "+cells.Cut(e.Summary, max(8, width-10)))))
+			continue
+		}
+		timed = append(timed, e)
+	}
+
+	var open []calendar.Event // events started and not yet over
+	during := func(t time.Time) bool {
+		kept := open[:0]
+		for _, e := range open {
+			if e.During(t) {
+				kept = append(kept, e)
+			}
+		}
+		open = kept
+		return len(open) > 0
+	}
+	for i, j := 0, 0; i < len(timed) || j < len(entries); {
+		if i < len(timed) && (j == len(entries) || !entries[j].Time.Before(timed[i].Start)) {
+			e := timed[i]
+			i++
+			during(e.Start)
+			open = append(open, e)
+			span := fmt.Sprintf("until %s", strings.TrimSpace(clock(e.End)))
+			label := cells.Cut(e.Summary, max(8, width-cells.Width(span)-12))
+			rows = append(rows, clock(e.Start)+" "+td.event.Render("▌ "+label)+" "+td.dim.Render(span))
+			continue
+		}
+		entry := entries[j]
+		j++
+		marker := "  "
+		if during(entry.Time) {
+			marker = td.event.Render("│ ")
+		}
+		rows = append(rows, clock(entry.Time)+" "+marker+cells.Cut("ctx:: "+entry.Content, max(8, width-12)))
+	}
+	return rows
+}
+
+func (td *TimelineDoor) View(width, height int) string {
+	inner := max(20, cells.Inner(td.style, width))
+	day := td.Day()
+	rows := td.rows(inner)
+
+	var b strings.Builder
+	heading := fmt.Sprintf("🗓 Timeline — %s", day.Format("Mon Jan 2"))
+	if sameDay(day, td.now()) {
+		heading += " (today)"
+	}
+	b.WriteString(td.title.Render(heading))
+	b.WriteString("\n" + td.dim.Render("←/→: day · t: today · ↑/↓: scroll · Esc: close") + "\n\n")
+
+	switch {
+	case len(rows) == 0 && td.calendar.Len() == 0:
+		b.WriteString(td.dim.Render("  No ctx:: captures this day, and no calendar: set [calendar] ics or caldav"))
+	case len(rows) == 0:
+		b.WriteString(td.dim.Render("  No ctx:: captures or events this day"))
+	default:
+		visible := max(1, height-6)
+		td.offset = min(td.offset, max(0, len(rows)-visible))
+		rows = rows[td.offset:min(len(rows), td.offset+visible)]
+		b.WriteString(strings.Join(rows, "\n"))
+	}
+
+	return cells.Frame(td.style, width).Height(height - 2).MaxHeight(height).Render(b.String())
+}
+
+func (td *TimelineDoor) IsActive() bool { return td.active }
+func (td *TimelineDoor) Activate()      { td.active = true }
+func (td *TimelineDoor) Deactivate()    { td.active = false }
+func (td *TimelineDoor) GetState() map[string]interface{} {
+	if td.day.IsZero() {
+		return map[string]interface{}{}
+	}
+	return map[string]interface{}{"day": td.day.Format("2006-01-02")}
+}
+func (td *TimelineDoor) OnConsciousnessCapture(patterns []ConsciousnessPattern) {}
+
+func (td *TimelineDoor) SetState(state map[string]interface{}) {
+	if day, ok := state["day"].(string); ok {
+		if t, err := time.ParseInLocation("2006-01-02", day, time.Local); err == nil {
+			td.day = t
+		}
+	}
+}
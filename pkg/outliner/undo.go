@@ -0,0 +1,68 @@
+package outliner
+
+import (
+	"maps"
+	"slices"
+)
+
+// undoLimit caps how many structural edits Ctrl+Z can take back
+const undoLimit = 50
+
+// undoState is the outline as it was before a structural edit
+type undoState struct {
+	lines     []OutlineNode
+	cursor    int
+	cursorPos int
+}
+
+// saveUndo remembers the outline before a structural edit such as sorting
+// children, so Undo can put it back
+func (o *Outliner) saveUndo() {
+	lines := make([]OutlineNode, len(o.lines))
+	for i, node := range o.lines {
+		node.Metadata = maps.Clone(node.Metadata)
+		node.Links = slices.Clone(node.Links)
+		node.Backlinks = slices.Clone(node.Backlinks)
+		node.History = slices.Clone(node.History)
+		lines[i] = node
+	}
+	o.undo = append(o.undo, undoState{lines: lines, cursor: o.cursor, cursorPos: o.cursorPos})
+	if len(o.undo) > undoLimit {
+		o.undo = o.undo[len(o.undo)-undoLimit:]
+	}
+}
+
+// CanUndo reports whether there's a structural edit to take back
+func (o *Outliner) CanUndo() bool {
+	return len(o.undo) > 0
+}
+
+// Undo restores the outline from before the last structural edit; typing
+// isn't undone
+func (o *Outliner) Undo() bool {
+	if len(o.undo) == 0 {
+		return false
+	}
+	state := o.undo[len(o.undo)-1]
+	o.undo = o.undo[:len(o.undo)-1]
+
+	o.lines = state.lines
+	o.cursor, o.cursorPos = min(state.cursor, len(o.lines)-1), state.cursorPos
+	clear(o.linkRegistry)
+	for i := range o.lines {
+		o.refreshNodeLinks(i)
+	}
+	o.updateBacklinks()
+	o.structureChanged()
+	return true
+}
+
+// structureChanged refreshes what depends on the outline's shape after a
+// command rearranged nodes outside Update
+func (o *Outliner) structureChanged() {
+	o.syncMirrors(-1)
+	markChildren(o.lines)
+	o.markArchived()
+	o.refreshDiagnostics()
+	o.scrollToCursor()
+}
@@ -0,0 +1,271 @@
+package outliner
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+)
+
+// renderCacheSlack is how far the render cache may outgrow the outline
+// before it's dropped and rebuilt from the visible rows
+const renderCacheSlack = 1024
+
+// outlineRows is the number of outline rows the main panel shows: its
+// height less the border, padding, and line-count header; zen mode has
+// none of those
+func (o *Outliner) outlineRows() int {
+	if o.zen && o.imprintView == nil && o.editHistory == nil {
+		return max(1, o.height-o.zenPanelHeight())
+	}
+	height := o.height - 4 - o.bottomPanelHeight()
+	if o.narrow() {
+		height-- // the switcher row
+	} else if o.debugPanel.IsVisible() {
+		height -= o.debugHeight()
+	}
+	return max(1, height-1)
+}
+
+// singlePanelBelow is the width below which the outline and the debug
+// panel take turns on screen: rows wrap so much sooner that neither gets
+// enough of a shared height
+const singlePanelBelow = 80
+
+// narrow reports whether the outline and the debug panel take turns under
+// a switcher row, alt+l moving between them
+func (o *Outliner) narrow() bool {
+	return o.width > 0 && o.width < singlePanelBelow && o.debugPanel.IsVisible()
+}
+
+// renderPanelSwitcher renders the row naming the panel a narrow terminal
+// shows and how to reach the other
+func (o *Outliner) renderPanelSwitcher() string {
+	active := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("62"))
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	outlineName, debugName := i18n.T("pane.outline"), i18n.T("pane.debug")
+	outline, debug := active.Render("["+outlineName+"]"), dim.Render(" "+debugName+" ")
+	if o.debugPanel.Focused() {
+		outline, debug = dim.Render(" "+outlineName+" "), active.Render("["+debugName+"]")
+	}
+	return cells.Cut(" "+outline+dim.Render("›")+debug+dim.Render("  alt+l"), o.width)
+}
+
+// Resizing the debug panel keeps its share of the height within these, and
+// moves it debugResizeStep at a time
+const (
+	minDebugRatio   = 0.15
+	maxDebugRatio   = 0.7
+	debugResizeStep = 0.05
+)
+
+// debugHeight is the rows the debug panel takes: a third of the outliner,
+// or the share it was resized to
+func (o *Outliner) debugHeight() int {
+	if o.debugRatio == 0 {
+		return o.height / 3
+	}
+	return int(float64(o.height) * o.debugRatio)
+}
+
+// SetDebugRatio sets the debug panel's share of the height, e.g. one
+// restored from the last session; 0 is the default third
+func (o *Outliner) SetDebugRatio(ratio float64) {
+	if ratio != 0 {
+		ratio = math.Max(minDebugRatio, math.Min(maxDebugRatio, ratio))
+	}
+	o.debugRatio = ratio
+	o.scrollToCursor()
+}
+
+// DebugRatio is the debug panel's share of the height, 0 until resized
+func (o *Outliner) DebugRatio() float64 {
+	return o.debugRatio
+}
+
+// resizeDebug grows the debug panel a step, or shrinks it
+func (o *Outliner) resizeDebug(grow bool) {
+	ratio := o.debugRatio
+	if ratio == 0 {
+		ratio = 1.0 / 3
+	}
+	if grow {
+		ratio += debugResizeStep
+	} else {
+		ratio -= debugResizeStep
+	}
+	o.SetDebugRatio(ratio)
+}
+
+// nextVisible returns the first visible node after i, skipping the
+// children of a collapsed node, or len(o.lines)
+func (o *Outliner) nextVisible(i int) int {
+	if o.reviewMode {
+		j := i + 1
+		for j < len(o.lines) && o.isHidden(j) {
+			j++
+		}
+		return j
+	}
+	if !o.lines[i].Collapsed {
+		return i + 1
+	}
+	level := o.lines[i].Level
+	j := i + 1
+	for j < len(o.lines) && o.lines[j].Level > level {
+		j++
+	}
+	return j
+}
+
+// prevVisible returns the last visible node before i, or -1
+func (o *Outliner) prevVisible(i int) int {
+	for j := i - 1; j >= 0; j-- {
+		if !o.isHidden(j) {
+			return j
+		}
+	}
+	return -1
+}
+
+// visibleRows returns the indexes of the nodes in the viewport
+func (o *Outliner) visibleRows() []int {
+	rows := make([]int, 0, o.outlineRows())
+	for i := o.offset; i < len(o.lines) && len(rows) < cap(rows); i = o.nextVisible(i) {
+		// The first row can be one the review filter hides
+		if !o.isHidden(i) {
+			rows = append(rows, i)
+		}
+	}
+	return rows
+}
+
+// SetTypewriter keeps the cursor on the middle row as it moves down, so
+// writing doesn't happen at the bottom edge
+func (o *Outliner) SetTypewriter(enabled bool) {
+	o.typewriter = enabled
+	o.scrollToCursor()
+}
+
+// SetScrollMargin keeps margin rows between the cursor and the top and
+// bottom of the viewport
+func (o *Outliner) SetScrollMargin(margin int) {
+	o.scrollMargin = max(0, margin)
+	o.scrollToCursor()
+}
+
+// scrollToCursor moves the viewport just enough to keep the cursor in it,
+// scrollMargin rows from either edge; in typewriter mode the cursor stays
+// on the middle row once it gets there
+func (o *Outliner) scrollToCursor() {
+	if o.offset >= len(o.lines) {
+		o.offset = len(o.lines) - 1
+	}
+	// Folding an ancestor can hide the first row
+	for o.offset > 0 && o.isHidden(o.offset) {
+		o.offset--
+	}
+
+	rows := o.outlineRows()
+	if o.typewriter {
+		o.offset = o.rowsAbove(max(0, o.cursor), rows/2)
+		return
+	}
+	margin := min(o.scrollMargin, (rows-1)/2)
+	if o.cursor <= o.offset {
+		o.offset = o.rowsAbove(max(0, o.cursor), margin)
+		return
+	}
+	if o.cursor-o.offset >= margin && o.cursor-o.offset < rows-margin {
+		return // fewer nodes than rows between them, hidden or not
+	}
+
+	seen := 0 // rows from the first to the cursor's, up to a screenful
+	for i := o.offset; i < len(o.lines) && i <= o.cursor && seen <= rows; i = o.nextVisible(i) {
+		seen++
+	}
+	switch {
+	case seen-1 < margin:
+		o.offset = o.rowsAbove(o.cursor, margin)
+	case seen > rows-margin:
+		// Put the cursor on the last row the margin allows
+		o.offset = o.rowsAbove(o.cursor, rows-1-margin)
+	}
+}
+
+// rowsAbove returns the node n visible rows above node i, or the first
+// visible node when there are fewer
+func (o *Outliner) rowsAbove(i, n int) int {
+	for ; n > 0; n-- {
+		prev := o.prevVisible(i)
+		if prev < 0 {
+			break
+		}
+		i = prev
+	}
+	return i
+}
+
+// renderKey identifies everything a cached row depends on
+func (o *Outliner) renderKey(i int) string {
+	line := o.lines[i]
+	kind := string(line.Kind)
+	if line.Kind == KindCode {
+		kind += ":" + o.codeLanguage(i)
+	}
+	if isTableRow(line) {
+		kind += fmt.Sprint(o.tableWidths(i))
+	}
+	return fmt.Sprintf("%d\x00%s\x00%t\x00%t\x00%t\x00%t\x00%s\x00%d\x00%s\x00%s\x00%s",
+		line.Level, kind, line.HasChildren, line.Collapsed, line.Captured, o.archived[line.ID], line.Mirror, o.MirrorCount(line.ID), o.lineSeverity(i), o.imprintOf[line.ID], line.Text)
+}
+
+// cachedRow returns the rendered row for node i, rendering it on a miss.
+// Detail mode shows live ref counts and timestamps, so it's never cached.
+func (o *Outliner) cachedRow(i int, render func() string) string {
+	if o.detailMode || o.renderCache == nil {
+		return render()
+	}
+	key := o.renderKey(i)
+	if row, ok := o.renderCache[key]; ok {
+		return row
+	}
+	if len(o.renderCache) > len(o.lines)+renderCacheSlack {
+		clear(o.renderCache)
+	}
+	row := render()
+	o.renderCache[key] = row
+	return row
+}
+
+// ClearRenderCache drops cached rows, e.g. after the link index changes
+// which [[links]] resolve
+func (o *Outliner) ClearRenderCache() {
+	clear(o.renderCache)
+}
+
+// insertNodes inserts nodes before index at. The tail still moves, but in
+// place: nothing is allocated while the slice has capacity, where the
+// splice it replaced copied the tail into a new slice every time.
+func (o *Outliner) insertNodes(at int, nodes ...OutlineNode) {
+	o.lines = slices.Insert(o.lines, at, nodes...)
+}
+
+// deleteNodes removes nodes [from, to), shifting the tail down in place,
+// and drops their links from the link registry and the backlinks of the
+// nodes they linked to
+func (o *Outliner) deleteNodes(from, to int) {
+	var unlinked []string
+	for _, node := range o.lines[from:to] {
+		for _, link := range node.Links {
+			o.removeLinkFromRegistry(link, node.ID)
+		}
+		unlinked = append(unlinked, o.backlinks.drop(node.ID)...)
+	}
+	o.lines = slices.Delete(o.lines, from, to)
+	o.refreshBacklinks(unlinked)
+}
@@ -0,0 +1,115 @@
+package outliner
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// defaultZenWidth is the zen mode column width when none is configured
+const defaultZenWidth = 80
+
+// zenDimStyle renders the rows outside the current subtree in zen mode
+var zenDimStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+// IsZen reports whether the distraction-free writing mode is on
+func (o *Outliner) IsZen() bool {
+	return o.zen
+}
+
+// SetZenWidth sets the width of the zen mode column; 0 keeps the default
+func (o *Outliner) SetZenWidth(width int) {
+	if width <= 0 {
+		width = defaultZenWidth
+	}
+	o.zenWidth = width
+}
+
+// toggleZen switches zen mode, hiding the debug panel while it's on and
+// bringing it back after
+func (o *Outliner) toggleZen() {
+	o.zen = !o.zen
+	if o.zen {
+		o.zenDebug = o.debugPanel.IsVisible()
+		o.debugPanel.Blur()
+		o.debugPanel.SetVisible(false)
+	} else {
+		o.debugPanel.SetVisible(o.zenDebug)
+	}
+	o.scrollToCursor()
+}
+
+// zenPanelHeight is the height of the form shown under the outline in zen
+// mode; the diagnostics panel stays hidden
+func (o *Outliner) zenPanelHeight() int {
+	if o.capturePanel != nil || o.metaEditor != nil {
+		return o.bottomPanelHeight()
+	}
+	return 0
+}
+
+// currentSubtree returns the range of nodes under the cursor's top-level
+// ancestor, the part of the outline zen mode leaves undimmed
+func (o *Outliner) currentSubtree() (start, end int) {
+	start = min(o.cursor, len(o.lines)-1)
+	for start > 0 && o.lines[start].Level > 0 {
+		start--
+	}
+	end = start + 1
+	for end < len(o.lines) && o.lines[end].Level > o.lines[start].Level {
+		end++
+	}
+	return start, end
+}
+
+// renderZen renders the outline as a centered column without borders,
+// header, gutter or tree lines
+func (o *Outliner) renderZen() string {
+	width := min(o.zenWidth, o.width)
+	if width <= 0 {
+		width = min(defaultZenWidth, o.width)
+	}
+	start, end := o.currentSubtree()
+
+	var content strings.Builder
+	for rendered, i := range o.visibleRows() {
+		if rendered > 0 {
+			content.WriteString("\n")
+		}
+		content.WriteString(o.renderZenRow(i, i == o.cursor && o.focused, i < start || i >= end))
+	}
+
+	column := lipgloss.NewStyle().Width(width).Height(o.outlineRows()).Render(content.String())
+	view := lipgloss.PlaceHorizontal(o.width, lipgloss.Center, column)
+	switch {
+	case o.capturePanel != nil:
+		view += "\n" + o.renderCapturePanel(o.width)
+	case o.metaEditor != nil:
+		view += "\n" + o.renderMetadataPanel(o.width)
+	}
+	return view
+}
+
+// renderZenRow renders node i indented by level, with its bullet but no
+// capture glyphs; dimmed rows are plain text
+func (o *Outliner) renderZenRow(i int, isCurrentLine, dimmed bool) string {
+	line := o.lines[i]
+	indent := strings.Repeat("  ", line.Level)
+	bullet := nodeBullet(line) + " "
+	if line.Kind != KindBullet && !line.HasChildren {
+		bullet = "  "
+	}
+
+	switch {
+	case isCurrentLine:
+		pos := min(o.cursorPos, len(line.Text))
+		return indent + o.bulletStyle.Render(bullet) + line.Text[:pos] + o.cursorStyle.Render("│") + line.Text[pos:]
+	case dimmed:
+		return zenDimStyle.Render(indent + bullet + line.Text)
+	case line.Kind == KindCode:
+		return indent + bullet + o.renderCode(i)
+	case isTableRow(line):
+		return indent + bullet + o.renderTableRow(i)
+	}
+	return indent + o.bulletStyle.Render(bullet) + o.renderNodeContent(line)
+}
@@ -0,0 +1,213 @@
+// Package recall schedules spaced-repetition reviews of captured
+// highlights with a lite SM-2: a card's interval grows by its ease each
+// time it's remembered and starts over when it's forgotten
+package recall
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/encrypt"
+)
+
+const (
+	startEase = 2.5 // a new card's ease
+	minEase   = 1.3 // the lowest ease hard and again reviews bring a card to
+)
+
+// Grade is how well a card was remembered
+type Grade int
+
+const (
+	Again Grade = iota // forgotten: it starts over tomorrow
+	Hard               // remembered with effort: a little longer, less ease
+	Good               // remembered: the interval grows by the ease
+	Easy               // remembered at once: longer still, more ease
+)
+
+var gradeNames = [...]string{"again", "hard", "good", "easy"}
+
+// Grades lists every grade, worst first
+var Grades = []Grade{Again, Hard, Good, Easy}
+
+func (g Grade) String() string {
+	if g < 0 || int(g) >= len(gradeNames) {
+		return fmt.Sprintf("Grade(%d)", int(g))
+	}
+	return gradeNames[g]
+}
+
+// Card is a captured pattern and when it's next reviewed
+type Card struct {
+	Type     string    `json:"type"`
+	Content  string    `json:"content"`
+	Source   string    `json:"source,omitempty"` // where it's from: the book for a Readwise highlight, else the file
+	Added    time.Time `json:"added"`
+	Due      time.Time `json:"due"`
+	Interval int       `json:"interval,omitempty"` // days from the last review to Due
+	Ease     float64   `json:"ease"`
+	Reps     int       `json:"reps,omitempty"`   // reviews since it was last forgotten
+	Lapses   int       `json:"lapses,omitempty"` // times it was forgotten
+	Reviewed time.Time `json:"reviewed,omitempty"`
+}
+
+// Key identifies a card by its pattern, so the same text dispatched again,
+// as every save does, stays one card
+func Key(patternType, content string) string {
+	return patternType + ":: " + strings.Join(strings.Fields(content), " ")
+}
+
+// Key is the card's key in its deck
+func (c Card) Key() string {
+	return Key(c.Type, c.Content)
+}
+
+// Schedule returns the card after a review graded g at now. Due falls at
+// the start of a day, so a card is due all of that day.
+func Schedule(c Card, g Grade, now time.Time) Card {
+	switch g {
+	case Again:
+		c.Interval, c.Reps = 1, 0
+		c.Lapses++
+		c.Ease -= 0.2
+	case Hard:
+		c.Interval = max(1, int(math.Round(float64(c.Interval)*1.2)))
+		c.Ease -= 0.15
+		c.Reps++
+	case Good:
+		c.Interval = goodInterval(c)
+		c.Reps++
+	case Easy:
+		c.Interval = max(4, int(math.Round(float64(goodInterval(c))*1.3)))
+		c.Ease += 0.15
+		c.Reps++
+	}
+	c.Ease = max(minEase, c.Ease)
+	c.Reviewed = now
+	c.Due = startOfDay(now).AddDate(0, 0, c.Interval)
+	return c
+}
+
+// goodInterval is how many days a good review waits: one, then six, then
+// the last interval times the ease
+func goodInterval(c Card) int {
+	switch c.Reps {
+	case 0:
+		return 1
+	case 1:
+		return 6
+	}
+	return max(c.Interval+1, int(math.Round(float64(c.Interval)*c.Ease)))
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Deck is every card and its schedule, kept in a JSON file
+type Deck struct {
+	path string
+
+	Cards map[string]*Card `json:"cards"`
+}
+
+// Load reads the deck at path, opening it if it's sealed; a missing file
+// is an empty deck
+func Load(path string) (*Deck, error) {
+	d := &Deck{path: path, Cards: make(map[string]*Card)}
+
+	data, err := encrypt.ReadFile(path)
+	if os.IsNotExist(err) {
+		return d, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read recall deck: %w", err)
+	}
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, fmt.Errorf("parse recall deck %s: %w", path, err)
+	}
+	if d.Cards == nil {
+		d.Cards = make(map[string]*Card)
+	}
+	return d, nil
+}
+
+// Path returns the deck file location
+func (d *Deck) Path() string {
+	return d.path
+}
+
+// Save writes the deck back to disk, sealed when encryption is on
+func (d *Deck) Save() error {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(d.path), 0755); err != nil {
+		return err
+	}
+	// Write-then-rename so a crash never leaves a torn deck
+	tmp := d.path + ".tmp"
+	if err := encrypt.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write recall deck: %w", err)
+	}
+	return os.Rename(tmp, d.path)
+}
+
+// Add makes a card for a pattern captured at captured, due at once, and
+// reports whether it's new; a card the deck has is left as it is
+func (d *Deck) Add(patternType, content, source string, captured time.Time) bool {
+	content = strings.Join(strings.Fields(content), " ")
+	key := Key(patternType, content)
+	if _, ok := d.Cards[key]; ok || content == "" {
+		return false
+	}
+	d.Cards[key] = &Card{Type: patternType, Content: content, Source: source, Added: captured, Due: captured, Ease: startEase}
+	return true
+}
+
+// Due lists the cards due by now, the longest due first, so new cards come
+// in the order they were captured
+func (d *Deck) Due(now time.Time) []Card {
+	var due []Card
+	for _, c := range d.Cards {
+		if !c.Due.After(now) {
+			due = append(due, *c)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].Due.Equal(due[j].Due) {
+			return due[i].Due.Before(due[j].Due)
+		}
+		return due[i].Key() < due[j].Key()
+	})
+	return due
+}
+
+// Next is when the first card not due by now falls due, zero when there's
+// none
+func (d *Deck) Next(now time.Time) time.Time {
+	var next time.Time
+	for _, c := range d.Cards {
+		if c.Due.After(now) && (next.IsZero() || c.Due.Before(next)) {
+			next = c.Due
+		}
+	}
+	return next
+}
+
+// Grade schedules the card with key after a review graded g at now
+func (d *Deck) Grade(key string, g Grade, now time.Time) (Card, error) {
+	c, ok := d.Cards[key]
+	if !ok {
+		return Card{}, fmt.Errorf("no card %q to review", key)
+	}
+	*c = Schedule(*c, g, now)
+	return *c, nil
+}
@@ -0,0 +1,134 @@
+package recall
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/encrypt"
+)
+
+func TestSchedule(t *testing.T) {
+	now := time.Date(2025, 3, 10, 15, 30, 0, 0, time.UTC)
+	c := Card{Type: "eureka", Content: "the cache key was the bug", Ease: startEase}
+
+	// Good reviews wait a day, then six, then grow by the ease
+	var intervals []int
+	for i := 0; i < 4; i++ {
+		c = Schedule(c, Good, now)
+		intervals = append(intervals, c.Interval)
+	}
+	if want := []int{1, 6, 15, 38}; !slices.Equal(intervals, want) {
+		t.Errorf("good intervals = %v, want %v", intervals, want)
+	}
+	if want := time.Date(2025, 4, 17, 0, 0, 0, 0, time.UTC); !c.Due.Equal(want) {
+		t.Errorf("due %v, want the start of %v", c.Due, want)
+	}
+
+	forgotten := Schedule(c, Again, now)
+	if forgotten.Interval != 1 || forgotten.Reps != 0 || forgotten.Lapses != 1 || !near(forgotten.Ease, 2.3) {
+		t.Errorf("again = %+v", forgotten)
+	}
+	if again := Schedule(Schedule(forgotten, Good, now), Good, now); again.Interval != 6 {
+		t.Errorf("relearning interval = %d, want 6", again.Interval)
+	}
+
+	if hard := Schedule(c, Hard, now); hard.Interval != 46 || !near(hard.Ease, 2.35) {
+		t.Errorf("hard = %+v", hard)
+	}
+	if easy := Schedule(Card{Ease: startEase}, Easy, now); easy.Interval != 4 || !near(easy.Ease, 2.65) {
+		t.Errorf("easy on a new card = %+v", easy)
+	}
+
+	floor := Card{Ease: minEase}
+	if floor = Schedule(floor, Again, now); floor.Ease != minEase {
+		t.Errorf("ease fell to %v", floor.Ease)
+	}
+}
+
+func TestDeck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recall", "recall.json")
+	deck, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	captured := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	now := captured.Add(48 * time.Hour)
+	if !deck.Add("eureka", "the cache   key was the bug", "notes.md", captured) {
+		t.Fatal("first capture wasn't a new card")
+	}
+	if deck.Add("eureka", "the cache key was the bug", "other.md", now) || deck.Add("highlight", " ", "", now) {
+		t.Error("a repeat or empty capture made a card")
+	}
+	deck.Add("highlight", "Attention is the rarest form of generosity", "Letters — Weil", captured.Add(time.Hour))
+
+	due := deck.Due(now)
+	if len(due) != 2 || due[0].Type != "eureka" || due[0].Source != "notes.md" {
+		t.Fatalf("due = %+v", due)
+	}
+	if _, err := deck.Grade(due[0].Key(), Good, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := deck.Grade("ctx:: nothing", Good, now); err == nil {
+		t.Error("graded a card that isn't in the deck")
+	}
+	if err := deck.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if due := loaded.Due(now); len(due) != 1 || due[0].Type != "highlight" {
+		t.Errorf("due after grading = %+v", due)
+	}
+	if next := loaded.Next(now); !next.Equal(time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("next due %v", next)
+	}
+}
+
+// near compares eases, which add and take away tenths
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestSealedDeck(t *testing.T) {
+	k, err := encrypt.Unlock(filepath.Join(t.TempDir(), "identity.age"), []byte("pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypt.Use(k)
+	defer encrypt.Use(nil)
+
+	path := filepath.Join(t.TempDir(), "recall.json")
+	deck, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deck.Add("eureka", "the cache key was the bug", "notes.md", time.Now())
+	if err := deck.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !encrypt.Sealed(data) || bytes.Contains(data, []byte("cache key")) {
+		t.Fatalf("deck on disk isn't sealed:\n%s", data)
+	}
+	if loaded, err := Load(path); err != nil || len(loaded.Cards) != 1 {
+		t.Errorf("sealed deck loaded %+v, %v", loaded, err)
+	}
+
+	// Without the key, the deck is locked rather than read as empty
+	encrypt.Use(nil)
+	if _, err := Load(path); !errors.Is(err, encrypt.ErrLocked) {
+		t.Errorf("Load without the key: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package scenario
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+)
+
+// Transcript frame size
+const (
+	frameWidth  = 80
+	frameHeight = 24
+)
+
+// Result is the outcome of a run
+type Result struct {
+	Scenario   Scenario
+	Content    string // the outline after the last step
+	Actions    []outliner.DispatchAction
+	Failures   []string
+	Transcript string
+}
+
+// Passed reports whether every expectation held
+func (r Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Run plays a scenario against a fresh outliner with evna dispatch off,
+// then captures as a save would and checks the expectations
+func Run(s Scenario) Result {
+	o := outliner.New()
+	o.Evna().SetEnabled(false)
+	o.SetDebugVisible(false)
+	o.SetSize(frameWidth, frameHeight)
+	o.Focus()
+	o.SetContent(s.Content)
+
+	var transcript strings.Builder
+	fmt.Fprintf(&transcript, "# %s\n", s.Name)
+	if s.Description != "" {
+		fmt.Fprintf(&transcript, "%s\n", strings.TrimSpace(s.Description))
+	}
+	transcript.WriteString("\n")
+
+	for _, step := range s.Steps {
+		if step.Type != "" {
+			fmt.Fprintf(&transcript, "> type %q\n", step.Type)
+			for _, r := range step.Type {
+				msg, _ := KeyMsg(string(r))
+				o, _ = o.Update(msg)
+			}
+			continue
+		}
+		fmt.Fprintf(&transcript, "> %s\n", step.Key)
+		msg, _ := KeyMsg(step.Key) // checked by Parse
+		o, _ = o.Update(msg)
+	}
+	if len(s.Steps) > 0 {
+		transcript.WriteString("\n")
+	}
+
+	o.TriggerConsciousnessCapture()
+	dispatch := o.Dispatch()
+
+	result := Result{
+		Scenario: s,
+		Content:  o.GetContent(),
+		Actions:  dispatch.GetActions(),
+	}
+	result.Failures = check(s.Expect, result, dispatch)
+
+	transcript.WriteString(o.View())
+	transcript.WriteString("\n\nDispatches:\n")
+	for _, action := range result.Actions {
+		fmt.Fprintf(&transcript, "  %-10s → %-14s %s\n", action.PatternType, action.Imprint, action.Content)
+	}
+
+	reducers := dispatch.GetReducers()
+	if len(reducers) > 0 {
+		names := make([]string, 0, len(reducers))
+		for name := range reducers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		transcript.WriteString("\nReducers:\n")
+		for _, name := range names {
+			fmt.Fprintf(&transcript, "  %s: %d\n", name, len(dispatch.GetReducerOutput(name)))
+		}
+	}
+
+	transcript.WriteString("\n")
+	if result.Passed() {
+		transcript.WriteString("PASS\n")
+	} else {
+		for _, failure := range result.Failures {
+			fmt.Fprintf(&transcript, "FAIL: %s\n", failure)
+		}
+	}
+	result.Transcript = transcript.String()
+	return result
+}
+
+// check compares a run with its expectations
+func check(expect Expect, result Result, dispatch *outliner.FloatDispatchSystem) []string {
+	var failures []string
+
+	for _, want := range expect.Dispatches {
+		if !dispatched(result.Actions, want) {
+			desc := want.Type + ":: " + want.Content
+			if want.Imprint != "" {
+				desc += " → " + want.Imprint
+			}
+			failures = append(failures, "no dispatch matching "+desc)
+		}
+	}
+
+	names := make([]string, 0, len(expect.Reducers))
+	for name := range expect.Reducers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := dispatch.GetReducers()[name]; !ok {
+			failures = append(failures, fmt.Sprintf("no reducer %q", name))
+			continue
+		}
+		if got := len(dispatch.GetReducerOutput(name)); got != expect.Reducers[name] {
+			failures = append(failures, fmt.Sprintf("reducer %q collected %d actions, want %d", name, got, expect.Reducers[name]))
+		}
+	}
+
+	if expect.Content != "" && strings.TrimSpace(result.Content) != strings.TrimSpace(expect.Content) {
+		failures = append(failures, fmt.Sprintf("content is\n%s", result.Content))
+	}
+	return failures
+}
+
+// dispatched reports whether any action matches want
+func dispatched(actions []outliner.DispatchAction, want ExpectedDispatch) bool {
+	for _, action := range actions {
+		if action.PatternType != want.Type || !strings.Contains(action.Content, want.Content) {
+			continue
+		}
+		if want.Imprint != "" && action.Imprint != want.Imprint {
+			continue
+		}
+		return true
+	}
+	return false
+}
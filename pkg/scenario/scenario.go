@@ -0,0 +1,176 @@
+package scenario
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"gopkg.in/yaml.v3"
+)
+
+// builtins are the scenarios shipped with the binary
+//
+//go:embed testdata/*.yaml
+var builtins embed.FS
+
+// Scenario is a scripted outliner session: the outline to start from, the
+// keys to press, and what the resulting capture should dispatch
+type Scenario struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Content     string `yaml:"content"`
+	Steps       []Step `yaml:"steps"`
+	Expect      Expect `yaml:"expect"`
+}
+
+// Step is one key press, or text typed a rune at a time
+type Step struct {
+	Key  string `yaml:"key,omitempty"`  // as Bubble Tea names it: enter, tab, ctrl+s, alt+m
+	Type string `yaml:"type,omitempty"` // text to type
+}
+
+// Expect lists what a run must produce; empty fields aren't checked
+type Expect struct {
+	Dispatches []ExpectedDispatch `yaml:"dispatches"`
+	Reducers   map[string]int     `yaml:"reducers"` // reducer name -> actions collected
+	Content    string             `yaml:"content"`  // the final outline, as GetContent writes it
+}
+
+// ExpectedDispatch matches an action by type, a substring of its content,
+// and its imprint when given
+type ExpectedDispatch struct {
+	Type    string `yaml:"type"`
+	Content string `yaml:"content"`
+	Imprint string `yaml:"imprint,omitempty"`
+}
+
+// ErrNotFound is returned by Find for a name that is neither a file, a
+// scenario in the directory, nor a built-in
+var ErrNotFound = errors.New("scenario not found")
+
+// Parse reads a scenario definition
+func Parse(data []byte) (Scenario, error) {
+	var s Scenario
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return Scenario{}, err
+	}
+	for i, step := range s.Steps {
+		if (step.Key == "") == (step.Type == "") {
+			return Scenario{}, fmt.Errorf("step %d: set exactly one of key or type", i+1)
+		}
+		if step.Key != "" {
+			if _, err := KeyMsg(step.Key); err != nil {
+				return Scenario{}, fmt.Errorf("step %d: %w", i+1, err)
+			}
+		}
+	}
+	return s, nil
+}
+
+// Load reads a scenario file; the name defaults to the file's base name
+func Load(path string) (Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Scenario{}, err
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return Scenario{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if s.Name == "" {
+		s.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return s, nil
+}
+
+// Find resolves nameOrPath to a scenario file, then <dir>/<name>.yaml, then
+// a built-in
+func Find(nameOrPath, dir string) (Scenario, error) {
+	if info, err := os.Stat(nameOrPath); err == nil && !info.IsDir() {
+		return Load(nameOrPath)
+	}
+	if dir != "" {
+		path := filepath.Join(dir, nameOrPath+".yaml")
+		if _, err := os.Stat(path); err == nil {
+			return Load(path)
+		}
+	}
+	if s, ok := Builtin(nameOrPath); ok {
+		return s, nil
+	}
+	return Scenario{}, fmt.Errorf("%w: %s", ErrNotFound, nameOrPath)
+}
+
+// Builtin returns the built-in scenario with the given name
+func Builtin(name string) (Scenario, bool) {
+	data, err := builtins.ReadFile("testdata/" + name + ".yaml")
+	if err != nil {
+		return Scenario{}, false
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return Scenario{}, false
+	}
+	if s.Name == "" {
+		s.Name = name
+	}
+	return s, true
+}
+
+// Names lists the built-in scenarios and those in dir, sorted
+func Names(dir string) []string {
+	seen := map[string]bool{}
+	collect := func(fsys fs.FS, pattern string) {
+		matches, _ := fs.Glob(fsys, pattern)
+		for _, m := range matches {
+			seen[strings.TrimSuffix(filepath.Base(m), ".yaml")] = true
+		}
+	}
+	collect(builtins, "testdata/*.yaml")
+	if dir != "" {
+		collect(os.DirFS(dir), "*.yaml")
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// keyTypes maps Bubble Tea's key names back to key types
+var keyTypes = func() map[string]tea.KeyType {
+	types := map[string]tea.KeyType{}
+	for k := tea.KeyType(-128); k < 128; k++ {
+		if name := (tea.Key{Type: k}).String(); name != "" && k != tea.KeyRunes {
+			types[name] = k
+		}
+	}
+	return types
+}()
+
+// KeyMsg builds the message for a key name: "enter", "shift+tab",
+// "ctrl+s", a single character, or "alt+" and a character
+func KeyMsg(name string) (tea.KeyMsg, error) {
+	if k, ok := keyTypes[name]; ok {
+		return tea.KeyMsg{Type: k}, nil
+	}
+	alt := false
+	if rest, ok := strings.CutPrefix(name, "alt+"); ok {
+		alt, name = true, rest
+		if k, ok := keyTypes[name]; ok {
+			return tea.KeyMsg{Type: k, Alt: true}, nil
+		}
+	}
+	if runes := []rune(name); len(runes) == 1 {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: runes, Alt: alt}, nil
+	}
+	return tea.KeyMsg{}, fmt.Errorf("unknown key %q", name)
+}
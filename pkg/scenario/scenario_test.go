@@ -0,0 +1,48 @@
+package scenario
+
+import (
+	"testing"
+)
+
+func TestBuiltinScenarios(t *testing.T) {
+	for _, name := range Names("") {
+		t.Run(name, func(t *testing.T) {
+			s, ok := Builtin(name)
+			if !ok {
+				t.Fatal("doesn't parse")
+			}
+			if result := Run(s); !result.Passed() {
+				t.Fatalf("%v\n\n%s", result.Failures, result.Transcript)
+			}
+		})
+	}
+}
+
+func TestKeyMsg(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"enter", "enter"},
+		{"shift+tab", "shift+tab"},
+		{"ctrl+s", "ctrl+s"},
+		{"ctrl+up", "ctrl+up"},
+		{"x", "x"},
+		{"alt+m", "alt+m"},
+		{"alt+enter", "alt+enter"},
+	}
+	for _, tt := range tests {
+		msg, err := KeyMsg(tt.name)
+		if err != nil {
+			t.Errorf("KeyMsg(%q): %v", tt.name, err)
+			continue
+		}
+		if got := msg.String(); got != tt.want {
+			t.Errorf("KeyMsg(%q) = %q", tt.name, got)
+		}
+	}
+
+	if _, err := KeyMsg("hyper+x"); err == nil {
+		t.Error("unknown key accepted")
+	}
+}
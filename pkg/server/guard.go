@@ -0,0 +1,103 @@
+package server
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SetAddr sets the address the server listens on, so requests naming
+// another host, as DNS rebinding does, are refused
+func (s *Server) SetAddr(addr string) {
+	s.addr = addr
+}
+
+// AllowOrigins lets pages and extensions from these origins (like
+// "chrome-extension://<id>") call the API; the server's own origin always
+// can
+func (s *Server) AllowOrigins(origins ...string) {
+	for _, origin := range origins {
+		s.origins[strings.TrimSuffix(origin, "/")] = true
+	}
+}
+
+// guard refuses requests a browser could be tricked into sending: those
+// for another host, from a foreign origin, or posting anything but JSON,
+// which forms and "simple" cross-origin fetches can't
+func (s *Server) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.allowedHost(r.Host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %q isn't this server", r.Host))
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" && !s.allowedOrigin(origin) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("origin %q isn't allowed", origin))
+			return
+		}
+		if r.Method == http.MethodPost {
+			if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("content type must be application/json"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedHost reports whether a Host header names this server: localhost
+// or an IP address, or the listen host, on the listen port. A rebinding
+// attack needs a domain of its own, so these are all it can't forge.
+func (s *Server) allowedHost(hostport string) bool {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host, port = hostport, ""
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	if s.addr == "" {
+		return host == "localhost" || isLoopback(host)
+	}
+
+	listenHost, listenPort, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return false
+	}
+	if listenPort != "" && listenPort != "0" && port != listenPort {
+		return false
+	}
+	switch {
+	case host == "localhost" || isLoopback(host):
+		return true
+	case strings.EqualFold(host, strings.Trim(listenHost, "[]")):
+		return true
+	case listenHost == "" || isUnspecified(listenHost):
+		// Listening everywhere: any of the machine's addresses will do
+		return net.ParseIP(host) != nil
+	}
+	return false
+}
+
+// allowedOrigin reports whether a browser's Origin header is one of the
+// allowed origins or the server itself
+func (s *Server) allowedOrigin(origin string) bool {
+	if s.origins[strings.TrimSuffix(origin, "/")] {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	return s.allowedHost(u.Host)
+}
+
+func isLoopback(host string) bool {
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func isUnspecified(host string) bool {
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsUnspecified()
+}
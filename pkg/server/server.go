@@ -0,0 +1,475 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evanschultz/float-rw-client/pkg/dispatchlog"
+	"github.com/evanschultz/float-rw-client/pkg/events"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+)
+
+// maxBodyBytes caps request bodies; fragments are small
+const maxBodyBytes = 1 << 20
+
+// Server exposes a FloatDispatchSystem over local HTTP/JSON so other FLOAT
+// tools can share one set of reducers and selectors
+type Server struct {
+	mu       sync.Mutex
+	parser   *outliner.Parser
+	dispatch *outliner.FloatDispatchSystem
+	evna     *outliner.EvnaDispatcher
+	log      *dispatchlog.Log // optional; nil keeps actions in memory only
+	hub      *events.Hub
+	addr     string          // listen address Host headers must name; any loopback host when unset
+	origins  map[string]bool // browser origins allowed besides the server's own
+
+	// reducers that collected the action being dispatched, from the bus
+	collected []string
+
+	logError func(error)
+}
+
+// New creates a server around an existing dispatch system; log may be nil
+func New(dispatch *outliner.FloatDispatchSystem, evna *outliner.EvnaDispatcher, log *dispatchlog.Log) *Server {
+	s := &Server{
+		parser:   outliner.NewParser(),
+		dispatch: dispatch,
+		evna:     evna,
+		log:      log,
+		hub:      events.NewHub(log),
+		origins:  make(map[string]bool),
+		logError: func(error) {},
+	}
+	evna.SetErrorLogger(func(msgType, content string) {
+		s.logError(fmt.Errorf("%s: %s", msgType, content))
+	})
+	outliner.Subscribe(dispatch.Bus(), func(e outliner.ReducerCollected) {
+		s.collected = append(s.collected, e.Reducer)
+	})
+	return s
+}
+
+// SetErrorLogger sets where non-fatal errors (evna, log writes) are reported
+func (s *Server) SetErrorLogger(logError func(error)) {
+	s.logError = logError
+}
+
+// Handler returns the HTTP routes, behind the checks that keep browsers
+// from being used against them
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/dispatch", s.handleDispatch)
+	mux.HandleFunc("/webhook", s.handleWebhook)
+	mux.HandleFunc("/actions", s.handleActions)
+	mux.HandleFunc("/reducers", s.handleReducers)
+	mux.HandleFunc("/selectors", s.handleSelectors)
+	mux.Handle("/events", s.hub)
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	return s.guard(mux)
+}
+
+// dispatchRequest is the body of POST /dispatch. Without a type the content
+// is parsed for :: patterns, so a whole note can be submitted at once.
+type dispatchRequest struct {
+	Content string `json:"content"`
+	Type    string `json:"type,omitempty"`
+	Source  string `json:"source,omitempty"`
+}
+
+// webhookRequest accepts evna_capture payloads and plain {"text": ...}
+type webhookRequest struct {
+	Action     string `json:"action,omitempty"`
+	Text       string `json:"text"`
+	Collection string `json:"collection,omitempty"`
+	Source     string `json:"source,omitempty"`
+}
+
+// actionJSON is the wire shape of a DispatchAction
+type actionJSON struct {
+	ID          string            `json:"id"`
+	NodeID      string            `json:"node_id,omitempty"`
+	Content     string            `json:"content"`
+	PatternType string            `json:"type"`
+	Imprint     string            `json:"imprint"`
+	Sigil       string            `json:"sigil,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Timestamp   time.Time         `json:"timestamp"`
+	State       string            `json:"state"`
+}
+
+type reducerJSON struct {
+	Name    string       `json:"name"`
+	Query   string       `json:"query"`
+	Count   int          `json:"count"`
+	Global  bool         `json:"global,omitempty"` // defined in config, not by a document
+	Actions []actionJSON `json:"actions"`
+}
+
+type selectorJSON struct {
+	Name   string   `json:"name"`
+	Inputs []string `json:"inputs"`
+	Output string   `json:"output"`
+}
+
+func (s *Server) handleDispatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	var req dispatchRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("content is required"))
+		return
+	}
+	if req.Source == "" {
+		req.Source = "http"
+	}
+
+	var patterns []outliner.ConsciousnessPattern
+	if req.Type != "" {
+		patterns = []outliner.ConsciousnessPattern{{Type: req.Type, Content: req.Content, Line: 1}}
+	} else {
+		patterns = s.parser.Parse(req.Content).ConsciousnessData
+	}
+	if err := checkDefinitions(patterns); err != nil {
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, s.dispatchPatterns(patterns, req.Source))
+}
+
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	var req webhookRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("text is required"))
+		return
+	}
+
+	source := "webhook"
+	if req.Source != "" {
+		source = "webhook:" + req.Source
+	}
+
+	patterns := s.parser.Parse(req.Text).ConsciousnessData
+	if len(patterns) == 0 {
+		// Plain text from tools that don't speak :: becomes a dispatch
+		patterns = []outliner.ConsciousnessPattern{{Type: "dispatch", Content: req.Text, Line: 1}}
+	}
+	if err := checkDefinitions(patterns); err != nil {
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, s.dispatchPatterns(patterns, source))
+}
+
+// Restore replays logged entries into the dispatch system so reducers and
+// selectors survive a restart; nothing is re-sent to evna or re-logged
+func (s *Server) Restore(entries []dispatchlog.Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range entries {
+		s.dispatchLocked(outliner.ConsciousnessPattern{Type: e.Type, Content: e.Content, Line: e.Line, Context: e.Context}, e.Source, e.Time)
+	}
+	// Replayed, not new: nothing to stream
+	s.collected = nil
+}
+
+// Reload defines the reducers and selectors a persistent store's
+// reducer::/selector:: actions describe, then recomputes them from
+// everything stored; it's Restore for a dispatch system on such a store
+func (s *Server) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, patternType := range []string{"reducer", "selector"} {
+		defs, err := s.dispatch.Query(outliner.DispatchQuery{PatternType: patternType})
+		if err != nil {
+			return err
+		}
+		for _, action := range defs {
+			s.define(outliner.ConsciousnessPattern{Type: action.PatternType, Content: action.Content})
+		}
+	}
+	s.dispatch.RecomputeReducers()
+	return nil
+}
+
+// Recompute re-evaluates reducers so time windows stay current
+func (s *Server) Recompute() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dispatch.RecomputeReducers()
+}
+
+// dispatchLocked defines reducer::/selector:: state the way the editor does,
+// then dispatches the pattern; callers hold s.mu
+func (s *Server) dispatchLocked(pattern outliner.ConsciousnessPattern, source string, at time.Time) *outliner.DispatchAction {
+	s.define(pattern)
+	return s.dispatch.DispatchAt(fmt.Sprintf("%s:%d", source, pattern.Line), pattern.Content, pattern.Type, at)
+}
+
+// checkDefinition refuses reducers that would run a command or call the
+// embeddings endpoint: anything that can reach the API can post them, and
+// the log would replay them on every restart
+func checkDefinition(pattern outliner.ConsciousnessPattern) error {
+	if pattern.Type != "reducer" {
+		return nil
+	}
+	name, query, ok := outliner.ParseReducerDefinition(pattern.Content)
+	if !ok {
+		return nil
+	}
+	if _, _, exec := outliner.ParseExecQuery(query); exec {
+		return fmt.Errorf("reducer %s: exec reducers can't be defined over HTTP; define them in a document or [reducers.global]", name)
+	}
+	if _, _, similar := outliner.ParseSimilarityQuery(query); similar {
+		return fmt.Errorf("reducer %s: similarity reducers can't be defined over HTTP; define them in a document or [reducers.global]", name)
+	}
+	return nil
+}
+
+// checkDefinitions is checkDefinition for every pattern of a request
+func checkDefinitions(patterns []outliner.ConsciousnessPattern) error {
+	for _, pattern := range patterns {
+		if err := checkDefinition(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// define adds the reducer or selector a definition pattern describes
+func (s *Server) define(pattern outliner.ConsciousnessPattern) {
+	if err := checkDefinition(pattern); err != nil {
+		s.logError(err)
+		return
+	}
+	switch pattern.Type {
+	case "reducer":
+		if name, query, ok := outliner.ParseReducerDefinition(pattern.Content); ok {
+			if err := s.dispatch.DefineReducer(name, query); err != nil {
+				s.logError(err)
+			}
+		}
+	case "selector":
+		if inputs, format, ok := outliner.ParseSelectorDefinition(pattern.Content); ok {
+			name := outliner.SelectorName(pattern.Content)
+			if name == "" {
+				name = format
+			}
+			if err := s.dispatch.AddSelector(name, inputs, outliner.SelectorTransform(format)); err != nil {
+				s.logError(err)
+			}
+		}
+	}
+}
+
+// dispatchPatterns dispatches submitted patterns, appends them to the log,
+// streams them to /events subscribers, and forwards them to evna once
+// s.mu is released, so a slow evna never holds up other requests
+func (s *Server) dispatchPatterns(patterns []outliner.ConsciousnessPattern, source string) []actionJSON {
+	actions, send := s.dispatchPatternsLocked(patterns, source)
+	if send != nil {
+		if msg, ok := send().(outliner.EvnaResultMsg); ok {
+			for _, result := range msg.Results {
+				if result.Err != nil {
+					s.logError(fmt.Errorf("evna %s: %w", result.Type, result.Err))
+				}
+			}
+		}
+	}
+	return actions
+}
+
+// dispatchPatternsLocked is dispatchPatterns under s.mu, returning the
+// evna send for the caller to run after unlocking
+func (s *Server) dispatchPatternsLocked(patterns []outliner.ConsciousnessPattern, source string) ([]actionJSON, tea.Cmd) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	actions := make([]actionJSON, 0, len(patterns))
+	for _, pattern := range patterns {
+		action := s.dispatchLocked(pattern, source, time.Now())
+
+		entry := dispatchlog.Entry{
+			Time:     action.Timestamp,
+			Source:   source,
+			Line:     pattern.Line,
+			Type:     pattern.Type,
+			Content:  pattern.Content,
+			Context:  pattern.Context,
+			ActionID: action.ID,
+			Imprint:  action.Imprint,
+			Sigil:    action.Sigil,
+		}
+		if s.log != nil {
+			logged, err := s.log.Append(entry)
+			if err != nil {
+				s.logError(err)
+			} else {
+				entry = logged
+			}
+		}
+
+		published := s.hub.PublishAction(entry)
+		for _, name := range s.collected {
+			s.hub.PublishReducerUpdate(name, published.Action)
+		}
+		s.collected = nil
+
+		actions = append(actions, toActionJSON(*action))
+	}
+	return actions, s.evna.DispatchCmd(patterns, source)
+}
+
+// handleActions lists actions, filtered by ?type=, ?imprint=, ?q= (content
+// substring), ?since= (RFC3339), and capped by ?limit= (most recent)
+func (s *Server) handleActions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	query := r.URL.Query()
+	var since time.Time
+	if v := query.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("since must be RFC3339"))
+			return
+		}
+		since = t
+	}
+	limit := 0
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("limit must be a non-negative integer"))
+			return
+		}
+		limit = n
+	}
+	q := outliner.DispatchQuery{
+		PatternType: query.Get("type"),
+		Imprint:     query.Get("imprint"),
+		Text:        query.Get("q"),
+		Since:       since,
+		Limit:       limit,
+	}
+
+	s.mu.Lock()
+	found, err := s.dispatch.Query(q)
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	matched := make([]actionJSON, len(found))
+	for i, action := range found {
+		matched[i] = toActionJSON(action)
+	}
+	writeJSON(w, http.StatusOK, matched)
+}
+
+func (s *Server) handleReducers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	s.mu.Lock()
+	reducers := []reducerJSON{}
+	for name, reducer := range s.dispatch.GetReducers() {
+		actions := make([]actionJSON, len(reducer.Actions))
+		for i, action := range reducer.Actions {
+			actions[i] = toActionJSON(action)
+		}
+		reducers = append(reducers, reducerJSON{Name: name, Query: reducer.Query, Count: len(actions), Global: reducer.Global, Actions: actions})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(reducers, func(i, j int) bool { return reducers[i].Name < reducers[j].Name })
+	writeJSON(w, http.StatusOK, reducers)
+}
+
+func (s *Server) handleSelectors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	s.mu.Lock()
+	selectors := []selectorJSON{}
+	for name, selector := range s.dispatch.GetSelectors() {
+		selectors = append(selectors, selectorJSON{Name: name, Inputs: selector.Inputs, Output: selector.Output})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(selectors, func(i, j int) bool { return selectors[i].Name < selectors[j].Name })
+	writeJSON(w, http.StatusOK, selectors)
+}
+
+func toActionJSON(action outliner.DispatchAction) actionJSON {
+	return actionJSON{
+		ID:          action.ID,
+		NodeID:      action.NodeID,
+		Content:     action.Content,
+		PatternType: action.PatternType,
+		Imprint:     action.Imprint,
+		Sigil:       action.Sigil,
+		Metadata:    action.Metadata,
+		Timestamp:   action.Timestamp,
+		State:       string(action.State),
+	}
+}
+
+func decodeBody(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxBodyBytes))
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid JSON body: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed string) {
+	w.Header().Set("Allow", allowed)
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("use %s", allowed))
+}
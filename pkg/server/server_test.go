@@ -0,0 +1,334 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/dispatchlog"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+)
+
+// newTestServer is a server with evna off and exec reducers enabled, as
+// they would be with [reducers] exec = true
+func newTestServer(t *testing.T, log *dispatchlog.Log) (*Server, *outliner.FloatDispatchSystem) {
+	t.Helper()
+	dispatch := outliner.NewFloatDispatchSystem()
+	dispatch.SetExecConfig(outliner.ExecConfig{Enabled: true, Timeout: time.Second, BatchSize: 10})
+	evna := outliner.NewEvnaDispatcher()
+	evna.SetEnabled(false)
+	return New(dispatch, evna, log), dispatch
+}
+
+// send makes a request to the server's handler as a local client would
+func send(h http.Handler, method, path, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Host = "localhost:7777"
+	if method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// post sends a JSON body to the server's handler
+func post(h http.Handler, path, body string) *httptest.ResponseRecorder {
+	return send(h, http.MethodPost, path, body)
+}
+
+func TestExecReducerRefused(t *testing.T) {
+	dir := t.TempDir()
+	probe := filepath.Join(dir, "pwned")
+	srv, dispatch := newTestServer(t, nil)
+	var logged []error
+	srv.SetErrorLogger(func(err error) { logged = append(logged, err) })
+	h := srv.Handler()
+
+	for _, body := range []string{
+		`{"content":"reducer:: x exec touch ` + probe + `"}`,
+		`{"content":"x exec --batch touch ` + probe + `","type":"reducer"}`,
+		`{"content":"reducer:: near decisions similar to caching"}`,
+	} {
+		if rec := post(h, "/dispatch", body); rec.Code != http.StatusForbidden {
+			t.Errorf("POST /dispatch %s = %d %s, want 403", body, rec.Code, rec.Body)
+		}
+	}
+	if rec := post(h, "/webhook", `{"text":"reducer:: x exec touch `+probe+`"}`); rec.Code != http.StatusForbidden {
+		t.Errorf("POST /webhook = %d, want 403", rec.Code)
+	}
+	if rec := post(h, "/dispatch", `{"content":"reducer:: auth collect all decisions about auth"}`); rec.Code != http.StatusCreated {
+		t.Errorf("plain reducer = %d %s, want 201", rec.Code, rec.Body)
+	}
+
+	// A definition already in the log isn't replayed into a reducer either
+	srv.Restore([]dispatchlog.Entry{{Type: "reducer", Content: "y exec touch " + probe, Line: 1, Source: "http"}})
+
+	reducers := dispatch.GetReducers()
+	if _, ok := reducers["auth"]; !ok {
+		t.Error("plain reducer wasn't defined")
+	}
+	for _, name := range []string{"x", "y", "near"} {
+		if _, ok := reducers[name]; ok {
+			t.Errorf("reducer %s was defined", name)
+		}
+	}
+	if len(logged) != 1 {
+		t.Errorf("logged %v, want the replayed definition refused", logged)
+	}
+	if _, err := os.Stat(probe); !os.IsNotExist(err) {
+		t.Errorf("the exec command ran: %v", err)
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	srv, _ := newTestServer(t, nil)
+	h := srv.Handler()
+
+	for _, tc := range []struct {
+		method, path, body string
+		status             int
+		allow              string
+	}{
+		{http.MethodGet, "/health", "", http.StatusOK, ""},
+		{http.MethodPost, "/dispatch", `{"content":"eureka:: routes work"}`, http.StatusCreated, ""},
+		{http.MethodPost, "/dispatch", `{"content":"  "}`, http.StatusBadRequest, ""},
+		{http.MethodPost, "/dispatch", `{"content":`, http.StatusBadRequest, ""},
+		{http.MethodGet, "/dispatch", "", http.StatusMethodNotAllowed, "POST"},
+		{http.MethodPost, "/webhook", `{"action":"evna_capture","text":"plain words"}`, http.StatusAccepted, ""},
+		{http.MethodPost, "/webhook", `{}`, http.StatusBadRequest, ""},
+		{http.MethodPut, "/webhook", "", http.StatusMethodNotAllowed, "POST"},
+		{http.MethodGet, "/actions", "", http.StatusOK, ""},
+		{http.MethodPost, "/actions", `{}`, http.StatusMethodNotAllowed, "GET"},
+		{http.MethodGet, "/reducers", "", http.StatusOK, ""},
+		{http.MethodDelete, "/reducers", "", http.StatusMethodNotAllowed, "GET"},
+		{http.MethodGet, "/selectors", "", http.StatusOK, ""},
+		{http.MethodPost, "/selectors", `{}`, http.StatusMethodNotAllowed, "GET"},
+	} {
+		rec := send(h, tc.method, tc.path, tc.body)
+		if rec.Code != tc.status || rec.Header().Get("Allow") != tc.allow {
+			t.Errorf("%s %s = %d (Allow %q) %s, want %d (Allow %q)", tc.method, tc.path, rec.Code, rec.Header().Get("Allow"), rec.Body, tc.status, tc.allow)
+		}
+	}
+
+	post(h, "/dispatch", `{"content":"reducer:: auth collect all decisions about auth"}`)
+	post(h, "/dispatch", `{"content":"decision:: rotate auth tokens daily"}`)
+	post(h, "/dispatch", `{"content":"selector:: (auth) => auth digest"}`)
+
+	var reducers []reducerJSON
+	decode(t, send(h, http.MethodGet, "/reducers", ""), &reducers)
+	if len(reducers) != 1 || reducers[0].Name != "auth" || reducers[0].Count != 1 {
+		t.Errorf("reducers = %+v", reducers)
+	}
+	var selectors []selectorJSON
+	decode(t, send(h, http.MethodGet, "/selectors", ""), &selectors)
+	if len(selectors) != 1 || selectors[0].Name != "auth digest" || !slices.Equal(selectors[0].Inputs, []string{"auth"}) {
+		t.Errorf("selectors = %+v", selectors)
+	}
+}
+
+func TestGuard(t *testing.T) {
+	srv, _ := newTestServer(t, nil)
+	srv.SetAddr("127.0.0.1:7777")
+	srv.AllowOrigins("chrome-extension://float/")
+	h := srv.Handler()
+	body := `{"content":"eureka:: guarded"}`
+
+	for _, tc := range []struct {
+		name   string
+		host   string
+		header []string
+		status int
+	}{
+		{"localhost", "localhost:7777", nil, http.StatusCreated},
+		{"listen address", "127.0.0.1:7777", nil, http.StatusCreated},
+		{"ipv6 loopback", "[::1]:7777", nil, http.StatusCreated},
+		{"rebound domain", "attacker.example:7777", nil, http.StatusForbidden},
+		{"other port", "localhost:8080", nil, http.StatusForbidden},
+		{"own origin", "localhost:7777", []string{"Origin", "http://127.0.0.1:7777"}, http.StatusCreated},
+		{"allowed origin", "localhost:7777", []string{"Origin", "chrome-extension://float"}, http.StatusCreated},
+		{"foreign origin", "localhost:7777", []string{"Origin", "https://evil.example"}, http.StatusForbidden},
+		{"local dev server", "localhost:7777", []string{"Origin", "http://localhost:3000"}, http.StatusForbidden},
+		{"null origin", "localhost:7777", []string{"Origin", "null"}, http.StatusForbidden},
+		{"text/plain", "localhost:7777", []string{"Content-Type", "text/plain"}, http.StatusUnsupportedMediaType},
+		{"form", "localhost:7777", []string{"Content-Type", "application/x-www-form-urlencoded"}, http.StatusUnsupportedMediaType},
+		{"json with charset", "localhost:7777", []string{"Content-Type", "application/json; charset=utf-8"}, http.StatusCreated},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/dispatch", strings.NewReader(body))
+		req.Host = tc.host
+		req.Header.Set("Content-Type", "application/json")
+		for i := 0; i+1 < len(tc.header); i += 2 {
+			req.Header.Set(tc.header[i], tc.header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Errorf("%s: %d %s, want %d", tc.name, rec.Code, rec.Body, tc.status)
+		}
+	}
+
+	// GETs are refused for other hosts too, so rebinding can't read the log
+	req := httptest.NewRequest(http.MethodGet, "/actions", nil)
+	req.Host = "attacker.example:7777"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("GET for another host = %d, want 403", rec.Code)
+	}
+}
+
+func TestBodyCap(t *testing.T) {
+	srv, dispatch := newTestServer(t, nil)
+	h := srv.Handler()
+
+	huge := `{"content":"eureka:: ` + strings.Repeat("a", maxBodyBytes) + `"}`
+	if rec := post(h, "/dispatch", huge); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "too large") {
+		t.Errorf("oversized body = %d %s, want 400", rec.Code, rec.Body)
+	}
+	fits := `{"content":"eureka:: ` + strings.Repeat("a", maxBodyBytes-64) + `"}`
+	if rec := post(h, "/dispatch", fits); rec.Code != http.StatusCreated {
+		t.Errorf("body under the cap = %d", rec.Code)
+	}
+	if got := len(dispatch.GetActions()); got != 1 {
+		t.Errorf("%d actions dispatched, want 1", got)
+	}
+}
+
+func TestActionFilters(t *testing.T) {
+	srv, _ := newTestServer(t, nil)
+	h := srv.Handler()
+	day := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	srv.Restore([]dispatchlog.Entry{
+		{Time: day, Source: "notes.md", Line: 1, Type: "eureka", Content: "first eureka"},
+		{Time: day.Add(time.Hour), Source: "notes.md", Line: 2, Type: "decision", Content: "a decision"},
+		{Time: day.Add(2 * time.Hour), Source: "notes.md", Line: 3, Type: "eureka", Content: "second eureka"},
+		{Time: day.Add(3 * time.Hour), Source: "notes.md", Line: 4, Type: "eureka", Content: "third eureka"},
+	})
+
+	for _, tc := range []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"first eureka", "a decision", "second eureka", "third eureka"}},
+		{"?type=eureka", []string{"first eureka", "second eureka", "third eureka"}},
+		{"?type=eureka&limit=2", []string{"second eureka", "third eureka"}},
+		{"?since=" + day.Add(90*time.Minute).Format(time.RFC3339), []string{"second eureka", "third eureka"}},
+		{"?type=decision&since=" + day.Add(90*time.Minute).Format(time.RFC3339), nil},
+		{"?q=second", []string{"second eureka"}},
+	} {
+		var actions []actionJSON
+		decode(t, send(h, http.MethodGet, "/actions"+tc.query, ""), &actions)
+		var got []string
+		for _, action := range actions {
+			got = append(got, action.Content)
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("/actions%s = %q, want %q", tc.query, got, tc.want)
+		}
+	}
+
+	for _, query := range []string{"?since=yesterday", "?limit=-1", "?limit=ten"} {
+		if rec := send(h, http.MethodGet, "/actions"+query, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("/actions%s = %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestEventsResume(t *testing.T) {
+	log, err := dispatchlog.Open(filepath.Join(t.TempDir(), "dispatch-log.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, _ := newTestServer(t, log)
+	for _, content := range []string{"one", "two", "three"} {
+		post(srv.Handler(), "/dispatch", `{"content":"eureka:: `+content+`"}`)
+	}
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/events", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("GET /events = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	var ids []string
+	scanner := bufio.NewScanner(resp.Body)
+	for len(ids) < 2 && scanner.Scan() {
+		if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	if !slices.Equal(ids, []string{"2", "3"}) {
+		t.Errorf("resumed ids = %v, want [2 3]", ids)
+	}
+}
+
+func TestEvnaOutsideLock(t *testing.T) {
+	received := make(chan struct{})
+	release := make(chan struct{})
+	evnaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(received)
+		<-release
+	}))
+	defer evnaServer.Close()
+	defer close(release)
+
+	srv, _ := newTestServer(t, nil)
+	srv.evna.SetEnabled(true)
+	srv.evna.SetEndpoint(evnaServer.URL)
+	h := srv.Handler()
+
+	done := make(chan int)
+	go func() { done <- post(h, "/dispatch", `{"content":"eureka:: slow evna"}`).Code }()
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("evna was never called")
+	}
+
+	// evna is still answering; the rest of the API isn't waiting on it
+	answered := make(chan int)
+	go func() { answered <- send(h, http.MethodGet, "/reducers", "").Code }()
+	select {
+	case code := <-answered:
+		if code != http.StatusOK {
+			t.Errorf("GET /reducers = %d", code)
+		}
+	case <-time.After(time.Second):
+		t.Error("GET /reducers waited for evna")
+	}
+	release <- struct{}{}
+	if code := <-done; code != http.StatusCreated {
+		t.Errorf("slow dispatch = %d", code)
+	}
+}
+
+// decode reads a JSON response into v
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,183 @@
+// Package termcolor works out how much color the terminal shows and
+// renders both TUIs to match, down to a monochrome mode that keeps bold,
+// italic and underline but drops every color.
+package termcolor
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Level is how much color the TUIs render with
+type Level int
+
+const (
+	Auto      Level = iota // not applied yet: lipgloss detects what it can
+	Mono                   // attributes only, no colors
+	ANSI16                 // the 16 basic colors
+	ANSI256                // the 256-color palette
+	TrueColor              // 24-bit color
+)
+
+// levelNames are the theme.colors settings, by level
+var levelNames = map[Level]string{
+	Auto:      "auto",
+	Mono:      "mono",
+	ANSI16:    "16",
+	ANSI256:   "256",
+	TrueColor: "truecolor",
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// current is the level Apply set
+var current = Auto
+
+// Current is the level the TUIs render with; Auto until Apply is called
+func Current() Level {
+	return current
+}
+
+// Parse reads a theme.colors setting: auto (or empty) detects the
+// terminal's level, the others force one
+func Parse(setting string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(setting)) {
+	case "", "auto":
+		return Detect(), nil
+	case "mono", "monochrome", "none":
+		return Mono, nil
+	case "16", "ansi":
+		return ANSI16, nil
+	case "256", "ansi256":
+		return ANSI256, nil
+	case "truecolor", "24bit":
+		return TrueColor, nil
+	}
+	return Auto, fmt.Errorf("unknown color level %q (auto, truecolor, 256, 16 or mono)", setting)
+}
+
+// Detect works out the terminal's level from COLORTERM and TERM. NO_COLOR
+// and terminals that show no color, like TERM=dumb, are monochrome.
+func Detect() Level {
+	output := termenv.NewOutput(os.Stdout)
+	if output.EnvNoColor() {
+		return Mono
+	}
+	switch output.EnvColorProfile() {
+	case termenv.TrueColor:
+		return TrueColor
+	case termenv.ANSI256:
+		return ANSI256
+	case termenv.ANSI:
+		return ANSI16
+	}
+	return Mono
+}
+
+// Apply makes level the one lipgloss renders with. Colors a level lacks
+// are mapped to the nearest it has; monochrome renders the 16 colors,
+// which Filter then takes out.
+func Apply(level Level) {
+	current = level
+	switch level {
+	case TrueColor:
+		lipgloss.SetColorProfile(termenv.TrueColor)
+	case ANSI256:
+		lipgloss.SetColorProfile(termenv.ANSI256)
+	case ANSI16, Mono:
+		lipgloss.SetColorProfile(termenv.ANSI)
+	}
+}
+
+// Filter wraps m so its frames carry no color once Apply set the
+// monochrome level; otherwise m is returned as it is
+func Filter(m tea.Model) tea.Model {
+	if current != Mono {
+		return m
+	}
+	return monoModel{m}
+}
+
+// monoModel strips the colors from a model's frames
+type monoModel struct {
+	tea.Model
+}
+
+func (m monoModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.Model.Update(msg)
+	return monoModel{model}, cmd
+}
+
+func (m monoModel) View() string {
+	return Strip(m.Model.View())
+}
+
+// Strip takes the colors out of s's SGR escape sequences, keeping bold,
+// italic, underline and the rest. Sequences left with nothing are dropped;
+// other escapes, like images, are kept as they are.
+func Strip(s string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "\x1b[")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		end := start + 2
+		for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == ';' || s[end] == ':') {
+			end++
+		}
+		b.WriteString(s[:start])
+		if end >= len(s) || s[end] != 'm' {
+			// Not SGR
+			b.WriteString(s[start:end])
+			s = s[end:]
+			continue
+		}
+		params := s[start+2 : end]
+		if kept, ok := stripParams(params); ok {
+			b.WriteString("\x1b[" + kept + "m")
+		}
+		s = s[end+1:]
+	}
+}
+
+// stripParams drops the color parameters of an SGR sequence, reporting
+// false when only colors were set
+func stripParams(params string) (string, bool) {
+	if params == "" {
+		return "", true // a reset
+	}
+	fields := strings.Split(params, ";")
+	var kept []string
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		code, _ := strconv.Atoi(strings.SplitN(field, ":", 2)[0])
+		switch {
+		case code == 38 || code == 48 || code == 58:
+			// Extended colors: 5;n or 2;r;g;b follow unless they're
+			// colon-separated within the field
+			if !strings.Contains(field, ":") && i+1 < len(fields) {
+				switch fields[i+1] {
+				case "5":
+					i += 2
+				case "2":
+					i += 4
+				}
+			}
+		case code >= 30 && code <= 37, code == 39, code >= 40 && code <= 47, code == 49,
+			code >= 90 && code <= 97, code >= 100 && code <= 107, code == 59:
+		default:
+			kept = append(kept, field)
+		}
+	}
+	return strings.Join(kept, ";"), len(kept) > 0
+}
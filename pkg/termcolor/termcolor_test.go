@@ -0,0 +1,34 @@
+package termcolor
+
+import "testing"
+
+func TestStripKeepsAttributes(t *testing.T) {
+	for in, want := range map[string]string{
+		"\x1b[1;38;5;62mctx::\x1b[0m":        "\x1b[1mctx::\x1b[0m",
+		"\x1b[38;2;255;0;0;4mred\x1b[m":      "\x1b[4mred\x1b[m",
+		"\x1b[91;48;5;236mrow\x1b[0m":        "row\x1b[0m",
+		"\x1b[3;38:5:12mbridge\x1b[0m":       "\x1b[3mbridge\x1b[0m",
+		"plain \x1b[2Kline":                  "plain \x1b[2Kline",
+		"\x1b[7mcursor\x1b[27m and \x1b[32m": "\x1b[7mcursor\x1b[27m and ",
+	} {
+		if got := Strip(in); got != want {
+			t.Errorf("Strip(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for setting, want := range map[string]Level{"mono": Mono, "16": ANSI16, "256": ANSI256, "TrueColor": TrueColor} {
+		if got, err := Parse(setting); err != nil || got != want {
+			t.Errorf("Parse(%q) = %v, %v", setting, got, err)
+		}
+	}
+	if _, err := Parse("sepia"); err == nil {
+		t.Error("Parse took an unknown level")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if got, _ := Parse("auto"); got != Mono {
+		t.Errorf("NO_COLOR detected as %v", got)
+	}
+}
@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/api"
+	"github.com/evanschultz/float-rw-client/pkg/models"
+)
+
+// activity is a network operation a pane is waiting on: a spinner until
+// it knows how many pages there are, then a bar of pages loaded. Its
+// requests are made with ctx, so canceling it stops them.
+type activity struct {
+	label   string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	spinner spinner.Model
+	bar     progress.Model
+	page    int // pages loaded
+	pages   int // pages in all, 0 while unknown
+}
+
+// newActivity starts an activity described by label, e.g. "Loading books"
+func newActivity(label string) *activity {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &activity{
+		label:   label,
+		ctx:     ctx,
+		cancel:  cancel,
+		spinner: spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("170")))),
+		bar:     progress.New(progress.WithDefaultGradient(), progress.WithoutPercentage()),
+	}
+}
+
+// tick starts the spinner
+func (a *activity) tick() tea.Cmd {
+	return a.spinner.Tick
+}
+
+// update advances the spinner on its own ticks; a nil activity ignores
+// them, which stops the ticks of one that finished
+func (a *activity) update(msg spinner.TickMsg) tea.Cmd {
+	if a == nil || msg.ID != a.spinner.ID() {
+		return nil
+	}
+	var cmd tea.Cmd
+	a.spinner, cmd = a.spinner.Update(msg)
+	return cmd
+}
+
+// progress records that page of pages has loaded
+func (a *activity) progress(page, pages int) {
+	a.page, a.pages = page, pages
+}
+
+// stop cancels the activity's requests
+func (a *activity) stop() {
+	if a != nil {
+		a.cancel()
+	}
+}
+
+// view renders the spinner or the bar in width cells
+func (a *activity) view(width int) string {
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	line := a.spinner.View() + " " + a.label + "..."
+	if a.pages > 1 {
+		a.bar.Width = max(10, width-12)
+		line += "\n" + a.bar.ViewAs(float64(a.page)/float64(a.pages)) + dim.Render(fmt.Sprintf(" %d/%d pages", a.page, a.pages))
+	}
+	return line + "\n" + dim.Render("esc: cancel")
+}
+
+// highlightsPageMsg carries the highlights of a book loaded so far when
+// more pages follow
+type highlightsPageMsg struct {
+	load       *activity
+	bookID     int
+	page       int
+	highlights []models.Highlight
+	count      int // the book's highlights in all
+}
+
+// loadFailedMsg reports the request that ended an activity
+type loadFailedMsg struct {
+	load *activity
+	err  error
+}
+
+// loadBooks fetches the book list under load
+func loadBooks(client *api.Client, load *activity) tea.Cmd {
+	return func() tea.Msg {
+		books, err := client.GetBooks(load.ctx, nil)
+		if load.ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return loadFailedMsg{load: load, err: err}
+		}
+		return booksLoadedMsg{books: books.Results, load: load}
+	}
+}
+
+// loadHighlightsPage fetches page of a book's highlights under load,
+// adding them to loaded. It reports a highlightsPageMsg while more pages
+// follow and a highlightsLoadedMsg with everything after the last; a
+// canceled load reports nothing.
+func loadHighlightsPage(client *api.Client, load *activity, bookID, page int, loaded []models.Highlight) tea.Cmd {
+	return func() tea.Msg {
+		params := url.Values{}
+		params.Set("book_id", strconv.Itoa(bookID))
+		params.Set("page", strconv.Itoa(page))
+		list, err := client.GetHighlights(load.ctx, params)
+		if load.ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return loadFailedMsg{load: load, err: fmt.Errorf("load highlights page %d: %w", page, err)}
+		}
+
+		loaded = append(loaded, list.Results...)
+		if list.Next == "" {
+			return highlightsLoadedMsg{highlights: loaded, load: load}
+		}
+		return highlightsPageMsg{load: load, bookID: bookID, page: page, highlights: loaded, count: list.Count}
+	}
+}
+
+// nextHighlightsPage records the page msg carries and fetches the next
+func nextHighlightsPage(client *api.Client, msg highlightsPageMsg) tea.Cmd {
+	// Every page before the last is full, so the loaded highlights give
+	// the page size
+	perPage := max(1, len(msg.highlights)/msg.page)
+	msg.load.progress(msg.page, (msg.count+perPage-1)/perPage)
+	return loadHighlightsPage(client, msg.load, msg.bookID, msg.page+1, msg.highlights)
+}
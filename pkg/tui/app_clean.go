@@ -2,17 +2,21 @@ package tui
 
 import (
 	"fmt"
-	"net/url"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/evanschultz/float-rw-client/pkg/api"
+	"github.com/evanschultz/float-rw-client/pkg/cache"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
 	"github.com/evanschultz/float-rw-client/pkg/models"
 	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
 )
 
 // Simple focus states - just 3 panels
@@ -51,13 +55,30 @@ type CleanModel struct {
 	bookList      list.Model
 	highlightList list.Model
 	detailView    viewport.Model
-	noteOutliner  outliner.Outliner
+	noteOutliner  outliner.Model
 	parser        *outliner.Parser
 
-	// UI state
-	loading  bool
-	err      error
-	editMode EditMode
+	// UI state; a load is nil once it's done
+	bookLoad      *activity
+	highlightLoad *activity
+	toasts        components.Toasts
+	editMode      EditMode
+
+	// The highlight as it was when editing began, and the merge view
+	// while a save is resolving a conflict with Readwise
+	base  models.Highlight
+	merge *mergeView
+
+	// Highlight-to-consciousness pipeline
+	capture *HighlightCapture
+
+	// Book list sorting, filters and archive
+	shelf *bookShelf
+
+	// Book detail overlay, and how covers are drawn in it
+	bookInfo      *bookDetail
+	coverProtocol components.ImageProtocol
+	clearImages   bool // covers drawn before the overlay closed need erasing
 }
 
 func NewCleanModel(apiClient *api.Client) CleanModel {
@@ -79,7 +100,7 @@ func NewCleanModel(apiClient *api.Client) CleanModel {
 	detailView := viewport.New(0, 0)
 
 	// Note outliner
-	noteOutliner := outliner.New()
+	noteOutliner := outliner.NewModel(outliner.New())
 
 	return CleanModel{
 		api:           apiClient,
@@ -90,16 +111,49 @@ func NewCleanModel(apiClient *api.Client) CleanModel {
 		noteOutliner:  noteOutliner,
 		parser:        outliner.NewParser(),
 		editMode:      ModeView,
+		bookLoad:      newActivity(i18n.T("rw.status.loading_books")),
+		toasts:        components.NewToasts(),
+		capture:       defaultCapture(),
+		shelf:         newBookShelf(),
+		coverProtocol: components.ProtocolASCII,
 	}
 }
 
+// SetCapture sets where captured highlights are dispatched
+func (m *CleanModel) SetCapture(capture *HighlightCapture) {
+	m.capture = capture
+}
+
+// SetLibrary sets the local library cache the book list's sorting,
+// filters and archive are kept in
+func (m *CleanModel) SetLibrary(library *cache.Library) {
+	m.shelf.library = library
+}
+
+// SetViews sets the smart views listed above the books, and the cache of
+// every book's highlights they're read from
+func (m *CleanModel) SetViews(views map[string]config.ViewConfig, highlights *cache.Highlights) {
+	m.shelf.views = smartViews(views)
+	m.shelf.highlights = highlights
+}
+
+// SetCoverProtocol sets how the book detail draws covers
+func (m *CleanModel) SetCoverProtocol(protocol components.ImageProtocol) {
+	m.coverProtocol = protocol
+}
+
 func (m CleanModel) Init() tea.Cmd {
-	return m.loadBooks()
+	return tea.Batch(loadBooks(m.api, m.bookLoad), m.bookLoad.tick(), m.noteOutliner.Init())
 }
 
 func (m CleanModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	// Notifications and their dismissal timers
+	if m.toasts.Update(msg) {
+		return m, m.toasts.Flush()
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
@@ -107,6 +161,55 @@ func (m CleanModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.updateSizes()
 
 	case tea.KeyMsg:
+		m.clearImages = false
+		// alt+n opens the notification inbox, which takes keys while it's open
+		if msg.String() == "alt+n" {
+			m.toasts.ToggleInbox()
+			return m, nil
+		}
+		if m.toasts.InboxOpen() {
+			switch msg.String() {
+			case "ctrl+c":
+				return m, tea.Quit
+			case "esc", "q":
+				m.toasts.ToggleInbox()
+			}
+			return m, nil
+		}
+
+		// The book detail overlay takes keys while it's open
+		if m.bookInfo != nil {
+			switch msg.String() {
+			case "ctrl+c", "q":
+				return m, tea.Quit
+			case "esc", "i":
+				m.closeBookDetail()
+			case "enter":
+				book := m.bookInfo.book
+				m.closeBookDetail()
+				m.currentBook = &book
+				m.currentHighlight = nil
+				cmd := m.loadHighlights(book)
+				return m, cmd
+			}
+			return m, nil
+		}
+
+		// A conflicting save waits on the merge view
+		if m.merge != nil {
+			accept, cancel := m.merge.update(msg.String())
+			switch {
+			case accept:
+				merged := m.merge.merged()
+				m.base = m.merge.remote
+				m.merge = nil
+				return m, saveHighlight(m.api, m.base, merged)
+			case cancel:
+				m.merge = nil
+			}
+			return m, nil
+		}
+
 		// In edit mode, handle only specific keys and pass everything else to outliner
 		if m.editMode == ModeEdit {
 			switch msg.String() {
@@ -117,24 +220,19 @@ func (m CleanModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.editMode = ModeView
 				m.noteOutliner.Blur()
 			case "ctrl+s":
-				// Save note
+				// Save the highlight, note and meta back to Readwise; edit
+				// mode ends once the save lands
 				if m.currentHighlight != nil {
-					m.currentHighlight.Note = m.noteOutliner.GetContent()
-
 					// Trigger consciousness capture before saving
-					m.noteOutliner.TriggerConsciousnessCapture()
-
-					m.editMode = ModeView
-					m.noteOutliner.Blur()
-					// TODO: Save to API
-					return m, m.renderHighlightDetail()
+					capture := m.updateNote(outliner.CaptureRequestMsg{})
+					return m, tea.Batch(m.saveOutlinerContent(), capture)
 				}
 			default:
 				// ALL other keys go to the outliner
-				newOutliner, cmd := m.noteOutliner.Update(msg)
-				m.noteOutliner = newOutliner
-				cmds = append(cmds, cmd)
+				cmds = append(cmds, m.updateNote(msg))
 			}
+		} else if msg.String() == "esc" && (m.bookLoad != nil || m.highlightLoad != nil) {
+			cmds = append(cmds, m.cancelLoads())
 		} else {
 			// View mode - normal key handling
 			switch msg.String() {
@@ -158,9 +256,10 @@ func (m CleanModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.focus == FocusDetail && m.currentHighlight != nil {
 					m.editMode = ModeEdit
 					m.noteOutliner.Focus()
+					m.base = *m.currentHighlight
 					// Load structured content into outliner
 					content := m.highlightToOutlinerFormat(m.currentHighlight)
-					m.noteOutliner.SetContent(content)
+					cmds = append(cmds, m.updateNote(outliner.SetContentMsg{Content: content}))
 				}
 
 			case "ctrl+s":
@@ -182,11 +281,35 @@ func (m CleanModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Let the focused component handle other keys
 				switch m.focus {
 				case FocusBooks:
+					if m.bookList.FilterState() != list.Filtering {
+						var selected *models.Book
+						if i, ok := m.bookList.SelectedItem().(bookItem); ok {
+							selected = &i.book
+						}
+						if msg.String() == "i" && selected != nil && !m.shelf.isView(selected) {
+							m.bookInfo = newBookDetail(*selected, m.coverProtocol)
+							cmds = append(cmds, m.bookInfo.load.tick(), loadBookDetail(m.api, m.bookInfo, m.width))
+							break
+						}
+						if handled, cmd := m.shelf.handleKey(msg.String(), selected); handled {
+							cmds = append(cmds, cmd)
+							m.shelf.apply(&m.bookList)
+							m.updateSizes()
+							break
+						}
+					}
 					newList, cmd := m.bookList.Update(msg)
 					m.bookList = newList
 					cmds = append(cmds, cmd)
 
 				case FocusHighlights:
+					// Typed into the filter, the keys are search text
+					if m.highlightList.FilterState() != list.Filtering {
+						if cmd, ok := m.captureForKey(msg.String()); ok {
+							cmds = append(cmds, cmd)
+							break
+						}
+					}
 					newList, cmd := m.highlightList.Update(msg)
 					m.highlightList = newList
 					cmds = append(cmds, cmd)
@@ -194,8 +317,12 @@ func (m CleanModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				case FocusDetail:
 					if m.editMode == ModeEdit {
 						// Update outliner when in edit mode
-						newOutliner, cmd := m.noteOutliner.Update(msg)
-						m.noteOutliner = newOutliner
+						cmds = append(cmds, m.updateNote(msg))
+					} else if update, ok := m.highlightActionForKey(msg.String()); ok {
+						cmds = append(cmds, m.updateCurrentHighlight(update))
+					} else if cmd, ok := m.captureForKey(msg.String()); ok {
+						cmds = append(cmds, cmd)
+					} else if cmd, ok := linkForKey(m.currentHighlight, msg.String()); ok {
 						cmds = append(cmds, cmd)
 					} else {
 						// Update viewport when in view mode
@@ -207,24 +334,57 @@ func (m CleanModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case spinner.TickMsg:
+		cmds = append(cmds, m.bookLoad.update(msg), m.highlightLoad.update(msg))
+		if m.bookInfo != nil {
+			cmds = append(cmds, m.bookInfo.load.update(msg))
+		}
+
 	case booksLoadedMsg:
-		m.loading = false
+		if msg.load != m.bookLoad {
+			break
+		}
+		m.bookLoad = nil
 		m.books = msg.books
-		items := make([]list.Item, len(m.books))
-		for i, book := range m.books {
-			items[i] = bookItem{book: book}
+		m.shelf.books = msg.books
+		m.capture.SetBooks(msg.books)
+		m.shelf.apply(&m.bookList)
+		m.updateSizes()
+
+	case bookDetailLoadedMsg:
+		if m.bookInfo != nil && m.bookInfo.book.ID == msg.detail.book.ID {
+			m.bookInfo = msg.detail
+		}
+
+	case highlightsPageMsg:
+		if msg.load == m.highlightLoad {
+			cmds = append(cmds, nextHighlightsPage(m.api, msg))
+		}
+
+	case viewPageMsg:
+		if msg.load == m.highlightLoad {
+			cmds = append(cmds, nextViewPage(m.api, m.shelf.highlights, msg))
 		}
-		m.bookList.SetItems(items)
 
 	case highlightsLoadedMsg:
-		m.loading = false
+		if msg.load != m.highlightLoad {
+			break
+		}
+		m.highlightLoad = nil
 		m.highlights = msg.highlights
 		items := make([]list.Item, len(m.highlights))
 		for i, highlight := range m.highlights {
 			items[i] = highlightItem{highlight: highlight}
 		}
 		m.highlightList.SetItems(items)
+		if m.shelf.isView(m.currentBook) {
+			// The sync may have changed the views' counts
+			m.shelf.apply(&m.bookList)
+		}
 		// Don't auto-focus - let user navigate manually
+		if m.capture.Auto() {
+			cmds = append(cmds, m.capture.Capture(m.highlights, m.currentBook))
+		}
 
 	case highlightRenderedMsg:
 		if m.editMode == ModeEdit {
@@ -235,84 +395,93 @@ func (m CleanModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.detailView.SetContent(msg.content)
 		}
 
+	case outliner.EvnaResultMsg:
+		if msg.Source != captureSource {
+			cmds = append(cmds, m.updateNote(msg))
+		} else {
+			cmds = append(cmds, captureNotice(msg))
+		}
+
 	case highlightSavedMsg:
+		if msg.highlight != nil {
+			if m.currentHighlight != nil && m.currentHighlight.ID == msg.highlight.ID {
+				*m.currentHighlight = *msg.highlight
+			}
+			replaceHighlight(m.highlights, &m.highlightList, *msg.highlight)
+		}
 		// Exit edit mode after successful save
 		m.editMode = ModeView
 		m.noteOutliner.Blur()
 		// Refresh the detail view with updated content
-		return m, m.renderHighlightDetail()
+		return m, tea.Batch(m.renderHighlightDetail(), components.Notify(components.ToastSuccess, i18n.T("rw.toast.highlight_saved")))
+
+	case highlightConflictMsg:
+		// Stay in edit mode behind the merge view
+		m.merge = newMergeView(msg)
+
+	case highlightUpdatedMsg:
+		if m.currentHighlight != nil && m.currentHighlight.ID == msg.highlight.ID {
+			*m.currentHighlight = msg.highlight
+		}
+		replaceHighlight(m.highlights, &m.highlightList, msg.highlight)
+
+	case highlightUpdateFailedMsg:
+		// Roll back the optimistic update
+		if m.currentHighlight != nil && m.currentHighlight.ID == msg.original.ID {
+			*m.currentHighlight = msg.original
+		}
+		replaceHighlight(m.highlights, &m.highlightList, msg.original)
+		cmds = append(cmds, components.NotifyError(msg.err))
+
+	case loadFailedMsg:
+		if msg.load == m.bookLoad {
+			m.bookLoad = nil
+		}
+		if msg.load == m.highlightLoad {
+			m.highlightLoad = nil
+		}
+		cmds = append(cmds, components.NotifyError(msg.err))
 
 	case errMsg:
-		m.err = msg.err
-		m.loading = false
+		cmds = append(cmds, components.NotifyError(msg.err))
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
-func (m CleanModel) View() string {
-	if m.err != nil {
-		return fmt.Sprintf("Error: %v\n\nPress q to quit.", m.err)
-	}
+// updateNote passes msg to the note outliner
+func (m *CleanModel) updateNote(msg tea.Msg) tea.Cmd {
+	model, cmd := m.noteOutliner.Update(msg)
+	m.noteOutliner = model.(outliner.Model)
+	return cmd
+}
 
+func (m CleanModel) View() string {
 	if m.width == 0 || m.height == 0 {
-		return "Loading..."
+		return i18n.T("rw.status.loading")
 	}
 
 	// Calculate layout - always 3 columns when we have data
-	bookWidth := 30
-	highlightWidth := 40
-	detailWidth := m.width - bookWidth - highlightWidth - 6 // Account for borders
-
-	// Ensure minimum widths
-	if detailWidth < 40 {
-		bookWidth = 25
-		highlightWidth = 35
-		detailWidth = m.width - bookWidth - highlightWidth - 6
-	}
-
-	contentHeight := m.height - 3 // Account for help text
-
-	// Styles
-	focusedStyle := lipgloss.NewStyle().
-		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("62")).
-		Padding(0, 1)
-
-	unfocusedStyle := lipgloss.NewStyle().
-		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("240")).
-		Padding(0, 1)
+	bookWidth, highlightWidth, detailWidth, contentHeight := m.columns()
 
 	// Book panel
-	bookContent := m.bookList.View()
-	if m.loading && m.focus == FocusBooks {
-		bookContent = "Loading books..."
-	}
-
-	var bookPanel string
-	if m.focus == FocusBooks {
-		bookPanel = focusedStyle.Width(bookWidth - 4).Height(contentHeight - 2).Render(bookContent)
-	} else {
-		bookPanel = unfocusedStyle.Width(bookWidth - 4).Height(contentHeight - 2).Render(bookContent)
+	bookContent := m.bookList.View() + "\n" + m.shelf.facetView(paneInner(bookWidth))
+	if m.bookLoad != nil {
+		bookContent = m.bookLoad.view(paneInner(bookWidth))
 	}
+	bookPanel := renderPane(bookContent, bookWidth, contentHeight, m.focus == FocusBooks)
 
 	// Highlight panel (show if we have a book)
 	var highlightPanel string
 	if m.currentBook != nil {
 		highlightContent := m.highlightList.View()
-		if m.loading && m.focus == FocusHighlights {
-			highlightContent = fmt.Sprintf("Loading highlights for %s...", m.currentBook.Title)
-		}
-
-		if m.focus == FocusHighlights {
-			highlightPanel = focusedStyle.Width(highlightWidth - 4).Height(contentHeight - 2).Render(highlightContent)
-		} else {
-			highlightPanel = unfocusedStyle.Width(highlightWidth - 4).Height(contentHeight - 2).Render(highlightContent)
+		if m.highlightLoad != nil {
+			highlightContent = m.highlightLoad.view(paneInner(highlightWidth))
 		}
+		highlightPanel = renderPane(highlightContent, highlightWidth, contentHeight, m.focus == FocusHighlights)
 	} else {
 		// Empty placeholder
-		highlightPanel = unfocusedStyle.Width(highlightWidth - 4).Height(contentHeight - 2).Render("Select a book to see highlights")
+		highlightPanel = renderPane(i18n.T("rw.status.select_book"), highlightWidth, contentHeight, false)
 	}
 
 	// Detail panel (show if we have a highlight)
@@ -322,38 +491,50 @@ func (m CleanModel) View() string {
 
 		if m.editMode == ModeEdit {
 			// Show outliner for editing
-			m.noteOutliner.SetSize(detailWidth-4, contentHeight-2)
+			m.noteOutliner.SetSize(paneInner(detailWidth), contentHeight-2)
 			detailContent = m.noteOutliner.View()
 		} else {
 			// Show rendered view
 			detailContent = m.detailView.View()
 		}
-
-		if m.focus == FocusDetail || m.editMode == ModeEdit {
-			detailPanel = focusedStyle.Width(detailWidth - 4).Height(contentHeight - 2).Render(detailContent)
-		} else {
-			detailPanel = unfocusedStyle.Width(detailWidth - 4).Height(contentHeight - 2).Render(detailContent)
-		}
+		detailPanel = renderPane(detailContent, detailWidth, contentHeight, m.focus == FocusDetail || m.editMode == ModeEdit)
 	} else {
 		// Empty placeholder
-		detailPanel = unfocusedStyle.Width(detailWidth - 4).Height(contentHeight - 2).Render("Select a highlight to see details")
+		detailPanel = renderPane(i18n.T("rw.status.select_highlight"), detailWidth, contentHeight, false)
 	}
 
-	// Join panels
+	// Join panels, or show the focused one in a narrow terminal; a
+	// conflicting save covers them with the merge view
 	content := lipgloss.JoinHorizontal(lipgloss.Top, bookPanel, highlightPanel, detailPanel)
+	if m.width < singlePaneBelow {
+		shown := m.shownPane()
+		panels := []string{bookPanel, highlightPanel, detailPanel}
+		content = lipgloss.JoinVertical(lipgloss.Left, paneSwitcher(int(shown), m.openPanes(), m.width), panels[shown])
+	}
+	if m.toasts.InboxOpen() {
+		content = components.ClearImages(m.coverProtocol) + m.toasts.InboxView(m.width, contentHeight)
+	} else if m.merge != nil {
+		content = m.merge.view(m.width, contentHeight)
+	} else if m.bookInfo != nil {
+		content = m.bookInfo.view(m.width, contentHeight)
+	} else if m.clearImages {
+		content = components.ClearImages(m.coverProtocol) + content
+	}
 
 	// Help text
 	helpText := m.getHelpText()
 	helpStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("241")).
 		Align(lipgloss.Center).
-		Width(m.width)
+		Width(m.width).
+		MaxHeight(3)
 
-	return lipgloss.JoinVertical(
+	// Toasts sit over the top right corner
+	return m.toasts.Overlay(lipgloss.JoinVertical(
 		lipgloss.Top,
 		content,
 		helpStyle.Render(helpText),
-	)
+	), m.width)
 }
 
 // Focus management
@@ -400,20 +581,35 @@ func (m *CleanModel) focusRight() {
 	}
 }
 
+// closeBookDetail closes the book detail, erasing any cover it drew
+func (m *CleanModel) closeBookDetail() {
+	m.bookInfo.load.stop()
+	m.clearImages = m.bookInfo.cover != ""
+	m.bookInfo = nil
+}
+
 func (m CleanModel) handleEnter() (tea.Model, tea.Cmd) {
 	switch m.focus {
 	case FocusBooks:
 		if i, ok := m.bookList.SelectedItem().(bookItem); ok {
 			m.currentBook = &i.book
 			m.currentHighlight = nil // Clear previous highlight
-			m.loading = true
-			return m, m.loadHighlights(i.book.ID)
+			if m.width < singlePaneBelow {
+				// Only one panel shows, so it's the one just opened
+				m.focus = FocusHighlights
+			}
+			cmd := m.loadHighlights(i.book)
+			return m, cmd
 		}
 
 	case FocusHighlights:
 		if i, ok := m.highlightList.SelectedItem().(highlightItem); ok {
 			m.currentHighlight = &i.highlight
-			// Don't auto-focus detail - just load it
+			// Don't auto-focus detail - just load it, unless it's the only
+			// panel showing
+			if m.width < singlePaneBelow {
+				m.focus = FocusDetail
+			}
 			return m, m.renderHighlightDetail()
 		}
 	}
@@ -421,65 +617,169 @@ func (m CleanModel) handleEnter() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m *CleanModel) updateSizes() {
-	bookWidth := 30
-	highlightWidth := 40
-	detailWidth := m.width - bookWidth - highlightWidth - 6
+// Column widths the clean layout keeps while the terminal has room for all
+// three
+const (
+	minCleanBookWidth   = 12
+	minCleanDetailWidth = 30
+)
+
+// columns sizes the book, highlight and detail columns, and the height
+// they share with the help text. Narrow terminals shrink the book and
+// highlight columns; below singlePaneBelow each takes the full width
+// under the pane switcher.
+func (m CleanModel) columns() (book, highlight, detail, height int) {
+	height = m.height - 3 // Account for help text
+	if m.width < singlePaneBelow {
+		return m.width, m.width, m.width, height - 1
+	}
+
+	book, highlight = 30, 40
+	if m.width-book-highlight < 46 {
+		book, highlight = 25, 35
+	}
+	detail = m.width - book - highlight
+	if detail < minCleanDetailWidth {
+		rest := m.width - minCleanDetailWidth
+		book = max(minCleanBookWidth, rest*2/5)
+		highlight, detail = rest-book, minCleanDetailWidth
+	}
+	return book, highlight, detail, height
+}
+
+// shownPane is the panel a narrow terminal shows: the focused one, or the
+// nearest open one before it
+func (m CleanModel) shownPane() Focus {
+	switch {
+	case m.editMode == ModeEdit || (m.focus == FocusDetail && m.currentHighlight != nil):
+		return FocusDetail
+	case m.focus != FocusBooks && m.currentBook != nil:
+		return FocusHighlights
+	}
+	return FocusBooks
+}
 
-	if detailWidth < 40 {
-		bookWidth = 25
-		highlightWidth = 35
-		detailWidth = m.width - bookWidth - highlightWidth - 6
+// openPanes counts the panels the switcher lists
+func (m CleanModel) openPanes() int {
+	switch {
+	case m.currentHighlight != nil:
+		return 3
+	case m.currentBook != nil:
+		return 2
 	}
+	return 1
+}
 
-	contentHeight := m.height - 3
+func (m *CleanModel) updateSizes() {
+	bookWidth, highlightWidth, detailWidth, contentHeight := m.columns()
 
-	m.bookList.SetSize(bookWidth-6, contentHeight-2)
-	m.highlightList.SetSize(highlightWidth-6, contentHeight-2)
-	m.detailView.Width = detailWidth - 6
-	m.detailView.Height = contentHeight - 2
+	m.bookList.SetSize(paneInner(bookWidth), max(1, contentHeight-2-m.shelf.facetHeight()))
+	m.highlightList.SetSize(paneInner(highlightWidth), max(1, contentHeight-2))
+	m.detailView.Width = paneInner(detailWidth)
+	m.detailView.Height = max(1, contentHeight-2)
 }
 
 func (m CleanModel) getHelpText() string {
+	if m.merge != nil {
+		return i18n.T("rw.help.clean.merge")
+	}
+	if m.bookInfo != nil {
+		return i18n.T("rw.help.clean.book_info")
+	}
 	if m.editMode == ModeEdit {
-		return "tab: indent • shift+tab: outdent • enter: new line • ctrl+s: save • esc: cancel"
+		return i18n.T("rw.help.clean.edit")
 	}
 
 	switch m.focus {
 	case FocusBooks:
-		return "enter: select • /: search • tab/→: next • q: quit"
+		return i18n.T("rw.help.clean.books")
 	case FocusHighlights:
-		return "enter: view • /: search • ←→: navigate • tab: next • q: quit"
+		help := i18n.T("rw.help.clean.highlights")
+		if m.capture.Auto() {
+			help = i18n.T("rw.help.auto_capture", m.capture.Count()) + " • " + help
+		}
+		return help
 	case FocusDetail:
-		return "e: edit note • ↑↓: scroll • ←: back • tab: next • q: quit"
+		return i18n.T("rw.help.clean.detail")
 	}
-	return "tab/←→: navigate • q: quit"
+	return i18n.T("rw.help.clean.navigate")
 }
 
-// Commands (reuse existing ones)
-func (m CleanModel) loadBooks() tea.Cmd {
-	return func() tea.Msg {
-		books, err := m.api.GetBooks(nil)
-		if err != nil {
-			return errMsg{err}
-		}
-		return booksLoadedMsg{books: books.Results}
+// highlightActionForKey maps detail pane keys to highlight updates
+func (m CleanModel) highlightActionForKey(key string) (models.HighlightUpdate, bool) {
+	if m.currentHighlight == nil {
+		return models.HighlightUpdate{}, false
 	}
+
+	switch key {
+	case "f":
+		return toggleFavoriteUpdate(*m.currentHighlight), true
+	case "x":
+		return toggleDiscardUpdate(*m.currentHighlight), true
+	case "c":
+		return cycleColorUpdate(*m.currentHighlight), true
+	}
+	return models.HighlightUpdate{}, false
 }
 
-func (m CleanModel) loadHighlights(bookID int) tea.Cmd {
-	return func() tea.Msg {
-		params := url.Values{}
-		params.Set("book_id", fmt.Sprintf("%d", bookID))
-		highlights, err := m.api.GetHighlights(params)
-		if err != nil {
-			return errMsg{err}
+// captureForKey maps capture keys to the capture of the highlights they
+// name: p the current (or selected) one, P the loaded book's, a toggles
+// capturing as highlights load
+func (m *CleanModel) captureForKey(key string) (tea.Cmd, bool) {
+	switch key {
+	case "p":
+		if m.focus == FocusDetail && m.currentHighlight != nil {
+			return m.capture.Capture([]models.Highlight{*m.currentHighlight}, m.currentBook), true
 		}
-		return highlightsLoadedMsg{
-			highlights:  highlights.Results,
-			nextPageURL: highlights.Next,
+		if i, ok := m.highlightList.SelectedItem().(highlightItem); ok && m.focus == FocusHighlights {
+			return m.capture.Capture([]models.Highlight{i.highlight}, m.currentBook), true
+		}
+	case "P":
+		if m.focus == FocusHighlights {
+			return m.capture.Capture(m.highlights, m.currentBook), true
 		}
+	case "a":
+		if m.focus == FocusHighlights {
+			m.capture.SetAuto(!m.capture.Auto())
+			if m.capture.Auto() {
+				return m.capture.Capture(m.highlights, m.currentBook), true
+			}
+			return nil, true
+		}
+	}
+	return nil, false
+}
+
+// updateCurrentHighlight applies a favorite/discard/color change optimistically
+// and sends it to the API; failures are rolled back in Update
+func (m *CleanModel) updateCurrentHighlight(update models.HighlightUpdate) tea.Cmd {
+	original := *m.currentHighlight
+	*m.currentHighlight = applyHighlightUpdate(original, update)
+	replaceHighlight(m.highlights, &m.highlightList, *m.currentHighlight)
+
+	return sendHighlightUpdate(m.api, original, update)
+}
+
+// Commands (reuse existing ones)
+
+// loadHighlights starts loading a book's highlights page by page, or
+// a smart view's from the highlights cache, canceling a load still running
+func (m *CleanModel) loadHighlights(book models.Book) tea.Cmd {
+	m.highlightLoad.stop()
+	if view, ok := m.shelf.view(book); ok {
+		m.highlightLoad = newActivity(i18n.T("rw.status.loading_view", view.name))
+		return tea.Batch(m.highlightLoad.tick(), loadView(m.api, m.highlightLoad, m.shelf.highlights, view))
 	}
+	m.highlightLoad = newActivity(i18n.T("rw.status.loading_book", book.Title))
+	return tea.Batch(m.highlightLoad.tick(), loadHighlightsPage(m.api, m.highlightLoad, book.ID, 1, nil))
+}
+
+// cancelLoads stops the book and highlight loads in flight
+func (m *CleanModel) cancelLoads() tea.Cmd {
+	m.bookLoad.stop()
+	m.highlightLoad.stop()
+	m.bookLoad, m.highlightLoad = nil, nil
+	return components.Notify(components.ToastWarn, i18n.T("rw.toast.loading_canceled"))
 }
 
 func (m CleanModel) renderHighlightDetail() tea.Cmd {
@@ -499,8 +799,12 @@ func (m CleanModel) renderHighlightDetail() tea.Cmd {
 func (m CleanModel) highlightToOutlinerFormat(highlight *models.Highlight) string {
 	var lines []string
 
-	// Main highlight section
-	lines = append(lines, "• highlight:: "+highlight.Text)
+	// Main highlight section, linked to where it came from
+	source := "• highlight:: " + highlight.Text
+	if link := highlightLink(*highlight, false); link != "" {
+		source += " [source:: " + link + "]"
+	}
+	lines = append(lines, source)
 
 	// Add book info as sub-bullet if available
 	if m.currentBook != nil {
@@ -516,24 +820,12 @@ func (m CleanModel) highlightToOutlinerFormat(highlight *models.Highlight) strin
 		lines = append(lines, "• tags:: "+strings.Join(tagNames, ", "))
 	}
 
-	// Add note section
+	// Add note section, its lines nested as they were saved
 	if highlight.Note != "" {
-		lines = append(lines, "• note:: "+highlight.Note)
-
-		// If note has multiple lines, make them sub-bullets
-		noteLines := strings.Split(highlight.Note, "\n")
-		if len(noteLines) > 1 {
-			// Replace the single line note with structured version
-			lines[len(lines)-1] = "• note::"
-			for _, noteLine := range noteLines {
-				if strings.TrimSpace(noteLine) != "" {
-					lines = append(lines, "  • "+strings.TrimSpace(noteLine))
-				}
-			}
-		}
+		lines = append(lines, noteOutline(highlight.Note)...)
 	} else {
 		lines = append(lines, "• note::")
-		lines = append(lines, "  • *Add your thoughts here*")
+		lines = append(lines, "  • "+notePlaceholder)
 	}
 
 	// Add metadata section
@@ -549,42 +841,29 @@ func (m CleanModel) highlightToOutlinerFormat(highlight *models.Highlight) strin
 	return strings.Join(lines, "\n")
 }
 
-// saveOutlinerContent parses the outliner content and saves it back to Readwise
+// saveOutlinerContent parses the outliner content and saves it back to
+// Readwise. The content and highlight are read here, on the UI goroutine;
+// the command only talks to the API and reports back, with a
+// highlightConflictMsg when Readwise changed since editing began.
 func (m CleanModel) saveOutlinerContent() tea.Cmd {
-	return func() tea.Msg {
-		if m.currentHighlight == nil {
-			return errMsg{fmt.Errorf("no highlight selected")}
-		}
-
-		// Get content from outliner
-		content := m.noteOutliner.GetContent()
-
-		// Parse structured content
-		parsed := m.parser.Parse(content)
-
-		// Convert back to Readwise format
-		highlight, note, _ := parsed.ToReadwiseFormat()
-
-		// Update the highlight via API
-		update := models.HighlightUpdate{
-			Text: highlight,
-			Note: note,
-		}
+	if m.currentHighlight == nil {
+		return func() tea.Msg { return errMsg{fmt.Errorf("no highlight selected")} }
+	}
 
-		updatedHighlight, err := m.api.UpdateHighlight(m.currentHighlight.ID, update)
-		if err != nil {
-			return errMsg{err}
-		}
+	// Parse structured content and convert back to Readwise format
+	parsed := m.parser.Parse(m.noteOutliner.GetContent())
+	highlight, note, _ := parsed.ToReadwiseFormat()
 
-		// Update local state with the response from API
-		if updatedHighlight != nil {
-			m.currentHighlight = updatedHighlight
-		} else {
-			// Fallback to updating local state manually
-			m.currentHighlight.Text = highlight
-			m.currentHighlight.Note = note
-		}
+	local := *m.currentHighlight
+	local.Text = highlight
+	local.Note = outlineNote(note)
+	if color := parsed.Meta["color"]; color != "" {
+		local.Color = color
+	}
 
-		return highlightSavedMsg{}
+	base := m.base
+	if base.ID != local.ID {
+		base = *m.currentHighlight
 	}
+	return saveHighlight(m.api, base, local)
 }
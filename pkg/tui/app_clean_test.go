@@ -0,0 +1,650 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/exp/golden"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/evanschultz/float-rw-client/pkg/api"
+	"github.com/evanschultz/float-rw-client/pkg/api/apitest"
+	"github.com/evanschultz/float-rw-client/pkg/cache"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/dispatchlog"
+	"github.com/evanschultz/float-rw-client/pkg/models"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+	"github.com/muesli/termenv"
+)
+
+func init() {
+	// Golden frames are compared without color
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
+
+// fakeReadwise serves one book with one highlight
+func fakeReadwise(t *testing.T) *api.Client {
+	t.Helper()
+	srv := apitest.NewServer()
+	srv.SetLibrary(
+		[]models.Book{{ID: 1, Title: "Shacks Not Cathedrals", Author: "Float", NumHighlights: 1}},
+		[]models.Highlight{{ID: 10, BookID: 1, Text: "Build the small thing first"}},
+	)
+	t.Cleanup(srv.Close)
+	return srv.APIClient()
+}
+
+// waitForText waits until the program has drawn s
+func waitForText(t *testing.T, tm *teatest.TestModel, s string) {
+	t.Helper()
+	teatest.WaitFor(t, tm.Output(), func(out []byte) bool {
+		return strings.Contains(string(out), s)
+	}, teatest.WithDuration(5*time.Second))
+}
+
+func send(tm *teatest.TestModel, keys ...tea.KeyType) {
+	for _, k := range keys {
+		tm.Send(tea.KeyMsg{Type: k})
+	}
+}
+
+func TestCleanModelEditSave(t *testing.T) {
+	tm := teatest.NewTestModel(t, NewCleanModel(fakeReadwise(t)), teatest.WithInitialTermSize(140, 30))
+
+	waitForText(t, tm, "Shacks Not Cathedrals")
+	send(tm, tea.KeyEnter)
+	waitForText(t, tm, "Build the small thing first")
+	send(tm, tea.KeyRight, tea.KeyEnter, tea.KeyRight)
+
+	// Write a nested note in place of the placeholder
+	tm.Type("e")
+	send(tm, tea.KeyDown, tea.KeyDown, tea.KeyDown, tea.KeyEnd, tea.KeyEnter)
+	tm.Type("start with a shack")
+	send(tm, tea.KeyEnter, tea.KeyTab)
+	tm.Type("then a cathedral")
+	send(tm, tea.KeyCtrlS)
+	// The detail view re-renders with the saved note, nesting kept
+	waitForText(t, tm, "    • then a cathedral")
+
+	if err := tm.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	m := tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second)).(CleanModel)
+
+	if m.editMode != ModeView {
+		t.Error("still in edit mode after ctrl+s")
+	}
+	if m.currentHighlight == nil || m.currentHighlight.Note != "start with a shack\n  then a cathedral" {
+		t.Fatalf("note not saved: %+v", m.currentHighlight)
+	}
+	golden.RequireEqual(t, []byte(m.View()))
+}
+
+func TestSplitModelTextDiff(t *testing.T) {
+	srv := apitest.NewServer()
+	srv.SetLibrary(
+		[]models.Book{{ID: 1, Title: "Shacks Not Cathedrals", Author: "Float", NumHighlights: 1}},
+		[]models.Highlight{{ID: 10, BookID: 1, Text: "Build the small thing first"}},
+	)
+	t.Cleanup(srv.Close)
+	tm := teatest.NewTestModel(t, NewSplitModel(srv.APIClient()), teatest.WithInitialTermSize(140, 30))
+
+	waitForText(t, tm, "Shacks Not Cathedrals")
+	send(tm, tea.KeyEnter)
+	waitForText(t, tm, "Build the small thing first")
+	send(tm, tea.KeyEnter)
+	tm.Type("e")
+	send(tm, tea.KeyCtrlW)
+	send(tm, tea.KeyBackspace, tea.KeyBackspace, tea.KeyBackspace, tea.KeyBackspace, tea.KeyBackspace)
+	tm.Type("today")
+
+	// Saving the edited text waits on its diff; n goes back to editing
+	send(tm, tea.KeyCtrlS)
+	waitForText(t, tm, "1 word removed, 1 added")
+	tm.Type("n")
+	send(tm, tea.KeyCtrlS)
+	send(tm, tea.KeyTab)
+	waitForText(t, tm, "Original")
+	if h, _ := srv.Highlight(10); h.Text != "Build the small thing first" {
+		t.Fatalf("saved before confirming: %q", h.Text)
+	}
+	tm.Type("y")
+	waitForText(t, tm, "Highlight saved")
+
+	if err := tm.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	final := tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second)).(ModelSplit)
+	if final.textDiff != nil || final.editMode != editNone {
+		t.Error("still confirming after y")
+	}
+	if h, _ := srv.Highlight(10); h.Text != "Build the small thing today" {
+		t.Errorf("Readwise has %q", h.Text)
+	}
+
+	diff := newTextDiff("Build the small thing first", "Build one small thing")
+	if removed, added := diff.changes(); removed != 2 || added != 1 {
+		t.Errorf("changes = -%d +%d", removed, added)
+	}
+}
+
+func TestSplitModelResize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	session, err := cache.OpenSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewSplitModel(fakeReadwise(t))
+	m.SetSession(session)
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(140, 30))
+	waitForText(t, tm, "Shacks Not Cathedrals")
+
+	// Widen the books pane, then open a highlight and shrink its detail pane
+	send(tm, tea.KeyCtrlRight, tea.KeyCtrlRight, tea.KeyEnter)
+	waitForText(t, tm, "Build the small thing first")
+	send(tm, tea.KeyEnter, tea.KeyCtrlRight, tea.KeyCtrlUp)
+	if err := tm.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	final := tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second)).(ModelSplit)
+	if final.bookPaneWidth != 49 || final.highlightPaneWidth+final.bookPaneWidth+final.detailPaneWidth != 140 {
+		t.Errorf("panes %d/%d/%d", final.bookPaneWidth, final.highlightPaneWidth, final.detailPaneWidth)
+	}
+	if final.detailPaneWidth >= 50 || final.splitRatio != 0.45 {
+		t.Errorf("detail %d wide, split %v", final.detailPaneWidth, final.splitRatio)
+	}
+
+	// The next session starts from the same layout
+	reopened, err := cache.OpenSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewSplitModel(fakeReadwise(t))
+	restored.SetSession(reopened)
+	restored.width, restored.height = 140, 30
+	restored.calculateLayout()
+	if restored.bookPaneWidth != final.bookPaneWidth || restored.splitRatio != final.splitRatio {
+		t.Errorf("restored books %d split %v from %+v", restored.bookPaneWidth, restored.splitRatio, reopened)
+	}
+
+	// However far it's pushed, every pane keeps its minimum
+	for i := 0; i < 30; i++ {
+		restored.resizePanes(resizeStep)
+	}
+	if restored.highlightPaneWidth < minResizedListWidth {
+		t.Errorf("highlights squeezed to %d", restored.highlightPaneWidth)
+	}
+}
+
+func TestSplitModelLayouts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	session, err := cache.OpenSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewSplitModel(fakeReadwise(t))
+	m.SetSession(session)
+	m.SetStackBelow(90)
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(140, 30))
+	waitForText(t, tm, "Shacks Not Cathedrals")
+
+	tm.Type("L")
+	waitForText(t, tm, "Layout: reading")
+	send(tm, tea.KeyEnter)
+	waitForText(t, tm, "Build the small thing first")
+	send(tm, tea.KeyEnter)
+
+	// A narrow terminal stacks the panes whatever the preset, the focused
+	// detail under its highlights
+	tm.Send(tea.WindowSizeMsg{Width: 80, Height: 30})
+	if err := tm.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	final := tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second)).(ModelSplit)
+	if !final.stacked() || final.detailPaneWidth != 80 || final.bookPaneHeight != 0 || final.highlightPaneHeight+final.detailPaneHeight != final.contentHeight {
+		t.Errorf("stacked %v: detail %d wide, heights %d/%d/%d", final.stacked(), final.detailPaneWidth, final.bookPaneHeight, final.highlightPaneHeight, final.detailPaneHeight)
+	}
+	if view := final.View(); strings.Count(view, "\n") >= 30 || !strings.Contains(view, "Build the small thing first") {
+		t.Errorf("stacked view:\n%s", view)
+	}
+
+	// Widened again, the preset's ratios are back; the custom ones are kept
+	final.width = 140
+	final.calculateLayout()
+	if final.detailPaneWidth != 84 {
+		t.Errorf("reading layout's detail is %d wide", final.detailPaneWidth)
+	}
+	reopened, err := cache.OpenSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Layout != "reading" || reopened.DetailRatio != 0 {
+		t.Errorf("session %+v", reopened)
+	}
+}
+
+// requireFits fails unless view fits a width×height terminal
+func requireFits(t *testing.T, view string, width, height int) {
+	t.Helper()
+	lines := strings.Split(view, "\n")
+	if len(lines) > height {
+		t.Errorf("%d rows in a %d row terminal:\n%s", len(lines), height, view)
+	}
+	for i, line := range lines {
+		if w := lipgloss.Width(line); w > width {
+			t.Errorf("row %d is %d wide in %d columns:\n%s", i, w, width, view)
+			return
+		}
+	}
+}
+
+func TestNarrowTerminals(t *testing.T) {
+	for _, width := range []int{40, 60, 80} {
+		t.Run(fmt.Sprint(width), func(t *testing.T) {
+			// Open the highlight, then step back through every pane
+			tuis := map[string]tea.Model{"split": NewSplitModel(fakeReadwise(t)), "clean": NewCleanModel(fakeReadwise(t))}
+			for name, m := range tuis {
+				tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(width, 24))
+				waitForText(t, tm, "Shacks")
+				send(tm, tea.KeyEnter)
+				waitForText(t, tm, "Build the small")
+				if name == "clean" && width >= 80 {
+					send(tm, tea.KeyRight)
+				}
+				send(tm, tea.KeyEnter)
+				if name == "clean" && width >= 80 {
+					send(tm, tea.KeyRight)
+				}
+				if err := tm.Quit(); err != nil {
+					t.Fatal(err)
+				}
+				final := tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second))
+				requireFits(t, final.View(), width, 24)
+				for i := 0; i < 2; i++ {
+					final, _ = final.Update(tea.KeyMsg{Type: tea.KeyLeft})
+					requireFits(t, final.View(), width, 24)
+				}
+				if width < 80 && !strings.Contains(final.View(), "Highlights") {
+					t.Errorf("%s: no pane switcher:\n%s", name, final.View())
+				}
+				final, _ = final.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")})
+				requireFits(t, final.View(), width, 24)
+			}
+		})
+	}
+}
+
+func TestCleanModelMerge(t *testing.T) {
+	client := fakeReadwise(t)
+	tm := teatest.NewTestModel(t, NewCleanModel(client), teatest.WithInitialTermSize(140, 30))
+
+	waitForText(t, tm, "Shacks Not Cathedrals")
+	send(tm, tea.KeyEnter)
+	waitForText(t, tm, "Build the small thing first")
+	send(tm, tea.KeyRight, tea.KeyEnter, tea.KeyRight)
+	tm.Type("e")
+	waitForText(t, tm, "Add your thoughts here")
+
+	// Someone edits the note and color in Readwise meanwhile
+	if _, err := client.UpdateHighlight(context.Background(), 10, models.HighlightUpdate{Note: "written on the phone", Color: "blue"}); err != nil {
+		t.Fatal(err)
+	}
+	send(tm, tea.KeyDown, tea.KeyDown, tea.KeyDown, tea.KeyEnd, tea.KeyEnter)
+	tm.Type("written at the desk")
+	send(tm, tea.KeyCtrlS)
+	waitForText(t, tm, "note:: (local, conflict)")
+	if remote, _ := client.GetHighlight(context.Background(), 10); remote.Note != "written on the phone" {
+		t.Fatalf("conflicting save went through: %q", remote.Note)
+	}
+
+	// Keep both notes; the color only changed remotely, so it stays
+	tm.Type("b")
+	send(tm, tea.KeyEnter)
+	waitForText(t, tm, "• written on the phone")
+	if err := tm.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	m := tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second)).(CleanModel)
+	remote, _ := client.GetHighlight(context.Background(), 10)
+	if remote.Note != "written at the desk\nwritten on the phone" || remote.Color != "blue" {
+		t.Errorf("merged to %q, %q", remote.Note, remote.Color)
+	}
+	if m.merge != nil || m.editMode != ModeView {
+		t.Error("merge view still open after saving")
+	}
+}
+
+func TestBookShelf(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "library.json")
+	library, err := cache.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	craft := models.Tag{ID: 1, Name: "craft"}
+	srv := apitest.NewServer()
+	srv.SetLibrary([]models.Book{
+		{ID: 1, Title: "Zen and the Shack", Category: "books", NumHighlights: 1, Tags: []models.Tag{craft}},
+		{ID: 2, Title: "Atlas of Rituals", Category: "articles", NumHighlights: 4, Tags: []models.Tag{craft}},
+		{ID: 3, Title: "Moss", Category: "books", NumHighlights: 2},
+	}, nil)
+	t.Cleanup(srv.Close)
+
+	m := NewCleanModel(srv.APIClient())
+	m.SetLibrary(library)
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(140, 30))
+	waitForText(t, tm, "Zen and the Shack")
+
+	// Books by title, archive the first (Moss), then only those tagged craft
+	tm.Type("scA")
+	waitForText(t, tm, "sort: title · books")
+	tm.Type("t")
+	waitForText(t, tm, "› craft 1")
+	if err := tm.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	final := tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second)).(CleanModel)
+
+	var titles []string
+	for _, book := range final.shelf.visible() {
+		titles = append(titles, book.Title)
+	}
+	if strings.Join(titles, ", ") != "Zen and the Shack" {
+		t.Errorf("listed %v", titles)
+	}
+
+	// Everything chosen is back next session
+	reopened, err := cache.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Sort != "title" || reopened.Category != "books" || reopened.Tag != "craft" || !reopened.IsArchived(3) {
+		t.Errorf("library cache has %+v", reopened)
+	}
+}
+
+func TestBookDetail(t *testing.T) {
+	// A black cover, drawn in ASCII as all @
+	covers := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		png.Encode(w, image.NewGray(image.Rect(0, 0, 40, 60)))
+	}))
+	t.Cleanup(covers.Close)
+
+	jan := time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)
+	srv := apitest.NewServer()
+	srv.SetLibrary(
+		[]models.Book{{ID: 1, Title: "Shacks Not Cathedrals", Author: "Float", Category: "books", NumHighlights: 3,
+			DocumentNote: "Read it **slowly**", CoverImageURL: covers.URL + "/cover.png"}},
+		[]models.Highlight{
+			{ID: 10, BookID: 1, Text: "Build the small thing first", HighlightedAt: &jan},
+			{ID: 11, BookID: 1, Text: "Then live in it", HighlightedAt: &mar},
+			{ID: 12, BookID: 1, Text: "Then move the walls", HighlightedAt: &mar},
+		},
+	)
+	t.Cleanup(srv.Close)
+
+	tm := teatest.NewTestModel(t, NewCleanModel(srv.APIClient()), teatest.WithInitialTermSize(140, 30))
+	waitForText(t, tm, "Shacks Not Cathedrals")
+	tm.Type("i")
+	teatest.WaitFor(t, tm.Output(), func(out []byte) bool {
+		return strings.Contains(string(out), "Jan 2024 ▄ █ Mar 2024") &&
+			strings.Contains(string(out), "slowly") &&
+			strings.Contains(string(out), strings.Repeat("@", 20))
+	}, teatest.WithDuration(5*time.Second))
+
+	// enter goes on to the book's highlights
+	send(tm, tea.KeyEnter)
+	waitForText(t, tm, "Then move the walls")
+	if err := tm.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	if m := tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second)).(CleanModel); m.bookInfo != nil {
+		t.Error("book detail still open")
+	}
+
+	// Graphics lay out as a block of the cover's size
+	kitty := components.RenderCover(image.NewGray(image.Rect(0, 0, 4, 4)), components.ProtocolKitty, 20, 12)
+	if lipgloss.Width(kitty) != 20 || lipgloss.Height(kitty) != 12 || !strings.HasPrefix(kitty, "\x1b_G") {
+		t.Errorf("kitty cover is %dx%d", lipgloss.Width(kitty), lipgloss.Height(kitty))
+	}
+}
+
+func TestHighlightLinks(t *testing.T) {
+	h := models.Highlight{ID: 10, Text: "Build the small thing first", URL: "https://example.com/shacks#p1", ReadwiseURL: "https://readwise.io/open/10"}
+	if highlightLink(h, false) != h.URL || highlightLink(h, true) != h.ReadwiseURL {
+		t.Error("links don't follow the source/Readwise choice")
+	}
+	if unsourced := (models.Highlight{ReadwiseURL: h.ReadwiseURL}); highlightLink(unsourced, false) != h.ReadwiseURL {
+		t.Error("a highlight without a source doesn't fall back to Readwise")
+	}
+	if _, ok := linkForKey(nil, "o"); ok {
+		t.Error("link key handled without a highlight")
+	}
+
+	// Pulled into the outliner the source rides along as an annotation,
+	// and saving leaves the text as it was
+	outline := NewCleanModel(nil).highlightToOutlinerFormat(&h)
+	if !strings.Contains(outline, "• highlight:: Build the small thing first [source:: https://example.com/shacks#p1]") {
+		t.Fatalf("outline is %q", outline)
+	}
+	parsed := outliner.NewParser().Parse(outline)
+	if parsed.Highlight != h.Text || parsed.Meta["source"] != h.URL {
+		t.Errorf("parsed back to %q, source %q", parsed.Highlight, parsed.Meta["source"])
+	}
+}
+
+func TestToasts(t *testing.T) {
+	tm := teatest.NewTestModel(t, NewCleanModel(fakeReadwise(t)), teatest.WithInitialTermSize(140, 30))
+	waitForText(t, tm, "Shacks Not Cathedrals")
+
+	// Errors become friendly toasts; past three the rest wait their turn
+	tm.Send(errMsg{fmt.Errorf("list books: %w", api.ErrRateLimited)})
+	for _, text := range []string{"one", "two", "three"} {
+		tm.Send(components.NotifyMsg{Level: components.ToastSuccess, Text: "Saved " + text})
+	}
+	teatest.WaitFor(t, tm.Output(), func(out []byte) bool {
+		return strings.Contains(string(out), "✗ Readwise rate limit hit") && strings.Contains(string(out), "+1 more")
+	}, teatest.WithDuration(5*time.Second))
+
+	// Everything shown stays in the inbox
+	tm.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n"), Alt: true})
+	waitForText(t, tm, "Notifications (4)")
+	if err := tm.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	final := tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second)).(CleanModel)
+	inbox := final.toasts.Inbox()
+	errors := 0
+	for _, toast := range inbox {
+		if toast.Level == components.ToastError {
+			errors++
+		}
+	}
+	if len(inbox) != 4 || errors != 1 || !inbox[0].At.After(inbox[3].At) {
+		t.Errorf("inbox is %+v", inbox)
+	}
+	if len(final.toasts.Active()) != 4 {
+		t.Errorf("%d toasts active, want all 4 before any timer fires", len(final.toasts.Active()))
+	}
+}
+
+func TestLoadProgress(t *testing.T) {
+	srv := apitest.NewServer()
+	srv.SetLibrary(
+		[]models.Book{{ID: 1, Title: "Shacks Not Cathedrals", NumHighlights: 3}},
+		[]models.Highlight{{ID: 10, BookID: 1, Text: "one"}, {ID: 11, BookID: 1, Text: "two"}, {ID: 12, BookID: 1, Text: "three"}},
+	)
+	t.Cleanup(srv.Close)
+	client := srv.APIClient()
+	client.SetPageSize(1)
+
+	tm := teatest.NewTestModel(t, NewCleanModel(client), teatest.WithInitialTermSize(140, 30))
+	waitForText(t, tm, "Shacks Not Cathedrals")
+
+	// Each page of highlights is its own request
+	send(tm, tea.KeyEnter)
+	waitForText(t, tm, "3 items")
+	if n := srv.Requests(); n != 4 {
+		t.Errorf("made %d requests, want the books and 3 pages", n)
+	}
+
+	// A slow reload shows its spinner and esc gives up on it
+	srv.SetLatency(5 * time.Second)
+	send(tm, tea.KeyEnter)
+	waitForText(t, tm, "Loading highlights for Shacks")
+	send(tm, tea.KeyEsc)
+	waitForText(t, tm, "Loading canceled")
+	if err := tm.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	final := tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second)).(CleanModel)
+	if final.highlightLoad != nil || len(final.highlights) != 3 {
+		t.Errorf("after canceling: load %v, %d highlights", final.highlightLoad, len(final.highlights))
+	}
+
+	// The bar counts pages once the first says how many there are
+	load := newActivity("Loading highlights")
+	nextHighlightsPage(client, highlightsPageMsg{load: load, bookID: 1, page: 2, highlights: make([]models.Highlight, 2), count: 5})
+	if load.page != 2 || load.pages != 5 || !strings.Contains(load.view(40), "2/5 pages") {
+		t.Errorf("progress %d/%d: %q", load.page, load.pages, load.view(40))
+	}
+}
+
+func TestSmartViews(t *testing.T) {
+	at := time.Date(2025, 8, 1, 9, 0, 0, 0, time.UTC)
+	later := at.Add(time.Hour)
+	consciousness := models.Tag{ID: 1, Name: "consciousness"}
+	books := []models.Book{
+		{ID: 1, Title: "Shacks Not Cathedrals", Author: "Float", Category: "books", NumHighlights: 2},
+		{ID: 2, Title: "The Ritual Stack", Author: "Evan Schultz", Category: "articles", NumHighlights: 1},
+	}
+	srv := apitest.NewServer()
+	srv.SetLibrary(books, []models.Highlight{
+		{ID: 10, BookID: 1, Text: "Attention is the shack", Tags: []models.Tag{consciousness}, HighlightedAt: &at, Updated: at},
+		{ID: 11, BookID: 1, Text: "Thin walls", HighlightedAt: &at, Updated: at},
+		{ID: 20, BookID: 2, Text: "Rituals are interfaces", Tags: []models.Tag{{ID: 2, Name: "Consciousness"}}, HighlightedAt: &later, Updated: at},
+	})
+	t.Cleanup(srv.Close)
+	client := srv.APIClient()
+
+	path := filepath.Join(t.TempDir(), "highlights.json")
+	highlights, err := cache.OpenHighlights(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	views := map[string]config.ViewConfig{"consciousness": {Tags: []string{"consciousness"}}}
+	m := NewCleanModel(client)
+	m.SetViews(views, highlights)
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(140, 30))
+	waitForText(t, tm, "tagged consciousness")
+
+	// The view is listed first, and opening it syncs the whole library
+	send(tm, tea.KeyEnter)
+	waitForText(t, tm, "2 highlights")
+	if err := tm.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	final := tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second)).(CleanModel)
+	var got []int
+	for _, h := range final.highlights {
+		got = append(got, h.ID)
+	}
+	if fmt.Sprint(got) != "[20 10]" {
+		t.Errorf("view listed %v, want the newest first", got)
+	}
+	if book := final.capture.bookOf(final.highlights[0], final.currentBook); book == nil || book.Title != "The Ritual Stack" {
+		t.Errorf("view highlight captured from %+v", book)
+	}
+
+	// The synced cache is reused, and once stale only changes are fetched
+	reopened, err := cache.OpenHighlights(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	view := smartViews(views)[0]
+	requests := srv.Requests()
+	if msgs := runCmds(loadView(client, newActivity("Loading view"), reopened, view)); len(msgs) != 1 || srv.Requests() != requests {
+		t.Errorf("fresh cache: %v after %d requests", msgs, srv.Requests()-requests)
+	}
+	if _, err := client.UpdateHighlight(context.Background(), 11, models.HighlightUpdate{Note: "consciousness, in passing"}); err != nil {
+		t.Fatal(err)
+	}
+	reopened.Complete(time.Now().Add(-time.Second), time.Now().Add(-time.Hour))
+	text := smartView{name: "passing", cfg: config.ViewConfig{Text: "in passing", Category: "books"}}
+	msgs := runCmds(loadView(client, newActivity("Loading view"), reopened, text))
+	if loaded, ok := msgs[0].(highlightsLoadedMsg); !ok || len(loaded.highlights) != 1 || loaded.highlights[0].ID != 11 {
+		t.Errorf("after an edit: %+v", msgs)
+	}
+	if view.count(reopened) != 2 {
+		t.Errorf("merging the change lost highlights: %d in view", view.count(reopened))
+	}
+}
+
+func TestHighlightCapture(t *testing.T) {
+	log, err := dispatchlog.Open(filepath.Join(t.TempDir(), "dispatch-log.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dispatch := outliner.NewFloatDispatchSystem()
+	dispatch.AddReducer("reading", "collect all highlights", outliner.ReducerMatcher("collect all highlights"))
+	evna := outliner.NewEvnaDispatcher()
+	capture := NewHighlightCapture(dispatch, evna, log)
+
+	book := &models.Book{ID: 1, Title: "Shacks Not Cathedrals", Author: "Float"}
+	highlights := []models.Highlight{
+		{ID: 10, BookID: 1, Text: "Build the small\nthing first"},
+		{ID: 11, BookID: 1, Text: "Thrown away", IsDiscard: true},
+	}
+	for _, msg := range runCmds(capture.Capture(highlights, book)) {
+		if result, ok := msg.(outliner.EvnaResultMsg); ok && (len(result.Results) != 1 || result.Results[0].Collection != "float_highlights") {
+			t.Errorf("evna results %+v", result.Results)
+		}
+		if err, ok := msg.(errMsg); ok {
+			t.Fatal(err.err)
+		}
+	}
+	if cmd := capture.Capture(highlights, book); cmd != nil {
+		t.Error("captured the same highlight twice")
+	}
+
+	collected := dispatch.GetReducerOutput("reading")
+	if len(collected) != 1 || collected[0].Content != "Build the small thing first" || collected[0].Metadata["author"] != "Float" {
+		t.Fatalf("reducer collected %+v", collected)
+	}
+	entries, _ := log.ReadSince(0)
+	if len(entries) != 1 || entries[0].Source != "readwise" || entries[0].Context["book"] != "Shacks Not Cathedrals" {
+		t.Fatalf("log has %+v", entries)
+	}
+
+	// A later session knows what's already been captured
+	if again := NewHighlightCapture(outliner.NewFloatDispatchSystem(), evna, log); !again.Captured(10) || again.Captured(11) {
+		t.Error("captures weren't restored from the log")
+	}
+}
+
+// runCmds runs cmd and the commands it batches, returning their messages
+func runCmds(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	batch, ok := msg.(tea.BatchMsg)
+	if !ok {
+		return []tea.Msg{msg}
+	}
+	var msgs []tea.Msg
+	for _, c := range batch {
+		msgs = append(msgs, runCmds(c)...)
+	}
+	return msgs
+}
@@ -1,21 +1,29 @@
 package tui
 
 import (
+	"context"
 	"fmt"
-	"net/url"
+	"log/slog"
+	"math"
 	"os"
 	"os/exec"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/evanschultz/float-rw-client/pkg/api"
+	"github.com/evanschultz/float-rw-client/pkg/cache"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
 	"github.com/evanschultz/float-rw-client/pkg/models"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
 )
 
 type focusedPane int
@@ -38,8 +46,17 @@ const (
 	minBookPaneWidth = 25
 	maxBookPaneWidth = 35
 	minPaneHeight    = 10
+
+	// Panes resized with ctrl+arrows keep at least these widths
+	minResizedBookWidth   = 15
+	minResizedListWidth   = 25
+	minResizedDetailWidth = 30
 )
 
+// resizeStep is how far one ctrl+arrow moves a pane boundary, as a share
+// of the screen
+const resizeStep = 0.05
+
 type ModelSplit struct {
 	api    *api.Client
 	width  int
@@ -52,6 +69,11 @@ type ModelSplit struct {
 	detailPaneWidth    int
 	contentHeight      int
 
+	// Pane heights: the content height, or less once stacked
+	bookPaneHeight      int
+	highlightPaneHeight int
+	detailPaneHeight    int
+
 	// Components
 	bookList        list.Model
 	highlightList   list.Model
@@ -67,17 +89,34 @@ type ModelSplit struct {
 	currentBook       *models.Book
 	currentHighlight  *models.Highlight
 	originalHighlight *models.Highlight
-	nextPageURL       string
+	textDiff          *textDiff // the text edit waiting on confirmation before saving
 
 	// UI state
 	focusedPane     focusedPane
 	editMode        editMode
-	activeEditor    int // 0 = highlight, 1 = note
-	loading         bool
+	activeEditor    int       // 0 = highlight, 1 = note
+	bookLoad        *activity // nil once loaded
+	highlightLoad   *activity
 	saving          bool
-	err             error
+	toasts          components.Toasts
 	booksPaneHidden bool
-	splitRatio      float64
+	splitRatio      float64        // the highlight's share of the detail pane
+	bookRatio       float64        // the books pane's share of the width; 0 sizes it to fit
+	detailRatio     float64        // the detail pane's share of the width; 0 sizes it to fit
+	session         *cache.Session // where the ratios are kept
+	layout          int            // the layoutPresets entry in use
+	stackBelow      int            // widths below this stack the panes; 0 never does
+
+	// Highlight-to-consciousness pipeline
+	capture *HighlightCapture
+
+	// Book list sorting, filters and archive
+	shelf *bookShelf
+
+	// Book detail overlay, and how covers are drawn in it
+	bookInfo      *bookDetail
+	coverProtocol components.ImageProtocol
+	clearImages   bool // covers drawn before the overlay closed need erasing
 }
 
 func NewSplitModel(apiClient *api.Client) ModelSplit {
@@ -87,6 +126,13 @@ func NewSplitModel(apiClient *api.Client) ModelSplit {
 		help:        help.New(),
 		splitRatio:  0.5,
 		editMode:    editNone,
+		bookLoad:    newActivity(i18n.T("rw.status.loading_books")),
+		toasts:      components.NewToasts(),
+		capture:     defaultCapture(),
+		shelf:       newBookShelf(),
+		session:     cache.NewSession(),
+
+		coverProtocol: components.ProtocolASCII,
 	}
 
 	// Initialize lists with custom delegates
@@ -127,14 +173,14 @@ func NewSplitModel(apiClient *api.Client) ModelSplit {
 
 	// Initialize text areas for editing
 	m.highlightEditor = textarea.New()
-	m.highlightEditor.Placeholder = "Edit highlight text..."
+	m.highlightEditor.Placeholder = i18n.T("rw.status.edit_placeholder")
 	m.highlightEditor.CharLimit = 5000
 	m.highlightEditor.SetHeight(10)
 	m.highlightEditor.FocusedStyle.CursorLine = lipgloss.NewStyle()
 	m.highlightEditor.ShowLineNumbers = false
 
 	m.noteEditor = textarea.New()
-	m.noteEditor.Placeholder = "Add your note..."
+	m.noteEditor.Placeholder = i18n.T("rw.status.note_placeholder")
 	m.noteEditor.CharLimit = 10000
 	m.noteEditor.SetHeight(10)
 	m.noteEditor.FocusedStyle.CursorLine = lipgloss.NewStyle()
@@ -143,17 +189,74 @@ func NewSplitModel(apiClient *api.Client) ModelSplit {
 	return m
 }
 
+// SetCapture sets where captured highlights are dispatched
+func (m *ModelSplit) SetCapture(capture *HighlightCapture) {
+	m.capture = capture
+}
+
+// SetLibrary sets the local library cache the book list's sorting,
+// filters and archive are kept in
+func (m *ModelSplit) SetLibrary(library *cache.Library) {
+	m.shelf.library = library
+}
+
+// SetViews sets the smart views listed above the books, and the cache of
+// every book's highlights they're read from
+func (m *ModelSplit) SetViews(views map[string]config.ViewConfig, highlights *cache.Highlights) {
+	m.shelf.views = smartViews(views)
+	m.shelf.highlights = highlights
+}
+
+// SetSession sets the session the pane ratios and layout preset are
+// restored from and kept in as they change
+func (m *ModelSplit) SetSession(session *cache.Session) {
+	m.session = session
+	m.bookRatio, m.detailRatio = session.BookRatio, session.DetailRatio
+	if session.SplitRatio > 0 {
+		m.splitRatio = session.SplitRatio
+	}
+	m.layout = layoutPresetIndex(session.Layout)
+}
+
+// SetStackBelow sets the terminal width below which the panes are stacked
+// whatever the preset; 0 only stacks them in the stacked preset
+func (m *ModelSplit) SetStackBelow(width int) {
+	m.stackBelow = width
+}
+
+// SetCoverProtocol sets how the book detail draws covers
+func (m *ModelSplit) SetCoverProtocol(protocol components.ImageProtocol) {
+	m.coverProtocol = protocol
+}
+
 func (m ModelSplit) Init() tea.Cmd {
-	m.loading = true
 	return tea.Batch(
-		m.loadBooks(),
+		loadBooks(m.api, m.bookLoad),
+		m.bookLoad.tick(),
 		tea.EnterAltScreen,
 	)
 }
 
+// Update handles msg, then sizes stacked panes again: which list is shown
+// over an open highlight follows the focus
 func (m ModelSplit) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	if split, ok := model.(ModelSplit); ok && split.ready && split.stacked() {
+		split.calculateLayout()
+		split.updateComponentSizes()
+		return split, cmd
+	}
+	return model, cmd
+}
+
+func (m ModelSplit) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	// Notifications and their dismissal timers
+	if m.toasts.Update(msg) {
+		return m, m.toasts.Flush()
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
@@ -167,10 +270,69 @@ func (m ModelSplit) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.Batch(cmds...)
 
 	case tea.KeyMsg:
+		m.clearImages = false
+		// alt+n opens the notification inbox, which takes keys while it's open
+		if msg.String() == "alt+n" {
+			m.toasts.ToggleInbox()
+			return m, nil
+		}
+		if m.toasts.InboxOpen() {
+			switch msg.String() {
+			case "ctrl+c", "ctrl+d":
+				return m, tea.Quit
+			case "esc", "q":
+				m.toasts.ToggleInbox()
+			}
+			return m, nil
+		}
+
+		// The book detail overlay takes keys while it's open
+		if m.bookInfo != nil {
+			switch msg.String() {
+			case "ctrl+c", "ctrl+d":
+				return m, tea.Quit
+			case "esc", "i", "q":
+				m.closeBookDetail()
+			case "enter":
+				book := m.bookInfo.book
+				m.closeBookDetail()
+				m.currentBook = &book
+				m.currentHighlight = nil
+				m.focusedPane = focusHighlights
+				cmd := m.loadHighlights(book)
+				return m, cmd
+			}
+			return m, nil
+		}
+
+		// A text edit is confirmed against its diff before it's saved
+		if m.textDiff != nil {
+			switch msg.String() {
+			case "ctrl+c":
+				return m, tea.Quit
+			case "y", "enter", "ctrl+s":
+				m.textDiff = nil
+				return m, m.saveEdits()
+			case "tab":
+				m.textDiff.sideBySide = !m.textDiff.sideBySide
+			case "n", "esc":
+				m.textDiff = nil
+			case "ctrl+q":
+				m.textDiff = nil
+				m.cancelEdit()
+				return m, m.renderHighlightDetail()
+			}
+			return m, nil
+		}
+
 		// When in edit mode, handle editor keys first
 		if m.editMode != editNone {
 			switch msg.String() {
 			case "ctrl+s":
+				if m.editMode == editBoth && m.highlightEditor.Value() != m.currentHighlight.Text {
+					m.textDiff = newTextDiff(m.currentHighlight.Text, m.highlightEditor.Value())
+					return m, nil
+				}
 				cmds = append(cmds, m.saveEdits())
 				return m, tea.Batch(cmds...)
 			case "ctrl+q":
@@ -209,6 +371,11 @@ func (m ModelSplit) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		// esc cancels loads in flight before anything else
+		if msg.String() == "esc" && (m.bookLoad != nil || m.highlightLoad != nil) {
+			return m, m.cancelLoads()
+		}
+
 		// Normal mode key handling
 		switch msg.String() {
 		case "ctrl+c", "ctrl+d":
@@ -223,10 +390,27 @@ func (m ModelSplit) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, tea.Batch(cmds...)
 
+		case "ctrl+left", "ctrl+right":
+			delta := resizeStep
+			if msg.String() == "ctrl+left" {
+				delta = -delta
+			}
+			return m, m.resizePanes(delta)
+
+		case "ctrl+up", "ctrl+down":
+			delta := resizeStep
+			if msg.String() == "ctrl+up" {
+				delta = -delta
+			}
+			return m, m.resizeSplit(delta)
+
+		case "L":
+			if m.bookList.FilterState() != list.Filtering && m.highlightList.FilterState() != list.Filtering {
+				return m, m.cycleLayout()
+			}
+
 		case "tab":
 			m.cycleFocus()
-			// Debug: uncomment to see focus changes
-			// fmt.Printf("Focus changed to: %d\n", m.focusedPane)
 			return m, nil
 
 		case "left", "h":
@@ -248,18 +432,35 @@ func (m ModelSplit) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch m.focusedPane {
 		case focusBooks:
 			if !m.booksPaneHidden {
+				if m.bookList.FilterState() != list.Filtering {
+					var selected *models.Book
+					if i, ok := m.bookList.SelectedItem().(bookItem); ok {
+						selected = &i.book
+					}
+					if handled, cmd := m.shelf.handleKey(msg.String(), selected); handled {
+						m.shelf.apply(&m.bookList)
+						m.updateComponentSizes()
+						return m, cmd
+					}
+				}
 				switch msg.String() {
 				case "enter":
 					if i, ok := m.bookList.SelectedItem().(bookItem); ok {
 						m.currentBook = &i.book
 						m.currentHighlight = nil
 						m.focusedPane = focusHighlights
-						m.loading = true
-						return m, m.loadHighlights(i.book.ID)
+						cmd := m.loadHighlights(i.book)
+						return m, cmd
+					}
+				case "i":
+					if i, ok := m.bookList.SelectedItem().(bookItem); ok && !m.shelf.isView(&i.book) {
+						m.bookInfo = newBookDetail(i.book, m.coverProtocol)
+						return m, tea.Batch(m.bookInfo.load.tick(), loadBookDetail(m.api, m.bookInfo, m.width))
 					}
 				case "r":
-					m.loading = true
-					return m, m.loadBooks()
+					m.bookLoad.stop()
+					m.bookLoad = newActivity(i18n.T("rw.status.loading_books"))
+					return m, tea.Batch(loadBooks(m.api, m.bookLoad), m.bookLoad.tick())
 				default:
 					newList, cmd := m.bookList.Update(msg)
 					m.bookList = newList
@@ -268,7 +469,12 @@ func (m ModelSplit) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case focusHighlights:
-			switch msg.String() {
+			key := msg.String()
+			if m.highlightList.FilterState() == list.Filtering && key != "enter" && key != "esc" {
+				// Typed into the filter, the keys are search text
+				key = ""
+			}
+			switch key {
 			case "enter":
 				if i, ok := m.highlightList.SelectedItem().(highlightItem); ok {
 					m.currentHighlight = &i.highlight
@@ -286,6 +492,18 @@ func (m ModelSplit) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.focusedPane = focusBooks
 				}
 				return m, nil
+			case "p":
+				if i, ok := m.highlightList.SelectedItem().(highlightItem); ok {
+					return m, m.capture.Capture([]models.Highlight{i.highlight}, m.currentBook)
+				}
+			case "P":
+				return m, m.capture.Capture(m.highlights, m.currentBook)
+			case "a":
+				m.capture.SetAuto(!m.capture.Auto())
+				if m.capture.Auto() {
+					return m, m.capture.Capture(m.highlights, m.currentBook)
+				}
+				return m, nil
 			default:
 				newList, cmd := m.highlightList.Update(msg)
 				m.highlightList = newList
@@ -302,6 +520,17 @@ func (m ModelSplit) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			case "ctrl+e":
 				return m, m.openExternalEditor()
+			case "f":
+				return m, m.updateCurrentHighlight(toggleFavoriteUpdate(*m.currentHighlight))
+			case "x":
+				return m, m.updateCurrentHighlight(toggleDiscardUpdate(*m.currentHighlight))
+			case "c":
+				return m, m.updateCurrentHighlight(cycleColorUpdate(*m.currentHighlight))
+			case "p":
+				return m, m.capture.Capture([]models.Highlight{*m.currentHighlight}, m.currentBook)
+			case "o", "O", "y", "Y":
+				cmd, _ := linkForKey(m.currentHighlight, msg.String())
+				return m, cmd
 			case "esc":
 				// Go back to highlights pane
 				m.focusedPane = focusHighlights
@@ -318,24 +547,59 @@ func (m ModelSplit) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case spinner.TickMsg:
+		cmds = append(cmds, m.bookLoad.update(msg), m.highlightLoad.update(msg))
+		if m.bookInfo != nil {
+			cmds = append(cmds, m.bookInfo.load.update(msg))
+		}
+
 	case booksLoadedMsg:
-		m.loading = false
+		if msg.load != m.bookLoad {
+			break
+		}
+		m.bookLoad = nil
 		m.books = msg.books
-		items := make([]list.Item, len(m.books))
-		for i, book := range m.books {
-			items[i] = bookItem{book: book}
+		m.shelf.books = msg.books
+		m.capture.SetBooks(msg.books)
+		m.shelf.apply(&m.bookList)
+		m.updateComponentSizes()
+
+	case bookDetailLoadedMsg:
+		if m.bookInfo != nil && m.bookInfo.book.ID == msg.detail.book.ID {
+			m.bookInfo = msg.detail
+		}
+
+	case highlightsPageMsg:
+		if msg.load == m.highlightLoad {
+			cmds = append(cmds, nextHighlightsPage(m.api, msg))
+		}
+
+	case viewPageMsg:
+		if msg.load == m.highlightLoad {
+			cmds = append(cmds, nextViewPage(m.api, m.shelf.highlights, msg))
 		}
-		m.bookList.SetItems(items)
 
 	case highlightsLoadedMsg:
-		m.loading = false
+		if msg.load != m.highlightLoad {
+			break
+		}
+		m.highlightLoad = nil
 		m.highlights = msg.highlights
-		m.nextPageURL = msg.nextPageURL
 		items := make([]list.Item, len(m.highlights))
 		for i, highlight := range m.highlights {
 			items[i] = highlightItem{highlight: highlight}
 		}
 		m.highlightList.SetItems(items)
+		if m.shelf.isView(m.currentBook) {
+			// The sync may have changed the views' counts
+			m.shelf.apply(&m.bookList)
+		}
+		if m.capture.Auto() {
+			cmds = append(cmds, m.capture.Capture(m.highlights, m.currentBook))
+		}
+
+	case outliner.EvnaResultMsg:
+		cmds = append(cmds, captureNotice(msg))
 
 	case highlightRenderedMsg:
 		m.highlightView.SetContent(msg.content)
@@ -344,6 +608,8 @@ func (m ModelSplit) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case highlightSavedMsg:
 		m.saving = false
 		m.editMode = editNone
+		saved := *m.currentHighlight
+		m.originalHighlight = &saved // what a later edit cancels back to
 		items := m.highlightList.Items()
 		for i, item := range items {
 			if h, ok := item.(highlightItem); ok && h.highlight.ID == m.currentHighlight.ID {
@@ -352,11 +618,33 @@ func (m ModelSplit) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 		m.highlightList.SetItems(items)
-		cmds = append(cmds, m.renderHighlightDetail())
+		cmds = append(cmds, m.renderHighlightDetail(), components.Notify(components.ToastSuccess, i18n.T("rw.toast.highlight_saved")))
+
+	case highlightUpdatedMsg:
+		if m.currentHighlight != nil && m.currentHighlight.ID == msg.highlight.ID {
+			*m.currentHighlight = msg.highlight
+		}
+		replaceHighlight(m.highlights, &m.highlightList, msg.highlight)
+
+	case highlightUpdateFailedMsg:
+		// Roll back the optimistic update
+		if m.currentHighlight != nil && m.currentHighlight.ID == msg.original.ID {
+			*m.currentHighlight = msg.original
+		}
+		replaceHighlight(m.highlights, &m.highlightList, msg.original)
+		cmds = append(cmds, components.NotifyError(msg.err))
+
+	case loadFailedMsg:
+		if msg.load == m.bookLoad {
+			m.bookLoad = nil
+		}
+		if msg.load == m.highlightLoad {
+			m.highlightLoad = nil
+		}
+		cmds = append(cmds, components.NotifyError(msg.err))
 
 	case errMsg:
-		m.err = msg.err
-		m.loading = false
+		cmds = append(cmds, components.NotifyError(msg.err))
 		m.saving = false
 
 	case externalEditorFinishedMsg:
@@ -405,25 +693,11 @@ func (m ModelSplit) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m ModelSplit) View() string {
-	if m.err != nil {
-		return fmt.Sprintf("Error: %v\n\nPress ctrl+c to quit.", m.err)
-	}
-
 	if !m.ready || m.width == 0 || m.height == 0 {
-		return "Initializing..."
+		return i18n.T("rw.status.initializing")
 	}
 
 	// Create styles
-	focusedStyle := lipgloss.NewStyle().
-		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("62")).
-		Padding(0, 1)
-
-	unfocusedStyle := lipgloss.NewStyle().
-		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("240")).
-		Padding(0, 1)
-
 	hiddenStyle := lipgloss.NewStyle().
 		BorderStyle(lipgloss.NormalBorder()).
 		BorderForeground(lipgloss.Color("240")).
@@ -435,104 +709,96 @@ func (m ModelSplit) View() string {
 	// Build panes
 	var panes []string
 
-	// Books pane
-	if m.booksPaneHidden {
-		indicator := strings.Repeat("│\n", m.contentHeight-2)
+	// Books pane; stacked, a hidden one takes no room
+	stacked := m.stacked()
+	if stacked && m.bookPaneHeight == 0 {
+		// Hidden
+	} else if m.booksPaneHidden && !stacked {
+		glyph := "│"
+		if accessible {
+			glyph = " "
+			hiddenStyle = hiddenStyle.BorderStyle(lipgloss.HiddenBorder())
+		}
+		indicator := strings.Repeat(glyph+"\n", m.contentHeight-2)
 		bookPane := hiddenStyle.
 			Height(m.contentHeight).
 			Render(indicator)
 		panes = append(panes, bookPane)
 	} else {
-		bookContent := m.bookList.View()
-		if m.loading && m.focusedPane == focusBooks {
-			bookContent = "Loading books..."
-		}
-
-		bookPane := bookContent
-		if m.focusedPane == focusBooks && m.editMode == editNone {
-			bookPane = focusedStyle.
-				Width(m.bookPaneWidth - 4).
-				Height(m.contentHeight - 2).
-				Render(bookContent)
-		} else {
-			bookPane = unfocusedStyle.
-				Width(m.bookPaneWidth - 4).
-				Height(m.contentHeight - 2).
-				Render(bookContent)
+		bookContent := m.bookList.View() + "\n" + m.shelf.facetView(paneInner(m.bookPaneWidth))
+		if m.bookLoad != nil {
+			bookContent = m.bookLoad.view(paneInner(m.bookPaneWidth))
 		}
-		panes = append(panes, bookPane)
+		focused := m.focusedPane == focusBooks && m.editMode == editNone
+		panes = append(panes, renderPane(bookContent, m.bookPaneWidth, m.bookPaneHeight, focused))
 	}
 
-	// Highlights pane
-	if m.currentBook != nil {
+	// Highlights pane; stacked, it gives way to the books when they're
+	// focused over a highlight
+	if m.currentBook != nil && (!stacked || m.highlightPaneHeight > 0) {
 		highlightContent := m.highlightList.View()
-		if m.loading && m.focusedPane == focusHighlights {
-			highlightContent = fmt.Sprintf("Loading highlights for %s...", m.currentBook.Title)
+		if m.highlightLoad != nil {
+			highlightContent = m.highlightLoad.view(paneInner(m.highlightPaneWidth))
 		}
-
-		highlightPane := highlightContent
-		if m.focusedPane == focusHighlights && m.editMode == editNone {
-			highlightPane = focusedStyle.
-				Width(m.highlightPaneWidth - 4).
-				Height(m.contentHeight - 2).
-				Render(highlightContent)
-		} else {
-			highlightPane = unfocusedStyle.
-				Width(m.highlightPaneWidth - 4).
-				Height(m.contentHeight - 2).
-				Render(highlightContent)
-		}
-		panes = append(panes, highlightPane)
+		focused := m.focusedPane == focusHighlights && m.editMode == editNone
+		panes = append(panes, renderPane(highlightContent, m.highlightPaneWidth, m.highlightPaneHeight, focused))
 	}
 
-	// Detail pane - show whenever we have a highlight
-	if m.currentHighlight != nil {
+	// Detail pane - show whenever we have a highlight, unless another pane
+	// has the one a narrow terminal shows
+	if m.currentHighlight != nil && (!stacked || m.detailPaneHeight > 0) {
 		var detailContent string
 
 		if m.saving {
-			detailContent = "Saving..."
+			detailContent = i18n.T("rw.status.saving")
 		} else if m.editMode != editNone {
 			detailContent = m.renderEditView()
 		} else {
 			detailContent = m.renderSplitView()
 		}
 
-		detailPane := detailContent
-		if m.focusedPane == focusDetail || m.editMode != editNone {
-			detailPane = focusedStyle.
-				Width(m.detailPaneWidth - 4).
-				Height(m.contentHeight - 2).
-				Render(detailContent)
-		} else {
-			detailPane = unfocusedStyle.
-				Width(m.detailPaneWidth - 4).
-				Height(m.contentHeight - 2).
-				Render(detailContent)
-		}
-		panes = append(panes, detailPane)
+		focused := m.focusedPane == focusDetail || m.editMode != editNone
+		panes = append(panes, renderPane(detailContent, m.detailPaneWidth, m.detailPaneHeight, focused))
 	}
 
-	// Join panes horizontally
+	// Join panes side by side, or one above another; the book detail
+	// covers them
 	content := lipgloss.JoinHorizontal(lipgloss.Top, panes...)
+	if stacked {
+		content = lipgloss.JoinVertical(lipgloss.Left, panes...)
+	}
+	if m.singlePane() {
+		content = lipgloss.JoinVertical(lipgloss.Left, paneSwitcher(int(m.shownPane()), m.openPanes(), m.width), content)
+	}
+	if m.toasts.InboxOpen() {
+		content = components.ClearImages(m.coverProtocol) + m.toasts.InboxView(m.width, m.contentHeight)
+	} else if m.bookInfo != nil {
+		content = m.bookInfo.view(m.width, m.contentHeight)
+	} else if m.clearImages {
+		content = components.ClearImages(m.coverProtocol) + content
+	}
 
 	// Add help text
 	helpText := m.getHelpText()
 	helpStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("241")).
 		Align(lipgloss.Center).
-		Width(m.width)
+		Width(m.width).
+		MaxHeight(2)
 
-	return lipgloss.JoinVertical(
+	// Toasts sit over the top right corner
+	return m.toasts.Overlay(lipgloss.JoinVertical(
 		lipgloss.Top,
 		content,
 		helpStyle.Render(helpText),
-	)
+	), m.width)
 }
 
 func (m ModelSplit) renderSplitView() string {
-	innerWidth := max(1, m.detailPaneWidth-6)
-	splitHeight := max(2, m.contentHeight-4)
-	highlightHeight := max(1, int(float64(splitHeight)*m.splitRatio))
+	innerWidth := paneInner(m.detailPaneWidth)
+	_, _, split := m.ratios()
+	splitHeight := max(2, m.detailPaneHeight-4)
+	highlightHeight := max(1, int(float64(splitHeight)*split))
 	noteHeight := max(1, splitHeight-highlightHeight-1)
 
 	// Highlight section with border indicator
@@ -615,9 +881,12 @@ func (m ModelSplit) renderSplitView() string {
 }
 
 func (m ModelSplit) renderEditView() string {
-	innerWidth := max(20, m.detailPaneWidth-6)
-	innerHeight := max(10, m.contentHeight-4)
+	innerWidth := max(20, paneInner(m.detailPaneWidth))
+	innerHeight := max(10, m.detailPaneHeight-4)
 
+	if m.textDiff != nil {
+		return m.textDiff.view(innerWidth)
+	}
 	if m.editMode == editNote {
 		m.noteEditor.SetWidth(innerWidth)
 		m.noteEditor.SetHeight(innerHeight)
@@ -651,9 +920,9 @@ func (m ModelSplit) renderEditView() string {
 
 		return lipgloss.JoinVertical(
 			lipgloss.Top,
-			lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("Highlight:"),
+			lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(i18n.T("rw.edit.highlight")),
 			highlightSection,
-			lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("Note:"),
+			lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(i18n.T("rw.edit.note")),
 			noteSection,
 		)
 	}
@@ -661,6 +930,9 @@ func (m ModelSplit) renderEditView() string {
 	return ""
 }
 
+// scrollbarWidth is the room addScrollbar takes right of a viewport
+const scrollbarWidth = 2
+
 func (m ModelSplit) addScrollbar(content string, height int, scrollPercent float64) string {
 	if height <= 0 {
 		return content
@@ -700,10 +972,19 @@ func (m *ModelSplit) calculateLayout() {
 
 	helpHeight := 2
 	m.contentHeight = m.height - helpHeight
+	m.bookPaneHeight, m.highlightPaneHeight, m.detailPaneHeight = m.contentHeight, m.contentHeight, m.contentHeight
 
-	// Debug: uncomment to see layout calculations
-	// fmt.Printf("Layout: width=%d, highlight=%v, bookWidth=%d, highlightWidth=%d, detailWidth=%d\n",
-	//	m.width, m.currentHighlight != nil, m.bookPaneWidth, m.highlightPaneWidth, m.detailPaneWidth) // PRIORITY: If we have a highlight, detail panel MUST be visible
+	defer func() {
+		slog.Debug("layout", "width", m.width, "highlight", m.currentHighlight != nil,
+			"books", m.bookPaneWidth, "highlights", m.highlightPaneWidth, "detail", m.detailPaneWidth)
+	}()
+
+	if m.stacked() {
+		m.stackPanes()
+		return
+	}
+
+	// PRIORITY: If we have a highlight, detail panel MUST be visible
 	// This ensures the highlight/note view is always accessible
 	if m.currentHighlight != nil {
 		// Force minimum detail panel width
@@ -751,34 +1032,109 @@ func (m *ModelSplit) calculateLayout() {
 			m.detailPaneWidth = 0
 		}
 	}
+	if bookRatio, detailRatio, _ := m.ratios(); bookRatio > 0 || detailRatio > 0 {
+		m.applyRatios(bookRatio, detailRatio)
+	}
+}
+
+// applyRatios sizes the books and detail panes by the layout's ratios, the
+// highlights pane taking the rest; every pane keeps its minimum width
+func (m *ModelSplit) applyRatios(bookRatio, detailRatio float64) {
+	books, detail := m.bookPaneWidth, m.detailPaneWidth
+	if detail > 0 && detailRatio > 0 {
+		detail = int(float64(m.width) * detailRatio)
+	}
+	if detail > 0 {
+		detail = max(minResizedDetailWidth, detail)
+	}
+	if !m.booksPaneHidden {
+		if bookRatio > 0 {
+			books = int(float64(m.width) * bookRatio)
+		}
+		books = max(minResizedBookWidth, min(books, m.width-minResizedListWidth-detail))
+	}
+	if detail > 0 {
+		detail = max(minResizedDetailWidth, min(detail, m.width-books-minResizedListWidth))
+	}
+	m.bookPaneWidth, m.detailPaneWidth = books, detail
+	m.highlightPaneWidth = m.width - books - detail
+}
+
+// resizePanes moves a pane boundary by delta of the width: the right edge
+// of the books pane while it's focused or no highlight is open, else the
+// left edge of the detail pane. Stacked panes have no boundary to move.
+func (m *ModelSplit) resizePanes(delta float64) tea.Cmd {
+	if m.width == 0 || m.stacked() {
+		return nil
+	}
+	m.customize()
+	width := float64(m.width)
+	switch {
+	case m.currentHighlight != nil && (m.focusedPane != focusBooks || m.booksPaneHidden):
+		m.detailRatio = float64(m.detailPaneWidth)/width - delta
+	case !m.booksPaneHidden:
+		m.bookRatio = float64(m.bookPaneWidth)/width + delta
+	default:
+		return nil
+	}
+	return tea.Batch(m.relayout(), m.saveLayout())
+}
+
+// resizeSplit moves the boundary between the highlight and its note in the
+// detail pane by delta of its height
+func (m *ModelSplit) resizeSplit(delta float64) tea.Cmd {
+	if m.detailPaneWidth == 0 {
+		return nil
+	}
+	m.customize()
+	m.splitRatio = math.Max(0.2, math.Min(0.8, m.splitRatio+delta))
+	m.updateComponentSizes()
+	return tea.Batch(m.renderHighlightDetail(), m.saveLayout())
+}
+
+// saveLayout keeps the pane ratios and preset in the session
+func (m *ModelSplit) saveLayout() tea.Cmd {
+	err := m.session.Update(func(s *cache.Session) {
+		s.BookRatio, s.DetailRatio, s.SplitRatio = m.bookRatio, m.detailRatio, m.splitRatio
+		s.Layout = ""
+		if m.layout > 0 {
+			s.Layout = m.preset().name
+		}
+	})
+	if err != nil {
+		return components.NotifyError(err)
+	}
+	return nil
 }
 func (m *ModelSplit) updateComponentSizes() {
 	// Update list sizes
 	if !m.booksPaneHidden {
-		m.bookList.SetSize(m.bookPaneWidth-6, m.contentHeight-2)
+		m.bookList.SetSize(paneInner(m.bookPaneWidth), max(1, m.bookPaneHeight-2-m.shelf.facetHeight()))
 	}
-	m.highlightList.SetSize(m.highlightPaneWidth-6, m.contentHeight-2)
+	m.highlightList.SetSize(paneInner(m.highlightPaneWidth), max(1, m.highlightPaneHeight-2))
 
 	// Update viewport sizes
 	if m.detailPaneWidth > 0 {
-		splitHeight := m.contentHeight - 4
-		highlightHeight := int(float64(splitHeight) * m.splitRatio)
+		_, _, split := m.ratios()
+		splitHeight := m.detailPaneHeight - 4
+		highlightHeight := int(float64(splitHeight) * split)
 		noteHeight := splitHeight - highlightHeight - 1
 
-		// Ensure minimum heights
-		if highlightHeight < minPaneHeight {
-			highlightHeight = minPaneHeight
+		// Ensure minimum heights, less in a short stacked pane
+		minHeight := min(minPaneHeight, max(1, (splitHeight-1)/2))
+		if highlightHeight < minHeight {
+			highlightHeight = minHeight
 			noteHeight = splitHeight - highlightHeight - 1
 		}
-		if noteHeight < minPaneHeight {
-			noteHeight = minPaneHeight
+		if noteHeight < minHeight {
+			noteHeight = minHeight
 			highlightHeight = splitHeight - noteHeight - 1
 		}
 
-		m.highlightView.Width = m.detailPaneWidth - 8 // Account for padding and scrollbar
+		m.highlightView.Width = max(1, paneInner(m.detailPaneWidth)-scrollbarWidth)
 		m.highlightView.Height = highlightHeight
 
-		m.noteView.Width = m.detailPaneWidth - 8
+		m.noteView.Width = m.highlightView.Width
 		m.noteView.Height = noteHeight
 	}
 }
@@ -862,9 +1218,14 @@ func (m *ModelSplit) cycleFocus() {
 	currentIndex := m.findPaneIndex()
 	m.focusedPane = panes[(currentIndex+1)%len(panes)]
 
-	// Debug: uncomment to see focus transitions
-	// fmt.Printf("Focus: %d -> %d (available: %v)\n", oldFocus, m.focusedPane, panes)
-	_ = oldFocus // Prevent unused variable warning
+	slog.Debug("focus", "from", oldFocus, "to", m.focusedPane, "panes", panes)
+}
+
+// closeBookDetail closes the book detail, erasing any cover it drew
+func (m *ModelSplit) closeBookDetail() {
+	m.bookInfo.load.stop()
+	m.clearImages = m.bookInfo.cover != ""
+	m.bookInfo = nil
 }
 
 func (m *ModelSplit) startEdit(mode editMode) {
@@ -904,55 +1265,73 @@ func (m ModelSplit) getHelpText() string {
 	var parts []string
 
 	if m.booksPaneHidden {
-		parts = append(parts, "ctrl+b: show books")
+		parts = append(parts, i18n.T("rw.help.show_books"))
 	} else {
-		parts = append(parts, "ctrl+b: hide books")
+		parts = append(parts, i18n.T("rw.help.hide_books"))
 	}
 
-	if m.editMode != editNone {
-		parts = append(parts, "ctrl+s: save • ctrl+q: cancel")
+	if m.textDiff != nil {
+		parts = append(parts, i18n.T("rw.help.diff"))
+	} else if m.editMode != editNone {
+		parts = append(parts, i18n.T("rw.help.edit"))
 		if m.editMode == editBoth {
-			parts = append(parts, "ctrl+w: switch editor")
+			parts = append(parts, i18n.T("rw.help.switch_editor"))
 		}
+	} else if m.bookInfo != nil {
+		parts = append(parts, i18n.T("rw.help.book_info"))
 	} else {
 		switch m.focusedPane {
 		case focusBooks:
-			parts = append(parts, "enter: select • /: search • r: refresh")
+			parts = append(parts, i18n.T("rw.help.books"))
 		case focusHighlights:
-			parts = append(parts, "enter: view • /: search • esc: back")
+			parts = append(parts, i18n.T("rw.help.highlights"))
 			if m.currentBook != nil {
-				status := fmt.Sprintf("%d highlights", len(m.highlights))
-				if m.nextPageURL != "" {
-					status += " (more available)"
+				status := i18n.N("rw.help.count", len(m.highlights))
+				if m.capture.Auto() {
+					status += " • " + i18n.T("rw.help.auto_capture", m.capture.Count())
 				}
 				parts = append([]string{status}, parts...)
 			}
 		case focusDetail:
-			parts = append(parts, "e: edit both • E: edit note • ctrl+e: external • ↑↓: scroll • esc: back")
+			parts = append(parts, i18n.T("rw.help.detail"))
 		}
 
-		parts = append(parts, "tab/←→: navigate • ctrl+c: quit")
+		parts = append(parts, i18n.T("rw.help.navigate", m.preset().name))
 	}
 
 	return strings.Join(parts, " • ")
 }
 
-func (m ModelSplit) saveEdits() tea.Cmd {
-	return func() tea.Msg {
-		if m.currentHighlight == nil {
-			return nil
-		}
+// saveEdits takes the editors' text into the highlight and sends it to
+// Readwise; edit mode ends once the save lands
+func (m *ModelSplit) saveEdits() tea.Cmd {
+	if m.currentHighlight == nil {
+		return nil
+	}
 
-		if m.editMode == editNote || m.editMode == editBoth {
-			m.currentHighlight.Note = m.noteEditor.Value()
-		}
-		if m.editMode == editBoth {
-			m.currentHighlight.Text = m.highlightEditor.Value()
-		}
+	if m.editMode == editNote || m.editMode == editBoth {
+		m.currentHighlight.Note = m.noteEditor.Value()
+	}
+	if m.editMode == editBoth {
+		m.currentHighlight.Text = m.highlightEditor.Value()
+	}
 
-		m.saving = true
-		return highlightSavedMsg{}
+	m.saving = true
+	return m.updateHighlightNote()
+}
+
+// updateCurrentHighlight applies a favorite/discard/color change optimistically
+// and sends it to the API; failures are rolled back in Update
+func (m *ModelSplit) updateCurrentHighlight(update models.HighlightUpdate) tea.Cmd {
+	if m.currentHighlight == nil {
+		return nil
 	}
+
+	original := *m.currentHighlight
+	*m.currentHighlight = applyHighlightUpdate(original, update)
+	replaceHighlight(m.highlights, &m.highlightList, *m.currentHighlight)
+
+	return sendHighlightUpdate(m.api, original, update)
 }
 
 func (m ModelSplit) openExternalEditor() tea.Cmd {
@@ -1005,29 +1384,24 @@ func (m ModelSplit) openExternalEditor() tea.Cmd {
 }
 
 // Commands
-func (m ModelSplit) loadBooks() tea.Cmd {
-	return func() tea.Msg {
-		books, err := m.api.GetBooks(nil)
-		if err != nil {
-			return errMsg{err}
-		}
-		return booksLoadedMsg{books: books.Results}
+// loadHighlights starts loading a book's highlights page by page, or
+// a smart view's from the highlights cache, canceling a load still running
+func (m *ModelSplit) loadHighlights(book models.Book) tea.Cmd {
+	m.highlightLoad.stop()
+	if view, ok := m.shelf.view(book); ok {
+		m.highlightLoad = newActivity(i18n.T("rw.status.loading_view", view.name))
+		return tea.Batch(m.highlightLoad.tick(), loadView(m.api, m.highlightLoad, m.shelf.highlights, view))
 	}
+	m.highlightLoad = newActivity(i18n.T("rw.status.loading_book", book.Title))
+	return tea.Batch(m.highlightLoad.tick(), loadHighlightsPage(m.api, m.highlightLoad, book.ID, 1, nil))
 }
 
-func (m ModelSplit) loadHighlights(bookID int) tea.Cmd {
-	return func() tea.Msg {
-		params := url.Values{}
-		params.Set("book_id", fmt.Sprintf("%d", bookID))
-		highlights, err := m.api.GetHighlights(params)
-		if err != nil {
-			return errMsg{err}
-		}
-		return highlightsLoadedMsg{
-			highlights:  highlights.Results,
-			nextPageURL: highlights.Next,
-		}
-	}
+// cancelLoads stops the book and highlight loads in flight
+func (m *ModelSplit) cancelLoads() tea.Cmd {
+	m.bookLoad.stop()
+	m.highlightLoad.stop()
+	m.bookLoad, m.highlightLoad = nil, nil
+	return components.Notify(components.ToastWarn, i18n.T("rw.toast.loading_canceled"))
 }
 
 func (m ModelSplit) renderHighlightDetail() tea.Cmd {
@@ -1054,10 +1428,9 @@ func (m ModelSplit) renderHighlightDetail() tea.Cmd {
 			noteContent += "*No note yet. Press 'e' to add one.*"
 		}
 
-		detailWidth := max(50, m.detailPaneWidth-10)
-		if detailWidth < 40 {
-			detailWidth = 40
-		}
+		// Wrapped inside the viewport, less glamour's margin, whatever the
+		// terminal's width
+		detailWidth := max(20, paneInner(m.detailPaneWidth)-scrollbarWidth-2)
 
 		renderer, _ := glamour.NewTermRenderer(
 			glamour.WithAutoStyle(),
@@ -1095,7 +1468,7 @@ func (m ModelSplit) updateHighlightNote() tea.Cmd {
 			update.Text = m.currentHighlight.Text
 		}
 
-		_, err := m.api.UpdateHighlight(m.currentHighlight.ID, update)
+		_, err := m.api.UpdateHighlight(context.Background(), m.currentHighlight.ID, update)
 		if err != nil {
 			return errMsg{err}
 		}
@@ -0,0 +1,224 @@
+package tui
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/api"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/models"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+)
+
+// Cover size in cells
+const (
+	coverCols = 20
+	coverRows = 12
+)
+
+// sparkLevels draw the highlight sparkline, lowest first
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// bookDetail is the book detail overlay: metadata, the rendered document
+// note, highlights over time and the cover
+type bookDetail struct {
+	book       models.Book
+	load       *activity // fetching highlights and cover, nil once loaded
+	highlights []models.Highlight
+	note       string // document_note rendered with glamour
+	cover      string // rendered cover block, "" for none
+	protocol   components.ImageProtocol
+	err        error
+}
+
+// bookDetailLoadedMsg carries a book detail once its highlights, note and
+// cover are ready
+type bookDetailLoadedMsg struct {
+	detail *bookDetail
+}
+
+// newBookDetail opens the overlay for a book, loading until
+// loadBookDetail's result arrives
+func newBookDetail(book models.Book, protocol components.ImageProtocol) *bookDetail {
+	return &bookDetail{book: book, load: newActivity(i18n.T("rw.status.loading_highlights")), protocol: protocol}
+}
+
+// loadBookDetail fetches the book's highlights and cover and renders its
+// note for a detail overlay width cells wide. A cover that can't be
+// fetched falls back to the placeholder rather than failing the detail;
+// closing the overlay cancels the load.
+func loadBookDetail(client *api.Client, loading *bookDetail, width int) tea.Cmd {
+	book, protocol, load := loading.book, loading.protocol, loading.load
+	return func() tea.Msg {
+		detail := &bookDetail{book: book, protocol: protocol}
+
+		params := url.Values{}
+		params.Set("book_id", strconv.Itoa(book.ID))
+		highlights, err := client.GetHighlights(load.ctx, params)
+		if load.ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			detail.err = err
+		} else {
+			detail.highlights = highlights.Results
+		}
+
+		if note := strings.TrimSpace(book.DocumentNote); note != "" {
+			renderer, _ := glamour.NewTermRenderer(
+				glamour.WithAutoStyle(),
+				glamour.WithWordWrap(max(20, width-coverCols-10)),
+			)
+			rendered, err := renderer.Render(note)
+			if err != nil {
+				rendered = note
+			}
+			detail.note = strings.Trim(rendered, "\n")
+		}
+
+		if book.CoverImageURL != "" && protocol != components.ProtocolOff {
+			if img, err := components.LoadCover(book.CoverImageURL); err == nil {
+				detail.cover = components.RenderCover(img, protocol, coverCols, coverRows)
+			}
+		}
+		return bookDetailLoadedMsg{detail: detail}
+	}
+}
+
+// sparkline buckets highlight dates by month from the first to the last,
+// returning the line and the months it spans; at most width months are
+// shown, the latest
+func sparkline(highlights []models.Highlight, width int) (line string, first, last time.Time) {
+	counts := make(map[time.Time]int)
+	for _, h := range highlights {
+		if h.HighlightedAt == nil {
+			continue
+		}
+		month := time.Date(h.HighlightedAt.Year(), h.HighlightedAt.Month(), 1, 0, 0, 0, 0, time.UTC)
+		counts[month]++
+		if first.IsZero() || month.Before(first) {
+			first = month
+		}
+		if month.After(last) {
+			last = month
+		}
+	}
+	if len(counts) == 0 || width <= 0 {
+		return "", first, last
+	}
+
+	var months []time.Time
+	for m := first; !m.After(last); m = m.AddDate(0, 1, 0) {
+		months = append(months, m)
+	}
+	if len(months) > width {
+		months = months[len(months)-width:]
+		first = months[0]
+	}
+
+	peak := 0
+	for _, m := range months {
+		peak = max(peak, counts[m])
+	}
+	var b strings.Builder
+	for _, m := range months {
+		if counts[m] == 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		b.WriteRune(sparkLevels[(counts[m]*len(sparkLevels)-1)/peak])
+	}
+	return b.String(), first, last
+}
+
+// coverPlaceholder stands in for a book without a cover, or a cover the
+// terminal can't show
+func coverPlaceholder(book models.Book) string {
+	initials := ""
+	for _, word := range strings.Fields(book.Title) {
+		if len(initials) < 3 {
+			initials += strings.ToUpper(string([]rune(word)[:1]))
+		}
+	}
+	return lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		Width(coverCols-2).
+		Height(coverRows-2).
+		Align(lipgloss.Center, lipgloss.Center).
+		Render(initials)
+}
+
+// view renders the overlay over width×height cells
+func (d *bookDetail) view(width, height int) string {
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	label := func(name, value string) string {
+		return dim.Render(fmt.Sprintf("%-11s", name)) + value
+	}
+
+	textWidth := max(10, width-coverCols-8)
+	rows := []string{lipgloss.NewStyle().Bold(true).Width(textWidth).Render(d.book.Title)}
+	if d.book.Author != "" {
+		rows = append(rows, label("author", d.book.Author))
+	}
+	kind := d.book.Category
+	if d.book.Source != "" {
+		kind += " · " + d.book.Source
+	}
+	rows = append(rows, label("category", kind), label("highlights", strconv.Itoa(d.book.NumHighlights)))
+	if d.book.LastHighlightAt != nil {
+		rows = append(rows, label("last", d.book.LastHighlightAt.Format("Jan 2, 2006")))
+	}
+	if len(d.book.Tags) > 0 {
+		names := make([]string, len(d.book.Tags))
+		for i, tag := range d.book.Tags {
+			names[i] = tag.Name
+		}
+		rows = append(rows, label("tags", strings.Join(names, ", ")))
+	}
+	if d.book.SourceURL != "" {
+		rows = append(rows, label("source", d.book.SourceURL))
+	}
+
+	rows = append(rows, "")
+	switch {
+	case d.load != nil:
+		rows = append(rows, d.load.view(textWidth))
+	case d.err != nil:
+		rows = append(rows, dim.Render(i18n.T("rw.status.highlights_unavailable", d.err.Error())))
+	default:
+		line, first, last := sparkline(d.highlights, textWidth-18)
+		if line == "" {
+			rows = append(rows, dim.Render(i18n.T("rw.status.no_dated_highlights")))
+		} else {
+			rows = append(rows, dim.Render(first.Format("Jan 2006")+" ")+line+dim.Render(" "+last.Format("Jan 2006")))
+		}
+	}
+	if d.note != "" {
+		rows = append(rows, "", d.note)
+	}
+
+	cover := d.cover
+	if cover == "" {
+		cover = coverPlaceholder(d.book)
+	}
+	body := lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().PaddingRight(2).Render(cover),
+		lipgloss.NewStyle().Width(textWidth).Render(strings.Join(rows, "\n")))
+
+	content := body + "\n\n" + dim.Render("esc/i: close • enter: highlights")
+	return lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1).
+		Width(width - 4).
+		Height(height - 2).
+		Render(content)
+}
@@ -0,0 +1,291 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/cache"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/models"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+)
+
+// bookSorts are the book list orders s cycles through; the first is the
+// default
+var bookSorts = []string{"recent", "title", "highlights"}
+
+// bookCategories are the Readwise categories c cycles through; "" shows
+// every category
+var bookCategories = []string{"", "books", "articles", "tweets", "podcasts"}
+
+// maxFacetTags is how many tags the facet under the book list shows
+const maxFacetTags = 5
+
+// tagCount is a book tag and how many listed books carry it
+type tagCount struct {
+	name  string
+	count int
+}
+
+// bookShelf sorts and filters the book list by the settings kept in the
+// local library cache, listing the smart views above the books
+type bookShelf struct {
+	library      *cache.Library
+	highlights   *cache.Highlights // every book's highlights, for the views
+	views        []smartView
+	books        []models.Book
+	showArchived bool // list the archive instead of the other books
+}
+
+// newBookShelf creates a shelf over an in-memory library
+func newBookShelf() *bookShelf {
+	return &bookShelf{library: cache.NewLibrary(), highlights: cache.NewHighlights()}
+}
+
+// viewBooks is the smart views as the pseudo-books listed for them
+func (s *bookShelf) viewBooks() []models.Book {
+	books := make([]models.Book, len(s.views))
+	for i, view := range s.views {
+		books[i] = models.Book{
+			ID:            -(i + 1),
+			Title:         "🔎 " + view.name,
+			Author:        view.summary(),
+			Category:      viewCategory,
+			NumHighlights: view.count(s.highlights),
+		}
+	}
+	return books
+}
+
+// isView reports whether book is a smart view's pseudo-book
+func (s *bookShelf) isView(book *models.Book) bool {
+	if book == nil {
+		return false
+	}
+	_, ok := s.view(*book)
+	return ok
+}
+
+// view is the smart view a listed book stands for, if it's a pseudo-book
+func (s *bookShelf) view(book models.Book) (smartView, bool) {
+	if i := -book.ID - 1; book.Category == viewCategory && i >= 0 && i < len(s.views) {
+		return s.views[i], true
+	}
+	return smartView{}, false
+}
+
+// inView reports whether a book passes the archive and category filters
+func (s *bookShelf) inView(book models.Book) bool {
+	if s.library.IsArchived(book.ID) != s.showArchived {
+		return false
+	}
+	return s.library.Category == "" || book.Category == s.library.Category
+}
+
+// hasTag reports whether a book carries the named tag
+func hasTag(book models.Book, name string) bool {
+	for _, tag := range book.Tags {
+		if tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// visible is the books to list, filtered and sorted
+func (s *bookShelf) visible() []models.Book {
+	var books []models.Book
+	for _, book := range s.books {
+		if s.inView(book) && (s.library.Tag == "" || hasTag(book, s.library.Tag)) {
+			books = append(books, book)
+		}
+	}
+
+	switch s.library.Sort {
+	case "title":
+		sort.SliceStable(books, func(i, j int) bool {
+			return strings.ToLower(books[i].Title) < strings.ToLower(books[j].Title)
+		})
+	case "highlights":
+		sort.SliceStable(books, func(i, j int) bool {
+			return books[i].NumHighlights > books[j].NumHighlights
+		})
+	default:
+		// Most recently highlighted first, never-highlighted books last
+		sort.SliceStable(books, func(i, j int) bool {
+			a, b := books[i].LastHighlightAt, books[j].LastHighlightAt
+			if a == nil || b == nil {
+				return a != nil
+			}
+			return a.After(*b)
+		})
+	}
+	return books
+}
+
+// tags counts the tags of the books in view, most used first
+func (s *bookShelf) tags() []tagCount {
+	counts := make(map[string]int)
+	for _, book := range s.books {
+		if !s.inView(book) {
+			continue
+		}
+		for _, tag := range book.Tags {
+			counts[tag.Name]++
+		}
+	}
+
+	tags := make([]tagCount, 0, len(counts))
+	for name, count := range counts {
+		tags = append(tags, tagCount{name: name, count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].count != tags[j].count {
+			return tags[i].count > tags[j].count
+		}
+		return tags[i].name < tags[j].name
+	})
+	return tags
+}
+
+// step returns the option delta places from current, wrapping around
+func step(options []string, current string, delta int) string {
+	at := 0
+	for i, option := range options {
+		if option == current {
+			at = i
+		}
+	}
+	return options[((at+delta)%len(options)+len(options))%len(options)]
+}
+
+// handleKey applies a book pane key to the shelf, saving the library when a
+// setting changed: s sorts, c and t/T filter by category and tag, A archives
+// or restores the selected book and V switches to the archive. It reports
+// whether the key was the shelf's, and the toast the key raised.
+func (s *bookShelf) handleKey(key string, selected *models.Book) (bool, tea.Cmd) {
+	var notice tea.Cmd
+	switch key {
+	case "s":
+		s.library.Sort = step(bookSorts, s.library.Sort, 1)
+	case "c":
+		s.library.Category = step(bookCategories, s.library.Category, 1)
+	case "t", "T":
+		options := []string{""}
+		for _, tag := range s.tags() {
+			options = append(options, tag.name)
+		}
+		delta := 1
+		if key == "T" {
+			delta = -1
+		}
+		s.library.Tag = step(options, s.library.Tag, delta)
+	case "A":
+		if selected == nil {
+			return true, nil
+		}
+		if _, ok := s.view(*selected); ok {
+			return true, components.Notify(components.ToastInfo, i18n.T("rw.toast.views_not_archived"))
+		}
+		archived := !s.library.IsArchived(selected.ID)
+		s.library.SetArchived(selected.ID, archived)
+		if archived {
+			notice = components.Notify(components.ToastSuccess, i18n.T("rw.toast.archived", selected.Title))
+		} else {
+			notice = components.Notify(components.ToastSuccess, i18n.T("rw.toast.restored", selected.Title))
+		}
+	case "V":
+		s.showArchived = !s.showArchived
+		return true, nil
+	default:
+		return false, nil
+	}
+	if err := s.library.Save(); err != nil {
+		return true, components.NotifyError(err)
+	}
+	return true, notice
+}
+
+// apply lists the smart views and the visible books in l; the archive
+// lists no views
+func (s *bookShelf) apply(l *list.Model) {
+	books := s.visible()
+	if !s.showArchived {
+		books = append(s.viewBooks(), books...)
+	}
+	items := make([]list.Item, len(books))
+	for i, book := range books {
+		items[i] = bookItem{book: book}
+	}
+	l.SetItems(items)
+	l.Title = "📚 Books"
+	if s.showArchived {
+		l.Title = "📦 Archive"
+	}
+}
+
+// facetTags is the tags the facet lists: the most used, and the active tag
+// even when it isn't among them or no book in view carries it
+func (s *bookShelf) facetTags() []tagCount {
+	tags := s.tags()
+	active := -1
+	for i, tag := range tags {
+		if tag.name == s.library.Tag {
+			active = i
+		}
+	}
+	if active < 0 && s.library.Tag != "" {
+		tags = append(tags, tagCount{name: s.library.Tag})
+		active = len(tags) - 1
+	}
+	if len(tags) > maxFacetTags {
+		if active >= maxFacetTags {
+			tags = append(tags[:maxFacetTags-1], tags[active])
+		} else {
+			tags = tags[:maxFacetTags]
+		}
+	}
+	return tags
+}
+
+// facetHeight is the rows facetView takes under the book list
+func (s *bookShelf) facetHeight() int {
+	if tags := s.facetTags(); len(tags) > 0 {
+		return len(tags) + 3
+	}
+	return 2
+}
+
+// facetView renders the sort, category and tag facet under the book list
+func (s *bookShelf) facetView(width int) string {
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	active := lipgloss.NewStyle().Foreground(lipgloss.Color("170"))
+	line := lipgloss.NewStyle().MaxWidth(width)
+
+	sortName := step(bookSorts, s.library.Sort, 0)
+	category := s.library.Category
+	if category == "" {
+		category = "all"
+	}
+	rows := []string{"", line.Render(dim.Render("sort: ") + sortName + dim.Render(" · ") + category)}
+
+	tags := s.facetTags()
+	if len(tags) == 0 {
+		return strings.Join(rows, "\n")
+	}
+	rows = append(rows, dim.Render("tags"))
+	for _, tag := range tags {
+		text := fmt.Sprintf("%s %d", tag.name, tag.count)
+		if tag.name == s.library.Tag {
+			rows = append(rows, line.Render(active.Render("› "+text)))
+		} else {
+			rows = append(rows, line.Render("  "+text))
+		}
+	}
+	return strings.Join(rows, "\n")
+}
@@ -0,0 +1,67 @@
+package components
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Announcer tells a screen reader user about events off the screen, like a
+// capture, with the terminal bell and desktop notifications
+type Announcer struct {
+	out  io.Writer
+	bell bool
+	osc  bool
+}
+
+// NewAnnouncer writes announcements to out as notify asks: bell, osc (the
+// OSC 9 and OSC 777 desktop notifications), both or off
+func NewAnnouncer(out io.Writer, notify string) (*Announcer, error) {
+	a := &Announcer{out: out}
+	switch strings.ToLower(strings.TrimSpace(notify)) {
+	case "", "bell":
+		a.bell = true
+	case "osc":
+		a.osc = true
+	case "both":
+		a.bell, a.osc = true, true
+	case "off", "none":
+	default:
+		return nil, fmt.Errorf("unknown notify %q (bell, osc, both or off)", notify)
+	}
+	return a, nil
+}
+
+// Announce sends text to the terminal; a nil Announcer announces nothing
+func (a *Announcer) Announce(text string) tea.Cmd {
+	if a == nil || !a.bell && !a.osc {
+		return nil
+	}
+	var b strings.Builder
+	if a.osc {
+		text = oscText(text)
+		b.WriteString("\x1b]9;" + text + "\x07")
+		b.WriteString("\x1b]777;notify;float-line;" + text + "\x07")
+	}
+	if a.bell {
+		b.WriteString("\a")
+	}
+	seq := b.String()
+	return func() tea.Msg {
+		io.WriteString(a.out, seq)
+		return nil
+	}
+}
+
+// oscText keeps control characters, which would end the sequence early,
+// out of a notification
+func oscText(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, text)
+}
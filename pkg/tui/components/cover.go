@@ -0,0 +1,267 @@
+package components
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // covers come as JPEG, PNG or GIF
+	_ "image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ImageProtocol is how a terminal is sent images
+type ImageProtocol string
+
+const (
+	ProtocolKitty ImageProtocol = "kitty"
+	ProtocolITerm ImageProtocol = "iterm"
+	ProtocolSixel ImageProtocol = "sixel"
+	ProtocolASCII ImageProtocol = "ascii"
+	ProtocolOff   ImageProtocol = "off"
+)
+
+// Cells are assumed this many pixels across and down when sizing sixels
+const (
+	cellWidth  = 10
+	cellHeight = 20
+)
+
+// asciiRamp runs from light to dark for the ASCII fallback
+const asciiRamp = " .:-=+*#%@"
+
+var coverClient = &http.Client{Timeout: 10 * time.Second}
+
+// DetectImageProtocol guesses the terminal's image support from its
+// environment, falling back to ASCII
+func DetectImageProtocol() ImageProtocol {
+	term := os.Getenv("TERM")
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "" || term == "xterm-kitty" || os.Getenv("TERM_PROGRAM") == "ghostty":
+		return ProtocolKitty
+	case os.Getenv("TERM_PROGRAM") == "iTerm.app" || os.Getenv("TERM_PROGRAM") == "WezTerm":
+		return ProtocolITerm
+	case strings.Contains(term, "sixel") || term == "foot" || term == "mlterm":
+		return ProtocolSixel
+	}
+	return ProtocolASCII
+}
+
+// ParseImageProtocol reads a configured protocol; "auto" and "" detect it
+func ParseImageProtocol(s string) (ImageProtocol, error) {
+	switch p := ImageProtocol(strings.ToLower(s)); p {
+	case "", "auto":
+		return DetectImageProtocol(), nil
+	case ProtocolKitty, ProtocolITerm, ProtocolSixel, ProtocolASCII, ProtocolOff:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown image protocol %q: use auto, kitty, iterm, sixel, ascii or off", s)
+}
+
+// LoadCover downloads and decodes a cover image
+func LoadCover(url string) (image.Image, error) {
+	resp, err := coverClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("fetch cover: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch cover: %s", resp.Status)
+	}
+	img, _, err := image.Decode(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("decode cover: %w", err)
+	}
+	return img, nil
+}
+
+// RenderCover draws img in a block cols cells wide and rows high. Graphics
+// protocols put the image on the first line with the cursor saved around
+// it and pad the block with spaces, so it lays out like any other text.
+func RenderCover(img image.Image, protocol ImageProtocol, cols, rows int) string {
+	if cols <= 0 || rows <= 0 {
+		return ""
+	}
+
+	var graphic string
+	switch protocol {
+	case ProtocolKitty:
+		graphic = kittyImage(img, cols, rows)
+	case ProtocolITerm:
+		graphic = itermImage(img, cols, rows)
+	case ProtocolSixel:
+		graphic = "\x1b7" + sixelImage(resize(img, cols*cellWidth, rows*cellHeight)) + "\x1b8"
+	case ProtocolOff:
+		return ""
+	default:
+		return asciiImage(img, cols, rows)
+	}
+
+	blank := strings.Repeat(" ", cols)
+	lines := make([]string, rows)
+	for i := range lines {
+		lines[i] = blank
+	}
+	lines[0] = graphic + blank
+	return strings.Join(lines, "\n")
+}
+
+// ClearImages is the sequence that removes images a protocol leaves
+// behind when the text over them changes; only kitty needs one
+func ClearImages(protocol ImageProtocol) string {
+	if protocol == ProtocolKitty {
+		return "\x1b_Ga=d,d=A\x1b\\"
+	}
+	return ""
+}
+
+// kittyImage transmits img as PNG in 4096 byte chunks and places it over
+// cols×rows cells without moving the cursor
+func kittyImage(img image.Image, cols, rows int) string {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return ""
+	}
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	var b strings.Builder
+	b.WriteString(ClearImages(ProtocolKitty))
+	for first := true; len(data) > 0; first = false {
+		chunk := data[:min(4096, len(data))]
+		data = data[len(chunk):]
+		more := 0
+		if len(data) > 0 {
+			more = 1
+		}
+		if first {
+			fmt.Fprintf(&b, "\x1b_Ga=T,f=100,q=2,C=1,c=%d,r=%d,m=%d;%s\x1b\\", cols, rows, more, chunk)
+		} else {
+			fmt.Fprintf(&b, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	return b.String()
+}
+
+// itermImage sends img as an inline file scaled to cols×rows cells
+func itermImage(img image.Image, cols, rows int) string {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return ""
+	}
+	return fmt.Sprintf("\x1b7\x1b]1337;File=inline=1;width=%d;height=%d;preserveAspectRatio=1:%s\a\x1b8",
+		cols, rows, base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+// sixelImage encodes img as sixels over a 6×6×6 color cube
+func sixelImage(img image.Image) string {
+	bounds := img.Bounds()
+	var b strings.Builder
+	b.WriteString("\x1bPq")
+	for i := 0; i < 216; i++ {
+		r, g, bl := i/36, i/6%6, i%6
+		fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i, r*20, g*20, bl*20)
+	}
+
+	for top := bounds.Min.Y; top < bounds.Max.Y; top += 6 {
+		// Which color each pixel of the band is, and which colors appear
+		band := make([][6]int, bounds.Dx())
+		used := make(map[int]bool)
+		for x := range band {
+			for dy := 0; dy < 6; dy++ {
+				band[x][dy] = -1
+				if y := top + dy; y < bounds.Max.Y {
+					band[x][dy] = cubeIndex(img.At(bounds.Min.X+x, y))
+					used[band[x][dy]] = true
+				}
+			}
+		}
+
+		for c := 0; c < 216; c++ {
+			if !used[c] {
+				continue
+			}
+			fmt.Fprintf(&b, "#%d", c)
+			var run byte
+			count := 0
+			flush := func() {
+				switch {
+				case count > 3:
+					fmt.Fprintf(&b, "!%d%c", count, run)
+				case count > 0:
+					b.WriteString(strings.Repeat(string(run), count))
+				}
+			}
+			for x := range band {
+				bits := byte(0)
+				for dy := 0; dy < 6; dy++ {
+					if band[x][dy] == c {
+						bits |= 1 << dy
+					}
+				}
+				char := bits + 63
+				if char != run {
+					flush()
+					run, count = char, 0
+				}
+				count++
+			}
+			flush()
+			b.WriteString("$")
+		}
+		b.WriteString("-")
+	}
+	b.WriteString("\x1b\\")
+	return b.String()
+}
+
+// cubeIndex maps a color to the nearest 6×6×6 cube entry
+func cubeIndex(c color.Color) int {
+	r, g, b, _ := c.RGBA()
+	level := func(v uint32) int { return int((v>>8)*5+127) / 255 }
+	return level(r)*36 + level(g)*6 + level(b)
+}
+
+// asciiImage draws img with asciiRamp, one character per cell
+func asciiImage(img image.Image, cols, rows int) string {
+	small := resize(img, cols, rows)
+	lines := make([]string, rows)
+	for y := 0; y < rows; y++ {
+		var line strings.Builder
+		for x := 0; x < cols; x++ {
+			gray := color.GrayModel.Convert(small.At(x, y)).(color.Gray)
+			line.WriteByte(asciiRamp[(255-int(gray.Y))*(len(asciiRamp)-1)/255])
+		}
+		lines[y] = line.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// resize scales img to width×height by averaging the pixels under each
+// new one
+func resize(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*bounds.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*bounds.Dx()/width)
+
+			var r, g, b, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, _ := img.At(sx, sy).RGBA()
+					r, g, b, n = r+pr, g+pg, b+pb, n+1
+				}
+			}
+			out.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: 0xffff})
+		}
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/evanschultz/float-rw-client/pkg/api"
+)
+
+// ErrorMessage maps API and network errors to messages that tell the user
+// what to do next
+func ErrorMessage(err error) string {
+	var apiErr *api.APIError
+	errors.As(err, &apiErr)
+
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, api.ErrUnauthorized):
+		return "Readwise token invalid — run `float-rw auth`"
+	case errors.Is(err, api.ErrRateLimited):
+		if apiErr != nil && apiErr.RetryAfter > 0 {
+			return fmt.Sprintf("Readwise rate limit hit — retry in %s", apiErr.RetryAfter)
+		}
+		return "Readwise rate limit hit — wait a minute and retry"
+	case errors.Is(err, api.ErrNotFound):
+		return "Not found on Readwise — it may have been deleted"
+	case errors.Is(err, api.ErrServer):
+		return "Readwise is having trouble — try again shortly"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "Timed out reaching Readwise — check your connection"
+	case errors.As(err, &netErr):
+		return "Can't reach Readwise — check your connection"
+	case apiErr != nil:
+		return fmt.Sprintf("Readwise rejected the request (%d)", apiErr.StatusCode)
+	}
+
+	return strings.Join(strings.Fields(err.Error()), " ")
+}
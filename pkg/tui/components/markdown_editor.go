@@ -8,6 +8,8 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/cells"
 )
 
 type EditorMode int
@@ -202,12 +204,7 @@ func (m MarkdownEditor) View() string {
 	// Title bar
 	title := m.titleStyle.Render("Markdown Editor")
 	mode := m.modeStyle.Render(modeText)
-	titleBar := lipgloss.JoinHorizontal(
-		lipgloss.Top,
-		title,
-		strings.Repeat(" ", max(0, m.width-lipgloss.Width(title)-lipgloss.Width(mode)-4)),
-		mode,
-	)
+	titleBar := cells.Spread(title, mode, m.width)
 
 	// Help text
 	var helpText string
@@ -225,7 +222,7 @@ func (m MarkdownEditor) View() string {
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
 		titleBar,
-		m.borderStyle.Width(m.width-2).Height(m.height-4).Render(content),
+		cells.Frame(m.borderStyle, m.width).Height(m.height-4).Render(content),
 		helpText,
 	)
 }
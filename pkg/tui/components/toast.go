@@ -0,0 +1,233 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+)
+
+// ToastLevel is how a notification is shown and how long it stays
+type ToastLevel int
+
+const (
+	ToastInfo ToastLevel = iota
+	ToastSuccess
+	ToastWarn
+	ToastError
+)
+
+const (
+	maxVisibleToasts = 3
+	maxInbox         = 100
+	toastWidth       = 44
+)
+
+// toastStyles are the icon, color and lifetime of each level
+var toastStyles = map[ToastLevel]struct {
+	icon  string
+	color string
+	ttl   time.Duration
+}{
+	ToastInfo:    {"ℹ", "12", 3 * time.Second},
+	ToastSuccess: {"✓", "10", 3 * time.Second},
+	ToastWarn:    {"!", "11", 5 * time.Second},
+	ToastError:   {"✗", "9", 8 * time.Second},
+}
+
+// Toast is one notification
+type Toast struct {
+	ID    int
+	Level ToastLevel
+	Text  string
+	At    time.Time
+}
+
+// NotifyMsg asks the model's toasts to show a notification
+type NotifyMsg struct {
+	Level ToastLevel
+	Text  string
+}
+
+// toastExpiredMsg dismisses a toast once its time is up
+type toastExpiredMsg struct {
+	id int
+}
+
+// Notify is the command that reports a result as a toast
+func Notify(level ToastLevel, text string) tea.Cmd {
+	return func() tea.Msg { return NotifyMsg{Level: level, Text: text} }
+}
+
+// NotifyError reports err as an error toast, nil reporting nothing
+func NotifyError(err error) tea.Cmd {
+	if err == nil {
+		return nil
+	}
+	return Notify(ToastError, ErrorMessage(err))
+}
+
+// Toasts is the queue of transient notifications shown in the corner and
+// the inbox of everything shown before. Dismissal timers queued by Push
+// are returned by Flush.
+type Toasts struct {
+	active  []Toast // oldest first; the first maxVisibleToasts are shown
+	inbox   []Toast // newest first
+	nextID  int
+	pending []tea.Cmd
+
+	inboxOpen bool
+}
+
+// NewToasts creates an empty queue
+func NewToasts() Toasts {
+	return Toasts{}
+}
+
+// Push queues a notification
+func (t *Toasts) Push(level ToastLevel, text string) {
+	t.nextID++
+	toast := Toast{ID: t.nextID, Level: level, Text: strings.Join(strings.Fields(text), " "), At: time.Now()}
+	t.active = append(t.active, toast)
+	t.inbox = append([]Toast{toast}, t.inbox...)
+	if len(t.inbox) > maxInbox {
+		t.inbox = t.inbox[:maxInbox]
+	}
+	// A queued toast's time starts once it's shown
+	if len(t.active) <= maxVisibleToasts {
+		t.expire(toast)
+	}
+}
+
+// PushError queues err as an error notification
+func (t *Toasts) PushError(err error) {
+	if err != nil {
+		t.Push(ToastError, ErrorMessage(err))
+	}
+}
+
+func (t *Toasts) expire(toast Toast) {
+	id := toast.ID
+	t.pending = append(t.pending, tea.Tick(toastStyles[toast.Level].ttl, func(time.Time) tea.Msg {
+		return toastExpiredMsg{id: id}
+	}))
+}
+
+// Flush returns the dismissal timers queued since the last call
+func (t *Toasts) Flush() tea.Cmd {
+	cmds := t.pending
+	t.pending = nil
+	return tea.Batch(cmds...)
+}
+
+// Update handles notification and dismissal messages, reporting whether
+// msg was one
+func (t *Toasts) Update(msg tea.Msg) bool {
+	switch msg := msg.(type) {
+	case NotifyMsg:
+		t.Push(msg.Level, msg.Text)
+	case toastExpiredMsg:
+		for i, toast := range t.active {
+			if toast.ID != msg.id {
+				continue
+			}
+			t.active = append(t.active[:i], t.active[i+1:]...)
+			if len(t.active) >= maxVisibleToasts {
+				t.expire(t.active[maxVisibleToasts-1])
+			}
+			break
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// Active returns the toasts waiting or on screen, oldest first
+func (t Toasts) Active() []Toast {
+	return t.active
+}
+
+// Inbox returns every notification shown, newest first
+func (t Toasts) Inbox() []Toast {
+	return t.inbox
+}
+
+// ToggleInbox opens or closes the inbox
+func (t *Toasts) ToggleInbox() {
+	t.inboxOpen = !t.inboxOpen
+}
+
+// InboxOpen reports whether the inbox is showing
+func (t Toasts) InboxOpen() bool {
+	return t.inboxOpen
+}
+
+// renderToast draws one toast as a bordered box
+func renderToast(toast Toast, width int) string {
+	style := toastStyles[toast.Level]
+	return lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color(style.color)).
+		Padding(0, 1).
+		Width(width - 2).
+		Render(lipgloss.NewStyle().Foreground(lipgloss.Color(style.color)).Render(style.icon) + " " + toast.Text)
+}
+
+// Overlay draws the shown toasts over the top right corner of view, which
+// is width cells wide
+func (t Toasts) Overlay(view string, width int) string {
+	if len(t.active) == 0 || width < 20 {
+		return view
+	}
+	boxWidth := min(toastWidth, width-2)
+
+	var boxes []string
+	for _, toast := range t.active[:min(len(t.active), maxVisibleToasts)] {
+		boxes = append(boxes, renderToast(toast, boxWidth))
+	}
+	if waiting := len(t.active) - maxVisibleToasts; waiting > 0 {
+		boxes = append(boxes, lipgloss.NewStyle().Width(boxWidth).Align(lipgloss.Right).
+			Foreground(lipgloss.Color("241")).Render(fmt.Sprintf("+%d more", waiting)))
+	}
+
+	lines := strings.Split(view, "\n")
+	for i, box := range strings.Split(strings.Join(boxes, "\n"), "\n") {
+		row := i + 1 // leave the top border showing
+		if row >= len(lines) {
+			lines = append(lines, "")
+		}
+		// No "…" where the box cuts a line off: it covers the rest anyway
+		lines[row] = cells.Pad(ansi.Truncate(lines[row], width-boxWidth-1, ""), width-boxWidth-1) + box
+	}
+	return strings.Join(lines, "\n")
+}
+
+// InboxView lists past notifications, newest first, in a width×height box
+func (t Toasts) InboxView(width, height int) string {
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	rows := []string{lipgloss.NewStyle().Bold(true).Render(i18n.T("notifications.title", len(t.inbox))), ""}
+	if len(t.inbox) == 0 {
+		rows = append(rows, dim.Render(i18n.T("notifications.none")))
+	}
+	for _, toast := range t.inbox[:min(len(t.inbox), max(0, height-6))] {
+		style := toastStyles[toast.Level]
+		icon := lipgloss.NewStyle().Foreground(lipgloss.Color(style.color)).Render(style.icon)
+		rows = append(rows, dim.Render(toast.At.Format("15:04:05"))+" "+icon+" "+cells.Cut(toast.Text, max(10, width-20)))
+	}
+	rows = append(rows, "", dim.Render("alt+n/esc: close"))
+
+	return lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1).
+		Width(width - 4).
+		Height(height - 2).
+		Render(strings.Join(rows, "\n"))
+}
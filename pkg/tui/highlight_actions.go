@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"context"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/api"
+	"github.com/evanschultz/float-rw-client/pkg/models"
+)
+
+// highlightColors is the cycle order for Readwise highlight colors
+var highlightColors = []string{"yellow", "blue", "pink", "orange", "green", "purple"}
+
+// highlightColorCodes maps Readwise colors to terminal colors for badges
+var highlightColorCodes = map[string]string{
+	"yellow": "11",
+	"blue":   "12",
+	"pink":   "13",
+	"orange": "208",
+	"green":  "10",
+	"purple": "5",
+}
+
+// highlightUpdatedMsg reports a successful favorite/discard/color update
+type highlightUpdatedMsg struct {
+	highlight models.Highlight
+}
+
+// highlightUpdateFailedMsg carries the pre-update highlight so the
+// optimistic change can be rolled back
+type highlightUpdateFailedMsg struct {
+	original models.Highlight
+	err      error
+}
+
+// nextHighlightColor returns the color after current in the cycle
+func nextHighlightColor(current string) string {
+	for i, color := range highlightColors {
+		if color == current {
+			return highlightColors[(i+1)%len(highlightColors)]
+		}
+	}
+	return highlightColors[0]
+}
+
+// toggleFavoriteUpdate builds an update flipping is_favorite
+func toggleFavoriteUpdate(h models.Highlight) models.HighlightUpdate {
+	favorite := !h.IsFavorite
+	return models.HighlightUpdate{IsFavorite: &favorite}
+}
+
+// toggleDiscardUpdate builds an update flipping is_discard
+func toggleDiscardUpdate(h models.Highlight) models.HighlightUpdate {
+	discard := !h.IsDiscard
+	return models.HighlightUpdate{IsDiscard: &discard}
+}
+
+// cycleColorUpdate builds an update moving to the next highlight color
+func cycleColorUpdate(h models.Highlight) models.HighlightUpdate {
+	return models.HighlightUpdate{Color: nextHighlightColor(h.Color)}
+}
+
+// applyHighlightUpdate returns the highlight as it will look once the update
+// lands, used for optimistic UI updates
+func applyHighlightUpdate(h models.Highlight, update models.HighlightUpdate) models.Highlight {
+	if update.IsFavorite != nil {
+		h.IsFavorite = *update.IsFavorite
+	}
+	if update.IsDiscard != nil {
+		h.IsDiscard = *update.IsDiscard
+	}
+	if update.Color != "" {
+		h.Color = update.Color
+	}
+	return h
+}
+
+// sendHighlightUpdate patches the highlight and reports success or a
+// rollback message carrying the original state
+func sendHighlightUpdate(client *api.Client, original models.Highlight, update models.HighlightUpdate) tea.Cmd {
+	return func() tea.Msg {
+		updated, err := client.UpdateHighlight(context.Background(), original.ID, update)
+		if err != nil {
+			return highlightUpdateFailedMsg{original: original, err: err}
+		}
+		if updated == nil {
+			return highlightUpdatedMsg{highlight: applyHighlightUpdate(original, update)}
+		}
+		return highlightUpdatedMsg{highlight: *updated}
+	}
+}
+
+// replaceHighlight swaps a highlight in both the data slice and the list items
+func replaceHighlight(highlights []models.Highlight, highlightList *list.Model, h models.Highlight) {
+	for i := range highlights {
+		if highlights[i].ID == h.ID {
+			highlights[i] = h
+			break
+		}
+	}
+
+	items := highlightList.Items()
+	for i, item := range items {
+		if hi, ok := item.(highlightItem); ok && hi.highlight.ID == h.ID {
+			hi.highlight = h
+			highlightList.SetItem(i, hi)
+			break
+		}
+	}
+}
+
+// highlightBadges renders favorite/discard/color markers for list items
+func highlightBadges(h models.Highlight) string {
+	badges := ""
+	if h.IsFavorite {
+		badges += "★ "
+	}
+	if h.IsDiscard {
+		badges += "✗ "
+	}
+	if code, ok := highlightColorCodes[h.Color]; ok {
+		badges += lipgloss.NewStyle().Foreground(lipgloss.Color(code)).Render("●") + " "
+	}
+	return badges
+}
@@ -0,0 +1,206 @@
+package tui
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/evanschultz/float-rw-client/pkg/dispatchlog"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/models"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+)
+
+// captureSource is the evna source and dispatch log source of captured
+// highlights
+const captureSource = "readwise"
+
+// HighlightCapture pushes Readwise highlights through FLOAT.dispatch as
+// highlight:: actions, then on to evna and the dispatch log, where the
+// outliner's reducers pick them up alongside its own captures
+type HighlightCapture struct {
+	dispatch *outliner.FloatDispatchSystem
+	evna     *outliner.EvnaDispatcher
+	log      *dispatchlog.Log    // optional; nil keeps actions in memory only
+	auto     bool                // capture highlights as they're loaded
+	captured map[int]bool        // highlight IDs already captured
+	books    map[int]models.Book // the library, for highlights a smart view lists
+
+	announcer *components.Announcer // the screen reader mode's, nil when off
+}
+
+// NewHighlightCapture creates a capture; highlights already in log are
+// never captured twice
+func NewHighlightCapture(dispatch *outliner.FloatDispatchSystem, evna *outliner.EvnaDispatcher, log *dispatchlog.Log) *HighlightCapture {
+	c := &HighlightCapture{dispatch: dispatch, evna: evna, log: log, captured: make(map[int]bool)}
+	if log == nil {
+		return c
+	}
+	entries, _ := log.ReadSince(0)
+	for _, e := range entries {
+		if e.Source != captureSource {
+			continue
+		}
+		if id, err := strconv.Atoi(e.Context["readwise_id"]); err == nil {
+			c.captured[id] = true
+		}
+	}
+	return c
+}
+
+// defaultCapture keeps captures in memory, for models nobody configured
+func defaultCapture() *HighlightCapture {
+	return NewHighlightCapture(outliner.NewFloatDispatchSystem(), outliner.NewEvnaDispatcher(), nil)
+}
+
+// SetAuto turns capturing highlights as they're loaded on or off
+func (c *HighlightCapture) SetAuto(auto bool) {
+	c.auto = auto
+}
+
+// Auto reports whether loaded highlights are captured automatically
+func (c *HighlightCapture) Auto() bool {
+	return c.auto
+}
+
+// SetAnnouncer announces each capture through a, for the screen reader
+// mode; nil stops announcing
+func (c *HighlightCapture) SetAnnouncer(a *components.Announcer) {
+	c.announcer = a
+}
+
+// Captured reports whether a highlight has been captured
+func (c *HighlightCapture) Captured(id int) bool {
+	return c.captured[id]
+}
+
+// Count is the number of highlights captured, this session or before
+func (c *HighlightCapture) Count() int {
+	return len(c.captured)
+}
+
+// SetBooks sets the library's books, which give the highlights of a smart
+// view their book and author
+func (c *HighlightCapture) SetBooks(books []models.Book) {
+	c.books = make(map[int]models.Book, len(books))
+	for _, book := range books {
+		c.books[book.ID] = book
+	}
+}
+
+// bookOf is the book a highlight is from: book, unless book is a smart
+// view's pseudo-book, when the library's book the highlight names
+func (c *HighlightCapture) bookOf(h models.Highlight, book *models.Book) *models.Book {
+	if book == nil || book.Category != viewCategory {
+		return book
+	}
+	if b, ok := c.books[h.BookID]; ok {
+		return &b
+	}
+	return nil
+}
+
+// highlightMetadata is the book, author and Readwise details a highlight's
+// action carries
+func highlightMetadata(h models.Highlight, book *models.Book) map[string]string {
+	metadata := map[string]string{"readwise_id": strconv.Itoa(h.ID)}
+	if book != nil {
+		metadata["book"] = book.Title
+		metadata["author"] = book.Author
+	}
+	if h.Note != "" {
+		metadata["note"] = strings.Join(strings.Fields(h.Note), " ")
+	}
+	if h.Color != "" {
+		metadata["color"] = h.Color
+	}
+	if h.ReadwiseURL != "" {
+		metadata["url"] = h.ReadwiseURL
+	}
+	return metadata
+}
+
+// Capture dispatches the highlights not captured yet, skipping discarded
+// ones, and returns the command that sends them to evna and the log. It
+// returns nil when there's nothing new.
+func (c *HighlightCapture) Capture(highlights []models.Highlight, book *models.Book) tea.Cmd {
+	var patterns []outliner.ConsciousnessPattern
+	var entries []dispatchlog.Entry
+	for _, h := range highlights {
+		if c.captured[h.ID] || h.IsDiscard {
+			continue
+		}
+		c.captured[h.ID] = true
+
+		at := time.Now()
+		if h.HighlightedAt != nil {
+			at = *h.HighlightedAt
+		}
+		content := strings.Join(strings.Fields(h.Text), " ")
+		metadata := highlightMetadata(h, c.bookOf(h, book))
+		action := c.dispatch.DispatchWith(fmt.Sprintf("%s:%d", captureSource, h.ID), content, "highlight", metadata, at)
+
+		patterns = append(patterns, outliner.ConsciousnessPattern{Type: "highlight", Content: content, Context: metadata})
+		entries = append(entries, dispatchlog.Entry{
+			Time:     at,
+			Source:   captureSource,
+			Type:     "highlight",
+			Content:  content,
+			Context:  metadata,
+			ActionID: action.ID,
+			Imprint:  action.Imprint,
+			Sigil:    action.Sigil,
+		})
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	cmds := []tea.Cmd{c.evna.DispatchCmd(patterns, captureSource)}
+	if c.announcer != nil {
+		cmds = append(cmds, c.announcer.Announce(i18n.N("rw.toast.captured", len(patterns))))
+	}
+	if log := c.log; log != nil {
+		cmds = append(cmds, func() tea.Msg {
+			for _, e := range entries {
+				if _, err := log.Append(e); err != nil {
+					slog.Error("captured highlight not recorded", "log", log.Path(), "err", err)
+					return errMsg{fmt.Errorf("record captured highlight: %w", err)}
+				}
+			}
+			return nil
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// captureError is the first failed evna send in a capture's results
+func captureError(msg outliner.EvnaResultMsg) error {
+	for _, result := range msg.Results {
+		if result.Err != nil {
+			return fmt.Errorf("send highlight to %s: %w", result.Collection, result.Err)
+		}
+	}
+	return nil
+}
+
+// captureNotice is the toast for a capture's results: the first failed
+// send, or how many highlights reached evna
+func captureNotice(msg outliner.EvnaResultMsg) tea.Cmd {
+	if err := captureError(msg); err != nil {
+		slog.Warn("highlight capture failed", "err", err)
+		return components.NotifyError(err)
+	}
+	for _, result := range msg.Results {
+		if len(result.Redacted) > 0 {
+			slog.Info("highlight redacted before sending to evna", "withheld", result.Withheld, "redactions", result.Redacted.String())
+		}
+	}
+	slog.Info("highlights captured", "count", len(msg.Results))
+	return components.Notify(components.ToastSuccess, i18n.N("rw.toast.sent", len(msg.Results)))
+}
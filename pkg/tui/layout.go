@@ -0,0 +1,169 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+)
+
+// layoutPreset is a named arrangement of the split layout's panes that L
+// cycles through. A zero ratio keeps the one resized with ctrl+arrows.
+type layoutPreset struct {
+	name        string
+	bookRatio   float64 // the books pane's share of the width
+	detailRatio float64 // the detail pane's share of the width
+	splitRatio  float64 // the highlight's share of the detail pane
+	stacked     bool    // panes one above another at the full width
+}
+
+// layoutPresets are the split layout's presets in the order L cycles
+// them; the first is the custom layout ctrl+arrows resize
+var layoutPresets = []layoutPreset{
+	{name: "custom"},
+	{name: "reading", bookRatio: 0.12, detailRatio: 0.6, splitRatio: 0.65},
+	{name: "triage", bookRatio: 0.3, detailRatio: 0.3, splitRatio: 0.4},
+	{name: "stacked", stacked: true},
+}
+
+// layoutPresetIndex finds a preset by name; unknown names are the custom
+// layout
+func layoutPresetIndex(name string) int {
+	for i, preset := range layoutPresets {
+		if preset.name == name {
+			return i
+		}
+	}
+	return 0
+}
+
+// preset is the layout preset in use
+func (m ModelSplit) preset() layoutPreset {
+	return layoutPresets[m.layout]
+}
+
+// stacked reports whether the panes are laid out one above another: the
+// stacked preset, or any preset in a terminal narrower than stackBelow or
+// than a single pane needs
+func (m ModelSplit) stacked() bool {
+	return m.preset().stacked || (m.stackBelow > 0 && m.width < m.stackBelow) || m.singlePane()
+}
+
+// singlePane reports whether the terminal is too narrow for more than one
+// pane, so only the focused one is shown under the switcher
+func (m ModelSplit) singlePane() bool {
+	return m.width < singlePaneBelow
+}
+
+// shownPane is the pane a single-pane layout shows: the focused one, or
+// the nearest open one before it
+func (m ModelSplit) shownPane() focusedPane {
+	switch {
+	case m.editMode != editNone || (m.focusedPane == focusDetail && m.currentHighlight != nil):
+		return focusDetail
+	case m.focusedPane != focusBooks && m.currentBook != nil:
+		return focusHighlights
+	}
+	return focusBooks
+}
+
+// openPanes counts the panes the switcher lists: the books, then the
+// highlights once a book is open and the detail once a highlight is
+func (m ModelSplit) openPanes() int {
+	switch {
+	case m.currentHighlight != nil:
+		return 3
+	case m.currentBook != nil:
+		return 2
+	}
+	return 1
+}
+
+// ratios are the pane ratios the layout sizes panes by: the preset's,
+// falling back to the custom ones
+func (m ModelSplit) ratios() (book, detail, split float64) {
+	preset := m.preset()
+	book, detail, split = m.bookRatio, m.detailRatio, m.splitRatio
+	if preset.bookRatio > 0 {
+		book = preset.bookRatio
+	}
+	if preset.detailRatio > 0 {
+		detail = preset.detailRatio
+	}
+	if preset.splitRatio > 0 {
+		split = preset.splitRatio
+	}
+	return book, detail, split
+}
+
+// customize switches to the custom layout before a resize, taking the
+// preset's ratios so the resize starts from what's on screen
+func (m *ModelSplit) customize() {
+	if m.layout == 0 || m.preset().stacked {
+		return
+	}
+	m.bookRatio, m.detailRatio, m.splitRatio = m.ratios()
+	m.layout = 0
+}
+
+// cycleLayout switches to the next layout preset and keeps it in the
+// session
+func (m *ModelSplit) cycleLayout() tea.Cmd {
+	m.layout = (m.layout + 1) % len(layoutPresets)
+	text := i18n.T("rw.toast.layout", m.preset().name)
+	if m.stacked() && !m.preset().stacked {
+		text = i18n.T("rw.toast.stacked_below", m.preset().name, m.stackBelow)
+	}
+	return tea.Batch(m.relayout(), m.saveLayout(), components.Notify(components.ToastInfo, text))
+}
+
+// relayout sizes the panes again after the layout changed
+func (m *ModelSplit) relayout() tea.Cmd {
+	m.calculateLayout()
+	m.updateComponentSizes()
+	if m.currentHighlight != nil {
+		return m.renderHighlightDetail()
+	}
+	return nil
+}
+
+// stackPanes lays the open panes out one above another at the full width:
+// the lists share the height, or an open highlight's detail takes half and
+// the focused list the rest
+func (m *ModelSplit) stackPanes() {
+	m.bookPaneWidth, m.highlightPaneWidth, m.detailPaneWidth = m.width, m.width, 0
+	height := m.contentHeight
+	m.detailPaneHeight = 0
+	if m.currentHighlight != nil {
+		m.detailPaneWidth = m.width
+	}
+	if m.singlePane() {
+		// One pane under the switcher row
+		m.bookPaneHeight, m.highlightPaneHeight, m.detailPaneHeight = 0, 0, 0
+		switch m.shownPane() {
+		case focusDetail:
+			m.detailPaneHeight = height - 1
+		case focusHighlights:
+			m.highlightPaneHeight = height - 1
+		default:
+			m.bookPaneHeight = height - 1
+		}
+		return
+	}
+	if m.currentHighlight != nil {
+		m.detailPaneHeight = height / 2
+		height -= m.detailPaneHeight
+	}
+
+	m.bookPaneHeight, m.highlightPaneHeight = height, 0
+	switch {
+	case m.currentBook == nil:
+	case m.booksPaneHidden || (m.currentHighlight != nil && m.focusedPane != focusBooks):
+		m.bookPaneHeight, m.highlightPaneHeight = 0, height
+	case m.currentHighlight != nil:
+		// The books were focused over the highlight
+	default:
+		m.bookPaneHeight = height / 2
+		m.highlightPaneHeight = height - m.bookPaneHeight
+	}
+}
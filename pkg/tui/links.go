@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/models"
+	"github.com/evanschultz/float-rw-client/pkg/tui/components"
+)
+
+// highlightLink is where a highlight came from, or its Readwise page when
+// readwise is set or it has no source
+func highlightLink(h models.Highlight, readwise bool) string {
+	if readwise || h.URL == "" {
+		return h.ReadwiseURL
+	}
+	return h.URL
+}
+
+// openCommand is the command that opens url in the default browser
+func openCommand(url string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url)
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	}
+	return exec.Command("xdg-open", url)
+}
+
+// clipboardCommand is the command that copies its stdin to the clipboard,
+// nil when no clipboard tool is installed
+func clipboardCommand() *exec.Cmd {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	case "windows":
+		candidates = [][]string{{"clip"}}
+	default:
+		candidates = [][]string{{"wl-copy"}, {"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}}
+	}
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c[0]); err == nil {
+			return exec.Command(c[0], c[1:]...)
+		}
+	}
+	return nil
+}
+
+// openLink opens a highlight's link in the browser
+func openLink(h models.Highlight, readwise bool) tea.Cmd {
+	url := highlightLink(h, readwise)
+	return func() tea.Msg {
+		if url == "" {
+			return errMsg{fmt.Errorf("highlight %d has no link to open", h.ID)}
+		}
+		if err := openCommand(url).Run(); err != nil {
+			return errMsg{fmt.Errorf("open %s: %w", url, err)}
+		}
+		return nil
+	}
+}
+
+// copyLink copies a highlight's link to the clipboard
+func copyLink(h models.Highlight, readwise bool) tea.Cmd {
+	url := highlightLink(h, readwise)
+	return func() tea.Msg {
+		if url == "" {
+			return errMsg{fmt.Errorf("highlight %d has no link to copy", h.ID)}
+		}
+		cmd := clipboardCommand()
+		if cmd == nil {
+			return errMsg{fmt.Errorf("copy link: no clipboard tool found (install wl-copy, xclip or xsel)")}
+		}
+		cmd.Stdin = strings.NewReader(url)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return errMsg{fmt.Errorf("copy link: %w: %s", err, strings.TrimSpace(string(out)))}
+		}
+		return components.NotifyMsg{Level: components.ToastSuccess, Text: i18n.T("rw.toast.copied", url)}
+	}
+}
+
+// linkForKey maps the detail pane's link keys to their commands: o opens
+// the source, O the Readwise page, y and Y copy them
+func linkForKey(h *models.Highlight, key string) (tea.Cmd, bool) {
+	if h == nil {
+		return nil, false
+	}
+	switch key {
+	case "o":
+		return openLink(*h, false), true
+	case "O":
+		return openLink(*h, true), true
+	case "y":
+		return copyLink(*h, false), true
+	case "Y":
+		return copyLink(*h, true), true
+	}
+	return nil, false
+}
@@ -0,0 +1,225 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/api"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/models"
+)
+
+// mergeChoice is which version of a section the merge keeps
+type mergeChoice int
+
+const (
+	keepLocal mergeChoice = iota
+	keepRemote
+	keepBoth // local, then remote; notes only
+)
+
+func (c mergeChoice) String() string {
+	switch c {
+	case keepRemote:
+		return "remote"
+	case keepBoth:
+		return "both"
+	}
+	return "local"
+}
+
+// mergeSection is one field of a highlight edited in the TUI, in Readwise,
+// or both since editing began
+type mergeSection struct {
+	name                string
+	base, local, remote string
+	choice              mergeChoice
+}
+
+// conflicted reports whether both sides changed the section differently
+func (s mergeSection) conflicted() bool {
+	return s.local != s.base && s.remote != s.base && s.local != s.remote
+}
+
+// merged is the section's value with its choice applied
+func (s mergeSection) merged() string {
+	switch s.choice {
+	case keepRemote:
+		return s.remote
+	case keepBoth:
+		if s.local == "" {
+			return s.remote
+		}
+		return s.local + "\n" + s.remote
+	}
+	return s.local
+}
+
+// highlightConflictMsg reports a save stopped because the highlight changed
+// in Readwise since editing began; base is the version editing started from
+type highlightConflictMsg struct {
+	base, local, remote models.Highlight
+}
+
+// mergeView shows local, remote and merged versions of each changed
+// section, for the user to accept one side or both before saving
+type mergeView struct {
+	remote   models.Highlight // the version being merged with, the new base
+	sections []mergeSection
+	selected int
+}
+
+// newMergeView lists the sections either side changed. Where only one
+// side did, its version is kept; where both did, local is, until the user
+// picks otherwise.
+func newMergeView(msg highlightConflictMsg) *mergeView {
+	v := &mergeView{remote: msg.remote}
+	for _, field := range []struct {
+		name                string
+		base, local, remote string
+	}{
+		{"text", msg.base.Text, msg.local.Text, msg.remote.Text},
+		{"note", msg.base.Note, msg.local.Note, msg.remote.Note},
+		{"color", msg.base.Color, msg.local.Color, msg.remote.Color},
+	} {
+		if field.local == field.base && field.remote == field.base {
+			continue
+		}
+		section := mergeSection{name: field.name, base: field.base, local: field.local, remote: field.remote}
+		if field.local == field.base {
+			section.choice = keepRemote
+		}
+		v.sections = append(v.sections, section)
+	}
+	return v
+}
+
+// merged applies the chosen versions to the remote highlight
+func (v *mergeView) merged() models.Highlight {
+	h := v.remote
+	for _, s := range v.sections {
+		switch s.name {
+		case "text":
+			h.Text = s.merged()
+		case "note":
+			h.Note = s.merged()
+		case "color":
+			h.Color = s.merged()
+		}
+	}
+	return h
+}
+
+// update handles a key, reporting whether the merge was accepted or
+// abandoned
+func (v *mergeView) update(key string) (accept, cancel bool) {
+	if len(v.sections) == 0 {
+		return key == "enter", key == "esc"
+	}
+	s := &v.sections[v.selected]
+	switch key {
+	case "up", "k":
+		v.selected = max(0, v.selected-1)
+	case "down", "j":
+		v.selected = min(len(v.sections)-1, v.selected+1)
+	case "l":
+		s.choice = keepLocal
+	case "r":
+		s.choice = keepRemote
+	case "b":
+		if s.name == "note" {
+			s.choice = keepBoth
+		}
+	case "tab", " ":
+		s.choice = (s.choice + 1) % 3
+		if s.choice == keepBoth && s.name != "note" {
+			s.choice = keepLocal
+		}
+	case "enter":
+		return true, false
+	case "esc":
+		return false, true
+	}
+	return false, false
+}
+
+// view renders each section as local, remote and merged columns
+func (v *mergeView) view(width, height int) string {
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	column := (width - 6) / 3
+	cell := lipgloss.NewStyle().Width(column).MaxHeight(6).PaddingRight(1)
+	chosen := cell.Foreground(lipgloss.Color("170"))
+
+	var rows []string
+	rows = append(rows, lipgloss.NewStyle().Bold(true).Render(i18n.T("rw.merge.title")), "")
+	rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top,
+		cell.Bold(true).Render(i18n.T("rw.merge.local")), cell.Bold(true).Render(i18n.T("rw.merge.remote")), cell.Bold(true).Render(i18n.T("rw.merge.merged"))))
+	for i, s := range v.sections {
+		marker := "  "
+		if i == v.selected {
+			marker = "› "
+		}
+		status := s.choice.String()
+		if s.conflicted() {
+			status += i18n.T("rw.merge.conflict")
+		}
+		rows = append(rows, "", marker+fmt.Sprintf("%s:: (%s)", s.name, status))
+
+		local, remote := cell, cell
+		switch s.choice {
+		case keepLocal:
+			local = chosen
+		case keepRemote:
+			remote = chosen
+		case keepBoth:
+			local, remote = chosen, chosen
+		}
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top,
+			local.Render(s.local), remote.Render(s.remote), cell.Render(s.merged())))
+	}
+	if len(v.sections) == 0 {
+		rows = append(rows, "", dim.Render(i18n.T("rw.merge.unchanged")))
+	}
+	rows = append(rows, "", dim.Render(i18n.T("rw.help.merge")))
+
+	return lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1).
+		Width(width - 4).
+		Height(height - 2).
+		Render(strings.Join(rows, "\n"))
+}
+
+// saveHighlight re-fetches the highlight and patches it with local's text,
+// note and color when it hasn't changed since base, or reports a
+// highlightConflictMsg. Saves aren't canceled by navigating away.
+func saveHighlight(client *api.Client, base, local models.Highlight) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		remote, err := client.GetHighlight(ctx, local.ID)
+		if err != nil {
+			return errMsg{err}
+		}
+		if !remote.Updated.Equal(base.Updated) {
+			return highlightConflictMsg{base: base, local: local, remote: *remote}
+		}
+
+		update := models.HighlightUpdate{Text: local.Text, Note: local.Note}
+		if local.Color != base.Color {
+			update.Color = local.Color
+		}
+		updated, err := client.UpdateHighlight(ctx, local.ID, update)
+		if err != nil {
+			return errMsg{err}
+		}
+		if updated == nil {
+			updated = &local
+		}
+		return highlightSavedMsg{highlight: updated}
+	}
+}
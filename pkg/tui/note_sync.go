@@ -0,0 +1,39 @@
+package tui
+
+import "strings"
+
+// notePlaceholder is the child an empty note:: starts with; it's never
+// saved as the note
+const notePlaceholder = "*Add your thoughts here*"
+
+// noteOutline writes a Readwise note as outline lines under note::. Each
+// two-space indent in the note is a level deeper, so a note saved from the
+// outline loads back with the same structure.
+func noteOutline(note string) []string {
+	lines := strings.Split(note, "\n")
+	if len(lines) == 1 && !strings.HasPrefix(note, " ") {
+		return []string{"• note:: " + note}
+	}
+
+	outline := []string{"• note::"}
+	for _, line := range lines {
+		text := strings.TrimLeft(line, " ")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		depth := (len(line) - len(text)) / 2
+		outline = append(outline, strings.Repeat("  ", depth+1)+"• "+strings.TrimRight(text, " "))
+	}
+	return outline
+}
+
+// outlineNote is the note parsed from the outline, without the placeholder
+func outlineNote(note string) string {
+	var lines []string
+	for _, line := range strings.Split(note, "\n") {
+		if strings.TrimSpace(line) != notePlaceholder {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/cells"
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/termcolor"
+)
+
+// singlePaneBelow is the terminal width below which both layouts show one
+// pane at a time under a switcher row, rather than side by side or stacked
+const singlePaneBelow = 80
+
+// paneStyle is the box both layouts draw a pane in, focusedPaneStyle the
+// focused pane's
+var (
+	paneStyle = lipgloss.NewStyle().
+			BorderStyle(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("240")).
+			Padding(0, 1)
+	focusedPaneStyle = paneStyle.BorderForeground(lipgloss.Color("62"))
+)
+
+// accessible is the screen reader mode SetAccessible turned on
+var accessible bool
+
+// SetAccessible turns the screen reader mode on or off for both layouts:
+// panes lose their box drawing and the focused one says so in words
+func SetAccessible(on bool) {
+	accessible = on
+	border := lipgloss.RoundedBorder()
+	if on {
+		border = lipgloss.HiddenBorder()
+	}
+	paneStyle = paneStyle.BorderStyle(border)
+	focusedPaneStyle = focusedPaneStyle.BorderStyle(border)
+}
+
+// renderPane draws content in a pane taking width by height cells,
+// border included. Without color the focused pane's border is heavy; in
+// the screen reader mode its top border reads "focused" instead.
+func renderPane(content string, width, height int, focused bool) string {
+	style := paneStyle
+	if focused {
+		style = focusedPaneStyle
+		if termcolor.Current() == termcolor.Mono && !accessible {
+			style = style.BorderStyle(lipgloss.ThickBorder())
+		}
+	}
+	pane := cells.Frame(style, width).Height(max(0, height-2)).Render(content)
+	if focused && accessible {
+		_, rest, _ := strings.Cut(pane, "\n")
+		pane = cells.Fit(" "+i18n.T("pane.focused"), width) + "\n" + rest
+	}
+	return pane
+}
+
+// paneInner is the room for content in a pane width cells wide
+func paneInner(width int) int {
+	return cells.Inner(paneStyle, width)
+}
+
+// paneNames are the message keys of the panes the switcher names, in the
+// order tab and ←/→ move through them
+var paneNames = []string{"pane.books", "pane.highlights", "pane.detail"}
+
+// paneSwitcher renders the row over a single pane: the panes open so far,
+// the one shown highlighted, cut to width
+func paneSwitcher(shown, open, width int) string {
+	active := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("62"))
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	var tabs []string
+	for i, key := range paneNames[:max(1, min(open, len(paneNames)))] {
+		name := i18n.T(key)
+		if i == shown {
+			tabs = append(tabs, active.Render("["+name+"]"))
+		} else {
+			tabs = append(tabs, dim.Render(" "+name+" "))
+		}
+	}
+	return cells.Cut(" "+strings.Join(tabs, dim.Render("›"))+dim.Render("  ←/→"), width)
+}
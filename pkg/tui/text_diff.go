@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evanschultz/float-rw-client/pkg/i18n"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+)
+
+var (
+	diffRemovedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Strikethrough(true)
+	diffAddedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Bold(true)
+)
+
+// textDiff asks before an edit of a highlight's text is saved, showing the
+// words it removes and adds: the text is the highlight itself, and saving
+// replaces it in Readwise
+type textDiff struct {
+	ops        []outliner.DiffOp
+	sideBySide bool // original and edited in columns, else one inline diff
+}
+
+// newTextDiff diffs an edit of a highlight's text word by word
+func newTextDiff(original, edited string) *textDiff {
+	return &textDiff{ops: outliner.WordDiff(original, edited)}
+}
+
+// changes counts the words the edit removes and adds
+func (d *textDiff) changes() (removed, added int) {
+	for _, op := range d.ops {
+		switch op.Op {
+		case '-':
+			removed += len(strings.Fields(op.Text))
+		case '+':
+			added += len(strings.Fields(op.Text))
+		}
+	}
+	return removed, added
+}
+
+// side renders one side of the diff: the words kept and those only on it
+func (d *textDiff) side(op byte, style lipgloss.Style) string {
+	var parts []string
+	for _, o := range d.ops {
+		switch o.Op {
+		case ' ':
+			parts = append(parts, o.Text)
+		case op:
+			parts = append(parts, style.Render(o.Text))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// view renders the diff in width cells
+func (d *textDiff) view(width int) string {
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("170"))
+
+	removed, added := d.changes()
+	summary := i18n.N("rw.diff.summary", removed, added)
+	if removed == 0 && added == 0 {
+		summary = i18n.T("rw.diff.whitespace")
+	}
+	rows := []string{
+		title.Render(i18n.T("rw.diff.title")),
+		dim.Width(width).Render(i18n.T("rw.diff.replaces")),
+		dim.Render(summary),
+		"",
+	}
+
+	if d.sideBySide {
+		column := max(10, (width-3)/2)
+		box := lipgloss.NewStyle().Width(column)
+		left := lipgloss.JoinVertical(lipgloss.Left, dim.Render("Original"), box.Render(d.side('-', diffRemovedStyle)))
+		right := lipgloss.JoinVertical(lipgloss.Left, dim.Render("Edited"), box.Render(d.side('+', diffAddedStyle)))
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, left, dim.Render(" │ "), right))
+	} else {
+		parts := make([]string, len(d.ops))
+		for i, op := range d.ops {
+			switch op.Op {
+			case '-':
+				parts[i] = diffRemovedStyle.Render(op.Text)
+			case '+':
+				parts[i] = diffAddedStyle.Render(op.Text)
+			default:
+				parts[i] = op.Text
+			}
+		}
+		rows = append(rows, lipgloss.NewStyle().Width(width).Render(strings.Join(parts, " ")))
+	}
+	return strings.Join(rows, "\n")
+}
@@ -10,11 +10,12 @@ import (
 // Messages
 type booksLoadedMsg struct {
 	books []models.Book
+	load  *activity
 }
 
 type highlightsLoadedMsg struct {
-	highlights  []models.Highlight
-	nextPageURL string
+	highlights []models.Highlight
+	load       *activity
 }
 
 type highlightRenderedMsg struct {
@@ -22,7 +23,11 @@ type highlightRenderedMsg struct {
 	noteContent string
 }
 
-type highlightSavedMsg struct{}
+// highlightSavedMsg reports a finished save; highlight, when set, is the
+// saved version to apply locally
+type highlightSavedMsg struct {
+	highlight *models.Highlight
+}
 
 type errMsg struct {
 	err error
@@ -50,29 +55,29 @@ func (i highlightItem) Title() string {
 	// Remove excessive whitespace and newlines for display
 	text = strings.ReplaceAll(text, "\n", " ")
 	text = strings.Join(strings.Fields(text), " ")
-	
+
 	if len(text) > 200 {
-		return text[:197] + "..."
+		text = text[:197] + "..."
 	}
-	return text
+	return highlightBadges(i.highlight) + text
 }
 
 func (i highlightItem) Description() string {
 	parts := []string{}
-	
+
 	// Show note preview if present
 	if i.highlight.Note != "" {
 		note := i.highlight.Note
 		// Clean up note for display
 		note = strings.ReplaceAll(note, "\n", " ")
 		note = strings.Join(strings.Fields(note), " ")
-		
+
 		if len(note) > 150 {
 			note = note[:147] + "..."
 		}
 		parts = append(parts, "📝 "+note)
 	}
-	
+
 	// Add metadata
 	metadata := []string{}
 	if i.highlight.URL != "" {
@@ -81,10 +86,10 @@ func (i highlightItem) Description() string {
 	if i.highlight.HighlightedAt != nil {
 		metadata = append(metadata, i.highlight.HighlightedAt.Format("Jan 2, 2006"))
 	}
-	
+
 	if len(metadata) > 0 && len(parts) == 0 {
 		parts = append(parts, strings.Join(metadata, " • "))
 	}
-	
+
 	return strings.Join(parts, "\n")
 }
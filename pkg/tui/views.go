@@ -0,0 +1,228 @@
+package tui
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/evanschultz/float-rw-client/pkg/api"
+	"github.com/evanschultz/float-rw-client/pkg/cache"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/models"
+)
+
+// viewCacheTTL is how long after a sync a view is read from the cache
+// without asking Readwise for changes
+const viewCacheTTL = 5 * time.Minute
+
+// viewCategory marks the pseudo-books smart views are listed as
+const viewCategory = "view"
+
+// smartView is a saved [views.<name>] query over every book's highlights
+type smartView struct {
+	name string
+	cfg  config.ViewConfig
+}
+
+// smartViews lists the configured views by name
+func smartViews(views map[string]config.ViewConfig) []smartView {
+	list := make([]smartView, 0, len(views))
+	for name, cfg := range views {
+		list = append(list, smartView{name: name, cfg: cfg})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+	return list
+}
+
+// matches reports whether a highlight passes every filter of the view
+func (v smartView) matches(book models.ExportBook, h models.ExportHighlight) bool {
+	if h.IsDiscard || (v.cfg.Favorites && !h.IsFavorite) {
+		return false
+	}
+	if v.cfg.Category != "" && book.Category != v.cfg.Category {
+		return false
+	}
+	if v.cfg.BookTag != "" && !tagged(book.BookTags, v.cfg.BookTag) {
+		return false
+	}
+	if len(v.cfg.Tags) > 0 {
+		found := false
+		for _, tag := range v.cfg.Tags {
+			found = found || tagged(h.Tags, tag)
+		}
+		if !found {
+			return false
+		}
+	}
+	if text := strings.ToLower(v.cfg.Text); text != "" {
+		return strings.Contains(strings.ToLower(h.Text), text) || strings.Contains(strings.ToLower(h.Note), text)
+	}
+	return true
+}
+
+// tagged reports whether tags holds the named tag, ignoring case
+func tagged(tags []models.Tag, name string) bool {
+	for _, tag := range tags {
+		if strings.EqualFold(tag.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// summary describes the view's filters, e.g. "tagged consciousness · books"
+func (v smartView) summary() string {
+	var parts []string
+	if len(v.cfg.Tags) > 0 {
+		parts = append(parts, "tagged "+strings.Join(v.cfg.Tags, " or "))
+	}
+	if v.cfg.Category != "" {
+		parts = append(parts, v.cfg.Category)
+	}
+	if v.cfg.BookTag != "" {
+		parts = append(parts, "books tagged "+v.cfg.BookTag)
+	}
+	if v.cfg.Favorites {
+		parts = append(parts, "favorites")
+	}
+	if v.cfg.Text != "" {
+		parts = append(parts, fmt.Sprintf("%q", v.cfg.Text))
+	}
+	if len(parts) == 0 {
+		return "all highlights"
+	}
+	return strings.Join(parts, " · ")
+}
+
+// count is how many of the cache's highlights are in the view
+func (v smartView) count(c *cache.Highlights) int {
+	n := 0
+	c.Each(func(book models.ExportBook, h models.ExportHighlight) {
+		if v.matches(book, h) {
+			n++
+		}
+	})
+	return n
+}
+
+// highlights is the view's highlights in the cache, most recently
+// highlighted first
+func (v smartView) highlights(c *cache.Highlights) []models.Highlight {
+	var highlights []models.Highlight
+	c.Each(func(book models.ExportBook, h models.ExportHighlight) {
+		if v.matches(book, h) {
+			highlights = append(highlights, exportedHighlight(book, h))
+		}
+	})
+	sort.SliceStable(highlights, func(i, j int) bool {
+		a, b := highlights[i].HighlightedAt, highlights[j].HighlightedAt
+		if a == nil || b == nil {
+			return a != nil
+		}
+		if a.Equal(*b) {
+			return highlights[i].ID < highlights[j].ID
+		}
+		return a.After(*b)
+	})
+	return highlights
+}
+
+// exportedHighlight converts a highlight from /export/ to the shape the
+// highlight pane lists
+func exportedHighlight(book models.ExportBook, h models.ExportHighlight) models.Highlight {
+	highlight := models.Highlight{
+		ID:            h.ID,
+		Text:          h.Text,
+		Note:          h.Note,
+		Location:      h.Location,
+		LocationType:  h.LocationType,
+		HighlightedAt: h.HighlightedAt,
+		URL:           h.URL,
+		Color:         h.Color,
+		BookID:        h.BookID,
+		Tags:          h.Tags,
+		IsFavorite:    h.IsFavorite,
+		IsDiscard:     h.IsDiscard,
+		ReadwiseURL:   h.ReadwiseURL,
+	}
+	if highlight.BookID == 0 {
+		highlight.BookID = book.UserBookID
+	}
+	if h.UpdatedAt != nil {
+		highlight.Updated = *h.UpdatedAt
+	}
+	return highlight
+}
+
+// viewPageMsg reports a page of the library synced into the highlights
+// cache for a view when more pages follow
+type viewPageMsg struct {
+	load    *activity
+	view    smartView
+	started time.Time
+	page    int
+	cursor  int
+	perPage int // the most books a page has held
+	count   int // the books the sync covers
+}
+
+// loadView lists a view's highlights as a highlightsLoadedMsg, first
+// bringing the cache up to date from /export/ unless it synced within
+// viewCacheTTL
+func loadView(client *api.Client, load *activity, c *cache.Highlights, view smartView) tea.Cmd {
+	return func() tea.Msg {
+		if c.Fresh(time.Now(), viewCacheTTL) {
+			return highlightsLoadedMsg{highlights: view.highlights(c), load: load}
+		}
+		return syncViewPage(client, load, c, viewPageMsg{load: load, view: view, started: time.Now()})()
+	}
+}
+
+// syncViewPage merges the page of changes after the one msg reports into
+// the cache. It reports a viewPageMsg while more pages follow and the
+// view's highlights after the last, saving the cache; a canceled sync
+// reports nothing.
+func syncViewPage(client *api.Client, load *activity, c *cache.Highlights, msg viewPageMsg) tea.Cmd {
+	return func() tea.Msg {
+		params := url.Values{}
+		if since := c.UpdatedAfter(); since != "" {
+			params.Set("updatedAfter", since)
+		}
+		if msg.page > 0 {
+			params.Set("pageCursor", strconv.Itoa(msg.cursor))
+		}
+		list, err := client.Export(load.ctx, params)
+		if load.ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return loadFailedMsg{load: load, err: fmt.Errorf("sync highlights page %d: %w", msg.page+1, err)}
+		}
+		c.Merge(list.Results)
+
+		if list.NextPageCursor == nil {
+			c.Complete(msg.started, time.Now())
+			if err := c.Save(); err != nil {
+				slog.Warn("saving highlights cache failed", "err", err)
+			}
+			return highlightsLoadedMsg{highlights: msg.view.highlights(c), load: load}
+		}
+		msg.page++
+		msg.cursor = *list.NextPageCursor
+		msg.perPage = max(msg.perPage, len(list.Results))
+		msg.count = list.Count
+		return msg
+	}
+}
+
+// nextViewPage records the page msg carries and syncs the next
+func nextViewPage(client *api.Client, c *cache.Highlights, msg viewPageMsg) tea.Cmd {
+	msg.load.progress(msg.page, max(msg.page+1, (msg.count+msg.perPage-1)/max(1, msg.perPage)))
+	return syncViewPage(client, msg.load, c, msg)
+}
@@ -0,0 +1,444 @@
+package vault
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind is the vault convention in use
+type Kind string
+
+const (
+	KindObsidian Kind = "obsidian"
+	KindLogseq   Kind = "logseq"
+	KindPlain    Kind = "plain" // a directory of markdown files
+)
+
+var (
+	wikiLinkRegex = regexp.MustCompile(`\[\[([^\]]+)\]\]`)
+	// aliasRegex matches Logseq `alias:: a, [[b]]` and Obsidian
+	// `aliases: [a, b]`; each alias is trimmed of its brackets
+	aliasRegex = regexp.MustCompile(`(?i)^\s*(?:•\s*|-\s*)?(alias::|aliases:)\s*(.*?)\s*$`)
+	// aliasItemRegex matches an item of a YAML list under a bare `aliases:`
+	aliasItemRegex = regexp.MustCompile(`^\s*-\s+(.+?)\s*$`)
+)
+
+// Ref is one [[link]] occurrence in the vault
+type Ref struct {
+	Path string // relative to the vault root
+	Line int
+	Text string
+}
+
+// Vault indexes a notes directory so [[wikilinks]] resolve to files and
+// backlinks are known across every file, not just the open one
+type Vault struct {
+	Root string
+	Kind Kind
+
+	journalDir    string
+	journalFormat string // Go time layout for journal file names
+
+	mu    sync.RWMutex
+	pages map[string]string // lowercase page name or alias -> relative path
+	links map[string][]Ref  // lowercase target page -> references to it
+	files map[string][]string
+}
+
+// Detect finds the vault containing path by walking up to a directory with
+// .obsidian or logseq/; ok is false when path isn't inside one
+func Detect(path string) (root string, kind Kind, ok bool) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", false
+	}
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	for {
+		if isDir(filepath.Join(dir, ".obsidian")) {
+			return dir, KindObsidian, true
+		}
+		if isDir(filepath.Join(dir, "logseq")) {
+			return dir, KindLogseq, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", false
+		}
+		dir = parent
+	}
+}
+
+// New returns an empty vault for root with its journal naming configured;
+// call Reindex before resolving links
+func New(root string, kind Kind) (*Vault, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Vault{Root: abs, Kind: kind}
+	v.configureJournals()
+	return v, nil
+}
+
+// Open indexes the vault at root
+func Open(root string, kind Kind) (*Vault, error) {
+	v, err := New(root, kind)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.Reindex(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// configureJournals applies each tool's daily note naming
+func (v *Vault) configureJournals() {
+	switch v.Kind {
+	case KindLogseq:
+		v.journalDir = "journals"
+		v.journalFormat = "2006_01_02"
+	case KindObsidian:
+		v.journalDir = ""
+		v.journalFormat = "2006-01-02"
+
+		// .obsidian/daily-notes.json: {"folder": "journal", "format": "YYYY-MM-DD"}
+		var settings struct {
+			Folder string `json:"folder"`
+			Format string `json:"format"`
+		}
+		data, err := os.ReadFile(filepath.Join(v.Root, ".obsidian", "daily-notes.json"))
+		if err == nil && json.Unmarshal(data, &settings) == nil {
+			v.journalDir = settings.Folder
+			if settings.Format != "" {
+				v.journalFormat = momentToLayout(settings.Format)
+			}
+		}
+	default:
+		v.journalDir = "journals"
+		v.journalFormat = "2006-01-02"
+	}
+}
+
+// Reindex rebuilds the page and link index from disk
+func (v *Vault) Reindex() error {
+	pages := make(map[string]string)
+	links := make(map[string][]Ref)
+	files := make(map[string][]string)
+
+	err := filepath.WalkDir(v.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != v.Root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(path), ".md") {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(v.Root, path)
+		names, refs, err := v.scanFile(path, rel)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if _, taken := pages[name]; !taken {
+				pages[name] = rel
+			}
+		}
+		for target, r := range refs {
+			links[target] = append(links[target], r...)
+		}
+		files[rel] = names
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("index vault: %w", err)
+	}
+
+	v.mu.Lock()
+	v.pages, v.links, v.files = pages, links, files
+	v.mu.Unlock()
+	return nil
+}
+
+// Update re-indexes a single file after it is saved
+func (v *Vault) Update(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(v.Root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil // outside the vault
+	}
+
+	names, refs, err := v.scanFile(abs, rel)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, name := range v.files[rel] {
+		if v.pages[name] == rel {
+			delete(v.pages, name)
+		}
+	}
+	for target, existing := range v.links {
+		kept := existing[:0]
+		for _, r := range existing {
+			if r.Path != rel {
+				kept = append(kept, r)
+			}
+		}
+		v.links[target] = kept
+	}
+
+	for _, name := range names {
+		if _, taken := v.pages[name]; !taken {
+			v.pages[name] = rel
+		}
+	}
+	for target, r := range refs {
+		v.links[target] = append(v.links[target], r...)
+	}
+	v.files[rel] = names
+	return nil
+}
+
+// scanFile returns the names a file answers to and the links it contains
+func (v *Vault) scanFile(path, rel string) ([]string, map[string][]Ref, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read %s: %w", rel, err)
+	}
+	defer f.Close()
+
+	names := v.pageNames(rel)
+	refs := make(map[string][]Ref)
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	addAliases := func(list string) {
+		for _, alias := range strings.Split(list, ",") {
+			alias = strings.Trim(strings.TrimSpace(alias), `"'[]`)
+			if alias != "" {
+				names = append(names, strings.ToLower(alias))
+			}
+		}
+	}
+
+	line := 0
+	aliasList := false // in the "- a" lines under a bare aliases:
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+
+		if m := aliasItemRegex.FindStringSubmatch(text); aliasList && m != nil {
+			addAliases(m[1])
+		} else if m := aliasRegex.FindStringSubmatch(text); m != nil {
+			addAliases(m[2])
+			aliasList = strings.EqualFold(m[1], "aliases:") && strings.TrimSpace(m[2]) == ""
+		} else {
+			aliasList = false
+		}
+
+		for _, m := range wikiLinkRegex.FindAllStringSubmatch(text, -1) {
+			target := strings.ToLower(linkTarget(m[1]))
+			refs[target] = append(refs[target], Ref{Path: rel, Line: line, Text: strings.TrimSpace(text)})
+		}
+	}
+	return names, refs, scanner.Err()
+}
+
+// pageNames derives the link names for a file from its path
+func (v *Vault) pageNames(rel string) []string {
+	base := strings.TrimSuffix(filepath.Base(rel), filepath.Ext(rel))
+	withoutExt := strings.TrimSuffix(filepath.ToSlash(rel), filepath.Ext(rel))
+	names := []string{strings.ToLower(base), strings.ToLower(withoutExt)}
+
+	if v.Kind == KindLogseq {
+		// Logseq namespaces: pages/a___b.md (or a%2Fb.md) is [[a/b]]
+		ns := strings.ReplaceAll(strings.ReplaceAll(base, "___", "/"), "%2F", "/")
+		names = append(names, strings.ToLower(ns))
+
+		if filepath.ToSlash(filepath.Dir(rel)) == v.journalDir {
+			if t, err := time.Parse(v.journalFormat, base); err == nil {
+				names = append(names, strings.ToLower(logseqJournalTitle(t)))
+			}
+		}
+	}
+	return names
+}
+
+// Resolve maps a link target to a file path relative to the root
+func (v *Vault) Resolve(link string) (string, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	target := strings.ToLower(linkTarget(link))
+	if rel, ok := v.pages[target]; ok {
+		return rel, true
+	}
+	return "", false
+}
+
+// PathFor returns where a link's file lives, or where a new page for it
+// should be created; journal dates go to the journal directory
+func (v *Vault) PathFor(link string) string {
+	if rel, ok := v.Resolve(link); ok {
+		return filepath.Join(v.Root, rel)
+	}
+
+	target := linkTarget(link)
+	for _, layout := range []string{"2006-01-02", v.journalFormat} {
+		if t, err := time.Parse(layout, target); err == nil {
+			return v.JournalPath(t)
+		}
+	}
+	if t, ok := parseLogseqJournalTitle(target); ok {
+		return v.JournalPath(t)
+	}
+
+	name := target
+	if v.Kind == KindLogseq {
+		name = strings.ReplaceAll(name, "/", "___")
+		return filepath.Join(v.Root, "pages", name+".md")
+	}
+	return filepath.Join(v.Root, filepath.FromSlash(name)+".md")
+}
+
+// JournalPath returns the daily note file for a date
+func (v *Vault) JournalPath(t time.Time) string {
+	return filepath.Join(v.Root, filepath.FromSlash(v.journalDir), t.Format(v.journalFormat)+".md")
+}
+
+// Backlinks returns every reference to a page across the vault, under any
+// of its names or aliases
+func (v *Vault) Backlinks(page string) []Ref {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	refs := v.refsLocked(page)
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Path != refs[j].Path {
+			return refs[i].Path < refs[j].Path
+		}
+		return refs[i].Line < refs[j].Line
+	})
+	return refs
+}
+
+// Mentions returns how many times a page is linked across the vault
+func (v *Vault) Mentions(page string) int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return len(v.refsLocked(page))
+}
+
+func (v *Vault) refsLocked(page string) []Ref {
+	target := strings.ToLower(linkTarget(page))
+	rel, ok := v.pages[target]
+	if !ok {
+		return append([]Ref(nil), v.links[target]...)
+	}
+
+	var refs []Ref
+	seen := make(map[string]bool)
+	for _, name := range v.files[rel] {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		refs = append(refs, v.links[name]...)
+	}
+	return refs
+}
+
+// Pages returns the number of indexed files
+func (v *Vault) Pages() int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return len(v.files)
+}
+
+// linkTarget strips Obsidian display text and heading/block suffixes:
+// [[page#heading|label]] targets "page"
+func linkTarget(link string) string {
+	if i := strings.Index(link, "|"); i >= 0 {
+		link = link[:i]
+	}
+	if i := strings.Index(link, "#"); i >= 0 {
+		link = link[:i]
+	}
+	return strings.TrimSpace(link)
+}
+
+// logseqJournalTitle renders Logseq's default journal title, "Aug 5th, 2025"
+func logseqJournalTitle(t time.Time) string {
+	return fmt.Sprintf("%s %d%s, %d", t.Format("Jan"), t.Day(), ordinal(t.Day()), t.Year())
+}
+
+var logseqTitleRegex = regexp.MustCompile(`^([A-Z][a-z]{2}) (\d{1,2})(?:st|nd|rd|th), (\d{4})$`)
+
+func parseLogseqJournalTitle(title string) (time.Time, bool) {
+	m := logseqTitleRegex.FindStringSubmatch(title)
+	if m == nil {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("Jan 2 2006", m[1]+" "+m[2]+" "+m[3])
+	return t, err == nil
+}
+
+func ordinal(day int) string {
+	switch {
+	case day >= 11 && day <= 13:
+		return "th"
+	case day%10 == 1:
+		return "st"
+	case day%10 == 2:
+		return "nd"
+	case day%10 == 3:
+		return "rd"
+	}
+	return "th"
+}
+
+// momentToLayout converts the moment.js tokens Obsidian uses for daily
+// notes into a Go time layout
+func momentToLayout(format string) string {
+	return strings.NewReplacer(
+		"YYYY", "2006",
+		"YY", "06",
+		"MMMM", "January",
+		"MMM", "Jan",
+		"MM", "01",
+		"DD", "02",
+		"dddd", "Monday",
+		"ddd", "Mon",
+	).Replace(format)
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
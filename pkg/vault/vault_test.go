@@ -0,0 +1,187 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeVault creates files, by slash-separated path, under a temp dir
+func writeVault(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestDetect(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		files map[string]string
+		from  string // where to start, relative to the temp dir
+		root  string // the root expected, relative to it
+		kind  Kind
+		ok    bool
+	}{
+		{"obsidian", map[string]string{".obsidian/app.json": "{}", "notes/a.md": ""}, "notes/a.md", "", KindObsidian, true},
+		{"logseq", map[string]string{"logseq/config.edn": "{}", "pages/a.md": ""}, "pages", "", KindLogseq, true},
+		{"nested", map[string]string{"outer/.obsidian/app.json": "{}", "outer/inner/deep/b.md": ""}, "outer/inner/deep", "outer", KindObsidian, true},
+		{"plain", map[string]string{"notes/a.md": ""}, "notes/a.md", "", "", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeVault(t, tc.files)
+			root, kind, ok := Detect(filepath.Join(dir, filepath.FromSlash(tc.from)))
+			if ok != tc.ok || kind != tc.kind {
+				t.Fatalf("Detect = %q, %q, %v; want kind %q, %v", root, kind, ok, tc.kind, tc.ok)
+			}
+			if ok && root != filepath.Join(dir, filepath.FromSlash(tc.root)) {
+				t.Errorf("root = %s, want %s", root, filepath.Join(dir, tc.root))
+			}
+		})
+	}
+}
+
+func TestPathFor(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		kind  Kind
+		files map[string]string
+		link  string
+		want  string // relative to the root
+	}{
+		{"logseq namespace", KindLogseq, nil, "project/float", "pages/project___float.md"},
+		{"logseq existing namespace", KindLogseq, map[string]string{"pages/a___b.md": ""}, "A/B", "pages/a___b.md"},
+		{"logseq percent namespace", KindLogseq, map[string]string{"pages/a%2Fb.md": ""}, "a/b", "pages/a%2Fb.md"},
+		{"logseq journal date", KindLogseq, nil, "2026-10-14", "journals/2026_10_14.md"},
+		{"logseq journal title", KindLogseq, nil, "Oct 14th, 2026", "journals/2026_10_14.md"},
+		{"logseq existing journal", KindLogseq, map[string]string{"journals/2026_10_14.md": ""}, "Oct 14th, 2026", "journals/2026_10_14.md"},
+		{"obsidian page", KindObsidian, nil, "Some Page#heading|label", "Some Page.md"},
+		{"obsidian default journal", KindObsidian, nil, "2026-10-14", "2026-10-14.md"},
+		{"obsidian daily notes", KindObsidian, map[string]string{
+			".obsidian/daily-notes.json": `{"folder": "daily", "format": "DD-MM-YYYY"}`,
+		}, "2026-10-14", "daily/14-10-2026.md"},
+		{"obsidian daily notes names", KindObsidian, map[string]string{
+			".obsidian/daily-notes.json": `{"folder": "journal", "format": "YYYY/MMMM/ddd DD"}`,
+		}, "2026-10-14", "journal/2026/October/Wed 14.md"},
+		{"obsidian daily notes short", KindObsidian, map[string]string{
+			".obsidian/daily-notes.json": `{"format": "YY MMM DD dddd"}`,
+		}, "2026-10-14", "26 Oct 14 Wednesday.md"},
+		{"plain", KindPlain, nil, "2026-10-14", "journals/2026-10-14.md"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := Open(writeVault(t, tc.files), tc.kind)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := v.PathFor(tc.link), filepath.Join(v.Root, filepath.FromSlash(tc.want)); got != want {
+				t.Errorf("PathFor(%q) = %s, want %s", tc.link, got, want)
+			}
+		})
+	}
+}
+
+func TestAliases(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		kind    Kind
+		content string
+		aliases []string
+	}{
+		{"logseq", KindLogseq, "alias:: FL, float line\n- body", []string{"fl", "float line"}},
+		{"logseq bullet", KindLogseq, "- alias:: [[FL]], [[float line]]\n- body", []string{"fl", "float line"}},
+		{"obsidian inline", KindObsidian, "---\naliases: [FL, \"float line\"]\n---\nbody", []string{"fl", "float line"}},
+		{"obsidian list", KindObsidian, "---\ntitle: x\naliases:\n  - FL\n  - \"float line\"\n- 'the line'\ntags: [a]\n---\n- not an alias", []string{"fl", "float line", "the line"}},
+		{"obsidian empty list", KindObsidian, "---\naliases:\ntags:\n  - a\n---\n", nil},
+		{"logseq empty", KindLogseq, "alias::\n- not an alias", nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := Open(writeVault(t, map[string]string{"Page.md": tc.content}), tc.kind)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := v.files["Page.md"], append(v.pageNames("Page.md"), tc.aliases...); !slices.Equal(got, want) {
+				t.Errorf("names = %q, want %q", got, want)
+			}
+			for _, alias := range tc.aliases {
+				if rel, ok := v.Resolve(alias); !ok || rel != "Page.md" {
+					t.Errorf("Resolve(%q) = %q, %v", alias, rel, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdatePrunesStaleRefs(t *testing.T) {
+	root := writeVault(t, map[string]string{
+		"a.md":      "aliases: [first]\nsee [[b]] and [[c]]\n",
+		"b.md":      "back to [[a]]\n",
+		"c.md":      "",
+		"other.md":  "also [[b]]\n",
+		"second.md": "",
+	})
+	v, err := Open(root, KindObsidian)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := v.Mentions("b"); n != 2 {
+		t.Fatalf("b has %d mentions, want 2", n)
+	}
+
+	// a.md drops [[b]], keeps [[c]] on another line and renames its alias
+	// to the name of a page that already exists
+	if err := os.WriteFile(filepath.Join(root, "a.md"), []byte("aliases: [renamed, second]\n\nonly [[c]]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Update(filepath.Join(root, "a.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	if refs := v.Backlinks("b"); len(refs) != 1 || refs[0].Path != "other.md" {
+		t.Errorf("b backlinks = %+v, want only other.md", refs)
+	}
+	if refs := v.Backlinks("c"); len(refs) != 1 || refs[0].Line != 3 {
+		t.Errorf("c backlinks = %+v, want a.md line 3", refs)
+	}
+	if _, ok := v.Resolve("first"); ok {
+		t.Error("the dropped alias still resolves")
+	}
+	if rel, _ := v.Resolve("renamed"); rel != "a.md" {
+		t.Errorf("renamed resolves to %q", rel)
+	}
+	if rel, _ := v.Resolve("second"); rel != "second.md" {
+		t.Errorf("an alias took over the page second: %q", rel)
+	}
+	if refs := v.Backlinks("renamed"); len(refs) != 1 || refs[0].Path != "b.md" {
+		t.Errorf("backlinks under the new alias = %+v", refs)
+	}
+
+	// Files outside the vault are ignored, missing ones reported
+	if err := v.Update(filepath.Join(t.TempDir(), "x.md")); err != nil {
+		t.Errorf("update outside the vault: %v", err)
+	}
+	if err := v.Update(filepath.Join(root, "gone.md")); err == nil {
+		t.Error("updating a missing file succeeded")
+	}
+}
+
+func TestLogseqJournalTitle(t *testing.T) {
+	for day, want := range map[int]string{1: "Oct 1st, 2026", 2: "Oct 2nd, 2026", 3: "Oct 3rd, 2026", 11: "Oct 11th, 2026", 12: "Oct 12th, 2026", 22: "Oct 22nd, 2026", 31: "Oct 31st, 2026"} {
+		date := time.Date(2026, 10, day, 0, 0, 0, 0, time.UTC)
+		if got := logseqJournalTitle(date); got != want {
+			t.Errorf("title for day %d = %q, want %q", day, got, want)
+		}
+		if parsed, ok := parseLogseqJournalTitle(want); !ok || !parsed.Equal(date) {
+			t.Errorf("parse %q = %v, %v", want, parsed, ok)
+		}
+	}
+}
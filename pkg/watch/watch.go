@@ -0,0 +1,319 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/evanschultz/float-rw-client/pkg/calendar"
+	"github.com/evanschultz/float-rw-client/pkg/config"
+	"github.com/evanschultz/float-rw-client/pkg/dispatchlog"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+)
+
+const (
+	// stateDir holds the dispatch log inside the watched directory
+	stateDir = ".float-line"
+	logFile  = "dispatch-log.jsonl"
+
+	// debounce collapses the burst of events editors emit per save
+	debounce = 250 * time.Millisecond
+)
+
+// logPath is dir's dispatch log for the selected profile:
+// .float-line/dispatch-log.jsonl, or .float-line/profiles/<name>/...
+func logPath(dir string) string {
+	return filepath.Join(dir, stateDir, config.ProfileDir(), logFile)
+}
+
+// LogRoot is the watched directory a dispatch log belongs to, which the
+// sources it records are relative to
+func LogRoot(path string) string {
+	dir := filepath.Dir(path)
+	for filepath.Base(dir) != stateDir && filepath.Dir(dir) != dir {
+		dir = filepath.Dir(dir)
+	}
+	return filepath.Dir(dir)
+}
+
+// FindLog returns the dispatch log of the nearest watched directory
+// containing path
+func FindLog(path string) (string, bool) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	for {
+		candidate := logPath(dir)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// Options configures a watcher
+type Options struct {
+	Dir     string
+	LogPath string // defaults to the profile's log under <dir>/.float-line
+
+	// Calendar annotates ctx:: captures with the meeting they were
+	// written in; nil leaves them as they are
+	Calendar *calendar.Feed
+
+	// OnDispatch is called for every newly dispatched pattern
+	OnDispatch func(dispatchlog.Entry)
+	// OnError reports non-fatal problems (unreadable files, evna failures)
+	OnError func(error)
+}
+
+// Watcher turns a notes directory into a background capture daemon: new
+// :: patterns in changed files are dispatched and appended to one log
+type Watcher struct {
+	opts     Options
+	parser   *outliner.Parser
+	dispatch *outliner.FloatDispatchSystem
+	evna     *outliner.EvnaDispatcher
+	log      *dispatchlog.Log
+
+	// seen holds pattern keys already dispatched, per file
+	seen map[string]map[string]bool
+}
+
+// New creates a watcher, seeding what was already dispatched from the log
+// so restarts don't dispatch the same patterns twice
+func New(opts Options, dispatch *outliner.FloatDispatchSystem, evna *outliner.EvnaDispatcher) (*Watcher, error) {
+	if opts.LogPath == "" {
+		opts.LogPath = logPath(opts.Dir)
+	}
+	if opts.OnDispatch == nil {
+		opts.OnDispatch = func(dispatchlog.Entry) {}
+	}
+	if opts.OnError == nil {
+		opts.OnError = func(error) {}
+	}
+
+	log, err := dispatchlog.Open(opts.LogPath)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		opts:     opts,
+		parser:   outliner.NewParser(),
+		dispatch: dispatch,
+		evna:     evna,
+		log:      log,
+		seen:     make(map[string]map[string]bool),
+	}
+
+	entries, err := log.ReadSince(0)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		w.markSeen(e.Source, patternKey(e.Type, e.Content))
+	}
+
+	evna.SetErrorLogger(func(msgType, content string) {
+		w.opts.OnError(fmt.Errorf("%s: %s", msgType, content))
+	})
+
+	return w, nil
+}
+
+// Log returns the dispatch log the watcher appends to
+func (w *Watcher) Log() *dispatchlog.Log {
+	return w.log
+}
+
+// Scan processes every markdown file once, dispatching anything new since
+// the last run
+func (w *Watcher) Scan() error {
+	return filepath.WalkDir(w.opts.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != w.opts.Dir && isHidden(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isNote(path) {
+			w.processFile(path)
+		}
+		return nil
+	})
+}
+
+// Run watches the directory tree until stop is closed
+func (w *Watcher) Run(stop <-chan struct{}) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("start watcher: %w", err)
+	}
+	defer fsw.Close()
+
+	if err := w.addTree(fsw, w.opts.Dir, nil); err != nil {
+		return err
+	}
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() && !isHidden(info.Name()) {
+					if err := w.addTree(fsw, event.Name, pending); err != nil {
+						w.opts.OnError(err)
+					}
+					timer.Reset(debounce)
+					continue
+				}
+			}
+			if isNote(event.Name) && (event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Rename)) {
+				pending[event.Name] = true
+				timer.Reset(debounce)
+			}
+
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			w.opts.OnError(err)
+
+		case <-timer.C:
+			for path := range pending {
+				w.processFile(path)
+			}
+			pending = make(map[string]bool)
+		}
+	}
+}
+
+// processFile parses a file and dispatches patterns not seen before
+func (w *Watcher) processFile(path string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		// Renamed away between the event and the read
+		if !os.IsNotExist(err) {
+			w.opts.OnError(fmt.Errorf("read %s: %w", path, err))
+		}
+		return
+	}
+
+	if w.opts.Calendar.Due(time.Now()) {
+		if err := w.opts.Calendar.Refresh(context.Background()); err != nil {
+			w.opts.OnError(err)
+		}
+	}
+
+	source := w.relative(path)
+	for _, pattern := range w.parser.Parse(string(content)).ConsciousnessData {
+		key := patternKey(pattern.Type, pattern.Content)
+		if w.seen[source][key] {
+			continue
+		}
+
+		pattern = outliner.AnnotateMeeting(w.opts.Calendar.Calendar(), pattern, time.Now())
+		action := w.dispatch.DispatchWith(fmt.Sprintf("%s:%d", source, pattern.Line), pattern.Content, pattern.Type, outliner.MeetingMetadata(pattern), time.Now())
+		if err := w.evna.DispatchPatterns([]outliner.ConsciousnessPattern{pattern}, "float-watch:"+source); err != nil {
+			w.opts.OnError(err)
+		}
+
+		entry, err := w.log.Append(dispatchlog.Entry{
+			Time:     action.Timestamp,
+			Source:   source,
+			Line:     pattern.Line,
+			Type:     pattern.Type,
+			Content:  pattern.Content,
+			Context:  pattern.Context,
+			ActionID: action.ID,
+			Imprint:  action.Imprint,
+			Sigil:    action.Sigil,
+		})
+		if err != nil {
+			w.opts.OnError(err)
+			continue
+		}
+
+		w.markSeen(source, key)
+		w.opts.OnDispatch(entry)
+	}
+}
+
+// addTree watches dir and every non-hidden directory below it. Notes
+// already in a new directory, written before its watch was added, are
+// queued in pending when it isn't nil.
+func (w *Watcher) addTree(fsw *fsnotify.Watcher, dir string, pending map[string]bool) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			if pending != nil && isNote(path) {
+				pending[path] = true
+			}
+			return nil
+		}
+		if path != dir && isHidden(d.Name()) {
+			return filepath.SkipDir
+		}
+		if err := fsw.Add(path); err != nil {
+			return fmt.Errorf("watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+func (w *Watcher) markSeen(source, key string) {
+	if w.seen[source] == nil {
+		w.seen[source] = make(map[string]bool)
+	}
+	w.seen[source][key] = true
+}
+
+// relative names files relative to the watched directory in the log
+func (w *Watcher) relative(path string) string {
+	if rel, err := filepath.Rel(w.opts.Dir, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+func patternKey(patternType, content string) string {
+	return patternType + "\x00" + content
+}
+
+func isNote(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".md") && !isHidden(filepath.Base(path))
+}
+
+// isHidden skips dot directories (.git, .float-line) and editor temp files
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
@@ -0,0 +1,172 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/evanschultz/float-rw-client/pkg/dispatchlog"
+	"github.com/evanschultz/float-rw-client/pkg/outliner"
+)
+
+// newWatcher creates a watcher on dir with evna off, sending what it
+// dispatches to the channel returned
+func newWatcher(t *testing.T, dir string) (*Watcher, <-chan dispatchlog.Entry) {
+	t.Helper()
+	evna := outliner.NewEvnaDispatcher()
+	evna.SetEnabled(false)
+	dispatched := make(chan dispatchlog.Entry, 100)
+	w, err := New(Options{
+		Dir:        dir,
+		OnDispatch: func(e dispatchlog.Entry) { dispatched <- e },
+		OnError:    func(err error) { t.Log(err) },
+	}, outliner.NewFloatDispatchSystem(), evna)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w, dispatched
+}
+
+// run watches until the test ends, returning once the watch is live
+func run(t *testing.T, w *Watcher, dispatched <-chan dispatchlog.Entry) {
+	t.Helper()
+	stop, done := make(chan struct{}), make(chan error, 1)
+	go func() { done <- w.Run(stop) }()
+	t.Cleanup(func() {
+		close(stop)
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	})
+
+	// The watch starts in the goroutine, so write a probe until it's seen
+	probe := filepath.Join(w.opts.Dir, "probe.md")
+	deadline := time.After(5 * time.Second)
+	for {
+		writeNote(t, probe, "• ctx:: ready")
+		select {
+		case <-dispatched:
+			return
+		case <-time.After(debounce + 100*time.Millisecond):
+		case <-deadline:
+			t.Fatal("the watcher never saw the probe")
+		}
+	}
+}
+
+func writeNote(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// next waits for the next dispatch
+func next(t *testing.T, dispatched <-chan dispatchlog.Entry) dispatchlog.Entry {
+	t.Helper()
+	select {
+	case e := <-dispatched:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing was dispatched")
+	}
+	return dispatchlog.Entry{}
+}
+
+// quiet fails if anything more is dispatched within a debounce or two
+func quiet(t *testing.T, dispatched <-chan dispatchlog.Entry) {
+	t.Helper()
+	select {
+	case e := <-dispatched:
+		t.Errorf("unexpected dispatch %s:: %s from %s", e.Type, e.Content, e.Source)
+	case <-time.After(2 * debounce):
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	dir := t.TempDir()
+	w, dispatched := newWatcher(t, dir)
+	run(t, w, dispatched)
+
+	// A burst of writes is read once, after it ends
+	path := filepath.Join(dir, "notes.md")
+	writeNote(t, path, "• ctx:: first draft")
+	writeNote(t, path, "• ctx:: second draft")
+	writeNote(t, path, "• ctx:: final")
+	if e := next(t, dispatched); e.Content != "final" || e.Source != "notes.md" {
+		t.Errorf("dispatched %s:: %s from %s, want only the final text", e.Type, e.Content, e.Source)
+	}
+	quiet(t, dispatched)
+
+	// Rewriting a file only dispatches what's new in it
+	writeNote(t, path, "• ctx:: final\n• eureka:: added")
+	if e := next(t, dispatched); e.Type != "eureka" || e.Line != 2 {
+		t.Errorf("dispatched %s:: %s at line %d, want the new eureka", e.Type, e.Content, e.Line)
+	}
+	quiet(t, dispatched)
+}
+
+func TestNewDirectory(t *testing.T) {
+	dir := t.TempDir()
+	w, dispatched := newWatcher(t, dir)
+	run(t, w, dispatched)
+
+	// Notes written straight after their directories are created, before
+	// the watcher has had a chance to watch them, still count
+	deep := filepath.Join(dir, "new", "deep")
+	if err := os.MkdirAll(deep, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeNote(t, filepath.Join(deep, "a.md"), "• ctx:: in a new directory")
+	if e := next(t, dispatched); e.Source != filepath.Join("new", "deep", "a.md") {
+		t.Errorf("dispatched from %s", e.Source)
+	}
+
+	// and so do later ones
+	writeNote(t, filepath.Join(deep, "b.md"), "• ctx:: later")
+	if e := next(t, dispatched); e.Source != filepath.Join("new", "deep", "b.md") {
+		t.Errorf("dispatched from %s", e.Source)
+	}
+
+	// Hidden directories and non-notes are left alone
+	hidden := filepath.Join(dir, ".hidden")
+	if err := os.Mkdir(hidden, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeNote(t, filepath.Join(hidden, "c.md"), "• ctx:: hidden")
+	writeNote(t, filepath.Join(deep, "d.txt"), "• ctx:: not a note")
+	quiet(t, dispatched)
+}
+
+func TestScanResumesFromLog(t *testing.T) {
+	dir := t.TempDir()
+	writeNote(t, filepath.Join(dir, "a.md"), "• ctx:: one\n• eureka:: two")
+
+	w, dispatched := newWatcher(t, dir)
+	if err := w.Scan(); err != nil {
+		t.Fatal(err)
+	}
+	if len(dispatched) != 2 {
+		t.Fatalf("first scan dispatched %d patterns, want 2", len(dispatched))
+	}
+
+	// A restarted watcher knows what the log already holds
+	writeNote(t, filepath.Join(dir, "a.md"), "• ctx:: one\n• eureka:: two\n• ctx:: three")
+	w, dispatched = newWatcher(t, dir)
+	if err := w.Scan(); err != nil {
+		t.Fatal(err)
+	}
+	if len(dispatched) != 1 {
+		t.Fatalf("second scan dispatched %d patterns, want only the new one", len(dispatched))
+	}
+	if e := <-dispatched; e.Content != "three" || e.Seq != 3 {
+		t.Errorf("second scan dispatched %+v", e)
+	}
+	if root := LogRoot(w.Log().Path()); root != dir {
+		t.Errorf("LogRoot = %s, want %s", root, dir)
+	}
+	if path, ok := FindLog(filepath.Join(dir, "a.md")); !ok || path != w.Log().Path() {
+		t.Errorf("FindLog = %s, %v", path, ok)
+	}
+}